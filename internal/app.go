@@ -12,6 +12,7 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/database/mongodb"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/golang/protobuf/ptypes"
+	"github.com/jinzhu/now"
 	"github.com/micro/cli"
 	"github.com/micro/go-micro"
 	goConfig "github.com/micro/go-micro/config"
@@ -21,6 +22,7 @@ import (
 	metrics "github.com/micro/go-plugins/wrapper/monitoring/prometheus"
 	"github.com/paysuper/paysuper-billing-server/internal/config"
 	"github.com/paysuper/paysuper-billing-server/internal/database"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-billing-server/internal/service"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	paysuperI18n "github.com/paysuper/paysuper-i18n"
@@ -160,6 +162,41 @@ func (app *Application) Init() {
 				Value: "",
 				Usage: "force rebuild accounting entries for order",
 			},
+			cli.StringFlag{
+				Name:  "format",
+				Value: "",
+				Usage: "format of exported file, i.e. csv, saf-t, datev",
+			},
+			cli.StringFlag{
+				Name:  "merchantid",
+				Value: "",
+				Usage: "selected merchant id",
+			},
+			cli.StringFlag{
+				Name:  "operatingcompanyid",
+				Value: "",
+				Usage: "selected operating company id",
+			},
+			cli.StringFlag{
+				Name:  "datefrom",
+				Value: "",
+				Usage: "task context start date, i.e. 2006-01-02",
+			},
+			cli.StringFlag{
+				Name:  "dateto",
+				Value: "",
+				Usage: "task context end date, i.e. 2006-01-02",
+			},
+			cli.StringFlag{
+				Name:  "dryrun",
+				Value: "",
+				Usage: "compare rebuilt accounting entries with stored ones without writing",
+			},
+			cli.StringFlag{
+				Name:  "apply",
+				Value: "",
+				Usage: "write differences of rebuilt accounting entries with stored ones",
+			},
 		),
 	}
 
@@ -251,6 +288,12 @@ func (app *Application) Init() {
 		app.logger.Fatal("Service init failed", zap.Error(err))
 	}
 
+	err = intPkg.RegisterBillingServiceExtHandler(app.service.Server(), app.svc)
+
+	if err != nil {
+		app.logger.Fatal("Service init failed", zap.Error(err))
+	}
+
 	app.router = http.NewServeMux()
 	app.initHealth()
 	app.initMetrics()
@@ -406,6 +449,66 @@ func (app *Application) TaskRebuildAccountingEntries(orderId string, force bool)
 	return app.svc.RebuildAccountingEntries(context.TODO(), orderId, force)
 }
 
+func (app *Application) TaskRebuildAccountingEntriesDiff(orderId, merchantId, dateFrom, dateTo string, apply bool) error {
+	req := &intPkg.AccountingRebuildRequest{
+		OrderId:    orderId,
+		MerchantId: merchantId,
+		Apply:      apply,
+	}
+
+	if dateFrom != "" {
+		date, err := time.Parse("2006-01-02", dateFrom)
+
+		if err != nil {
+			return err
+		}
+
+		req.DateFrom = date.Unix()
+	}
+
+	if dateTo != "" {
+		date, err := time.Parse("2006-01-02", dateTo)
+
+		if err != nil {
+			return err
+		}
+
+		req.DateTo = date.AddDate(0, 0, 1).Add(-time.Second).Unix()
+	}
+
+	rsp := &intPkg.AccountingRebuildResponse{}
+	err := app.svc.RebuildAccounting(context.TODO(), req, rsp)
+
+	if err != nil {
+		return err
+	}
+
+	if rsp.Status != billingpb.ResponseStatusOk {
+		return rsp.Message
+	}
+
+	for _, item := range rsp.Item.Items {
+		app.logger.Info(
+			"accounting entries differ",
+			zap.String("order_id", item.OrderId),
+			zap.Bool("applied", item.Applied),
+			zap.String("error", item.Error),
+			zap.Any("entries", item.Entries),
+		)
+	}
+
+	app.logger.Info(
+		"accounting rebuild finished",
+		zap.Bool("dry_run", rsp.Item.DryRun),
+		zap.Int32("sources", rsp.Item.SourcesCount),
+		zap.Int32("changed", rsp.Item.ChangedCount),
+		zap.Int32("applied", rsp.Item.AppliedCount),
+		zap.Int32("failed", rsp.Item.FailedCount),
+	)
+
+	return nil
+}
+
 func (app *Application) TaskFixReportDates() error {
 	return app.svc.TaskFixReportDates(context.TODO())
 }
@@ -418,6 +521,116 @@ func (app *Application) TaskExtendRoyaltiesWithVat() error {
 	return app.svc.TaskExtendRoyaltiesWithVat(context.TODO())
 }
 
+func (app *Application) TaskProcessSubscriptionsLifecycle() error {
+	return app.svc.ProcessSubscriptionsLifecycle(context.TODO())
+}
+
+func (app *Application) TaskProcessExpiringCards() error {
+	return app.svc.ProcessExpiringCards(context.TODO())
+}
+
+func (app *Application) TaskReleaseRollingReserves() error {
+	return app.svc.ReleaseRollingReserves(context.TODO())
+}
+
+func (app *Application) TaskExportLedger(date, operatingCompanyId, dateFrom, dateTo, format string) error {
+	exportDate := time.Now()
+
+	if date != "" {
+		var err error
+		exportDate, err = time.Parse("2006-01-02", date)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	month := now.New(exportDate).BeginningOfMonth().AddDate(0, -1, 0)
+	from := now.New(month).BeginningOfMonth()
+	to := now.New(month).EndOfMonth()
+
+	if dateFrom != "" {
+		var err error
+		from, err = time.Parse("2006-01-02", dateFrom)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if dateTo != "" {
+		date, err := time.Parse("2006-01-02", dateTo)
+
+		if err != nil {
+			return err
+		}
+
+		to = date.AddDate(0, 0, 1).Add(-time.Second)
+	}
+
+	return app.svc.ExportLedger(context.TODO(), operatingCompanyId, from, to, format)
+}
+
+func (app *Application) TaskFxRevaluation(date string) error {
+	revaluationDate := time.Now()
+
+	if date != "" {
+		var err error
+		revaluationDate, err = time.Parse("2006-01-02", date)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return app.svc.RevalueFxPositions(context.TODO(), revaluationDate)
+}
+
+func (app *Application) TaskGenerateOssReturns(date string) error {
+	generationDate := time.Now()
+
+	if date != "" {
+		var err error
+		generationDate, err = time.Parse("2006-01-02", date)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return app.svc.GenerateOssReturns(context.TODO(), generationDate)
+}
+
+func (app *Application) TaskTrackSalesTaxNexus(date string) error {
+	trackingDate := time.Now()
+
+	if date != "" {
+		var err error
+		trackingDate, err = time.Parse("2006-01-02", date)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return app.svc.TrackSalesTaxNexus(context.TODO(), trackingDate)
+}
+
+func (app *Application) TaskGenerateBillingDocuments(date string) error {
+	generationDate := time.Now()
+
+	if date != "" {
+		var err error
+		generationDate, err = time.Parse("2006-01-02", date)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return app.svc.GenerateMonthlyBillingDocuments(context.TODO(), generationDate)
+}
+
 func (app *Application) TaskMerchantsMigrate() error {
 	return app.svc.MerchantsMigrate(context.TODO())
 }
@@ -67,6 +67,7 @@ type EmailTemplates struct {
 	AdminDocumentUploaded          string `envconfig:"EMAIL_ADMIN_DOCUMENT_UPLOAD_TEMPLATE" default:"p1_admin_upload_document"`
 	MinimalKeyProductNotify        string `envconfig:"EMAIL_MINIMAL_KEY_PRODUCT_NOTIFY" default:"p1_minimal_key_product"`
 	EmptyKeyProductNotify          string `envconfig:"EMAIL_EMPTY_KEY_PRODUCT_NOTIFY" default:"p1_empty_key_product"`
+	CardExpiring                   string `envconfig:"EMAIL_CARD_EXPIRING_TEMPLATE" default:"p1_card_expiring"`
 
 	MultiLanguage map[string]map[string]string
 }
@@ -160,6 +161,30 @@ type Config struct {
 	DashboardUrl string `envconfig:"DASHBOARD_URL" default:"https://paysupermgmt.tst.protocol.one"`
 	CheckoutUrl  string `envconfig:"CHECKOUT_URL" default:"https://checkout.tst.pay.super.com"`
 
+	// CardAccountUpdaterFile is a path to the acquirer's account updater file with new expiration dates of reissued cards.
+	CardAccountUpdaterFile string `envconfig:"CARD_ACCOUNT_UPDATER_FILE" default:""`
+
+	// MerchantBalanceSettlementDelay is a time in seconds after which the merchant's payments funds become available for payout.
+	MerchantBalanceSettlementDelay int64 `envconfig:"MERCHANT_BALANCE_SETTLEMENT_DELAY" default:"604800"`
+
+	// AccountingFunctionalCurrency is a currency of operating companies accounting, merchants balances in other currencies are revaluated to it.
+	AccountingFunctionalCurrency string `envconfig:"ACCOUNTING_FUNCTIONAL_CURRENCY" default:"EUR"`
+
+	// AccountingRebuildWithoutTransaction allows to apply the accounting rebuild on the standalone database server which
+	// doesn't support transactions, changes of the source aren't atomic then. Should be used for development and testing.
+	AccountingRebuildWithoutTransaction bool `envconfig:"ACCOUNTING_REBUILD_WITHOUT_TRANSACTION" default:"false"`
+
+	// VatIdValidator is a validator of customers VAT IDs, "vies" checks them in the VIES service of the European Commission,
+	// "stub" only checks the format of VAT ID and accepts any well-formed one, use it for development and testing only.
+	VatIdValidator string `envconfig:"VAT_ID_VALIDATOR" default:"vies"`
+	VatIdViesUrl   string `envconfig:"VAT_ID_VIES_URL" default:"https://ec.europa.eu/taxation_customs/vies/rest-api/check-vat-number"`
+
+	// TaxProvider is a provider of tax rates for orders and VAT reports, "tax_service" requests rates from the tax service,
+	// "rate_table" uses versioned tax rate tables stored in the database.
+	TaxProvider string `envconfig:"TAX_PROVIDER" default:"tax_service"`
+	// TaxProviderFallback is a provider of tax rates which is used when the main provider fails, "none" disables the fallback.
+	TaxProviderFallback string `envconfig:"TAX_PROVIDER_FALLBACK" default:"rate_table"`
+
 	MetricsPort              string `envconfig:"METRICS_PORT" default:"8086"`
 	MetricsReadTimeout       int    `envconfig:"METRICS_READ_TIMEOUT" default:"60"`
 	MetricsReadHeaderTimeout int    `envconfig:"METRICS_READ_HEADER_TIMEOUT" default:"60"`
@@ -318,6 +343,10 @@ func (cfg *Config) GetUserInviteUrl(token string) string {
 	return fmt.Sprintf(pkg.UserInviteUrl, cfg.DashboardUrl, token)
 }
 
+func (cfg *Config) GetCardUpdateUrl(token string) string {
+	return fmt.Sprintf(pkg.CardUpdateUrl, cfg.CheckoutUrl, token)
+}
+
 func (cfg *Config) buildMultiLanguageTemplates() map[string]map[string]string {
 	return map[string]map[string]string{
 		EmailTemplateConfirmAccount: {
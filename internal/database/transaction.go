@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	// Any collection gives access to the client of the database, the collection isn't queried
+	transactionClientCollection = "transaction"
+)
+
+// IsTransactionSupported checks that the database server supports transactions, only members of replica sets
+// and routers of sharded clusters support them.
+func IsTransactionSupported(ctx context.Context, db mongodb.SourceInterface) (bool, error) {
+	client := db.Collection(transactionClientCollection).Database().Client()
+
+	var info bson.M
+	err := client.Database("admin").RunCommand(ctx, bson.D{{"isMaster", 1}}).Decode(&info)
+
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := info["setName"]
+
+	return ok || info["msg"] == "isdbgrid", nil
+}
+
+// WithTransaction runs the function in the transaction of the database session, the context passed to the function
+// must be used by all queries of the transaction. The transaction is aborted if the function returns an error.
+// Standalone servers don't support transactions, the function is run without the transaction on them and writes
+// of the function aren't atomic. Callers which can't run without the transaction must check the server
+// by IsTransactionSupported. The function called with the context of a running transaction joins the transaction.
+func WithTransaction(ctx context.Context, db mongodb.SourceInterface, fn func(ctx context.Context) error) error {
+	return runTransaction(ctx, db, fn, false)
+}
+
+// WithRetryableTransaction runs the function like WithTransaction and runs it again in the new transaction
+// if the transaction fails by the transient error like the write conflict of concurrent transactions, so
+// the function must not change the state outside of the transaction. The function called with the context
+// of a running transaction joins the transaction and isn't run again by itself.
+func WithRetryableTransaction(ctx context.Context, db mongodb.SourceInterface, fn func(ctx context.Context) error) error {
+	return runTransaction(ctx, db, fn, true)
+}
+
+func runTransaction(ctx context.Context, db mongodb.SourceInterface, fn func(ctx context.Context) error, retry bool) error {
+	if _, ok := ctx.(mongo.SessionContext); ok {
+		return fn(ctx)
+	}
+
+	supported, err := IsTransactionSupported(ctx, db)
+
+	if err != nil {
+		return err
+	}
+
+	if !supported {
+		zap.L().Warn("Database server doesn't support transactions, queries are run without transaction")
+		return fn(ctx)
+	}
+
+	client := db.Collection(transactionClientCollection).Database().Client()
+
+	return client.UseSession(ctx, func(sc mongo.SessionContext) error {
+		if retry {
+			_, err := sc.WithTransaction(sc, func(sc mongo.SessionContext) (interface{}, error) {
+				return nil, fn(sc)
+			})
+			return err
+		}
+
+		if err := sc.StartTransaction(); err != nil {
+			return err
+		}
+
+		if err := fn(sc); err != nil {
+			_ = sc.AbortTransaction(sc)
+			return err
+		}
+
+		return sc.CommitTransaction(sc)
+	})
+}
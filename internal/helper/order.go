@@ -184,6 +184,7 @@ func MakeOrderAggregateQuery(
 				"royalty_report_id":                                 1,
 				"recurring":                                         1,
 				"recurring_id":                                      1,
+				"recurring_settings":                                1,
 				"report_summary":                                    1,
 			},
 		},
@@ -19,6 +19,20 @@ type AccountingEntryRepositoryInterface struct {
 	mock.Mock
 }
 
+// ApplyChanges provides a mock function with given fields: ctx, inserted, replaced, deletedIds
+func (_m *AccountingEntryRepositoryInterface) ApplyChanges(ctx context.Context, inserted []*billingpb.AccountingEntry, replaced []*billingpb.AccountingEntry, deletedIds []string) error {
+	ret := _m.Called(ctx, inserted, replaced, deletedIds)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*billingpb.AccountingEntry, []*billingpb.AccountingEntry, []string) error); ok {
+		r0 = rf(ctx, inserted, replaced, deletedIds)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ApplyObjectSource provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4, _a5
 func (_m *AccountingEntryRepositoryInterface) ApplyObjectSource(_a0 context.Context, _a1 string, _a2 string, _a3 string, _a4 string, _a5 *billingpb.AccountingEntry) (*billingpb.AccountingEntry, error) {
 	ret := _m.Called(_a0, _a1, _a2, _a3, _a4, _a5)
@@ -277,6 +291,29 @@ func (_m *AccountingEntryRepositoryInterface) GetRollingReservesForRoyaltyReport
 	return r0, r1
 }
 
+// GetVatByOperatingCompanyDates provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *AccountingEntryRepositoryInterface) GetVatByOperatingCompanyDates(_a0 context.Context, _a1 string, _a2 time.Time, _a3 time.Time) ([]*pkg.LedgerVatQueryResItem, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []*pkg.LedgerVatQueryResItem
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) []*pkg.LedgerVatQueryResItem); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*pkg.LedgerVatQueryResItem)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // MultipleInsert provides a mock function with given fields: _a0, _a1
 func (_m *AccountingEntryRepositoryInterface) MultipleInsert(_a0 context.Context, _a1 []*billingpb.AccountingEntry) error {
 	ret := _m.Called(_a0, _a1)
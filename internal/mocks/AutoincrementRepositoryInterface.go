@@ -33,3 +33,24 @@ func (_m *AutoincrementRepositoryInterface) GatPayoutAutoincrementId(ctx context
 
 	return r0, r1
 }
+
+// GetBillingDocumentAutoincrementId provides a mock function with given fields: ctx, operatingCompanyId, documentType
+func (_m *AutoincrementRepositoryInterface) GetBillingDocumentAutoincrementId(ctx context.Context, operatingCompanyId string, documentType string) (int64, error) {
+	ret := _m.Called(ctx, operatingCompanyId, documentType)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) int64); ok {
+		r0 = rf(ctx, operatingCompanyId, documentType)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, operatingCompanyId, documentType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
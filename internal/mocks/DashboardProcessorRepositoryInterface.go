@@ -346,6 +346,29 @@ func (_m *DashboardProcessorRepositoryInterface) ExecuteSourcesReport(ctx contex
 	return r0, r1
 }
 
+// ExecuteSubscriptionsReport provides a mock function with given fields: ctx, out
+func (_m *DashboardProcessorRepositoryInterface) ExecuteSubscriptionsReport(ctx context.Context, out interface{}) (interface{}, error) {
+	ret := _m.Called(ctx, out)
+
+	var r0 interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}) interface{}); ok {
+		r0 = rf(ctx, out)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interface{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}) error); ok {
+		r1 = rf(ctx, out)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ExecuteTotalTransactionsAndArpuReports provides a mock function with given fields: ctx, out
 func (_m *DashboardProcessorRepositoryInterface) ExecuteTotalTransactionsAndArpuReports(ctx context.Context, out interface{}) (interface{}, error) {
 	ret := _m.Called(ctx, out)
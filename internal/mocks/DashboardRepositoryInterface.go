@@ -8,6 +8,8 @@ import (
 	billingpb "github.com/paysuper/paysuper-proto/go/billingpb"
 
 	mock "github.com/stretchr/testify/mock"
+
+	pkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
 )
 
 // DashboardRepositoryInterface is an autogenerated mock type for the DashboardRepositoryInterface type
@@ -129,3 +131,26 @@ func (_m *DashboardRepositoryInterface) GetRevenueDynamicsReport(ctx context.Con
 
 	return r0, r1
 }
+
+// GetSubscriptionsReport provides a mock function with given fields: ctx, merchantId, projectId, period
+func (_m *DashboardRepositoryInterface) GetSubscriptionsReport(ctx context.Context, merchantId string, projectId string, period string) (*pkg.DashboardSubscriptionsReport, error) {
+	ret := _m.Called(ctx, merchantId, projectId, period)
+
+	var r0 *pkg.DashboardSubscriptionsReport
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *pkg.DashboardSubscriptionsReport); ok {
+		r0 = rf(ctx, merchantId, projectId, period)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*pkg.DashboardSubscriptionsReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, merchantId, projectId, period)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -309,6 +309,52 @@ func (_m *OrderViewRepositoryInterface) GetPublicOrderBy(ctx context.Context, id
 	return r0, r1
 }
 
+// GetRefundAnalytics provides a mock function with given fields: ctx, merchantId, projectId, groupBy, interval, from, to
+func (_m *OrderViewRepositoryInterface) GetRefundAnalytics(ctx context.Context, merchantId string, projectId string, groupBy string, interval string, from time.Time, to time.Time) ([]*pkg.RefundAnalyticsItem, error) {
+	ret := _m.Called(ctx, merchantId, projectId, groupBy, interval, from, to)
+
+	var r0 []*pkg.RefundAnalyticsItem
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, time.Time, time.Time) []*pkg.RefundAnalyticsItem); ok {
+		r0 = rf(ctx, merchantId, projectId, groupBy, interval, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*pkg.RefundAnalyticsItem)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, merchantId, projectId, groupBy, interval, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReverseChargeVatSummary provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4, _a5
+func (_m *OrderViewRepositoryInterface) GetReverseChargeVatSummary(_a0 context.Context, _a1 string, _a2 string, _a3 bool, _a4 time.Time, _a5 time.Time) ([]*pkg.VatReportQueryResItem, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4, _a5)
+
+	var r0 []*pkg.VatReportQueryResItem
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool, time.Time, time.Time) []*pkg.VatReportQueryResItem); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4, _a5)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*pkg.VatReportQueryResItem)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, bool, time.Time, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3, _a4, _a5)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetRoyaltyForMerchants provides a mock function with given fields: _a0, _a1, _a2, _a3
 func (_m *OrderViewRepositoryInterface) GetRoyaltyForMerchants(_a0 context.Context, _a1 []string, _a2 time.Time, _a3 time.Time) ([]*pkg.RoyaltyReportMerchant, error) {
 	ret := _m.Called(_a0, _a1, _a2, _a3)
@@ -460,6 +506,29 @@ func (_m *OrderViewRepositoryInterface) GetTransactionsPublic(ctx context.Contex
 	return r0, r1
 }
 
+// GetSalesTaxNexusSummary provides a mock function with given fields: ctx, operatingCompanyId, country, currency, from, to
+func (_m *OrderViewRepositoryInterface) GetSalesTaxNexusSummary(ctx context.Context, operatingCompanyId string, country string, currency string, from time.Time, to time.Time) ([]*pkg.SalesTaxNexusQueryResItem, error) {
+	ret := _m.Called(ctx, operatingCompanyId, country, currency, from, to)
+
+	var r0 []*pkg.SalesTaxNexusQueryResItem
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, time.Time, time.Time) []*pkg.SalesTaxNexusQueryResItem); ok {
+		r0 = rf(ctx, operatingCompanyId, country, currency, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*pkg.SalesTaxNexusQueryResItem)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, operatingCompanyId, country, currency, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTurnoverSummary provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4, _a5
 func (_m *OrderViewRepositoryInterface) GetTurnoverSummary(_a0 context.Context, _a1 string, _a2 string, _a3 string, _a4 time.Time, _a5 time.Time) ([]*pkg.TurnoverQueryResItem, error) {
 	ret := _m.Called(_a0, _a1, _a2, _a3, _a4, _a5)
@@ -9,6 +9,8 @@ import (
 	protoiface "google.golang.org/protobuf/runtime/protoiface"
 
 	recurringpb "github.com/paysuper/paysuper-proto/go/recurringpb"
+
+	time "time"
 )
 
 // PaymentSystemInterface is an autogenerated mock type for the PaymentSystemInterface type
@@ -142,6 +144,20 @@ func (_m *PaymentSystemInterface) IsSubscriptionCallback(request protoiface.Mess
 	return r0
 }
 
+// PauseRecurringSubscription provides a mock function with given fields: order, subscription
+func (_m *PaymentSystemInterface) PauseRecurringSubscription(order *billingpb.Order, subscription *recurringpb.Subscription) error {
+	ret := _m.Called(order, subscription)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*billingpb.Order, *recurringpb.Subscription) error); ok {
+		r0 = rf(order, subscription)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ProcessPayment provides a mock function with given fields: order, message, raw, signature
 func (_m *PaymentSystemInterface) ProcessPayment(order *billingpb.Order, message protoiface.MessageV1, raw string, signature string) error {
 	ret := _m.Called(order, message, raw, signature)
@@ -169,3 +185,38 @@ func (_m *PaymentSystemInterface) ProcessRefund(order *billingpb.Order, refund *
 
 	return r0
 }
+
+// ResumeRecurringSubscription provides a mock function with given fields: order, subscription
+func (_m *PaymentSystemInterface) ResumeRecurringSubscription(order *billingpb.Order, subscription *recurringpb.Subscription) error {
+	ret := _m.Called(order, subscription)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*billingpb.Order, *recurringpb.Subscription) error); ok {
+		r0 = rf(order, subscription)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateRecurringCard provides a mock function with given fields: order, subscription, requisites, startAt
+func (_m *PaymentSystemInterface) UpdateRecurringCard(order *billingpb.Order, subscription *recurringpb.Subscription, requisites map[string]string, startAt time.Time) (string, error) {
+	ret := _m.Called(order, subscription, requisites, startAt)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(*billingpb.Order, *recurringpb.Subscription, map[string]string, time.Time) string); ok {
+		r0 = rf(order, subscription, requisites, startAt)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*billingpb.Order, *recurringpb.Subscription, map[string]string, time.Time) error); ok {
+		r1 = rf(order, subscription, requisites, startAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
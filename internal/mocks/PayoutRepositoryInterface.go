@@ -7,6 +7,8 @@ import (
 
 	billingpb "github.com/paysuper/paysuper-proto/go/billingpb"
 
+	time "time"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -82,6 +84,29 @@ func (_m *PayoutRepositoryInterface) FindCount(ctx context.Context, in *billingp
 	return r0, r1
 }
 
+// FindPending provides a mock function with given fields: ctx, operatingCompanyId, currency
+func (_m *PayoutRepositoryInterface) FindPending(ctx context.Context, operatingCompanyId string, currency string) ([]*billingpb.PayoutDocument, error) {
+	ret := _m.Called(ctx, operatingCompanyId, currency)
+
+	var r0 []*billingpb.PayoutDocument
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []*billingpb.PayoutDocument); ok {
+		r0 = rf(ctx, operatingCompanyId, currency)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*billingpb.PayoutDocument)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, operatingCompanyId, currency)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetBalanceAmount provides a mock function with given fields: _a0, _a1, _a2
 func (_m *PayoutRepositoryInterface) GetBalanceAmount(_a0 context.Context, _a1 string, _a2 string) (float64, error) {
 	ret := _m.Called(_a0, _a1, _a2)
@@ -172,6 +197,29 @@ func (_m *PayoutRepositoryInterface) GetLast(_a0 context.Context, _a1 string, _a
 	return r0, r1
 }
 
+// GetSkipped provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *PayoutRepositoryInterface) GetSkipped(_a0 context.Context, _a1 string, _a2 string, _a3 time.Time) (*billingpb.PayoutDocument, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 *billingpb.PayoutDocument
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) *billingpb.PayoutDocument); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*billingpb.PayoutDocument)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Insert provides a mock function with given fields: _a0, _a1, _a2, _a3
 func (_m *PayoutRepositoryInterface) Insert(_a0 context.Context, _a1 *billingpb.PayoutDocument, _a2 string, _a3 string) error {
 	ret := _m.Called(_a0, _a1, _a2, _a3)
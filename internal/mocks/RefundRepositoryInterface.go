@@ -103,6 +103,27 @@ func (_m *RefundRepositoryInterface) GetById(_a0 context.Context, _a1 string) (*
 	return r0, r1
 }
 
+// GetReasonCode provides a mock function with given fields: _a0, _a1
+func (_m *RefundRepositoryInterface) GetReasonCode(_a0 context.Context, _a1 string) (string, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Insert provides a mock function with given fields: _a0, _a1
 func (_m *RefundRepositoryInterface) Insert(_a0 context.Context, _a1 *billingpb.Refund) error {
 	ret := _m.Called(_a0, _a1)
@@ -117,6 +138,20 @@ func (_m *RefundRepositoryInterface) Insert(_a0 context.Context, _a1 *billingpb.
 	return r0
 }
 
+// InsertWithReasonCode provides a mock function with given fields: ctx, refund, reasonCode
+func (_m *RefundRepositoryInterface) InsertWithReasonCode(ctx context.Context, refund *billingpb.Refund, reasonCode string) error {
+	ret := _m.Called(ctx, refund, reasonCode)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *billingpb.Refund, string) error); ok {
+		r0 = rf(ctx, refund, reasonCode)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: _a0, _a1
 func (_m *RefundRepositoryInterface) Update(_a0 context.Context, _a1 *billingpb.Refund) error {
 	ret := _m.Called(_a0, _a1)
@@ -44,6 +44,29 @@ func (_m *RoyaltyReportRepositoryInterface) FindByMerchantStatusDates(ctx contex
 	return r0, r1
 }
 
+// FindByOperatingCompanyPeriod provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *RoyaltyReportRepositoryInterface) FindByOperatingCompanyPeriod(_a0 context.Context, _a1 string, _a2 time.Time, _a3 time.Time) ([]*billingpb.RoyaltyReport, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []*billingpb.RoyaltyReport
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) []*billingpb.RoyaltyReport); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*billingpb.RoyaltyReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindCountByMerchantStatusDates provides a mock function with given fields: ctx, merchantId, statuses, dateFrom, dateTo
 func (_m *RoyaltyReportRepositoryInterface) FindCountByMerchantStatusDates(ctx context.Context, merchantId string, statuses []string, dateFrom string, dateTo string) (int64, error) {
 	ret := _m.Called(ctx, merchantId, statuses, dateFrom, dateTo)
@@ -217,6 +240,29 @@ func (_m *RoyaltyReportRepositoryInterface) GetByPeriod(_a0 context.Context, _a1
 	return r0, r1
 }
 
+// GetLastByMerchant provides a mock function with given fields: ctx, merchantId, currency
+func (_m *RoyaltyReportRepositoryInterface) GetLastByMerchant(ctx context.Context, merchantId string, currency string) (*billingpb.RoyaltyReport, error) {
+	ret := _m.Called(ctx, merchantId, currency)
+
+	var r0 *billingpb.RoyaltyReport
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *billingpb.RoyaltyReport); ok {
+		r0 = rf(ctx, merchantId, currency)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*billingpb.RoyaltyReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, merchantId, currency)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetNonPayoutReports provides a mock function with given fields: ctx, merchantId, currency
 func (_m *RoyaltyReportRepositoryInterface) GetNonPayoutReports(ctx context.Context, merchantId string, currency string) ([]*billingpb.RoyaltyReport, error) {
 	ret := _m.Called(ctx, merchantId, currency)
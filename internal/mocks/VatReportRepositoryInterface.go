@@ -17,6 +17,29 @@ type VatReportRepositoryInterface struct {
 	mock.Mock
 }
 
+// FindByOperatingCompanyPeriod provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *VatReportRepositoryInterface) FindByOperatingCompanyPeriod(_a0 context.Context, _a1 string, _a2 time.Time, _a3 time.Time) ([]*billingpb.VatReport, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []*billingpb.VatReport
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) []*billingpb.VatReport); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*billingpb.VatReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByCountry provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4
 func (_m *VatReportRepositoryInterface) GetByCountry(_a0 context.Context, _a1 string, _a2 []string, _a3 int64, _a4 int64) ([]*billingpb.VatReport, error) {
 	ret := _m.Called(_a0, _a1, _a2, _a3, _a4)
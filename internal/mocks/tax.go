@@ -2,16 +2,24 @@ package mocks
 
 import (
 	"context"
+	"errors"
 	"github.com/micro/go-micro/client"
 	"github.com/paysuper/paysuper-proto/go/taxpb"
 )
 
 type TaxServiceOkMock struct{}
+type TaxServiceErrorMock struct {
+	TaxServiceOkMock
+}
 
 func NewTaxServiceOkMock() taxpb.TaxService {
 	return &TaxServiceOkMock{}
 }
 
+func NewTaxServiceErrorMock() taxpb.TaxService {
+	return &TaxServiceErrorMock{}
+}
+
 func (m *TaxServiceOkMock) GetRate(
 	ctx context.Context,
 	in *taxpb.GeoIdentity,
@@ -61,3 +69,11 @@ func (m *TaxServiceOkMock) DeleteRateById(
 ) (*taxpb.DeleteRateResponse, error) {
 	return &taxpb.DeleteRateResponse{}, nil
 }
+
+func (m *TaxServiceErrorMock) GetRate(
+	ctx context.Context,
+	in *taxpb.GeoIdentity,
+	opts ...client.CallOption,
+) (*taxpb.TaxRate, error) {
+	return nil, errors.New("tax service unavailable")
+}
@@ -0,0 +1,91 @@
+package payment_system
+
+import (
+	"encoding/csv"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	accountUpdaterFileColumnRecurringId = iota
+	accountUpdaterFileColumnMaskedPan
+	accountUpdaterFileColumnExpireMonth
+	accountUpdaterFileColumnExpireYear
+	accountUpdaterFileColumnsCount
+)
+
+// AccountUpdaterInterface is an abstraction of acquirer's account updater service,
+// which knows actual expiration dates of reissued cards.
+type AccountUpdaterInterface interface {
+	// GetUpdates returns new expiration dates for the cards which were reissued.
+	GetUpdates(cards []*intPkg.CardExpiry) ([]*intPkg.CardExpiryUpdate, error)
+}
+
+type accountUpdaterFile struct {
+	path string
+}
+
+// NewAccountUpdaterFile returns account updater which reads acquirer's account updater file.
+// The file is CSV with columns: recurring identifier, masked card number, expiration month and expiration year.
+func NewAccountUpdaterFile(path string) AccountUpdaterInterface {
+	return &accountUpdaterFile{path: path}
+}
+
+func (u *accountUpdaterFile) GetUpdates(cards []*intPkg.CardExpiry) ([]*intPkg.CardExpiryUpdate, error) {
+	file, err := os.Open(u.path)
+
+	if err != nil {
+		zap.L().Error("unable to open account updater file", zap.Error(err), zap.String("path", u.path))
+		return nil, err
+	}
+
+	defer file.Close()
+
+	index := make(map[string]*intPkg.CardExpiry, len(cards))
+
+	for _, card := range cards {
+		index[card.RecurringId] = card
+	}
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = accountUpdaterFileColumnsCount
+
+	var updates []*intPkg.CardExpiryUpdate
+
+	for {
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			zap.L().Error("unable to read account updater file", zap.Error(err), zap.String("path", u.path))
+			return nil, err
+		}
+
+		card, ok := index[strings.TrimSpace(record[accountUpdaterFileColumnRecurringId])]
+
+		if !ok {
+			continue
+		}
+
+		update := &intPkg.CardExpiryUpdate{
+			RecurringId: card.RecurringId,
+			MaskedPan:   strings.TrimSpace(record[accountUpdaterFileColumnMaskedPan]),
+			ExpireMonth: strings.TrimSpace(record[accountUpdaterFileColumnExpireMonth]),
+			ExpireYear:  strings.TrimSpace(record[accountUpdaterFileColumnExpireYear]),
+		}
+
+		if update.ExpireMonth == card.ExpireMonth && update.ExpireYear == card.ExpireYear {
+			continue
+		}
+
+		updates = append(updates, update)
+	}
+
+	return updates, nil
+}
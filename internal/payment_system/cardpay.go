@@ -37,6 +37,7 @@ const (
 
 	CardPayDateFormat          = "2006-01-02T15:04:05Z"
 	cardPayInitiatorCardholder = "cit"
+	cardPayInitiatorMerchant   = "mit"
 
 	cardPayMaxItemNameLength        = 50
 	cardPayMaxItemDescriptionLength = 200
@@ -103,13 +104,15 @@ type CardPayPaymentData struct {
 }
 
 type CardPayRecurringData struct {
-	Currency   string                      `json:"currency"`
-	Amount     float64                     `json:"amount"`
-	Filing     *CardPayRecurringDataFiling `json:"filing,omitempty"`
-	Descriptor string                      `json:"dynamic_descriptor"`
-	Note       string                      `json:"note"`
-	Initiator  string                      `json:"initiator"`
-	Plan       *CardPayRecurringPlan       `json:"plan"`
+	Currency          string                      `json:"currency"`
+	Amount            float64                     `json:"amount"`
+	Filing            *CardPayRecurringDataFiling `json:"filing,omitempty"`
+	Descriptor        string                      `json:"dynamic_descriptor"`
+	Note              string                      `json:"note"`
+	Initiator         string                      `json:"initiator"`
+	Plan              *CardPayRecurringPlan       `json:"plan"`
+	SubscriptionStart string                      `json:"subscription_start,omitempty"`
+	TransactionId     string                      `json:"trans_id,omitempty"`
 }
 
 type CardPayRecurringPlan struct {
@@ -805,6 +808,11 @@ func (h *cardPay) getCardPayOrder(
 				Id: recurringId,
 			}
 
+			if requisites[pkg.PaymentCreateFieldInitiator] == pkg.PaymentInitiatorMerchant {
+				cardPayOrder.RecurringData.Initiator = cardPayInitiatorMerchant
+				cardPayOrder.RecurringData.TransactionId = requisites[pkg.PaymentCreateFieldInitialTransactionId]
+			}
+
 			return cardPayOrder, nil
 		}
 	} else {
@@ -1125,7 +1133,7 @@ func (h *cardPay) CreateRecurringSubscription(
 		return "", err
 	}
 
-	subscription.CardpaySubscriptionId, redirectUrl, err = h.createRecurringSubscription(order, subscription.CardpayPlanId, successUrl, failUrl, requisites)
+	subscription.CardpaySubscriptionId, redirectUrl, err = h.createRecurringSubscription(order, subscription.CardpayPlanId, successUrl, failUrl, requisites, time.Time{})
 
 	if err != nil {
 		return "", err
@@ -1234,7 +1242,7 @@ func (h *cardPay) createRecurringPlan(order *billingpb.Order) (string, error) {
 }
 
 func (h *cardPay) createRecurringSubscription(
-	order *billingpb.Order, planId, successUrl, failUrl string, requisites map[string]string,
+	order *billingpb.Order, planId, successUrl, failUrl string, requisites map[string]string, startAt time.Time,
 ) (string, string, error) {
 	data := &CardPayRecurringSubscriptionRequest{
 		Request: &CardPayRequest{
@@ -1264,6 +1272,10 @@ func (h *cardPay) createRecurringSubscription(
 		},
 	}
 
+	if !startAt.IsZero() {
+		data.RecurringData.SubscriptionStart = startAt.UTC().Format(CardPayDateFormat)
+	}
+
 	if order.PaymentMethod.ExternalId == recurringpb.PaymentSystemGroupAliasBankCard {
 		expire := requisites[billingpb.PaymentCreateFieldMonth] + "/" + requisites[billingpb.PaymentCreateFieldYear]
 
@@ -1372,6 +1384,161 @@ func (h *cardPay) DeleteRecurringSubscription(order *billingpb.Order, subscripti
 	return nil
 }
 
+func (h *cardPay) PauseRecurringSubscription(order *billingpb.Order, subscription *recurringpb.Subscription) error {
+	err := h.updateRecurringSubscription(order, subscription, cardPayStatusInactive)
+
+	if err != nil {
+		zap.L().Error(
+			"cardpay API: pause recurring subscription request failed",
+			zap.Error(err),
+			zap.String("method", pkg.CardPayPaths[pkg.PaymentSystemActionUpdateRecurringSubscription].Method),
+			zap.Any(pkg.LogFieldRequest, subscription),
+			zap.Any(pkg.LogFieldOrder, order),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (h *cardPay) ResumeRecurringSubscription(order *billingpb.Order, subscription *recurringpb.Subscription) error {
+	err := h.updateRecurringSubscription(order, subscription, cardPayStatusActive)
+
+	if err != nil {
+		zap.L().Error(
+			"cardpay API: resume recurring subscription request failed",
+			zap.Error(err),
+			zap.String("method", pkg.CardPayPaths[pkg.PaymentSystemActionUpdateRecurringSubscription].Method),
+			zap.Any(pkg.LogFieldRequest, subscription),
+			zap.Any(pkg.LogFieldOrder, order),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateRecurringCard binds new card to the recurring subscription or verifies saved card by zero-amount recurring payment.
+// For subscription new one is created on the same plan with deferred start, so the card is verified without charge,
+// and after that the previous subscription is cancelled.
+func (h *cardPay) UpdateRecurringCard(
+	order *billingpb.Order, subscription *recurringpb.Subscription, requisites map[string]string, startAt time.Time,
+) (string, error) {
+	if subscription == nil {
+		return h.verifyCard(order, requisites)
+	}
+
+	subscriptionId, _, err := h.createRecurringSubscription(order, subscription.CardpayPlanId, "", "", requisites, startAt)
+
+	if err != nil {
+		return "", err
+	}
+
+	err = h.updateRecurringSubscription(order, subscription, cardPayStatusCancelled)
+
+	if err != nil {
+		zap.L().Error(
+			"cardpay API: cancel previous recurring subscription failed",
+			zap.Error(err),
+			zap.Any(pkg.LogFieldRequest, subscription),
+			zap.Any(pkg.LogFieldOrder, order),
+		)
+
+		created := &recurringpb.Subscription{CardpaySubscriptionId: subscriptionId}
+		_ = h.updateRecurringSubscription(order, created, cardPayStatusCancelled)
+
+		return "", err
+	}
+
+	subscription.CardpaySubscriptionId = subscriptionId
+
+	return subscriptionId, nil
+}
+
+func (h *cardPay) verifyCard(order *billingpb.Order, requisites map[string]string) (string, error) {
+	data := &CardPayOrder{
+		Request: &CardPayRequest{
+			Id:   order.Id + time.Now().UTC().Format(CardPayDateFormat),
+			Time: time.Now().UTC().Format(CardPayDateFormat),
+		},
+		MerchantOrder: &CardPayMerchantOrder{
+			Id:          order.Id,
+			Description: order.Description,
+		},
+		Description:   order.Description,
+		PaymentMethod: recurringpb.PaymentSystemGroupAliasBankCard,
+		RecurringData: &CardPayRecurringData{
+			Currency:  order.ChargeCurrency,
+			Amount:    0,
+			Initiator: cardPayInitiatorCardholder,
+		},
+		Customer: &CardPayCustomer{
+			Ip:      order.User.Ip,
+			Account: order.User.Id,
+			Email:   order.User.TechEmail,
+		},
+	}
+	h.geBankCardCardPayOrder(data, requisites)
+
+	req, err := h.getRequestWithAuth(order, data, pkg.PaymentSystemActionRecurringPayment)
+
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := h.httpClient.Do(req)
+
+	if err != nil {
+		zap.L().Error(
+			"cardpay API: send card verification request failed",
+			zap.Error(err),
+			zap.String("method", pkg.CardPayPaths[pkg.PaymentSystemActionRecurringPayment].Method),
+			zap.Any(pkg.LogFieldOrder, order),
+		)
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		zap.L().Error(
+			"card verification response returned with bad http status",
+			zap.String("method", pkg.CardPayPaths[pkg.PaymentSystemActionRecurringPayment].Method),
+			zap.Any("status", resp.StatusCode),
+			zap.Any(pkg.LogFieldOrder, order),
+		)
+		return "", paymentSystemErrorCardVerificationFailed
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+
+	if err != nil {
+		return "", err
+	}
+
+	response := &CardPayOrderRecurringResponse{}
+	err = json.Unmarshal(b, &response)
+
+	if err != nil {
+		zap.L().Error(
+			"card verification response contain invalid json",
+			zap.Error(err),
+			zap.Any(pkg.LogFieldOrder, order),
+			zap.ByteString(pkg.LogFieldResponse, b),
+		)
+		return "", err
+	}
+
+	if !response.IsSuccessStatus() || response.RecurringData.Filing == nil {
+		zap.L().Error(
+			"card verification declined",
+			zap.Any(pkg.LogFieldOrder, order),
+			zap.ByteString(pkg.LogFieldResponse, b),
+		)
+		return "", paymentSystemErrorCardVerificationFailed
+	}
+
+	return response.RecurringData.Filing.Id, nil
+}
+
 func (h *cardPay) updateRecurringSubscription(order *billingpb.Order, subscription *recurringpb.Subscription, status string) error {
 	data := &CardPayRecurringSubscriptionUpdateRequest{
 		Request: &CardPayRequest{
@@ -5,6 +5,7 @@ import (
 	"github.com/paysuper/paysuper-billing-server/pkg/errors"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"github.com/paysuper/paysuper-proto/go/recurringpb"
+	"time"
 )
 
 const (
@@ -30,6 +31,7 @@ var (
 	paymentSystemErrorCreateRecurringSubscriptionFailed      = errors.NewBillingServerErrorMsg("ph000016", "create recurring subscription failed")
 	paymentSystemErrorDeleteRecurringPlanFailed              = errors.NewBillingServerErrorMsg("ph000017", "delete recurring plan failed")
 	paymentSystemErrorUpdateRecurringSubscriptionFailed      = errors.NewBillingServerErrorMsg("ph000018", "update recurring subscription failed")
+	paymentSystemErrorCardVerificationFailed                 = errors.NewBillingServerErrorMsg("ph000019", "card verification failed")
 )
 
 type PaymentSystemInterface interface {
@@ -43,6 +45,9 @@ type PaymentSystemInterface interface {
 	CreateRecurringSubscription(order *billingpb.Order, subscription *recurringpb.Subscription, successUrl, failUrl string, requisites map[string]string) (string, error)
 	IsSubscriptionCallback(request proto.Message) bool
 	DeleteRecurringSubscription(order *billingpb.Order, subscription *recurringpb.Subscription) error
+	PauseRecurringSubscription(order *billingpb.Order, subscription *recurringpb.Subscription) error
+	ResumeRecurringSubscription(order *billingpb.Order, subscription *recurringpb.Subscription) error
+	UpdateRecurringCard(order *billingpb.Order, subscription *recurringpb.Subscription, requisites map[string]string, startAt time.Time) (string, error)
 }
 
 type PaymentSystemManagerInterface interface {
@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// AccountingCorrection is the manual accounting entry which is posted after the approval by the second finance user.
+// Posted correction can't be deleted and is reversed by the counter-entry.
+type AccountingCorrection struct {
+	Id              primitive.ObjectID                `bson:"_id" json:"id"`
+	MerchantId      string                            `bson:"merchant_id" json:"merchant_id"`
+	RoyaltyReportId string                            `bson:"royalty_report_id" json:"royalty_report_id"`
+	Type            string                            `bson:"type" json:"type"`
+	Amount          float64                           `bson:"amount" json:"amount"`
+	Currency        string                            `bson:"currency" json:"currency"`
+	ReasonCode      string                            `bson:"reason_code" json:"reason_code"`
+	Comment         string                            `bson:"comment" json:"comment"`
+	Attachments     []*AccountingCorrectionAttachment `bson:"attachments" json:"attachments"`
+	Status          string                            `bson:"status" json:"status"`
+	CreatedBy       string                            `bson:"created_by" json:"created_by"`
+	ApprovedBy      string                            `bson:"approved_by" json:"approved_by"`
+	EntryId         string                            `bson:"entry_id" json:"entry_id"`
+	ReversalEntryId string                            `bson:"reversal_entry_id" json:"reversal_entry_id"`
+	CreatedAt       time.Time                         `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time                         `bson:"updated_at" json:"updated_at"`
+}
+
+type AccountingCorrectionAttachment struct {
+	Name string `bson:"name" json:"name"`
+	Url  string `bson:"url" json:"url"`
+}
+
+// AccountingCorrectionLog is the record of the audit trail of the correction. Records are never changed or deleted.
+type AccountingCorrectionLog struct {
+	Id           primitive.ObjectID `bson:"_id" json:"id"`
+	CorrectionId string             `bson:"correction_id" json:"correction_id"`
+	Action       string             `bson:"action" json:"action"`
+	UserId       string             `bson:"user_id" json:"user_id"`
+	Comment      string             `bson:"comment" json:"comment"`
+	// Status is the status of the correction after the action.
+	Status    string    `bson:"status" json:"status"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+type CreateAccountingCorrectionRequest struct {
+	MerchantId string `json:"merchant_id"`
+	// RoyaltyReportId is optional, the correction is posted to the next royalty report of the merchant if it is empty.
+	RoyaltyReportId string                            `json:"royalty_report_id"`
+	Type            string                            `json:"type"`
+	Amount          float64                           `json:"amount"`
+	ReasonCode      string                            `json:"reason_code"`
+	Comment         string                            `json:"comment"`
+	Attachments     []*AccountingCorrectionAttachment `json:"attachments"`
+	UserId          string                            `json:"user_id"`
+}
+
+type AccountingCorrectionActionRequest struct {
+	CorrectionId string `json:"correction_id"`
+	UserId       string `json:"user_id"`
+	Comment      string `json:"comment"`
+}
+
+type AccountingCorrectionResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *AccountingCorrection           `json:"item,omitempty"`
+}
+
+type ListAccountingCorrectionsRequest struct {
+	MerchantId      string   `json:"merchant_id"`
+	RoyaltyReportId string   `json:"royalty_report_id"`
+	Status          []string `json:"status"`
+	Offset          int64    `json:"offset"`
+	Limit           int64    `json:"limit"`
+}
+
+type ListAccountingCorrectionsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*AccountingCorrection         `json:"items"`
+}
+
+type GetAccountingCorrectionRequest struct {
+	CorrectionId string `json:"correction_id"`
+}
+
+type AccountingCorrectionLogResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*AccountingCorrectionLog      `json:"items"`
+}
@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// AccountingRebuildRequest selects orders which accounting entries are recomputed by order id or by merchant
+// and dates of payment. Entries are compared only unless Apply is set.
+type AccountingRebuildRequest struct {
+	OrderId    string `json:"order_id"`
+	MerchantId string `json:"merchant_id"`
+	DateFrom   int64  `json:"date_from"`
+	DateTo     int64  `json:"date_to"`
+	Apply      bool   `json:"apply"`
+}
+
+type AccountingRebuildResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *AccountingRebuild              `json:"item,omitempty"`
+}
+
+// AccountingRebuild is the result of recomputing of accounting entries.
+// Items contain only the sources which stored entries differ from the recomputed ones or which failed.
+type AccountingRebuild struct {
+	DryRun       bool                       `json:"dry_run"`
+	SourcesCount int32                      `json:"sources_count"`
+	ChangedCount int32                      `json:"changed_count"`
+	AppliedCount int32                      `json:"applied_count"`
+	FailedCount  int32                      `json:"failed_count"`
+	Items        []*AccountingRebuildSource `json:"items"`
+}
+
+// AccountingRebuildSource contains differences of entries of one accounting event source (order or refund).
+type AccountingRebuildSource struct {
+	OrderId    string                 `json:"order_id"`
+	SourceId   string                 `json:"source_id"`
+	SourceType string                 `json:"source_type"`
+	Entries    []*AccountingEntryDiff `json:"entries"`
+	Applied    bool                   `json:"applied"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// AccountingEntryDiff is the difference of the entry. EntryId is the id of the stored entry,
+// it is empty for the entry which is added by the rebuild.
+type AccountingEntryDiff struct {
+	EntryId string                      `json:"entry_id,omitempty"`
+	Type    string                      `json:"type"`
+	Action  string                      `json:"action"`
+	Fields  []*AccountingEntryFieldDiff `json:"fields"`
+}
+
+type AccountingEntryFieldDiff struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
@@ -0,0 +1,132 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// BillingDocument is the accounting document issued to the merchant by the operating company: the monthly act
+// of completion, the monthly invoice of commission, the credit note or the debit note. Number is sequential
+// per operating company and type of document. Documents are immutable, Hash is the SHA-256 hash of the content
+// of the document calculated on issue and FileHash is the SHA-256 hash of the PDF file rendered by the reporter service.
+// Documents are never changed after issue, corrections are issued as credit and debit notes which refer
+// to the original invoice with OriginalDocumentId or to the original royalty report with OriginalRoyaltyReportId.
+// PayoutAmount of the note is the amount payable to the merchant, it is positive for credit notes and negative
+// for debit notes. The note is posted as the royalty correction of the merchant and settled with the royalty report
+// which includes the correction.
+type BillingDocument struct {
+	Id                      primitive.ObjectID     `bson:"_id" json:"id"`
+	Type                    string                 `bson:"type" json:"type"`
+	Number                  string                 `bson:"number" json:"number"`
+	Sequence                int64                  `bson:"sequence" json:"sequence"`
+	MerchantId              string                 `bson:"merchant_id" json:"merchant_id"`
+	OperatingCompanyId      string                 `bson:"operating_company_id" json:"operating_company_id"`
+	OriginalDocumentId      string                 `bson:"original_document_id" json:"original_document_id"`
+	OriginalRoyaltyReportId string                 `bson:"original_royalty_report_id" json:"original_royalty_report_id"`
+	DateFrom                time.Time              `bson:"date_from" json:"date_from"`
+	DateTo                  time.Time              `bson:"date_to" json:"date_to"`
+	Currency                string                 `bson:"currency" json:"currency"`
+	TransactionsCount       int32                  `bson:"transactions_count" json:"transactions_count"`
+	PayoutAmount            float64                `bson:"payout_amount" json:"payout_amount"`
+	CorrectionsAmount       float64                `bson:"corrections_amount" json:"corrections_amount"`
+	Balance                 float64                `bson:"balance" json:"balance"`
+	NetAmount               float64                `bson:"net_amount" json:"net_amount"`
+	VatRate                 float64                `bson:"vat_rate" json:"vat_rate"`
+	VatAmount               float64                `bson:"vat_amount" json:"vat_amount"`
+	TotalAmount             float64                `bson:"total_amount" json:"total_amount"`
+	VatTreatment            string                 `bson:"vat_treatment" json:"vat_treatment"`
+	Lines                   []*BillingDocumentLine `bson:"lines" json:"lines"`
+	Reason                  string                 `bson:"reason" json:"reason"`
+	Hash                    string                 `bson:"hash" json:"hash"`
+	FileName                string                 `bson:"file_name" json:"file_name"`
+	File                    []byte                 `bson:"file" json:"-"`
+	FileHash                string                 `bson:"file_hash" json:"file_hash"`
+	CreatedBy               string                 `bson:"created_by" json:"created_by"`
+	CreatedAt               time.Time              `bson:"created_at" json:"created_at"`
+}
+
+// BillingDocumentLine is the line item of the credit or debit note.
+type BillingDocumentLine struct {
+	Description string  `bson:"description" json:"description"`
+	NetAmount   float64 `bson:"net_amount" json:"net_amount"`
+	VatRate     float64 `bson:"vat_rate" json:"vat_rate"`
+	VatAmount   float64 `bson:"vat_amount" json:"vat_amount"`
+	TotalAmount float64 `bson:"total_amount" json:"total_amount"`
+}
+
+// GenerateBillingDocumentsRequest issues the act of completion and the invoice of commission of the merchant
+// for the ended month.
+type GenerateBillingDocumentsRequest struct {
+	MerchantId string `json:"merchant_id"`
+	Year       int32  `json:"year"`
+	Month      int32  `json:"month"`
+	UserId     string `json:"user_id"`
+}
+
+type GenerateBillingDocumentsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*BillingDocument              `json:"items,omitempty"`
+}
+
+type GetBillingDocumentRequest struct {
+	DocumentId string `json:"document_id"`
+	MerchantId string `json:"merchant_id"`
+}
+
+type BillingDocumentResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *BillingDocument                `json:"item,omitempty"`
+}
+
+type ListBillingDocumentsRequest struct {
+	MerchantId string `json:"merchant_id"`
+	Type       string `json:"type"`
+	Offset     int64  `json:"offset"`
+	Limit      int64  `json:"limit"`
+}
+
+type ListBillingDocumentsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*BillingDocument              `json:"items,omitempty"`
+}
+
+// CreateBillingCreditNoteRequest issues the credit note to the act of completion or the invoice. NetAmount is
+// the amount of correction excluding VAT, VAT is charged with the rate of the original document.
+type CreateBillingCreditNoteRequest struct {
+	OriginalDocumentId string  `json:"original_document_id"`
+	NetAmount          float64 `json:"net_amount"`
+	Reason             string  `json:"reason"`
+	UserId             string  `json:"user_id"`
+}
+
+// CreateBillingNoteRequest issues the credit or debit note to the invoice or to the royalty report. One of
+// OriginalDocumentId and OriginalRoyaltyReportId is required, the merchant, the currency and the period of the note
+// are taken from the original. VAT of lines is charged with the rate of the original under the standard treatment,
+// VAT isn't charged under the reverse charge and exempt treatments.
+type CreateBillingNoteRequest struct {
+	Type                    string                   `json:"type"`
+	OriginalDocumentId      string                   `json:"original_document_id"`
+	OriginalRoyaltyReportId string                   `json:"original_royalty_report_id"`
+	VatTreatment            string                   `json:"vat_treatment"`
+	Lines                   []*CreateBillingNoteLine `json:"lines"`
+	Reason                  string                   `json:"reason"`
+	UserId                  string                   `json:"user_id"`
+}
+
+// CreateBillingNoteLine is the line item of the note, NetAmount is the amount excluding VAT.
+type CreateBillingNoteLine struct {
+	Description string  `json:"description"`
+	NetAmount   float64 `json:"net_amount"`
+}
+
+// BillingDocumentPdfUploadedRequest is sent by the reporter service with the rendered PDF file of the document.
+type BillingDocumentPdfUploadedRequest struct {
+	DocumentId string `json:"document_id"`
+	Filename   string `json:"filename"`
+	Content    []byte `json:"content"`
+}
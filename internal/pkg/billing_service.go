@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"context"
+	"github.com/micro/go-micro/server"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// BillingServiceExtHandler is the server API of the billing service methods which request and response types
+// are declared in this package. The methods are served by the billing service next to the BillingService
+// methods of the proto package under the BillingServiceExt endpoints. Request and response types aren't
+// protobuf messages, clients must call the methods with the application/json content type.
+type BillingServiceExtHandler interface {
+	GetRecurringSubscriptionState(context.Context, *RecurringSubscriptionRequest, *RecurringSubscriptionStateResponse) error
+	PauseRecurringSubscription(context.Context, *PauseRecurringSubscriptionRequest, *RecurringSubscriptionStateResponse) error
+	ResumeRecurringSubscription(context.Context, *RecurringSubscriptionRequest, *RecurringSubscriptionStateResponse) error
+	CancelRecurringSubscriptionAtPeriodEnd(context.Context, *RecurringSubscriptionRequest, *RecurringSubscriptionStateResponse) error
+	GetCardUpdateForm(context.Context, *CardUpdateFormRequest, *CardUpdateFormResponse) error
+	ProcessCardUpdate(context.Context, *CardUpdateRequest, *CardUpdateResponse) error
+	GetDashboardSubscriptionsReport(context.Context, *DashboardSubscriptionsReportRequest, *DashboardSubscriptionsReportResponse) error
+	ChargeSavedCard(context.Context, *ChargeSavedCardRequest, *ChargeSavedCardResponse) error
+	GetSavedCardChargeSettings(context.Context, *GetSavedCardChargeSettingsRequest, *SavedCardChargeSettingsResponse) error
+	SetSavedCardChargeSettings(context.Context, *SavedCardChargeSettings, *SavedCardChargeSettingsResponse) error
+	CreateRefundWithReason(context.Context, *CreateRefundWithReasonRequest, *billingpb.CreateRefundResponse) error
+	GetRefundReasons(context.Context, *billingpb.EmptyRequest, *RefundReasonsResponse) error
+	GetRefundAnalytics(context.Context, *RefundAnalyticsRequest, *RefundAnalyticsResponse) error
+	ListLedgerAccounts(context.Context, *ListLedgerAccountsRequest, *ListLedgerAccountsResponse) error
+	SetLedgerAccount(context.Context, *LedgerAccount, *LedgerAccountResponse) error
+	GetTrialBalance(context.Context, *TrialBalanceRequest, *TrialBalanceResponse) error
+	GetLedgerAccountStatement(context.Context, *LedgerAccountStatementRequest, *LedgerAccountStatementResponse) error
+	ListMerchantBalanceTransactions(context.Context, *ListMerchantBalanceTransactionsRequest, *ListMerchantBalanceTransactionsResponse) error
+	GetMerchantBalanceAsOf(context.Context, *MerchantBalanceAsOfRequest, *MerchantBalanceAsOfResponse) error
+	GetRollingReservePolicy(context.Context, *GetRollingReservePolicyRequest, *RollingReservePolicyResponse) error
+	SetRollingReservePolicy(context.Context, *RollingReservePolicy, *RollingReservePolicyResponse) error
+	GetRollingReserveSchedule(context.Context, *RollingReserveScheduleRequest, *RollingReserveScheduleResponse) error
+	CreateAccountingCorrection(context.Context, *CreateAccountingCorrectionRequest, *AccountingCorrectionResponse) error
+	ApproveAccountingCorrection(context.Context, *AccountingCorrectionActionRequest, *AccountingCorrectionResponse) error
+	RejectAccountingCorrection(context.Context, *AccountingCorrectionActionRequest, *AccountingCorrectionResponse) error
+	ReverseAccountingCorrection(context.Context, *AccountingCorrectionActionRequest, *AccountingCorrectionResponse) error
+	GetAccountingCorrection(context.Context, *GetAccountingCorrectionRequest, *AccountingCorrectionResponse) error
+	ListAccountingCorrections(context.Context, *ListAccountingCorrectionsRequest, *ListAccountingCorrectionsResponse) error
+	GetAccountingCorrectionLog(context.Context, *GetAccountingCorrectionRequest, *AccountingCorrectionLogResponse) error
+	GetLedgerExport(context.Context, *LedgerExportRequest, *LedgerExportResponse) error
+	CreateLedgerExportFile(context.Context, *LedgerExportRequest, *CreateLedgerExportFileResponse) error
+	GetFxRevaluationReport(context.Context, *FxRevaluationReportRequest, *FxRevaluationReportResponse) error
+	RebuildAccounting(context.Context, *AccountingRebuildRequest, *AccountingRebuildResponse) error
+	GetRoyaltyReportSchedule(context.Context, *GetRoyaltyReportScheduleRequest, *RoyaltyReportScheduleResponse) error
+	SetRoyaltyReportSchedule(context.Context, *RoyaltyReportSchedule, *RoyaltyReportScheduleResponse) error
+	OpenRoyaltyReportDispute(context.Context, *OpenRoyaltyReportDisputeRequest, *RoyaltyReportDisputeResponse) error
+	AddRoyaltyReportDisputeComment(context.Context, *RoyaltyReportDisputeCommentRequest, *RoyaltyReportDisputeResponse) error
+	ResolveRoyaltyReportDispute(context.Context, *ResolveRoyaltyReportDisputeRequest, *RoyaltyReportDisputeResponse) error
+	ListRoyaltyReportDisputes(context.Context, *ListRoyaltyReportDisputesRequest, *ListRoyaltyReportDisputesResponse) error
+	CreatePayoutBatch(context.Context, *CreatePayoutBatchRequest, *PayoutBatchResponse) error
+	GetPayoutBatch(context.Context, *GetPayoutBatchRequest, *PayoutBatchResponse) error
+	ListPayoutBatches(context.Context, *ListPayoutBatchesRequest, *ListPayoutBatchesResponse) error
+	ImportPayoutBatchStatusReport(context.Context, *ImportPayoutBatchStatusReportRequest, *PayoutBatchResponse) error
+	GetMerchantPayoutSchedule(context.Context, *GetMerchantPayoutScheduleRequest, *MerchantPayoutScheduleResponse) error
+	SetMerchantPayoutSchedule(context.Context, *MerchantPayoutSchedule, *MerchantPayoutScheduleResponse) error
+	GenerateOssReturn(context.Context, *GenerateOssReturnRequest, *OssReturnResponse) error
+	GetOssReturn(context.Context, *GetOssReturnRequest, *OssReturnResponse) error
+	ListOssReturns(context.Context, *ListOssReturnsRequest, *ListOssReturnsResponse) error
+	UpdateOssReturnStatus(context.Context, *UpdateOssReturnStatusRequest, *OssReturnResponse) error
+	GetSalesTaxNexusReport(context.Context, *GetSalesTaxNexusReportRequest, *SalesTaxNexusReportResponse) error
+	SetOrderVatId(context.Context, *SetOrderVatIdRequest, *SetOrderVatIdResponse) error
+	GetVatReportReverseCharge(context.Context, *GetVatReportReverseChargeRequest, *VatReportReverseChargeResponse) error
+	GetVatReportHistory(context.Context, *GetVatReportHistoryRequest, *VatReportHistoryResponse) error
+	AddTaxRateTable(context.Context, *AddTaxRateTableRequest, *TaxRateTableResponse) error
+	GetTaxRateTable(context.Context, *GetTaxRateTableRequest, *TaxRateTableResponse) error
+	GetOrderTaxEvidence(context.Context, *GetOrderTaxEvidenceRequest, *OrderTaxEvidenceResponse) error
+	GetVatReportTaxEvidence(context.Context, *VatReportTaxEvidenceRequest, *VatReportTaxEvidenceResponse) error
+	CreateVatReportTaxEvidenceFile(context.Context, *VatReportTaxEvidenceRequest, *CreateVatReportTaxEvidenceFileResponse) error
+	GenerateBillingDocuments(context.Context, *GenerateBillingDocumentsRequest, *GenerateBillingDocumentsResponse) error
+	GetBillingDocument(context.Context, *GetBillingDocumentRequest, *BillingDocumentResponse) error
+	ListBillingDocuments(context.Context, *ListBillingDocumentsRequest, *ListBillingDocumentsResponse) error
+	CreateBillingCreditNote(context.Context, *CreateBillingCreditNoteRequest, *BillingDocumentResponse) error
+	BillingDocumentPdfUploaded(context.Context, *BillingDocumentPdfUploadedRequest, *BillingDocumentResponse) error
+	CreateBillingNote(context.Context, *CreateBillingNoteRequest, *BillingDocumentResponse) error
+	ListMerchantPayoutAccounts(context.Context, *ListMerchantPayoutAccountsRequest, *ListMerchantPayoutAccountsResponse) error
+	SetMerchantPayoutAccount(context.Context, *MerchantPayoutAccount, *MerchantPayoutAccountResponse) error
+	DeleteMerchantPayoutAccount(context.Context, *DeleteMerchantPayoutAccountRequest, *MerchantPayoutAccountResponse) error
+	ListMerchantBalances(context.Context, *ListMerchantBalancesRequest, *ListMerchantBalancesResponse) error
+}
+
+// RegisterBillingServiceExtHandler registers methods of the handler in the server.
+func RegisterBillingServiceExtHandler(s server.Server, hdlr BillingServiceExtHandler, opts ...server.HandlerOption) error {
+	type BillingServiceExt struct {
+		BillingServiceExtHandler
+	}
+	return s.Handle(s.NewHandler(&BillingServiceExt{hdlr}, opts...))
+}
@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// CardExpiry contains expiration data of the card saved by customer or used for recurring subscription.
+// Recurring repository service can't search cards by expiration date, so billing server tracks it by itself
+// to notify customers about expiring cards before payments start failing.
+type CardExpiry struct {
+	Id             primitive.ObjectID `bson:"_id" json:"id"`
+	CustomerId     string             `bson:"customer_id" json:"customer_id"`
+	ProjectId      string             `bson:"project_id" json:"project_id"`
+	MerchantId     string             `bson:"merchant_id" json:"merchant_id"`
+	OrderId        string             `bson:"order_id" json:"order_id"`
+	SubscriptionId string             `bson:"subscription_id" json:"subscription_id"`
+	RecurringId    string             `bson:"recurring_id" json:"recurring_id"`
+	MaskedPan      string             `bson:"masked_pan" json:"masked_pan"`
+	CardHolder     string             `bson:"card_holder" json:"card_holder"`
+	Email          string             `bson:"email" json:"email"`
+	Locale         string             `bson:"locale" json:"locale"`
+	Currency       string             `bson:"currency" json:"currency"`
+	ExpireMonth    string             `bson:"expire_month" json:"expire_month"`
+	ExpireYear     string             `bson:"expire_year" json:"expire_year"`
+	// ExpireAt is the last moment when card is valid, i.e. the end of expiration month.
+	ExpireAt            time.Time `bson:"expire_at" json:"expire_at"`
+	NotifiedAt          time.Time `bson:"notified_at" json:"notified_at"`
+	UpdateToken         string    `bson:"update_token" json:"-"`
+	UpdateTokenExpireAt time.Time `bson:"update_token_expire_at" json:"-"`
+	CreatedAt           time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt           time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// CardExpiryUpdate is a new expiration date of the card received from the acquirer's account updater.
+type CardExpiryUpdate struct {
+	RecurringId string
+	MaskedPan   string
+	ExpireMonth string
+	ExpireYear  string
+}
+
+type CardUpdateFormRequest struct {
+	Token string `json:"token"`
+}
+
+// CardUpdateForm contains data to render card-only payment form for zero-amount card verification.
+type CardUpdateForm struct {
+	MaskedPan   string  `json:"masked_pan"`
+	ExpireMonth string  `json:"expire_month"`
+	ExpireYear  string  `json:"expire_year"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+}
+
+type CardUpdateFormResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *CardUpdateForm                 `json:"item,omitempty"`
+}
+
+type CardUpdateRequest struct {
+	Token  string `json:"token"`
+	Pan    string `json:"pan"`
+	Cvv    string `json:"cvv"`
+	Month  string `json:"month"`
+	Year   string `json:"year"`
+	Holder string `json:"card_holder"`
+}
+
+type CardUpdateResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+}
@@ -0,0 +1,47 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+type DashboardSubscriptionsReportRequest struct {
+	MerchantId string `json:"merchant_id"`
+	// ProjectId is optional, report is built by all merchant's projects if it is empty.
+	ProjectId string `json:"project_id"`
+	Period    string `json:"period"`
+}
+
+type DashboardSubscriptionsReportResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *DashboardSubscriptionsReport   `json:"item,omitempty"`
+}
+
+// DashboardSubscriptionsReport contains recurring revenue metrics of the merchant for the requested period.
+// All amounts are monthly normalized and specified in the merchant's payout currency.
+type DashboardSubscriptionsReport struct {
+	Currency string `json:"currency" bson:"currency"`
+	// Mrr is monthly recurring revenue at the end of the period, MrrPrevious is the same at the beginning of the period.
+	Mrr            float64 `json:"mrr" bson:"mrr"`
+	MrrPrevious    float64 `json:"mrr_previous" bson:"mrr_previous"`
+	Arr            float64 `json:"arr" bson:"arr"`
+	NewMrr         float64 `json:"new_mrr" bson:"new_mrr"`
+	ExpansionMrr   float64 `json:"expansion_mrr" bson:"expansion_mrr"`
+	ContractionMrr float64 `json:"contraction_mrr" bson:"contraction_mrr"`
+	ChurnedMrr     float64 `json:"churned_mrr" bson:"churned_mrr"`
+
+	ActiveSubscribers         int64 `json:"active_subscribers" bson:"active_subscribers"`
+	ActiveSubscribersPrevious int64 `json:"active_subscribers_previous" bson:"active_subscribers_previous"`
+	NewSubscribers            int64 `json:"new_subscribers" bson:"new_subscribers"`
+	ChurnedSubscribers        int64 `json:"churned_subscribers" bson:"churned_subscribers"`
+	// InvoluntaryChurnedSubscribers is a number of churned subscribers which last renewal payment was failed.
+	InvoluntaryChurnedSubscribers int64 `json:"involuntary_churned_subscribers" bson:"involuntary_churned_subscribers"`
+
+	RenewalAttempts   int64 `json:"renewal_attempts" bson:"renewal_attempts"`
+	RenewalSuccessful int64 `json:"renewal_successful" bson:"renewal_successful"`
+
+	// Rates are specified in percents.
+	ChurnRate            float64 `json:"churn_rate" bson:"churn_rate"`
+	InvoluntaryChurnRate float64 `json:"involuntary_churn_rate" bson:"involuntary_churn_rate"`
+	RenewalSuccessRate   float64 `json:"renewal_success_rate" bson:"renewal_success_rate"`
+}
@@ -65,6 +65,12 @@ type ReserveQueryResItem struct {
 	Amount float64 `bson:"amount"`
 }
 
+type LedgerVatQueryResItem struct {
+	Country       string  `bson:"country"`
+	LocalCurrency string  `bson:"local_currency"`
+	Amount        float64 `bson:"amount"`
+}
+
 type VatReportQueryResItem struct {
 	Id                             string  `bson:"_id"`
 	Count                          int32   `bson:"count"`
@@ -81,6 +87,12 @@ type TurnoverQueryResItem struct {
 	Amount float64 `bson:"amount"`
 }
 
+type SalesTaxNexusQueryResItem struct {
+	Id     string  `bson:"_id"`
+	Count  int64   `bson:"count"`
+	Amount float64 `bson:"amount"`
+}
+
 type RoyaltyReportMerchant struct {
 	Id primitive.ObjectID `bson:"_id"`
 }
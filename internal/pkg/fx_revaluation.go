@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// FxRevaluation is the revaluation of the merchant's position in the foreign currency to the functional currency
+// of the operating company.
+// Unrealized revaluation is made at the end of month for the open balance or rolling reserve at the closing rate,
+// realized revaluation is made on payout settlement for the paid amount at the rate of the settlement date.
+// CarryingAmount is the amount of the position in the functional currency before the revaluation,
+// FunctionalAmount is the amount after it. GainAmount is positive when the liability to the merchant decreased.
+type FxRevaluation struct {
+	Id                 primitive.ObjectID `bson:"_id" json:"id"`
+	OperatingCompanyId string             `bson:"operating_company_id" json:"operating_company_id"`
+	MerchantId         string             `bson:"merchant_id" json:"merchant_id"`
+	Currency           string             `bson:"currency" json:"currency"`
+	FunctionalCurrency string             `bson:"functional_currency" json:"functional_currency"`
+	Type               string             `bson:"type" json:"type"`
+	PositionType       string             `bson:"position_type" json:"position_type"`
+	SourceId           string             `bson:"source_id" json:"source_id"`
+	Amount             float64            `bson:"amount" json:"amount"`
+	Rate               float64            `bson:"rate" json:"rate"`
+	CarryingAmount     float64            `bson:"carrying_amount" json:"carrying_amount"`
+	FunctionalAmount   float64            `bson:"functional_amount" json:"functional_amount"`
+	GainAmount         float64            `bson:"gain_amount" json:"gain_amount"`
+	JournalId          string             `bson:"journal_id" json:"journal_id"`
+	Date               time.Time          `bson:"date" json:"date"`
+	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type FxRevaluationReportRequest struct {
+	OperatingCompanyId string `json:"operating_company_id"`
+	DateFrom           int64  `json:"date_from"`
+	DateTo             int64  `json:"date_to"`
+}
+
+type FxRevaluationReportResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *FxRevaluationReport            `json:"item,omitempty"`
+}
+
+// FxRevaluationReport contains realized and unrealized currency exchange gains of the operating company
+// for the period in the functional currency.
+type FxRevaluationReport struct {
+	OperatingCompanyId string                     `json:"operating_company_id"`
+	FunctionalCurrency string                     `json:"functional_currency"`
+	Items              []*FxRevaluationReportItem `json:"items"`
+	UnrealizedAmount   float64                    `json:"unrealized_amount"`
+	RealizedAmount     float64                    `json:"realized_amount"`
+	TotalAmount        float64                    `json:"total_amount"`
+}
+
+// FxRevaluationReportItem contains gains of revaluations of one type for positions in one currency.
+type FxRevaluationReportItem struct {
+	Type       string  `bson:"type" json:"type"`
+	Currency   string  `bson:"currency" json:"currency"`
+	Count      int32   `bson:"count" json:"count"`
+	Amount     float64 `bson:"amount" json:"amount"`
+	GainAmount float64 `bson:"gain_amount" json:"gain_amount"`
+}
+
+// MerchantBalanceCurrency is the currency in which the merchant has balance transactions.
+type MerchantBalanceCurrency struct {
+	MerchantId string `bson:"merchant_id" json:"merchant_id"`
+	Currency   string `bson:"currency" json:"currency"`
+}
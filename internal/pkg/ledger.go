@@ -0,0 +1,128 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// LedgerAccount is the account of the chart of accounts of the operating company.
+// ErpCode is the code of the account in the ERP of the operating company, Code is exported if it is empty.
+type LedgerAccount struct {
+	Id                 primitive.ObjectID `bson:"_id" json:"id"`
+	OperatingCompanyId string             `bson:"operating_company_id" json:"operating_company_id"`
+	Code               string             `bson:"code" json:"code"`
+	Name               string             `bson:"name" json:"name"`
+	Type               string             `bson:"type" json:"type"`
+	ErpCode            string             `bson:"erp_code" json:"erp_code"`
+	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt          time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// LedgerJournal is the balanced posting of the accounting event to the general ledger.
+type LedgerJournal struct {
+	Id                 primitive.ObjectID   `bson:"_id" json:"id"`
+	OperatingCompanyId string               `bson:"operating_company_id" json:"operating_company_id"`
+	MerchantId         string               `bson:"merchant_id" json:"merchant_id"`
+	EventType          string               `bson:"event_type" json:"event_type"`
+	SourceId           string               `bson:"source_id" json:"source_id"`
+	SourceType         string               `bson:"source_type" json:"source_type"`
+	Lines              []*LedgerJournalLine `bson:"lines" json:"lines"`
+	// Date is the date of the accounting event, CreatedAt is the date of the posting.
+	Date      time.Time `bson:"date" json:"date"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// LedgerJournalLine is the debit or credit leg of the journal made by the accounting entry.
+type LedgerJournalLine struct {
+	AccountCode string  `bson:"account_code" json:"account_code"`
+	EntryId     string  `bson:"entry_id" json:"entry_id"`
+	EntryType   string  `bson:"entry_type" json:"entry_type"`
+	Currency    string  `bson:"currency" json:"currency"`
+	Debit       float64 `bson:"debit" json:"debit"`
+	Credit      float64 `bson:"credit" json:"credit"`
+	Reason      string  `bson:"reason,omitempty" json:"reason,omitempty"`
+}
+
+type ListLedgerAccountsRequest struct {
+	OperatingCompanyId string `json:"operating_company_id"`
+}
+
+type ListLedgerAccountsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*LedgerAccount                `json:"items"`
+}
+
+type LedgerAccountResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *LedgerAccount                  `json:"item,omitempty"`
+}
+
+type TrialBalanceRequest struct {
+	OperatingCompanyId string `json:"operating_company_id"`
+	DateFrom           int64  `json:"date_from"`
+	DateTo             int64  `json:"date_to"`
+}
+
+type TrialBalanceResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*TrialBalanceItem             `json:"items"`
+}
+
+// TrialBalanceItem contains turnovers of the account in one currency for the period of the trial balance.
+// Balance is positive when it is on the normal side of the account.
+type TrialBalanceItem struct {
+	AccountCode string  `bson:"account_code" json:"account_code"`
+	AccountName string  `bson:"-" json:"account_name"`
+	AccountType string  `bson:"-" json:"account_type"`
+	Currency    string  `bson:"currency" json:"currency"`
+	Debit       float64 `bson:"debit" json:"debit"`
+	Credit      float64 `bson:"credit" json:"credit"`
+	Balance     float64 `bson:"-" json:"balance"`
+}
+
+type LedgerAccountStatementRequest struct {
+	OperatingCompanyId string `json:"operating_company_id"`
+	AccountCode        string `json:"account_code"`
+	Currency           string `json:"currency"`
+	// MerchantId is optional, statement is built by all merchants if it is empty.
+	MerchantId string `json:"merchant_id"`
+	DateFrom   int64  `json:"date_from"`
+	DateTo     int64  `json:"date_to"`
+}
+
+type LedgerAccountStatementResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *LedgerAccountStatement         `json:"item,omitempty"`
+}
+
+type LedgerAccountStatement struct {
+	Account        *LedgerAccount                `json:"account"`
+	Currency       string                        `json:"currency"`
+	OpeningBalance float64                       `json:"opening_balance"`
+	ClosingBalance float64                       `json:"closing_balance"`
+	Items          []*LedgerAccountStatementItem `json:"items"`
+}
+
+type LedgerAccountStatementItem struct {
+	JournalId  string    `bson:"journal_id" json:"journal_id"`
+	Date       time.Time `bson:"date" json:"date"`
+	EventType  string    `bson:"event_type" json:"event_type"`
+	SourceId   string    `bson:"source_id" json:"source_id"`
+	SourceType string    `bson:"source_type" json:"source_type"`
+	MerchantId string    `bson:"merchant_id" json:"merchant_id"`
+	EntryType  string    `bson:"entry_type" json:"entry_type"`
+	Debit      float64   `bson:"debit" json:"debit"`
+	Credit     float64   `bson:"credit" json:"credit"`
+	Balance    float64   `bson:"-" json:"balance"`
+}
+
+// LedgerTurnover is the sum of debit and credit legs of the account.
+type LedgerTurnover struct {
+	Debit  float64 `bson:"debit"`
+	Credit float64 `bson:"credit"`
+}
@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+type LedgerExportRequest struct {
+	OperatingCompanyId string `json:"operating_company_id"`
+	Format             string `json:"format"`
+	DateFrom           int64  `json:"date_from"`
+	DateTo             int64  `json:"date_to"`
+	// UserId is the user who gets notification when the file is created by the reporter service.
+	UserId string `json:"user_id"`
+	// DatevConsultantNumber and DatevClientNumber are required by the header of DATEV file only.
+	DatevConsultantNumber int32 `json:"datev_consultant_number"`
+	DatevClientNumber     int32 `json:"datev_client_number"`
+}
+
+type LedgerExportResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *LedgerExport                   `json:"item,omitempty"`
+}
+
+type CreateLedgerExportFileResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+}
+
+// LedgerExport is the file with accounting entries of the operating company for the import to ERP.
+type LedgerExport struct {
+	FileName       string                        `json:"file_name"`
+	ContentType    string                        `json:"content_type"`
+	Content        []byte                        `json:"content"`
+	Totals         []*LedgerExportTotal          `json:"totals"`
+	Reconciliation []*LedgerExportReconciliation `json:"reconciliation"`
+}
+
+// LedgerExportTotal contains control totals of the exported entries in one currency.
+type LedgerExportTotal struct {
+	Currency     string  `json:"currency"`
+	EntriesCount int32   `json:"entries_count"`
+	Debit        float64 `json:"debit"`
+	Credit       float64 `json:"credit"`
+}
+
+// LedgerExportReconciliation compares the amount of the royalty or VAT reports of the export period
+// with the amount calculated from the exported accounting entries.
+type LedgerExportReconciliation struct {
+	Source       string  `json:"source"`
+	Country      string  `json:"country,omitempty"`
+	Currency     string  `json:"currency"`
+	ReportAmount float64 `json:"report_amount"`
+	LedgerAmount float64 `json:"ledger_amount"`
+	Difference   float64 `json:"difference"`
+	Reconciled   bool    `json:"reconciled"`
+}
@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// MerchantBalanceTransaction is the record of the append-only log of merchant's balance movements.
+type MerchantBalanceTransaction struct {
+	Id         primitive.ObjectID `bson:"_id" json:"id"`
+	MerchantId string             `bson:"merchant_id" json:"merchant_id"`
+	Currency   string             `bson:"currency" json:"currency"`
+	Type       string             `bson:"type" json:"type"`
+	Amount     float64            `bson:"amount" json:"amount"`
+	// Balance is the running balance of the merchant in the currency after the transaction. It isn't stored,
+	// it's calculated by the sum of preceding transactions on listing.
+	Balance    float64 `bson:"-" json:"balance"`
+	SourceId   string  `bson:"source_id" json:"source_id"`
+	SourceType string  `bson:"source_type" json:"source_type"`
+	// AvailableAt is the date when funds of the transaction become available for payout.
+	AvailableAt time.Time `bson:"available_at" json:"available_at"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+}
+
+type ListMerchantBalanceTransactionsRequest struct {
+	MerchantId string `json:"merchant_id"`
+	// Currency is optional, the merchant's payout currency is used if it is empty.
+	Currency string   `json:"currency"`
+	Types    []string `json:"types"`
+	DateFrom int64    `json:"date_from"`
+	DateTo   int64    `json:"date_to"`
+	Offset   int64    `json:"offset"`
+	Limit    int64    `json:"limit"`
+}
+
+type ListMerchantBalanceTransactionsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*MerchantBalanceTransaction   `json:"items"`
+}
+
+type MerchantBalanceAsOfRequest struct {
+	MerchantId string `json:"merchant_id"`
+	// Currency is optional, the merchant's payout currency is used if it is empty.
+	Currency string `json:"currency"`
+	// Date is optional, the current balance is returned if it is empty.
+	Date int64 `json:"date"`
+}
+
+type MerchantBalanceAsOfResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *MerchantBalanceAsOf            `json:"item,omitempty"`
+}
+
+// MerchantBalanceAsOf is the merchant's balance on the date.
+// Pending is the part of the balance which is not settled yet, Available is the part which may be paid out.
+type MerchantBalanceAsOf struct {
+	MerchantId string    `json:"merchant_id"`
+	Currency   string    `json:"currency"`
+	Date       time.Time `json:"date"`
+	Balance    float64   `json:"balance"`
+	Pending    float64   `json:"pending"`
+	Available  float64   `json:"available"`
+}
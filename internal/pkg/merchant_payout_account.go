@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// MerchantPayoutAccount is the additional account of the merchant for payouts in the currency of the account.
+// Orders in the currency of the account and in currencies of OrderCurrencies are routed to the account, other orders
+// are routed to the payout currency and banking details of the merchant. Royalty reports, the balance and payouts
+// of the merchant are calculated per account. Payouts of the account less than MinPayoutAmount are skipped,
+// the minimal payout amount of the tariff of the merchant in the currency of the account is used if it isn't set.
+type MerchantPayoutAccount struct {
+	Id              primitive.ObjectID         `bson:"_id" json:"id"`
+	MerchantId      string                     `bson:"merchant_id" json:"merchant_id"`
+	Currency        string                     `bson:"currency" json:"currency"`
+	Banking         *billingpb.MerchantBanking `bson:"banking" json:"banking"`
+	OrderCurrencies []string                   `bson:"order_currencies" json:"order_currencies"`
+	MinPayoutAmount float64                    `bson:"min_payout_amount" json:"min_payout_amount"`
+	CreatedAt       time.Time                  `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time                  `bson:"updated_at" json:"updated_at"`
+}
+
+type ListMerchantPayoutAccountsRequest struct {
+	MerchantId string `json:"merchant_id"`
+}
+
+type ListMerchantPayoutAccountsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*MerchantPayoutAccount        `json:"items,omitempty"`
+}
+
+type MerchantPayoutAccountResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *MerchantPayoutAccount          `json:"item,omitempty"`
+}
+
+type DeleteMerchantPayoutAccountRequest struct {
+	MerchantId string `json:"merchant_id"`
+	Currency   string `json:"currency"`
+}
+
+type ListMerchantBalancesRequest struct {
+	MerchantId string `json:"merchant_id"`
+}
+
+// ListMerchantBalancesResponse contains balances of the merchant in the payout currency of the merchant
+// and in currencies of payout accounts of the merchant.
+type ListMerchantBalancesResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*billingpb.MerchantBalance    `json:"items,omitempty"`
+}
@@ -0,0 +1,35 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// MerchantPayoutSchedule determines when payouts of the merchant are created automatically.
+// Weekly payouts are created on WeekDay, monthly payouts on the first day of month and payouts by day of month
+// on MonthDay (or on the last day of shorter months). Payouts less than MinPayoutAmount are skipped and the amount
+// is carried over to the next payout. Payouts aren't created while the schedule is on hold.
+type MerchantPayoutSchedule struct {
+	Id              primitive.ObjectID `bson:"_id" json:"id"`
+	MerchantId      string             `bson:"merchant_id" json:"merchant_id"`
+	Frequency       string             `bson:"frequency" json:"frequency"`
+	WeekDay         int32              `bson:"week_day" json:"week_day"`
+	MonthDay        int32              `bson:"month_day" json:"month_day"`
+	MinPayoutAmount float64            `bson:"min_payout_amount" json:"min_payout_amount"`
+	Hold            bool               `bson:"hold" json:"hold"`
+	HoldReason      string             `bson:"hold_reason" json:"hold_reason"`
+	LastRunAt       time.Time          `bson:"last_run_at" json:"last_run_at"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type GetMerchantPayoutScheduleRequest struct {
+	MerchantId string `json:"merchant_id"`
+}
+
+type MerchantPayoutScheduleResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *MerchantPayoutSchedule         `json:"item,omitempty"`
+}
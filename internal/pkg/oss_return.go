@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// OssReturn is the quarterly VAT return of the operating company under the EU One-Stop-Shop scheme.
+// The return aggregates VAT reports of EU member states for the quarter by member state of consumption
+// and rate type, and declares corrections of returns for prior quarters. All amounts are in EUR.
+// The return is filed with the tax authority by hand with the XML file and the status of the return
+// is changed accordingly. Payment of the return marks the underlying VAT reports as paid.
+type OssReturn struct {
+	Id                 primitive.ObjectID     `bson:"_id" json:"id"`
+	OperatingCompanyId string                 `bson:"operating_company_id" json:"operating_company_id"`
+	Scheme             string                 `bson:"scheme" json:"scheme"`
+	Year               int32                  `bson:"year" json:"year"`
+	Quarter            int32                  `bson:"quarter" json:"quarter"`
+	DateFrom           time.Time              `bson:"date_from" json:"date_from"`
+	DateTo             time.Time              `bson:"date_to" json:"date_to"`
+	Currency           string                 `bson:"currency" json:"currency"`
+	Status             string                 `bson:"status" json:"status"`
+	Lines              []*OssReturnLine       `bson:"lines" json:"lines"`
+	Corrections        []*OssReturnCorrection `bson:"corrections" json:"corrections"`
+	VatReportIds       []string               `bson:"vat_report_ids" json:"vat_report_ids"`
+	TotalVatAmount     float64                `bson:"total_vat_amount" json:"total_vat_amount"`
+	Reference          string                 `bson:"reference" json:"reference"`
+	FileName           string                 `bson:"file_name" json:"file_name"`
+	File               []byte                 `bson:"file" json:"file"`
+	CreatedBy          string                 `bson:"created_by" json:"created_by"`
+	CreatedAt          time.Time              `bson:"created_at" json:"created_at"`
+	UpdatedAt          time.Time              `bson:"updated_at" json:"updated_at"`
+	SubmittedAt        time.Time              `bson:"submitted_at" json:"submitted_at"`
+	PaidAt             time.Time              `bson:"paid_at" json:"paid_at"`
+}
+
+// OssReturnLine is the total of supplies to consumers of the member state taxed with the VAT rate.
+type OssReturnLine struct {
+	Country           string  `bson:"country" json:"country"`
+	RateType          string  `bson:"rate_type" json:"rate_type"`
+	VatRate           float64 `bson:"vat_rate" json:"vat_rate"`
+	TransactionsCount int32   `bson:"transactions_count" json:"transactions_count"`
+	TaxableAmount     float64 `bson:"taxable_amount" json:"taxable_amount"`
+	VatAmount         float64 `bson:"vat_amount" json:"vat_amount"`
+}
+
+// OssReturnCorrection is the change of VAT of the member state declared by the return for the prior quarter.
+type OssReturnCorrection struct {
+	Country   string  `bson:"country" json:"country"`
+	Year      int32   `bson:"year" json:"year"`
+	Quarter   int32   `bson:"quarter" json:"quarter"`
+	VatAmount float64 `bson:"vat_amount" json:"vat_amount"`
+}
+
+type GenerateOssReturnRequest struct {
+	OperatingCompanyId string `json:"operating_company_id"`
+	Year               int32  `json:"year"`
+	Quarter            int32  `json:"quarter"`
+	UserId             string `json:"user_id"`
+}
+
+type GetOssReturnRequest struct {
+	ReturnId string `json:"return_id"`
+}
+
+type ListOssReturnsRequest struct {
+	OperatingCompanyId string   `json:"operating_company_id"`
+	Year               int32    `json:"year"`
+	Status             []string `json:"status"`
+	Offset             int64    `json:"offset"`
+	Limit              int64    `json:"limit"`
+}
+
+// UpdateOssReturnStatusRequest changes the filing status of the return. Reference is the number
+// of the submission assigned by the tax authority.
+type UpdateOssReturnStatusRequest struct {
+	ReturnId  string `json:"return_id"`
+	Status    string `json:"status"`
+	Reference string `json:"reference"`
+}
+
+type OssReturnResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *OssReturn                      `json:"item,omitempty"`
+}
+
+type ListOssReturnsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*OssReturn                    `json:"items"`
+}
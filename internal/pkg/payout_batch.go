@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// PayoutBatch is the set of pending payouts of the operating company in one currency which are sent to the bank
+// as one payment file. The file is SEPA credit transfer initiation (ISO 20022 pain.001) for payouts in EUR
+// and CSV with SWIFT transfers for other currencies. Statuses of the items are updated on import
+// of the payment status report (ISO 20022 pain.002) received from the bank.
+type PayoutBatch struct {
+	Id                       primitive.ObjectID `bson:"_id" json:"id"`
+	OperatingCompanyId       string             `bson:"operating_company_id" json:"operating_company_id"`
+	Currency                 string             `bson:"currency" json:"currency"`
+	Format                   string             `bson:"format" json:"format"`
+	Status                   string             `bson:"status" json:"status"`
+	MessageId                string             `bson:"message_id" json:"message_id"`
+	DebtorAccount            string             `bson:"debtor_account" json:"debtor_account"`
+	DebtorSwift              string             `bson:"debtor_swift" json:"debtor_swift"`
+	ExecutionDate            time.Time          `bson:"execution_date" json:"execution_date"`
+	Items                    []*PayoutBatchItem `bson:"items" json:"items"`
+	SkippedPayoutDocumentIds []string           `bson:"skipped_payout_document_ids" json:"skipped_payout_document_ids"`
+	TotalAmount              float64            `bson:"total_amount" json:"total_amount"`
+	FileName                 string             `bson:"file_name" json:"file_name"`
+	File                     []byte             `bson:"file" json:"file"`
+	CreatedBy                string             `bson:"created_by" json:"created_by"`
+	CreatedAt                time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt                time.Time          `bson:"updated_at" json:"updated_at"`
+	ProcessedAt              time.Time          `bson:"processed_at" json:"processed_at"`
+}
+
+// PayoutBatchItem is the transfer of the payout in the payment file. EndToEndId identifies the transfer
+// in status reports of the bank, Status is the status of the payout document after the import of the report.
+type PayoutBatchItem struct {
+	PayoutDocumentId string  `bson:"payout_document_id" json:"payout_document_id"`
+	MerchantId       string  `bson:"merchant_id" json:"merchant_id"`
+	EndToEndId       string  `bson:"end_to_end_id" json:"end_to_end_id"`
+	Amount           float64 `bson:"amount" json:"amount"`
+	CreditorName     string  `bson:"creditor_name" json:"creditor_name"`
+	CreditorAccount  string  `bson:"creditor_account" json:"creditor_account"`
+	CreditorSwift    string  `bson:"creditor_swift" json:"creditor_swift"`
+	Status           string  `bson:"status" json:"status"`
+	StatusCode       string  `bson:"status_code" json:"status_code"`
+	StatusMessage    string  `bson:"status_message" json:"status_message"`
+}
+
+// CreatePayoutBatchRequest selects pending payouts of the operating company in the currency for the batch.
+// All pending payouts which are not included into unprocessed batches are selected if PayoutDocumentIds is empty.
+// DebtorAccount and DebtorSwift are the account of the operating company from which payouts are transferred.
+type CreatePayoutBatchRequest struct {
+	OperatingCompanyId string    `json:"operating_company_id"`
+	Currency           string    `json:"currency"`
+	PayoutDocumentIds  []string  `json:"payout_document_ids"`
+	DebtorAccount      string    `json:"debtor_account"`
+	DebtorSwift        string    `json:"debtor_swift"`
+	ExecutionDate      time.Time `json:"execution_date"`
+	UserId             string    `json:"user_id"`
+}
+
+type GetPayoutBatchRequest struct {
+	BatchId string `json:"batch_id"`
+}
+
+type ListPayoutBatchesRequest struct {
+	OperatingCompanyId string   `json:"operating_company_id"`
+	Currency           string   `json:"currency"`
+	Status             []string `json:"status"`
+	Offset             int64    `json:"offset"`
+	Limit              int64    `json:"limit"`
+}
+
+// ImportPayoutBatchStatusReportRequest contains the payment status report (ISO 20022 pain.002) of the bank.
+type ImportPayoutBatchStatusReportRequest struct {
+	File []byte `json:"file"`
+	Ip   string `json:"ip"`
+}
+
+type PayoutBatchResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *PayoutBatch                    `json:"item,omitempty"`
+}
+
+type ListPayoutBatchesResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*PayoutBatch                  `json:"items"`
+}
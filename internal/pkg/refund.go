@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// RefundReason is the item of the refund reason codes catalog.
+type RefundReason struct {
+	Code string `json:"code"`
+	// Label is the key of the localized label in i18n messages.
+	Label string `json:"label"`
+}
+
+// CreateRefundWithReasonRequest is the request to create refund with the reason code of the catalog.
+// Comment of the merchant is saved as the reason of the refund.
+type CreateRefundWithReasonRequest struct {
+	OrderId    string  `json:"order_id"`
+	Amount     float64 `json:"amount"`
+	CreatorId  string  `json:"creator_id"`
+	MerchantId string  `json:"merchant_id"`
+	ReasonCode string  `json:"reason_code"`
+	Comment    string  `json:"comment"`
+}
+
+type RefundReasonsResponse struct {
+	Status int32           `json:"status"`
+	Items  []*RefundReason `json:"items"`
+}
+
+type RefundAnalyticsRequest struct {
+	MerchantId string `json:"merchant_id"`
+	// ProjectId is optional, report is built by all merchant's projects if it is empty.
+	ProjectId string `json:"project_id"`
+	GroupBy   string `json:"group_by"`
+	Interval  string `json:"interval"`
+	DateFrom  int64  `json:"date_from"`
+	DateTo    int64  `json:"date_to"`
+}
+
+type RefundAnalyticsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*RefundAnalyticsItem          `json:"items"`
+}
+
+// RefundAnalyticsItem contains payments and refunds of the group for one interval of the report.
+// Amounts are in the currency of the merchant's royalty.
+type RefundAnalyticsItem struct {
+	Group          string  `json:"group"`
+	Date           string  `json:"date"`
+	Currency       string  `json:"currency"`
+	PaymentsCount  int64   `json:"payments_count"`
+	PaymentsAmount float64 `json:"payments_amount"`
+	RefundsCount   int64   `json:"refunds_count"`
+	RefundsAmount  float64 `json:"refunds_amount"`
+	// RefundRate is the percentage of refunded payments and RefundAmountRate is the percentage of refunded amount.
+	RefundRate       float64 `json:"refund_rate"`
+	RefundAmountRate float64 `json:"refund_amount_rate"`
+}
+
+// RefundAnalyticsQueryResItem is the result of aggregation of payments or refunds by group and interval.
+type RefundAnalyticsQueryResItem struct {
+	Id struct {
+		Group    string `bson:"group"`
+		Date     string `bson:"date"`
+		Currency string `bson:"currency"`
+	} `bson:"_id"`
+	Count  int64   `bson:"count"`
+	Amount float64 `bson:"amount"`
+}
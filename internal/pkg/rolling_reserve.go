@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// RollingReservePolicy describes how funds of merchant's payments are held in the rolling reserve.
+// Percentage policy holds the percent of each payment for HoldDays days, the total held amount is limited by Cap
+// if it is set. Fixed deposit policy holds payments until Amount is collected and releases them after HoldDays days
+// or keeps them until the policy is changed if HoldDays is empty.
+type RollingReservePolicy struct {
+	Id         primitive.ObjectID `bson:"_id" json:"id"`
+	MerchantId string             `bson:"merchant_id" json:"merchant_id"`
+	Type       string             `bson:"type" json:"type"`
+	Percent    float64            `bson:"percent" json:"percent"`
+	HoldDays   int32              `bson:"hold_days" json:"hold_days"`
+	Cap        float64            `bson:"cap" json:"cap"`
+	Amount     float64            `bson:"amount" json:"amount"`
+	Currency   string             `bson:"currency" json:"currency"`
+	Enabled    bool               `bson:"enabled" json:"enabled"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// RollingReserveHold is the amount of the payment held in the rolling reserve by the policy.
+type RollingReserveHold struct {
+	Id             primitive.ObjectID `bson:"_id" json:"id"`
+	MerchantId     string             `bson:"merchant_id" json:"merchant_id"`
+	OrderId        string             `bson:"order_id" json:"order_id"`
+	Currency       string             `bson:"currency" json:"currency"`
+	Amount         float64            `bson:"amount" json:"amount"`
+	Status         string             `bson:"status" json:"status"`
+	HoldEntryId    string             `bson:"hold_entry_id" json:"hold_entry_id"`
+	ReleaseEntryId string             `bson:"release_entry_id" json:"release_entry_id"`
+	// ReleaseAt is empty for holds which are released manually only.
+	ReleaseAt  time.Time `bson:"release_at" json:"release_at"`
+	ReleasedAt time.Time `bson:"released_at" json:"released_at"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+}
+
+type GetRollingReservePolicyRequest struct {
+	MerchantId string `json:"merchant_id"`
+}
+
+type RollingReservePolicyResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *RollingReservePolicy           `json:"item,omitempty"`
+}
+
+type RollingReserveScheduleRequest struct {
+	MerchantId string `json:"merchant_id"`
+}
+
+type RollingReserveScheduleResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *RollingReserveSchedule         `json:"item,omitempty"`
+}
+
+// RollingReserveSchedule contains upcoming releases of the merchant's rolling reserve by dates.
+// UndatedAmount is the held amount which isn't scheduled for automatic release.
+type RollingReserveSchedule struct {
+	MerchantId    string                        `json:"merchant_id"`
+	Currency      string                        `json:"currency"`
+	TotalHeld     float64                       `json:"total_held"`
+	UndatedAmount float64                       `json:"undated_amount"`
+	Items         []*RollingReserveScheduleItem `json:"items"`
+}
+
+type RollingReserveScheduleItem struct {
+	Date   string  `bson:"_id" json:"date"`
+	Amount float64 `bson:"amount" json:"amount"`
+	Count  int64   `bson:"count" json:"count"`
+}
@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// RoyaltyReportDispute is the merchant's dispute of the royalty report.
+// Items of the dispute reference disputed orders or product lines of the report summary, the admin resolves
+// the dispute by adjustments of the items which are posted as corrections of the report.
+type RoyaltyReportDispute struct {
+	Id              primitive.ObjectID             `bson:"_id" json:"id"`
+	RoyaltyReportId string                         `bson:"royalty_report_id" json:"royalty_report_id"`
+	MerchantId      string                         `bson:"merchant_id" json:"merchant_id"`
+	Status          string                         `bson:"status" json:"status"`
+	Reason          string                         `bson:"reason" json:"reason"`
+	Items           []*RoyaltyReportDisputeItem    `bson:"items" json:"items"`
+	Comments        []*RoyaltyReportDisputeComment `bson:"comments" json:"comments"`
+	CreatedBy       string                         `bson:"created_by" json:"created_by"`
+	ResolvedBy      string                         `bson:"resolved_by" json:"resolved_by"`
+	CreatedAt       time.Time                      `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time                      `bson:"updated_at" json:"updated_at"`
+	ClosedAt        time.Time                      `bson:"closed_at" json:"closed_at"`
+}
+
+// RoyaltyReportDisputeItem is the disputed order or product line of the report.
+// Product line is identified by the product name and optional region of the report summary.
+type RoyaltyReportDisputeItem struct {
+	Id                string  `bson:"id" json:"id"`
+	OrderId           string  `bson:"order_id" json:"order_id"`
+	Product           string  `bson:"product" json:"product"`
+	Region            string  `bson:"region" json:"region"`
+	ClaimedAmount     float64 `bson:"claimed_amount" json:"claimed_amount"`
+	Description       string  `bson:"description" json:"description"`
+	AdjustmentAmount  float64 `bson:"adjustment_amount" json:"adjustment_amount"`
+	AdjustmentEntryId string  `bson:"adjustment_entry_id" json:"adjustment_entry_id"`
+}
+
+type RoyaltyReportDisputeComment struct {
+	Id        string    `bson:"id" json:"id"`
+	Author    string    `bson:"author" json:"author"`
+	UserId    string    `bson:"user_id" json:"user_id"`
+	Message   string    `bson:"message" json:"message"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+type OpenRoyaltyReportDisputeRequest struct {
+	ReportId   string                      `json:"report_id"`
+	MerchantId string                      `json:"merchant_id"`
+	UserId     string                      `json:"user_id"`
+	Reason     string                      `json:"reason"`
+	Items      []*RoyaltyReportDisputeItem `json:"items"`
+	Ip         string                      `json:"ip"`
+}
+
+// RoyaltyReportDisputeCommentRequest adds the comment to the dispute.
+// MerchantId must be set for comments of the merchant and must be empty for comments of the admin.
+type RoyaltyReportDisputeCommentRequest struct {
+	DisputeId  string `json:"dispute_id"`
+	MerchantId string `json:"merchant_id"`
+	UserId     string `json:"user_id"`
+	Message    string `json:"message"`
+}
+
+// ResolveRoyaltyReportDisputeRequest closes the dispute. The dispute is rejected if there are no adjustments.
+type ResolveRoyaltyReportDisputeRequest struct {
+	DisputeId   string                            `json:"dispute_id"`
+	UserId      string                            `json:"user_id"`
+	Comment     string                            `json:"comment"`
+	Adjustments []*RoyaltyReportDisputeAdjustment `json:"adjustments"`
+	Ip          string                            `json:"ip"`
+}
+
+type RoyaltyReportDisputeAdjustment struct {
+	ItemId string  `json:"item_id"`
+	Amount float64 `json:"amount"`
+}
+
+type ListRoyaltyReportDisputesRequest struct {
+	ReportId   string `json:"report_id"`
+	MerchantId string `json:"merchant_id"`
+}
+
+type RoyaltyReportDisputeResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *RoyaltyReportDispute           `json:"item,omitempty"`
+}
+
+type ListRoyaltyReportDisputesResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*RoyaltyReportDispute         `json:"items"`
+}
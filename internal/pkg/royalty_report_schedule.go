@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// RoyaltyReportSchedule determines the periods of royalty reports of the merchant.
+// Weekly and bi-weekly periods start on WeekDay, bi-weekly periods are aligned to AnchorDate.
+// Monthly periods are calendar months. NextPeriodFrom is the end of the last period for which reports were generated,
+// the next period always starts at it, so changing of the schedule doesn't cause overlaps or gaps.
+type RoyaltyReportSchedule struct {
+	Id             primitive.ObjectID `bson:"_id" json:"id"`
+	MerchantId     string             `bson:"merchant_id" json:"merchant_id"`
+	Type           string             `bson:"type" json:"type"`
+	WeekDay        int32              `bson:"week_day" json:"week_day"`
+	AnchorDate     time.Time          `bson:"anchor_date" json:"anchor_date"`
+	NextPeriodFrom time.Time          `bson:"next_period_from" json:"next_period_from"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type GetRoyaltyReportScheduleRequest struct {
+	MerchantId string `json:"merchant_id"`
+}
+
+type RoyaltyReportScheduleResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *RoyaltyReportSchedule          `json:"item,omitempty"`
+}
@@ -0,0 +1,53 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// SalesTaxNexusThreshold is the economic nexus threshold of the US state. Nexus is established when gross sales
+// or the count of transactions in the window reach the threshold, or both if RequireBoth is set.
+// The count of transactions isn't checked if TransactionsCount is zero. Thresholds with calendar year window
+// are checked for the previous and the current calendar years.
+type SalesTaxNexusThreshold struct {
+	SalesAmount       float64
+	TransactionsCount int64
+	RequireBoth       bool
+	Window            string
+}
+
+// SalesTaxNexus is the tracker of sales of the operating company to customers of the US state
+// against the economic nexus threshold of the state. Window is the window with sales closest to the threshold.
+type SalesTaxNexus struct {
+	Id                         primitive.ObjectID `bson:"_id" json:"id"`
+	OperatingCompanyId         string             `bson:"operating_company_id" json:"operating_company_id"`
+	Country                    string             `bson:"country" json:"country"`
+	State                      string             `bson:"state" json:"state"`
+	Window                     string             `bson:"window" json:"window"`
+	WindowFrom                 time.Time          `bson:"window_from" json:"window_from"`
+	WindowTo                   time.Time          `bson:"window_to" json:"window_to"`
+	Currency                   string             `bson:"currency" json:"currency"`
+	SalesAmount                float64            `bson:"sales_amount" json:"sales_amount"`
+	TransactionsCount          int64              `bson:"transactions_count" json:"transactions_count"`
+	ThresholdSalesAmount       float64            `bson:"threshold_sales_amount" json:"threshold_sales_amount"`
+	ThresholdTransactionsCount int64              `bson:"threshold_transactions_count" json:"threshold_transactions_count"`
+	ThresholdRequireBoth       bool               `bson:"threshold_require_both" json:"threshold_require_both"`
+	Status                     string             `bson:"status" json:"status"`
+	NexusEstablishedAt         time.Time          `bson:"nexus_established_at" json:"nexus_established_at"`
+	CreatedAt                  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt                  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// GetSalesTaxNexusReportRequest selects trackers of the operating company by statuses.
+// Only states with established nexus are returned if Status is empty.
+type GetSalesTaxNexusReportRequest struct {
+	OperatingCompanyId string   `json:"operating_company_id"`
+	Status             []string `json:"status"`
+}
+
+type SalesTaxNexusReportResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*SalesTaxNexus                `json:"items"`
+}
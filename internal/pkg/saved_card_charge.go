@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// SavedCardChargeSettings contains permission of the project to charge customers' saved cards
+// by server-to-server requests and limits of such charges.
+type SavedCardChargeSettings struct {
+	Id         primitive.ObjectID `bson:"_id" json:"id"`
+	ProjectId  string             `bson:"project_id" json:"project_id"`
+	MerchantId string             `bson:"merchant_id" json:"merchant_id"`
+	Enabled    bool               `bson:"enabled" json:"enabled"`
+	// MitEnabled allows merchant-initiated charges made without the customer's participation.
+	MitEnabled bool `bson:"mit_enabled" json:"mit_enabled"`
+	// Limits of the amount charged from one customer, zero value means no limit.
+	// Limits are specified in Currency, charges in other currencies are not allowed if any limit is set.
+	Currency             string    `bson:"currency" json:"currency"`
+	CustomerDailyLimit   float64   `bson:"customer_daily_limit" json:"customer_daily_limit"`
+	CustomerMonthlyLimit float64   `bson:"customer_monthly_limit" json:"customer_monthly_limit"`
+	CreatedAt            time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt            time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// HasCustomerLimits checks that the amount charged from one customer is limited.
+func (m *SavedCardChargeSettings) HasCustomerLimits() bool {
+	return m.CustomerDailyLimit > 0 || m.CustomerMonthlyLimit > 0
+}
+
+// StoredCredential is the initial customer-initiated transaction made when the customer agreed to save the card.
+// Card schemes require to refer this transaction in merchant-initiated transactions.
+type StoredCredential struct {
+	Id                   primitive.ObjectID `bson:"_id" json:"id"`
+	RecurringId          string             `bson:"recurring_id" json:"recurring_id"`
+	CustomerId           string             `bson:"customer_id" json:"customer_id"`
+	ProjectId            string             `bson:"project_id" json:"project_id"`
+	InitialOrderId       string             `bson:"initial_order_id" json:"initial_order_id"`
+	InitialTransactionId string             `bson:"initial_transaction_id" json:"initial_transaction_id"`
+	CreatedAt            time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// SavedCardCharge is a charge of the saved card requested by the merchant.
+type SavedCardCharge struct {
+	Id                   primitive.ObjectID `bson:"_id" json:"id"`
+	OrderId              string             `bson:"order_id" json:"order_id"`
+	ProjectId            string             `bson:"project_id" json:"project_id"`
+	CustomerId           string             `bson:"customer_id" json:"customer_id"`
+	SavedCardId          string             `bson:"saved_card_id" json:"saved_card_id"`
+	Initiator            string             `bson:"initiator" json:"initiator"`
+	InitialTransactionId string             `bson:"initial_transaction_id" json:"initial_transaction_id"`
+	Amount               float64            `bson:"amount" json:"amount"`
+	Currency             string             `bson:"currency" json:"currency"`
+	Status               string             `bson:"status" json:"status"`
+	// CounterIds are counters of charges of the customer the amount of the charge is reserved in.
+	CounterIds []primitive.ObjectID `bson:"counter_ids" json:"-"`
+	CreatedAt  time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time            `bson:"updated_at" json:"updated_at"`
+}
+
+// SavedCardChargeCounter is the total amount of pending and processed charges of the customer in the project
+// made in the currency during the period. Counters are changed atomically to check limits of the customer.
+type SavedCardChargeCounter struct {
+	Id         primitive.ObjectID `bson:"_id" json:"id"`
+	ProjectId  string             `bson:"project_id" json:"project_id"`
+	CustomerId string             `bson:"customer_id" json:"customer_id"`
+	Currency   string             `bson:"currency" json:"currency"`
+	Period     string             `bson:"period" json:"period"`
+	PeriodFrom time.Time          `bson:"period_from" json:"period_from"`
+	Amount     float64            `bson:"amount" json:"amount"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type ChargeSavedCardRequest struct {
+	ProjectId string `json:"project_id"`
+	// OrderId is an uuid of the order created by the merchant for this charge.
+	OrderId     string `json:"order_id"`
+	SavedCardId string `json:"saved_card_id"`
+	// Initiator is PaymentInitiatorCustomer for one-click payment when the customer is present in the game
+	// and PaymentInitiatorMerchant for merchant-initiated transaction.
+	Initiator string `json:"initiator"`
+	Ip        string `json:"ip"`
+}
+
+type ChargeSavedCardResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *SavedCardCharge                `json:"item,omitempty"`
+	// RedirectUrl is returned when payment system requires the customer's authentication for this charge.
+	RedirectUrl string `json:"redirect_url,omitempty"`
+}
+
+type GetSavedCardChargeSettingsRequest struct {
+	ProjectId  string `json:"project_id"`
+	MerchantId string `json:"merchant_id"`
+}
+
+type SavedCardChargeSettingsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *SavedCardChargeSettings        `json:"item,omitempty"`
+}
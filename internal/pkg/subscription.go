@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// SubscriptionState contains lifecycle status of the recurring subscription.
+// Subscription itself is stored in the recurring repository service, which doesn't know
+// anything about pauses and delayed cancellations, so these data are kept in billing server.
+type SubscriptionState struct {
+	Id             primitive.ObjectID         `bson:"_id" json:"id"`
+	SubscriptionId string                     `bson:"subscription_id" json:"subscription_id"`
+	MerchantId     string                     `bson:"merchant_id" json:"merchant_id"`
+	ProjectId      string                     `bson:"project_id" json:"project_id"`
+	CustomerId     string                     `bson:"customer_id" json:"customer_id"`
+	Status         string                     `bson:"status" json:"status"`
+	PauseCycles    int32                      `bson:"pause_cycles" json:"pause_cycles"`
+	PausedAt       time.Time                  `bson:"paused_at" json:"paused_at"`
+	ResumeAt       time.Time                  `bson:"resume_at" json:"resume_at"`
+	CancelAt       time.Time                  `bson:"cancel_at" json:"cancel_at"`
+	History        []*SubscriptionStateChange `bson:"history" json:"history"`
+	CreatedAt      time.Time                  `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time                  `bson:"updated_at" json:"updated_at"`
+}
+
+// SubscriptionStateChange is a single transition of the subscription lifecycle status.
+type SubscriptionStateChange struct {
+	Status    string    `bson:"status" json:"status"`
+	Source    string    `bson:"source" json:"source"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// RecurringSubscriptionRequest identifies the subscription and the performer of lifecycle action.
+// Performer is a customer (by browser cookie) or a merchant (by merchant identifier).
+type RecurringSubscriptionRequest struct {
+	Id         string `json:"id"`
+	Cookie     string `json:"cookie"`
+	MerchantId string `json:"merchant_id"`
+}
+
+type PauseRecurringSubscriptionRequest struct {
+	Id         string `json:"id"`
+	Cookie     string `json:"cookie"`
+	MerchantId string `json:"merchant_id"`
+	// Cycles is a number of billing periods which will be skipped.
+	Cycles int32 `json:"cycles"`
+}
+
+type RecurringSubscriptionStateResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *SubscriptionState              `json:"item,omitempty"`
+}
@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// OrderTaxEvidence is the set of evidence of the customer location collected on payment of the order.
+// Place of supply of digital goods is the country confirmed by two non-conflicting pieces of evidence,
+// Rule is the decision rule which determined TaxCountry.
+type OrderTaxEvidence struct {
+	Id                 primitive.ObjectID `bson:"_id" json:"id"`
+	OrderId            string             `bson:"order_id" json:"order_id"`
+	OrderUuid          string             `bson:"order_uuid" json:"order_uuid"`
+	OperatingCompanyId string             `bson:"operating_company_id" json:"operating_company_id"`
+	OrderCountry       string             `bson:"order_country" json:"order_country"`
+	Items              []*TaxEvidenceItem `bson:"items" json:"items"`
+	Rule               string             `bson:"rule" json:"rule"`
+	TaxCountry         string             `bson:"tax_country" json:"tax_country"`
+	Sufficient         bool               `bson:"sufficient" json:"sufficient"`
+	Conflicting        bool               `bson:"conflicting" json:"conflicting"`
+	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt          time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// TaxEvidenceItem is the piece of evidence of the customer location. Value is the source of evidence,
+// for example IP address, postal code of billing address, BIN of bank card or phone country code.
+type TaxEvidenceItem struct {
+	Type    string `bson:"type" json:"type"`
+	Country string `bson:"country" json:"country"`
+	Value   string `bson:"value" json:"value"`
+}
+
+type GetOrderTaxEvidenceRequest struct {
+	OrderId string `json:"order_id"`
+}
+
+type OrderTaxEvidenceResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *OrderTaxEvidence               `json:"item,omitempty"`
+}
+
+type VatReportTaxEvidenceRequest struct {
+	VatReportId string `json:"vat_report_id"`
+	UserId      string `json:"user_id"`
+}
+
+// VatReportTaxEvidence is the export of evidence of orders of the VAT report for audits. MissingCount is
+// the number of orders of the report which were paid before evidence collection.
+type VatReportTaxEvidence struct {
+	VatReportId  string              `json:"vat_report_id"`
+	Country      string              `json:"country"`
+	Items        []*OrderTaxEvidence `json:"items"`
+	MissingCount int32               `json:"missing_count"`
+	Content      []byte              `json:"content"`
+	FileName     string              `json:"file_name"`
+}
+
+type VatReportTaxEvidenceResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *VatReportTaxEvidence           `json:"item,omitempty"`
+}
+
+type CreateVatReportTaxEvidenceFileResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+}
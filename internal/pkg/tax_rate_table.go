@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// TaxRateTable is the version of local tax rates which is used when tax rates of the tax service are unavailable.
+// The table with the latest effective date not after the date of calculation is used.
+type TaxRateTable struct {
+	Id            primitive.ObjectID  `bson:"_id" json:"id"`
+	Version       int32               `bson:"version" json:"version"`
+	EffectiveFrom time.Time           `bson:"effective_from" json:"effective_from"`
+	Rates         []*TaxRateTableItem `bson:"rates" json:"rates"`
+	CreatedBy     string              `bson:"created_by" json:"created_by"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// TaxRateTableItem is the tax rate of the country, of the state of the country or of the zip code.
+// Empty state and zip match any state and zip, the most specific rate is used.
+type TaxRateTableItem struct {
+	Country string  `bson:"country" json:"country"`
+	State   string  `bson:"state" json:"state"`
+	Zip     string  `bson:"zip" json:"zip"`
+	Rate    float64 `bson:"rate" json:"rate"`
+}
+
+type TaxRateRequest struct {
+	Country string
+	State   string
+	Zip     string
+	Date    time.Time
+}
+
+// TaxRateResult is the tax rate with the provider which calculated it. TableVersion is set for rates
+// of local tax rate tables only.
+type TaxRateResult struct {
+	Rate         float64
+	Provider     string
+	TableVersion int32
+}
+
+type AddTaxRateTableRequest struct {
+	EffectiveFrom time.Time           `json:"effective_from"`
+	Rates         []*TaxRateTableItem `json:"rates"`
+	UserId        string              `json:"user_id"`
+}
+
+// GetTaxRateTableRequest returns the table effective at the date, the current table is returned for empty date.
+type GetTaxRateTableRequest struct {
+	Date time.Time `json:"date"`
+}
+
+type TaxRateTableResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *TaxRateTable                   `json:"item,omitempty"`
+}
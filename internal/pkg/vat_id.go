@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// VatIdValidationResult is the answer of the VAT ID registry. ConsultationNumber is the identifier of the request
+// in the registry which proves that VAT ID was checked.
+type VatIdValidationResult struct {
+	Valid              bool
+	CompanyName        string
+	CompanyAddress     string
+	ConsultationNumber string
+	CheckedAt          time.Time
+}
+
+// OrderVatId is the VAT ID of the business payer of the order. Order with valid VAT ID is zero-rated
+// and VAT is accounted for by the payer under the reverse charge.
+type OrderVatId struct {
+	Id                 primitive.ObjectID `bson:"_id" json:"id"`
+	OrderId            string             `bson:"order_id" json:"order_id"`
+	OrderUuid          string             `bson:"order_uuid" json:"order_uuid"`
+	OperatingCompanyId string             `bson:"operating_company_id" json:"operating_company_id"`
+	Country            string             `bson:"country" json:"country"`
+	VatId              string             `bson:"vat_id" json:"vat_id"`
+	Valid              bool               `bson:"valid" json:"valid"`
+	ReverseCharge      bool               `bson:"reverse_charge" json:"reverse_charge"`
+	CompanyName        string             `bson:"company_name" json:"company_name"`
+	CompanyAddress     string             `bson:"company_address" json:"company_address"`
+	ConsultationNumber string             `bson:"consultation_number" json:"consultation_number"`
+	CheckedAt          time.Time          `bson:"checked_at" json:"checked_at"`
+	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt          time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// SetOrderVatIdRequest sets VAT ID of the business payer entered on the payment form.
+// VAT ID is removed from the order if VatId is empty.
+type SetOrderVatIdRequest struct {
+	OrderId string `json:"order_id"`
+	VatId   string `json:"vat_id"`
+}
+
+type SetOrderVatIdResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *OrderVatId                     `json:"item,omitempty"`
+	Order   *billingpb.Order                `json:"order,omitempty"`
+}
+
+// VatReportReverseCharge is the summary of zero-rated B2B sales of the VAT report period,
+// which are excluded from the VAT report and declared by payers under the reverse charge.
+type VatReportReverseCharge struct {
+	Id                 primitive.ObjectID `bson:"_id" json:"id"`
+	VatReportId        string             `bson:"vat_report_id" json:"vat_report_id"`
+	OperatingCompanyId string             `bson:"operating_company_id" json:"operating_company_id"`
+	Country            string             `bson:"country" json:"country"`
+	DateFrom           time.Time          `bson:"date_from" json:"date_from"`
+	DateTo             time.Time          `bson:"date_to" json:"date_to"`
+	Currency           string             `bson:"currency" json:"currency"`
+	TransactionsCount  int32              `bson:"transactions_count" json:"transactions_count"`
+	GrossRevenue       float64            `bson:"gross_revenue" json:"gross_revenue"`
+	FeesAmount         float64            `bson:"fees_amount" json:"fees_amount"`
+	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt          time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type GetVatReportReverseChargeRequest struct {
+	VatReportId string `json:"vat_report_id"`
+}
+
+type VatReportReverseChargeResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *VatReportReverseCharge         `json:"item,omitempty"`
+}
@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// VatReportVersion is the snapshot of amounts of the VAT report. The original version is the report
+// as it was due to pay, each amendment contains amounts recalculated after late refunds or corrections
+// of orders of the report period with the delta to the previous version.
+type VatReportVersion struct {
+	Id                 primitive.ObjectID      `bson:"_id" json:"id"`
+	VatReportId        string                  `bson:"vat_report_id" json:"vat_report_id"`
+	Version            int32                   `bson:"version" json:"version"`
+	Type               string                  `bson:"type" json:"type"`
+	OperatingCompanyId string                  `bson:"operating_company_id" json:"operating_company_id"`
+	Country            string                  `bson:"country" json:"country"`
+	Currency           string                  `bson:"currency" json:"currency"`
+	VatRate            float64                 `bson:"vat_rate" json:"vat_rate"`
+	DateFrom           time.Time               `bson:"date_from" json:"date_from"`
+	DateTo             time.Time               `bson:"date_to" json:"date_to"`
+	Lines              []*VatReportVersionLine `bson:"lines" json:"lines"`
+	// CorrectionAmount is the delta of VAT due to pay, it's the difference of VAT amount and deduction amount deltas.
+	CorrectionAmount float64 `bson:"correction_amount" json:"correction_amount"`
+	// FiledInVatReportId is identifier of VAT report of the next period which includes the amendment
+	// to the correction amount. Empty if the amendment isn't filed yet.
+	FiledInVatReportId string    `bson:"filed_in_vat_report_id" json:"filed_in_vat_report_id"`
+	CreatedAt          time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt          time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// VatReportVersionLine is the amount of the VAT report. Delta is the change of the amount to the previous version,
+// it's zero for the original version.
+type VatReportVersionLine struct {
+	Name   string  `bson:"name" json:"name"`
+	Amount float64 `bson:"amount" json:"amount"`
+	Delta  float64 `bson:"delta" json:"delta"`
+}
+
+type GetVatReportHistoryRequest struct {
+	VatReportId string `json:"vat_report_id"`
+}
+
+// VatReportHistoryResponse contains versions of the VAT report ordered by number of version
+// and amendments of other VAT reports which are filed in the VAT report.
+type VatReportHistoryResponse struct {
+	Status          int32                           `json:"status"`
+	Message         *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items           []*VatReportVersion             `json:"items"`
+	FiledAmendments []*VatReportVersion             `json:"filed_amendments"`
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionAccountingCorrection = "accounting_correction"
+)
+
+type accountingCorrectionRepository repository
+
+// NewAccountingCorrectionRepository create and return an object for working with the accounting correction repository.
+// The returned object implements the AccountingCorrectionRepositoryInterface interface.
+func NewAccountingCorrectionRepository(db mongodb.SourceInterface) AccountingCorrectionRepositoryInterface {
+	s := &accountingCorrectionRepository{db: db}
+	return s
+}
+
+func (r *accountingCorrectionRepository) Insert(ctx context.Context, obj *intPkg.AccountingCorrection) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+	}
+
+	obj.CreatedAt = time.Now()
+	obj.UpdatedAt = obj.CreatedAt
+	_, err := r.db.Collection(collectionAccountingCorrection).InsertOne(ctx, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingCorrection),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *accountingCorrectionRepository) Update(ctx context.Context, obj *intPkg.AccountingCorrection) error {
+	obj.UpdatedAt = time.Now()
+	filter := bson.M{"_id": obj.Id}
+	_, err := r.db.Collection(collectionAccountingCorrection).ReplaceOne(ctx, filter, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingCorrection),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *accountingCorrectionRepository) GetById(ctx context.Context, id string) (*intPkg.AccountingCorrection, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingCorrection),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"_id": oid}
+
+	var obj *intPkg.AccountingCorrection
+	err = r.db.Collection(collectionAccountingCorrection).FindOne(ctx, query).Decode(&obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingCorrection),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *accountingCorrectionRepository) Find(
+	ctx context.Context,
+	merchantId, royaltyReportId string,
+	status []string,
+	offset, limit int64,
+) ([]*intPkg.AccountingCorrection, error) {
+	query := r.getFindQuery(merchantId, royaltyReportId, status)
+	sorts := bson.M{"created_at": -1}
+	opts := options.Find().SetSort(sorts).SetSkip(offset)
+
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := r.db.Collection(collectionAccountingCorrection).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingCorrection),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+		)
+		return nil, err
+	}
+
+	var items []*intPkg.AccountingCorrection
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingCorrection),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *accountingCorrectionRepository) FindCount(
+	ctx context.Context,
+	merchantId, royaltyReportId string,
+	status []string,
+) (int64, error) {
+	query := r.getFindQuery(merchantId, royaltyReportId, status)
+	count, err := r.db.Collection(collectionAccountingCorrection).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingCorrection),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *accountingCorrectionRepository) getFindQuery(merchantId, royaltyReportId string, status []string) bson.M {
+	query := bson.M{}
+
+	if merchantId != "" {
+		query["merchant_id"] = merchantId
+	}
+
+	if royaltyReportId != "" {
+		query["royalty_report_id"] = royaltyReportId
+	}
+
+	if len(status) > 0 {
+		query["status"] = bson.M{"$in": status}
+	}
+
+	return query
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// AccountingCorrectionRepositoryInterface is abstraction layer for working with manual accounting corrections.
+type AccountingCorrectionRepositoryInterface interface {
+	// Insert adds the correction to the collection.
+	Insert(context.Context, *intPkg.AccountingCorrection) error
+
+	// Update updates the correction in the collection.
+	Update(context.Context, *intPkg.AccountingCorrection) error
+
+	// GetById returns the correction by unique identity.
+	GetById(ctx context.Context, id string) (*intPkg.AccountingCorrection, error)
+
+	// Find returns corrections by merchant, royalty report and statuses ordered by creation date.
+	Find(ctx context.Context, merchantId, royaltyReportId string, status []string, offset, limit int64) ([]*intPkg.AccountingCorrection, error)
+
+	// FindCount returns the count of corrections by merchant, royalty report and statuses.
+	FindCount(ctx context.Context, merchantId, royaltyReportId string, status []string) (int64, error)
+}
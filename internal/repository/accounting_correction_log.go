@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionAccountingCorrectionLog = "accounting_correction_log"
+)
+
+type accountingCorrectionLogRepository repository
+
+// NewAccountingCorrectionLogRepository create and return an object for working with the accounting correction log repository.
+// The returned object implements the AccountingCorrectionLogRepositoryInterface interface.
+func NewAccountingCorrectionLogRepository(db mongodb.SourceInterface) AccountingCorrectionLogRepositoryInterface {
+	s := &accountingCorrectionLogRepository{db: db}
+	return s
+}
+
+func (r *accountingCorrectionLogRepository) Insert(ctx context.Context, obj *intPkg.AccountingCorrectionLog) error {
+	obj.Id = primitive.NewObjectID()
+	obj.CreatedAt = time.Now()
+	_, err := r.db.Collection(collectionAccountingCorrectionLog).InsertOne(ctx, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingCorrectionLog),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *accountingCorrectionLogRepository) FindByCorrectionId(
+	ctx context.Context,
+	correctionId string,
+) ([]*intPkg.AccountingCorrectionLog, error) {
+	query := bson.M{"correction_id": correctionId}
+	sorts := bson.D{{"created_at", 1}, {"_id", 1}}
+	opts := options.Find().SetSort(sorts)
+	cursor, err := r.db.Collection(collectionAccountingCorrectionLog).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingCorrectionLog),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+		)
+		return nil, err
+	}
+
+	var items []*intPkg.AccountingCorrectionLog
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingCorrectionLog),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// AccountingCorrectionLogRepositoryInterface is abstraction layer for working with the audit trail of accounting corrections.
+// The audit trail is append-only, records can't be changed or deleted.
+type AccountingCorrectionLogRepositoryInterface interface {
+	// Insert adds the record to the audit trail.
+	Insert(context.Context, *intPkg.AccountingCorrectionLog) error
+
+	// FindByCorrectionId returns records of the correction ordered by date.
+	FindByCorrectionId(ctx context.Context, correctionId string) ([]*intPkg.AccountingCorrectionLog, error)
+}
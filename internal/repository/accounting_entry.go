@@ -408,6 +408,74 @@ func (r *accountingEntryRepository) FindByTypeCountryDates(
 	return objs, nil
 }
 
+func (r *accountingEntryRepository) GetVatByOperatingCompanyDates(
+	ctx context.Context, operatingCompanyId string, dateFrom, dateTo time.Time,
+) ([]*pkg2.LedgerVatQueryResItem, error) {
+	query := []bson.M{
+		{
+			"$match": bson.M{
+				"operating_company_id": operatingCompanyId,
+				"type": bson.M{
+					"$in": []string{pkg.AccountingEntryTypeRealTaxFee, pkg.AccountingEntryTypeRealRefundTaxFee},
+				},
+				"created_at": bson.M{
+					"$gte": dateFrom,
+					"$lte": dateTo,
+				},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{"country": "$country", "local_currency": "$local_currency"},
+				"amount": bson.M{
+					"$sum": bson.M{
+						"$cond": []interface{}{
+							bson.M{"$eq": []string{"$type", pkg.AccountingEntryTypeRealTaxFee}},
+							"$local_amount_rounded",
+							bson.M{"$multiply": []interface{}{"$local_amount_rounded", -1}},
+						},
+					},
+				},
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":            0,
+				"country":        "$_id.country",
+				"local_currency": "$_id.local_currency",
+				"amount":         1,
+			},
+		},
+	}
+
+	var items []*pkg2.LedgerVatQueryResItem
+	cursor, err := r.db.Collection(collectionAccountingEntry).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingEntry),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingEntry),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
+
 func (r *accountingEntryRepository) GetDistinctBySourceId(ctx context.Context) ([]string, error) {
 	res, err := r.db.Collection(collectionAccountingEntry).Distinct(ctx, "source.id", bson.M{})
 
@@ -677,3 +745,89 @@ func (r *accountingEntryRepository) BulkWrite(ctx context.Context, list []*billi
 
 	return nil
 }
+
+func (r *accountingEntryRepository) ApplyChanges(
+	ctx context.Context,
+	inserted, replaced []*billingpb.AccountingEntry,
+	deletedIds []string,
+) error {
+	var operations []mongo.WriteModel
+
+	for _, ae := range inserted {
+		mgo, err := r.mapper.MapObjectToMgo(ae)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, ae),
+			)
+			return err
+		}
+
+		operations = append(operations, mongo.NewInsertOneModel().SetDocument(mgo))
+	}
+
+	for _, ae := range replaced {
+		oid, err := primitive.ObjectIDFromHex(ae.Id)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingEntry),
+				zap.String(pkg.ErrorDatabaseFieldQuery, ae.Id),
+			)
+			return err
+		}
+
+		mgo, err := r.mapper.MapObjectToMgo(ae)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, ae),
+			)
+			return err
+		}
+
+		operation := mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": oid}).
+			SetReplacement(mgo)
+		operations = append(operations, operation)
+	}
+
+	for _, id := range deletedIds {
+		oid, err := primitive.ObjectIDFromHex(id)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingEntry),
+				zap.String(pkg.ErrorDatabaseFieldQuery, id),
+			)
+			return err
+		}
+
+		operations = append(operations, mongo.NewDeleteOneModel().SetFilter(bson.M{"_id": oid}))
+	}
+
+	if len(operations) == 0 {
+		return nil
+	}
+
+	_, err := r.db.Collection(collectionAccountingEntry).BulkWrite(ctx, operations)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionAccountingEntry),
+		)
+		return err
+	}
+
+	return nil
+}
@@ -45,6 +45,13 @@ type AccountingEntryRepositoryInterface interface {
 	// FindByTypeCountryDates returns the account entries by type, country and dates.
 	FindByTypeCountryDates(context.Context, string, []string, time.Time, time.Time) ([]*billingpb.AccountingEntry, error)
 
+	// GetVatByOperatingCompanyDates returns the amount of taxes of payments less taxes of refunds in local currency
+	// of the operating company by country and dates.
+	GetVatByOperatingCompanyDates(context.Context, string, time.Time, time.Time) ([]*pkg.LedgerVatQueryResItem, error)
+
 	// BulkWrite writing account entries.
 	BulkWrite(context.Context, []*billingpb.AccountingEntry) error
+
+	// ApplyChanges inserts, replaces and deletes the account entries by one bulk write.
+	ApplyChanges(ctx context.Context, inserted, replaced []*billingpb.AccountingEntry, deletedIds []string) error
 }
@@ -31,6 +31,19 @@ func (m *autoincrementRepository) GatPayoutAutoincrementId(ctx context.Context)
 	return doc.Counter, nil
 }
 
+func (m *autoincrementRepository) GetBillingDocumentAutoincrementId(
+	ctx context.Context,
+	operatingCompanyId, documentType string,
+) (int64, error) {
+	doc, err := m.getAutoincrement(ctx, collectionBillingDocument+"_"+documentType+"_"+operatingCompanyId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return doc.Counter, nil
+}
+
 func (m *autoincrementRepository) getAutoincrement(ctx context.Context, collection string) (*models.Autoincrement, error) {
 	opts := options.FindOneAndUpdate().
 		SetReturnDocument(options.After).
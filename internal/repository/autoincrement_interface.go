@@ -4,4 +4,5 @@ import "context"
 
 type AutoincrementRepositoryInterface interface {
 	GatPayoutAutoincrementId(ctx context.Context) (int64, error)
+	GetBillingDocumentAutoincrementId(ctx context.Context, operatingCompanyId, documentType string) (int64, error)
 }
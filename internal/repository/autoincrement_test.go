@@ -64,6 +64,26 @@ func (suite *AutoincrementTestSuite) TestAutoincrement_GatPayoutAutoincrementId_
 	}
 }
 
+func (suite *AutoincrementTestSuite) TestAutoincrement_GetBillingDocumentAutoincrementId_Ok() {
+	for i := 1; i < 10; i++ {
+		counter, err := suite.repository.GetBillingDocumentAutoincrementId(context.TODO(), "oc1", pkg.BillingDocumentTypeInvoice)
+		assert.NoError(suite.T(), err)
+		assert.EqualValues(suite.T(), i, counter)
+	}
+
+	counter, err := suite.repository.GetBillingDocumentAutoincrementId(context.TODO(), "oc2", pkg.BillingDocumentTypeInvoice)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, counter)
+
+	counter, err = suite.repository.GetBillingDocumentAutoincrementId(context.TODO(), "oc1", pkg.BillingDocumentTypeActOfCompletion)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, counter)
+
+	counter, err = suite.repository.GatPayoutAutoincrementId(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, counter)
+}
+
 func (suite *AutoincrementTestSuite) TestAutoincrement_GatPayoutAutoincrementId_Error() {
 	singleResultMock := &mongodbMock.SingleResultInterface{}
 	singleResultMock.On("Decode", mock.Anything).Return(errors.New("AutoincrementRepository_SingleResult_Error"))
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionBillingDocument = "billing_document"
+)
+
+type billingDocumentRepository repository
+
+// NewBillingDocumentRepository create and return an object for working with the repository of accounting documents
+// issued to merchants.
+// The returned object implements the BillingDocumentRepositoryInterface interface.
+func NewBillingDocumentRepository(db mongodb.SourceInterface) BillingDocumentRepositoryInterface {
+	s := &billingDocumentRepository{db: db}
+	return s
+}
+
+func (r *billingDocumentRepository) Insert(ctx context.Context, obj *intPkg.BillingDocument) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+	}
+
+	if obj.CreatedAt.IsZero() {
+		obj.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Collection(collectionBillingDocument).InsertOne(ctx, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBillingDocument),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *billingDocumentRepository) SetFile(
+	ctx context.Context,
+	obj *intPkg.BillingDocument,
+	fileName string,
+	file []byte,
+	fileHash string,
+) error {
+	filter := bson.M{"_id": obj.Id, "file_hash": ""}
+	set := bson.M{"$set": bson.M{"file_name": fileName, "file": file, "file_hash": fileHash}}
+	res, err := r.db.Collection(collectionBillingDocument).UpdateOne(ctx, filter, set)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBillingDocument),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+		)
+		return err
+	}
+
+	if res.MatchedCount <= 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	obj.FileName = fileName
+	obj.File = file
+	obj.FileHash = fileHash
+
+	return nil
+}
+
+func (r *billingDocumentRepository) GetById(ctx context.Context, id string) (*intPkg.BillingDocument, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBillingDocument),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (r *billingDocumentRepository) GetByPeriod(
+	ctx context.Context,
+	merchantId, documentType, currency string,
+	dateFrom time.Time,
+) (*intPkg.BillingDocument, error) {
+	query := bson.M{"merchant_id": merchantId, "type": documentType, "currency": currency, "date_from": dateFrom}
+	return r.findOne(ctx, query)
+}
+
+func (r *billingDocumentRepository) Find(
+	ctx context.Context,
+	merchantId, documentType string,
+	offset, limit int64,
+) ([]*intPkg.BillingDocument, error) {
+	query := r.getFindQuery(merchantId, documentType)
+	sorts := bson.D{{"created_at", -1}, {"sequence", -1}}
+	opts := options.Find().SetSort(sorts).SetSkip(offset).SetProjection(bson.M{"file": 0})
+
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	return r.find(ctx, query, opts)
+}
+
+func (r *billingDocumentRepository) FindCount(ctx context.Context, merchantId, documentType string) (int64, error) {
+	query := r.getFindQuery(merchantId, documentType)
+	count, err := r.db.Collection(collectionBillingDocument).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBillingDocument),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *billingDocumentRepository) FindByOriginalDocumentId(
+	ctx context.Context,
+	originalDocumentId string,
+) ([]*intPkg.BillingDocument, error) {
+	query := bson.M{"original_document_id": originalDocumentId}
+	opts := options.Find().SetSort(bson.M{"sequence": 1}).SetProjection(bson.M{"file": 0})
+
+	return r.find(ctx, query, opts)
+}
+
+func (r *billingDocumentRepository) find(
+	ctx context.Context,
+	query bson.M,
+	opts *options.FindOptions,
+) ([]*intPkg.BillingDocument, error) {
+	cursor, err := r.db.Collection(collectionBillingDocument).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBillingDocument),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var items []*intPkg.BillingDocument
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBillingDocument),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *billingDocumentRepository) findOne(ctx context.Context, query bson.M) (*intPkg.BillingDocument, error) {
+	var obj *intPkg.BillingDocument
+	err := r.db.Collection(collectionBillingDocument).FindOne(ctx, query).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionBillingDocument),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *billingDocumentRepository) getFindQuery(merchantId, documentType string) bson.M {
+	query := bson.M{}
+
+	if merchantId != "" {
+		query["merchant_id"] = merchantId
+	}
+
+	if documentType != "" {
+		query["type"] = documentType
+	}
+
+	return query
+}
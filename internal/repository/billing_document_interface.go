@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"time"
+)
+
+// BillingDocumentRepositoryInterface is abstraction layer for working with accounting documents issued to merchants.
+// Documents are immutable, only the rendered file may be stored once.
+type BillingDocumentRepositoryInterface interface {
+	// Insert adds the document to the collection.
+	Insert(context.Context, *intPkg.BillingDocument) error
+
+	// SetFile stores the rendered file of the document if the file isn't stored yet.
+	SetFile(ctx context.Context, obj *intPkg.BillingDocument, fileName string, file []byte, fileHash string) error
+
+	// GetById returns the document by unique identity.
+	GetById(ctx context.Context, id string) (*intPkg.BillingDocument, error)
+
+	// GetByPeriod returns the document of the merchant of the type in the currency issued for the period starting at the date.
+	GetByPeriod(ctx context.Context, merchantId, documentType, currency string, dateFrom time.Time) (*intPkg.BillingDocument, error)
+
+	// Find returns documents by merchant and type ordered by date of issue from the latest one.
+	Find(ctx context.Context, merchantId, documentType string, offset, limit int64) ([]*intPkg.BillingDocument, error)
+
+	// FindCount returns the count of documents by merchant and type.
+	FindCount(ctx context.Context, merchantId, documentType string) (int64, error)
+
+	// FindByOriginalDocumentId returns notes issued to the original document.
+	FindByOriginalDocumentId(ctx context.Context, originalDocumentId string) ([]*intPkg.BillingDocument, error)
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionCardExpiry = "card_expiry"
+)
+
+type cardExpiryRepository repository
+
+// NewCardExpiryRepository create and return an object for working with the card expiry repository.
+// The returned object implements the CardExpiryRepositoryInterface interface.
+func NewCardExpiryRepository(db mongodb.SourceInterface) CardExpiryRepositoryInterface {
+	s := &cardExpiryRepository{db: db}
+	return s
+}
+
+func (r *cardExpiryRepository) Upsert(ctx context.Context, obj *intPkg.CardExpiry) error {
+	filter := bson.M{
+		"customer_id":     obj.CustomerId,
+		"project_id":      obj.ProjectId,
+		"masked_pan":      obj.MaskedPan,
+		"subscription_id": obj.SubscriptionId,
+	}
+	// Notification data must stay untouched when the same card is saved again,
+	// otherwise customer will be notified about expiring card again after each payment.
+	update := bson.M{
+		"$set": bson.M{
+			"merchant_id":  obj.MerchantId,
+			"order_id":     obj.OrderId,
+			"recurring_id": obj.RecurringId,
+			"card_holder":  obj.CardHolder,
+			"email":        obj.Email,
+			"locale":       obj.Locale,
+			"currency":     obj.Currency,
+			"expire_month": obj.ExpireMonth,
+			"expire_year":  obj.ExpireYear,
+			"expire_at":    obj.ExpireAt,
+			"updated_at":   time.Now(),
+		},
+		"$setOnInsert": bson.M{
+			"_id":                    primitive.NewObjectID(),
+			"notified_at":            time.Time{},
+			"update_token":           "",
+			"update_token_expire_at": time.Time{},
+			"created_at":             time.Now(),
+		},
+	}
+	opts := options.Update().SetUpsert(true)
+	_, err := r.db.Collection(collectionCardExpiry).UpdateOne(ctx, filter, update, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardExpiry),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *cardExpiryRepository) Update(ctx context.Context, obj *intPkg.CardExpiry) error {
+	obj.UpdatedAt = time.Now()
+
+	filter := bson.M{"_id": obj.Id}
+	_, err := r.db.Collection(collectionCardExpiry).ReplaceOne(ctx, filter, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardExpiry),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *cardExpiryRepository) GetByUpdateToken(ctx context.Context, token string) (*intPkg.CardExpiry, error) {
+	obj := &intPkg.CardExpiry{}
+	query := bson.M{"update_token": token}
+	err := r.db.Collection(collectionCardExpiry).FindOne(ctx, query).Decode(obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardExpiry),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *cardExpiryRepository) FindExpiring(ctx context.Context, from, to time.Time) ([]*intPkg.CardExpiry, error) {
+	query := bson.M{"expire_at": bson.M{"$gte": from, "$lte": to}}
+	cursor, err := r.db.Collection(collectionCardExpiry).Find(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardExpiry),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*intPkg.CardExpiry
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardExpiry),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return list, nil
+}
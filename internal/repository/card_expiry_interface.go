@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"time"
+)
+
+// CardExpiryRepositoryInterface is abstraction layer for working with expiration data of saved cards.
+type CardExpiryRepositoryInterface interface {
+	// Upsert add or update the card expiration data identified by customer, project, card and subscription.
+	Upsert(context.Context, *intPkg.CardExpiry) error
+
+	// Update updates the card expiration data in the collection.
+	Update(context.Context, *intPkg.CardExpiry) error
+
+	// GetByUpdateToken returns the card expiration data by token of card update link.
+	GetByUpdateToken(context.Context, string) (*intPkg.CardExpiry, error)
+
+	// FindExpiring returns cards which expire in specified period.
+	FindExpiring(context.Context, time.Time, time.Time) ([]*intPkg.CardExpiry, error)
+}
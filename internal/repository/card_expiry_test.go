@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"testing"
+	"time"
+)
+
+type CardExpiryTestSuite struct {
+	suite.Suite
+	db         mongodb.SourceInterface
+	repository *cardExpiryRepository
+}
+
+func Test_CardExpiry(t *testing.T) {
+	suite.Run(t, new(CardExpiryTestSuite))
+}
+
+func (suite *CardExpiryTestSuite) SetupTest() {
+	_, err := config.NewConfig()
+	assert.NoError(suite.T(), err, "Config load failed")
+
+	suite.db, err = mongodb.NewDatabase()
+	assert.NoError(suite.T(), err, "Database connection failed")
+
+	suite.repository = &cardExpiryRepository{db: suite.db}
+}
+
+func (suite *CardExpiryTestSuite) TearDownTest() {
+	if err := suite.db.Drop(); err != nil {
+		suite.FailNow("Database deletion failed", "%v", err)
+	}
+
+	if err := suite.db.Close(); err != nil {
+		suite.FailNow("Database close failed", "%v", err)
+	}
+}
+
+func (suite *CardExpiryTestSuite) getCard(expireAt time.Time) *intPkg.CardExpiry {
+	return &intPkg.CardExpiry{
+		CustomerId:  primitive.NewObjectID().Hex(),
+		ProjectId:   primitive.NewObjectID().Hex(),
+		RecurringId: primitive.NewObjectID().Hex(),
+		MaskedPan:   "400000******0002",
+		ExpireMonth: expireAt.Format("01"),
+		ExpireYear:  expireAt.Format("2006"),
+		ExpireAt:    expireAt,
+	}
+}
+
+func (suite *CardExpiryTestSuite) TestCardExpiry_NewCardExpiryRepository_Ok() {
+	repository := NewCardExpiryRepository(suite.db)
+	assert.IsType(suite.T(), &cardExpiryRepository{}, repository)
+}
+
+func (suite *CardExpiryTestSuite) TestCardExpiry_Upsert_KeepNotification_Ok() {
+	expireAt := time.Now().UTC().AddDate(0, 0, 10)
+	card := suite.getCard(expireAt)
+	err := suite.repository.Upsert(context.TODO(), card)
+	assert.NoError(suite.T(), err)
+
+	list, err := suite.repository.FindExpiring(context.TODO(), expireAt.Add(-time.Minute), expireAt.Add(time.Minute))
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), list, 1)
+
+	list[0].UpdateToken = "token"
+	list[0].NotifiedAt = time.Now().UTC()
+	err = suite.repository.Update(context.TODO(), list[0])
+	assert.NoError(suite.T(), err)
+
+	card.RecurringId = primitive.NewObjectID().Hex()
+	err = suite.repository.Upsert(context.TODO(), card)
+	assert.NoError(suite.T(), err)
+
+	card2, err := suite.repository.GetByUpdateToken(context.TODO(), "token")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), list[0].Id, card2.Id)
+	assert.Equal(suite.T(), card.RecurringId, card2.RecurringId)
+	assert.False(suite.T(), card2.NotifiedAt.IsZero())
+}
+
+func (suite *CardExpiryTestSuite) TestCardExpiry_GetByUpdateToken_NotFound() {
+	_, err := suite.repository.GetByUpdateToken(context.TODO(), "unknown")
+	assert.Equal(suite.T(), mongo.ErrNoDocuments, err)
+}
+
+func (suite *CardExpiryTestSuite) TestCardExpiry_FindExpiring_Ok() {
+	now := time.Now().UTC()
+	cards := []*intPkg.CardExpiry{
+		suite.getCard(now.AddDate(0, 0, -1)),
+		suite.getCard(now.AddDate(0, 0, 10)),
+		suite.getCard(now.AddDate(0, 0, 20)),
+		suite.getCard(now.AddDate(0, 2, 0)),
+	}
+
+	for _, card := range cards {
+		err := suite.repository.Upsert(context.TODO(), card)
+		assert.NoError(suite.T(), err)
+	}
+
+	list, err := suite.repository.FindExpiring(context.TODO(), now, now.AddDate(0, 0, 30))
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), list, 2)
+}
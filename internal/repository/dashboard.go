@@ -27,6 +27,7 @@ const (
 	dashboardBaseSalesTodayCacheKey               = "dashboard:base:sales_today:%x"
 	dashboardBaseSourcesCacheKey                  = "dashboard:base:sources:%x"
 	dashboardCustomerCacheKey                     = "dashboard:customers:%x"
+	dashboardSubscriptionsCacheKey                = "dashboard:subscriptions:%s:%%x"
 
 	dashboardReportGroupByHour        = "$hour"
 	dashboardReportGroupByDay         = "$day"
@@ -513,6 +514,44 @@ func (r *dashboardRepository) GetMainReport(
 	return result, nil
 }
 
+func (r *dashboardRepository) GetSubscriptionsReport(
+	ctx context.Context,
+	merchantId, projectId, period string,
+) (*pkg2.DashboardSubscriptionsReport, error) {
+	processor, err := r.newDashboardReportProcessor(
+		merchantId,
+		period,
+		fmt.Sprintf(dashboardSubscriptionsCacheKey, projectId),
+		bson.M{"$in": []string{"processed", "refunded", "chargeback", "rejected"}},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if projectId != "" {
+		projectOid, err := primitive.ObjectIDFromHex(projectId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		processor.(*DashboardReportProcessor).Match["project._id"] = projectOid
+	}
+
+	data, err := processor.ExecuteReport(
+		ctx,
+		new(pkg2.DashboardSubscriptionsReport),
+		processor.ExecuteSubscriptionsReport,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return data.(*pkg2.DashboardSubscriptionsReport), nil
+}
+
 func (r *dashboardRepository) GetBaseReport(
 	ctx context.Context,
 	merchantId, period string,
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 )
 
@@ -13,4 +14,7 @@ type DashboardRepositoryInterface interface {
 
 	GetCustomersReport(ctx context.Context, merchantId string, period string) (*billingpb.DashboardCustomerReport, error)
 	GetCustomerARPU(ctx context.Context, merchantId string, customerId string) (*billingpb.DashboardAmountItemWithChart, error)
+
+	// GetSubscriptionsReport returns recurring revenue metrics of the merchant (or of the merchant's project) for the period.
+	GetSubscriptionsReport(ctx context.Context, merchantId, projectId, period string) (*intPkg.DashboardSubscriptionsReport, error)
 }
@@ -2,12 +2,15 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"github.com/paysuper/paysuper-billing-server/internal/database"
 	"github.com/paysuper/paysuper-billing-server/internal/helper"
 	pkg2 "github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/recurringpb"
+	tools "github.com/paysuper/paysuper-tools/number"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.uber.org/zap"
 	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
@@ -16,6 +19,13 @@ import (
 
 const (
 	baseReportsItemsLimit = 5
+
+	dashboardSubscriptionPaymentStatusProcessed = "processed"
+	dashboardSubscriptionPaymentStatusRejected  = "rejected"
+)
+
+var (
+	errorDashboardSubscriptionsPeriodNotSet = fmt.Errorf("dashboard report period not set")
 )
 
 type Customers struct {
@@ -1455,3 +1465,217 @@ func (m *DashboardReportProcessor) ExecuteCustomerARPPU(ctx context.Context) (in
 
 	return res, nil
 }
+
+func (m *DashboardReportProcessor) ExecuteSubscriptionsReport(ctx context.Context, _ interface{}) (interface{}, error) {
+	dates, ok := m.Match["pm_order_close_date"].(bson.M)
+
+	if !ok {
+		return nil, errorDashboardSubscriptionsPeriodNotSet
+	}
+
+	from := dates["$gte"].(time.Time)
+	to := dates["$lte"].(time.Time)
+	match := bson.M{}
+
+	for k, v := range m.Match {
+		match[k] = v
+	}
+
+	// Yearly subscriptions paid up to one year before the period beginning are still active in the period,
+	// so payments are selected with this margin to calculate MRR at the beginning of the period.
+	match["recurring"] = true
+	match["pm_order_close_date"] = bson.M{"$gte": from.AddDate(-1, 0, -1), "$lte": to}
+
+	query := []bson.M{
+		{"$match": match},
+		{
+			"$project": bson.M{
+				"recurring_id": "$recurring_id",
+				"amount":       "$payment_gross_revenue.amount",
+				"currency":     bson.M{"$ifNull": []string{"$payment_gross_revenue.currency", ""}},
+				"period":       bson.M{"$ifNull": []string{"$recurring_settings.period", recurringpb.RecurringPeriodMonth}},
+				"status":       "$status",
+				"renewal":      bson.M{"$gt": []string{"$parent_order.id", ""}},
+				"date":         "$pm_order_close_date",
+			},
+		},
+		{"$sort": bson.M{"date": 1}},
+	}
+
+	cursor, err := m.Db.Collection(m.Collection).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, m.Collection),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var payments []*models.MgoDashboardSubscriptionPayment
+	err = cursor.All(ctx, &payments)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, m.Collection),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return calculateSubscriptionsReport(payments, from, to), nil
+}
+
+// calculateSubscriptionsReport calculates recurring metrics by subscription payments sorted by payment date.
+// Subscription is active at the moment if its latest successful payment covers this moment.
+func calculateSubscriptionsReport(
+	payments []*models.MgoDashboardSubscriptionPayment,
+	from, to time.Time,
+) *pkg2.DashboardSubscriptionsReport {
+	report := &pkg2.DashboardSubscriptionsReport{}
+	activeFrom := getActiveSubscriptionPayments(payments, from)
+	activeTo := getActiveSubscriptionPayments(payments, to)
+	lastAttempts := make(map[string]*models.MgoDashboardSubscriptionPayment)
+
+	for _, payment := range payments {
+		if report.Currency == "" {
+			report.Currency = payment.Currency
+		}
+
+		if !payment.Renewal || payment.Date.Before(from) || payment.Date.After(to) {
+			continue
+		}
+
+		report.RenewalAttempts++
+		lastAttempts[payment.RecurringId] = payment
+
+		if payment.Status != dashboardSubscriptionPaymentStatusRejected {
+			report.RenewalSuccessful++
+		}
+	}
+
+	for id, payment := range activeTo {
+		mrr := getSubscriptionPaymentMrr(payment)
+		report.Mrr += mrr
+		previous, ok := activeFrom[id]
+
+		if !ok {
+			report.NewMrr += mrr
+			report.NewSubscribers++
+			continue
+		}
+
+		delta := mrr - getSubscriptionPaymentMrr(previous)
+
+		if delta > 0 {
+			report.ExpansionMrr += delta
+		} else {
+			report.ContractionMrr -= delta
+		}
+	}
+
+	for id, payment := range activeFrom {
+		mrr := getSubscriptionPaymentMrr(payment)
+		report.MrrPrevious += mrr
+
+		if _, ok := activeTo[id]; ok {
+			continue
+		}
+
+		report.ChurnedMrr += mrr
+		report.ChurnedSubscribers++
+
+		if attempt, ok := lastAttempts[id]; ok && attempt.Status == dashboardSubscriptionPaymentStatusRejected {
+			report.InvoluntaryChurnedSubscribers++
+		}
+	}
+
+	report.ActiveSubscribers = int64(len(activeTo))
+	report.ActiveSubscribersPrevious = int64(len(activeFrom))
+
+	if report.ActiveSubscribersPrevious > 0 {
+		previous := float64(report.ActiveSubscribersPrevious)
+		report.ChurnRate = helper.Round(float64(report.ChurnedSubscribers) / previous * 100)
+		report.InvoluntaryChurnRate = helper.Round(float64(report.InvoluntaryChurnedSubscribers) / previous * 100)
+	}
+
+	if report.RenewalAttempts > 0 {
+		report.RenewalSuccessRate = helper.Round(float64(report.RenewalSuccessful) / float64(report.RenewalAttempts) * 100)
+	}
+
+	report.Mrr = tools.FormatAmount(report.Mrr)
+	report.MrrPrevious = tools.FormatAmount(report.MrrPrevious)
+	report.Arr = tools.FormatAmount(report.Mrr * 12)
+	report.NewMrr = tools.FormatAmount(report.NewMrr)
+	report.ExpansionMrr = tools.FormatAmount(report.ExpansionMrr)
+	report.ContractionMrr = tools.FormatAmount(report.ContractionMrr)
+	report.ChurnedMrr = tools.FormatAmount(report.ChurnedMrr)
+
+	return report
+}
+
+// getActiveSubscriptionPayments returns the latest successful payment of each subscription active at the moment.
+func getActiveSubscriptionPayments(
+	payments []*models.MgoDashboardSubscriptionPayment,
+	moment time.Time,
+) map[string]*models.MgoDashboardSubscriptionPayment {
+	latest := make(map[string]*models.MgoDashboardSubscriptionPayment)
+
+	for _, payment := range payments {
+		if payment.Date.After(moment) {
+			break
+		}
+
+		// Failed renewal doesn't break the subscription immediately, but refund and chargeback do
+		if payment.Status == dashboardSubscriptionPaymentStatusRejected {
+			continue
+		}
+
+		latest[payment.RecurringId] = payment
+	}
+
+	active := make(map[string]*models.MgoDashboardSubscriptionPayment)
+
+	for id, payment := range latest {
+		if payment.Status != dashboardSubscriptionPaymentStatusProcessed {
+			continue
+		}
+
+		if getSubscriptionPaymentPeriodEnd(payment).After(moment) {
+			active[id] = payment
+		}
+	}
+
+	return active
+}
+
+func getSubscriptionPaymentPeriodEnd(payment *models.MgoDashboardSubscriptionPayment) time.Time {
+	switch payment.Period {
+	case recurringpb.RecurringPeriodDay:
+		return payment.Date.AddDate(0, 0, 1)
+	case recurringpb.RecurringPeriodWeek:
+		return payment.Date.AddDate(0, 0, 7)
+	case recurringpb.RecurringPeriodYear:
+		return payment.Date.AddDate(1, 0, 0)
+	default:
+		return payment.Date.AddDate(0, 1, 0)
+	}
+}
+
+// getSubscriptionPaymentMrr returns payment amount normalized to one month.
+func getSubscriptionPaymentMrr(payment *models.MgoDashboardSubscriptionPayment) float64 {
+	switch payment.Period {
+	case recurringpb.RecurringPeriodDay:
+		return payment.Amount * 365 / 12
+	case recurringpb.RecurringPeriodWeek:
+		return payment.Amount * 52 / 12
+	case recurringpb.RecurringPeriodYear:
+		return payment.Amount / 12
+	default:
+		return payment.Amount
+	}
+}
@@ -14,6 +14,7 @@ type DashboardProcessorRepositoryInterface interface {
 	ExecuteRevenueByCountryReport(ctx context.Context, out interface{}) (interface{}, error)
 	ExecuteSalesTodayReport(ctx context.Context, out interface{}) (interface{}, error)
 	ExecuteSourcesReport(ctx context.Context, out interface{}) (interface{}, error)
+	ExecuteSubscriptionsReport(ctx context.Context, out interface{}) (interface{}, error)
 
 	ExecuteCustomerLTV(ctx context.Context, out interface{}) (interface{}, interface{}, error)
 	ExecuteCustomerARPU(ctx context.Context, customerId string) (interface{}, error)
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-proto/go/recurringpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type DashboardProcessorTestSuite struct {
+	suite.Suite
+	from time.Time
+	to   time.Time
+}
+
+func Test_DashboardProcessor(t *testing.T) {
+	suite.Run(t, new(DashboardProcessorTestSuite))
+}
+
+func (suite *DashboardProcessorTestSuite) SetupTest() {
+	suite.from = time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC)
+	suite.to = time.Date(2021, time.February, 28, 23, 59, 59, 0, time.UTC)
+}
+
+func (suite *DashboardProcessorTestSuite) getPayment(
+	id, period, status string,
+	amount float64,
+	renewal bool,
+	date time.Time,
+) *models.MgoDashboardSubscriptionPayment {
+	return &models.MgoDashboardSubscriptionPayment{
+		RecurringId: id,
+		Amount:      amount,
+		Currency:    "USD",
+		Period:      period,
+		Status:      status,
+		Renewal:     renewal,
+		Date:        date,
+	}
+}
+
+func (suite *DashboardProcessorTestSuite) TestDashboardProcessor_CalculateSubscriptionsReport_Empty() {
+	report := calculateSubscriptionsReport(nil, suite.from, suite.to)
+	assert.NotNil(suite.T(), report)
+	assert.Zero(suite.T(), report.Mrr)
+	assert.Zero(suite.T(), report.ActiveSubscribers)
+	assert.Zero(suite.T(), report.ChurnRate)
+	assert.Zero(suite.T(), report.RenewalSuccessRate)
+	assert.Empty(suite.T(), report.Currency)
+}
+
+func (suite *DashboardProcessorTestSuite) TestDashboardProcessor_CalculateSubscriptionsReport_Ok() {
+	month := recurringpb.RecurringPeriodMonth
+	payments := []*models.MgoDashboardSubscriptionPayment{
+		// yearly subscription paid before the period and still active
+		suite.getPayment("yearly", recurringpb.RecurringPeriodYear, "processed", 120, false, time.Date(2020, time.June, 10, 0, 0, 0, 0, time.UTC)),
+		// churned voluntarily: no renewal attempts in the period
+		suite.getPayment("voluntary", month, "refunded", 10, false, time.Date(2020, time.December, 5, 0, 0, 0, 0, time.UTC)),
+		suite.getPayment("voluntary", month, "processed", 10, false, time.Date(2021, time.January, 5, 0, 0, 0, 0, time.UTC)),
+		// renewed with upgraded plan
+		suite.getPayment("expansion", month, "processed", 10, false, time.Date(2021, time.January, 10, 0, 0, 0, 0, time.UTC)),
+		// churned involuntarily: renewal payment was rejected
+		suite.getPayment("involuntary", month, "processed", 20, false, time.Date(2021, time.January, 15, 0, 0, 0, 0, time.UTC)),
+		suite.getPayment("expansion", month, "processed", 15, true, time.Date(2021, time.February, 10, 0, 0, 0, 0, time.UTC)),
+		suite.getPayment("involuntary", month, "rejected", 20, true, time.Date(2021, time.February, 15, 0, 0, 0, 0, time.UTC)),
+		// new subscription
+		suite.getPayment("new", recurringpb.RecurringPeriodWeek, "processed", 3, false, time.Date(2021, time.February, 25, 0, 0, 0, 0, time.UTC)),
+	}
+
+	report := calculateSubscriptionsReport(payments, suite.from, suite.to)
+	assert.Equal(suite.T(), "USD", report.Currency)
+
+	assert.EqualValues(suite.T(), 4, report.ActiveSubscribersPrevious)
+	assert.EqualValues(suite.T(), 3, report.ActiveSubscribers)
+	assert.Equal(suite.T(), float64(50), report.MrrPrevious)
+	assert.Equal(suite.T(), float64(38), report.Mrr)
+	assert.Equal(suite.T(), float64(456), report.Arr)
+
+	assert.EqualValues(suite.T(), 1, report.NewSubscribers)
+	assert.Equal(suite.T(), float64(13), report.NewMrr)
+	assert.Equal(suite.T(), float64(5), report.ExpansionMrr)
+	assert.Zero(suite.T(), report.ContractionMrr)
+
+	assert.EqualValues(suite.T(), 2, report.ChurnedSubscribers)
+	assert.EqualValues(suite.T(), 1, report.InvoluntaryChurnedSubscribers)
+	assert.Equal(suite.T(), float64(30), report.ChurnedMrr)
+	assert.Equal(suite.T(), float64(50), report.ChurnRate)
+	assert.Equal(suite.T(), float64(25), report.InvoluntaryChurnRate)
+
+	assert.EqualValues(suite.T(), 2, report.RenewalAttempts)
+	assert.EqualValues(suite.T(), 1, report.RenewalSuccessful)
+	assert.Equal(suite.T(), float64(50), report.RenewalSuccessRate)
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionFxRevaluation = "fx_revaluation"
+)
+
+type fxRevaluationRepository repository
+
+// NewFxRevaluationRepository create and return an object for working with the currency revaluation repository.
+// The returned object implements the FxRevaluationRepositoryInterface interface.
+func NewFxRevaluationRepository(db mongodb.SourceInterface) FxRevaluationRepositoryInterface {
+	s := &fxRevaluationRepository{db: db}
+	return s
+}
+
+func (r *fxRevaluationRepository) Insert(ctx context.Context, obj *intPkg.FxRevaluation) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+	}
+
+	if obj.CreatedAt.IsZero() {
+		obj.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Collection(collectionFxRevaluation).InsertOne(ctx, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionFxRevaluation),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *fxRevaluationRepository) GetLast(
+	ctx context.Context,
+	merchantId, currency, positionType string,
+	date time.Time,
+) (*intPkg.FxRevaluation, error) {
+	query := bson.M{
+		"merchant_id":   merchantId,
+		"currency":      currency,
+		"type":          pkg.FxRevaluationTypeUnrealized,
+		"position_type": positionType,
+		"date":          bson.M{"$lte": date},
+	}
+	sorts := bson.D{{"date", -1}, {"_id", -1}}
+
+	return r.findOne(ctx, query, sorts)
+}
+
+func (r *fxRevaluationRepository) GetBySource(ctx context.Context, sourceId string) (*intPkg.FxRevaluation, error) {
+	query := bson.M{
+		"source_id": sourceId,
+		"type":      pkg.FxRevaluationTypeRealized,
+	}
+	sorts := bson.D{{"_id", -1}}
+
+	return r.findOne(ctx, query, sorts)
+}
+
+func (r *fxRevaluationRepository) GetReport(
+	ctx context.Context,
+	operatingCompanyId string,
+	from, to time.Time,
+) ([]*intPkg.FxRevaluationReportItem, error) {
+	query := []bson.M{
+		{
+			"$match": bson.M{
+				"operating_company_id": operatingCompanyId,
+				"date":                 bson.M{"$gte": from, "$lte": to},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":         bson.M{"type": "$type", "currency": "$currency"},
+				"count":       bson.M{"$sum": 1},
+				"amount":      bson.M{"$sum": "$amount"},
+				"gain_amount": bson.M{"$sum": "$gain_amount"},
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":         0,
+				"type":        "$_id.type",
+				"currency":    "$_id.currency",
+				"count":       1,
+				"amount":      1,
+				"gain_amount": 1,
+			},
+		},
+		{"$sort": bson.D{{"type", 1}, {"currency", 1}}},
+	}
+
+	cursor, err := r.db.Collection(collectionFxRevaluation).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionFxRevaluation),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var items []*intPkg.FxRevaluationReportItem
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionFxRevaluation),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *fxRevaluationRepository) findOne(ctx context.Context, query bson.M, sorts bson.D) (*intPkg.FxRevaluation, error) {
+	opts := options.FindOne().SetSort(sorts)
+
+	var obj *intPkg.FxRevaluation
+	err := r.db.Collection(collectionFxRevaluation).FindOne(ctx, query, opts).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionFxRevaluation),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+				zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
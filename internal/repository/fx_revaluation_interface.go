@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"time"
+)
+
+// FxRevaluationRepositoryInterface is abstraction layer for working with currency revaluations of merchants positions.
+type FxRevaluationRepositoryInterface interface {
+	// Insert adds the revaluation to the collection.
+	Insert(context.Context, *intPkg.FxRevaluation) error
+
+	// GetLast returns the last unrealized revaluation of the merchant's position in the currency made before or at the date.
+	// Returns mongo.ErrNoDocuments if the position hasn't been revaluated before the date.
+	GetLast(ctx context.Context, merchantId, currency, positionType string, date time.Time) (*intPkg.FxRevaluation, error)
+
+	// GetBySource returns the realized revaluation made for the source.
+	// Returns mongo.ErrNoDocuments if the source hasn't been revaluated.
+	GetBySource(ctx context.Context, sourceId string) (*intPkg.FxRevaluation, error)
+
+	// GetReport returns gains of revaluations of the operating company by dates grouped by type and currency.
+	GetReport(ctx context.Context, operatingCompanyId string, from, to time.Time) ([]*intPkg.FxRevaluationReportItem, error)
+}
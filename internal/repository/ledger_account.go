@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionLedgerAccount = "ledger_account"
+)
+
+type ledgerAccountRepository repository
+
+// NewLedgerAccountRepository create and return an object for working with the ledger account repository.
+// The returned object implements the LedgerAccountRepositoryInterface interface.
+func NewLedgerAccountRepository(db mongodb.SourceInterface) LedgerAccountRepositoryInterface {
+	s := &ledgerAccountRepository{db: db}
+	return s
+}
+
+func (r *ledgerAccountRepository) Upsert(ctx context.Context, obj *intPkg.LedgerAccount) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+		obj.CreatedAt = time.Now()
+	}
+
+	obj.UpdatedAt = time.Now()
+
+	filter := bson.M{"operating_company_id": obj.OperatingCompanyId, "code": obj.Code}
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.db.Collection(collectionLedgerAccount).ReplaceOne(ctx, filter, obj, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionLedgerAccount),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *ledgerAccountRepository) FindByOperatingCompanyId(ctx context.Context, id string) ([]*intPkg.LedgerAccount, error) {
+	query := bson.M{"operating_company_id": id}
+	opts := options.Find().SetSort(bson.M{"code": 1})
+	cursor, err := r.db.Collection(collectionLedgerAccount).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionLedgerAccount),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var accounts []*intPkg.LedgerAccount
+	err = cursor.All(ctx, &accounts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionLedgerAccount),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// LedgerAccountRepositoryInterface is abstraction layer for working with chart of accounts of operating companies.
+type LedgerAccountRepositoryInterface interface {
+	// Upsert add or update the account to the chart of accounts of the operating company.
+	Upsert(context.Context, *intPkg.LedgerAccount) error
+
+	// FindByOperatingCompanyId returns the chart of accounts of the operating company.
+	FindByOperatingCompanyId(context.Context, string) ([]*intPkg.LedgerAccount, error)
+}
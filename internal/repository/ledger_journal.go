@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionLedgerJournal = "ledger_journal"
+)
+
+type ledgerJournalRepository repository
+
+// NewLedgerJournalRepository create and return an object for working with the ledger journal repository.
+// The returned object implements the LedgerJournalRepositoryInterface interface.
+func NewLedgerJournalRepository(db mongodb.SourceInterface) LedgerJournalRepositoryInterface {
+	s := &ledgerJournalRepository{db: db}
+	return s
+}
+
+func (r *ledgerJournalRepository) Insert(ctx context.Context, obj *intPkg.LedgerJournal) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+	}
+
+	obj.CreatedAt = time.Now()
+	_, err := r.db.Collection(collectionLedgerJournal).InsertOne(ctx, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionLedgerJournal),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *ledgerJournalRepository) DeleteBySource(ctx context.Context, sourceId, sourceType string) error {
+	query := bson.M{"source_id": sourceId, "source_type": sourceType}
+	_, err := r.db.Collection(collectionLedgerJournal).DeleteMany(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionLedgerJournal),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *ledgerJournalRepository) FindByOperatingCompanyDates(
+	ctx context.Context,
+	operatingCompanyId string,
+	from, to time.Time,
+	fn func(*intPkg.LedgerJournal) error,
+) error {
+	query := bson.M{
+		"operating_company_id": operatingCompanyId,
+		"date":                 bson.M{"$gte": from, "$lte": to},
+	}
+	sorts := bson.D{{"date", 1}, {"_id", 1}}
+	opts := options.Find().SetSort(sorts)
+	cursor, err := r.db.Collection(collectionLedgerJournal).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionLedgerJournal),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+		)
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		journal := &intPkg.LedgerJournal{}
+
+		if err = cursor.Decode(journal); err != nil {
+			zap.L().Error(
+				pkg.ErrorQueryCursorExecutionFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionLedgerJournal),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+			return err
+		}
+
+		if err = fn(journal); err != nil {
+			return err
+		}
+	}
+
+	if err = cursor.Err(); err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionLedgerJournal),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *ledgerJournalRepository) GetTrialBalance(
+	ctx context.Context,
+	operatingCompanyId string,
+	from, to time.Time,
+) ([]*intPkg.TrialBalanceItem, error) {
+	query := []bson.M{
+		{
+			"$match": bson.M{
+				"operating_company_id": operatingCompanyId,
+				"date":                 bson.M{"$gte": from, "$lte": to},
+			},
+		},
+		{"$unwind": "$lines"},
+		{
+			"$group": bson.M{
+				"_id":    bson.M{"account_code": "$lines.account_code", "currency": "$lines.currency"},
+				"debit":  bson.M{"$sum": "$lines.debit"},
+				"credit": bson.M{"$sum": "$lines.credit"},
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":          0,
+				"account_code": "$_id.account_code",
+				"currency":     "$_id.currency",
+				"debit":        1,
+				"credit":       1,
+			},
+		},
+		{"$sort": bson.D{{"account_code", 1}, {"currency", 1}}},
+	}
+
+	var items []*intPkg.TrialBalanceItem
+
+	if err := r.aggregate(ctx, query, &items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *ledgerJournalRepository) GetAccountTurnover(
+	ctx context.Context,
+	operatingCompanyId, accountCode, currency, merchantId string,
+	before time.Time,
+) (*intPkg.LedgerTurnover, error) {
+	match := r.getAccountMatch(operatingCompanyId, merchantId, bson.M{"$lt": before})
+	query := []bson.M{
+		{"$match": match},
+		{"$unwind": "$lines"},
+		{"$match": bson.M{"lines.account_code": accountCode, "lines.currency": currency}},
+		{
+			"$group": bson.M{
+				"_id":    nil,
+				"debit":  bson.M{"$sum": "$lines.debit"},
+				"credit": bson.M{"$sum": "$lines.credit"},
+			},
+		},
+	}
+
+	var items []*intPkg.LedgerTurnover
+
+	if err := r.aggregate(ctx, query, &items); err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return &intPkg.LedgerTurnover{}, nil
+	}
+
+	return items[0], nil
+}
+
+func (r *ledgerJournalRepository) GetAccountStatement(
+	ctx context.Context,
+	operatingCompanyId, accountCode, currency, merchantId string,
+	from, to time.Time,
+) ([]*intPkg.LedgerAccountStatementItem, error) {
+	match := r.getAccountMatch(operatingCompanyId, merchantId, bson.M{"$gte": from, "$lte": to})
+	query := []bson.M{
+		{"$match": match},
+		{"$unwind": "$lines"},
+		{"$match": bson.M{"lines.account_code": accountCode, "lines.currency": currency}},
+		{
+			"$project": bson.M{
+				"_id":         0,
+				"journal_id":  bson.M{"$toString": "$_id"},
+				"date":        1,
+				"event_type":  1,
+				"source_id":   1,
+				"source_type": 1,
+				"merchant_id": 1,
+				"entry_type":  "$lines.entry_type",
+				"debit":       "$lines.debit",
+				"credit":      "$lines.credit",
+			},
+		},
+		{"$sort": bson.D{{"date", 1}, {"journal_id", 1}}},
+	}
+
+	var items []*intPkg.LedgerAccountStatementItem
+
+	if err := r.aggregate(ctx, query, &items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *ledgerJournalRepository) getAccountMatch(operatingCompanyId, merchantId string, date bson.M) bson.M {
+	match := bson.M{
+		"operating_company_id": operatingCompanyId,
+		"date":                 date,
+	}
+
+	if merchantId != "" {
+		match["merchant_id"] = merchantId
+	}
+
+	return match
+}
+
+func (r *ledgerJournalRepository) aggregate(ctx context.Context, query []bson.M, result interface{}) error {
+	cursor, err := r.db.Collection(collectionLedgerJournal).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionLedgerJournal),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return err
+	}
+
+	err = cursor.All(ctx, result)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionLedgerJournal),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return err
+	}
+
+	return nil
+}
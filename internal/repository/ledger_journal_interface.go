@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"time"
+)
+
+// LedgerJournalRepositoryInterface is abstraction layer for working with journals of the general ledger.
+type LedgerJournalRepositoryInterface interface {
+	// Insert adds the journal to the collection.
+	Insert(context.Context, *intPkg.LedgerJournal) error
+
+	// DeleteBySource deletes journals posted by the accounting events of the source.
+	DeleteBySource(ctx context.Context, sourceId, sourceType string) error
+
+	// FindByOperatingCompanyDates iterates journals of the operating company by dates in order of date and passes
+	// each journal to the function, iteration stops on the first error returned by the function.
+	FindByOperatingCompanyDates(ctx context.Context, operatingCompanyId string, from, to time.Time, fn func(*intPkg.LedgerJournal) error) error
+
+	// GetTrialBalance returns debit and credit turnovers of each account of the operating company by dates.
+	GetTrialBalance(ctx context.Context, operatingCompanyId string, from, to time.Time) ([]*intPkg.TrialBalanceItem, error)
+
+	// GetAccountTurnover returns debit and credit turnovers of the account in the currency before the date.
+	GetAccountTurnover(ctx context.Context, operatingCompanyId, accountCode, currency, merchantId string, before time.Time) (*intPkg.LedgerTurnover, error)
+
+	// GetAccountStatement returns the legs of the account in the currency by dates ordered by date.
+	GetAccountStatement(ctx context.Context, operatingCompanyId, accountCode, currency, merchantId string, from, to time.Time) ([]*intPkg.LedgerAccountStatementItem, error)
+}
@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionMerchantBalanceTransaction = "merchant_balance_transaction"
+)
+
+type merchantBalanceTransactionRepository repository
+
+// NewMerchantBalanceTransactionRepository create and return an object for working with the merchant balance transaction repository.
+// The returned object implements the MerchantBalanceTransactionRepositoryInterface interface.
+func NewMerchantBalanceTransactionRepository(db mongodb.SourceInterface) MerchantBalanceTransactionRepositoryInterface {
+	s := &merchantBalanceTransactionRepository{db: db}
+	return s
+}
+
+func (r *merchantBalanceTransactionRepository) Insert(ctx context.Context, obj *intPkg.MerchantBalanceTransaction) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+	}
+
+	_, err := r.db.Collection(collectionMerchantBalanceTransaction).InsertOne(ctx, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBalanceTransaction),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *merchantBalanceTransactionRepository) GetLast(
+	ctx context.Context,
+	merchantId, currency string,
+	date time.Time,
+) (*intPkg.MerchantBalanceTransaction, error) {
+	query := bson.M{
+		"merchant_id": merchantId,
+		"currency":    currency,
+		"created_at":  bson.M{"$lte": date},
+	}
+	sorts := bson.D{{"created_at", -1}, {"_id", -1}}
+	opts := options.FindOne().SetSort(sorts)
+
+	var obj *intPkg.MerchantBalanceTransaction
+	err := r.db.Collection(collectionMerchantBalanceTransaction).FindOne(ctx, query, opts).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBalanceTransaction),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+				zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *merchantBalanceTransactionRepository) GetPendingAmount(
+	ctx context.Context,
+	merchantId, currency string,
+	date time.Time,
+) (float64, error) {
+	query := bson.M{
+		"merchant_id":  merchantId,
+		"currency":     currency,
+		"created_at":   bson.M{"$lte": date},
+		"available_at": bson.M{"$gt": date},
+	}
+
+	return r.getAmount(ctx, query)
+}
+
+func (r *merchantBalanceTransactionRepository) GetBalance(
+	ctx context.Context,
+	merchantId, currency string,
+	date time.Time,
+) (float64, error) {
+	query := bson.M{
+		"merchant_id": merchantId,
+		"currency":    currency,
+		"created_at":  bson.M{"$lte": date},
+	}
+
+	return r.getAmount(ctx, query)
+}
+
+func (r *merchantBalanceTransactionRepository) GetBalanceBefore(
+	ctx context.Context,
+	obj *intPkg.MerchantBalanceTransaction,
+) (float64, error) {
+	query := bson.M{
+		"merchant_id": obj.MerchantId,
+		"currency":    obj.Currency,
+		"$or": []bson.M{
+			{"created_at": bson.M{"$lt": obj.CreatedAt}},
+			{"created_at": obj.CreatedAt, "_id": bson.M{"$lt": obj.Id}},
+		},
+	}
+
+	return r.getAmount(ctx, query)
+}
+
+func (r *merchantBalanceTransactionRepository) GetAmountsBySource(
+	ctx context.Context,
+	sourceId, sourceType string,
+	types []string,
+) (map[string]float64, error) {
+	query := []bson.M{
+		{
+			"$match": bson.M{
+				"source_id":   sourceId,
+				"source_type": sourceType,
+				"type":        bson.M{"$in": types},
+			},
+		},
+		{"$group": bson.M{"_id": "$currency", "amount": bson.M{"$sum": "$amount"}}},
+	}
+
+	cursor, err := r.db.Collection(collectionMerchantBalanceTransaction).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBalanceTransaction),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var res []struct {
+		Currency string  `bson:"_id"`
+		Amount   float64 `bson:"amount"`
+	}
+	err = cursor.All(ctx, &res)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBalanceTransaction),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	amounts := make(map[string]float64)
+
+	for _, v := range res {
+		amounts[v.Currency] = v.Amount
+	}
+
+	return amounts, nil
+}
+
+func (r *merchantBalanceTransactionRepository) DeleteBySource(
+	ctx context.Context,
+	sourceId, sourceType string,
+	types []string,
+) error {
+	query := bson.M{
+		"source_id":   sourceId,
+		"source_type": sourceType,
+		"type":        bson.M{"$in": types},
+	}
+
+	_, err := r.db.Collection(collectionMerchantBalanceTransaction).DeleteMany(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBalanceTransaction),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *merchantBalanceTransactionRepository) Find(
+	ctx context.Context,
+	merchantId, currency string,
+	types []string,
+	from, to time.Time,
+	offset, limit int64,
+) ([]*intPkg.MerchantBalanceTransaction, error) {
+	query := r.getFindQuery(merchantId, currency, types, from, to)
+	sorts := bson.D{{"created_at", 1}, {"_id", 1}}
+	opts := options.Find().SetSort(sorts).SetSkip(offset)
+
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := r.db.Collection(collectionMerchantBalanceTransaction).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBalanceTransaction),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+		)
+		return nil, err
+	}
+
+	var items []*intPkg.MerchantBalanceTransaction
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBalanceTransaction),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *merchantBalanceTransactionRepository) FindCount(
+	ctx context.Context,
+	merchantId, currency string,
+	types []string,
+	from, to time.Time,
+) (int64, error) {
+	query := r.getFindQuery(merchantId, currency, types, from, to)
+	count, err := r.db.Collection(collectionMerchantBalanceTransaction).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBalanceTransaction),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *merchantBalanceTransactionRepository) GetMerchantCurrencies(
+	ctx context.Context,
+	date time.Time,
+) ([]*intPkg.MerchantBalanceCurrency, error) {
+	query := []bson.M{
+		{"$match": bson.M{"created_at": bson.M{"$lte": date}}},
+		{"$group": bson.M{"_id": bson.M{"merchant_id": "$merchant_id", "currency": "$currency"}}},
+		{"$project": bson.M{"_id": 0, "merchant_id": "$_id.merchant_id", "currency": "$_id.currency"}},
+		{"$sort": bson.D{{"merchant_id", 1}, {"currency", 1}}},
+	}
+
+	cursor, err := r.db.Collection(collectionMerchantBalanceTransaction).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBalanceTransaction),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var items []*intPkg.MerchantBalanceCurrency
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBalanceTransaction),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *merchantBalanceTransactionRepository) getFindQuery(
+	merchantId, currency string,
+	types []string,
+	from, to time.Time,
+) bson.M {
+	query := bson.M{
+		"merchant_id": merchantId,
+		"currency":    currency,
+	}
+
+	if len(types) > 0 {
+		query["type"] = bson.M{"$in": types}
+	}
+
+	date := bson.M{}
+
+	if !from.IsZero() {
+		date["$gte"] = from
+	}
+
+	if !to.IsZero() {
+		date["$lte"] = to
+	}
+
+	if len(date) > 0 {
+		query["created_at"] = date
+	}
+
+	return query
+}
+
+func (r *merchantBalanceTransactionRepository) getAmount(ctx context.Context, match bson.M) (float64, error) {
+	query := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{"_id": nil, "amount": bson.M{"$sum": "$amount"}}},
+	}
+
+	cursor, err := r.db.Collection(collectionMerchantBalanceTransaction).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBalanceTransaction),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return 0, err
+	}
+
+	var res []struct {
+		Amount float64 `bson:"amount"`
+	}
+	err = cursor.All(ctx, &res)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBalanceTransaction),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return 0, err
+	}
+
+	if len(res) == 0 {
+		return 0, nil
+	}
+
+	return res[0].Amount, nil
+}
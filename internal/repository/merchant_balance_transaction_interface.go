@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"time"
+)
+
+// MerchantBalanceTransactionRepositoryInterface is abstraction layer for working with the log of merchant's balance movements.
+type MerchantBalanceTransactionRepositoryInterface interface {
+	// Insert adds the transaction to the collection.
+	Insert(context.Context, *intPkg.MerchantBalanceTransaction) error
+
+	// GetLast returns the last transaction of the merchant in the currency created before or at the date.
+	// Returns mongo.ErrNoDocuments if the merchant has no transactions before the date.
+	GetLast(ctx context.Context, merchantId, currency string, date time.Time) (*intPkg.MerchantBalanceTransaction, error)
+
+	// GetPendingAmount returns the sum of transactions created before or at the date which aren't available at the date.
+	GetPendingAmount(ctx context.Context, merchantId, currency string, date time.Time) (float64, error)
+
+	// GetBalance returns the sum of transactions of the merchant in the currency created before or at the date.
+	GetBalance(ctx context.Context, merchantId, currency string, date time.Time) (float64, error)
+
+	// GetBalanceBefore returns the sum of transactions of the merchant in the currency ordered before the transaction.
+	GetBalanceBefore(ctx context.Context, obj *intPkg.MerchantBalanceTransaction) (float64, error)
+
+	// GetAmountsBySource returns sums of transactions of the source by types grouped by currency.
+	GetAmountsBySource(ctx context.Context, sourceId, sourceType string, types []string) (map[string]float64, error)
+
+	// DeleteBySource removes transactions of the source by types.
+	DeleteBySource(ctx context.Context, sourceId, sourceType string, types []string) error
+
+	// Find returns transactions of the merchant in the currency by types and dates ordered by date.
+	Find(ctx context.Context, merchantId, currency string, types []string, from, to time.Time, offset, limit int64) ([]*intPkg.MerchantBalanceTransaction, error)
+
+	// FindCount returns the count of transactions of the merchant in the currency by types and dates.
+	FindCount(ctx context.Context, merchantId, currency string, types []string, from, to time.Time) (int64, error)
+
+	// GetMerchantCurrencies returns merchants and currencies which have transactions created before or at the date.
+	GetMerchantCurrencies(ctx context.Context, date time.Time) ([]*intPkg.MerchantBalanceCurrency, error)
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionMerchantPayoutAccount = "merchant_payout_account"
+)
+
+type merchantPayoutAccountRepository repository
+
+// NewMerchantPayoutAccountRepository create and return an object for working with the merchant payout account repository.
+// The returned object implements the MerchantPayoutAccountRepositoryInterface interface.
+func NewMerchantPayoutAccountRepository(db mongodb.SourceInterface) MerchantPayoutAccountRepositoryInterface {
+	s := &merchantPayoutAccountRepository{db: db}
+	return s
+}
+
+func (r *merchantPayoutAccountRepository) Upsert(ctx context.Context, obj *intPkg.MerchantPayoutAccount) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+		obj.CreatedAt = time.Now()
+	}
+
+	obj.UpdatedAt = time.Now()
+
+	filter := bson.M{"merchant_id": obj.MerchantId, "currency": obj.Currency}
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.db.Collection(collectionMerchantPayoutAccount).ReplaceOne(ctx, filter, obj, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantPayoutAccount),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *merchantPayoutAccountRepository) Delete(ctx context.Context, obj *intPkg.MerchantPayoutAccount) error {
+	filter := bson.M{"_id": obj.Id}
+	_, err := r.db.Collection(collectionMerchantPayoutAccount).DeleteOne(ctx, filter)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantPayoutAccount),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationDelete),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *merchantPayoutAccountRepository) GetByMerchantIdAndCurrency(
+	ctx context.Context,
+	merchantId, currency string,
+) (*intPkg.MerchantPayoutAccount, error) {
+	query := bson.M{"merchant_id": merchantId, "currency": currency}
+
+	var obj *intPkg.MerchantPayoutAccount
+	err := r.db.Collection(collectionMerchantPayoutAccount).FindOne(ctx, query).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantPayoutAccount),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *merchantPayoutAccountRepository) GetByMerchantId(
+	ctx context.Context,
+	merchantId string,
+) ([]*intPkg.MerchantPayoutAccount, error) {
+	query := bson.M{"merchant_id": merchantId}
+	opts := options.Find().SetSort(bson.M{"currency": 1})
+	cursor, err := r.db.Collection(collectionMerchantPayoutAccount).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantPayoutAccount),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*intPkg.MerchantPayoutAccount
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantPayoutAccount),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return list, nil
+}
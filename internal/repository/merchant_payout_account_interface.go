@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// MerchantPayoutAccountRepositoryInterface is abstraction layer for working with payout accounts of merchants.
+type MerchantPayoutAccountRepositoryInterface interface {
+	// Upsert adds or replaces the account of the merchant in the currency.
+	Upsert(context.Context, *intPkg.MerchantPayoutAccount) error
+
+	// Delete removes the account of the merchant.
+	Delete(context.Context, *intPkg.MerchantPayoutAccount) error
+
+	// GetByMerchantIdAndCurrency returns the account of the merchant in the currency.
+	// Returns mongo.ErrNoDocuments if the merchant has no account in the currency.
+	GetByMerchantIdAndCurrency(ctx context.Context, merchantId, currency string) (*intPkg.MerchantPayoutAccount, error)
+
+	// GetByMerchantId returns accounts of the merchant ordered by currency.
+	GetByMerchantId(ctx context.Context, merchantId string) ([]*intPkg.MerchantPayoutAccount, error)
+}
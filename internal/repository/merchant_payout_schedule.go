@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionMerchantPayoutSchedule = "merchant_payout_schedule"
+)
+
+type merchantPayoutScheduleRepository repository
+
+// NewMerchantPayoutScheduleRepository create and return an object for working with the merchant payout schedule repository.
+// The returned object implements the MerchantPayoutScheduleRepositoryInterface interface.
+func NewMerchantPayoutScheduleRepository(db mongodb.SourceInterface) MerchantPayoutScheduleRepositoryInterface {
+	s := &merchantPayoutScheduleRepository{db: db}
+	return s
+}
+
+func (r *merchantPayoutScheduleRepository) Upsert(ctx context.Context, obj *intPkg.MerchantPayoutSchedule) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+		obj.CreatedAt = time.Now()
+	}
+
+	obj.UpdatedAt = time.Now()
+
+	filter := bson.M{"merchant_id": obj.MerchantId}
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.db.Collection(collectionMerchantPayoutSchedule).ReplaceOne(ctx, filter, obj, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantPayoutSchedule),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *merchantPayoutScheduleRepository) GetByMerchantId(
+	ctx context.Context,
+	merchantId string,
+) (*intPkg.MerchantPayoutSchedule, error) {
+	query := bson.M{"merchant_id": merchantId}
+
+	var obj *intPkg.MerchantPayoutSchedule
+	err := r.db.Collection(collectionMerchantPayoutSchedule).FindOne(ctx, query).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantPayoutSchedule),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *merchantPayoutScheduleRepository) GetAll(ctx context.Context) ([]*intPkg.MerchantPayoutSchedule, error) {
+	query := bson.M{}
+	cursor, err := r.db.Collection(collectionMerchantPayoutSchedule).Find(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantPayoutSchedule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*intPkg.MerchantPayoutSchedule
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantPayoutSchedule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return list, nil
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// MerchantPayoutScheduleRepositoryInterface is abstraction layer for working with payout schedules of merchants.
+type MerchantPayoutScheduleRepositoryInterface interface {
+	// Upsert adds or replaces the schedule of the merchant.
+	Upsert(context.Context, *intPkg.MerchantPayoutSchedule) error
+
+	// GetByMerchantId returns the schedule of the merchant.
+	// Returns mongo.ErrNoDocuments if the merchant has no schedule.
+	GetByMerchantId(ctx context.Context, merchantId string) (*intPkg.MerchantPayoutSchedule, error)
+
+	// GetAll returns schedules of all merchants.
+	GetAll(ctx context.Context) ([]*intPkg.MerchantPayoutSchedule, error)
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	tools "github.com/paysuper/paysuper-tools/number"
+	"time"
 )
 
 type dashboardRevenueDynamicReportItemMapper struct{}
@@ -141,3 +142,14 @@ func (m *dashboardRevenueByCountryReportMapper) MapMgoToObject(obj interface{})
 
 	return out, nil
 }
+
+// MgoDashboardSubscriptionPayment is a payment (or a failed renewal attempt) of the recurring subscription.
+type MgoDashboardSubscriptionPayment struct {
+	RecurringId string    `bson:"recurring_id"`
+	Amount      float64   `bson:"amount"`
+	Currency    string    `bson:"currency"`
+	Period      string    `bson:"period"`
+	Status      string    `bson:"status"`
+	Renewal     bool      `bson:"renewal"`
+	Date        time.Time `bson:"date"`
+}
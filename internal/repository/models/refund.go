@@ -28,6 +28,8 @@ type MgoRefund struct {
 	IsChargeback   bool               `bson:"is_chargeback"`
 	CreatedOrderId primitive.ObjectID `bson:"created_order_id,omitempty"`
 	Reason         string             `bson:"reason"`
+	// ReasonCode isn't a field of the refund message, it's set by the repository on insert of the refund.
+	ReasonCode     string             `bson:"reason_code,omitempty"`
 }
 
 
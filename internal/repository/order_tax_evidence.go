@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionOrderTaxEvidence = "order_tax_evidence"
+)
+
+type orderTaxEvidenceRepository repository
+
+// NewOrderTaxEvidenceRepository create and return an object for working with the repository of evidence
+// of customers location of orders.
+// The returned object implements the OrderTaxEvidenceRepositoryInterface interface.
+func NewOrderTaxEvidenceRepository(db mongodb.SourceInterface) OrderTaxEvidenceRepositoryInterface {
+	s := &orderTaxEvidenceRepository{db: db}
+	return s
+}
+
+func (r *orderTaxEvidenceRepository) Upsert(ctx context.Context, obj *intPkg.OrderTaxEvidence) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+		obj.CreatedAt = time.Now()
+	}
+
+	obj.UpdatedAt = time.Now()
+
+	filter := bson.M{"order_id": obj.OrderId}
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.db.Collection(collectionOrderTaxEvidence).ReplaceOne(ctx, filter, obj, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderTaxEvidence),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *orderTaxEvidenceRepository) GetByOrderId(ctx context.Context, orderId string) (*intPkg.OrderTaxEvidence, error) {
+	var obj *intPkg.OrderTaxEvidence
+	query := bson.M{"order_id": orderId}
+	err := r.db.Collection(collectionOrderTaxEvidence).FindOne(ctx, query).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderTaxEvidence),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *orderTaxEvidenceRepository) FindByOrderIds(
+	ctx context.Context,
+	orderIds []string,
+) ([]*intPkg.OrderTaxEvidence, error) {
+	query := bson.M{"order_id": bson.M{"$in": orderIds}}
+	sorts := bson.M{"created_at": 1}
+	opts := options.Find().SetSort(sorts)
+	cursor, err := r.db.Collection(collectionOrderTaxEvidence).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderTaxEvidence),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+		)
+		return nil, err
+	}
+
+	var items []*intPkg.OrderTaxEvidence
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderTaxEvidence),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// OrderTaxEvidenceRepositoryInterface is abstraction layer for working with evidence of customers location of orders.
+type OrderTaxEvidenceRepositoryInterface interface {
+	// Upsert adds or updates evidence of the order.
+	Upsert(context.Context, *intPkg.OrderTaxEvidence) error
+
+	// GetByOrderId returns evidence of the order.
+	GetByOrderId(ctx context.Context, orderId string) (*intPkg.OrderTaxEvidence, error)
+
+	// FindByOrderIds returns evidence of the orders.
+	FindByOrderIds(ctx context.Context, orderIds []string) ([]*intPkg.OrderTaxEvidence, error)
+}
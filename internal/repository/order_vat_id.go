@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionOrderVatId = "order_vat_id"
+)
+
+type orderVatIdRepository repository
+
+// NewOrderVatIdRepository create and return an object for working with the order VAT ID repository.
+// The returned object implements the OrderVatIdRepositoryInterface interface.
+func NewOrderVatIdRepository(db mongodb.SourceInterface) OrderVatIdRepositoryInterface {
+	s := &orderVatIdRepository{db: db}
+	return s
+}
+
+func (r *orderVatIdRepository) Upsert(ctx context.Context, obj *intPkg.OrderVatId) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+		obj.CreatedAt = time.Now()
+	}
+
+	obj.UpdatedAt = time.Now()
+
+	filter := bson.M{"order_id": obj.OrderId}
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.db.Collection(collectionOrderVatId).ReplaceOne(ctx, filter, obj, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderVatId),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *orderVatIdRepository) GetByOrderId(ctx context.Context, orderId string) (*intPkg.OrderVatId, error) {
+	var obj *intPkg.OrderVatId
+	query := bson.M{"order_id": orderId}
+	err := r.db.Collection(collectionOrderVatId).FindOne(ctx, query).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderVatId),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
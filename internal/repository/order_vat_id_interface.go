@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// OrderVatIdRepositoryInterface is abstraction layer for working with VAT IDs of business payers of orders.
+type OrderVatIdRepositoryInterface interface {
+	// Upsert adds or updates VAT ID of the order.
+	Upsert(context.Context, *intPkg.OrderVatId) error
+
+	// GetByOrderId returns VAT ID of the order.
+	GetByOrderId(ctx context.Context, orderId string) (*intPkg.OrderVatId, error)
+}
@@ -18,6 +18,7 @@ import (
 	"go.uber.org/zap"
 	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
 	"math"
+	"sort"
 	"strings"
 	"time"
 )
@@ -26,6 +27,11 @@ const (
 	CollectionOrderView = "order_view"
 )
 
+var (
+	errorRefundAnalyticsIntervalInvalid = fmt.Errorf("refund analytics interval is invalid")
+	errorRefundAnalyticsGroupInvalid    = fmt.Errorf("refund analytics grouping is invalid")
+)
+
 type orderViewRepository struct {
 	repository
 	publicOrderMapper models.Mapper
@@ -843,7 +849,56 @@ func (r *orderViewRepository) GetPublicByOrderId(ctx context.Context, orderId st
 func (r *orderViewRepository) GetVatSummary(
 	ctx context.Context, operatingCompanyId, country string, isVatDeduction bool, from, to time.Time,
 ) (items []*pkg2.VatReportQueryResItem, err error) {
-	matchQuery := bson.M{
+	query := []bson.M{
+		{
+			"$match": r.getVatSummaryMatchQuery(operatingCompanyId, country, isVatDeduction, from, to),
+		},
+		r.getVatSummaryGroupingQuery(),
+	}
+
+	return r.getVatSummary(ctx, query)
+}
+
+func (r *orderViewRepository) GetReverseChargeVatSummary(
+	ctx context.Context, operatingCompanyId, country string, isVatDeduction bool, from, to time.Time,
+) (items []*pkg2.VatReportQueryResItem, err error) {
+	query := []bson.M{
+		{
+			"$match": r.getVatSummaryMatchQuery(operatingCompanyId, country, isVatDeduction, from, to),
+		},
+		{
+			// VAT ID is stored for the sale order, refunds and renewals are matched by the parent order
+			"$addFields": bson.M{
+				"sale_order_id": bson.M{
+					"$cond": []interface{}{
+						bson.M{"$gt": []string{"$parent_order.id", ""}},
+						"$parent_order.id",
+						bson.M{"$toString": "$_id"},
+					},
+				},
+			},
+		},
+		{
+			"$lookup": bson.M{
+				"from":         collectionOrderVatId,
+				"localField":   "sale_order_id",
+				"foreignField": "order_id",
+				"as":           "order_vat_id",
+			},
+		},
+		{
+			"$match": bson.M{"order_vat_id.reverse_charge": true},
+		},
+		r.getVatSummaryGroupingQuery(),
+	}
+
+	return r.getVatSummary(ctx, query)
+}
+
+func (r *orderViewRepository) getVatSummaryMatchQuery(
+	operatingCompanyId, country string, isVatDeduction bool, from, to time.Time,
+) bson.M {
+	return bson.M{
 		"pm_order_close_date": bson.M{
 			"$gte": now.New(from).BeginningOfDay(),
 			"$lte": now.New(to).EndOfDay(),
@@ -853,25 +908,26 @@ func (r *orderViewRepository) GetVatSummary(
 		"operating_company_id": operatingCompanyId,
 		"is_production":        true,
 	}
+}
 
-	query := []bson.M{
-		{
-			"$match": &matchQuery,
-		},
-		{
-			"$group": bson.M{
-				"_id":                                "$country_code",
-				"count":                              bson.M{"$sum": 1},
-				"payment_gross_revenue_local":        bson.M{"$sum": "$payment_gross_revenue_local.amount"},
-				"payment_tax_fee_local":              bson.M{"$sum": "$payment_tax_fee_local.amount"},
-				"payment_refund_gross_revenue_local": bson.M{"$sum": "$payment_refund_gross_revenue_local.amount"},
-				"payment_refund_tax_fee_local":       bson.M{"$sum": "$payment_refund_tax_fee_local.amount"},
-				"fees_total":                         bson.M{"$sum": "$fees_total_local.amount"},
-				"refund_fees_total":                  bson.M{"$sum": "$refund_fees_total_local.amount"},
-			},
+func (r *orderViewRepository) getVatSummaryGroupingQuery() bson.M {
+	return bson.M{
+		"$group": bson.M{
+			"_id":                                "$country_code",
+			"count":                              bson.M{"$sum": 1},
+			"payment_gross_revenue_local":        bson.M{"$sum": "$payment_gross_revenue_local.amount"},
+			"payment_tax_fee_local":              bson.M{"$sum": "$payment_tax_fee_local.amount"},
+			"payment_refund_gross_revenue_local": bson.M{"$sum": "$payment_refund_gross_revenue_local.amount"},
+			"payment_refund_tax_fee_local":       bson.M{"$sum": "$payment_refund_tax_fee_local.amount"},
+			"fees_total":                         bson.M{"$sum": "$fees_total_local.amount"},
+			"refund_fees_total":                  bson.M{"$sum": "$refund_fees_total_local.amount"},
 		},
 	}
+}
 
+func (r *orderViewRepository) getVatSummary(
+	ctx context.Context, query []bson.M,
+) (items []*pkg2.VatReportQueryResItem, err error) {
 	cursor, err := r.db.Collection(CollectionOrderView).Aggregate(ctx, query)
 
 	if err != nil {
@@ -986,6 +1042,76 @@ func (r *orderViewRepository) GetTurnoverSummary(
 	return res, nil
 }
 
+func (r *orderViewRepository) GetSalesTaxNexusSummary(
+	ctx context.Context, operatingCompanyId, country, currency string, from, to time.Time,
+) ([]*pkg2.SalesTaxNexusQueryResItem, error) {
+	query := []bson.M{
+		{
+			"$match": bson.M{
+				"pm_order_close_date": bson.M{
+					"$gte": from,
+					"$lte": to,
+				},
+				"operating_company_id":                 operatingCompanyId,
+				"country_code":                         country,
+				"is_production":                        true,
+				"type":                                 pkg.OrderTypeOrder,
+				"status":                               recurringpb.OrderPublicStatusProcessed,
+				"payment_gross_revenue_local.currency": currency,
+			},
+		},
+		{
+			"$project": bson.M{
+				"amount": "$payment_gross_revenue_local.amount",
+				"state": bson.M{
+					"$cond": []interface{}{
+						bson.M{"$gt": []interface{}{bson.M{"$ifNull": []string{"$billing_address.state", ""}}, ""}},
+						"$billing_address.state",
+						bson.M{"$ifNull": []string{"$user.address.state", ""}},
+					},
+				},
+			},
+		},
+		{
+			"$match": bson.M{"state": bson.M{"$ne": ""}},
+		},
+		{
+			"$group": bson.M{
+				"_id":    "$state",
+				"count":  bson.M{"$sum": 1},
+				"amount": bson.M{"$sum": "$amount"},
+			},
+		},
+	}
+
+	cursor, err := r.db.Collection(CollectionOrderView).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionOrderView),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var items []*pkg2.SalesTaxNexusQueryResItem
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionOrderView),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
+
 func (r *orderViewRepository) GetRoyaltyForMerchants(
 	ctx context.Context, statuses []string, from time.Time, to time.Time,
 ) ([]*pkg2.RoyaltyReportMerchant, error) {
@@ -1314,3 +1440,212 @@ func (r *orderViewRepository) GetCountBy(ctx context.Context, filter bson.M, opt
 
 	return count, nil
 }
+
+func (r *orderViewRepository) GetRefundAnalytics(
+	ctx context.Context,
+	merchantId, projectId, groupBy, interval string,
+	from, to time.Time,
+) ([]*pkg2.RefundAnalyticsItem, error) {
+	merchantOid, _ := primitive.ObjectIDFromHex(merchantId)
+	dateFormats := map[string]string{
+		pkg.RefundAnalyticsIntervalDay:   "%Y-%m-%d",
+		pkg.RefundAnalyticsIntervalWeek:  "%G-W%V",
+		pkg.RefundAnalyticsIntervalMonth: "%Y-%m",
+	}
+	groups := map[string]interface{}{
+		pkg.RefundAnalyticsGroupByProject: bson.M{"$toString": "$project._id"},
+		pkg.RefundAnalyticsGroupByProduct: bson.M{
+			"$ifNull": []interface{}{bson.M{"$toString": bson.M{"$arrayElemAt": []interface{}{"$items._id", 0}}}, ""},
+		},
+		pkg.RefundAnalyticsGroupByPaymentMethod: "$payment_method.name",
+		pkg.RefundAnalyticsGroupByCountry:       "$country_code",
+		pkg.RefundAnalyticsGroupByReason:        "$refund.code",
+	}
+
+	dateFormat, ok := dateFormats[interval]
+
+	if !ok {
+		return nil, errorRefundAnalyticsIntervalInvalid
+	}
+
+	group, ok := groups[groupBy]
+
+	if !ok {
+		return nil, errorRefundAnalyticsGroupInvalid
+	}
+
+	match := bson.M{
+		"merchant_id":         merchantOid,
+		"pm_order_close_date": bson.M{"$gte": from, "$lte": to},
+		"is_production":       true,
+	}
+
+	if projectId != "" {
+		projectOid, _ := primitive.ObjectIDFromHex(projectId)
+		match["project._id"] = projectOid
+	}
+
+	paymentsMatch := bson.M{
+		"type": pkg.OrderTypeOrder,
+		"status": bson.M{"$in": []string{
+			recurringpb.OrderPublicStatusProcessed,
+			recurringpb.OrderPublicStatusRefunded,
+			recurringpb.OrderPublicStatusChargeback,
+		}},
+	}
+	refundsMatch := bson.M{
+		"type":   pkg.OrderTypeRefund,
+		"status": bson.M{"$in": []string{recurringpb.OrderPublicStatusRefunded, recurringpb.OrderPublicStatusChargeback}},
+	}
+
+	for k, v := range match {
+		paymentsMatch[k] = v
+		refundsMatch[k] = v
+	}
+
+	paymentsGroup := group
+
+	// Payments haven't reason, so refunds by each reason are compared with all payments of the interval
+	if groupBy == pkg.RefundAnalyticsGroupByReason {
+		paymentsGroup = ""
+	}
+
+	payments, err := r.getRefundAnalyticsGroups(ctx, paymentsMatch, paymentsGroup, dateFormat, "$gross_revenue.amount")
+
+	if err != nil {
+		return nil, err
+	}
+
+	refunds, err := r.getRefundAnalyticsGroups(ctx, refundsMatch, group, dateFormat, "$refund_gross_revenue.amount")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return calculateRefundAnalytics(groupBy, payments, refunds), nil
+}
+
+func (r *orderViewRepository) getRefundAnalyticsGroups(
+	ctx context.Context,
+	match bson.M,
+	group interface{},
+	dateFormat, amountField string,
+) ([]*pkg2.RefundAnalyticsQueryResItem, error) {
+	query := []bson.M{
+		{"$match": match},
+		{
+			"$group": bson.M{
+				"_id": bson.M{
+					"group":    group,
+					"date":     bson.M{"$dateToString": bson.M{"format": dateFormat, "date": "$pm_order_close_date"}},
+					"currency": "$merchant_payout_currency",
+				},
+				"count":  bson.M{"$sum": 1},
+				"amount": bson.M{"$sum": amountField},
+			},
+		},
+	}
+
+	cursor, err := r.db.Collection(CollectionOrderView).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionOrderView),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var items []*pkg2.RefundAnalyticsQueryResItem
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionOrderView),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func calculateRefundAnalytics(
+	groupBy string,
+	payments, refunds []*pkg2.RefundAnalyticsQueryResItem,
+) []*pkg2.RefundAnalyticsItem {
+	var items []*pkg2.RefundAnalyticsItem
+	index := make(map[string]*pkg2.RefundAnalyticsItem)
+	paymentsIndex := make(map[string]*pkg2.RefundAnalyticsQueryResItem)
+
+	getKey := func(group, date, currency string) string {
+		return group + "|" + date + "|" + currency
+	}
+	getItem := func(group, date, currency string) *pkg2.RefundAnalyticsItem {
+		key := getKey(group, date, currency)
+
+		if item, ok := index[key]; ok {
+			return item
+		}
+
+		item := &pkg2.RefundAnalyticsItem{Group: group, Date: date, Currency: currency}
+		index[key] = item
+		items = append(items, item)
+
+		return item
+	}
+
+	for _, v := range payments {
+		paymentsIndex[getKey(v.Id.Group, v.Id.Date, v.Id.Currency)] = v
+
+		if groupBy == pkg.RefundAnalyticsGroupByReason {
+			continue
+		}
+
+		item := getItem(v.Id.Group, v.Id.Date, v.Id.Currency)
+		item.PaymentsCount = v.Count
+		item.PaymentsAmount = v.Amount
+	}
+
+	for _, v := range refunds {
+		item := getItem(v.Id.Group, v.Id.Date, v.Id.Currency)
+		item.RefundsCount = v.Count
+		item.RefundsAmount = v.Amount
+
+		if groupBy != pkg.RefundAnalyticsGroupByReason {
+			continue
+		}
+
+		if payment, ok := paymentsIndex[getKey("", v.Id.Date, v.Id.Currency)]; ok {
+			item.PaymentsCount = payment.Count
+			item.PaymentsAmount = payment.Amount
+		}
+	}
+
+	for _, item := range items {
+		item.PaymentsAmount = tools.FormatAmount(item.PaymentsAmount)
+		item.RefundsAmount = tools.FormatAmount(item.RefundsAmount)
+
+		if item.PaymentsCount > 0 {
+			item.RefundRate = tools.FormatAmount(float64(item.RefundsCount) / float64(item.PaymentsCount) * 100)
+		}
+
+		if item.PaymentsAmount > 0 {
+			item.RefundAmountRate = tools.FormatAmount(item.RefundsAmount / item.PaymentsAmount * 100)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Date != items[j].Date {
+			return items[i].Date < items[j].Date
+		}
+
+		return items[i].Group < items[j].Group
+	})
+
+	return items
+}
@@ -54,9 +54,17 @@ type OrderViewRepositoryInterface interface {
 	// GetVatSummary returns orders for summary vat report by operating company id, country, vat deduction and dates.
 	GetVatSummary(context.Context, string, string, bool, time.Time, time.Time) ([]*pkg.VatReportQueryResItem, error)
 
+	// GetReverseChargeVatSummary returns orders of business payers with VAT ID zero-rated under the reverse charge
+	// for summary vat report by operating company id, country, vat deduction and dates.
+	GetReverseChargeVatSummary(context.Context, string, string, bool, time.Time, time.Time) ([]*pkg.VatReportQueryResItem, error)
+
 	// GetTurnoverSummary returns orders for summary turnover report by operating company id, country, currency policy and dates.
 	GetTurnoverSummary(context.Context, string, string, string, time.Time, time.Time) ([]*pkg.TurnoverQueryResItem, error)
 
+	// GetSalesTaxNexusSummary returns counts and gross revenues in the currency of orders of the operating company
+	// to customers of the country grouped by state of customers.
+	GetSalesTaxNexusSummary(ctx context.Context, operatingCompanyId, country, currency string, from, to time.Time) ([]*pkg.SalesTaxNexusQueryResItem, error)
+
 	// GetRoyaltyForMerchants returns orders for merchants royal report by statuses and dates.
 	GetRoyaltyForMerchants(context.Context, []string, time.Time, time.Time) ([]*pkg.RoyaltyReportMerchant, error)
 
@@ -68,4 +76,7 @@ type OrderViewRepositoryInterface interface {
 
 	// GetRoyaltySummary returns orders for summary royal report by merchant id, currency and dates with checking exists royalty report.
 	GetRoyaltySummaryRoundedAmounts(ctx context.Context, merchantId, currency string, from, to time.Time) (items []*billingpb.RoyaltyReportProductSummaryItem, total *billingpb.RoyaltyReportProductSummaryItem, ordersIds []primitive.ObjectID, err error)
+
+	// GetRefundAnalytics returns payments and refunds of the merchant grouped by the dimension and the date interval.
+	GetRefundAnalytics(ctx context.Context, merchantId, projectId, groupBy, interval string, from, to time.Time) ([]*pkg.RefundAnalyticsItem, error)
 }
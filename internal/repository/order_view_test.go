@@ -0,0 +1,75 @@
+package repository
+
+import (
+	pkg2 "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type OrderViewTestSuite struct {
+	suite.Suite
+}
+
+func Test_OrderView(t *testing.T) {
+	suite.Run(t, new(OrderViewTestSuite))
+}
+
+func (suite *OrderViewTestSuite) getRefundAnalyticsRow(group, date string, count int64, amount float64) *pkg2.RefundAnalyticsQueryResItem {
+	row := &pkg2.RefundAnalyticsQueryResItem{Count: count, Amount: amount}
+	row.Id.Group = group
+	row.Id.Date = date
+	row.Id.Currency = "USD"
+
+	return row
+}
+
+func (suite *OrderViewTestSuite) TestOrderView_CalculateRefundAnalytics_ByCountry() {
+	payments := []*pkg2.RefundAnalyticsQueryResItem{
+		suite.getRefundAnalyticsRow("US", "2021-02", 10, 1000),
+		suite.getRefundAnalyticsRow("RU", "2021-02", 4, 200),
+		suite.getRefundAnalyticsRow("US", "2021-01", 5, 500),
+	}
+	refunds := []*pkg2.RefundAnalyticsQueryResItem{
+		suite.getRefundAnalyticsRow("US", "2021-02", 1, 150),
+		suite.getRefundAnalyticsRow("RU", "2021-02", 1, 50),
+	}
+
+	items := calculateRefundAnalytics(pkg.RefundAnalyticsGroupByCountry, payments, refunds)
+	assert.Len(suite.T(), items, 3)
+
+	assert.Equal(suite.T(), "2021-01", items[0].Date)
+	assert.EqualValues(suite.T(), 5, items[0].PaymentsCount)
+	assert.Zero(suite.T(), items[0].RefundRate)
+
+	assert.Equal(suite.T(), "RU", items[1].Group)
+	assert.EqualValues(suite.T(), 25, items[1].RefundRate)
+	assert.EqualValues(suite.T(), 25, items[1].RefundAmountRate)
+
+	assert.Equal(suite.T(), "US", items[2].Group)
+	assert.EqualValues(suite.T(), 10, items[2].RefundRate)
+	assert.EqualValues(suite.T(), 15, items[2].RefundAmountRate)
+}
+
+func (suite *OrderViewTestSuite) TestOrderView_CalculateRefundAnalytics_ByReason() {
+	payments := []*pkg2.RefundAnalyticsQueryResItem{
+		suite.getRefundAnalyticsRow("", "2021-02", 20, 2000),
+	}
+	refunds := []*pkg2.RefundAnalyticsQueryResItem{
+		suite.getRefundAnalyticsRow(pkg.RefundReasonFraud, "2021-02", 2, 100),
+		suite.getRefundAnalyticsRow(pkg.RefundReasonDuplicate, "2021-02", 1, 300),
+	}
+
+	items := calculateRefundAnalytics(pkg.RefundAnalyticsGroupByReason, payments, refunds)
+	assert.Len(suite.T(), items, 2)
+
+	assert.Equal(suite.T(), pkg.RefundReasonDuplicate, items[0].Group)
+	assert.EqualValues(suite.T(), 20, items[0].PaymentsCount)
+	assert.EqualValues(suite.T(), 5, items[0].RefundRate)
+	assert.EqualValues(suite.T(), 15, items[0].RefundAmountRate)
+
+	assert.Equal(suite.T(), pkg.RefundReasonFraud, items[1].Group)
+	assert.EqualValues(suite.T(), 10, items[1].RefundRate)
+	assert.EqualValues(suite.T(), 5, items[1].RefundAmountRate)
+}
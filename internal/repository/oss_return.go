@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionOssReturn = "oss_return"
+)
+
+type ossReturnRepository repository
+
+// NewOssReturnRepository create and return an object for working with the OSS return repository.
+// The returned object implements the OssReturnRepositoryInterface interface.
+func NewOssReturnRepository(db mongodb.SourceInterface) OssReturnRepositoryInterface {
+	s := &ossReturnRepository{db: db}
+	return s
+}
+
+func (r *ossReturnRepository) Insert(ctx context.Context, obj *intPkg.OssReturn) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+	}
+
+	obj.CreatedAt = time.Now()
+	obj.UpdatedAt = obj.CreatedAt
+	_, err := r.db.Collection(collectionOssReturn).InsertOne(ctx, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOssReturn),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *ossReturnRepository) Update(ctx context.Context, obj *intPkg.OssReturn) error {
+	obj.UpdatedAt = time.Now()
+	filter := bson.M{"_id": obj.Id}
+	_, err := r.db.Collection(collectionOssReturn).ReplaceOne(ctx, filter, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOssReturn),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *ossReturnRepository) GetById(ctx context.Context, id string) (*intPkg.OssReturn, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOssReturn),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (r *ossReturnRepository) Find(
+	ctx context.Context,
+	operatingCompanyId string,
+	year int32,
+	status []string,
+	offset, limit int64,
+) ([]*intPkg.OssReturn, error) {
+	query := r.getFindQuery(operatingCompanyId, year, status)
+	sorts := bson.D{{"year", -1}, {"quarter", -1}, {"created_at", -1}}
+	opts := options.Find().SetSort(sorts).SetSkip(offset)
+
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := r.db.Collection(collectionOssReturn).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOssReturn),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+		)
+		return nil, err
+	}
+
+	var items []*intPkg.OssReturn
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOssReturn),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *ossReturnRepository) FindCount(
+	ctx context.Context,
+	operatingCompanyId string,
+	year int32,
+	status []string,
+) (int64, error) {
+	query := r.getFindQuery(operatingCompanyId, year, status)
+	count, err := r.db.Collection(collectionOssReturn).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOssReturn),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *ossReturnRepository) findOne(ctx context.Context, query bson.M) (*intPkg.OssReturn, error) {
+	var obj *intPkg.OssReturn
+	err := r.db.Collection(collectionOssReturn).FindOne(ctx, query).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionOssReturn),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *ossReturnRepository) getFindQuery(operatingCompanyId string, year int32, status []string) bson.M {
+	query := bson.M{}
+
+	if operatingCompanyId != "" {
+		query["operating_company_id"] = operatingCompanyId
+	}
+
+	if year > 0 {
+		query["year"] = year
+	}
+
+	if len(status) > 0 {
+		query["status"] = bson.M{"$in": status}
+	}
+
+	return query
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// OssReturnRepositoryInterface is abstraction layer for working with One-Stop-Shop VAT returns
+// of operating companies.
+type OssReturnRepositoryInterface interface {
+	// Insert adds the return to the collection.
+	Insert(context.Context, *intPkg.OssReturn) error
+
+	// Update updates the return in the collection.
+	Update(context.Context, *intPkg.OssReturn) error
+
+	// GetById returns the return by unique identity.
+	GetById(ctx context.Context, id string) (*intPkg.OssReturn, error)
+
+	// Find returns returns by operating company, year and statuses ordered by period from the latest one.
+	Find(ctx context.Context, operatingCompanyId string, year int32, status []string, offset, limit int64) ([]*intPkg.OssReturn, error)
+
+	// FindCount returns the count of returns by operating company, year and statuses.
+	FindCount(ctx context.Context, operatingCompanyId string, year int32, status []string) (int64, error)
+}
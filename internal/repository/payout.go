@@ -376,6 +376,62 @@ func (r *payoutRepository) GetLast(ctx context.Context, merchantId, currency str
 	return obj.(*billingpb.PayoutDocument), nil
 }
 
+func (r *payoutRepository) GetSkipped(
+	ctx context.Context,
+	merchantId, currency string,
+	periodFrom time.Time,
+) (*billingpb.PayoutDocument, error) {
+	oid, err := primitive.ObjectIDFromHex(merchantId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutDocuments),
+			zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{
+		"merchant_id": oid,
+		"currency":    currency,
+		"status":      pkg.PayoutDocumentStatusSkip,
+		"period_from": periodFrom,
+	}
+
+	var mgo = models.MgoPayoutDocument{}
+	sorts := bson.M{"created_at": -1}
+	opts := options.FindOne().SetSort(sorts)
+	err = r.db.Collection(collectionPayoutDocuments).FindOne(ctx, query, opts).Decode(&mgo)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutDocuments),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+				zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+			)
+		}
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(&mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*billingpb.PayoutDocument), nil
+}
+
 func (r *payoutRepository) FindCount(
 	ctx context.Context,
 	in *billingpb.GetPayoutDocumentsRequest,
@@ -593,6 +649,61 @@ func (r *payoutRepository) FindAll(
 	return objs, nil
 }
 
+func (r *payoutRepository) FindPending(
+	ctx context.Context,
+	operatingCompanyId, currency string,
+) ([]*billingpb.PayoutDocument, error) {
+	query := bson.M{
+		"operating_company_id": operatingCompanyId,
+		"currency":             currency,
+		"status":               pkg.PayoutDocumentStatusPending,
+	}
+	sorts := bson.D{{"created_at", 1}, {"_id", 1}}
+	opts := options.Find().SetSort(sorts)
+	cursor, err := r.db.Collection(collectionPayoutDocuments).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutDocuments),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+		)
+		return nil, err
+	}
+
+	mgoPayoutDocuments := make([]*models.MgoPayoutDocument, 0)
+	err = cursor.All(ctx, &mgoPayoutDocuments)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutDocuments),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*billingpb.PayoutDocument, len(mgoPayoutDocuments))
+
+	for i, obj := range mgoPayoutDocuments {
+		v, err := r.mapper.MapMgoToObject(obj)
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+		objs[i] = v.(*billingpb.PayoutDocument)
+	}
+
+	return objs, nil
+}
+
 func (r *payoutRepository) getFindFilter(in *billingpb.GetPayoutDocumentsRequest) (bson.M, error) {
 	filter := make(bson.M)
 
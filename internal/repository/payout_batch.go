@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionPayoutBatch = "payout_batch"
+)
+
+var (
+	// ErrPayoutBatchPayoutExists is returned when the payout of the batch is already included into another batch.
+	ErrPayoutBatchPayoutExists = errors.New("payout document is already included into another batch")
+)
+
+type payoutBatchRepository repository
+
+// NewPayoutBatchRepository create and return an object for working with the payout batch repository.
+// The returned object implements the PayoutBatchRepositoryInterface interface.
+func NewPayoutBatchRepository(db mongodb.SourceInterface) PayoutBatchRepositoryInterface {
+	s := &payoutBatchRepository{db: db}
+	return s
+}
+
+func (r *payoutBatchRepository) Insert(ctx context.Context, obj *intPkg.PayoutBatch) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+	}
+
+	obj.CreatedAt = time.Now()
+	obj.UpdatedAt = obj.CreatedAt
+	_, err := r.db.Collection(collectionPayoutBatch).InsertOne(ctx, obj)
+
+	if isDuplicateKeyError(err) {
+		return ErrPayoutBatchPayoutExists
+	}
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutBatch),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *payoutBatchRepository) Update(ctx context.Context, obj *intPkg.PayoutBatch) error {
+	obj.UpdatedAt = time.Now()
+	filter := bson.M{"_id": obj.Id}
+	_, err := r.db.Collection(collectionPayoutBatch).ReplaceOne(ctx, filter, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutBatch),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *payoutBatchRepository) GetById(ctx context.Context, id string) (*intPkg.PayoutBatch, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutBatch),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (r *payoutBatchRepository) GetByMessageId(ctx context.Context, messageId string) (*intPkg.PayoutBatch, error) {
+	return r.findOne(ctx, bson.M{"message_id": messageId})
+}
+
+func (r *payoutBatchRepository) Find(
+	ctx context.Context,
+	operatingCompanyId, currency string,
+	status []string,
+	offset, limit int64,
+) ([]*intPkg.PayoutBatch, error) {
+	query := r.getFindQuery(operatingCompanyId, currency, status)
+	sorts := bson.M{"created_at": -1}
+	opts := options.Find().SetSort(sorts).SetSkip(offset)
+
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := r.db.Collection(collectionPayoutBatch).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutBatch),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+		)
+		return nil, err
+	}
+
+	var items []*intPkg.PayoutBatch
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutBatch),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *payoutBatchRepository) FindCount(
+	ctx context.Context,
+	operatingCompanyId, currency string,
+	status []string,
+) (int64, error) {
+	query := r.getFindQuery(operatingCompanyId, currency, status)
+	count, err := r.db.Collection(collectionPayoutBatch).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutBatch),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *payoutBatchRepository) findOne(ctx context.Context, query bson.M) (*intPkg.PayoutBatch, error) {
+	var obj *intPkg.PayoutBatch
+	err := r.db.Collection(collectionPayoutBatch).FindOne(ctx, query).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutBatch),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *payoutBatchRepository) getFindQuery(operatingCompanyId, currency string, status []string) bson.M {
+	query := bson.M{}
+
+	if operatingCompanyId != "" {
+		query["operating_company_id"] = operatingCompanyId
+	}
+
+	if currency != "" {
+		query["currency"] = currency
+	}
+
+	if len(status) > 0 {
+		query["status"] = bson.M{"$in": status}
+	}
+
+	return query
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// PayoutBatchRepositoryInterface is abstraction layer for working with batches of payouts sent to the bank.
+type PayoutBatchRepositoryInterface interface {
+	// Insert adds the batch to the collection. Returns ErrPayoutBatchPayoutExists if a payout of the batch
+	// is already included into another batch.
+	Insert(context.Context, *intPkg.PayoutBatch) error
+
+	// Update updates the batch in the collection.
+	Update(context.Context, *intPkg.PayoutBatch) error
+
+	// GetById returns the batch by unique identity.
+	GetById(ctx context.Context, id string) (*intPkg.PayoutBatch, error)
+
+	// GetByMessageId returns the batch by identity of the payment file message.
+	GetByMessageId(ctx context.Context, messageId string) (*intPkg.PayoutBatch, error)
+
+	// Find returns batches by operating company, currency and statuses ordered by creation date.
+	Find(ctx context.Context, operatingCompanyId, currency string, status []string, offset, limit int64) ([]*intPkg.PayoutBatch, error)
+
+	// FindCount returns the count of batches by operating company, currency and statuses.
+	FindCount(ctx context.Context, operatingCompanyId, currency string, status []string) (int64, error)
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"time"
 )
 
 // PayoutRepositoryInterface is abstraction layer for working with payout and representation in database.
@@ -25,6 +26,9 @@ type PayoutRepositoryInterface interface {
 	// GetLast returns the latest payout doc by merchant id and currency.
 	GetLast(context.Context, string, string) (*billingpb.PayoutDocument, error)
 
+	// GetSkipped returns the skipped payout doc by merchant id, currency and start date of the period.
+	GetSkipped(context.Context, string, string, time.Time) (*billingpb.PayoutDocument, error)
+
 	// Find payouts by merchant, statuses and dates from/to with pagination.
 	Find(ctx context.Context, in *billingpb.GetPayoutDocumentsRequest) ([]*billingpb.PayoutDocument, error)
 
@@ -33,4 +37,7 @@ type PayoutRepositoryInterface interface {
 
 	// Return all not paid payout invoices
 	FindAll(ctx context.Context) ([]*billingpb.PayoutDocument, error)
+
+	// FindPending returns pending payouts of the operating company in the currency ordered by creation date.
+	FindPending(ctx context.Context, operatingCompanyId, currency string) ([]*billingpb.PayoutDocument, error)
 }
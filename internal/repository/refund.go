@@ -7,6 +7,7 @@ import (
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
@@ -27,6 +28,10 @@ func NewRefundRepository(db mongodb.SourceInterface) RefundRepositoryInterface {
 }
 
 func (h *refundRepository) Insert(ctx context.Context, refund *billingpb.Refund) error {
+	return h.InsertWithReasonCode(ctx, refund, "")
+}
+
+func (h *refundRepository) InsertWithReasonCode(ctx context.Context, refund *billingpb.Refund, reasonCode string) error {
 	mgo, err := h.mapper.MapObjectToMgo(refund)
 
 	if err != nil {
@@ -38,6 +43,7 @@ func (h *refundRepository) Insert(ctx context.Context, refund *billingpb.Refund)
 		return err
 	}
 
+	mgo.(*models.MgoRefund).ReasonCode = reasonCode
 	_, err = h.db.Collection(CollectionRefund).InsertOne(ctx, mgo)
 
 	if err != nil {
@@ -78,6 +84,13 @@ func (h *refundRepository) Update(ctx context.Context, refund *billingpb.Refund)
 		return err
 	}
 
+	// The reason code isn't a field of the refund message, so the stored code is kept in the replaced document
+	mgo.(*models.MgoRefund).ReasonCode, err = h.GetReasonCode(ctx, refund.Id)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
 	_, err = h.db.Collection(CollectionRefund).ReplaceOne(ctx, bson.M{"_id": oid}, mgo)
 
 	if err != nil {
@@ -134,6 +147,37 @@ func (h *refundRepository) GetById(ctx context.Context, id string) (*billingpb.R
 	return obj.(*billingpb.Refund), nil
 }
 
+func (h *refundRepository) GetReasonCode(ctx context.Context, id string) (string, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionRefund),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return "", err
+	}
+
+	mgo := &models.MgoRefund{}
+	query := bson.M{"_id": oid}
+	opts := options.FindOne().SetProjection(bson.M{"reason_code": 1})
+	err = h.db.Collection(CollectionRefund).FindOne(ctx, query, opts).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionRefund),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return "", err
+	}
+
+	return mgo.ReasonCode, nil
+}
+
 func (h *refundRepository) FindByOrderUuid(ctx context.Context, id string, limit int64, offset int64) ([]*billingpb.Refund, error) {
 	query := bson.M{"original_order.uuid": id}
 	opts := options.Find().
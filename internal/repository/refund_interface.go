@@ -10,12 +10,18 @@ type RefundRepositoryInterface interface {
 	// Insert adds refund to the collection.
 	Insert(context.Context, *billingpb.Refund) error
 
+	// InsertWithReasonCode adds refund to the collection together with the code of the refund reason.
+	InsertWithReasonCode(ctx context.Context, refund *billingpb.Refund, reasonCode string) error
+
 	// Update updates the refund in the collection.
 	Update(context.Context, *billingpb.Refund) error
 
 	// GetById returns a refund by its identifier.
 	GetById(context.Context, string) (*billingpb.Refund, error)
 
+	// GetReasonCode returns the code of the refund reason, refunds created without code have the empty one.
+	GetReasonCode(context.Context, string) (string, error)
+
 	// FindByOrderUuid returns a list of refunds by the public identifier of the purchase order.
 	FindByOrderUuid(context.Context, string, int64, int64) ([]*billingpb.Refund, error)
 
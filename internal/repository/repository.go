@@ -3,11 +3,32 @@ package repository
 import (
 	"github.com/paysuper/paysuper-billing-server/internal/database"
 	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"go.mongodb.org/mongo-driver/mongo"
 	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
 )
 
+const (
+	mongoErrorCodeDuplicateKey = 11000
+)
+
 type repository struct {
 	db     mongodb.SourceInterface
 	cache  database.CacheInterface
 	mapper models.Mapper
 }
+
+// isDuplicateKeyError checks that the write failed because of the unique index violation.
+func isDuplicateKeyError(err error) bool {
+	switch e := err.(type) {
+	case mongo.WriteException:
+		for _, v := range e.WriteErrors {
+			if v.Code == mongoErrorCodeDuplicateKey {
+				return true
+			}
+		}
+	case mongo.CommandError:
+		return e.Code == mongoErrorCodeDuplicateKey
+	}
+
+	return false
+}
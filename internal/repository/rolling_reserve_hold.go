@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionRollingReserveHold     = "rolling_reserve_hold"
+	collectionRollingReserveHoldLock = "rolling_reserve_hold_lock"
+)
+
+type rollingReserveHoldRepository repository
+
+// NewRollingReserveHoldRepository create and return an object for working with the rolling reserve hold repository.
+// The returned object implements the RollingReserveHoldRepositoryInterface interface.
+func NewRollingReserveHoldRepository(db mongodb.SourceInterface) RollingReserveHoldRepositoryInterface {
+	s := &rollingReserveHoldRepository{db: db}
+	return s
+}
+
+func (r *rollingReserveHoldRepository) Insert(ctx context.Context, obj *intPkg.RollingReserveHold) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+	}
+
+	obj.CreatedAt = time.Now()
+	_, err := r.db.Collection(collectionRollingReserveHold).InsertOne(ctx, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRollingReserveHold),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *rollingReserveHoldRepository) Update(ctx context.Context, obj *intPkg.RollingReserveHold) error {
+	filter := bson.M{"_id": obj.Id}
+	_, err := r.db.Collection(collectionRollingReserveHold).ReplaceOne(ctx, filter, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRollingReserveHold),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *rollingReserveHoldRepository) GetByOrderId(ctx context.Context, orderId string) (*intPkg.RollingReserveHold, error) {
+	query := bson.M{"order_id": orderId}
+
+	var obj *intPkg.RollingReserveHold
+	err := r.db.Collection(collectionRollingReserveHold).FindOne(ctx, query).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionRollingReserveHold),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *rollingReserveHoldRepository) GetHeldAmount(ctx context.Context, merchantId, currency string) (float64, error) {
+	query := []bson.M{
+		{
+			"$match": bson.M{
+				"merchant_id": merchantId,
+				"currency":    currency,
+				"status":      pkg.RollingReserveHoldStatusHeld,
+			},
+		},
+		{"$group": bson.M{"_id": nil, "amount": bson.M{"$sum": "$amount"}}},
+	}
+
+	var res []*intPkg.RollingReserveScheduleItem
+
+	if err := r.aggregate(ctx, query, &res); err != nil {
+		return 0, err
+	}
+
+	if len(res) == 0 {
+		return 0, nil
+	}
+
+	return res[0].Amount, nil
+}
+
+func (r *rollingReserveHoldRepository) Lock(ctx context.Context, merchantId, currency string) error {
+	filter := bson.M{"merchant_id": merchantId, "currency": currency}
+	update := bson.M{"$set": bson.M{"locked_at": time.Now()}}
+	_, err := r.db.Collection(collectionRollingReserveHoldLock).UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRollingReserveHoldLock),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *rollingReserveHoldRepository) FindDue(ctx context.Context, date time.Time) ([]*intPkg.RollingReserveHold, error) {
+	query := bson.M{
+		"status":     pkg.RollingReserveHoldStatusHeld,
+		"release_at": bson.M{"$gt": time.Time{}, "$lte": date},
+	}
+	opts := options.Find().SetSort(bson.M{"release_at": 1})
+	cursor, err := r.db.Collection(collectionRollingReserveHold).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRollingReserveHold),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var items []*intPkg.RollingReserveHold
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRollingReserveHold),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *rollingReserveHoldRepository) GetSchedule(
+	ctx context.Context,
+	merchantId, currency string,
+) ([]*intPkg.RollingReserveScheduleItem, error) {
+	query := []bson.M{
+		{
+			"$match": bson.M{
+				"merchant_id": merchantId,
+				"currency":    currency,
+				"status":      pkg.RollingReserveHoldStatusHeld,
+				"release_at":  bson.M{"$gt": time.Time{}},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":    bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$release_at"}},
+				"amount": bson.M{"$sum": "$amount"},
+				"count":  bson.M{"$sum": 1},
+			},
+		},
+		{"$sort": bson.M{"_id": 1}},
+	}
+
+	var items []*intPkg.RollingReserveScheduleItem
+
+	if err := r.aggregate(ctx, query, &items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *rollingReserveHoldRepository) aggregate(ctx context.Context, query []bson.M, result interface{}) error {
+	cursor, err := r.db.Collection(collectionRollingReserveHold).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRollingReserveHold),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return err
+	}
+
+	err = cursor.All(ctx, result)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRollingReserveHold),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"time"
+)
+
+// RollingReserveHoldRepositoryInterface is abstraction layer for working with payments funds held in rolling reserves.
+type RollingReserveHoldRepositoryInterface interface {
+	// Insert adds the hold to the collection.
+	Insert(context.Context, *intPkg.RollingReserveHold) error
+
+	// Update updates the hold in the collection.
+	Update(context.Context, *intPkg.RollingReserveHold) error
+
+	// GetByOrderId returns the hold of the order payment.
+	// Returns mongo.ErrNoDocuments if the payment has no hold.
+	GetByOrderId(ctx context.Context, orderId string) (*intPkg.RollingReserveHold, error)
+
+	// GetHeldAmount returns the sum of not released holds of the merchant in the currency.
+	GetHeldAmount(ctx context.Context, merchantId, currency string) (float64, error)
+
+	// Lock locks holds of the merchant in the currency by the running transaction, concurrent transactions
+	// which lock the same holds fail by the write conflict.
+	Lock(ctx context.Context, merchantId, currency string) error
+
+	// FindDue returns not released holds which must be released before or at the date.
+	FindDue(ctx context.Context, date time.Time) ([]*intPkg.RollingReserveHold, error)
+
+	// GetSchedule returns amounts of not released holds of the merchant in the currency grouped by release day.
+	GetSchedule(ctx context.Context, merchantId, currency string) ([]*intPkg.RollingReserveScheduleItem, error)
+}
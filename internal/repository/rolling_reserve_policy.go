@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionRollingReservePolicy = "rolling_reserve_policy"
+)
+
+type rollingReservePolicyRepository repository
+
+// NewRollingReservePolicyRepository create and return an object for working with the rolling reserve policy repository.
+// The returned object implements the RollingReservePolicyRepositoryInterface interface.
+func NewRollingReservePolicyRepository(db mongodb.SourceInterface) RollingReservePolicyRepositoryInterface {
+	s := &rollingReservePolicyRepository{db: db}
+	return s
+}
+
+func (r *rollingReservePolicyRepository) Upsert(ctx context.Context, obj *intPkg.RollingReservePolicy) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+		obj.CreatedAt = time.Now()
+	}
+
+	obj.UpdatedAt = time.Now()
+
+	filter := bson.M{"merchant_id": obj.MerchantId}
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.db.Collection(collectionRollingReservePolicy).ReplaceOne(ctx, filter, obj, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRollingReservePolicy),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *rollingReservePolicyRepository) GetByMerchantId(ctx context.Context, merchantId string) (*intPkg.RollingReservePolicy, error) {
+	query := bson.M{"merchant_id": merchantId}
+
+	var obj *intPkg.RollingReservePolicy
+	err := r.db.Collection(collectionRollingReservePolicy).FindOne(ctx, query).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionRollingReservePolicy),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// RollingReservePolicyRepositoryInterface is abstraction layer for working with rolling reserve policies of merchants.
+type RollingReservePolicyRepositoryInterface interface {
+	// Upsert adds or replaces the policy of the merchant.
+	Upsert(context.Context, *intPkg.RollingReservePolicy) error
+
+	// GetByMerchantId returns the policy of the merchant.
+	// Returns mongo.ErrNoDocuments if the merchant has no policy.
+	GetByMerchantId(ctx context.Context, merchantId string) (*intPkg.RollingReservePolicy, error)
+}
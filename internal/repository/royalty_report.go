@@ -16,6 +16,7 @@ import (
 	"github.com/paysuper/paysuper-proto/go/postmarkpb"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
@@ -263,6 +264,57 @@ func (r *royaltyReportRepository) GetByPeriod(ctx context.Context, from, to time
 	return objs, nil
 }
 
+func (r *royaltyReportRepository) FindByOperatingCompanyPeriod(
+	ctx context.Context, operatingCompanyId string, from, to time.Time,
+) ([]*billingpb.RoyaltyReport, error) {
+	query := bson.M{
+		"operating_company_id": operatingCompanyId,
+		"period_from":          bson.M{"$gte": from},
+		"period_to":            bson.M{"$lte": to},
+	}
+	cursor, err := r.db.Collection(CollectionRoyaltyReport).Find(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionRoyaltyReport),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoRoyaltyReport
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionRoyaltyReport),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*billingpb.RoyaltyReport, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+		objs[i] = v.(*billingpb.RoyaltyReport)
+	}
+
+	return objs, nil
+}
+
 func (r *royaltyReportRepository) GetByAcceptedExpireWithStatus(
 	ctx context.Context, date time.Time, status string,
 ) ([]*billingpb.RoyaltyReport, error) {
@@ -717,6 +769,58 @@ func (r *royaltyReportRepository) GetReportExists(
 	return obj.(*billingpb.RoyaltyReport)
 }
 
+func (r *royaltyReportRepository) GetLastByMerchant(
+	ctx context.Context,
+	merchantId, currency string,
+) (*billingpb.RoyaltyReport, error) {
+	oid, err := primitive.ObjectIDFromHex(merchantId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionRoyaltyReport),
+			zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+		)
+		return nil, err
+	}
+
+	var mgo = models.MgoRoyaltyReport{}
+	query := bson.M{
+		"merchant_id": oid,
+		"currency":    currency,
+	}
+	sorts := bson.M{"period_to": -1}
+	opts := options.FindOne().SetSort(sorts)
+	err = r.db.Collection(CollectionRoyaltyReport).FindOne(ctx, query, opts).Decode(&mgo)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, CollectionRoyaltyReport),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+				zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+			)
+		}
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(&mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*billingpb.RoyaltyReport), nil
+}
+
 func (r *royaltyReportRepository) Insert(ctx context.Context, rr *billingpb.RoyaltyReport, ip, source string) (err error) {
 	mgo, err := r.mapper.MapObjectToMgo(rr)
 
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionRoyaltyReportDispute = "royalty_report_dispute"
+)
+
+type royaltyReportDisputeRepository repository
+
+// NewRoyaltyReportDisputeRepository create and return an object for working with the royalty report dispute repository.
+// The returned object implements the RoyaltyReportDisputeRepositoryInterface interface.
+func NewRoyaltyReportDisputeRepository(db mongodb.SourceInterface) RoyaltyReportDisputeRepositoryInterface {
+	s := &royaltyReportDisputeRepository{db: db}
+	return s
+}
+
+func (r *royaltyReportDisputeRepository) Insert(ctx context.Context, obj *intPkg.RoyaltyReportDispute) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+	}
+
+	obj.CreatedAt = time.Now()
+	obj.UpdatedAt = obj.CreatedAt
+	_, err := r.db.Collection(collectionRoyaltyReportDispute).InsertOne(ctx, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportDispute),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *royaltyReportDisputeRepository) Update(ctx context.Context, obj *intPkg.RoyaltyReportDispute) error {
+	obj.UpdatedAt = time.Now()
+	filter := bson.M{"_id": obj.Id}
+	_, err := r.db.Collection(collectionRoyaltyReportDispute).ReplaceOne(ctx, filter, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportDispute),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *royaltyReportDisputeRepository) AddComment(
+	ctx context.Context,
+	id string,
+	comment *intPkg.RoyaltyReportDisputeComment,
+) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportDispute),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return err
+	}
+
+	filter := bson.M{"_id": oid}
+	update := bson.M{
+		"$push": bson.M{"comments": comment},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+	res, err := r.db.Collection(collectionRoyaltyReportDispute).UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportDispute),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+			zap.Any(pkg.ErrorDatabaseFieldSet, update),
+		)
+		return err
+	}
+
+	if res.MatchedCount <= 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *royaltyReportDisputeRepository) GetById(ctx context.Context, id string) (*intPkg.RoyaltyReportDispute, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportDispute),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"_id": oid}
+
+	var obj *intPkg.RoyaltyReportDispute
+	err = r.db.Collection(collectionRoyaltyReportDispute).FindOne(ctx, query).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportDispute),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *royaltyReportDisputeRepository) FindByReportId(
+	ctx context.Context,
+	reportId string,
+) ([]*intPkg.RoyaltyReportDispute, error) {
+	query := bson.M{"royalty_report_id": reportId}
+	sorts := bson.M{"created_at": 1}
+	opts := options.Find().SetSort(sorts)
+	cursor, err := r.db.Collection(collectionRoyaltyReportDispute).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportDispute),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+		)
+		return nil, err
+	}
+
+	var list []*intPkg.RoyaltyReportDispute
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportDispute),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return list, nil
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// RoyaltyReportDisputeRepositoryInterface is abstraction layer for working with disputes of royalty reports.
+type RoyaltyReportDisputeRepositoryInterface interface {
+	// Insert adds the dispute to the collection.
+	Insert(context.Context, *intPkg.RoyaltyReportDispute) error
+
+	// Update updates the dispute in the collection.
+	Update(context.Context, *intPkg.RoyaltyReportDispute) error
+
+	// AddComment appends the comment to the thread of comments of the dispute.
+	AddComment(ctx context.Context, id string, comment *intPkg.RoyaltyReportDisputeComment) error
+
+	// GetById returns the dispute by unique identity.
+	GetById(ctx context.Context, id string) (*intPkg.RoyaltyReportDispute, error)
+
+	// FindByReportId returns disputes of the royalty report ordered by creation date.
+	FindByReportId(ctx context.Context, reportId string) ([]*intPkg.RoyaltyReportDispute, error)
+}
@@ -34,12 +34,19 @@ type RoyaltyReportRepositoryInterface interface {
 	// GetReportExists returns exists a royalty reports by merchant id, currency and dates from/to.
 	GetReportExists(ctx context.Context, merchantId, currency string, from, to time.Time) (report *billingpb.RoyaltyReport)
 
+	// GetLastByMerchant returns the royalty report of the merchant in the currency with the latest period.
+	// Returns mongo.ErrNoDocuments if the merchant has no reports.
+	GetLastByMerchant(ctx context.Context, merchantId, currency string) (*billingpb.RoyaltyReport, error)
+
 	// GetAll returns the all royalty reports.
 	GetAll(ctx context.Context) ([]*billingpb.RoyaltyReport, error)
 
 	// GetByPeriod returns the royalty reports by period of dates.
 	GetByPeriod(context.Context, time.Time, time.Time) ([]*billingpb.RoyaltyReport, error)
 
+	// FindByOperatingCompanyPeriod returns the royalty reports of the operating company which periods are within the dates.
+	FindByOperatingCompanyPeriod(context.Context, string, time.Time, time.Time) ([]*billingpb.RoyaltyReport, error)
+
 	// GetByAcceptedExpireWithStatus returns the royalty reports by accepted expire dates with status by filter.
 	GetByAcceptedExpireWithStatus(context.Context, time.Time, string) ([]*billingpb.RoyaltyReport, error)
 
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionRoyaltyReportSchedule = "royalty_report_schedule"
+)
+
+type royaltyReportScheduleRepository repository
+
+// NewRoyaltyReportScheduleRepository create and return an object for working with the royalty report schedule repository.
+// The returned object implements the RoyaltyReportScheduleRepositoryInterface interface.
+func NewRoyaltyReportScheduleRepository(db mongodb.SourceInterface) RoyaltyReportScheduleRepositoryInterface {
+	s := &royaltyReportScheduleRepository{db: db}
+	return s
+}
+
+func (r *royaltyReportScheduleRepository) Upsert(ctx context.Context, obj *intPkg.RoyaltyReportSchedule) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+		obj.CreatedAt = time.Now()
+	}
+
+	obj.UpdatedAt = time.Now()
+
+	filter := bson.M{"merchant_id": obj.MerchantId}
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.db.Collection(collectionRoyaltyReportSchedule).ReplaceOne(ctx, filter, obj, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportSchedule),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *royaltyReportScheduleRepository) GetByMerchantId(
+	ctx context.Context,
+	merchantId string,
+) (*intPkg.RoyaltyReportSchedule, error) {
+	query := bson.M{"merchant_id": merchantId}
+
+	var obj *intPkg.RoyaltyReportSchedule
+	err := r.db.Collection(collectionRoyaltyReportSchedule).FindOne(ctx, query).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportSchedule),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *royaltyReportScheduleRepository) GetAll(ctx context.Context) ([]*intPkg.RoyaltyReportSchedule, error) {
+	query := bson.M{}
+	cursor, err := r.db.Collection(collectionRoyaltyReportSchedule).Find(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportSchedule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*intPkg.RoyaltyReportSchedule
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportSchedule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return list, nil
+}
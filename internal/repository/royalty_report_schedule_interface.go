@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// RoyaltyReportScheduleRepositoryInterface is abstraction layer for working with royalty report schedules of merchants.
+type RoyaltyReportScheduleRepositoryInterface interface {
+	// Upsert adds or replaces the schedule of the merchant.
+	Upsert(context.Context, *intPkg.RoyaltyReportSchedule) error
+
+	// GetByMerchantId returns the schedule of the merchant.
+	// Returns mongo.ErrNoDocuments if the merchant has no schedule.
+	GetByMerchantId(ctx context.Context, merchantId string) (*intPkg.RoyaltyReportSchedule, error)
+
+	// GetAll returns schedules of all merchants.
+	GetAll(ctx context.Context) ([]*intPkg.RoyaltyReportSchedule, error)
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionSalesTaxNexus = "sales_tax_nexus"
+)
+
+type salesTaxNexusRepository repository
+
+// NewSalesTaxNexusRepository create and return an object for working with the sales tax nexus repository.
+// The returned object implements the SalesTaxNexusRepositoryInterface interface.
+func NewSalesTaxNexusRepository(db mongodb.SourceInterface) SalesTaxNexusRepositoryInterface {
+	s := &salesTaxNexusRepository{db: db}
+	return s
+}
+
+func (r *salesTaxNexusRepository) Upsert(ctx context.Context, obj *intPkg.SalesTaxNexus) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+		obj.CreatedAt = time.Now()
+	}
+
+	obj.UpdatedAt = time.Now()
+
+	filter := bson.M{"operating_company_id": obj.OperatingCompanyId, "country": obj.Country, "state": obj.State}
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.db.Collection(collectionSalesTaxNexus).ReplaceOne(ctx, filter, obj, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSalesTaxNexus),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *salesTaxNexusRepository) Find(
+	ctx context.Context,
+	operatingCompanyId string,
+	status []string,
+) ([]*intPkg.SalesTaxNexus, error) {
+	query := bson.M{"operating_company_id": operatingCompanyId}
+
+	if len(status) > 0 {
+		query["status"] = bson.M{"$in": status}
+	}
+
+	sorts := bson.M{"state": 1}
+	opts := options.Find().SetSort(sorts)
+	cursor, err := r.db.Collection(collectionSalesTaxNexus).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSalesTaxNexus),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+		)
+		return nil, err
+	}
+
+	var list []*intPkg.SalesTaxNexus
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSalesTaxNexus),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return list, nil
+}
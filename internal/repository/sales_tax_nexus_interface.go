@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// SalesTaxNexusRepositoryInterface is abstraction layer for working with trackers of US sales tax nexus
+// of operating companies.
+type SalesTaxNexusRepositoryInterface interface {
+	// Upsert adds or updates the tracker of the operating company for the state.
+	Upsert(context.Context, *intPkg.SalesTaxNexus) error
+
+	// Find returns trackers of the operating company by statuses ordered by state.
+	Find(ctx context.Context, operatingCompanyId string, status []string) ([]*intPkg.SalesTaxNexus, error)
+}
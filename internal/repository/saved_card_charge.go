@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionSavedCardCharge        = "saved_card_charge"
+	collectionSavedCardChargeCounter = "saved_card_charge_counter"
+)
+
+var (
+	// ErrSavedCardChargeLimitExceeded is returned when the charge exceeds the limit of charges of the customer.
+	ErrSavedCardChargeLimitExceeded = errors.New("limit of charges of the customer exceeded")
+)
+
+type savedCardChargeRepository repository
+
+// NewSavedCardChargeRepository create and return an object for working with the saved card charge repository.
+// The returned object implements the SavedCardChargeRepositoryInterface interface.
+func NewSavedCardChargeRepository(db mongodb.SourceInterface) SavedCardChargeRepositoryInterface {
+	s := &savedCardChargeRepository{db: db}
+	return s
+}
+
+func (r *savedCardChargeRepository) Insert(ctx context.Context, obj *intPkg.SavedCardCharge) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+	}
+
+	obj.CreatedAt = time.Now()
+	obj.UpdatedAt = time.Now()
+	_, err := r.db.Collection(collectionSavedCardCharge).InsertOne(ctx, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSavedCardCharge),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *savedCardChargeRepository) UpdateStatusByOrderId(
+	ctx context.Context,
+	orderId, status string,
+) (*intPkg.SavedCardCharge, error) {
+	obj := &intPkg.SavedCardCharge{}
+	filter := bson.M{"order_id": orderId, "status": pkg.SavedCardChargeStatusPending}
+	update := bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.db.Collection(collectionSavedCardCharge).FindOneAndUpdate(ctx, filter, update, opts).Decode(obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionSavedCardCharge),
+				zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+				zap.Any(pkg.ErrorDatabaseFieldSet, update),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *savedCardChargeRepository) GetByOrderId(ctx context.Context, id string) (*intPkg.SavedCardCharge, error) {
+	obj := &intPkg.SavedCardCharge{}
+	query := bson.M{"order_id": id}
+	err := r.db.Collection(collectionSavedCardCharge).FindOne(ctx, query).Decode(obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSavedCardCharge),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *savedCardChargeRepository) GetCustomerAmount(
+	ctx context.Context,
+	projectId, customerId, currency string,
+	from time.Time,
+) (float64, error) {
+	query := []bson.M{
+		{
+			"$match": bson.M{
+				"project_id":  projectId,
+				"customer_id": customerId,
+				"currency":    currency,
+				"status":      bson.M{"$in": []string{pkg.SavedCardChargeStatusPending, pkg.SavedCardChargeStatusProcessed}},
+				"created_at":  bson.M{"$gte": from},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":    nil,
+				"amount": bson.M{"$sum": "$amount"},
+			},
+		},
+	}
+
+	cursor, err := r.db.Collection(collectionSavedCardCharge).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSavedCardCharge),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return 0, err
+	}
+
+	var res []struct {
+		Amount float64 `bson:"amount"`
+	}
+	err = cursor.All(ctx, &res)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSavedCardCharge),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return 0, err
+	}
+
+	if len(res) == 0 {
+		return 0, nil
+	}
+
+	return res[0].Amount, nil
+}
+
+func (r *savedCardChargeRepository) ReserveCustomerAmount(
+	ctx context.Context,
+	counter *intPkg.SavedCardChargeCounter,
+	amount, limit float64,
+) error {
+	if amount > limit {
+		return ErrSavedCardChargeLimitExceeded
+	}
+
+	filter := bson.M{
+		"project_id":  counter.ProjectId,
+		"customer_id": counter.CustomerId,
+		"currency":    counter.Currency,
+		"period":      counter.Period,
+		"period_from": counter.PeriodFrom,
+	}
+	// The counter of the period is created with zero amount first, so the following conditional increment
+	// never inserts the counter and the limit is checked by the database atomically.
+	insert := bson.M{"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "amount": float64(0)}}
+	_, err := r.db.Collection(collectionSavedCardChargeCounter).UpdateOne(ctx, filter, insert, options.Update().SetUpsert(true))
+
+	if err != nil && !isDuplicateKeyError(err) {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSavedCardChargeCounter),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+		)
+		return err
+	}
+
+	filter["amount"] = bson.M{"$lte": limit - amount}
+	update := bson.M{"$inc": bson.M{"amount": amount}, "$set": bson.M{"updated_at": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.db.Collection(collectionSavedCardChargeCounter).FindOneAndUpdate(ctx, filter, update, opts).Decode(counter)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrSavedCardChargeLimitExceeded
+		}
+
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSavedCardChargeCounter),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+			zap.Any(pkg.ErrorDatabaseFieldSet, update),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *savedCardChargeRepository) ReleaseCustomerAmount(
+	ctx context.Context,
+	ids []primitive.ObjectID,
+	amount float64,
+) error {
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+	update := bson.M{"$inc": bson.M{"amount": -amount}, "$set": bson.M{"updated_at": time.Now()}}
+	_, err := r.db.Collection(collectionSavedCardChargeCounter).UpdateMany(ctx, filter, update)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSavedCardChargeCounter),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+			zap.Any(pkg.ErrorDatabaseFieldSet, update),
+		)
+		return err
+	}
+
+	return nil
+}
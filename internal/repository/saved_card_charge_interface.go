@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// SavedCardChargeRepositoryInterface is abstraction layer for working with charges of saved cards requested by merchants.
+type SavedCardChargeRepositoryInterface interface {
+	// Insert adds the charge to the collection.
+	Insert(context.Context, *intPkg.SavedCardCharge) error
+
+	// UpdateStatusByOrderId changes status of the pending charge made by the order and returns the changed charge.
+	UpdateStatusByOrderId(ctx context.Context, orderId, status string) (*intPkg.SavedCardCharge, error)
+
+	// GetByOrderId returns the charge by order identifier.
+	GetByOrderId(context.Context, string) (*intPkg.SavedCardCharge, error)
+
+	// GetCustomerAmount returns total amount of the processed and pending charges of the customer in the project
+	// made in the currency since the date.
+	GetCustomerAmount(ctx context.Context, projectId, customerId, currency string, from time.Time) (float64, error)
+
+	// ReserveCustomerAmount atomically adds the amount to the counter of charges of the customer for the period
+	// if the total amount of the period doesn't exceed the limit, ErrSavedCardChargeLimitExceeded is returned otherwise.
+	ReserveCustomerAmount(ctx context.Context, counter *intPkg.SavedCardChargeCounter, amount, limit float64) error
+
+	// ReleaseCustomerAmount subtracts the amount of the failed charge from counters of charges of the customer.
+	ReleaseCustomerAmount(ctx context.Context, ids []primitive.ObjectID, amount float64) error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionSavedCardChargeSettings = "saved_card_charge_settings"
+)
+
+type savedCardChargeSettingsRepository repository
+
+// NewSavedCardChargeSettingsRepository create and return an object for working with the saved card charge settings repository.
+// The returned object implements the SavedCardChargeSettingsRepositoryInterface interface.
+func NewSavedCardChargeSettingsRepository(db mongodb.SourceInterface) SavedCardChargeSettingsRepositoryInterface {
+	s := &savedCardChargeSettingsRepository{db: db}
+	return s
+}
+
+func (r *savedCardChargeSettingsRepository) Upsert(ctx context.Context, obj *intPkg.SavedCardChargeSettings) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+		obj.CreatedAt = time.Now()
+	}
+
+	obj.UpdatedAt = time.Now()
+
+	filter := bson.M{"project_id": obj.ProjectId}
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.db.Collection(collectionSavedCardChargeSettings).ReplaceOne(ctx, filter, obj, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSavedCardChargeSettings),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *savedCardChargeSettingsRepository) GetByProjectId(ctx context.Context, id string) (*intPkg.SavedCardChargeSettings, error) {
+	obj := &intPkg.SavedCardChargeSettings{}
+	query := bson.M{"project_id": id}
+	err := r.db.Collection(collectionSavedCardChargeSettings).FindOne(ctx, query).Decode(obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSavedCardChargeSettings),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return obj, nil
+}
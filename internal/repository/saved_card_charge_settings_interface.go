@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// SavedCardChargeSettingsRepositoryInterface is abstraction layer for working with project settings of saved card charges.
+type SavedCardChargeSettingsRepositoryInterface interface {
+	// Upsert add or update the project settings to the collection.
+	Upsert(context.Context, *intPkg.SavedCardChargeSettings) error
+
+	// GetByProjectId returns the settings by project identifier.
+	GetByProjectId(context.Context, string) (*intPkg.SavedCardChargeSettings, error)
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"testing"
+	"time"
+)
+
+type SavedCardChargeTestSuite struct {
+	suite.Suite
+	db         mongodb.SourceInterface
+	repository *savedCardChargeRepository
+}
+
+func Test_SavedCardCharge(t *testing.T) {
+	suite.Run(t, new(SavedCardChargeTestSuite))
+}
+
+func (suite *SavedCardChargeTestSuite) SetupTest() {
+	_, err := config.NewConfig()
+	assert.NoError(suite.T(), err, "Config load failed")
+
+	suite.db, err = mongodb.NewDatabase()
+	assert.NoError(suite.T(), err, "Database connection failed")
+
+	suite.repository = &savedCardChargeRepository{db: suite.db}
+}
+
+func (suite *SavedCardChargeTestSuite) TearDownTest() {
+	if err := suite.db.Drop(); err != nil {
+		suite.FailNow("Database deletion failed", "%v", err)
+	}
+
+	if err := suite.db.Close(); err != nil {
+		suite.FailNow("Database close failed", "%v", err)
+	}
+}
+
+func (suite *SavedCardChargeTestSuite) getCharge(projectId, customerId, currency string, amount float64) *intPkg.SavedCardCharge {
+	return &intPkg.SavedCardCharge{
+		OrderId:    primitive.NewObjectID().Hex(),
+		ProjectId:  projectId,
+		CustomerId: customerId,
+		Initiator:  pkg.PaymentInitiatorMerchant,
+		Amount:     amount,
+		Currency:   currency,
+		Status:     pkg.SavedCardChargeStatusPending,
+	}
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_NewSavedCardChargeRepository_Ok() {
+	repository := NewSavedCardChargeRepository(suite.db)
+	assert.IsType(suite.T(), &savedCardChargeRepository{}, repository)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_UpdateStatusByOrderId_Ok() {
+	charge := suite.getCharge(primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex(), "USD", 10)
+	assert.NoError(suite.T(), suite.repository.Insert(context.TODO(), charge))
+
+	charge1, err := suite.repository.UpdateStatusByOrderId(context.TODO(), charge.OrderId, pkg.SavedCardChargeStatusProcessed)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.SavedCardChargeStatusProcessed, charge1.Status)
+
+	_, err = suite.repository.UpdateStatusByOrderId(context.TODO(), charge.OrderId, pkg.SavedCardChargeStatusFailed)
+	assert.Equal(suite.T(), mongo.ErrNoDocuments, err)
+
+	charge2, err := suite.repository.GetByOrderId(context.TODO(), charge.OrderId)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.SavedCardChargeStatusProcessed, charge2.Status)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_GetCustomerAmount_Ok() {
+	projectId := primitive.NewObjectID().Hex()
+	customerId := primitive.NewObjectID().Hex()
+
+	charges := []*intPkg.SavedCardCharge{
+		suite.getCharge(projectId, customerId, "USD", 10),
+		suite.getCharge(projectId, customerId, "USD", 15),
+		suite.getCharge(projectId, customerId, "USD", 100),
+		suite.getCharge(projectId, customerId, "EUR", 20),
+		suite.getCharge(projectId, primitive.NewObjectID().Hex(), "USD", 30),
+	}
+
+	for _, charge := range charges {
+		assert.NoError(suite.T(), suite.repository.Insert(context.TODO(), charge))
+	}
+
+	_, err := suite.repository.UpdateStatusByOrderId(context.TODO(), charges[2].OrderId, pkg.SavedCardChargeStatusFailed)
+	assert.NoError(suite.T(), err)
+
+	amount, err := suite.repository.GetCustomerAmount(context.TODO(), projectId, customerId, "USD", time.Now().Add(-time.Hour))
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 25, amount)
+
+	amount, err = suite.repository.GetCustomerAmount(context.TODO(), projectId, customerId, "USD", time.Now().Add(time.Hour))
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 0, amount)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_ReserveCustomerAmount_Ok() {
+	counter := &intPkg.SavedCardChargeCounter{
+		ProjectId:  primitive.NewObjectID().Hex(),
+		CustomerId: primitive.NewObjectID().Hex(),
+		Currency:   "USD",
+		Period:     pkg.SavedCardChargeLimitPeriodDay,
+		PeriodFrom: time.Now().UTC().Truncate(24 * time.Hour),
+	}
+
+	assert.NoError(suite.T(), suite.repository.ReserveCustomerAmount(context.TODO(), counter, 60, 100))
+	assert.False(suite.T(), counter.Id.IsZero())
+	assert.EqualValues(suite.T(), 60, counter.Amount)
+
+	err := suite.repository.ReserveCustomerAmount(context.TODO(), counter, 50, 100)
+	assert.Equal(suite.T(), ErrSavedCardChargeLimitExceeded, err)
+
+	err = suite.repository.ReserveCustomerAmount(context.TODO(), counter, 120, 100)
+	assert.Equal(suite.T(), ErrSavedCardChargeLimitExceeded, err)
+
+	assert.NoError(suite.T(), suite.repository.ReleaseCustomerAmount(context.TODO(), []primitive.ObjectID{counter.Id}, 60))
+	assert.NoError(suite.T(), suite.repository.ReserveCustomerAmount(context.TODO(), counter, 100, 100))
+	assert.EqualValues(suite.T(), 100, counter.Amount)
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionStoredCredential = "stored_credential"
+)
+
+type storedCredentialRepository repository
+
+// NewStoredCredentialRepository create and return an object for working with the stored credential repository.
+// The returned object implements the StoredCredentialRepositoryInterface interface.
+func NewStoredCredentialRepository(db mongodb.SourceInterface) StoredCredentialRepositoryInterface {
+	s := &storedCredentialRepository{db: db}
+	return s
+}
+
+func (r *storedCredentialRepository) Insert(ctx context.Context, obj *intPkg.StoredCredential) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+	}
+
+	obj.CreatedAt = time.Now()
+	_, err := r.db.Collection(collectionStoredCredential).InsertOne(ctx, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionStoredCredential),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *storedCredentialRepository) GetByRecurringId(ctx context.Context, id string) (*intPkg.StoredCredential, error) {
+	obj := &intPkg.StoredCredential{}
+	query := bson.M{"recurring_id": id}
+	err := r.db.Collection(collectionStoredCredential).FindOne(ctx, query).Decode(obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionStoredCredential),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return obj, nil
+}
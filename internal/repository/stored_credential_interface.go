@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// StoredCredentialRepositoryInterface is abstraction layer for working with initial transactions of saved cards.
+type StoredCredentialRepositoryInterface interface {
+	// Insert adds the stored credential to the collection.
+	Insert(context.Context, *intPkg.StoredCredential) error
+
+	// GetByRecurringId returns the stored credential by recurring identifier of the saved card in the payment system.
+	GetByRecurringId(context.Context, string) (*intPkg.StoredCredential, error)
+}
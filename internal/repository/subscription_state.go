@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionSubscriptionState = "subscription_state"
+)
+
+type subscriptionStateRepository repository
+
+// NewSubscriptionStateRepository create and return an object for working with the subscription state repository.
+// The returned object implements the SubscriptionStateRepositoryInterface interface.
+func NewSubscriptionStateRepository(db mongodb.SourceInterface) SubscriptionStateRepositoryInterface {
+	s := &subscriptionStateRepository{db: db}
+	return s
+}
+
+func (r *subscriptionStateRepository) Upsert(ctx context.Context, obj *intPkg.SubscriptionState) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+		obj.CreatedAt = time.Now()
+	}
+
+	obj.UpdatedAt = time.Now()
+
+	filter := bson.M{"subscription_id": obj.SubscriptionId}
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.db.Collection(collectionSubscriptionState).ReplaceOne(ctx, filter, obj, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSubscriptionState),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *subscriptionStateRepository) GetBySubscriptionId(ctx context.Context, id string) (*intPkg.SubscriptionState, error) {
+	obj := &intPkg.SubscriptionState{}
+	query := bson.M{"subscription_id": id}
+	err := r.db.Collection(collectionSubscriptionState).FindOne(ctx, query).Decode(obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSubscriptionState),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (r *subscriptionStateRepository) GetBySubscriptionIds(ctx context.Context, ids []string) ([]*intPkg.SubscriptionState, error) {
+	query := bson.M{"subscription_id": bson.M{"$in": ids}}
+
+	return r.find(ctx, query)
+}
+
+func (r *subscriptionStateRepository) FindDue(ctx context.Context, date time.Time) ([]*intPkg.SubscriptionState, error) {
+	query := bson.M{
+		"$or": []bson.M{
+			{"status": pkg.SubscriptionStatusPaused, "resume_at": bson.M{"$lte": date}},
+			{"status": pkg.SubscriptionStatusCancelAtPeriodEnd, "cancel_at": bson.M{"$lte": date}},
+		},
+	}
+
+	return r.find(ctx, query)
+}
+
+func (r *subscriptionStateRepository) find(ctx context.Context, query bson.M) ([]*intPkg.SubscriptionState, error) {
+	cursor, err := r.db.Collection(collectionSubscriptionState).Find(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSubscriptionState),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*intPkg.SubscriptionState
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSubscriptionState),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return list, nil
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"time"
+)
+
+// SubscriptionStateRepositoryInterface is abstraction layer for working with lifecycle state of recurring subscriptions.
+type SubscriptionStateRepositoryInterface interface {
+	// Upsert add or update the subscription state to the collection.
+	Upsert(context.Context, *intPkg.SubscriptionState) error
+
+	// GetBySubscriptionId returns the state of subscription by subscription identifier.
+	GetBySubscriptionId(context.Context, string) (*intPkg.SubscriptionState, error)
+
+	// GetBySubscriptionIds returns the states of subscriptions by list of subscription identifiers.
+	GetBySubscriptionIds(context.Context, []string) ([]*intPkg.SubscriptionState, error)
+
+	// FindDue returns paused subscriptions which must be resumed and subscriptions which must be canceled on date.
+	FindDue(context.Context, time.Time) ([]*intPkg.SubscriptionState, error)
+}
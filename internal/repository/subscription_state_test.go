@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"testing"
+	"time"
+)
+
+type SubscriptionStateTestSuite struct {
+	suite.Suite
+	db         mongodb.SourceInterface
+	repository *subscriptionStateRepository
+}
+
+func Test_SubscriptionState(t *testing.T) {
+	suite.Run(t, new(SubscriptionStateTestSuite))
+}
+
+func (suite *SubscriptionStateTestSuite) SetupTest() {
+	_, err := config.NewConfig()
+	assert.NoError(suite.T(), err, "Config load failed")
+
+	suite.db, err = mongodb.NewDatabase()
+	assert.NoError(suite.T(), err, "Database connection failed")
+
+	suite.repository = &subscriptionStateRepository{db: suite.db}
+}
+
+func (suite *SubscriptionStateTestSuite) TearDownTest() {
+	if err := suite.db.Drop(); err != nil {
+		suite.FailNow("Database deletion failed", "%v", err)
+	}
+
+	if err := suite.db.Close(); err != nil {
+		suite.FailNow("Database close failed", "%v", err)
+	}
+}
+
+func (suite *SubscriptionStateTestSuite) TestSubscriptionState_NewSubscriptionStateRepository_Ok() {
+	repository := NewSubscriptionStateRepository(suite.db)
+	assert.IsType(suite.T(), &subscriptionStateRepository{}, repository)
+}
+
+func (suite *SubscriptionStateTestSuite) TestSubscriptionState_Upsert_Ok() {
+	state := &intPkg.SubscriptionState{
+		SubscriptionId: primitive.NewObjectID().Hex(),
+		Status:         pkg.SubscriptionStatusActive,
+	}
+	err := suite.repository.Upsert(context.TODO(), state)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), state.Id.IsZero())
+
+	state.Status = pkg.SubscriptionStatusPaused
+	err = suite.repository.Upsert(context.TODO(), state)
+	assert.NoError(suite.T(), err)
+
+	state2, err := suite.repository.GetBySubscriptionId(context.TODO(), state.SubscriptionId)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), state.Id, state2.Id)
+	assert.Equal(suite.T(), pkg.SubscriptionStatusPaused, state2.Status)
+}
+
+func (suite *SubscriptionStateTestSuite) TestSubscriptionState_GetBySubscriptionId_NotFound() {
+	_, err := suite.repository.GetBySubscriptionId(context.TODO(), primitive.NewObjectID().Hex())
+	assert.Equal(suite.T(), mongo.ErrNoDocuments, err)
+}
+
+func (suite *SubscriptionStateTestSuite) TestSubscriptionState_GetBySubscriptionIds_Ok() {
+	ids := []string{primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex()}
+
+	for _, id := range ids {
+		err := suite.repository.Upsert(context.TODO(), &intPkg.SubscriptionState{SubscriptionId: id})
+		assert.NoError(suite.T(), err)
+	}
+
+	err := suite.repository.Upsert(context.TODO(), &intPkg.SubscriptionState{SubscriptionId: primitive.NewObjectID().Hex()})
+	assert.NoError(suite.T(), err)
+
+	list, err := suite.repository.GetBySubscriptionIds(context.TODO(), ids)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), list, 2)
+}
+
+func (suite *SubscriptionStateTestSuite) TestSubscriptionState_FindDue_Ok() {
+	now := time.Now()
+	states := []*intPkg.SubscriptionState{
+		{SubscriptionId: "1", Status: pkg.SubscriptionStatusPaused, ResumeAt: now.Add(-time.Hour)},
+		{SubscriptionId: "2", Status: pkg.SubscriptionStatusPaused, ResumeAt: now.Add(time.Hour)},
+		{SubscriptionId: "3", Status: pkg.SubscriptionStatusCancelAtPeriodEnd, CancelAt: now.Add(-time.Hour)},
+		{SubscriptionId: "4", Status: pkg.SubscriptionStatusCancelAtPeriodEnd, CancelAt: now.Add(time.Hour)},
+		{SubscriptionId: "5", Status: pkg.SubscriptionStatusActive},
+	}
+
+	for _, state := range states {
+		err := suite.repository.Upsert(context.TODO(), state)
+		assert.NoError(suite.T(), err)
+	}
+
+	list, err := suite.repository.FindDue(context.TODO(), now)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), list, 2)
+}
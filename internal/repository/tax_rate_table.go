@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionTaxRateTable = "tax_rate_table"
+)
+
+type taxRateTableRepository repository
+
+// NewTaxRateTableRepository create and return an object for working with the repository of local tax rate tables.
+// The returned object implements the TaxRateTableRepositoryInterface interface.
+func NewTaxRateTableRepository(db mongodb.SourceInterface) TaxRateTableRepositoryInterface {
+	s := &taxRateTableRepository{db: db}
+	return s
+}
+
+func (r *taxRateTableRepository) Insert(ctx context.Context, obj *intPkg.TaxRateTable) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+	}
+
+	obj.CreatedAt = time.Now()
+	_, err := r.db.Collection(collectionTaxRateTable).InsertOne(ctx, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionTaxRateTable),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *taxRateTableRepository) GetLatestVersion(ctx context.Context) (int32, error) {
+	obj, err := r.findOne(ctx, bson.M{}, bson.D{{"version", -1}})
+
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return obj.Version, nil
+}
+
+func (r *taxRateTableRepository) GetEffective(ctx context.Context, date time.Time) (*intPkg.TaxRateTable, error) {
+	query := bson.M{"effective_from": bson.M{"$lte": date}}
+	return r.findOne(ctx, query, bson.D{{"effective_from", -1}, {"version", -1}})
+}
+
+func (r *taxRateTableRepository) findOne(
+	ctx context.Context,
+	query bson.M,
+	sorts bson.D,
+) (*intPkg.TaxRateTable, error) {
+	var obj *intPkg.TaxRateTable
+	opts := options.FindOne().SetSort(sorts)
+	err := r.db.Collection(collectionTaxRateTable).FindOne(ctx, query, opts).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionTaxRateTable),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+				zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
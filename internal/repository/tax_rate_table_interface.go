@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"time"
+)
+
+// TaxRateTableRepositoryInterface is abstraction layer for working with versions of local tax rate tables.
+type TaxRateTableRepositoryInterface interface {
+	// Insert adds the version of tax rate table to the collection.
+	Insert(context.Context, *intPkg.TaxRateTable) error
+
+	// GetLatestVersion returns number of the latest version of tax rate table or zero if tables not exist.
+	GetLatestVersion(ctx context.Context) (int32, error)
+
+	// GetEffective returns the latest version of tax rate table effective at the date.
+	GetEffective(ctx context.Context, date time.Time) (*intPkg.TaxRateTable, error)
+}
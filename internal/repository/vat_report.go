@@ -274,3 +274,58 @@ func (r *vatReportRepository) GetByCountryPeriod(ctx context.Context, country st
 
 	return obj.(*billingpb.VatReport), nil
 }
+
+func (r *vatReportRepository) FindByOperatingCompanyPeriod(
+	ctx context.Context, operatingCompanyId string, dateFrom, dateTo time.Time,
+) ([]*billingpb.VatReport, error) {
+	query := bson.M{
+		"operating_company_id": operatingCompanyId,
+		"date_from":            bson.M{"$gte": dateFrom},
+		"date_to":              bson.M{"$lte": dateTo},
+		"status":               bson.M{"$ne": pkg.VatReportStatusCanceled},
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"country": 1, "date_from": 1})
+	cursor, err := r.db.Collection(collectionVatReports).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVatReports),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var mgoVatReports []*models.MgoVatReport
+	err = cursor.All(ctx, &mgoVatReports)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVatReports),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*billingpb.VatReport, len(mgoVatReports))
+
+	for i, obj := range mgoVatReports {
+		v, err := r.mapper.MapMgoToObject(obj)
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+		objs[i] = v.(*billingpb.VatReport)
+	}
+
+	return objs, nil
+}
@@ -25,4 +25,8 @@ type VatReportRepositoryInterface interface {
 
 	// GetByCountryPeriod returns a vat report by country and period.
 	GetByCountryPeriod(context.Context, string, time.Time, time.Time) (*billingpb.VatReport, error)
+
+	// FindByOperatingCompanyPeriod returns list of a vat reports of the operating company which periods are
+	// within the dates.
+	FindByOperatingCompanyPeriod(context.Context, string, time.Time, time.Time) ([]*billingpb.VatReport, error)
 }
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionVatReportReverseCharge = "vat_report_reverse_charge"
+)
+
+type vatReportReverseChargeRepository repository
+
+// NewVatReportReverseChargeRepository create and return an object for working with the repository
+// of reverse charge summaries of VAT reports.
+// The returned object implements the VatReportReverseChargeRepositoryInterface interface.
+func NewVatReportReverseChargeRepository(db mongodb.SourceInterface) VatReportReverseChargeRepositoryInterface {
+	s := &vatReportReverseChargeRepository{db: db}
+	return s
+}
+
+func (r *vatReportReverseChargeRepository) Upsert(ctx context.Context, obj *intPkg.VatReportReverseCharge) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+		obj.CreatedAt = time.Now()
+	}
+
+	obj.UpdatedAt = time.Now()
+
+	filter := bson.M{"vat_report_id": obj.VatReportId}
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.db.Collection(collectionVatReportReverseCharge).ReplaceOne(ctx, filter, obj, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVatReportReverseCharge),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *vatReportReverseChargeRepository) GetByVatReportId(
+	ctx context.Context,
+	vatReportId string,
+) (*intPkg.VatReportReverseCharge, error) {
+	var obj *intPkg.VatReportReverseCharge
+	query := bson.M{"vat_report_id": vatReportId}
+	err := r.db.Collection(collectionVatReportReverseCharge).FindOne(ctx, query).Decode(&obj)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionVatReportReverseCharge),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// VatReportReverseChargeRepositoryInterface is abstraction layer for working with summaries of zero-rated
+// B2B sales of VAT reports.
+type VatReportReverseChargeRepositoryInterface interface {
+	// Upsert adds or updates the reverse charge summary of the VAT report.
+	Upsert(context.Context, *intPkg.VatReportReverseCharge) error
+
+	// GetByVatReportId returns the reverse charge summary of the VAT report.
+	GetByVatReportId(ctx context.Context, vatReportId string) (*intPkg.VatReportReverseCharge, error)
+}
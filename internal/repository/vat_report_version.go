@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionVatReportVersion = "vat_report_version"
+)
+
+type vatReportVersionRepository repository
+
+// NewVatReportVersionRepository create and return an object for working with the repository of versions of VAT reports.
+// The returned object implements the VatReportVersionRepositoryInterface interface.
+func NewVatReportVersionRepository(db mongodb.SourceInterface) VatReportVersionRepositoryInterface {
+	s := &vatReportVersionRepository{db: db}
+	return s
+}
+
+func (r *vatReportVersionRepository) Insert(ctx context.Context, obj *intPkg.VatReportVersion) error {
+	if obj.Id.IsZero() {
+		obj.Id = primitive.NewObjectID()
+	}
+
+	obj.CreatedAt = time.Now()
+	obj.UpdatedAt = obj.CreatedAt
+	_, err := r.db.Collection(collectionVatReportVersion).InsertOne(ctx, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVatReportVersion),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *vatReportVersionRepository) Update(ctx context.Context, obj *intPkg.VatReportVersion) error {
+	obj.UpdatedAt = time.Now()
+	filter := bson.M{"_id": obj.Id}
+	_, err := r.db.Collection(collectionVatReportVersion).ReplaceOne(ctx, filter, obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVatReportVersion),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, obj),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *vatReportVersionRepository) FindByVatReportId(
+	ctx context.Context,
+	vatReportId string,
+) ([]*intPkg.VatReportVersion, error) {
+	query := bson.M{"vat_report_id": vatReportId}
+	return r.find(ctx, query, bson.D{{"version", 1}})
+}
+
+func (r *vatReportVersionRepository) FindAmendments(
+	ctx context.Context,
+	operatingCompanyId, country, filedInVatReportId string,
+) ([]*intPkg.VatReportVersion, error) {
+	query := bson.M{
+		"operating_company_id":   operatingCompanyId,
+		"country":                country,
+		"type":                   pkg.VatReportVersionTypeAmendment,
+		"filed_in_vat_report_id": filedInVatReportId,
+	}
+	return r.find(ctx, query, bson.D{{"created_at", 1}})
+}
+
+func (r *vatReportVersionRepository) find(
+	ctx context.Context,
+	query bson.M,
+	sorts bson.D,
+) ([]*intPkg.VatReportVersion, error) {
+	opts := options.Find().SetSort(sorts)
+	cursor, err := r.db.Collection(collectionVatReportVersion).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVatReportVersion),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+		)
+		return nil, err
+	}
+
+	var items []*intPkg.VatReportVersion
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVatReportVersion),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+)
+
+// VatReportVersionRepositoryInterface is abstraction layer for working with versions of VAT reports.
+type VatReportVersionRepositoryInterface interface {
+	// Insert adds the version of VAT report to the collection.
+	Insert(context.Context, *intPkg.VatReportVersion) error
+
+	// Update updates the version of VAT report in the collection.
+	Update(context.Context, *intPkg.VatReportVersion) error
+
+	// FindByVatReportId returns versions of the VAT report ordered by number of version.
+	FindByVatReportId(ctx context.Context, vatReportId string) ([]*intPkg.VatReportVersion, error)
+
+	// FindAmendments returns amendments of VAT reports of the operating company in the country
+	// which are filed in the VAT report. Amendments which aren't filed yet are returned for empty VAT report id.
+	FindAmendments(ctx context.Context, operatingCompanyId, country, filedInVatReportId string) ([]*intPkg.VatReportVersion, error)
+}
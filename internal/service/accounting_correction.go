@@ -0,0 +1,521 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"time"
+)
+
+var (
+	accountingCorrectionErrorNotFound           = errors.NewBillingServerErrorMsg("ac000001", "accounting correction not found")
+	accountingCorrectionErrorTypeInvalid        = errors.NewBillingServerErrorMsg("ac000002", "accounting correction type is invalid")
+	accountingCorrectionErrorAmountRequired     = errors.NewBillingServerErrorMsg("ac000003", "accounting correction amount required and must be not zero")
+	accountingCorrectionErrorReasonInvalid      = errors.NewBillingServerErrorMsg("ac000004", "accounting correction reason code is invalid")
+	accountingCorrectionErrorCommentRequired    = errors.NewBillingServerErrorMsg("ac000005", "comment is required for accounting correction with other reason")
+	accountingCorrectionErrorAttachmentInvalid  = errors.NewBillingServerErrorMsg("ac000006", "accounting correction attachment must have name and url")
+	accountingCorrectionErrorUserRequired       = errors.NewBillingServerErrorMsg("ac000007", "user identifier is required")
+	accountingCorrectionErrorStatusInvalid      = errors.NewBillingServerErrorMsg("ac000008", "action isn't allowed in current status of accounting correction")
+	accountingCorrectionErrorSelfApprove        = errors.NewBillingServerErrorMsg("ac000009", "accounting correction must be approved by another user")
+	accountingCorrectionErrorReportNotFound     = errors.NewBillingServerErrorMsg("ac000010", "royalty report of accounting correction not found")
+	accountingCorrectionErrorReportClosed       = errors.NewBillingServerErrorMsg("ac000011", "royalty report of accounting correction is already accepted")
+	accountingCorrectionErrorCurrencyNotDefined = errors.NewBillingServerErrorMsg("ac000012", "currency of accounting correction can't be defined")
+	accountingCorrectionErrorUnknown            = errors.NewBillingServerErrorMsg("ac000013", "unknown error. try request later")
+	accountingCorrectionErrorApproverRole       = errors.NewBillingServerErrorMsg("ac000014", "accounting correction can be approved only by financial manager or administrator")
+
+	accountingCorrectionTypes = map[string]bool{
+		pkg.AccountingEntryTypeMerchantRoyaltyCorrection:     true,
+		pkg.AccountingEntryTypeMerchantRollingReserveCreate:  true,
+		pkg.AccountingEntryTypeMerchantRollingReserveRelease: true,
+	}
+
+	accountingCorrectionReasons = map[string]bool{
+		pkg.AccountingCorrectionReasonFeeAdjustment:  true,
+		pkg.AccountingCorrectionReasonTaxAdjustment:  true,
+		pkg.AccountingCorrectionReasonChargeback:     true,
+		pkg.AccountingCorrectionReasonRollingReserve: true,
+		pkg.AccountingCorrectionReasonGoodwill:       true,
+		pkg.AccountingCorrectionReasonOther:          true,
+	}
+
+	// Statuses of royalty reports which still may be changed by corrections
+	accountingCorrectionReportStatuses = map[string]bool{
+		billingpb.RoyaltyReportStatusPending: true,
+		billingpb.RoyaltyReportStatusDispute: true,
+	}
+
+	// System roles of users who can approve corrections
+	accountingCorrectionApproverRoles = map[string]bool{
+		billingpb.RoleSystemAdmin:     true,
+		billingpb.RoleSystemFinancial: true,
+	}
+)
+
+// CreateAccountingCorrection creates the draft of the correction which must be approved before posting.
+func (s *Service) CreateAccountingCorrection(
+	ctx context.Context,
+	req *intPkg.CreateAccountingCorrectionRequest,
+	rsp *intPkg.AccountingCorrectionResponse,
+) error {
+	if req.UserId == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = accountingCorrectionErrorUserRequired
+		return nil
+	}
+
+	if !accountingCorrectionTypes[req.Type] {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = accountingCorrectionErrorTypeInvalid
+		return nil
+	}
+
+	if req.Amount == 0 {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = accountingCorrectionErrorAmountRequired
+		return nil
+	}
+
+	if !accountingCorrectionReasons[req.ReasonCode] {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = accountingCorrectionErrorReasonInvalid
+		return nil
+	}
+
+	if req.ReasonCode == pkg.AccountingCorrectionReasonOther && req.Comment == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = accountingCorrectionErrorCommentRequired
+		return nil
+	}
+
+	for _, v := range req.Attachments {
+		if v.Name == "" || v.Url == "" {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = accountingCorrectionErrorAttachmentInvalid
+			return nil
+		}
+	}
+
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	correction := &intPkg.AccountingCorrection{
+		MerchantId:      merchant.Id,
+		RoyaltyReportId: req.RoyaltyReportId,
+		Type:            req.Type,
+		Amount:          req.Amount,
+		Currency:        merchant.GetPayoutCurrency(),
+		ReasonCode:      req.ReasonCode,
+		Comment:         req.Comment,
+		Attachments:     req.Attachments,
+		Status:          pkg.AccountingCorrectionStatusDraft,
+		CreatedBy:       req.UserId,
+	}
+
+	if req.RoyaltyReportId != "" {
+		report, err := s.royaltyReportRepository.GetById(ctx, req.RoyaltyReportId)
+
+		if err != nil || report.MerchantId != merchant.Id {
+			rsp.Status = billingpb.ResponseStatusNotFound
+			rsp.Message = accountingCorrectionErrorReportNotFound
+			return nil
+		}
+
+		if !accountingCorrectionReportStatuses[report.Status] {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = accountingCorrectionErrorReportClosed
+			return nil
+		}
+
+		correction.Currency = report.Currency
+	}
+
+	if correction.Currency == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = accountingCorrectionErrorCurrencyNotDefined
+		return nil
+	}
+
+	if err = s.accountingCorrectionRepository.Insert(ctx, correction); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = accountingCorrectionErrorUnknown
+		return nil
+	}
+
+	if err = s.addAccountingCorrectionLog(ctx, correction, pkg.AccountingCorrectionActionCreate, req.UserId, req.Comment); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = accountingCorrectionErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = correction
+
+	return nil
+}
+
+// ApproveAccountingCorrection approves the draft of the correction and posts its accounting entry in the same
+// transaction. The correction can be approved by financial managers and administrators only and can't be approved
+// by the user who created it.
+func (s *Service) ApproveAccountingCorrection(
+	ctx context.Context,
+	req *intPkg.AccountingCorrectionActionRequest,
+	rsp *intPkg.AccountingCorrectionResponse,
+) error {
+	correction, message := s.getAccountingCorrectionForAction(ctx, req, pkg.AccountingCorrectionStatusDraft)
+
+	if message != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = message
+		return nil
+	}
+
+	if correction.CreatedBy == req.UserId {
+		rsp.Status = billingpb.ResponseStatusForbidden
+		rsp.Message = accountingCorrectionErrorSelfApprove
+		return nil
+	}
+
+	role, err := s.userRoleRepository.GetAdminUserByUserId(ctx, req.UserId)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = accountingCorrectionErrorUnknown
+		return nil
+	}
+
+	if role == nil || !accountingCorrectionApproverRoles[role.Role] {
+		rsp.Status = billingpb.ResponseStatusForbidden
+		rsp.Message = accountingCorrectionErrorApproverRole
+		return nil
+	}
+
+	err = database.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		entry, err := s.postAccountingCorrectionEntry(ctx, correction, correction.Amount)
+
+		if err != nil {
+			return err
+		}
+
+		correction.Status = pkg.AccountingCorrectionStatusPosted
+		correction.ApprovedBy = req.UserId
+		correction.EntryId = entry.Id
+
+		return s.updateAccountingCorrection(ctx, correction, pkg.AccountingCorrectionActionApprove, req)
+	})
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = accountingCorrectionErrorUnknown
+
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = e
+		}
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = correction
+
+	return nil
+}
+
+func (s *Service) RejectAccountingCorrection(
+	ctx context.Context,
+	req *intPkg.AccountingCorrectionActionRequest,
+	rsp *intPkg.AccountingCorrectionResponse,
+) error {
+	correction, message := s.getAccountingCorrectionForAction(ctx, req, pkg.AccountingCorrectionStatusDraft)
+
+	if message != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = message
+		return nil
+	}
+
+	correction.Status = pkg.AccountingCorrectionStatusRejected
+
+	if err := s.updateAccountingCorrection(ctx, correction, pkg.AccountingCorrectionActionReject, req); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = accountingCorrectionErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = correction
+
+	return nil
+}
+
+// ReverseAccountingCorrection reverses the posted correction by the counter-entry with the opposite amount.
+func (s *Service) ReverseAccountingCorrection(
+	ctx context.Context,
+	req *intPkg.AccountingCorrectionActionRequest,
+	rsp *intPkg.AccountingCorrectionResponse,
+) error {
+	correction, message := s.getAccountingCorrectionForAction(ctx, req, pkg.AccountingCorrectionStatusPosted)
+
+	if message != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = message
+		return nil
+	}
+
+	err := database.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		entry, err := s.postAccountingCorrectionEntry(ctx, correction, -correction.Amount)
+
+		if err != nil {
+			return err
+		}
+
+		correction.Status = pkg.AccountingCorrectionStatusReversed
+		correction.ReversalEntryId = entry.Id
+
+		return s.updateAccountingCorrection(ctx, correction, pkg.AccountingCorrectionActionReverse, req)
+	})
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = accountingCorrectionErrorUnknown
+
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = e
+		}
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = correction
+
+	return nil
+}
+
+func (s *Service) GetAccountingCorrection(
+	ctx context.Context,
+	req *intPkg.GetAccountingCorrectionRequest,
+	rsp *intPkg.AccountingCorrectionResponse,
+) error {
+	correction, err := s.accountingCorrectionRepository.GetById(ctx, req.CorrectionId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = accountingCorrectionErrorNotFound
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = correction
+
+	return nil
+}
+
+func (s *Service) ListAccountingCorrections(
+	ctx context.Context,
+	req *intPkg.ListAccountingCorrectionsRequest,
+	rsp *intPkg.ListAccountingCorrectionsResponse,
+) error {
+	var err error
+
+	rsp.Count, err = s.accountingCorrectionRepository.FindCount(ctx, req.MerchantId, req.RoyaltyReportId, req.Status)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = accountingCorrectionErrorUnknown
+		return nil
+	}
+
+	if rsp.Count > 0 {
+		rsp.Items, err = s.accountingCorrectionRepository.Find(
+			ctx,
+			req.MerchantId,
+			req.RoyaltyReportId,
+			req.Status,
+			req.Offset,
+			req.Limit,
+		)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = accountingCorrectionErrorUnknown
+			return nil
+		}
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// GetAccountingCorrectionLog returns the audit trail of the correction.
+func (s *Service) GetAccountingCorrectionLog(
+	ctx context.Context,
+	req *intPkg.GetAccountingCorrectionRequest,
+	rsp *intPkg.AccountingCorrectionLogResponse,
+) error {
+	correction, err := s.accountingCorrectionRepository.GetById(ctx, req.CorrectionId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = accountingCorrectionErrorNotFound
+		return nil
+	}
+
+	rsp.Items, err = s.accountingCorrectionLogRepository.FindByCorrectionId(ctx, correction.Id.Hex())
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = accountingCorrectionErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+func (s *Service) getAccountingCorrectionForAction(
+	ctx context.Context,
+	req *intPkg.AccountingCorrectionActionRequest,
+	status string,
+) (*intPkg.AccountingCorrection, *billingpb.ResponseErrorMessage) {
+	if req.UserId == "" {
+		return nil, accountingCorrectionErrorUserRequired
+	}
+
+	correction, err := s.accountingCorrectionRepository.GetById(ctx, req.CorrectionId)
+
+	if err != nil {
+		return nil, accountingCorrectionErrorNotFound
+	}
+
+	if correction.Status != status {
+		return nil, accountingCorrectionErrorStatusInvalid
+	}
+
+	return correction, nil
+}
+
+func (s *Service) updateAccountingCorrection(
+	ctx context.Context,
+	correction *intPkg.AccountingCorrection,
+	action string,
+	req *intPkg.AccountingCorrectionActionRequest,
+) error {
+	if err := s.accountingCorrectionRepository.Update(ctx, correction); err != nil {
+		return err
+	}
+
+	return s.addAccountingCorrectionLog(ctx, correction, action, req.UserId, req.Comment)
+}
+
+func (s *Service) addAccountingCorrectionLog(
+	ctx context.Context,
+	correction *intPkg.AccountingCorrection,
+	action, userId, comment string,
+) error {
+	record := &intPkg.AccountingCorrectionLog{
+		CorrectionId: correction.Id.Hex(),
+		Action:       action,
+		UserId:       userId,
+		Comment:      comment,
+		Status:       correction.Status,
+	}
+
+	return s.accountingCorrectionLogRepository.Insert(ctx, record)
+}
+
+// postAccountingCorrectionEntry posts the accounting entry of the correction. The entry is posted into the period
+// of the linked royalty report while the report may be changed, otherwise it's posted into the next royalty report.
+func (s *Service) postAccountingCorrectionEntry(
+	ctx context.Context,
+	correction *intPkg.AccountingCorrection,
+	amount float64,
+) (*billingpb.AccountingEntry, error) {
+	var (
+		report   *billingpb.RoyaltyReport
+		from, to time.Time
+		err      error
+	)
+
+	date := time.Now()
+
+	if correction.RoyaltyReportId != "" {
+		report, err = s.royaltyReportRepository.GetById(ctx, correction.RoyaltyReportId)
+
+		if err != nil {
+			return nil, accountingCorrectionErrorReportNotFound
+		}
+
+		if accountingCorrectionReportStatuses[report.Status] {
+			if from, err = ptypes.Timestamp(report.PeriodFrom); err != nil {
+				return nil, err
+			}
+
+			if to, err = ptypes.Timestamp(report.PeriodTo); err != nil {
+				return nil, err
+			}
+
+			date = to.Add(-1 * time.Second)
+		} else {
+			report = nil
+		}
+	}
+
+	reason := correction.ReasonCode
+
+	if correction.Comment != "" {
+		reason = fmt.Sprintf("%s%s %s", reason, pkg.AccountingCorrectionReasonCommentSeparator, correction.Comment)
+	}
+
+	reqAe := &billingpb.CreateAccountingEntryRequest{
+		MerchantId: correction.MerchantId,
+		Amount:     amount,
+		Currency:   correction.Currency,
+		Reason:     reason,
+		Date:       date.Unix(),
+		Type:       correction.Type,
+	}
+	resAe := &billingpb.CreateAccountingEntryResponse{}
+	err = s.createAccountingEntry(ctx, reqAe, resAe)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resAe.Status != billingpb.ResponseStatusOk {
+		zap.L().Error(
+			"create accounting correction entry failed",
+			zap.String("correction_id", correction.Id.Hex()),
+			zap.Any("message", resAe.Message),
+		)
+		return nil, resAe.Message
+	}
+
+	if report != nil {
+		if err = s.updateRoyaltyReportCorrections(ctx, report, from, to); err != nil {
+			return nil, err
+		}
+
+		report.UpdatedAt = ptypes.TimestampNow()
+		err = s.royaltyReportRepository.Update(ctx, report, "", pkg.RoyaltyReportChangeSourceAdmin)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resAe.Item, nil
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/mongodb"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	casbinMocks "github.com/paysuper/paysuper-proto/go/casbinpb/mocks"
+	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"testing"
+)
+
+type AccountingCorrectionTestSuite struct {
+	suite.Suite
+	service    *Service
+	merchant   *billingpb.Merchant
+	approverId string
+}
+
+func Test_AccountingCorrection(t *testing.T) {
+	suite.Run(t, new(AccountingCorrectionTestSuite))
+}
+
+func (suite *AccountingCorrectionTestSuite) SetupTest() {
+	cfg, err := config.NewConfig()
+	assert.NoError(suite.T(), err, "Config load failed")
+
+	m, err := migrate.New(
+		"file://../../migrations/tests",
+		cfg.MongoDsn)
+	assert.NoError(suite.T(), err, "Migrate init failed")
+
+	err = m.Up()
+	if err != nil && err.Error() != "no change" {
+		suite.FailNow("Migrations failed", "%v", err)
+	}
+
+	db, err := mongodb.NewDatabase()
+	assert.NoError(suite.T(), err, "Database connection failed")
+
+	redisdb := mocks.NewTestRedis()
+	cache, err := database.NewCacheRedis(redisdb, "cache")
+
+	if err != nil {
+		suite.FailNow("Cache redis initialize failed", "%v", err)
+	}
+
+	suite.service = NewBillingService(
+		db,
+		cfg,
+		mocks.NewGeoIpServiceTestOk(),
+		mocks.NewRepositoryServiceOk(),
+		&mocks.TaxServiceOkMock{},
+		mocks.NewBrokerMockOk(),
+		nil,
+		cache,
+		mocks.NewCurrencyServiceMockOk(),
+		mocks.NewDocumentSignerMockOk(),
+		&reportingMocks.ReporterService{},
+		mocks.NewFormatterOK(),
+		mocks.NewBrokerMockOk(),
+		&casbinMocks.CasbinService{},
+		mocks.NewNotifierOk(),
+		mocks.NewBrokerMockOk(),
+	)
+
+	if err := suite.service.Init(); err != nil {
+		suite.FailNow("Billing service initialization failed", "%v", err)
+	}
+
+	operatingCompany := HelperOperatingCompany(suite.Suite, suite.service)
+	suite.merchant = HelperCreateMerchant(suite.Suite, suite.service, "USD", "RU", nil, 0, operatingCompany.Id)
+
+	suite.approverId = primitive.NewObjectID().Hex()
+	err = suite.service.userRoleRepository.AddAdminUser(context.TODO(), &billingpb.UserRole{
+		Id:     primitive.NewObjectID().Hex(),
+		UserId: suite.approverId,
+		Role:   billingpb.RoleSystemFinancial,
+	})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *AccountingCorrectionTestSuite) TearDownTest() {
+	err := suite.service.db.Drop()
+
+	if err != nil {
+		suite.FailNow("Database deletion failed", "%v", err)
+	}
+
+	err = suite.service.db.Close()
+
+	if err != nil {
+		suite.FailNow("Database close failed", "%v", err)
+	}
+}
+
+func (suite *AccountingCorrectionTestSuite) getCreateRequest() *intPkg.CreateAccountingCorrectionRequest {
+	return &intPkg.CreateAccountingCorrectionRequest{
+		MerchantId: suite.merchant.Id,
+		Type:       pkg.AccountingEntryTypeMerchantRoyaltyCorrection,
+		Amount:     50,
+		ReasonCode: pkg.AccountingCorrectionReasonFeeAdjustment,
+		Comment:    "unit test",
+		Attachments: []*intPkg.AccountingCorrectionAttachment{
+			{Name: "statement.pdf", Url: "https://example.com/statement.pdf"},
+		},
+		UserId: "creator",
+	}
+}
+
+func (suite *AccountingCorrectionTestSuite) createCorrection() *intPkg.AccountingCorrection {
+	rsp := &intPkg.AccountingCorrectionResponse{}
+	err := suite.service.CreateAccountingCorrection(context.TODO(), suite.getCreateRequest(), rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	return rsp.Item
+}
+
+func (suite *AccountingCorrectionTestSuite) approveCorrection(correction *intPkg.AccountingCorrection) *intPkg.AccountingCorrection {
+	req := &intPkg.AccountingCorrectionActionRequest{CorrectionId: correction.Id.Hex(), UserId: suite.approverId}
+	rsp := &intPkg.AccountingCorrectionResponse{}
+	err := suite.service.ApproveAccountingCorrection(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	return rsp.Item
+}
+
+func (suite *AccountingCorrectionTestSuite) getLog(correction *intPkg.AccountingCorrection) []*intPkg.AccountingCorrectionLog {
+	req := &intPkg.GetAccountingCorrectionRequest{CorrectionId: correction.Id.Hex()}
+	rsp := &intPkg.AccountingCorrectionLogResponse{}
+	err := suite.service.GetAccountingCorrectionLog(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	return rsp.Items
+}
+
+func (suite *AccountingCorrectionTestSuite) TestAccountingCorrection_CreateAccountingCorrection_Ok() {
+	correction := suite.createCorrection()
+	assert.Equal(suite.T(), pkg.AccountingCorrectionStatusDraft, correction.Status)
+	assert.Equal(suite.T(), "USD", correction.Currency)
+	assert.Len(suite.T(), correction.Attachments, 1)
+
+	items := suite.getLog(correction)
+	assert.Len(suite.T(), items, 1)
+	assert.Equal(suite.T(), pkg.AccountingCorrectionActionCreate, items[0].Action)
+	assert.Equal(suite.T(), "creator", items[0].UserId)
+	assert.Empty(suite.T(), correction.EntryId)
+}
+
+func (suite *AccountingCorrectionTestSuite) TestAccountingCorrection_CreateAccountingCorrection_ValidationErrors() {
+	requests := map[*billingpb.ResponseErrorMessage]func(req *intPkg.CreateAccountingCorrectionRequest){
+		accountingCorrectionErrorTypeInvalid: func(req *intPkg.CreateAccountingCorrectionRequest) {
+			req.Type = pkg.AccountingEntryTypeRealGrossRevenue
+		},
+		accountingCorrectionErrorAmountRequired: func(req *intPkg.CreateAccountingCorrectionRequest) {
+			req.Amount = 0
+		},
+		accountingCorrectionErrorReasonInvalid: func(req *intPkg.CreateAccountingCorrectionRequest) {
+			req.ReasonCode = "unknown"
+		},
+		accountingCorrectionErrorCommentRequired: func(req *intPkg.CreateAccountingCorrectionRequest) {
+			req.ReasonCode = pkg.AccountingCorrectionReasonOther
+			req.Comment = ""
+		},
+		accountingCorrectionErrorAttachmentInvalid: func(req *intPkg.CreateAccountingCorrectionRequest) {
+			req.Attachments[0].Url = ""
+		},
+		accountingCorrectionErrorUserRequired: func(req *intPkg.CreateAccountingCorrectionRequest) {
+			req.UserId = ""
+		},
+	}
+
+	for message, fn := range requests {
+		req := suite.getCreateRequest()
+		fn(req)
+
+		rsp := &intPkg.AccountingCorrectionResponse{}
+		err := suite.service.CreateAccountingCorrection(context.TODO(), req, rsp)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+		assert.Equal(suite.T(), message, rsp.Message)
+	}
+}
+
+func (suite *AccountingCorrectionTestSuite) TestAccountingCorrection_CreateAccountingCorrection_ReportNotFound_Error() {
+	req := suite.getCreateRequest()
+	req.RoyaltyReportId = primitive.NewObjectID().Hex()
+
+	rsp := &intPkg.AccountingCorrectionResponse{}
+	err := suite.service.CreateAccountingCorrection(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), accountingCorrectionErrorReportNotFound, rsp.Message)
+}
+
+func (suite *AccountingCorrectionTestSuite) TestAccountingCorrection_ApproveAccountingCorrection_Ok() {
+	correction := suite.approveCorrection(suite.createCorrection())
+	assert.Equal(suite.T(), pkg.AccountingCorrectionStatusPosted, correction.Status)
+	assert.Equal(suite.T(), suite.approverId, correction.ApprovedBy)
+	assert.NotEmpty(suite.T(), correction.EntryId)
+
+	entry, err := suite.service.accountingRepository.GetById(context.TODO(), correction.EntryId)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.AccountingEntryTypeMerchantRoyaltyCorrection, entry.Type)
+	assert.EqualValues(suite.T(), 50, entry.Amount)
+	assert.Equal(suite.T(), "fee_adjustment: unit test", entry.Reason)
+
+	items := suite.getLog(correction)
+	assert.Len(suite.T(), items, 2)
+	assert.Equal(suite.T(), pkg.AccountingCorrectionActionApprove, items[1].Action)
+	assert.Equal(suite.T(), pkg.AccountingCorrectionStatusPosted, items[1].Status)
+}
+
+func (suite *AccountingCorrectionTestSuite) TestAccountingCorrection_ApproveAccountingCorrection_SelfApprove_Error() {
+	correction := suite.createCorrection()
+
+	req := &intPkg.AccountingCorrectionActionRequest{CorrectionId: correction.Id.Hex(), UserId: "creator"}
+	rsp := &intPkg.AccountingCorrectionResponse{}
+	err := suite.service.ApproveAccountingCorrection(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusForbidden, rsp.Status)
+	assert.Equal(suite.T(), accountingCorrectionErrorSelfApprove, rsp.Message)
+}
+
+func (suite *AccountingCorrectionTestSuite) TestAccountingCorrection_ApproveAccountingCorrection_ApproverRole_Error() {
+	correction := suite.createCorrection()
+	userId := primitive.NewObjectID().Hex()
+	err := suite.service.userRoleRepository.AddAdminUser(context.TODO(), &billingpb.UserRole{
+		Id:     primitive.NewObjectID().Hex(),
+		UserId: userId,
+		Role:   billingpb.RoleSystemSupport,
+	})
+	assert.NoError(suite.T(), err)
+
+	for _, id := range []string{userId, primitive.NewObjectID().Hex()} {
+		req := &intPkg.AccountingCorrectionActionRequest{CorrectionId: correction.Id.Hex(), UserId: id}
+		rsp := &intPkg.AccountingCorrectionResponse{}
+		err = suite.service.ApproveAccountingCorrection(context.TODO(), req, rsp)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), billingpb.ResponseStatusForbidden, rsp.Status)
+		assert.Equal(suite.T(), accountingCorrectionErrorApproverRole, rsp.Message)
+	}
+}
+
+func (suite *AccountingCorrectionTestSuite) TestAccountingCorrection_RejectAccountingCorrection_Ok() {
+	correction := suite.createCorrection()
+
+	req := &intPkg.AccountingCorrectionActionRequest{
+		CorrectionId: correction.Id.Hex(),
+		UserId:       "approver",
+		Comment:      "wrong amount",
+	}
+	rsp := &intPkg.AccountingCorrectionResponse{}
+	err := suite.service.RejectAccountingCorrection(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.AccountingCorrectionStatusRejected, rsp.Item.Status)
+
+	err = suite.service.ApproveAccountingCorrection(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), accountingCorrectionErrorStatusInvalid, rsp.Message)
+
+	items := suite.getLog(correction)
+	assert.Len(suite.T(), items, 2)
+	assert.Equal(suite.T(), "wrong amount", items[1].Comment)
+}
+
+func (suite *AccountingCorrectionTestSuite) TestAccountingCorrection_ReverseAccountingCorrection_Ok() {
+	correction := suite.approveCorrection(suite.createCorrection())
+
+	req := &intPkg.AccountingCorrectionActionRequest{CorrectionId: correction.Id.Hex(), UserId: "financier"}
+	rsp := &intPkg.AccountingCorrectionResponse{}
+	err := suite.service.ReverseAccountingCorrection(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.AccountingCorrectionStatusReversed, rsp.Item.Status)
+	assert.NotEmpty(suite.T(), rsp.Item.ReversalEntryId)
+
+	entry, err := suite.service.accountingRepository.GetById(context.TODO(), rsp.Item.ReversalEntryId)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), -50, entry.Amount)
+
+	_, err = suite.service.accountingRepository.GetById(context.TODO(), correction.EntryId)
+	assert.NoError(suite.T(), err)
+
+	err = suite.service.ReverseAccountingCorrection(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), accountingCorrectionErrorStatusInvalid, rsp.Message)
+
+	assert.Len(suite.T(), suite.getLog(correction), 3)
+}
+
+func (suite *AccountingCorrectionTestSuite) TestAccountingCorrection_ListAccountingCorrections_Ok() {
+	suite.createCorrection()
+	suite.approveCorrection(suite.createCorrection())
+
+	req := &intPkg.ListAccountingCorrectionsRequest{
+		MerchantId: suite.merchant.Id,
+		Status:     []string{pkg.AccountingCorrectionStatusDraft},
+	}
+	rsp := &intPkg.ListAccountingCorrectionsResponse{}
+	err := suite.service.ListAccountingCorrections(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.EqualValues(suite.T(), 1, rsp.Count)
+	assert.Len(suite.T(), rsp.Items, 1)
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
 	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-billing-server/internal/repository"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	errors2 "github.com/paysuper/paysuper-billing-server/pkg/errors"
@@ -53,6 +55,7 @@ var (
 	accountingEntryOriginalTaxNotFound             = errors2.NewBillingServerErrorMsg("ae00016", "real_tax_fee entry from original order not found, refund processing failed")
 	accountingEntryVatCurrencyNotSet               = errors2.NewBillingServerErrorMsg("ae00017", "vat currency not set")
 	accountingEntryErrorRoundFailed                = errors2.NewBillingServerErrorMsg("ae00018", "amount rounding failed")
+	accountingEntryErrorCorrectionRequired         = errors2.NewBillingServerErrorMsg("ae00019", "accounting entry of this type must be created by accounting correction")
 
 	availableAccountingEntries = map[string]bool{
 		pkg.AccountingEntryTypeRealGrossRevenue:                    true,
@@ -139,6 +142,10 @@ type accountingEntry struct {
 	datetime          *timestamp.Timestamp
 	accountingEntries []*billingpb.AccountingEntry
 	req               *billingpb.CreateAccountingEntryRequest
+	// rebuild is set when entries are recomputed to compare them with the stored entries
+	rebuild bool
+	// rollingReserveHold is the hold of the payment which entry is added to the entries of the payment
+	rollingReserveHold *intPkg.RollingReserveHold
 }
 
 func (s *Service) CreateAccountingEntry(
@@ -153,6 +160,23 @@ func (s *Service) CreateAccountingEntry(
 		return nil
 	}
 
+	if accountingCorrectionTypes[req.Type] {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = accountingEntryErrorCorrectionRequired
+
+		return nil
+	}
+
+	return s.createAccountingEntry(ctx, req, rsp)
+}
+
+// createAccountingEntry creates the manual accounting entry without checking of the entry type. Royalty corrections
+// and rolling reserve entries are created here by approved accounting corrections only.
+func (s *Service) createAccountingEntry(
+	ctx context.Context,
+	req *billingpb.CreateAccountingEntryRequest,
+	rsp *billingpb.CreateAccountingEntryResponse,
+) error {
 	handler := &accountingEntry{Service: s, req: req, ctx: ctx}
 
 	countryCode := ""
@@ -281,16 +305,20 @@ func (s *Service) onPaymentNotify(ctx context.Context, order *billingpb.Order) e
 		return merchantErrorNotFound
 	}
 
-	handler := &accountingEntry{
-		Service:  s,
-		order:    order,
-		ctx:      ctx,
-		country:  country,
-		merchant: merchant,
-		datetime: order.PaymentMethodOrderClosedAt,
-	}
+	// The payment is processed again if the rolling reserve hold of the payment conflicts with holds
+	// of concurrent payments of the merchant
+	return database.WithRetryableTransaction(ctx, s.db, func(ctx context.Context) error {
+		handler := &accountingEntry{
+			Service:  s,
+			order:    order,
+			ctx:      ctx,
+			country:  country,
+			merchant: merchant,
+			datetime: order.PaymentMethodOrderClosedAt,
+		}
 
-	return s.processEvent(handler, accountingEventTypePayment)
+		return s.processEvent(handler, accountingEventTypePayment)
+	})
 }
 
 func (s *Service) onRefundNotify(ctx context.Context, refund *billingpb.Refund, order *billingpb.Order) error {
@@ -335,6 +363,10 @@ func (s *Service) processEvent(handler *accountingEntry, eventType string) error
 	switch eventType {
 	case accountingEventTypePayment:
 		err = handler.processPaymentEvent()
+
+		if err == nil {
+			err = handler.processRollingReserveHold()
+		}
 		break
 
 	case accountingEventTypeRefund:
@@ -353,7 +385,47 @@ func (s *Service) processEvent(handler *accountingEntry, eventType string) error
 		return err
 	}
 
-	return handler.saveAccountingEntries(s.orderViewRepository, s.paylinkRepository, s.paylinkVisitsRepository)
+	journal, err := s.prepareLedgerJournal(handler.ctx, eventType, handler.accountingEntries)
+
+	if err != nil {
+		return err
+	}
+
+	// Entries, the journal, balance transactions and the rolling reserve hold of the event are posted together,
+	// the event can be processed again if any of them fails
+	err = database.WithTransaction(handler.ctx, s.db, func(ctx context.Context) error {
+		if err := s.accountingRepository.MultipleInsert(ctx, handler.accountingEntries); err != nil {
+			return err
+		}
+
+		if journal == nil {
+			return nil
+		}
+
+		if err := s.ledgerJournalRepository.Insert(ctx, journal); err != nil {
+			return err
+		}
+
+		if err := s.addMerchantBalanceTransactionsByJournal(ctx, handler, eventType, journal); err != nil {
+			return err
+		}
+
+		if handler.rollingReserveHold == nil {
+			return nil
+		}
+
+		if handler.rollingReserveHold.Id.IsZero() {
+			return s.rollingReserveHoldRepository.Insert(ctx, handler.rollingReserveHold)
+		}
+
+		return s.rollingReserveHoldRepository.Update(ctx, handler.rollingReserveHold)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return handler.updateAccountingEntriesViews(s.orderViewRepository, s.paylinkRepository, s.paylinkVisitsRepository)
 }
 
 func (h *accountingEntry) processManualCorrectionEvent() error {
@@ -397,7 +469,7 @@ func (h *accountingEntry) processPaymentEvent() error {
 		repository.CollectionOrder,
 	)
 
-	if ae != nil {
+	if ae != nil && !h.rebuild {
 		zap.L().Error(
 			accountingEntryAlreadyCreated.Message,
 			zap.Error(err),
@@ -601,7 +673,13 @@ func (h *accountingEntry) processPaymentEvent() error {
 	// calculated in order_view
 
 	// 25. merchantNetRevenue
-	// calculated in order_view
+	// stored to be posted to the ledger, the order view calculates it by the same formula
+	merchantNetRevenue := h.newEntry(pkg.AccountingEntryTypeMerchantNetRevenue)
+	merchantNetRevenue.Amount = realGrossRevenue.Amount - psGrossRevenueFx.Amount - merchantPsFixedFee.Amount -
+		merchantTaxFeeCentralBankFx.Amount - psMethodFee.Amount - merchantTaxFeeCostValue.Amount
+	if err = h.addEntry(merchantNetRevenue); err != nil {
+		return err
+	}
 
 	// 26. psProfitTotal
 	// calculated in order_view
@@ -625,7 +703,7 @@ func (h *accountingEntry) processRefundEvent() error {
 		return err
 	}
 
-	if aes != nil {
+	if aes != nil && !h.rebuild {
 		zap.L().Error(
 			accountingEntryAlreadyCreated.Message,
 			zap.Error(err),
@@ -1108,6 +1186,15 @@ func (h *accountingEntry) saveAccountingEntries(
 		return err
 	}
 
+	return h.updateAccountingEntriesViews(owr, plr, plvr)
+}
+
+// updateAccountingEntriesViews updates views of orders and statistics of payment links of saved entries.
+func (h *accountingEntry) updateAccountingEntriesViews(
+	owr repository.OrderViewRepositoryInterface,
+	plr repository.PaylinkRepositoryInterface,
+	plvr repository.PaylinkVisitRepositoryInterface,
+) error {
 	var ids []string
 	var paylinks = map[string]string{}
 	if h.order != nil {
@@ -1128,7 +1215,7 @@ func (h *accountingEntry) saveAccountingEntries(
 		return nil
 	}
 
-	err = h.Service.updateOrderView(h.ctx, ids)
+	err := h.Service.updateOrderView(h.ctx, ids)
 	if err != nil {
 		return err
 	}
@@ -1522,33 +1609,47 @@ func (s *Service) RebuildAccountingEntries(ctx context.Context, orderId string,
 		return err
 	}
 
-	if force {
-		zap.L().Info("force rebuld - delete old entries")
-		err = s.accountingRepository.DeleteBySource(ctx, orderId, order.Type)
-		if err != nil {
-			zap.L().Error("accountingRepository.DeleteBySource failed with error", zap.Error(err))
-			return err
+	// Old entries, journals and balance transactions of the order are deleted in the same transaction
+	// the new ones are posted, so the forced rebuild doesn't duplicate changes of the merchant's balance
+	err = database.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if force {
+			zap.L().Info("force rebuld - delete old entries")
+			err := s.accountingRepository.DeleteBySource(ctx, orderId, order.Type)
+			if err != nil {
+				zap.L().Error("accountingRepository.DeleteBySource failed with error", zap.Error(err))
+				return err
+			}
+
+			err = s.ledgerJournalRepository.DeleteBySource(ctx, orderId, order.Type)
+			if err != nil {
+				zap.L().Error("ledgerJournalRepository.DeleteBySource failed with error", zap.Error(err))
+				return err
+			}
+
+			err = s.merchantBalanceTransactionRepository.DeleteBySource(ctx, orderId, order.Type, accountingRebuildBalanceTransactionTypes)
+			if err != nil {
+				zap.L().Error("merchantBalanceTransactionRepository.DeleteBySource failed with error", zap.Error(err))
+				return err
+			}
 		}
-	}
 
-	switch order.Type {
+		switch order.Type {
 
-	case pkg.OrderTypeOrder:
-		err = s.onPaymentNotify(ctx, order)
+		case pkg.OrderTypeOrder:
+			return s.onPaymentNotify(ctx, order)
 
-	case pkg.OrderTypeRefund:
-		refund, err := s.refundRepository.GetById(ctx, order.Refund.ReceiptNumber)
+		case pkg.OrderTypeRefund:
+			refund, err := s.refundRepository.GetById(ctx, order.Refund.ReceiptNumber)
 
-		if err != nil {
-			zap.L().Error("rebuilding accounting entries failed with error", zap.Error(err))
-			return err
-		}
+			if err != nil {
+				return err
+			}
 
-		err = s.onRefundNotify(ctx, refund, order)
+			return s.onRefundNotify(ctx, refund, order)
+		}
 
-	default:
-		err = errors.New("Unsupported order type")
-	}
+		return errors.New("Unsupported order type")
+	})
 
 	if err != nil {
 		zap.L().Error("rebuilding accounting entries failed with error", zap.Error(err))
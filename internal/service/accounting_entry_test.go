@@ -226,7 +226,7 @@ func (suite *AccountingEntryTestSuite) TestAccountingEntry_Ok_RUB_RUB_RUB() {
 	assert.NotNil(suite.T(), refund)
 
 	accountingEntries := suite.helperGetAccountingEntries(order.Id, repository.CollectionOrder)
-	assert.Equal(suite.T(), len(accountingEntries), len(orderControlResults)-11)
+	assert.Equal(suite.T(), len(accountingEntries), len(orderControlResults)-10)
 	merchantRoyaltyCurrency := order.GetMerchantRoyaltyCurrency()
 	assert.Equal(suite.T(), merchantRoyaltyCurrency, "RUB")
 	for _, entry := range accountingEntries {
@@ -338,7 +338,7 @@ func (suite *AccountingEntryTestSuite) TestAccountingEntry_Ok_RUB_USD_RUB() {
 	assert.NotNil(suite.T(), refund)
 
 	orderAccountingEntries := suite.helperGetAccountingEntries(order.Id, repository.CollectionOrder)
-	assert.Equal(suite.T(), len(orderAccountingEntries), len(orderControlResults)-11)
+	assert.Equal(suite.T(), len(orderAccountingEntries), len(orderControlResults)-10)
 	merchantRoyaltyCurrency := order.GetMerchantRoyaltyCurrency()
 	assert.Equal(suite.T(), merchantRoyaltyCurrency, "USD")
 	for _, entry := range orderAccountingEntries {
@@ -450,7 +450,7 @@ func (suite *AccountingEntryTestSuite) TestAccountingEntry_Ok_RUB_USD_USD() {
 	assert.NotNil(suite.T(), refund)
 
 	orderAccountingEntries := suite.helperGetAccountingEntries(order.Id, repository.CollectionOrder)
-	assert.Equal(suite.T(), len(orderAccountingEntries), len(orderControlResults)-11)
+	assert.Equal(suite.T(), len(orderAccountingEntries), len(orderControlResults)-10)
 	merchantRoyaltyCurrency := order.GetMerchantRoyaltyCurrency()
 	assert.Equal(suite.T(), merchantRoyaltyCurrency, "USD")
 	for _, entry := range orderAccountingEntries {
@@ -567,7 +567,7 @@ func (suite *AccountingEntryTestSuite) TestAccountingEntry_Ok_RUB_USD_EUR_VatPay
 	assert.NotNil(suite.T(), refund)
 
 	orderAccountingEntries := suite.helperGetAccountingEntries(order.Id, repository.CollectionOrder)
-	assert.Equal(suite.T(), len(orderAccountingEntries), len(orderControlResults)-11)
+	assert.Equal(suite.T(), len(orderAccountingEntries), len(orderControlResults)-10)
 	merchantRoyaltyCurrency := order.GetMerchantRoyaltyCurrency()
 	assert.Equal(suite.T(), merchantRoyaltyCurrency, "USD")
 	for _, entry := range orderAccountingEntries {
@@ -686,7 +686,7 @@ func (suite *AccountingEntryTestSuite) TestAccountingEntry_Ok_RUB_USD_EUR_VatPay
 	assert.NotNil(suite.T(), refund)
 
 	orderAccountingEntries := suite.helperGetAccountingEntries(order.Id, repository.CollectionOrder)
-	assert.Equal(suite.T(), len(orderAccountingEntries), len(orderControlResults)-11)
+	assert.Equal(suite.T(), len(orderAccountingEntries), len(orderControlResults)-10)
 	merchantRoyaltyCurrency := order.GetMerchantRoyaltyCurrency()
 	assert.Equal(suite.T(), merchantRoyaltyCurrency, "USD")
 	for _, entry := range orderAccountingEntries {
@@ -805,7 +805,7 @@ func (suite *AccountingEntryTestSuite) TestAccountingEntry_Ok_RUB_USD_EUR_VatPay
 	assert.NotNil(suite.T(), refund)
 
 	orderAccountingEntries := suite.helperGetAccountingEntries(order.Id, repository.CollectionOrder)
-	assert.Equal(suite.T(), len(orderAccountingEntries), len(orderControlResults)-11)
+	assert.Equal(suite.T(), len(orderAccountingEntries), len(orderControlResults)-10)
 	merchantRoyaltyCurrency := order.GetMerchantRoyaltyCurrency()
 	assert.Equal(suite.T(), merchantRoyaltyCurrency, "USD")
 	for _, entry := range orderAccountingEntries {
@@ -1219,6 +1219,36 @@ func (suite *AccountingEntryTestSuite) TestAccountingEntry_CreateAccountingEntry
 	assert.Empty(suite.T(), aes)
 }
 
+func (suite *AccountingEntryTestSuite) TestAccountingEntry_CreateAccountingEntry_CorrectionRequired_Error() {
+	types := []string{
+		pkg.AccountingEntryTypeMerchantRoyaltyCorrection,
+		pkg.AccountingEntryTypeMerchantRollingReserveCreate,
+		pkg.AccountingEntryTypeMerchantRollingReserveRelease,
+	}
+
+	for _, v := range types {
+		req := &billingpb.CreateAccountingEntryRequest{
+			Type:       v,
+			MerchantId: primitive.NewObjectID().Hex(),
+			Amount:     10,
+			Currency:   "RUB",
+			Status:     pkg.BalanceTransactionStatusAvailable,
+			Date:       time.Now().Unix(),
+			Reason:     "unit test",
+		}
+		rsp := &billingpb.CreateAccountingEntryResponse{}
+		err := suite.service.CreateAccountingEntry(context.TODO(), req, rsp)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+		assert.Equal(suite.T(), accountingEntryErrorCorrectionRequired, rsp.Message)
+		assert.Nil(suite.T(), rsp.Item)
+
+		aes, err := suite.service.accountingRepository.FindBySource(ctx, req.MerchantId, repository.CollectionMerchant)
+		assert.NoError(suite.T(), err)
+		assert.Empty(suite.T(), aes)
+	}
+}
+
 func (suite *AccountingEntryTestSuite) TestAccountingEntry_CreateAccountingEntry_RefundNotFound_Error() {
 	req := &billingpb.CreateAccountingEntryRequest{
 		Type:     pkg.AccountingEntryTypeRealGrossRevenue,
@@ -1492,5 +1522,24 @@ func (suite *AccountingEntryTestSuite) TestAccountingEntry_Ok_USD_EUR_None() {
 	assert.NotNil(suite.T(), order)
 
 	orderAccountingEntries := suite.helperGetAccountingEntries(order.Id, repository.CollectionOrder)
-	assert.Equal(suite.T(), len(orderAccountingEntries), 15)
+	assert.Equal(suite.T(), len(orderAccountingEntries), 16)
+}
+
+func (suite *AccountingEntryTestSuite) TestAccountingEntry_RebuildAccountingEntries_Force_Ok() {
+	ctx := context.TODO()
+	order := HelperCreateAndPayOrder(suite.Suite, suite.service, 100, "RUB", "RU", suite.projectFixedAmount, suite.paymentMethod, suite.cookie)
+	assert.NotNil(suite.T(), order)
+
+	types := []string{pkg.MerchantBalanceTransactionTypePaymentNet}
+	amounts, err := suite.service.merchantBalanceTransactionRepository.GetAmountsBySource(ctx, order.Id, repository.CollectionOrder, types)
+	assert.NoError(suite.T(), err)
+	entries := suite.helperGetAccountingEntries(order.Id, repository.CollectionOrder)
+
+	err = suite.service.RebuildAccountingEntries(ctx, order.Id, true)
+	assert.NoError(suite.T(), err)
+
+	rebuiltAmounts, err := suite.service.merchantBalanceTransactionRepository.GetAmountsBySource(ctx, order.Id, repository.CollectionOrder, types)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), amounts, rebuiltAmounts)
+	assert.Len(suite.T(), suite.helperGetAccountingEntries(order.Id, repository.CollectionOrder), len(entries))
 }
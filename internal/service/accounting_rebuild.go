@@ -0,0 +1,590 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/internal/repository"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	accountingRebuildErrorFilterRequired   = errors.NewBillingServerErrorMsg("ar000001", "order id, merchant id or dates are required for accounting rebuild")
+	accountingRebuildErrorDatesInvalid     = errors.NewBillingServerErrorMsg("ar000002", "accounting rebuild dates are invalid")
+	accountingRebuildErrorOrderNotFound    = errors.NewBillingServerErrorMsg("ar000003", "order not found for accounting rebuild")
+	accountingRebuildErrorOrderTypeInvalid = errors.NewBillingServerErrorMsg("ar000004", "order type is not supported by accounting rebuild")
+	accountingRebuildErrorUnknown          = errors.NewBillingServerErrorMsg("ar000005", "unknown error. try request later")
+	accountingRebuildErrorApplyForbidden   = errors.NewBillingServerErrorMsg("ar000006", "accounting rebuild can't be applied without database transactions")
+
+	// Types of merchant's balance transactions of the source which change the balance by its payable
+	accountingRebuildBalanceTransactionTypes = []string{
+		pkg.MerchantBalanceTransactionTypePaymentNet,
+		pkg.MerchantBalanceTransactionTypeRefund,
+		pkg.MerchantBalanceTransactionTypeChargeback,
+		pkg.MerchantBalanceTransactionTypeReserveHold,
+		pkg.MerchantBalanceTransactionTypeCorrection,
+	}
+
+	// Fields of accounting entries compared by the rebuild, identity, type and source of entries are always equal
+	accountingRebuildEntryFields = []*accountingRebuildEntryField{
+		{"object", func(e *billingpb.AccountingEntry) string { return e.Object }},
+		{"merchant_id", func(e *billingpb.AccountingEntry) string { return e.MerchantId }},
+		{"operating_company_id", func(e *billingpb.AccountingEntry) string { return e.OperatingCompanyId }},
+		{"status", func(e *billingpb.AccountingEntry) string { return e.Status }},
+		{"country", func(e *billingpb.AccountingEntry) string { return e.Country }},
+		{"amount", func(e *billingpb.AccountingEntry) string { return formatAccountingRebuildAmount(e.Amount) }},
+		{"currency", func(e *billingpb.AccountingEntry) string { return e.Currency }},
+		{"original_amount", func(e *billingpb.AccountingEntry) string { return formatAccountingRebuildAmount(e.OriginalAmount) }},
+		{"original_currency", func(e *billingpb.AccountingEntry) string { return e.OriginalCurrency }},
+		{"local_amount", func(e *billingpb.AccountingEntry) string { return formatAccountingRebuildAmount(e.LocalAmount) }},
+		{"local_currency", func(e *billingpb.AccountingEntry) string { return e.LocalCurrency }},
+		{"amount_rounded", func(e *billingpb.AccountingEntry) string { return formatAccountingRebuildAmount(e.AmountRounded) }},
+		{"original_amount_rounded", func(e *billingpb.AccountingEntry) string {
+			return formatAccountingRebuildAmount(e.OriginalAmountRounded)
+		}},
+		{"local_amount_rounded", func(e *billingpb.AccountingEntry) string {
+			return formatAccountingRebuildAmount(e.LocalAmountRounded)
+		}},
+		{"reason", func(e *billingpb.AccountingEntry) string { return e.Reason }},
+		{"created_at", func(e *billingpb.AccountingEntry) string {
+			if e.CreatedAt == nil {
+				return ""
+			}
+
+			date, err := ptypes.Timestamp(e.CreatedAt)
+
+			if err != nil {
+				return ""
+			}
+
+			return date.UTC().Format(time.RFC3339)
+		}},
+	}
+)
+
+type accountingRebuildEntryField struct {
+	name  string
+	value func(*billingpb.AccountingEntry) string
+}
+
+// accountingRebuildChanges contains the writes which make stored entries of the source equal to the recomputed ones.
+type accountingRebuildChanges struct {
+	diffs      []*intPkg.AccountingEntryDiff
+	inserted   []*billingpb.AccountingEntry
+	replaced   []*billingpb.AccountingEntry
+	deletedIds []string
+}
+
+// RebuildAccounting recomputes accounting entries of the order or of orders of the merchant and dates and returns
+// field-level differences with the stored entries. The differences are written only if apply mode is requested.
+func (s *Service) RebuildAccounting(
+	ctx context.Context,
+	req *intPkg.AccountingRebuildRequest,
+	rsp *intPkg.AccountingRebuildResponse,
+) error {
+	result, err := s.rebuildAccountingEntries(ctx, req)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = accountingRebuildErrorUnknown
+
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = e
+
+			if e == accountingRebuildErrorOrderNotFound {
+				rsp.Status = billingpb.ResponseStatusNotFound
+			}
+		}
+
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = result
+
+	return nil
+}
+
+func (s *Service) rebuildAccountingEntries(
+	ctx context.Context,
+	req *intPkg.AccountingRebuildRequest,
+) (*intPkg.AccountingRebuild, error) {
+	// changes of entries, ledger journal and balance of the source must be written atomically
+	if req.Apply && !s.cfg.AccountingRebuildWithoutTransaction {
+		supported, err := database.IsTransactionSupported(ctx, s.db)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !supported {
+			return nil, accountingRebuildErrorApplyForbidden
+		}
+	}
+
+	orders, err := s.getAccountingRebuildOrders(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &intPkg.AccountingRebuild{
+		DryRun: !req.Apply,
+		Items:  []*intPkg.AccountingRebuildSource{},
+	}
+
+	for _, order := range orders {
+		item, err := s.rebuildOrderAccountingEntries(ctx, order, req.Apply)
+		result.SourcesCount++
+
+		if err != nil {
+			zap.L().Error(
+				"Accounting rebuild of order failed",
+				zap.Error(err),
+				zap.String("order_id", order.Id),
+			)
+
+			result.FailedCount++
+			result.Items = append(result.Items, &intPkg.AccountingRebuildSource{OrderId: order.Id, Error: err.Error()})
+			continue
+		}
+
+		if len(item.Entries) == 0 {
+			continue
+		}
+
+		result.ChangedCount++
+
+		if item.Applied {
+			result.AppliedCount++
+		}
+
+		result.Items = append(result.Items, item)
+	}
+
+	return result, nil
+}
+
+func (s *Service) getAccountingRebuildOrders(
+	ctx context.Context,
+	req *intPkg.AccountingRebuildRequest,
+) ([]*billingpb.Order, error) {
+	if req.OrderId != "" {
+		order, err := s.getOrderById(ctx, req.OrderId)
+
+		if err != nil {
+			return nil, accountingRebuildErrorOrderNotFound
+		}
+
+		return []*billingpb.Order{order}, nil
+	}
+
+	if req.MerchantId == "" && req.DateFrom == 0 && req.DateTo == 0 {
+		return nil, accountingRebuildErrorFilterRequired
+	}
+
+	if req.DateFrom < 0 || req.DateTo < 0 || (req.DateTo > 0 && req.DateTo < req.DateFrom) {
+		return nil, accountingRebuildErrorDatesInvalid
+	}
+
+	query := bson.M{"status": bson.M{"$in": processableOrderStatus}}
+
+	if req.MerchantId != "" {
+		oid, err := primitive.ObjectIDFromHex(req.MerchantId)
+
+		if err != nil {
+			return nil, merchantErrorNotFound
+		}
+
+		query["project.merchant_id"] = oid
+	}
+
+	dates := bson.M{}
+
+	if req.DateFrom > 0 {
+		dates["$gte"] = time.Unix(req.DateFrom, 0)
+	}
+
+	if req.DateTo > 0 {
+		dates["$lte"] = time.Unix(req.DateTo, 0)
+	}
+
+	if len(dates) > 0 {
+		query["pm_order_close_date"] = dates
+	}
+
+	opts := options.Find().SetSort(bson.D{{"pm_order_close_date", 1}, {"_id", 1}})
+
+	return s.orderRepository.GetManyBy(ctx, query, opts)
+}
+
+func (s *Service) rebuildOrderAccountingEntries(
+	ctx context.Context,
+	order *billingpb.Order,
+	apply bool,
+) (*intPkg.AccountingRebuildSource, error) {
+	handler, eventType, err := s.getAccountingRebuildHandler(ctx, order)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sourceType := repository.CollectionOrder
+
+	if eventType == accountingEventTypeRefund {
+		sourceType = repository.CollectionRefund
+	}
+
+	stored, err := s.accountingRepository.FindBySource(ctx, order.Id, sourceType)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if helper.Contains(processableOrderStatus, handler.order.GetPublicStatus()) {
+		switch eventType {
+		case accountingEventTypePayment:
+			err = handler.processPaymentEvent()
+			break
+
+		case accountingEventTypeRefund:
+			err = handler.processRefundEvent()
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Rolling reserve hold of the payment depends on the merchant's policy at the moment of the payment,
+		// it isn't recomputed and is kept in the entries of the source
+		for _, entry := range stored {
+			if rollingReserveAccountingEntries[entry.Type] {
+				handler.accountingEntries = append(handler.accountingEntries, entry)
+			}
+		}
+	}
+
+	changes := getAccountingRebuildChanges(stored, handler.accountingEntries)
+	result := &intPkg.AccountingRebuildSource{
+		OrderId:    order.Id,
+		SourceId:   order.Id,
+		SourceType: sourceType,
+		Entries:    changes.diffs,
+	}
+
+	if !apply || len(changes.diffs) == 0 {
+		return result, nil
+	}
+
+	err = s.applyAccountingRebuild(ctx, handler, eventType, changes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.Applied = true
+
+	return result, nil
+}
+
+func (s *Service) getAccountingRebuildHandler(
+	ctx context.Context,
+	order *billingpb.Order,
+) (*accountingEntry, string, error) {
+	handler := &accountingEntry{
+		Service:  s,
+		ctx:      ctx,
+		datetime: order.PaymentMethodOrderClosedAt,
+		rebuild:  true,
+	}
+	eventType := accountingEventTypePayment
+
+	switch order.Type {
+	case pkg.OrderTypeOrder:
+		handler.order = order
+		break
+
+	case pkg.OrderTypeRefund:
+		refund, err := s.refundRepository.GetById(ctx, order.Refund.ReceiptNumber)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		originalOrder, err := s.getOrderById(ctx, refund.OriginalOrder.Id)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		handler.order = originalOrder
+		handler.refund = refund
+		handler.refundOrder = order
+		eventType = accountingEventTypeRefund
+		break
+
+	default:
+		return nil, "", accountingRebuildErrorOrderTypeInvalid
+	}
+
+	country, err := s.country.GetByIsoCodeA2(ctx, handler.order.GetCountry())
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	merchant, err := s.merchantRepository.GetById(ctx, order.GetMerchantId())
+
+	if err != nil {
+		return nil, "", merchantErrorNotFound
+	}
+
+	handler.country = country
+	handler.merchant = merchant
+
+	return handler, eventType, nil
+}
+
+// applyAccountingRebuild writes the changes of entries of the source, reposts its ledger journal and corrects
+// the merchant's balance up to the recomputed payable of the source in one transaction.
+func (s *Service) applyAccountingRebuild(
+	ctx context.Context,
+	handler *accountingEntry,
+	eventType string,
+	changes *accountingRebuildChanges,
+) error {
+	journal, err := s.prepareLedgerJournal(ctx, eventType, handler.accountingEntries)
+
+	if err != nil {
+		return err
+	}
+
+	newPayable := make(map[string]float64)
+
+	if journal != nil {
+		newPayable = getLedgerJournalMerchantPayable(journal)
+	}
+
+	sourceId := handler.order.Id
+	sourceType := repository.CollectionOrder
+
+	if handler.refundOrder != nil {
+		sourceId = handler.refundOrder.Id
+		sourceType = repository.CollectionRefund
+	}
+
+	err = database.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		err := s.accountingRepository.ApplyChanges(ctx, changes.inserted, changes.replaced, changes.deletedIds)
+
+		if err != nil {
+			return err
+		}
+
+		if err = s.ledgerJournalRepository.DeleteBySource(ctx, sourceId, sourceType); err != nil {
+			return err
+		}
+
+		if journal != nil {
+			if err = s.ledgerJournalRepository.Insert(ctx, journal); err != nil {
+				return err
+			}
+		}
+
+		// The balance is corrected up to the new payable of the source by transactions of the source,
+		// corrections written by the previous rebuild of the source are replaced
+		err = s.merchantBalanceTransactionRepository.DeleteBySource(
+			ctx,
+			sourceId,
+			sourceType,
+			[]string{pkg.MerchantBalanceTransactionTypeCorrection},
+		)
+
+		if err != nil {
+			return err
+		}
+
+		oldPayable, err := s.merchantBalanceTransactionRepository.GetAmountsBySource(
+			ctx,
+			sourceId,
+			sourceType,
+			accountingRebuildBalanceTransactionTypes,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		var currencies []string
+
+		for currency := range newPayable {
+			currencies = append(currencies, currency)
+		}
+
+		for currency := range oldPayable {
+			if _, ok := newPayable[currency]; !ok {
+				currencies = append(currencies, currency)
+			}
+		}
+
+		sort.Strings(currencies)
+
+		for _, currency := range currencies {
+			amount := tools.FormatAmount(newPayable[currency] - oldPayable[currency])
+
+			if amount == 0 {
+				continue
+			}
+
+			err = s.addMerchantBalanceTransaction(ctx, &intPkg.MerchantBalanceTransaction{
+				MerchantId: handler.merchant.Id,
+				Currency:   currency,
+				Type:       pkg.MerchantBalanceTransactionTypeCorrection,
+				Amount:     amount,
+				SourceId:   sourceId,
+				SourceType: sourceType,
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	ids := []string{handler.order.Id}
+
+	if handler.refundOrder != nil {
+		ids = append(ids, handler.refundOrder.Id)
+	}
+
+	return s.updateOrderView(ctx, ids)
+}
+
+// getAccountingRebuildChanges pairs stored and recomputed entries by type in order of their creation
+// and returns differences of paired entries, recomputed entries without pair as added
+// and stored entries without pair as removed.
+func getAccountingRebuildChanges(stored, rebuilt []*billingpb.AccountingEntry) *accountingRebuildChanges {
+	changes := &accountingRebuildChanges{diffs: []*intPkg.AccountingEntryDiff{}}
+	storedByType := make(map[string][]*billingpb.AccountingEntry)
+	paired := make(map[string]bool)
+
+	for _, entry := range stored {
+		storedByType[entry.Type] = append(storedByType[entry.Type], entry)
+	}
+
+	for _, entry := range rebuilt {
+		candidates := storedByType[entry.Type]
+
+		if len(candidates) == 0 {
+			changes.inserted = append(changes.inserted, entry)
+			changes.diffs = append(changes.diffs, &intPkg.AccountingEntryDiff{
+				Type:   entry.Type,
+				Action: pkg.AccountingRebuildActionAdded,
+				Fields: getAccountingEntryFieldsDiff(nil, entry),
+			})
+			continue
+		}
+
+		old := candidates[0]
+		storedByType[entry.Type] = candidates[1:]
+		paired[old.Id] = true
+		entry.Id = old.Id
+
+		fields := getAccountingEntryFieldsDiff(old, entry)
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		changes.replaced = append(changes.replaced, entry)
+		changes.diffs = append(changes.diffs, &intPkg.AccountingEntryDiff{
+			EntryId: old.Id,
+			Type:    entry.Type,
+			Action:  pkg.AccountingRebuildActionChanged,
+			Fields:  fields,
+		})
+	}
+
+	for _, entry := range stored {
+		if paired[entry.Id] {
+			continue
+		}
+
+		changes.deletedIds = append(changes.deletedIds, entry.Id)
+		changes.diffs = append(changes.diffs, &intPkg.AccountingEntryDiff{
+			EntryId: entry.Id,
+			Type:    entry.Type,
+			Action:  pkg.AccountingRebuildActionRemoved,
+			Fields:  getAccountingEntryFieldsDiff(entry, nil),
+		})
+	}
+
+	return changes
+}
+
+// getAccountingEntryFieldsDiff returns fields which values differ, the missing entry has empty values.
+func getAccountingEntryFieldsDiff(old, current *billingpb.AccountingEntry) []*intPkg.AccountingEntryFieldDiff {
+	var fields []*intPkg.AccountingEntryFieldDiff
+
+	for _, field := range accountingRebuildEntryFields {
+		var oldValue, newValue string
+
+		if old != nil {
+			oldValue = field.value(old)
+		}
+
+		if current != nil {
+			newValue = field.value(current)
+		}
+
+		if oldValue == newValue {
+			continue
+		}
+
+		fields = append(fields, &intPkg.AccountingEntryFieldDiff{
+			Field:    field.name,
+			OldValue: oldValue,
+			NewValue: newValue,
+		})
+	}
+
+	return fields
+}
+
+// getLedgerJournalMerchantPayable returns the change of merchant's payable made by the journal by currencies.
+func getLedgerJournalMerchantPayable(journal *intPkg.LedgerJournal) map[string]float64 {
+	amounts := make(map[string]float64)
+
+	for _, line := range journal.Lines {
+		if line.AccountCode != pkg.LedgerAccountMerchantPayable {
+			continue
+		}
+
+		amounts[line.Currency] += line.Credit - line.Debit
+	}
+
+	return amounts
+}
+
+func formatAccountingRebuildAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"github.com/go-redis/redis"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/mongodb"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang/protobuf/proto"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/internal/repository"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	casbinMocks "github.com/paysuper/paysuper-proto/go/casbinpb/mocks"
+	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	rabbitmq "gopkg.in/ProtocolONE/rabbitmq.v1/pkg"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type AccountingRebuildTestSuite struct {
+	suite.Suite
+	service *Service
+
+	projectFixedAmount *billingpb.Project
+	paymentMethod      *billingpb.PaymentMethod
+	cookie             string
+}
+
+func Test_AccountingRebuild(t *testing.T) {
+	suite.Run(t, new(AccountingRebuildTestSuite))
+}
+
+func (suite *AccountingRebuildTestSuite) SetupTest() {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		suite.FailNow("Config load failed", "%v", err)
+	}
+	cfg.CardPayApiUrl = "https://sandbox.cardpay.com"
+
+	m, err := migrate.New(
+		"file://../../migrations/tests",
+		cfg.MongoDsn)
+	assert.NoError(suite.T(), err, "Migrate init failed")
+
+	err = m.Up()
+	if err != nil && err.Error() != "no change" {
+		suite.FailNow("Migrations failed", "%v", err)
+	}
+
+	db, err := mongodb.NewDatabase()
+	if err != nil {
+		suite.FailNow("Database connection failed", "%v", err)
+	}
+
+	broker, err := rabbitmq.NewBroker(cfg.BrokerAddress)
+
+	if err != nil {
+		suite.FailNow("Creating RabbitMQ publisher failed", "%v", err)
+	}
+
+	redisClient := database.NewRedis(
+		&redis.Options{
+			Addr:     cfg.RedisHost,
+			Password: cfg.RedisPassword,
+		},
+	)
+
+	redisdb := mocks.NewTestRedis()
+	cache, err := database.NewCacheRedis(redisdb, "cache")
+	suite.service = NewBillingService(
+		db,
+		cfg,
+		mocks.NewGeoIpServiceTestOk(),
+		mocks.NewRepositoryServiceOk(),
+		mocks.NewTaxServiceOkMock(),
+		broker,
+		redisClient,
+		cache,
+		mocks.NewCurrencyServiceMockOk(),
+		mocks.NewDocumentSignerMockOk(),
+		&reportingMocks.ReporterService{},
+		mocks.NewFormatterOK(),
+		broker,
+		&casbinMocks.CasbinService{},
+		nil,
+		mocks.NewBrokerMockOk(),
+	)
+
+	if err := suite.service.Init(); err != nil {
+		suite.FailNow("Billing service initialization failed", "%v", err)
+	}
+
+	var customer *billingpb.Customer
+	_, suite.projectFixedAmount, suite.paymentMethod, _, customer = HelperCreateEntitiesForTests(suite.Suite, suite.service)
+
+	browserCustomer := &BrowserCookieCustomer{
+		CustomerId: customer.Id,
+		Ip:         "127.0.0.1",
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	suite.cookie, err = suite.service.generateBrowserCookie(browserCustomer)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *AccountingRebuildTestSuite) TearDownTest() {
+	err := suite.service.db.Drop()
+
+	if err != nil {
+		suite.FailNow("Database deletion failed", "%v", err)
+	}
+
+	err = suite.service.db.Close()
+
+	if err != nil {
+		suite.FailNow("Database close failed", "%v", err)
+	}
+}
+
+func (suite *AccountingRebuildTestSuite) createOrder() *billingpb.Order {
+	order := HelperCreateAndPayOrder(
+		suite.Suite,
+		suite.service,
+		100,
+		"RUB",
+		"RU",
+		suite.projectFixedAmount,
+		suite.paymentMethod,
+		suite.cookie,
+	)
+	assert.NotNil(suite.T(), order)
+
+	return order
+}
+
+// changeEntryAmount changes the amount of the stored entry of the order and returns the entry before the change.
+func (suite *AccountingRebuildTestSuite) changeEntryAmount(orderId, entryType string, delta float64) *billingpb.AccountingEntry {
+	entries, err := suite.service.accountingRepository.FindBySource(context.TODO(), orderId, repository.CollectionOrder)
+	assert.NoError(suite.T(), err)
+
+	for _, entry := range entries {
+		if entry.Type != entryType {
+			continue
+		}
+
+		original := proto.Clone(entry).(*billingpb.AccountingEntry)
+		entry.Amount = tools.FormatAmount(entry.Amount + delta)
+		err = suite.service.accountingRepository.ApplyChanges(context.TODO(), nil, []*billingpb.AccountingEntry{entry}, nil)
+		assert.NoError(suite.T(), err)
+
+		return original
+	}
+
+	suite.FailNow("Accounting entry not found", "%s", entryType)
+	return nil
+}
+
+func (suite *AccountingRebuildTestSuite) rebuild(req *intPkg.AccountingRebuildRequest) *intPkg.AccountingRebuild {
+	rsp := &intPkg.AccountingRebuildResponse{}
+	err := suite.service.RebuildAccounting(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	return rsp.Item
+}
+
+func (suite *AccountingRebuildTestSuite) TestAccountingRebuild_DryRun_NoChanges_Ok() {
+	order := suite.createOrder()
+
+	result := suite.rebuild(&intPkg.AccountingRebuildRequest{OrderId: order.Id})
+	assert.True(suite.T(), result.DryRun)
+	assert.EqualValues(suite.T(), 1, result.SourcesCount)
+	assert.EqualValues(suite.T(), 0, result.ChangedCount)
+	assert.EqualValues(suite.T(), 0, result.FailedCount)
+	assert.Empty(suite.T(), result.Items)
+}
+
+func (suite *AccountingRebuildTestSuite) TestAccountingRebuild_DryRun_Changed_Ok() {
+	order := suite.createOrder()
+	original := suite.changeEntryAmount(order.Id, pkg.AccountingEntryTypeMerchantMethodFee, 1)
+
+	result := suite.rebuild(&intPkg.AccountingRebuildRequest{OrderId: order.Id})
+	assert.True(suite.T(), result.DryRun)
+	assert.EqualValues(suite.T(), 1, result.ChangedCount)
+	assert.EqualValues(suite.T(), 0, result.AppliedCount)
+	assert.Len(suite.T(), result.Items, 1)
+
+	item := result.Items[0]
+	assert.Equal(suite.T(), order.Id, item.SourceId)
+	assert.Equal(suite.T(), repository.CollectionOrder, item.SourceType)
+	assert.False(suite.T(), item.Applied)
+	assert.Len(suite.T(), item.Entries, 1)
+	assert.Equal(suite.T(), original.Id, item.Entries[0].EntryId)
+	assert.Equal(suite.T(), pkg.AccountingRebuildActionChanged, item.Entries[0].Action)
+	assert.Len(suite.T(), item.Entries[0].Fields, 1)
+	assert.Equal(suite.T(), "amount", item.Entries[0].Fields[0].Field)
+	assert.Equal(suite.T(), strconv.FormatFloat(original.Amount, 'f', -1, 64), item.Entries[0].Fields[0].NewValue)
+
+	entry, err := suite.service.accountingRepository.GetById(context.TODO(), original.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), tools.FormatAmount(original.Amount+1), entry.Amount)
+}
+
+func (suite *AccountingRebuildTestSuite) TestAccountingRebuild_DryRun_Removed_Ok() {
+	order := suite.createOrder()
+	entries, err := suite.service.accountingRepository.FindBySource(context.TODO(), order.Id, repository.CollectionOrder)
+	assert.NoError(suite.T(), err)
+
+	extra := proto.Clone(entries[0]).(*billingpb.AccountingEntry)
+	extra.Id = primitive.NewObjectID().Hex()
+	err = suite.service.accountingRepository.ApplyChanges(context.TODO(), []*billingpb.AccountingEntry{extra}, nil, nil)
+	assert.NoError(suite.T(), err)
+
+	result := suite.rebuild(&intPkg.AccountingRebuildRequest{OrderId: order.Id})
+	assert.Len(suite.T(), result.Items, 1)
+	assert.Len(suite.T(), result.Items[0].Entries, 1)
+	assert.Equal(suite.T(), pkg.AccountingRebuildActionRemoved, result.Items[0].Entries[0].Action)
+	assert.Equal(suite.T(), extra.Type, result.Items[0].Entries[0].Type)
+}
+
+func (suite *AccountingRebuildTestSuite) TestAccountingRebuild_Apply_Ok() {
+	suite.service.cfg.AccountingRebuildWithoutTransaction = true
+	order := suite.createOrder()
+	original := suite.changeEntryAmount(order.Id, pkg.AccountingEntryTypePsMethodFee, 1)
+
+	// the balance was changed by the net revenue of the stored entries
+	err := suite.service.addMerchantBalanceTransaction(context.TODO(), &intPkg.MerchantBalanceTransaction{
+		MerchantId: original.MerchantId,
+		Currency:   original.Currency,
+		Type:       pkg.MerchantBalanceTransactionTypePaymentNet,
+		Amount:     -1,
+		SourceId:   order.Id,
+		SourceType: repository.CollectionOrder,
+	})
+	assert.NoError(suite.T(), err)
+
+	result := suite.rebuild(&intPkg.AccountingRebuildRequest{OrderId: order.Id, Apply: true})
+	assert.False(suite.T(), result.DryRun)
+	assert.EqualValues(suite.T(), 1, result.ChangedCount)
+	assert.EqualValues(suite.T(), 1, result.AppliedCount)
+	assert.True(suite.T(), result.Items[0].Applied)
+
+	entry, err := suite.service.accountingRepository.GetById(context.TODO(), original.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original.Amount, entry.Amount)
+
+	txs, err := suite.service.merchantBalanceTransactionRepository.Find(
+		context.TODO(),
+		original.MerchantId,
+		original.Currency,
+		[]string{pkg.MerchantBalanceTransactionTypeCorrection},
+		time.Time{},
+		time.Time{},
+		0,
+		0,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), txs, 1)
+	assert.EqualValues(suite.T(), 1, txs[0].Amount)
+	assert.Equal(suite.T(), order.Id, txs[0].SourceId)
+
+	result = suite.rebuild(&intPkg.AccountingRebuildRequest{OrderId: order.Id})
+	assert.EqualValues(suite.T(), 0, result.ChangedCount)
+
+	// the correction written by the previous rebuild is replaced
+	suite.changeEntryAmount(order.Id, pkg.AccountingEntryTypePsMethodFee, 1)
+	result = suite.rebuild(&intPkg.AccountingRebuildRequest{OrderId: order.Id, Apply: true})
+	assert.EqualValues(suite.T(), 1, result.AppliedCount)
+
+	txs, err = suite.service.merchantBalanceTransactionRepository.Find(
+		context.TODO(),
+		original.MerchantId,
+		original.Currency,
+		[]string{pkg.MerchantBalanceTransactionTypeCorrection},
+		time.Time{},
+		time.Time{},
+		0,
+		0,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), txs, 1)
+	assert.EqualValues(suite.T(), 1, txs[0].Amount)
+}
+
+func (suite *AccountingRebuildTestSuite) TestAccountingRebuild_ApplyWithoutTransaction_Error() {
+	supported, err := database.IsTransactionSupported(context.TODO(), suite.service.db)
+	assert.NoError(suite.T(), err)
+
+	if supported {
+		suite.T().Skip("database server supports transactions")
+	}
+
+	order := suite.createOrder()
+	suite.changeEntryAmount(order.Id, pkg.AccountingEntryTypePsMethodFee, 1)
+
+	rsp := &intPkg.AccountingRebuildResponse{}
+	err = suite.service.RebuildAccounting(
+		context.TODO(),
+		&intPkg.AccountingRebuildRequest{OrderId: order.Id, Apply: true},
+		rsp,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), accountingRebuildErrorApplyForbidden, rsp.Message)
+
+	result := suite.rebuild(&intPkg.AccountingRebuildRequest{OrderId: order.Id})
+	assert.EqualValues(suite.T(), 1, result.ChangedCount)
+}
+
+func (suite *AccountingRebuildTestSuite) TestAccountingRebuild_ByMerchant_Ok() {
+	order := suite.createOrder()
+	suite.changeEntryAmount(order.Id, pkg.AccountingEntryTypeMerchantMethodFee, 1)
+
+	result := suite.rebuild(&intPkg.AccountingRebuildRequest{
+		MerchantId: order.GetMerchantId(),
+		DateFrom:   time.Now().Add(-time.Hour).Unix(),
+		DateTo:     time.Now().Add(time.Hour).Unix(),
+	})
+	assert.EqualValues(suite.T(), 1, result.SourcesCount)
+	assert.EqualValues(suite.T(), 1, result.ChangedCount)
+	assert.Equal(suite.T(), order.Id, result.Items[0].OrderId)
+}
+
+func (suite *AccountingRebuildTestSuite) TestAccountingRebuild_FilterRequired_Error() {
+	rsp := &intPkg.AccountingRebuildResponse{}
+	err := suite.service.RebuildAccounting(context.TODO(), &intPkg.AccountingRebuildRequest{}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), accountingRebuildErrorFilterRequired, rsp.Message)
+}
+
+func (suite *AccountingRebuildTestSuite) TestAccountingRebuild_DatesInvalid_Error() {
+	req := &intPkg.AccountingRebuildRequest{
+		DateFrom: time.Now().Unix(),
+		DateTo:   time.Now().Add(-time.Hour).Unix(),
+	}
+	rsp := &intPkg.AccountingRebuildResponse{}
+	err := suite.service.RebuildAccounting(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), accountingRebuildErrorDatesInvalid, rsp.Message)
+}
+
+func (suite *AccountingRebuildTestSuite) TestAccountingRebuild_OrderNotFound_Error() {
+	req := &intPkg.AccountingRebuildRequest{OrderId: primitive.NewObjectID().Hex()}
+	rsp := &intPkg.AccountingRebuildResponse{}
+	err := suite.service.RebuildAccounting(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), accountingRebuildErrorOrderNotFound, rsp.Message)
+}
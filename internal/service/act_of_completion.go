@@ -121,24 +121,44 @@ func (s *Service) GetActOfCompletion(
 		return nil
 	}
 
+	rsp.Item, _, err = s.getActOfCompletion(ctx, merchant, merchant.GetPayoutCurrency(), dateFrom, dateTo)
+
+	if err != nil {
+		return err
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// getActOfCompletion calculates totals of the act of completion of the merchant for the period by the payout
+// account of the merchant in the currency. Returns the act with the royalty report of the period which the act
+// is based on.
+func (s *Service) getActOfCompletion(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+	currency string,
+	dateFrom, dateTo time.Time,
+) (*billingpb.ActOfCompletionDocument, *billingpb.RoyaltyReport, error) {
 	royaltyHandler := &royaltyHandler{
 		Service: s,
 		from:    dateFrom,
 		to:      dateTo,
 	}
-	report, _, err := royaltyHandler.buildMerchantRoyaltyReportRoundedAmounts(ctx, merchant)
+	report, _, err := royaltyHandler.buildMerchantRoyaltyReportRoundedAmounts(ctx, merchant, currency)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	oc, err := s.operatingCompanyRepository.GetById(ctx, report.OperatingCompanyId)
 	if err != nil {
-		return merchantOperatingCompanyNotFound
+		return nil, nil, merchantOperatingCompanyNotFound
 	}
 
 	report.Totals.B2BVatRate, err = s.GetB2bVatRate(oc.Country, merchant.Company.Country)
 	if err != nil {
-		return errorGettingB2BVatRate
+		return nil, nil, errorGettingB2BVatRate
 	}
 
 	report.Totals.B2BVatBase = report.Totals.FeeAmount
@@ -150,8 +170,7 @@ func (s *Service) GetActOfCompletion(
 	report.Totals.B2BVatAmount = math.Round(report.Totals.B2BVatAmount*100) / 100
 	report.Totals.FinalPayoutAmount = math.Round(report.Totals.FinalPayoutAmount*100) / 100
 
-	rsp.Status = billingpb.ResponseStatusOk
-	rsp.Item = &billingpb.ActOfCompletionDocument{
+	act := &billingpb.ActOfCompletionDocument{
 		MerchantId:        merchant.Id,
 		TotalFees:         report.Totals.PayoutAmount,
 		Balance:           report.Totals.FinalPayoutAmount - report.Totals.RollingReserveAmount,
@@ -163,5 +182,5 @@ func (s *Service) GetActOfCompletion(
 		CorrectionsAmount: report.Totals.CorrectionAmount,
 	}
 
-	return nil
+	return act, report, nil
 }
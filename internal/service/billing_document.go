@@ -0,0 +1,767 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/jinzhu/now"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/reporterpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"math"
+	"time"
+)
+
+const (
+	billingDocumentNumberTemplate = "%s-%06d"
+	billingDocumentMonthsInYear   = 12
+)
+
+var (
+	billingDocumentErrorPeriodInvalid     = errors.NewBillingServerErrorMsg("bd000001", "billing document period is invalid or not ended yet")
+	billingDocumentErrorNotFound          = errors.NewBillingServerErrorMsg("bd000002", "billing document not found")
+	billingDocumentErrorOriginalInvalid   = errors.NewBillingServerErrorMsg("bd000003", "note may be issued to act of completion or invoice only")
+	billingDocumentErrorAmountInvalid     = errors.NewBillingServerErrorMsg("bd000004", "amount of note must be greater than zero")
+	billingDocumentErrorAmountExceeded    = errors.NewBillingServerErrorMsg("bd000005", "amount of credit notes exceeds amount of original document")
+	billingDocumentErrorReasonRequired    = errors.NewBillingServerErrorMsg("bd000006", "reason of note is required")
+	billingDocumentErrorFileAlreadyStored = errors.NewBillingServerErrorMsg("bd000007", "file of billing document is already stored")
+	billingDocumentErrorUnknown           = errors.NewBillingServerErrorMsg("bd000008", "unknown error. try request later")
+	billingDocumentErrorNoteTypeInvalid   = errors.NewBillingServerErrorMsg("bd000009", "type of note must be credit note or debit note")
+	billingDocumentErrorOriginalRequired  = errors.NewBillingServerErrorMsg("bd000010", "one of original document and original royalty report is required")
+	billingDocumentErrorLinesRequired     = errors.NewBillingServerErrorMsg("bd000011", "note must have at least one line")
+	billingDocumentErrorLineInvalid       = errors.NewBillingServerErrorMsg("bd000012", "description of note line is required")
+	billingDocumentErrorVatTreatment      = errors.NewBillingServerErrorMsg("bd000013", "vat treatment of note is invalid")
+	billingDocumentErrorReportNotFound    = errors.NewBillingServerErrorMsg("bd000014", "original royalty report not found")
+	billingDocumentErrorReportNotAccepted = errors.NewBillingServerErrorMsg("bd000015", "note may be issued to royalty report accepted by merchant only")
+
+	billingDocumentNumberPrefixes = map[string]string{
+		pkg.BillingDocumentTypeActOfCompletion: "ACT",
+		pkg.BillingDocumentTypeInvoice:         "INV",
+		pkg.BillingDocumentTypeCreditNote:      "CN",
+		pkg.BillingDocumentTypeDebitNote:       "DN",
+	}
+
+	// Types of notes issued to correct monthly documents and royalty reports.
+	billingDocumentNoteTypes = []string{
+		pkg.BillingDocumentTypeCreditNote,
+		pkg.BillingDocumentTypeDebitNote,
+	}
+
+	billingDocumentVatTreatments = []string{
+		pkg.BillingDocumentVatTreatmentStandard,
+		pkg.BillingDocumentVatTreatmentReverseCharge,
+		pkg.BillingDocumentVatTreatmentExempt,
+	}
+
+	// Statuses of royalty reports which may be corrected by notes.
+	billingDocumentNoteRoyaltyReportStatuses = []string{
+		billingpb.RoyaltyReportStatusAccepted,
+		billingpb.RoyaltyReportStatusWaitForPayment,
+		billingpb.RoyaltyReportStatusPaid,
+	}
+
+	// Types of documents issued monthly to merchants.
+	billingDocumentMonthlyTypes = []string{
+		pkg.BillingDocumentTypeActOfCompletion,
+		pkg.BillingDocumentTypeInvoice,
+	}
+)
+
+// billingDocumentHashContent is the content of the document protected by the hash of the document.
+type billingDocumentHashContent struct {
+	Id                      string                        `json:"id"`
+	Type                    string                        `json:"type"`
+	Number                  string                        `json:"number"`
+	MerchantId              string                        `json:"merchant_id"`
+	OperatingCompanyId      string                        `json:"operating_company_id"`
+	OriginalDocumentId      string                        `json:"original_document_id"`
+	OriginalRoyaltyReportId string                        `json:"original_royalty_report_id,omitempty"`
+	DateFrom                string                        `json:"date_from"`
+	DateTo                  string                        `json:"date_to"`
+	Currency                string                        `json:"currency"`
+	TransactionsCount       int32                         `json:"transactions_count"`
+	PayoutAmount            float64                       `json:"payout_amount"`
+	CorrectionsAmount       float64                       `json:"corrections_amount"`
+	Balance                 float64                       `json:"balance"`
+	NetAmount               float64                       `json:"net_amount"`
+	VatRate                 float64                       `json:"vat_rate"`
+	VatAmount               float64                       `json:"vat_amount"`
+	TotalAmount             float64                       `json:"total_amount"`
+	VatTreatment            string                        `json:"vat_treatment,omitempty"`
+	Lines                   []*intPkg.BillingDocumentLine `json:"lines,omitempty"`
+	Reason                  string                        `json:"reason"`
+}
+
+// GenerateBillingDocuments issues the act of completion and the invoice of commission of the merchant
+// for the ended month. Documents which are already issued for the month are returned as is.
+func (s *Service) GenerateBillingDocuments(
+	ctx context.Context,
+	req *intPkg.GenerateBillingDocumentsRequest,
+	rsp *intPkg.GenerateBillingDocumentsResponse,
+) error {
+	if req.Year <= 0 || req.Month < 1 || req.Month > billingDocumentMonthsInYear {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = billingDocumentErrorPeriodInvalid
+		return nil
+	}
+
+	from, to := getBillingDocumentPeriodDates(req.Year, req.Month)
+
+	if !to.Before(time.Now()) {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = billingDocumentErrorPeriodInvalid
+		return nil
+	}
+
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	rsp.Items, err = s.generateBillingDocuments(ctx, merchant, from, to, req.UserId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = billingDocumentErrorUnknown
+
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Message = e
+		}
+
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// GenerateMonthlyBillingDocuments issues acts of completion and invoices of commission of all merchants
+// for the month previous to the date. Merchants without payments before the end of the month are skipped.
+func (s *Service) GenerateMonthlyBillingDocuments(ctx context.Context, date time.Time) error {
+	month := now.New(date).BeginningOfMonth().AddDate(0, -1, 0)
+	from, to := getBillingDocumentPeriodDates(int32(month.Year()), int32(month.Month()))
+
+	merchants, err := s.merchantRepository.GetAll(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, merchant := range merchants {
+		if merchant.FirstPaymentAt == nil || merchant.FirstPaymentAt.Seconds <= 0 ||
+			merchant.FirstPaymentAt.Seconds > to.Unix() {
+			continue
+		}
+
+		documents, err := s.generateBillingDocuments(ctx, merchant, from, to, "")
+
+		if err != nil {
+			return err
+		}
+
+		for _, document := range documents {
+			zap.L().Info(
+				"billing document issued",
+				zap.String("merchant_id", merchant.Id),
+				zap.String("type", document.Type),
+				zap.String("number", document.Number),
+			)
+		}
+	}
+
+	return nil
+}
+
+// GetBillingDocument returns the document. The document of other merchant isn't returned if the merchant is passed.
+func (s *Service) GetBillingDocument(
+	ctx context.Context,
+	req *intPkg.GetBillingDocumentRequest,
+	rsp *intPkg.BillingDocumentResponse,
+) error {
+	document, err := s.billingDocumentRepository.GetById(ctx, req.DocumentId)
+
+	if err != nil || (req.MerchantId != "" && document.MerchantId != req.MerchantId) {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = billingDocumentErrorNotFound
+		return nil
+	}
+
+	if !isBillingDocumentHashValid(document) {
+		zap.L().Error(
+			"hash of billing document doesn't match its content",
+			zap.String("document_id", req.DocumentId),
+			zap.String("number", document.Number),
+		)
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = document
+
+	return nil
+}
+
+func (s *Service) ListBillingDocuments(
+	ctx context.Context,
+	req *intPkg.ListBillingDocumentsRequest,
+	rsp *intPkg.ListBillingDocumentsResponse,
+) error {
+	var err error
+
+	rsp.Count, err = s.billingDocumentRepository.FindCount(ctx, req.MerchantId, req.Type)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = billingDocumentErrorUnknown
+		return nil
+	}
+
+	if rsp.Count > 0 {
+		rsp.Items, err = s.billingDocumentRepository.Find(ctx, req.MerchantId, req.Type, req.Offset, req.Limit)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = billingDocumentErrorUnknown
+			return nil
+		}
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// CreateBillingCreditNote issues the credit note with the single line to the act of completion or the invoice.
+// Issued documents are never changed, the credit note refers to the original document and reduces its amount.
+// The total amount of credit notes of the document can't exceed the amount of the document.
+func (s *Service) CreateBillingCreditNote(
+	ctx context.Context,
+	req *intPkg.CreateBillingCreditNoteRequest,
+	rsp *intPkg.BillingDocumentResponse,
+) error {
+	noteReq := &intPkg.CreateBillingNoteRequest{
+		Type:               pkg.BillingDocumentTypeCreditNote,
+		OriginalDocumentId: req.OriginalDocumentId,
+		Lines:              []*intPkg.CreateBillingNoteLine{{Description: req.Reason, NetAmount: req.NetAmount}},
+		Reason:             req.Reason,
+		UserId:             req.UserId,
+	}
+
+	return s.CreateBillingNote(ctx, noteReq, rsp)
+}
+
+// CreateBillingNote issues the credit or debit note to the act of completion, the invoice or the royalty report.
+// The amount of the credit note is payable to the merchant and the amount of the debit note is payable by
+// the merchant. The note is posted as the royalty correction of the merchant in the same transaction, so the balance
+// and the next royalty report include it once and it must not be corrected by accounting corrections again.
+func (s *Service) CreateBillingNote(
+	ctx context.Context,
+	req *intPkg.CreateBillingNoteRequest,
+	rsp *intPkg.BillingDocumentResponse,
+) error {
+	if !helper.Contains(billingDocumentNoteTypes, req.Type) {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = billingDocumentErrorNoteTypeInvalid
+		return nil
+	}
+
+	if (req.OriginalDocumentId == "") == (req.OriginalRoyaltyReportId == "") {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = billingDocumentErrorOriginalRequired
+		return nil
+	}
+
+	document := &intPkg.BillingDocument{
+		Type:         req.Type,
+		VatTreatment: req.VatTreatment,
+		Reason:       req.Reason,
+		CreatedBy:    req.UserId,
+	}
+
+	if document.VatTreatment == "" {
+		document.VatTreatment = pkg.BillingDocumentVatTreatmentStandard
+	}
+
+	var (
+		original *intPkg.BillingDocument
+		vatRate  float64
+		err      error
+	)
+
+	if req.OriginalDocumentId != "" {
+		original, err = s.billingDocumentRepository.GetById(ctx, req.OriginalDocumentId)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusNotFound
+			rsp.Message = billingDocumentErrorNotFound
+			return nil
+		}
+
+		if !helper.Contains(billingDocumentMonthlyTypes, original.Type) {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = billingDocumentErrorOriginalInvalid
+			return nil
+		}
+
+		document.MerchantId = original.MerchantId
+		document.OperatingCompanyId = original.OperatingCompanyId
+		document.OriginalDocumentId = original.Id.Hex()
+		document.DateFrom = original.DateFrom
+		document.DateTo = original.DateTo
+		document.Currency = original.Currency
+		vatRate = original.VatRate
+	} else {
+		report, err := s.royaltyReportRepository.GetById(ctx, req.OriginalRoyaltyReportId)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusNotFound
+			rsp.Message = billingDocumentErrorReportNotFound
+			return nil
+		}
+
+		if !helper.Contains(billingDocumentNoteRoyaltyReportStatuses, report.Status) {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = billingDocumentErrorReportNotAccepted
+			return nil
+		}
+
+		document.MerchantId = report.MerchantId
+		document.OperatingCompanyId = report.OperatingCompanyId
+		document.OriginalRoyaltyReportId = report.Id
+		document.DateFrom, _ = ptypes.Timestamp(report.PeriodFrom)
+		document.DateTo, _ = ptypes.Timestamp(report.PeriodTo)
+		document.Currency = report.Currency
+		vatRate = report.GetTotals().GetB2BVatRate()
+	}
+
+	if req.Reason == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = billingDocumentErrorReasonRequired
+		return nil
+	}
+
+	if len(req.Lines) <= 0 {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = billingDocumentErrorLinesRequired
+		return nil
+	}
+
+	for _, line := range req.Lines {
+		if line.NetAmount <= 0 {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = billingDocumentErrorAmountInvalid
+			return nil
+		}
+
+		if line.Description == "" {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = billingDocumentErrorLineInvalid
+			return nil
+		}
+	}
+
+	if !helper.Contains(billingDocumentVatTreatments, document.VatTreatment) {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = billingDocumentErrorVatTreatment
+		return nil
+	}
+
+	if document.VatTreatment != pkg.BillingDocumentVatTreatmentStandard {
+		vatRate = 0
+	}
+
+	setBillingDocumentLines(document, req.Lines, vatRate)
+
+	if original != nil && document.Type == pkg.BillingDocumentTypeCreditNote {
+		notes, err := s.billingDocumentRepository.FindByOriginalDocumentId(ctx, original.Id.Hex())
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = billingDocumentErrorUnknown
+			return nil
+		}
+
+		credited := document.NetAmount
+
+		for _, note := range notes {
+			if note.Type == pkg.BillingDocumentTypeCreditNote {
+				credited += note.NetAmount
+			}
+		}
+
+		if math.Round(credited*100) > math.Round(original.NetAmount*100) {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = billingDocumentErrorAmountExceeded
+			return nil
+		}
+	}
+
+	err = database.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if err := s.insertBillingDocument(ctx, document); err != nil {
+			return err
+		}
+
+		return s.postBillingNoteEntry(ctx, document)
+	})
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = billingDocumentErrorUnknown
+		return nil
+	}
+
+	_ = s.renderBillingDocument(ctx, document)
+
+	if _, err = s.updateMerchantBalance(ctx, document.MerchantId); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = billingDocumentErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = document
+
+	return nil
+}
+
+// BillingDocumentPdfUploaded stores the PDF file of the document rendered by the reporter service with its hash.
+// The file of the document is stored once and can't be replaced.
+func (s *Service) BillingDocumentPdfUploaded(
+	ctx context.Context,
+	req *intPkg.BillingDocumentPdfUploadedRequest,
+	rsp *intPkg.BillingDocumentResponse,
+) error {
+	document, err := s.billingDocumentRepository.GetById(ctx, req.DocumentId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = billingDocumentErrorNotFound
+		return nil
+	}
+
+	if document.FileHash != "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = billingDocumentErrorFileAlreadyStored
+		return nil
+	}
+
+	hash := sha256.Sum256(req.Content)
+	err = s.billingDocumentRepository.SetFile(ctx, document, req.Filename, req.Content, hex.EncodeToString(hash[:]))
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = billingDocumentErrorUnknown
+
+		if err == mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = billingDocumentErrorFileAlreadyStored
+		}
+
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = document
+
+	return nil
+}
+
+// generateBillingDocuments issues monthly documents of the merchant for the period which aren't issued yet.
+// Documents are issued per payout account of the merchant, documents of additional payout accounts are issued
+// only if orders or corrections of the period are routed to the account. Rendering of issued documents without
+// the file is requested again.
+func (s *Service) generateBillingDocuments(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+	from, to time.Time,
+	userId string,
+) ([]*intPkg.BillingDocument, error) {
+	accounts, err := s.getMerchantPayoutAccounts(ctx, merchant)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*intPkg.BillingDocument
+
+	for i, account := range accounts {
+		documents, err := s.generateBillingDocumentsByCurrency(ctx, merchant, account.Currency, from, to, userId, i > 0)
+
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, documents...)
+	}
+
+	return items, nil
+}
+
+func (s *Service) generateBillingDocumentsByCurrency(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+	currency string,
+	from, to time.Time,
+	userId string,
+	skipEmpty bool,
+) ([]*intPkg.BillingDocument, error) {
+	var (
+		act    *billingpb.ActOfCompletionDocument
+		report *billingpb.RoyaltyReport
+		items  []*intPkg.BillingDocument
+	)
+
+	for _, documentType := range billingDocumentMonthlyTypes {
+		document, err := s.billingDocumentRepository.GetByPeriod(ctx, merchant.Id, documentType, currency, from)
+
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+
+		if document != nil {
+			if document.FileHash == "" {
+				_ = s.renderBillingDocument(ctx, document)
+			}
+
+			items = append(items, document)
+			continue
+		}
+
+		if act == nil {
+			act, report, err = s.getActOfCompletion(ctx, merchant, currency, from, to)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if skipEmpty && act.TotalTransactions == 0 && act.CorrectionsAmount == 0 {
+			continue
+		}
+
+		document = &intPkg.BillingDocument{
+			Type:               documentType,
+			MerchantId:         merchant.Id,
+			OperatingCompanyId: report.OperatingCompanyId,
+			DateFrom:           from,
+			DateTo:             to,
+			Currency:           report.Currency,
+			CreatedBy:          userId,
+		}
+		setBillingDocumentAmounts(document, act.B2BVatBase, act.B2BVatRate)
+
+		if documentType == pkg.BillingDocumentTypeActOfCompletion {
+			document.TransactionsCount = act.TotalTransactions
+			document.PayoutAmount = act.TotalFees
+			document.CorrectionsAmount = act.CorrectionsAmount
+			document.Balance = math.Round(act.Balance*100) / 100
+		}
+
+		if err = s.issueBillingDocument(ctx, document); err != nil {
+			return nil, err
+		}
+
+		items = append(items, document)
+	}
+
+	return items, nil
+}
+
+// issueBillingDocument stores the document and asks the reporter service to render the PDF file.
+// The document stays issued if rendering fails, the failure is only logged.
+func (s *Service) issueBillingDocument(ctx context.Context, document *intPkg.BillingDocument) error {
+	if err := s.insertBillingDocument(ctx, document); err != nil {
+		return err
+	}
+
+	_ = s.renderBillingDocument(ctx, document)
+
+	return nil
+}
+
+// insertBillingDocument assigns the next number of documents of the type of the operating company to the document,
+// calculates the hash of the document and stores it.
+func (s *Service) insertBillingDocument(ctx context.Context, document *intPkg.BillingDocument) error {
+	sequence, err := s.autoincrementRepository.GetBillingDocumentAutoincrementId(
+		ctx,
+		document.OperatingCompanyId,
+		document.Type,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	document.Id = primitive.NewObjectID()
+	document.Sequence = sequence
+	document.Number = fmt.Sprintf(billingDocumentNumberTemplate, billingDocumentNumberPrefixes[document.Type], sequence)
+	document.CreatedAt = time.Now()
+	document.Hash, err = getBillingDocumentHash(document)
+
+	if err != nil {
+		return err
+	}
+
+	return s.billingDocumentRepository.Insert(ctx, document)
+}
+
+// postBillingNoteEntry posts the amount of the note payable to the merchant as the royalty correction entry
+// with the ledger journal. The reason of the entry refers to the number of the note.
+func (s *Service) postBillingNoteEntry(ctx context.Context, note *intPkg.BillingDocument) error {
+	req := &billingpb.CreateAccountingEntryRequest{
+		MerchantId: note.MerchantId,
+		Amount:     note.PayoutAmount,
+		Currency:   note.Currency,
+		Reason: fmt.Sprintf(
+			"%s%s %s %s",
+			pkg.AccountingCorrectionReasonBillingNote,
+			pkg.AccountingCorrectionReasonCommentSeparator,
+			note.Number,
+			note.Reason,
+		),
+		Date: note.CreatedAt.Unix(),
+		Type: pkg.AccountingEntryTypeMerchantRoyaltyCorrection,
+	}
+	rsp := &billingpb.CreateAccountingEntryResponse{}
+
+	if err := s.createAccountingEntry(ctx, req, rsp); err != nil {
+		return err
+	}
+
+	if rsp.Status != billingpb.ResponseStatusOk {
+		zap.L().Error(
+			"create billing note entry failed",
+			zap.String("billing_document_id", note.Id.Hex()),
+			zap.Any("message", rsp.Message),
+		)
+		return rsp.Message
+	}
+
+	return nil
+}
+
+func (s *Service) renderBillingDocument(ctx context.Context, document *intPkg.BillingDocument) error {
+	params, err := json.Marshal(map[string]interface{}{reporterpb.ParamsFieldId: document.Id.Hex()})
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to marshal the params of billing document for the reporting service.",
+			zap.Error(err),
+		)
+		return err
+	}
+
+	req := &reporterpb.ReportFile{
+		UserId:           document.CreatedBy,
+		MerchantId:       document.MerchantId,
+		ReportType:       pkg.ReportTypeBillingDocument,
+		FileType:         reporterpb.OutputExtensionPdf,
+		Params:           params,
+		SendNotification: document.CreatedBy != "",
+	}
+
+	return s.reporterServiceCreateFile(ctx, req)
+}
+
+// setBillingDocumentAmounts sets the amount excluding VAT, VAT and the total amount of the document.
+func setBillingDocumentAmounts(document *intPkg.BillingDocument, netAmount, vatRate float64) {
+	document.NetAmount = math.Round(netAmount*100) / 100
+	document.VatRate = vatRate
+	document.VatAmount = math.Round(document.NetAmount*vatRate*100) / 100
+	document.TotalAmount = math.Round((document.NetAmount+document.VatAmount)*100) / 100
+}
+
+// setBillingDocumentLines sets lines of the note with VAT calculated by the line and totals of the note.
+// The payout amount of the note is positive for the credit note and negative for the debit note.
+func setBillingDocumentLines(document *intPkg.BillingDocument, lines []*intPkg.CreateBillingNoteLine, vatRate float64) {
+	document.Lines = make([]*intPkg.BillingDocumentLine, 0, len(lines))
+	document.VatRate = vatRate
+	document.NetAmount = 0
+	document.VatAmount = 0
+
+	for _, v := range lines {
+		line := &intPkg.BillingDocumentLine{
+			Description: v.Description,
+			NetAmount:   math.Round(v.NetAmount*100) / 100,
+			VatRate:     vatRate,
+		}
+		line.VatAmount = math.Round(line.NetAmount*vatRate*100) / 100
+		line.TotalAmount = math.Round((line.NetAmount+line.VatAmount)*100) / 100
+
+		document.Lines = append(document.Lines, line)
+		document.NetAmount += line.NetAmount
+		document.VatAmount += line.VatAmount
+	}
+
+	document.NetAmount = math.Round(document.NetAmount*100) / 100
+	document.VatAmount = math.Round(document.VatAmount*100) / 100
+	document.TotalAmount = math.Round((document.NetAmount+document.VatAmount)*100) / 100
+	document.PayoutAmount = document.TotalAmount
+
+	if document.Type == pkg.BillingDocumentTypeDebitNote {
+		document.PayoutAmount = -document.TotalAmount
+	}
+}
+
+// getBillingDocumentHash returns the SHA-256 hash of the content of the document. Dates are hashed
+// in UTC with precision to seconds to get the same hash for the document loaded from the database.
+func getBillingDocumentHash(document *intPkg.BillingDocument) (string, error) {
+	content := &billingDocumentHashContent{
+		Id:                      document.Id.Hex(),
+		Type:                    document.Type,
+		Number:                  document.Number,
+		MerchantId:              document.MerchantId,
+		OperatingCompanyId:      document.OperatingCompanyId,
+		OriginalDocumentId:      document.OriginalDocumentId,
+		OriginalRoyaltyReportId: document.OriginalRoyaltyReportId,
+		DateFrom:                document.DateFrom.UTC().Format(time.RFC3339),
+		DateTo:                  document.DateTo.UTC().Format(time.RFC3339),
+		Currency:                document.Currency,
+		TransactionsCount:       document.TransactionsCount,
+		PayoutAmount:            document.PayoutAmount,
+		CorrectionsAmount:       document.CorrectionsAmount,
+		Balance:                 document.Balance,
+		NetAmount:               document.NetAmount,
+		VatRate:                 document.VatRate,
+		VatAmount:               document.VatAmount,
+		TotalAmount:             document.TotalAmount,
+		VatTreatment:            document.VatTreatment,
+		Lines:                   document.Lines,
+		Reason:                  document.Reason,
+	}
+	b, err := json.Marshal(content)
+
+	if err != nil {
+		zap.L().Error("Unable to marshal the content of billing document", zap.Error(err))
+		return "", err
+	}
+
+	hash := sha256.Sum256(b)
+
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// getBillingDocumentPeriodDates returns the first and the last moments of the month in UTC.
+func getBillingDocumentPeriodDates(year, month int32) (time.Time, time.Time) {
+	from := time.Date(int(year), time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return from, now.New(from).EndOfMonth()
+}
+
+// isBillingDocumentHashValid checks the content of the document wasn't changed after issue.
+func isBillingDocumentHashValid(document *intPkg.BillingDocument) bool {
+	hash, err := getBillingDocumentHash(document)
+	return err == nil && hash == document.Hash
+}
@@ -0,0 +1,512 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/jinzhu/now"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+func (suite *RoyaltyReportTestSuite) TestBillingDocument_GenerateBillingDocuments_Ok() {
+	month := now.BeginningOfMonth().AddDate(0, -1, 0)
+	req := &intPkg.GenerateBillingDocumentsRequest{
+		MerchantId: suite.merchant.Id,
+		Year:       int32(month.Year()),
+		Month:      int32(month.Month()),
+	}
+	rsp := &intPkg.GenerateBillingDocumentsResponse{}
+	err := suite.service.GenerateBillingDocuments(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 2)
+
+	act := rsp.Items[0]
+	assert.Equal(suite.T(), pkg.BillingDocumentTypeActOfCompletion, act.Type)
+	assert.Equal(suite.T(), "ACT-000001", act.Number)
+	assert.Equal(suite.T(), suite.merchant.OperatingCompanyId, act.OperatingCompanyId)
+	assert.Equal(suite.T(), time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC), act.DateFrom)
+	assert.NotEmpty(suite.T(), act.Hash)
+
+	invoice := rsp.Items[1]
+	assert.Equal(suite.T(), pkg.BillingDocumentTypeInvoice, invoice.Type)
+	assert.Equal(suite.T(), "INV-000001", invoice.Number)
+	assert.Equal(suite.T(), act.NetAmount, invoice.NetAmount)
+	assert.Equal(suite.T(), act.TotalAmount, invoice.TotalAmount)
+
+	rsp1 := &intPkg.GenerateBillingDocumentsResponse{}
+	err = suite.service.GenerateBillingDocuments(context.TODO(), req, rsp1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp1.Status)
+	assert.Len(suite.T(), rsp1.Items, 2)
+	assert.Equal(suite.T(), act.Id, rsp1.Items[0].Id)
+	assert.Equal(suite.T(), invoice.Id, rsp1.Items[1].Id)
+
+	req.MerchantId = suite.merchant1.Id
+	rsp1 = &intPkg.GenerateBillingDocumentsResponse{}
+	err = suite.service.GenerateBillingDocuments(context.TODO(), req, rsp1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp1.Status)
+	assert.Equal(suite.T(), "ACT-000002", rsp1.Items[0].Number)
+	assert.Equal(suite.T(), "INV-000002", rsp1.Items[1].Number)
+
+	docRsp := &intPkg.BillingDocumentResponse{}
+	err = suite.service.GetBillingDocument(
+		context.TODO(),
+		&intPkg.GetBillingDocumentRequest{DocumentId: act.Id.Hex(), MerchantId: suite.merchant.Id},
+		docRsp,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, docRsp.Status)
+	assert.True(suite.T(), isBillingDocumentHashValid(docRsp.Item))
+
+	docRsp = &intPkg.BillingDocumentResponse{}
+	err = suite.service.GetBillingDocument(
+		context.TODO(),
+		&intPkg.GetBillingDocumentRequest{DocumentId: act.Id.Hex(), MerchantId: suite.merchant1.Id},
+		docRsp,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, docRsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorNotFound, docRsp.Message)
+
+	listRsp := &intPkg.ListBillingDocumentsResponse{}
+	err = suite.service.ListBillingDocuments(
+		context.TODO(),
+		&intPkg.ListBillingDocumentsRequest{MerchantId: suite.merchant.Id, Type: pkg.BillingDocumentTypeInvoice},
+		listRsp,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, listRsp.Status)
+	assert.EqualValues(suite.T(), 1, listRsp.Count)
+	assert.Equal(suite.T(), invoice.Id, listRsp.Items[0].Id)
+}
+
+func (suite *RoyaltyReportTestSuite) TestBillingDocument_GenerateBillingDocuments_PayoutAccounts_Ok() {
+	month := now.BeginningOfMonth().AddDate(0, -1, 0)
+	banking := &billingpb.MerchantBanking{Name: "Bank", AccountNumber: "0001"}
+
+	for _, currency := range []string{"EUR", "GBP"} {
+		accountRsp := &intPkg.MerchantPayoutAccountResponse{}
+		err := suite.service.SetMerchantPayoutAccount(
+			context.TODO(),
+			&intPkg.MerchantPayoutAccount{MerchantId: suite.merchant.Id, Currency: currency, Banking: banking},
+			accountRsp,
+		)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), billingpb.ResponseStatusOk, accountRsp.Status)
+	}
+
+	entryReq := &billingpb.CreateAccountingEntryRequest{
+		Type:       pkg.AccountingEntryTypeMerchantRoyaltyCorrection,
+		MerchantId: suite.merchant.Id,
+		Amount:     10,
+		Currency:   "EUR",
+		Status:     pkg.BalanceTransactionStatusAvailable,
+		Date:       month.AddDate(0, 0, 10).Unix(),
+		Reason:     "unit test",
+	}
+	entryRsp := &billingpb.CreateAccountingEntryResponse{}
+	err := suite.service.createAccountingEntry(context.TODO(), entryReq, entryRsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, entryRsp.Status)
+
+	req := &intPkg.GenerateBillingDocumentsRequest{
+		MerchantId: suite.merchant.Id,
+		Year:       int32(month.Year()),
+		Month:      int32(month.Month()),
+	}
+	rsp := &intPkg.GenerateBillingDocumentsResponse{}
+	err = suite.service.GenerateBillingDocuments(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 4)
+
+	assert.Equal(suite.T(), suite.merchant.GetPayoutCurrency(), rsp.Items[0].Currency)
+	assert.Equal(suite.T(), suite.merchant.GetPayoutCurrency(), rsp.Items[1].Currency)
+
+	act := rsp.Items[2]
+	assert.Equal(suite.T(), pkg.BillingDocumentTypeActOfCompletion, act.Type)
+	assert.Equal(suite.T(), "EUR", act.Currency)
+	assert.Equal(suite.T(), "ACT-000002", act.Number)
+	assert.Equal(suite.T(), 10.0, act.CorrectionsAmount)
+	assert.Equal(suite.T(), pkg.BillingDocumentTypeInvoice, rsp.Items[3].Type)
+	assert.Equal(suite.T(), "EUR", rsp.Items[3].Currency)
+
+	rsp = &intPkg.GenerateBillingDocumentsResponse{}
+	err = suite.service.GenerateBillingDocuments(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 4)
+	assert.Equal(suite.T(), act.Id, rsp.Items[2].Id)
+}
+
+func (suite *RoyaltyReportTestSuite) TestBillingDocument_GenerateBillingDocuments_PeriodInvalid_Error() {
+	month := time.Now()
+	req := &intPkg.GenerateBillingDocumentsRequest{
+		MerchantId: suite.merchant.Id,
+		Year:       int32(month.Year()),
+		Month:      int32(month.Month()),
+	}
+	rsp := &intPkg.GenerateBillingDocumentsResponse{}
+	err := suite.service.GenerateBillingDocuments(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorPeriodInvalid, rsp.Message)
+
+	req.Month = 13
+	rsp = &intPkg.GenerateBillingDocumentsResponse{}
+	err = suite.service.GenerateBillingDocuments(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorPeriodInvalid, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestBillingDocument_CreateBillingCreditNote_Ok() {
+	original := suite.helperIssueBillingDocument(pkg.BillingDocumentTypeInvoice, 100, 0.2)
+	assert.Equal(suite.T(), 20.0, original.VatAmount)
+	assert.Equal(suite.T(), 120.0, original.TotalAmount)
+
+	req := &intPkg.CreateBillingCreditNoteRequest{
+		OriginalDocumentId: original.Id.Hex(),
+		NetAmount:          30,
+		Reason:             "chargeback fees were charged twice",
+	}
+	rsp := &intPkg.BillingDocumentResponse{}
+	err := suite.service.CreateBillingCreditNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.BillingDocumentTypeCreditNote, rsp.Item.Type)
+	assert.Equal(suite.T(), "CN-000001", rsp.Item.Number)
+	assert.Equal(suite.T(), original.Id.Hex(), rsp.Item.OriginalDocumentId)
+	assert.Equal(suite.T(), original.MerchantId, rsp.Item.MerchantId)
+	assert.Equal(suite.T(), 30.0, rsp.Item.NetAmount)
+	assert.Equal(suite.T(), 6.0, rsp.Item.VatAmount)
+	assert.Equal(suite.T(), 36.0, rsp.Item.TotalAmount)
+	assert.True(suite.T(), isBillingDocumentHashValid(rsp.Item))
+
+	creditNote := rsp.Item
+
+	req.NetAmount = 70.01
+	rsp = &intPkg.BillingDocumentResponse{}
+	err = suite.service.CreateBillingCreditNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorAmountExceeded, rsp.Message)
+
+	req.NetAmount = 70
+	rsp = &intPkg.BillingDocumentResponse{}
+	err = suite.service.CreateBillingCreditNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), "CN-000002", rsp.Item.Number)
+
+	doc, err := suite.service.billingDocumentRepository.GetById(context.TODO(), original.Id.Hex())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original.Hash, doc.Hash)
+	assert.Equal(suite.T(), 100.0, doc.NetAmount)
+
+	req.OriginalDocumentId = creditNote.Id.Hex()
+	req.NetAmount = 1
+	rsp = &intPkg.BillingDocumentResponse{}
+	err = suite.service.CreateBillingCreditNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorOriginalInvalid, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestBillingDocument_CreateBillingCreditNote_BadData_Error() {
+	original := suite.helperIssueBillingDocument(pkg.BillingDocumentTypeActOfCompletion, 100, 0.2)
+
+	req := &intPkg.CreateBillingCreditNoteRequest{OriginalDocumentId: original.Id.Hex(), Reason: "correction"}
+	rsp := &intPkg.BillingDocumentResponse{}
+	err := suite.service.CreateBillingCreditNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorAmountInvalid, rsp.Message)
+
+	req.NetAmount = 10
+	req.Reason = ""
+	rsp = &intPkg.BillingDocumentResponse{}
+	err = suite.service.CreateBillingCreditNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorReasonRequired, rsp.Message)
+
+	req.OriginalDocumentId = suite.merchant.Id
+	rsp = &intPkg.BillingDocumentResponse{}
+	err = suite.service.CreateBillingCreditNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorNotFound, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestBillingDocument_CreateBillingNote_DebitNote_Ok() {
+	original := suite.helperIssueBillingDocument(pkg.BillingDocumentTypeInvoice, 100, 0.2)
+
+	req := &intPkg.CreateBillingNoteRequest{
+		Type:               pkg.BillingDocumentTypeDebitNote,
+		OriginalDocumentId: original.Id.Hex(),
+		Lines: []*intPkg.CreateBillingNoteLine{
+			{Description: "refund fees", NetAmount: 10},
+			{Description: "chargeback fees", NetAmount: 5.55},
+		},
+		Reason: "fees weren't charged",
+	}
+	rsp := &intPkg.BillingDocumentResponse{}
+	err := suite.service.CreateBillingNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.BillingDocumentTypeDebitNote, rsp.Item.Type)
+	assert.Equal(suite.T(), "DN-000001", rsp.Item.Number)
+	assert.Equal(suite.T(), pkg.BillingDocumentVatTreatmentStandard, rsp.Item.VatTreatment)
+	assert.Equal(suite.T(), original.Currency, rsp.Item.Currency)
+	assert.Len(suite.T(), rsp.Item.Lines, 2)
+	assert.Equal(suite.T(), 2.0, rsp.Item.Lines[0].VatAmount)
+	assert.Equal(suite.T(), 1.11, rsp.Item.Lines[1].VatAmount)
+	assert.Equal(suite.T(), 6.66, rsp.Item.Lines[1].TotalAmount)
+	assert.Equal(suite.T(), 15.55, rsp.Item.NetAmount)
+	assert.Equal(suite.T(), 3.11, rsp.Item.VatAmount)
+	assert.Equal(suite.T(), 18.66, rsp.Item.TotalAmount)
+	assert.Equal(suite.T(), -18.66, rsp.Item.PayoutAmount)
+	assert.True(suite.T(), isBillingDocumentHashValid(rsp.Item))
+
+	entries, err := suite.service.accountingRepository.GetCorrectionsForRoyaltyReport(
+		context.TODO(),
+		original.MerchantId,
+		original.Currency,
+		time.Now().Add(-time.Hour),
+		time.Now().Add(time.Hour),
+	)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 1)
+	assert.Equal(suite.T(), pkg.AccountingEntryTypeMerchantRoyaltyCorrection, entries[0].Type)
+	assert.Equal(suite.T(), -18.66, entries[0].Amount)
+	assert.Equal(suite.T(), "billing_note: DN-000001 fees weren't charged", entries[0].Reason)
+
+	tx, err := suite.service.merchantBalanceTransactionRepository.GetLast(
+		context.TODO(),
+		original.MerchantId,
+		original.Currency,
+		time.Now().Add(time.Hour),
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.MerchantBalanceTransactionTypeCorrection, tx.Type)
+	assert.Equal(suite.T(), -18.66, tx.Amount)
+}
+
+func (suite *RoyaltyReportTestSuite) TestBillingDocument_CreateBillingNote_RoyaltyReport_Ok() {
+	report := suite.helperInsertRoyaltyReportForBillingNote(billingpb.RoyaltyReportStatusAccepted)
+
+	req := &intPkg.CreateBillingNoteRequest{
+		Type:                    pkg.BillingDocumentTypeCreditNote,
+		OriginalRoyaltyReportId: report.Id,
+		VatTreatment:            pkg.BillingDocumentVatTreatmentReverseCharge,
+		Lines:                   []*intPkg.CreateBillingNoteLine{{Description: "duplicated fee", NetAmount: 50}},
+		Reason:                  "fee was charged twice",
+	}
+	rsp := &intPkg.BillingDocumentResponse{}
+	err := suite.service.CreateBillingNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), "CN-000001", rsp.Item.Number)
+	assert.Equal(suite.T(), report.Id, rsp.Item.OriginalRoyaltyReportId)
+	assert.Empty(suite.T(), rsp.Item.OriginalDocumentId)
+	assert.Equal(suite.T(), report.MerchantId, rsp.Item.MerchantId)
+	assert.Equal(suite.T(), report.Currency, rsp.Item.Currency)
+	assert.Equal(suite.T(), 0.0, rsp.Item.VatRate)
+	assert.Equal(suite.T(), 0.0, rsp.Item.VatAmount)
+	assert.Equal(suite.T(), 50.0, rsp.Item.TotalAmount)
+	assert.Equal(suite.T(), 50.0, rsp.Item.PayoutAmount)
+
+	report = suite.helperInsertRoyaltyReportForBillingNote(billingpb.RoyaltyReportStatusPending)
+	req.OriginalRoyaltyReportId = report.Id
+	rsp = &intPkg.BillingDocumentResponse{}
+	err = suite.service.CreateBillingNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorReportNotAccepted, rsp.Message)
+
+	req.OriginalRoyaltyReportId = suite.merchant.Id
+	rsp = &intPkg.BillingDocumentResponse{}
+	err = suite.service.CreateBillingNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorReportNotFound, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestBillingDocument_CreateBillingNote_BadData_Error() {
+	original := suite.helperIssueBillingDocument(pkg.BillingDocumentTypeInvoice, 100, 0.2)
+
+	req := &intPkg.CreateBillingNoteRequest{
+		Type:               pkg.BillingDocumentTypeInvoice,
+		OriginalDocumentId: original.Id.Hex(),
+		Reason:             "correction",
+	}
+	rsp := &intPkg.BillingDocumentResponse{}
+	err := suite.service.CreateBillingNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorNoteTypeInvalid, rsp.Message)
+
+	req.Type = pkg.BillingDocumentTypeDebitNote
+	req.OriginalRoyaltyReportId = primitive.NewObjectID().Hex()
+	rsp = &intPkg.BillingDocumentResponse{}
+	err = suite.service.CreateBillingNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorOriginalRequired, rsp.Message)
+
+	req.OriginalRoyaltyReportId = ""
+	rsp = &intPkg.BillingDocumentResponse{}
+	err = suite.service.CreateBillingNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorLinesRequired, rsp.Message)
+
+	req.Lines = []*intPkg.CreateBillingNoteLine{{NetAmount: 10}}
+	rsp = &intPkg.BillingDocumentResponse{}
+	err = suite.service.CreateBillingNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorLineInvalid, rsp.Message)
+
+	req.Lines[0].Description = "correction"
+	req.VatTreatment = "unknown"
+	rsp = &intPkg.BillingDocumentResponse{}
+	err = suite.service.CreateBillingNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorVatTreatment, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestBillingDocument_BillingNotes_IncludedInRoyaltyReport_Ok() {
+	original := suite.helperIssueBillingDocument(pkg.BillingDocumentTypeInvoice, 100, 0.2)
+
+	for _, line := range []float64{10, 5} {
+		req := &intPkg.CreateBillingNoteRequest{
+			Type:               pkg.BillingDocumentTypeCreditNote,
+			OriginalDocumentId: original.Id.Hex(),
+			Lines:              []*intPkg.CreateBillingNoteLine{{Description: "correction", NetAmount: line}},
+			Reason:             "correction",
+		}
+		rsp := &intPkg.BillingDocumentResponse{}
+		err := suite.service.CreateBillingNote(context.TODO(), req, rsp)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	}
+
+	req := &intPkg.CreateBillingNoteRequest{
+		Type:               pkg.BillingDocumentTypeDebitNote,
+		OriginalDocumentId: original.Id.Hex(),
+		Lines:              []*intPkg.CreateBillingNoteLine{{Description: "correction", NetAmount: 3}},
+		Reason:             "correction",
+	}
+	rsp := &intPkg.BillingDocumentResponse{}
+	err := suite.service.CreateBillingNote(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	report := &billingpb.RoyaltyReport{
+		MerchantId: original.MerchantId,
+		Currency:   original.Currency,
+		Totals:     &billingpb.RoyaltyReportTotals{PayoutAmount: 100},
+	}
+	err = suite.service.updateRoyaltyReportCorrections(
+		context.TODO(),
+		report,
+		time.Now().Add(-time.Hour),
+		time.Now().Add(time.Hour),
+	)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), report.Summary.Corrections, 3)
+	assert.Equal(suite.T(), 14.4, report.Totals.CorrectionAmount)
+	assert.Equal(suite.T(), 114.4, report.Totals.FinalPayoutAmount)
+}
+
+func (suite *RoyaltyReportTestSuite) TestBillingDocument_BillingDocumentPdfUploaded_Ok() {
+	document := suite.helperIssueBillingDocument(pkg.BillingDocumentTypeInvoice, 100, 0.2)
+	content := []byte("%PDF-1.4 invoice")
+
+	req := &intPkg.BillingDocumentPdfUploadedRequest{
+		DocumentId: document.Id.Hex(),
+		Filename:   "invoice.pdf",
+		Content:    content,
+	}
+	rsp := &intPkg.BillingDocumentResponse{}
+	err := suite.service.BillingDocumentPdfUploaded(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	hash := sha256.Sum256(content)
+	doc, err := suite.service.billingDocumentRepository.GetById(context.TODO(), document.Id.Hex())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "invoice.pdf", doc.FileName)
+	assert.Equal(suite.T(), content, doc.File)
+	assert.Equal(suite.T(), hex.EncodeToString(hash[:]), doc.FileHash)
+	assert.True(suite.T(), isBillingDocumentHashValid(doc))
+
+	req.Content = []byte("%PDF-1.4 changed invoice")
+	rsp = &intPkg.BillingDocumentResponse{}
+	err = suite.service.BillingDocumentPdfUploaded(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), billingDocumentErrorFileAlreadyStored, rsp.Message)
+
+	doc, err = suite.service.billingDocumentRepository.GetById(context.TODO(), document.Id.Hex())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), content, doc.File)
+
+	doc.NetAmount = 1
+	assert.False(suite.T(), isBillingDocumentHashValid(doc))
+}
+
+func (suite *RoyaltyReportTestSuite) helperIssueBillingDocument(
+	documentType string,
+	netAmount, vatRate float64,
+) *intPkg.BillingDocument {
+	from, to := getBillingDocumentPeriodDates(2020, 1)
+	document := &intPkg.BillingDocument{
+		Type:               documentType,
+		MerchantId:         suite.merchant.Id,
+		OperatingCompanyId: suite.merchant.OperatingCompanyId,
+		DateFrom:           from,
+		DateTo:             to,
+		Currency:           "USD",
+	}
+	setBillingDocumentAmounts(document, netAmount, vatRate)
+
+	err := suite.service.issueBillingDocument(context.TODO(), document)
+	assert.NoError(suite.T(), err)
+
+	return document
+}
+
+func (suite *RoyaltyReportTestSuite) helperInsertRoyaltyReportForBillingNote(status string) *billingpb.RoyaltyReport {
+	report := &billingpb.RoyaltyReport{
+		Id:                 primitive.NewObjectID().Hex(),
+		MerchantId:         suite.merchant.Id,
+		OperatingCompanyId: suite.merchant.OperatingCompanyId,
+		Currency:           suite.merchant.GetPayoutCurrency(),
+		Status:             status,
+		Totals: &billingpb.RoyaltyReportTotals{
+			TransactionsCount: 10,
+			PayoutAmount:      1000,
+			B2BVatRate:        0.2,
+			FinalPayoutAmount: 1000,
+		},
+		CreatedAt:  ptypes.TimestampNow(),
+		PeriodFrom: ptypes.TimestampNow(),
+		PeriodTo:   ptypes.TimestampNow(),
+	}
+	err := suite.service.royaltyReportRepository.Insert(context.TODO(), report, "", pkg.RoyaltyReportChangeSourceAuto)
+	assert.NoError(suite.T(), err)
+
+	return report
+}
@@ -0,0 +1,466 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/postmarkpb"
+	"github.com/paysuper/paysuper-proto/go/recurringpb"
+	stringTools "github.com/paysuper/paysuper-tools/string"
+	"github.com/streadway/amqp"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+var (
+	cardExpiryErrorUpdateLinkNotFound = errors.NewBillingServerErrorMsg("ce000001", "card update link not found")
+	cardExpiryErrorUpdateLinkExpired  = errors.NewBillingServerErrorMsg("ce000002", "card update link is expired")
+	cardExpiryErrorVerificationFailed = errors.NewBillingServerErrorMsg("ce000003", "card verification failed")
+	cardExpiryErrorUpdateFailed       = errors.NewBillingServerErrorMsg("ce000004", "unable to update card")
+	cardExpiryErrorUnknown            = errors.NewBillingServerErrorMsg("ce000005", "unknown error")
+)
+
+// ProcessExpiringCards refreshes expiration dates of the cards which expire soon using the acquirer's account updater
+// and sends to customers the links for update of the cards which weren't refreshed.
+func (s *Service) ProcessExpiringCards(ctx context.Context) error {
+	now := time.Now().UTC()
+	cards, err := s.cardExpiryRepository.FindExpiring(ctx, now, now.AddDate(0, 0, pkg.CardExpiryNotifyDays))
+
+	if err != nil {
+		return err
+	}
+
+	if len(cards) <= 0 {
+		return nil
+	}
+
+	if s.accountUpdater != nil {
+		cards, err = s.applyAccountUpdates(ctx, cards)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, card := range cards {
+		if !card.NotifiedAt.IsZero() {
+			continue
+		}
+
+		if err = s.notifyCardExpiring(ctx, card); err != nil {
+			zap.L().Error(
+				"Unable to notify customer about expiring card",
+				zap.Error(err),
+				zap.String("card_expiry_id", card.Id.Hex()),
+			)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) GetCardUpdateForm(
+	ctx context.Context,
+	req *intPkg.CardUpdateFormRequest,
+	rsp *intPkg.CardUpdateFormResponse,
+) error {
+	card, status, msg := s.getCardExpiryByUpdateToken(ctx, req.Token)
+
+	if msg != nil {
+		rsp.Status = status
+		rsp.Message = msg
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = &intPkg.CardUpdateForm{
+		MaskedPan:   card.MaskedPan,
+		ExpireMonth: card.ExpireMonth,
+		ExpireYear:  card.ExpireYear,
+		Amount:      0,
+		Currency:    card.Currency,
+	}
+
+	return nil
+}
+
+func (s *Service) ProcessCardUpdate(
+	ctx context.Context,
+	req *intPkg.CardUpdateRequest,
+	rsp *intPkg.CardUpdateResponse,
+) error {
+	card, status, msg := s.getCardExpiryByUpdateToken(ctx, req.Token)
+
+	if msg != nil {
+		rsp.Status = status
+		rsp.Message = msg
+		return nil
+	}
+
+	validator := &bankCardValidator{
+		Pan:    req.Pan,
+		Cvv:    req.Cvv,
+		Month:  req.Month,
+		Year:   req.Year,
+		Holder: req.Holder,
+	}
+
+	if err := validator.Validate(); err != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = err.(*billingpb.ResponseErrorMessage)
+		return nil
+	}
+
+	if len(req.Year) < 3 {
+		req.Year = strconv.Itoa(time.Now().UTC().Year())[:2] + req.Year
+	}
+
+	order, _, h, status, msg := s.getOrderGateway(ctx, card.OrderId)
+
+	if msg != nil {
+		rsp.Status = status
+		rsp.Message = msg
+		return nil
+	}
+
+	var (
+		subscription *recurringpb.Subscription
+		startAt      time.Time
+		err          error
+	)
+
+	if card.SubscriptionId != "" {
+		res, err := s.rep.GetSubscription(ctx, &recurringpb.GetSubscriptionRequest{Id: card.SubscriptionId})
+
+		if err != nil || res.Status != billingpb.ResponseStatusOk {
+			rsp.Status = billingpb.ResponseStatusNotFound
+			rsp.Message = recurringErrorSubscriptionNotFound
+			return nil
+		}
+
+		subscription = res.Subscription
+		startAt, err = getSubscriptionPeriodEnd(subscription)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = cardExpiryErrorUnknown
+			return nil
+		}
+	}
+
+	requisites := map[string]string{
+		billingpb.PaymentCreateFieldPan:    req.Pan,
+		billingpb.PaymentCreateFieldCvv:    req.Cvv,
+		billingpb.PaymentCreateFieldMonth:  req.Month,
+		billingpb.PaymentCreateFieldYear:   req.Year,
+		billingpb.PaymentCreateFieldHolder: req.Holder,
+	}
+	recurringId, err := h.UpdateRecurringCard(order, subscription, requisites, startAt)
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to verify card on payment system",
+			zap.Error(err),
+			zap.String("card_expiry_id", card.Id.Hex()),
+		)
+
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = cardExpiryErrorVerificationFailed
+		return nil
+	}
+
+	oldMaskedPan := card.MaskedPan
+	card.MaskedPan = stringTools.MaskBankCardNumber(req.Pan)
+	card.CardHolder = req.Holder
+	card.RecurringId = recurringId
+
+	if err = setCardExpiryDate(card, req.Month, req.Year); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = cardExpiryErrorUnknown
+		return nil
+	}
+
+	if subscription != nil {
+		subscription.MaskedPan = card.MaskedPan
+		res, err := s.rep.UpdateSubscription(ctx, subscription)
+
+		if err != nil || res.Status != billingpb.ResponseStatusOk {
+			zap.L().Error(
+				pkg.ErrorGrpcServiceCallFailed,
+				zap.Error(err),
+				zap.String(errorFieldService, recurringpb.PayOneRepositoryServiceName),
+				zap.String(errorFieldMethod, "UpdateSubscription"),
+				zap.Any(errorFieldRequest, subscription),
+			)
+
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = cardExpiryErrorUpdateFailed
+			return nil
+		}
+	} else {
+		if err = s.replaceSavedCard(ctx, card, oldMaskedPan); err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = cardExpiryErrorUpdateFailed
+			return nil
+		}
+	}
+
+	card.NotifiedAt = time.Time{}
+	card.UpdateToken = ""
+	card.UpdateTokenExpireAt = time.Time{}
+
+	if err = s.cardExpiryRepository.Update(ctx, card); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = cardExpiryErrorUpdateFailed
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// trackCardExpiry saves expiration date of the card used for the order to notify customer when the card expires.
+func (s *Service) trackCardExpiry(ctx context.Context, order *billingpb.Order, recurringId, subscriptionId string) {
+	card := &intPkg.CardExpiry{
+		CustomerId:     order.User.Id,
+		ProjectId:      order.Project.Id,
+		MerchantId:     order.Project.MerchantId,
+		OrderId:        order.Id,
+		SubscriptionId: subscriptionId,
+		RecurringId:    recurringId,
+		MaskedPan:      order.PaymentMethodTxnParams[billingpb.PaymentCreateFieldPan],
+		CardHolder:     order.PaymentMethodTxnParams[billingpb.PaymentCreateFieldHolder],
+		Email:          order.User.Email,
+		Locale:         order.User.Locale,
+		Currency:       order.ChargeCurrency,
+	}
+
+	err := setCardExpiryDate(
+		card,
+		order.PaymentRequisites[billingpb.PaymentCreateFieldMonth],
+		order.PaymentRequisites[billingpb.PaymentCreateFieldYear],
+	)
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to parse card expiration date",
+			zap.Error(err),
+			zap.String("order_id", order.Id),
+		)
+		return
+	}
+
+	if err = s.cardExpiryRepository.Upsert(ctx, card); err != nil {
+		zap.L().Error(
+			"Unable to save card expiration date",
+			zap.Error(err),
+			zap.String("order_id", order.Id),
+		)
+	}
+}
+
+func (s *Service) applyAccountUpdates(ctx context.Context, cards []*intPkg.CardExpiry) ([]*intPkg.CardExpiry, error) {
+	updates, err := s.accountUpdater.GetUpdates(cards)
+
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]*intPkg.CardExpiryUpdate, len(updates))
+
+	for _, update := range updates {
+		index[update.RecurringId] = update
+	}
+
+	var rest []*intPkg.CardExpiry
+
+	for _, card := range cards {
+		update, ok := index[card.RecurringId]
+
+		if !ok {
+			rest = append(rest, card)
+			continue
+		}
+
+		oldMaskedPan := card.MaskedPan
+
+		if update.MaskedPan != "" {
+			card.MaskedPan = update.MaskedPan
+		}
+
+		if err = setCardExpiryDate(card, update.ExpireMonth, update.ExpireYear); err != nil {
+			zap.L().Error(
+				"Account updater returned invalid expiration date",
+				zap.Error(err),
+				zap.Any("update", update),
+			)
+			rest = append(rest, card)
+			continue
+		}
+
+		// The acquirer keeps the recurring token of reissued card, so only saved card must be refreshed.
+		if card.SubscriptionId == "" {
+			if err = s.replaceSavedCard(ctx, card, oldMaskedPan); err != nil {
+				rest = append(rest, card)
+				continue
+			}
+		}
+
+		card.NotifiedAt = time.Time{}
+		card.UpdateToken = ""
+		card.UpdateTokenExpireAt = time.Time{}
+
+		if err = s.cardExpiryRepository.Update(ctx, card); err != nil {
+			return nil, err
+		}
+	}
+
+	return rest, nil
+}
+
+func (s *Service) notifyCardExpiring(ctx context.Context, card *intPkg.CardExpiry) error {
+	if card.Email == "" {
+		return nil
+	}
+
+	card.UpdateToken = uuid.New().String()
+	card.UpdateTokenExpireAt = card.ExpireAt
+	card.NotifiedAt = time.Now()
+
+	if err := s.cardExpiryRepository.Update(ctx, card); err != nil {
+		return err
+	}
+
+	payload := &postmarkpb.Payload{
+		TemplateAlias: s.cfg.EmailTemplates.CardExpiring,
+		TemplateModel: map[string]string{
+			"masked_pan":   card.MaskedPan,
+			"expire_month": card.ExpireMonth,
+			"expire_year":  card.ExpireYear,
+			"update_url":   s.cfg.GetCardUpdateUrl(card.UpdateToken),
+			"current_year": time.Now().UTC().Format("2006"),
+		},
+		To: card.Email,
+	}
+
+	return s.postmarkBroker.Publish(postmarkpb.PostmarkSenderTopicName, payload, amqp.Table{})
+}
+
+// replaceSavedCard replaces the card in the recurring repository with the refreshed one.
+func (s *Service) replaceSavedCard(ctx context.Context, card *intPkg.CardExpiry, oldMaskedPan string) error {
+	list, err := s.rep.FindSavedCards(ctx, &recurringpb.SavedCardRequest{Token: card.CustomerId})
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorGrpcServiceCallFailed,
+			zap.Error(err),
+			zap.String(errorFieldService, recurringpb.PayOneRepositoryServiceName),
+			zap.String(errorFieldMethod, "FindSavedCards"),
+			zap.String("customer_id", card.CustomerId),
+		)
+		return err
+	}
+
+	for _, savedCard := range list.SavedCards {
+		if savedCard.ProjectId != card.ProjectId || savedCard.MaskedPan != oldMaskedPan {
+			continue
+		}
+
+		res, err := s.rep.DeleteSavedCard(ctx, &recurringpb.DeleteSavedCardRequest{Id: savedCard.Id, Token: card.CustomerId})
+
+		if err != nil || res.Status != billingpb.ResponseStatusOk {
+			zap.L().Error(
+				pkg.ErrorGrpcServiceCallFailed,
+				zap.Error(err),
+				zap.String(errorFieldService, recurringpb.PayOneRepositoryServiceName),
+				zap.String(errorFieldMethod, "DeleteSavedCard"),
+				zap.String("saved_card_id", savedCard.Id),
+			)
+			return cardExpiryErrorUpdateFailed
+		}
+	}
+
+	req := &recurringpb.SavedCardRequest{
+		Token:      card.CustomerId,
+		ProjectId:  card.ProjectId,
+		MerchantId: card.MerchantId,
+		MaskedPan:  card.MaskedPan,
+		CardHolder: card.CardHolder,
+		Expire: &recurringpb.CardExpire{
+			Month: card.ExpireMonth,
+			Year:  card.ExpireYear,
+		},
+		RecurringId: card.RecurringId,
+	}
+
+	if _, err = s.rep.InsertSavedCard(ctx, req); err != nil {
+		zap.L().Error(
+			pkg.ErrorGrpcServiceCallFailed,
+			zap.Error(err),
+			zap.String(errorFieldService, recurringpb.PayOneRepositoryServiceName),
+			zap.String(errorFieldMethod, "InsertSavedCard"),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) getCardExpiryByUpdateToken(
+	ctx context.Context,
+	token string,
+) (*intPkg.CardExpiry, int32, *billingpb.ResponseErrorMessage) {
+	if token == "" {
+		return nil, billingpb.ResponseStatusNotFound, cardExpiryErrorUpdateLinkNotFound
+	}
+
+	card, err := s.cardExpiryRepository.GetByUpdateToken(ctx, token)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, billingpb.ResponseStatusNotFound, cardExpiryErrorUpdateLinkNotFound
+		}
+
+		return nil, billingpb.ResponseStatusSystemError, cardExpiryErrorUnknown
+	}
+
+	if card.UpdateTokenExpireAt.Before(time.Now()) {
+		return nil, billingpb.ResponseStatusBadData, cardExpiryErrorUpdateLinkExpired
+	}
+
+	return card, billingpb.ResponseStatusOk, nil
+}
+
+func setCardExpiryDate(card *intPkg.CardExpiry, month, year string) error {
+	m, err := strconv.Atoi(month)
+
+	if err != nil {
+		return err
+	}
+
+	y, err := strconv.Atoi(year)
+
+	if err != nil {
+		return err
+	}
+
+	if y < 100 {
+		y += time.Now().UTC().Year() / 100 * 100
+	}
+
+	if m < 1 || m > 12 {
+		return bankCardMonthIsInvalid
+	}
+
+	card.ExpireMonth = month
+	card.ExpireYear = strconv.Itoa(y)
+	card.ExpireAt = time.Date(y, time.Month(m)+1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Second)
+
+	return nil
+}
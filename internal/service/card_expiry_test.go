@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	casbinMocks "github.com/paysuper/paysuper-proto/go/casbinpb/mocks"
+	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type CardExpiryTestSuite struct {
+	suite.Suite
+	service *Service
+}
+
+func Test_CardExpiry(t *testing.T) {
+	suite.Run(t, new(CardExpiryTestSuite))
+}
+
+func (suite *CardExpiryTestSuite) SetupTest() {
+	cfg, err := config.NewConfig()
+	assert.NoError(suite.T(), err, "Config load failed")
+
+	db, err := mongodb.NewDatabase()
+	assert.NoError(suite.T(), err, "Database connection failed")
+
+	redisdb := mocks.NewTestRedis()
+	cache, err := database.NewCacheRedis(redisdb, "cache")
+
+	if err != nil {
+		suite.FailNow("Cache redis initialize failed", "%v", err)
+	}
+
+	suite.service = NewBillingService(
+		db,
+		cfg,
+		mocks.NewGeoIpServiceTestOk(),
+		mocks.NewRepositoryServiceOk(),
+		&mocks.TaxServiceOkMock{},
+		mocks.NewBrokerMockOk(),
+		nil,
+		cache,
+		mocks.NewCurrencyServiceMockOk(),
+		mocks.NewDocumentSignerMockOk(),
+		&reportingMocks.ReporterService{},
+		mocks.NewFormatterOK(),
+		mocks.NewBrokerMockOk(),
+		&casbinMocks.CasbinService{},
+		mocks.NewNotifierOk(),
+		mocks.NewBrokerMockOk(),
+	)
+
+	if err := suite.service.Init(); err != nil {
+		suite.FailNow("Billing service initialization failed", "%v", err)
+	}
+}
+
+func (suite *CardExpiryTestSuite) TearDownTest() {
+	err := suite.service.db.Drop()
+
+	if err != nil {
+		suite.FailNow("Database deletion failed", "%v", err)
+	}
+
+	err = suite.service.db.Close()
+
+	if err != nil {
+		suite.FailNow("Database close failed", "%v", err)
+	}
+}
+
+func (suite *CardExpiryTestSuite) createCard(expireAt time.Time) *intPkg.CardExpiry {
+	card := &intPkg.CardExpiry{
+		CustomerId:  primitive.NewObjectID().Hex(),
+		ProjectId:   primitive.NewObjectID().Hex(),
+		MerchantId:  primitive.NewObjectID().Hex(),
+		OrderId:     "order_id",
+		RecurringId: primitive.NewObjectID().Hex(),
+		MaskedPan:   "400000******0002",
+		CardHolder:  "UNIT TEST",
+		Email:       "test@unit.test",
+		Currency:    "USD",
+	}
+	err := setCardExpiryDate(card, strconv.Itoa(int(expireAt.Month())), strconv.Itoa(expireAt.Year()))
+	assert.NoError(suite.T(), err)
+	card.ExpireAt = expireAt
+
+	err = suite.service.cardExpiryRepository.Upsert(context.TODO(), card)
+	assert.NoError(suite.T(), err)
+
+	list, err := suite.service.cardExpiryRepository.FindExpiring(context.TODO(), time.Time{}, card.ExpireAt)
+	assert.NoError(suite.T(), err)
+
+	for _, item := range list {
+		if item.CustomerId == card.CustomerId {
+			return item
+		}
+	}
+
+	suite.FailNow("Card expiry not found")
+	return nil
+}
+
+func (suite *CardExpiryTestSuite) TestCardExpiry_ProcessExpiringCards_Notify_Ok() {
+	card := suite.createCard(time.Now().UTC().AddDate(0, 0, 10))
+	suite.createCard(time.Now().UTC().AddDate(0, 3, 0))
+
+	err := suite.service.ProcessExpiringCards(context.TODO())
+	assert.NoError(suite.T(), err)
+
+	list, err := suite.service.cardExpiryRepository.FindExpiring(context.TODO(), time.Time{}, card.ExpireAt)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), list, 1)
+	assert.False(suite.T(), list[0].NotifiedAt.IsZero())
+	assert.NotEmpty(suite.T(), list[0].UpdateToken)
+
+	token := list[0].UpdateToken
+	err = suite.service.ProcessExpiringCards(context.TODO())
+	assert.NoError(suite.T(), err)
+
+	notified, err := suite.service.cardExpiryRepository.GetByUpdateToken(context.TODO(), token)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), list[0].Id, notified.Id)
+}
+
+func (suite *CardExpiryTestSuite) TestCardExpiry_ProcessExpiringCards_AccountUpdater_Ok() {
+	card := suite.createCard(time.Now().UTC().AddDate(0, 0, 10))
+	newExpire := time.Now().UTC().AddDate(3, 0, 0)
+
+	updater := &mocks.AccountUpdaterInterface{}
+	updater.On("GetUpdates", mock.Anything).Return([]*intPkg.CardExpiryUpdate{
+		{
+			RecurringId: card.RecurringId,
+			ExpireMonth: strconv.Itoa(int(newExpire.Month())),
+			ExpireYear:  strconv.Itoa(newExpire.Year()),
+		},
+	}, nil)
+	suite.service.accountUpdater = updater
+
+	err := suite.service.ProcessExpiringCards(context.TODO())
+	assert.NoError(suite.T(), err)
+
+	list, err := suite.service.cardExpiryRepository.FindExpiring(context.TODO(), time.Time{}, newExpire.AddDate(0, 1, 0))
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), list, 1)
+	assert.Equal(suite.T(), strconv.Itoa(newExpire.Year()), list[0].ExpireYear)
+	assert.True(suite.T(), list[0].NotifiedAt.IsZero())
+	assert.Empty(suite.T(), list[0].UpdateToken)
+}
+
+func (suite *CardExpiryTestSuite) TestCardExpiry_GetCardUpdateForm_NotFound_Error() {
+	rsp := &intPkg.CardUpdateFormResponse{}
+	err := suite.service.GetCardUpdateForm(context.TODO(), &intPkg.CardUpdateFormRequest{Token: "unknown"}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), cardExpiryErrorUpdateLinkNotFound, rsp.Message)
+}
+
+func (suite *CardExpiryTestSuite) TestCardExpiry_GetCardUpdateForm_Ok() {
+	card := suite.createCard(time.Now().UTC().AddDate(0, 0, 10))
+	err := suite.service.ProcessExpiringCards(context.TODO())
+	assert.NoError(suite.T(), err)
+
+	list, err := suite.service.cardExpiryRepository.FindExpiring(context.TODO(), time.Time{}, card.ExpireAt)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), list, 1)
+
+	rsp := &intPkg.CardUpdateFormResponse{}
+	err = suite.service.GetCardUpdateForm(context.TODO(), &intPkg.CardUpdateFormRequest{Token: list[0].UpdateToken}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), card.MaskedPan, rsp.Item.MaskedPan)
+	assert.Zero(suite.T(), rsp.Item.Amount)
+}
+
+func (suite *CardExpiryTestSuite) TestCardExpiry_ProcessCardUpdate_SavedCard_Ok() {
+	card := suite.createCard(time.Now().UTC().AddDate(0, 0, 10))
+	err := suite.service.ProcessExpiringCards(context.TODO())
+	assert.NoError(suite.T(), err)
+
+	list, err := suite.service.cardExpiryRepository.FindExpiring(context.TODO(), time.Time{}, card.ExpireAt)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), list, 1)
+	token := list[0].UpdateToken
+
+	order := &billingpb.Order{
+		Id: card.OrderId,
+		PaymentMethod: &billingpb.PaymentMethodOrder{
+			PaymentSystemId: "payment_system_id",
+		},
+	}
+	orderRepository := &mocks.OrderRepositoryInterface{}
+	orderRepository.On("GetById", mock.Anything, card.OrderId).Return(order, nil)
+	suite.service.orderRepository = orderRepository
+
+	psRepository := &mocks.PaymentSystemRepositoryInterface{}
+	psRepository.On("GetById", mock.Anything, "payment_system_id").Return(&billingpb.PaymentSystem{
+		Handler: "payment_system_handler",
+	}, nil)
+	suite.service.paymentSystemRepository = psRepository
+
+	paymentSystem := &mocks.PaymentSystemInterface{}
+	paymentSystem.On("UpdateRecurringCard", order, mock.Anything, mock.Anything, mock.Anything).
+		Return("new_recurring_id", nil)
+
+	gatewayManagerMock := &mocks.PaymentSystemManagerInterface{}
+	gatewayManagerMock.On("GetGateway", "payment_system_handler").Return(paymentSystem, nil)
+	suite.service.paymentSystemGateway = gatewayManagerMock
+
+	expire := time.Now().UTC().AddDate(2, 0, 0)
+	rsp := &intPkg.CardUpdateResponse{}
+	err = suite.service.ProcessCardUpdate(context.TODO(), &intPkg.CardUpdateRequest{
+		Token:  token,
+		Pan:    "5555555555554444",
+		Cvv:    "123",
+		Month:  strconv.Itoa(int(expire.Month())),
+		Year:   strconv.Itoa(expire.Year()),
+		Holder: "UNIT TEST",
+	}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	_, err = suite.service.cardExpiryRepository.GetByUpdateToken(context.TODO(), token)
+	assert.Error(suite.T(), err)
+
+	list, err = suite.service.cardExpiryRepository.FindExpiring(context.TODO(), time.Time{}, expire.AddDate(0, 1, 0))
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), list, 1)
+	assert.Equal(suite.T(), "new_recurring_id", list[0].RecurringId)
+	assert.Equal(suite.T(), "555555******4444", list[0].MaskedPan)
+}
+
+func (suite *CardExpiryTestSuite) TestCardExpiry_setCardExpiryDate() {
+	card := &intPkg.CardExpiry{}
+
+	err := setCardExpiryDate(card, "02", "24")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "2024", card.ExpireYear)
+	assert.Equal(suite.T(), time.Date(2024, time.February, 29, 23, 59, 59, 0, time.UTC), card.ExpireAt)
+
+	err = setCardExpiryDate(card, "13", "2024")
+	assert.Equal(suite.T(), bankCardMonthIsInvalid, err)
+}
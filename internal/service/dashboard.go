@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg/errors"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -148,3 +149,47 @@ func (s *Service) GetDashboardCustomerArpu(ctx context.Context, req *billingpb.D
 
 	return nil
 }
+
+func (s *Service) GetDashboardSubscriptionsReport(
+	ctx context.Context,
+	req *intPkg.DashboardSubscriptionsReportRequest,
+	rsp *intPkg.DashboardSubscriptionsReportResponse,
+) error {
+	_, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+
+		if err != mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusSystemError
+		}
+
+		return nil
+	}
+
+	if req.ProjectId != "" {
+		project, err := s.project.GetById(ctx, req.ProjectId)
+
+		if err != nil || project.MerchantId != req.MerchantId {
+			rsp.Status = billingpb.ResponseStatusNotFound
+			rsp.Message = projectErrorNotFound
+
+			return nil
+		}
+	}
+
+	report, err := s.dashboardRepository.GetSubscriptionsReport(ctx, req.MerchantId, req.ProjectId, req.Period)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = dashboardErrorUnknown
+
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = report
+
+	return nil
+}
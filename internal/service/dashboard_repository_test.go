@@ -69,6 +69,7 @@ import (
 	"github.com/paysuper/paysuper-billing-server/internal/config"
 	"github.com/paysuper/paysuper-billing-server/internal/database"
 	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	casbinMocks "github.com/paysuper/paysuper-proto/go/casbinpb/mocks"
@@ -76,6 +77,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
 	"math"
@@ -728,3 +730,45 @@ func (suite *DashboardRepositoryTestSuite) Test_GetDashboardCustomerReport_Empty
 	shouldBe.NotNil(report.Chart)
 	shouldBe.NotEmpty(report.Chart)
 }
+
+func (suite *DashboardRepositoryTestSuite) Test_GetDashboardSubscriptionsReport_EmptyResult_Ok() {
+	req := &intPkg.DashboardSubscriptionsReportRequest{
+		MerchantId: suite.project.MerchantId,
+		ProjectId:  suite.project.Id,
+		Period:     pkg.DashboardPeriodCurrentMonth,
+	}
+	rsp := &intPkg.DashboardSubscriptionsReportResponse{}
+	err := suite.service.GetDashboardSubscriptionsReport(ctx, req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.NotNil(suite.T(), rsp.Item)
+	assert.Zero(suite.T(), rsp.Item.Mrr)
+	assert.Zero(suite.T(), rsp.Item.ActiveSubscribers)
+	assert.Zero(suite.T(), rsp.Item.RenewalAttempts)
+}
+
+func (suite *DashboardRepositoryTestSuite) Test_GetDashboardSubscriptionsReport_ProjectNotFound_Error() {
+	req := &intPkg.DashboardSubscriptionsReportRequest{
+		MerchantId: suite.project.MerchantId,
+		ProjectId:  primitive.NewObjectID().Hex(),
+		Period:     pkg.DashboardPeriodCurrentMonth,
+	}
+	rsp := &intPkg.DashboardSubscriptionsReportResponse{}
+	err := suite.service.GetDashboardSubscriptionsReport(ctx, req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), projectErrorNotFound, rsp.Message)
+	assert.Nil(suite.T(), rsp.Item)
+}
+
+func (suite *DashboardRepositoryTestSuite) Test_GetDashboardSubscriptionsReport_UnknownPeriod_Error() {
+	req := &intPkg.DashboardSubscriptionsReportRequest{
+		MerchantId: suite.project.MerchantId,
+		Period:     "unknown",
+	}
+	rsp := &intPkg.DashboardSubscriptionsReportResponse{}
+	err := suite.service.GetDashboardSubscriptionsReport(ctx, req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusSystemError, rsp.Status)
+	assert.Equal(suite.T(), dashboardErrorUnknown, rsp.Message)
+}
@@ -0,0 +1,423 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/jinzhu/now"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/currenciespb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	fxRevaluationEventType  = "fx_revaluation"
+	fxRevaluationSourceType = "fx_revaluation"
+
+	fxRevaluationEntryTypeUnrealized = "fx_unrealized_revaluation"
+	fxRevaluationEntryTypeRealized   = "fx_realized_revaluation"
+)
+
+var (
+	fxRevaluationErrorDatesInvalid = errors.NewBillingServerErrorMsg("fx000001", "currency revaluation report dates are invalid")
+	fxRevaluationErrorUnknown      = errors.NewBillingServerErrorMsg("fx000002", "unknown error. try request later")
+
+	// Types of merchant balance transactions which make up the position and sign of their amount in the position
+	fxRevaluationPositionTransactions = map[string]*fxRevaluationPosition{
+		pkg.FxPositionTypeMerchantBalance: {sign: 1},
+		pkg.FxPositionTypeRollingReserve: {
+			sign: -1,
+			types: []string{
+				pkg.MerchantBalanceTransactionTypeReserveHold,
+				pkg.MerchantBalanceTransactionTypeReserveRelease,
+			},
+		},
+	}
+
+	// Transactions which take funds out of the position at the carrying rate
+	fxRevaluationCarryingRateTransactions = map[string]bool{
+		pkg.MerchantBalanceTransactionTypePayout:         true,
+		pkg.MerchantBalanceTransactionTypePayoutReversal: true,
+	}
+)
+
+type fxRevaluationPosition struct {
+	sign  float64
+	types []string
+}
+
+// GetFxRevaluationReport returns realized and unrealized currency exchange gains of the operating company by dates.
+func (s *Service) GetFxRevaluationReport(
+	ctx context.Context,
+	req *intPkg.FxRevaluationReportRequest,
+	rsp *intPkg.FxRevaluationReportResponse,
+) error {
+	if req.DateFrom <= 0 || req.DateTo < req.DateFrom {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = fxRevaluationErrorDatesInvalid
+		return nil
+	}
+
+	items, err := s.fxRevaluationRepository.GetReport(
+		ctx,
+		req.OperatingCompanyId,
+		time.Unix(req.DateFrom, 0),
+		time.Unix(req.DateTo, 0),
+	)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = fxRevaluationErrorUnknown
+		return nil
+	}
+
+	report := &intPkg.FxRevaluationReport{
+		OperatingCompanyId: req.OperatingCompanyId,
+		FunctionalCurrency: s.cfg.AccountingFunctionalCurrency,
+		Items:              items,
+	}
+
+	for _, item := range items {
+		item.Amount = tools.FormatAmount(item.Amount)
+		item.GainAmount = tools.FormatAmount(item.GainAmount)
+
+		switch item.Type {
+		case pkg.FxRevaluationTypeUnrealized:
+			report.UnrealizedAmount += item.GainAmount
+			break
+
+		case pkg.FxRevaluationTypeRealized:
+			report.RealizedAmount += item.GainAmount
+			break
+		}
+	}
+
+	report.UnrealizedAmount = tools.FormatAmount(report.UnrealizedAmount)
+	report.RealizedAmount = tools.FormatAmount(report.RealizedAmount)
+	report.TotalAmount = tools.FormatAmount(report.UnrealizedAmount + report.RealizedAmount)
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = report
+
+	return nil
+}
+
+// RevalueFxPositions revaluates merchants balances and rolling reserves in foreign currencies to the functional
+// currency at the closing rate of the previous month and posts unrealized currency exchange gains to the ledger.
+func (s *Service) RevalueFxPositions(ctx context.Context, date time.Time) error {
+	closingDate := now.New(date).BeginningOfMonth().Add(-time.Millisecond)
+	positions, err := s.merchantBalanceTransactionRepository.GetMerchantCurrencies(ctx, closingDate)
+
+	if err != nil {
+		return err
+	}
+
+	merchants := make(map[string]*billingpb.Merchant)
+	rates := make(map[string]float64)
+
+	for _, position := range positions {
+		if position.Currency == s.cfg.AccountingFunctionalCurrency {
+			continue
+		}
+
+		merchant, ok := merchants[position.MerchantId]
+
+		if !ok {
+			merchant, err = s.merchantRepository.GetById(ctx, position.MerchantId)
+
+			if err != nil {
+				zap.L().Error(
+					"Merchant of revaluated position not found",
+					zap.Error(err),
+					zap.String("merchant_id", position.MerchantId),
+				)
+				return err
+			}
+
+			merchants[position.MerchantId] = merchant
+		}
+
+		if merchant.OperatingCompanyId == "" {
+			continue
+		}
+
+		for _, positionType := range []string{pkg.FxPositionTypeMerchantBalance, pkg.FxPositionTypeRollingReserve} {
+			err = s.revalueFxPosition(ctx, merchant, position.Currency, positionType, closingDate, rates)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	zap.L().Info("currency revaluation finished", zap.Time("date", closingDate))
+
+	return nil
+}
+
+func (s *Service) revalueFxPosition(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+	currency, positionType string,
+	date time.Time,
+	rates map[string]float64,
+) error {
+	prev, err := s.fxRevaluationRepository.GetLast(ctx, merchant.Id, currency, positionType, date)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	var from time.Time
+	rev := &intPkg.FxRevaluation{
+		OperatingCompanyId: merchant.OperatingCompanyId,
+		MerchantId:         merchant.Id,
+		Currency:           currency,
+		FunctionalCurrency: s.cfg.AccountingFunctionalCurrency,
+		Type:               pkg.FxRevaluationTypeUnrealized,
+		PositionType:       positionType,
+		Date:               date,
+	}
+
+	if prev != nil {
+		if !prev.Date.Before(date) {
+			return nil
+		}
+
+		from = prev.Date.Add(time.Millisecond)
+		rev.Amount = prev.Amount
+		rev.CarryingAmount = prev.FunctionalAmount
+	}
+
+	position := fxRevaluationPositionTransactions[positionType]
+	txs, err := s.merchantBalanceTransactionRepository.Find(ctx, merchant.Id, currency, position.types, from, date, 0, 0)
+
+	if err != nil {
+		return err
+	}
+
+	if prev == nil && len(txs) == 0 {
+		return nil
+	}
+
+	for _, tx := range txs {
+		amount := position.sign * tx.Amount
+
+		if fxRevaluationCarryingRateTransactions[tx.Type] && prev != nil {
+			rev.CarryingAmount += amount * prev.Rate
+		} else {
+			rate, err := s.getFxRevaluationRate(ctx, currency, tx.CreatedAt, rates)
+
+			if err != nil {
+				return err
+			}
+
+			rev.CarryingAmount += amount * rate
+		}
+
+		rev.Amount += amount
+	}
+
+	rev.Rate, err = s.getFxRevaluationRate(ctx, currency, date, rates)
+
+	if err != nil {
+		return err
+	}
+
+	rev.Amount = tools.FormatAmount(rev.Amount)
+	rev.CarryingAmount = tools.FormatAmount(rev.CarryingAmount)
+	rev.FunctionalAmount = tools.FormatAmount(rev.Amount * rev.Rate)
+	rev.GainAmount = tools.FormatAmount(rev.CarryingAmount - rev.FunctionalAmount)
+
+	return s.postFxRevaluation(ctx, rev, pkg.LedgerAccountFxUnrealized, fxRevaluationEntryTypeUnrealized)
+}
+
+// realizeFxRevaluation posts the realized currency exchange gain of the paid payout, which is the difference between
+// the payout amount at the carrying rate of the merchant's balance and at the rate of the settlement date.
+func (s *Service) realizeFxRevaluation(ctx context.Context, pd *billingpb.PayoutDocument) error {
+	if pd.Currency == s.cfg.AccountingFunctionalCurrency || pd.TotalFees == 0 {
+		return nil
+	}
+
+	_, err := s.fxRevaluationRepository.GetBySource(ctx, pd.Id)
+
+	if err == nil {
+		return nil
+	}
+
+	if err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	createdAt, err := ptypes.Timestamp(pd.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	paidAt := time.Now()
+
+	if pd.PaidAt != nil {
+		paidAt, err = ptypes.Timestamp(pd.PaidAt)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	rates := make(map[string]float64)
+	prev, err := s.fxRevaluationRepository.GetLast(ctx, pd.MerchantId, pd.Currency, pkg.FxPositionTypeMerchantBalance, createdAt)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	var carryingRate float64
+
+	if prev != nil {
+		carryingRate = prev.Rate
+	} else {
+		carryingRate, err = s.getFxRevaluationRate(ctx, pd.Currency, createdAt, rates)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	rev := &intPkg.FxRevaluation{
+		OperatingCompanyId: pd.OperatingCompanyId,
+		MerchantId:         pd.MerchantId,
+		Currency:           pd.Currency,
+		FunctionalCurrency: s.cfg.AccountingFunctionalCurrency,
+		Type:               pkg.FxRevaluationTypeRealized,
+		PositionType:       pkg.FxPositionTypeMerchantBalance,
+		SourceId:           pd.Id,
+		Amount:             pd.TotalFees,
+		Date:               paidAt,
+	}
+
+	rev.Rate, err = s.getFxRevaluationRate(ctx, pd.Currency, paidAt, rates)
+
+	if err != nil {
+		return err
+	}
+
+	rev.CarryingAmount = tools.FormatAmount(rev.Amount * carryingRate)
+	rev.FunctionalAmount = tools.FormatAmount(rev.Amount * rev.Rate)
+	rev.GainAmount = tools.FormatAmount(rev.CarryingAmount - rev.FunctionalAmount)
+
+	return s.postFxRevaluation(ctx, rev, pkg.LedgerAccountFxRealized, fxRevaluationEntryTypeRealized)
+}
+
+// postFxRevaluation posts the gain of the revaluation to the ledger against the revaluation account
+// of merchants liabilities and saves the revaluation.
+func (s *Service) postFxRevaluation(
+	ctx context.Context,
+	rev *intPkg.FxRevaluation,
+	gainAccountCode, entryType string,
+) error {
+	rev.Id = primitive.NewObjectID()
+
+	if rev.GainAmount != 0 {
+		debit, credit := pkg.LedgerAccountFxRevaluation, gainAccountCode
+		amount := rev.GainAmount
+
+		if amount < 0 {
+			debit, credit = credit, debit
+			amount = -amount
+		}
+
+		journal := &intPkg.LedgerJournal{
+			OperatingCompanyId: rev.OperatingCompanyId,
+			MerchantId:         rev.MerchantId,
+			EventType:          fxRevaluationEventType,
+			SourceId:           rev.Id.Hex(),
+			SourceType:         fxRevaluationSourceType,
+			Lines: []*intPkg.LedgerJournalLine{
+				{
+					AccountCode: debit,
+					EntryId:     rev.Id.Hex(),
+					EntryType:   entryType,
+					Currency:    rev.FunctionalCurrency,
+					Debit:       amount,
+				},
+				{
+					AccountCode: credit,
+					EntryId:     rev.Id.Hex(),
+					EntryType:   entryType,
+					Currency:    rev.FunctionalCurrency,
+					Credit:      amount,
+				},
+			},
+			Date: rev.Date,
+		}
+
+		accounts, err := s.getLedgerAccountsMap(ctx, rev.OperatingCompanyId)
+
+		if err != nil {
+			return err
+		}
+
+		if err = validateLedgerJournal(journal, accounts); err != nil {
+			zap.L().Error(
+				"Ledger journal rejected",
+				zap.Error(err),
+				zap.Any("journal", journal),
+			)
+			return err
+		}
+
+		if err = s.ledgerJournalRepository.Insert(ctx, journal); err != nil {
+			return err
+		}
+
+		rev.JournalId = journal.Id.Hex()
+	}
+
+	return s.fxRevaluationRepository.Insert(ctx, rev)
+}
+
+// getFxRevaluationRate returns the rate of the currency to the functional currency on the day of the date,
+// rates are cached by currency and day.
+func (s *Service) getFxRevaluationRate(
+	ctx context.Context,
+	currency string,
+	date time.Time,
+	rates map[string]float64,
+) (float64, error) {
+	key := currency + date.Format("2006-01-02")
+
+	if rate, ok := rates[key]; ok {
+		return rate, nil
+	}
+
+	datetime, err := ptypes.TimestampProto(date)
+
+	if err != nil {
+		return 0, err
+	}
+
+	req := &currenciespb.ExchangeCurrencyByDateCommonRequest{
+		From:              currency,
+		To:                s.cfg.AccountingFunctionalCurrency,
+		RateType:          currenciespb.RateTypeOxr,
+		ExchangeDirection: currenciespb.ExchangeDirectionSell,
+		Amount:            1,
+		Datetime:          datetime,
+	}
+	rate, err := s.exchangeCurrencyByDateCommon(ctx, req)
+
+	if err != nil {
+		return 0, err
+	}
+
+	rates[key] = rate
+
+	return rate, nil
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/jinzhu/now"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	casbinMocks "github.com/paysuper/paysuper-proto/go/casbinpb/mocks"
+	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"testing"
+	"time"
+)
+
+type FxRevaluationTestSuite struct {
+	suite.Suite
+	service            *Service
+	merchant           *billingpb.Merchant
+	operatingCompanyId string
+	closingDate        time.Time
+}
+
+func Test_FxRevaluation(t *testing.T) {
+	suite.Run(t, new(FxRevaluationTestSuite))
+}
+
+func (suite *FxRevaluationTestSuite) SetupTest() {
+	cfg, err := config.NewConfig()
+	assert.NoError(suite.T(), err, "Config load failed")
+
+	cfg.AccountingFunctionalCurrency = "EUR"
+
+	db, err := mongodb.NewDatabase()
+	assert.NoError(suite.T(), err, "Database connection failed")
+
+	redisdb := mocks.NewTestRedis()
+	cache, err := database.NewCacheRedis(redisdb, "cache")
+
+	if err != nil {
+		suite.FailNow("Cache redis initialize failed", "%v", err)
+	}
+
+	suite.service = NewBillingService(
+		db,
+		cfg,
+		mocks.NewGeoIpServiceTestOk(),
+		mocks.NewRepositoryServiceOk(),
+		&mocks.TaxServiceOkMock{},
+		mocks.NewBrokerMockOk(),
+		nil,
+		cache,
+		mocks.NewCurrencyServiceMockOk(),
+		mocks.NewDocumentSignerMockOk(),
+		&reportingMocks.ReporterService{},
+		mocks.NewFormatterOK(),
+		mocks.NewBrokerMockOk(),
+		&casbinMocks.CasbinService{},
+		mocks.NewNotifierOk(),
+		mocks.NewBrokerMockOk(),
+	)
+
+	if err := suite.service.Init(); err != nil {
+		suite.FailNow("Billing service initialization failed", "%v", err)
+	}
+
+	operatingCompany := HelperOperatingCompany(suite.Suite, suite.service)
+	suite.operatingCompanyId = operatingCompany.Id
+	suite.merchant = HelperCreateMerchant(suite.Suite, suite.service, "USD", "RU", nil, 0, operatingCompany.Id)
+	suite.closingDate = now.BeginningOfMonth().Add(-time.Millisecond)
+}
+
+func (suite *FxRevaluationTestSuite) TearDownTest() {
+	err := suite.service.db.Drop()
+
+	if err != nil {
+		suite.FailNow("Database deletion failed", "%v", err)
+	}
+
+	err = suite.service.db.Close()
+
+	if err != nil {
+		suite.FailNow("Database close failed", "%v", err)
+	}
+}
+
+func (suite *FxRevaluationTestSuite) addTransaction(txType, currency string, amount float64, createdAt time.Time) {
+	tx := &intPkg.MerchantBalanceTransaction{
+		MerchantId: suite.merchant.Id,
+		Currency:   currency,
+		Type:       txType,
+		Amount:     amount,
+		SourceId:   primitive.NewObjectID().Hex(),
+		SourceType: "order",
+		CreatedAt:  createdAt,
+	}
+
+	err := suite.service.addMerchantBalanceTransaction(context.TODO(), tx)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *FxRevaluationTestSuite) addRevaluation(amount, rate float64, date time.Time) {
+	rev := &intPkg.FxRevaluation{
+		OperatingCompanyId: suite.operatingCompanyId,
+		MerchantId:         suite.merchant.Id,
+		Currency:           "USD",
+		FunctionalCurrency: "EUR",
+		Type:               pkg.FxRevaluationTypeUnrealized,
+		PositionType:       pkg.FxPositionTypeMerchantBalance,
+		Amount:             amount,
+		Rate:               rate,
+		CarryingAmount:     amount * rate,
+		FunctionalAmount:   amount * rate,
+		Date:               date,
+	}
+
+	err := suite.service.fxRevaluationRepository.Insert(context.TODO(), rev)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *FxRevaluationTestSuite) getRate(date time.Time) float64 {
+	rate, err := suite.service.getFxRevaluationRate(context.TODO(), "USD", date, make(map[string]float64))
+	assert.NoError(suite.T(), err)
+
+	return rate
+}
+
+func (suite *FxRevaluationTestSuite) getTrialBalanceItem(accountCode string, from, to time.Time) *intPkg.TrialBalanceItem {
+	req := &intPkg.TrialBalanceRequest{
+		OperatingCompanyId: suite.operatingCompanyId,
+		DateFrom:           from.Unix(),
+		DateTo:             to.Unix(),
+	}
+	rsp := &intPkg.TrialBalanceResponse{}
+	err := suite.service.GetTrialBalance(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	for _, item := range rsp.Items {
+		if item.AccountCode == accountCode {
+			return item
+		}
+	}
+
+	return nil
+}
+
+func (suite *FxRevaluationTestSuite) TestFxRevaluation_RevalueFxPositions_Ok() {
+	suite.addRevaluation(50, 1, suite.closingDate.AddDate(0, 0, -20))
+	suite.addTransaction(pkg.MerchantBalanceTransactionTypePaymentNet, "USD", 150, suite.closingDate.AddDate(0, 0, -25))
+	suite.addTransaction(pkg.MerchantBalanceTransactionTypePaymentNet, "USD", 100, suite.closingDate.AddDate(0, 0, -10))
+
+	err := suite.service.RevalueFxPositions(context.TODO(), time.Now())
+	assert.NoError(suite.T(), err)
+
+	rate := suite.getRate(suite.closingDate)
+	rev, err := suite.service.fxRevaluationRepository.GetLast(
+		context.TODO(),
+		suite.merchant.Id,
+		"USD",
+		pkg.FxPositionTypeMerchantBalance,
+		suite.closingDate,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.closingDate.Unix(), rev.Date.Unix())
+	assert.Equal(suite.T(), suite.operatingCompanyId, rev.OperatingCompanyId)
+	assert.Equal(suite.T(), "EUR", rev.FunctionalCurrency)
+	assert.EqualValues(suite.T(), 150, rev.Amount)
+	assert.Equal(suite.T(), rate, rev.Rate)
+	assert.Equal(suite.T(), tools.FormatAmount(50+100*rate), rev.CarryingAmount)
+	assert.Equal(suite.T(), tools.FormatAmount(150*rate), rev.FunctionalAmount)
+	assert.Equal(suite.T(), tools.FormatAmount(rev.CarryingAmount-rev.FunctionalAmount), rev.GainAmount)
+	assert.True(suite.T(), rev.GainAmount > 0)
+	assert.NotEmpty(suite.T(), rev.JournalId)
+
+	from, to := suite.closingDate.Add(-time.Hour), suite.closingDate.Add(time.Second)
+	item := suite.getTrialBalanceItem(pkg.LedgerAccountFxUnrealized, from, to)
+	assert.NotNil(suite.T(), item)
+	assert.Equal(suite.T(), "EUR", item.Currency)
+	assert.Equal(suite.T(), rev.GainAmount, item.Credit)
+
+	item = suite.getTrialBalanceItem(pkg.LedgerAccountFxRevaluation, from, to)
+	assert.NotNil(suite.T(), item)
+	assert.Equal(suite.T(), rev.GainAmount, item.Debit)
+
+	_, err = suite.service.fxRevaluationRepository.GetLast(
+		context.TODO(),
+		suite.merchant.Id,
+		"USD",
+		pkg.FxPositionTypeRollingReserve,
+		suite.closingDate,
+	)
+	assert.Equal(suite.T(), mongo.ErrNoDocuments, err)
+}
+
+func (suite *FxRevaluationTestSuite) TestFxRevaluation_RevalueFxPositions_RunTwice_Ok() {
+	suite.addRevaluation(50, 1, suite.closingDate.AddDate(0, 0, -20))
+	suite.addTransaction(pkg.MerchantBalanceTransactionTypePaymentNet, "USD", 100, suite.closingDate.AddDate(0, 0, -10))
+
+	err := suite.service.RevalueFxPositions(context.TODO(), time.Now())
+	assert.NoError(suite.T(), err)
+
+	err = suite.service.RevalueFxPositions(context.TODO(), time.Now())
+	assert.NoError(suite.T(), err)
+
+	items, err := suite.service.fxRevaluationRepository.GetReport(
+		context.TODO(),
+		suite.operatingCompanyId,
+		suite.closingDate.Add(-time.Hour),
+		suite.closingDate,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), items, 1)
+	assert.EqualValues(suite.T(), 1, items[0].Count)
+}
+
+func (suite *FxRevaluationTestSuite) TestFxRevaluation_RevalueFxPositions_RollingReserve_Ok() {
+	suite.addTransaction(pkg.MerchantBalanceTransactionTypePaymentNet, "USD", 100, suite.closingDate.AddDate(0, 0, -10))
+	suite.addTransaction(pkg.MerchantBalanceTransactionTypeReserveHold, "USD", -10, suite.closingDate.AddDate(0, 0, -10))
+
+	err := suite.service.RevalueFxPositions(context.TODO(), time.Now())
+	assert.NoError(suite.T(), err)
+
+	rev, err := suite.service.fxRevaluationRepository.GetLast(
+		context.TODO(),
+		suite.merchant.Id,
+		"USD",
+		pkg.FxPositionTypeRollingReserve,
+		suite.closingDate,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 10, rev.Amount)
+
+	rev, err = suite.service.fxRevaluationRepository.GetLast(
+		context.TODO(),
+		suite.merchant.Id,
+		"USD",
+		pkg.FxPositionTypeMerchantBalance,
+		suite.closingDate,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 90, rev.Amount)
+}
+
+func (suite *FxRevaluationTestSuite) TestFxRevaluation_RevalueFxPositions_FunctionalCurrency_Skipped() {
+	suite.addTransaction(pkg.MerchantBalanceTransactionTypePaymentNet, "EUR", 100, suite.closingDate.AddDate(0, 0, -10))
+
+	err := suite.service.RevalueFxPositions(context.TODO(), time.Now())
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.service.fxRevaluationRepository.GetLast(
+		context.TODO(),
+		suite.merchant.Id,
+		"EUR",
+		pkg.FxPositionTypeMerchantBalance,
+		suite.closingDate,
+	)
+	assert.Equal(suite.T(), mongo.ErrNoDocuments, err)
+}
+
+func (suite *FxRevaluationTestSuite) TestFxRevaluation_RealizeFxRevaluation_Ok() {
+	suite.addRevaluation(200, 1, time.Now().AddDate(0, 0, -5))
+
+	pd := &billingpb.PayoutDocument{
+		Id:                 primitive.NewObjectID().Hex(),
+		OperatingCompanyId: suite.operatingCompanyId,
+		MerchantId:         suite.merchant.Id,
+		Currency:           "USD",
+		TotalFees:          100,
+		CreatedAt:          ptypes.TimestampNow(),
+		PaidAt:             ptypes.TimestampNow(),
+	}
+
+	err := suite.service.realizeFxRevaluation(context.TODO(), pd)
+	assert.NoError(suite.T(), err)
+
+	rate := suite.getRate(time.Now())
+	rev, err := suite.service.fxRevaluationRepository.GetBySource(context.TODO(), pd.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.FxRevaluationTypeRealized, rev.Type)
+	assert.EqualValues(suite.T(), 100, rev.CarryingAmount)
+	assert.Equal(suite.T(), tools.FormatAmount(100*rate), rev.FunctionalAmount)
+	assert.Equal(suite.T(), tools.FormatAmount(100-100*rate), rev.GainAmount)
+	assert.NotEmpty(suite.T(), rev.JournalId)
+
+	item := suite.getTrialBalanceItem(pkg.LedgerAccountFxRealized, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	assert.NotNil(suite.T(), item)
+	assert.Equal(suite.T(), rev.GainAmount, item.Credit)
+
+	err = suite.service.realizeFxRevaluation(context.TODO(), pd)
+	assert.NoError(suite.T(), err)
+
+	item = suite.getTrialBalanceItem(pkg.LedgerAccountFxRealized, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	assert.Equal(suite.T(), rev.GainAmount, item.Credit)
+}
+
+func (suite *FxRevaluationTestSuite) TestFxRevaluation_RealizeFxRevaluation_FunctionalCurrency_Skipped() {
+	pd := &billingpb.PayoutDocument{
+		Id:                 primitive.NewObjectID().Hex(),
+		OperatingCompanyId: suite.operatingCompanyId,
+		MerchantId:         suite.merchant.Id,
+		Currency:           "EUR",
+		TotalFees:          100,
+		CreatedAt:          ptypes.TimestampNow(),
+		PaidAt:             ptypes.TimestampNow(),
+	}
+
+	err := suite.service.realizeFxRevaluation(context.TODO(), pd)
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.service.fxRevaluationRepository.GetBySource(context.TODO(), pd.Id)
+	assert.Equal(suite.T(), mongo.ErrNoDocuments, err)
+}
+
+func (suite *FxRevaluationTestSuite) TestFxRevaluation_GetFxRevaluationReport_Ok() {
+	suite.addRevaluation(50, 1, suite.closingDate.AddDate(0, 0, -20))
+	suite.addTransaction(pkg.MerchantBalanceTransactionTypePaymentNet, "USD", 100, suite.closingDate.AddDate(0, 0, -10))
+
+	err := suite.service.RevalueFxPositions(context.TODO(), time.Now())
+	assert.NoError(suite.T(), err)
+
+	pd := &billingpb.PayoutDocument{
+		Id:                 primitive.NewObjectID().Hex(),
+		OperatingCompanyId: suite.operatingCompanyId,
+		MerchantId:         suite.merchant.Id,
+		Currency:           "USD",
+		TotalFees:          100,
+		CreatedAt:          ptypes.TimestampNow(),
+		PaidAt:             ptypes.TimestampNow(),
+	}
+	err = suite.service.realizeFxRevaluation(context.TODO(), pd)
+	assert.NoError(suite.T(), err)
+
+	req := &intPkg.FxRevaluationReportRequest{
+		OperatingCompanyId: suite.operatingCompanyId,
+		DateFrom:           suite.closingDate.Add(-time.Hour).Unix(),
+		DateTo:             time.Now().Add(time.Hour).Unix(),
+	}
+	rsp := &intPkg.FxRevaluationReportResponse{}
+	err = suite.service.GetFxRevaluationReport(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), "EUR", rsp.Item.FunctionalCurrency)
+	assert.Len(suite.T(), rsp.Item.Items, 2)
+	assert.NotZero(suite.T(), rsp.Item.UnrealizedAmount)
+	assert.Equal(suite.T(), tools.FormatAmount(rsp.Item.UnrealizedAmount+rsp.Item.RealizedAmount), rsp.Item.TotalAmount)
+}
+
+func (suite *FxRevaluationTestSuite) TestFxRevaluation_GetFxRevaluationReport_DatesInvalid_Error() {
+	req := &intPkg.FxRevaluationReportRequest{
+		OperatingCompanyId: suite.operatingCompanyId,
+		DateFrom:           time.Now().Unix(),
+		DateTo:             time.Now().Add(-time.Hour).Unix(),
+	}
+	rsp := &intPkg.FxRevaluationReportResponse{}
+	err := suite.service.GetFxRevaluationReport(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), fxRevaluationErrorDatesInvalid, rsp.Message)
+}
@@ -0,0 +1,655 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.uber.org/zap"
+	"math"
+	"time"
+)
+
+const (
+	// Maximal difference between debit and credit of the journal caused by float calculations
+	ledgerBalanceTolerance = 0.000001
+)
+
+var (
+	ledgerErrorAccountNotFound     = errors.NewBillingServerErrorMsg("gl000001", "ledger account not found in chart of accounts of operating company")
+	ledgerErrorAccountTypeInvalid  = errors.NewBillingServerErrorMsg("gl000002", "ledger account type is invalid")
+	ledgerErrorAccountCodeRequired = errors.NewBillingServerErrorMsg("gl000003", "ledger account code and name are required")
+	ledgerErrorJournalUnbalanced   = errors.NewBillingServerErrorMsg("gl000004", "ledger journal is unbalanced")
+	ledgerErrorPostingRuleNotFound = errors.NewBillingServerErrorMsg("gl000005", "posting rule for accounting entry not found")
+	ledgerErrorDatesInvalid        = errors.NewBillingServerErrorMsg("gl000006", "ledger report dates are invalid")
+	ledgerErrorCurrencyRequired    = errors.NewBillingServerErrorMsg("gl000007", "currency is required for account statement")
+	ledgerErrorUnknown             = errors.NewBillingServerErrorMsg("gl000008", "unknown error. try request later")
+
+	ledgerAccountTypes = map[string]bool{
+		pkg.LedgerAccountTypeAsset:     true,
+		pkg.LedgerAccountTypeLiability: true,
+		pkg.LedgerAccountTypeEquity:    true,
+		pkg.LedgerAccountTypeRevenue:   true,
+		pkg.LedgerAccountTypeExpense:   true,
+	}
+
+	// Chart of accounts used by operating companies which haven't own chart of accounts
+	defaultLedgerAccounts = []*intPkg.LedgerAccount{
+		{Code: pkg.LedgerAccountAcquirerReceivable, Name: "Receivables from acquirers", Type: pkg.LedgerAccountTypeAsset},
+		{Code: pkg.LedgerAccountMerchantPayable, Name: "Payables to merchants", Type: pkg.LedgerAccountTypeLiability},
+		{Code: pkg.LedgerAccountTaxPayable, Name: "Taxes payable", Type: pkg.LedgerAccountTypeLiability},
+		{Code: pkg.LedgerAccountRollingReserve, Name: "Merchants rolling reserves", Type: pkg.LedgerAccountTypeLiability},
+		{Code: pkg.LedgerAccountFxRevaluation, Name: "Merchants liabilities currency revaluation", Type: pkg.LedgerAccountTypeLiability},
+		{Code: pkg.LedgerAccountFeeRevenue, Name: "Fee revenue", Type: pkg.LedgerAccountTypeRevenue},
+		{Code: pkg.LedgerAccountFxRevenue, Name: "Currency exchange revenue", Type: pkg.LedgerAccountTypeRevenue},
+		{Code: pkg.LedgerAccountFxUnrealized, Name: "Unrealized currency exchange gains and losses", Type: pkg.LedgerAccountTypeRevenue},
+		{Code: pkg.LedgerAccountFxRealized, Name: "Realized currency exchange gains and losses", Type: pkg.LedgerAccountTypeRevenue},
+		{Code: pkg.LedgerAccountPaymentMethodCosts, Name: "Payment methods costs", Type: pkg.LedgerAccountTypeExpense},
+		{Code: pkg.LedgerAccountRefundCosts, Name: "Refund costs", Type: pkg.LedgerAccountTypeExpense},
+		{Code: pkg.LedgerAccountCorrections, Name: "Merchants royalty corrections", Type: pkg.LedgerAccountTypeExpense},
+	}
+
+	// Posting rules of accounting entries. Amounts of payments and refunds are posted to one side of their own
+	// accounts: gross amount to the receivable, fees, taxes, currency exchange revenue and merchant's payable each
+	// to its account, so the journal of the event is balanced only if its entries are consistent. Costs, reserves
+	// and corrections move money between two accounts.
+	ledgerPostingRules = map[string]*ledgerPostingRule{
+		pkg.AccountingEntryTypeRealGrossRevenue:                    {debit: pkg.LedgerAccountAcquirerReceivable},
+		pkg.AccountingEntryTypePsGrossRevenueFx:                    {credit: pkg.LedgerAccountFxRevenue},
+		pkg.AccountingEntryTypeMerchantTaxFeeCostValue:             {credit: pkg.LedgerAccountTaxPayable},
+		pkg.AccountingEntryTypeMerchantTaxFeeCentralBankFx:         {credit: pkg.LedgerAccountTaxPayable},
+		pkg.AccountingEntryTypePsMethodFee:                         {credit: pkg.LedgerAccountFeeRevenue},
+		pkg.AccountingEntryTypeMerchantPsFixedFee:                  {credit: pkg.LedgerAccountFeeRevenue},
+		pkg.AccountingEntryTypeMerchantNetRevenue:                  {credit: pkg.LedgerAccountMerchantPayable},
+		pkg.AccountingEntryTypeMerchantMethodFeeCostValue:          {pkg.LedgerAccountPaymentMethodCosts, pkg.LedgerAccountAcquirerReceivable},
+		pkg.AccountingEntryTypeRealMerchantMethodFixedFeeCostValue: {pkg.LedgerAccountPaymentMethodCosts, pkg.LedgerAccountAcquirerReceivable},
+		pkg.AccountingEntryTypeRealRefund:                          {credit: pkg.LedgerAccountAcquirerReceivable},
+		pkg.AccountingEntryTypePsMerchantRefundFx:                  {credit: pkg.LedgerAccountFxRevenue},
+		pkg.AccountingEntryTypeMerchantRefundFee:                   {credit: pkg.LedgerAccountFeeRevenue},
+		pkg.AccountingEntryTypeMerchantRefundFixedFee:              {credit: pkg.LedgerAccountFeeRevenue},
+		pkg.AccountingEntryTypeReverseTaxFee:                       {debit: pkg.LedgerAccountTaxPayable},
+		pkg.AccountingEntryTypeReverseTaxFeeDelta:                  {credit: pkg.LedgerAccountFxRevenue},
+		pkg.AccountingEntryTypeMerchantReverseRevenue:              {debit: pkg.LedgerAccountMerchantPayable},
+		pkg.AccountingEntryTypeRealRefundFee:                       {pkg.LedgerAccountRefundCosts, pkg.LedgerAccountAcquirerReceivable},
+		pkg.AccountingEntryTypeRealRefundFixedFee:                  {pkg.LedgerAccountRefundCosts, pkg.LedgerAccountAcquirerReceivable},
+		pkg.AccountingEntryTypeMerchantRollingReserveCreate:        {pkg.LedgerAccountMerchantPayable, pkg.LedgerAccountRollingReserve},
+		pkg.AccountingEntryTypeMerchantRollingReserveRelease:       {pkg.LedgerAccountRollingReserve, pkg.LedgerAccountMerchantPayable},
+		pkg.AccountingEntryTypeMerchantRoyaltyCorrection:           {pkg.LedgerAccountCorrections, pkg.LedgerAccountMerchantPayable},
+	}
+
+	// Amounts of events which aren't stored as accounting entries, they're calculated by the same formulas
+	// as in the order view. Merchant's net revenue of the payment is stored, so the journal of the payment
+	// is balanced only if the stored net revenue matches the order view formula.
+	ledgerDerivedEntries = map[string][]*ledgerDerivedEntry{
+		accountingEventTypeRefund: {
+			{
+				entryType: pkg.AccountingEntryTypePsMerchantRefundFx,
+				baseType:  pkg.AccountingEntryTypeRealRefund,
+				factors: map[string]float64{
+					pkg.AccountingEntryTypeMerchantRefund: 1,
+					pkg.AccountingEntryTypeRealRefund:     -1,
+				},
+			},
+			{
+				entryType: pkg.AccountingEntryTypeMerchantReverseRevenue,
+				baseType:  pkg.AccountingEntryTypeRealRefund,
+				factors: map[string]float64{
+					pkg.AccountingEntryTypeMerchantRefund:         1,
+					pkg.AccountingEntryTypeMerchantRefundFee:      1,
+					pkg.AccountingEntryTypeMerchantRefundFixedFee: 1,
+					pkg.AccountingEntryTypeReverseTaxFeeDelta:     1,
+					pkg.AccountingEntryTypeReverseTaxFee:          -1,
+				},
+			},
+		},
+	}
+
+	// Accounting entries which are informational (totals, markups, profits and amounts in other currencies)
+	// or are included to derived amounts and aren't posted to avoid double counting
+	ledgerMemoEntries = map[string]bool{
+		pkg.AccountingEntryTypeRealTaxFee:                      true,
+		pkg.AccountingEntryTypeRealTaxFeeTotal:                 true,
+		pkg.AccountingEntryTypeCentralBankTaxFee:               true,
+		pkg.AccountingEntryTypePsGrossRevenueFxTaxFee:          true,
+		pkg.AccountingEntryTypePsGrossRevenueFxProfit:          true,
+		pkg.AccountingEntryTypeMerchantGrossRevenue:            true,
+		pkg.AccountingEntryTypeMerchantTaxFee:                  true,
+		pkg.AccountingEntryTypeMerchantMethodFee:               true,
+		pkg.AccountingEntryTypePsMarkupMerchantMethodFee:       true,
+		pkg.AccountingEntryTypeMerchantMethodFixedFee:          true,
+		pkg.AccountingEntryTypeRealMerchantMethodFixedFee:      true,
+		pkg.AccountingEntryTypeMarkupMerchantMethodFixedFeeFx:  true,
+		pkg.AccountingEntryTypePsMethodFixedFeeProfit:          true,
+		pkg.AccountingEntryTypeRealMerchantPsFixedFee:          true,
+		pkg.AccountingEntryTypeMarkupMerchantPsFixedFee:        true,
+		pkg.AccountingEntryTypePsMethodProfit:                  true,
+		pkg.AccountingEntryTypePsProfitTotal:                   true,
+		pkg.AccountingEntryTypeRealRefundTaxFee:                true,
+		pkg.AccountingEntryTypeMerchantRefund:                  true,
+		pkg.AccountingEntryTypePsMarkupMerchantRefundFee:       true,
+		pkg.AccountingEntryTypeMerchantRefundFixedFeeCostValue: true,
+		pkg.AccountingEntryTypePsMerchantRefundFixedFeeFx:      true,
+		pkg.AccountingEntryTypePsMerchantRefundFixedFeeProfit:  true,
+		pkg.AccountingEntryTypePsReverseTaxFeeDelta:            true,
+		pkg.AccountingEntryTypeMerchantReverseTaxFee:           true,
+		pkg.AccountingEntryTypePsRefundProfit:                  true,
+	}
+)
+
+// ledgerPostingRule contains accounts the amount of the accounting entry is posted to, the entry posted
+// to one side only has the empty account of other side.
+type ledgerPostingRule struct {
+	debit  string
+	credit string
+}
+
+// ledgerPostingLeg is the amount of the accounting entry posted to one side of the account.
+type ledgerPostingLeg struct {
+	entry   *billingpb.AccountingEntry
+	account string
+	debit   bool
+	amount  float64
+}
+
+// ledgerDerivedEntry is the amount of the event calculated as the sum of amounts of entries of the event
+// multiplied by their factors. The amount is in the currency of the base entry of the event.
+type ledgerDerivedEntry struct {
+	entryType string
+	baseType  string
+	factors   map[string]float64
+}
+
+func (s *Service) ListLedgerAccounts(
+	ctx context.Context,
+	req *intPkg.ListLedgerAccountsRequest,
+	rsp *intPkg.ListLedgerAccountsResponse,
+) error {
+	accounts, err := s.getLedgerAccounts(ctx, req.OperatingCompanyId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = ledgerErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Items = accounts
+
+	return nil
+}
+
+// SetLedgerAccount adds or updates the account of the chart of accounts of the operating company.
+// The default chart of accounts is copied to the operating company on the first change.
+func (s *Service) SetLedgerAccount(
+	ctx context.Context,
+	req *intPkg.LedgerAccount,
+	rsp *intPkg.LedgerAccountResponse,
+) error {
+	if req.Code == "" || req.Name == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = ledgerErrorAccountCodeRequired
+		return nil
+	}
+
+	if !ledgerAccountTypes[req.Type] {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = ledgerErrorAccountTypeInvalid
+		return nil
+	}
+
+	accounts, err := s.ledgerAccountRepository.FindByOperatingCompanyId(ctx, req.OperatingCompanyId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = ledgerErrorUnknown
+		return nil
+	}
+
+	if len(accounts) == 0 {
+		accounts = getDefaultLedgerAccounts(req.OperatingCompanyId)
+
+		for _, account := range accounts {
+			if err = s.ledgerAccountRepository.Upsert(ctx, account); err != nil {
+				rsp.Status = billingpb.ResponseStatusSystemError
+				rsp.Message = ledgerErrorUnknown
+				return nil
+			}
+		}
+	}
+
+	account := &intPkg.LedgerAccount{OperatingCompanyId: req.OperatingCompanyId, Code: req.Code}
+
+	for _, v := range accounts {
+		if v.Code == req.Code {
+			account = v
+			break
+		}
+	}
+
+	account.Name = req.Name
+	account.Type = req.Type
+	account.ErpCode = req.ErpCode
+
+	if err = s.ledgerAccountRepository.Upsert(ctx, account); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = ledgerErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = account
+
+	return nil
+}
+
+// GetTrialBalance returns debit and credit turnovers and balances of all accounts of the operating company
+// posted by dates.
+func (s *Service) GetTrialBalance(
+	ctx context.Context,
+	req *intPkg.TrialBalanceRequest,
+	rsp *intPkg.TrialBalanceResponse,
+) error {
+	if req.DateFrom <= 0 || req.DateTo < req.DateFrom {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = ledgerErrorDatesInvalid
+		return nil
+	}
+
+	accounts, err := s.getLedgerAccountsMap(ctx, req.OperatingCompanyId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = ledgerErrorUnknown
+		return nil
+	}
+
+	items, err := s.ledgerJournalRepository.GetTrialBalance(
+		ctx,
+		req.OperatingCompanyId,
+		time.Unix(req.DateFrom, 0),
+		time.Unix(req.DateTo, 0),
+	)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = ledgerErrorUnknown
+		return nil
+	}
+
+	for _, item := range items {
+		if account, ok := accounts[item.AccountCode]; ok {
+			item.AccountName = account.Name
+			item.AccountType = account.Type
+		}
+
+		item.Debit = tools.FormatAmount(item.Debit)
+		item.Credit = tools.FormatAmount(item.Credit)
+		item.Balance = getLedgerAccountBalance(item.AccountType, item.Debit, item.Credit)
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Items = items
+
+	return nil
+}
+
+// GetLedgerAccountStatement returns legs of the account in the currency posted by dates with running balance.
+func (s *Service) GetLedgerAccountStatement(
+	ctx context.Context,
+	req *intPkg.LedgerAccountStatementRequest,
+	rsp *intPkg.LedgerAccountStatementResponse,
+) error {
+	if req.DateFrom <= 0 || req.DateTo < req.DateFrom {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = ledgerErrorDatesInvalid
+		return nil
+	}
+
+	if req.Currency == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = ledgerErrorCurrencyRequired
+		return nil
+	}
+
+	accounts, err := s.getLedgerAccountsMap(ctx, req.OperatingCompanyId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = ledgerErrorUnknown
+		return nil
+	}
+
+	account, ok := accounts[req.AccountCode]
+
+	if !ok {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = ledgerErrorAccountNotFound
+		return nil
+	}
+
+	from := time.Unix(req.DateFrom, 0)
+	turnover, err := s.ledgerJournalRepository.GetAccountTurnover(
+		ctx,
+		req.OperatingCompanyId,
+		req.AccountCode,
+		req.Currency,
+		req.MerchantId,
+		from,
+	)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = ledgerErrorUnknown
+		return nil
+	}
+
+	items, err := s.ledgerJournalRepository.GetAccountStatement(
+		ctx,
+		req.OperatingCompanyId,
+		req.AccountCode,
+		req.Currency,
+		req.MerchantId,
+		from,
+		time.Unix(req.DateTo, 0),
+	)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = ledgerErrorUnknown
+		return nil
+	}
+
+	statement := &intPkg.LedgerAccountStatement{
+		Account:        account,
+		Currency:       req.Currency,
+		OpeningBalance: getLedgerAccountBalance(account.Type, turnover.Debit, turnover.Credit),
+		Items:          items,
+	}
+	balance := statement.OpeningBalance
+
+	for _, item := range items {
+		balance += getLedgerAccountBalance(account.Type, item.Debit, item.Credit)
+		item.Balance = tools.FormatAmount(balance)
+	}
+
+	statement.ClosingBalance = tools.FormatAmount(balance)
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = statement
+
+	return nil
+}
+
+// prepareLedgerJournal makes the journal of the accounting event from its accounting entries
+// and checks that the journal is balanced and posted to existing accounts.
+func (s *Service) prepareLedgerJournal(
+	ctx context.Context,
+	eventType string,
+	entries []*billingpb.AccountingEntry,
+) (*intPkg.LedgerJournal, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	journal, err := getLedgerJournal(eventType, entries)
+
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.getLedgerAccountsMap(ctx, journal.OperatingCompanyId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validateLedgerJournal(journal, accounts); err != nil {
+		zap.L().Error(
+			"Ledger journal rejected",
+			zap.Error(err),
+			zap.Any("journal", journal),
+		)
+		return nil, err
+	}
+
+	return journal, nil
+}
+
+func (s *Service) getLedgerAccounts(ctx context.Context, operatingCompanyId string) ([]*intPkg.LedgerAccount, error) {
+	accounts, err := s.ledgerAccountRepository.FindByOperatingCompanyId(ctx, operatingCompanyId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(accounts) == 0 {
+		accounts = getDefaultLedgerAccounts(operatingCompanyId)
+	}
+
+	return accounts, nil
+}
+
+func (s *Service) getLedgerAccountsMap(ctx context.Context, operatingCompanyId string) (map[string]*intPkg.LedgerAccount, error) {
+	accounts, err := s.getLedgerAccounts(ctx, operatingCompanyId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*intPkg.LedgerAccount, len(accounts))
+
+	for _, account := range accounts {
+		result[account.Code] = account
+	}
+
+	return result, nil
+}
+
+func getDefaultLedgerAccounts(operatingCompanyId string) []*intPkg.LedgerAccount {
+	accounts := make([]*intPkg.LedgerAccount, 0, len(defaultLedgerAccounts))
+
+	for _, v := range defaultLedgerAccounts {
+		accounts = append(accounts, &intPkg.LedgerAccount{
+			OperatingCompanyId: operatingCompanyId,
+			Code:               v.Code,
+			Name:               v.Name,
+			Type:               v.Type,
+		})
+	}
+
+	return accounts
+}
+
+func getLedgerJournal(eventType string, entries []*billingpb.AccountingEntry) (*intPkg.LedgerJournal, error) {
+	first := entries[0]
+	journal := &intPkg.LedgerJournal{
+		OperatingCompanyId: first.OperatingCompanyId,
+		MerchantId:         first.MerchantId,
+		EventType:          eventType,
+		Date:               time.Now(),
+	}
+
+	if first.Source != nil {
+		journal.SourceId = first.Source.Id
+		journal.SourceType = first.Source.Type
+	}
+
+	if first.CreatedAt != nil {
+		if date, err := ptypes.Timestamp(first.CreatedAt); err == nil {
+			journal.Date = date
+		}
+	}
+
+	legs, err := getLedgerPostingLegs(eventType, entries, getLedgerEntryAmount)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, leg := range legs {
+		line := &intPkg.LedgerJournalLine{
+			AccountCode: leg.account,
+			EntryId:     leg.entry.Id,
+			EntryType:   leg.entry.Type,
+			Currency:    leg.entry.Currency,
+			Reason:      leg.entry.Reason,
+		}
+
+		if leg.debit {
+			line.Debit = leg.amount
+		} else {
+			line.Credit = leg.amount
+		}
+
+		journal.Lines = append(journal.Lines, line)
+	}
+
+	return journal, nil
+}
+
+// getLedgerPostingLegs posts entries of the event with its derived amounts to accounts by posting rules.
+// The amount function returns the amount of the entry to post.
+func getLedgerPostingLegs(
+	eventType string,
+	entries []*billingpb.AccountingEntry,
+	amountFn func(*billingpb.AccountingEntry) float64,
+) ([]*ledgerPostingLeg, error) {
+	var legs []*ledgerPostingLeg
+
+	derived := getLedgerDerivedEntries(eventType, entries, amountFn)
+	posted := make([]*billingpb.AccountingEntry, 0, len(entries)+len(derived))
+	posted = append(posted, entries...)
+	posted = append(posted, derived...)
+
+	for _, entry := range posted {
+		if ledgerMemoEntries[entry.Type] {
+			continue
+		}
+
+		rule, ok := ledgerPostingRules[entry.Type]
+
+		if !ok {
+			return nil, ledgerErrorPostingRuleNotFound
+		}
+
+		amount := amountFn(entry)
+
+		if amount == 0 {
+			continue
+		}
+
+		debit, credit := rule.debit, rule.credit
+
+		// Manual correction of amount of payment or refund is posted against the corrections account
+		if eventType == accountingEventTypeManualCorrection {
+			if debit == "" {
+				debit = pkg.LedgerAccountCorrections
+			}
+
+			if credit == "" {
+				credit = pkg.LedgerAccountCorrections
+			}
+		}
+
+		// Negative amount is posted to the opposite sides of the accounts
+		if amount < 0 {
+			debit, credit = credit, debit
+		}
+
+		amount = math.Abs(amount)
+
+		if debit != "" {
+			legs = append(legs, &ledgerPostingLeg{entry: entry, account: debit, debit: true, amount: amount})
+		}
+
+		if credit != "" {
+			legs = append(legs, &ledgerPostingLeg{entry: entry, account: credit, amount: amount})
+		}
+	}
+
+	return legs, nil
+}
+
+// getLedgerDerivedEntries calculates amounts of the event which aren't stored as accounting entries.
+// Derived amount refers to the base entry of the event and sums only entries in the currency of the base
+// entry, entries in other currencies stay unbalanced in their currency.
+func getLedgerDerivedEntries(
+	eventType string,
+	entries []*billingpb.AccountingEntry,
+	amountFn func(*billingpb.AccountingEntry) float64,
+) []*billingpb.AccountingEntry {
+	var result []*billingpb.AccountingEntry
+
+	for _, derived := range ledgerDerivedEntries[eventType] {
+		var base *billingpb.AccountingEntry
+		amount := float64(0)
+
+		for _, entry := range entries {
+			if entry.Type == derived.baseType {
+				base = entry
+				break
+			}
+		}
+
+		if base == nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.Currency == base.Currency {
+				amount += amountFn(entry) * derived.factors[entry.Type]
+			}
+		}
+
+		result = append(result, &billingpb.AccountingEntry{
+			Id:                 base.Id,
+			Type:               derived.entryType,
+			Source:             base.Source,
+			MerchantId:         base.MerchantId,
+			Amount:             amount,
+			AmountRounded:      amount,
+			Currency:           base.Currency,
+			OperatingCompanyId: base.OperatingCompanyId,
+			CreatedAt:          base.CreatedAt,
+		})
+	}
+
+	return result
+}
+
+func getLedgerEntryAmount(entry *billingpb.AccountingEntry) float64 {
+	return entry.Amount
+}
+
+func validateLedgerJournal(journal *intPkg.LedgerJournal, accounts map[string]*intPkg.LedgerAccount) error {
+	balances := make(map[string]float64)
+
+	for _, line := range journal.Lines {
+		if _, ok := accounts[line.AccountCode]; !ok {
+			return ledgerErrorAccountNotFound
+		}
+
+		balances[line.Currency] += line.Debit - line.Credit
+	}
+
+	for _, balance := range balances {
+		if math.Abs(balance) > ledgerBalanceTolerance {
+			return ledgerErrorJournalUnbalanced
+		}
+	}
+
+	return nil
+}
+
+// getLedgerAccountBalance returns balance on the normal side of the account,
+// it's debit for assets and expenses and credit for other accounts.
+func getLedgerAccountBalance(accountType string, debit, credit float64) float64 {
+	if accountType == pkg.LedgerAccountTypeAsset || accountType == pkg.LedgerAccountTypeExpense {
+		return tools.FormatAmount(debit - credit)
+	}
+
+	return tools.FormatAmount(credit - debit)
+}
@@ -0,0 +1,956 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/jinzhu/now"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/reporterpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.uber.org/zap"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ledgerExportDateLayout          = "2006-01-02"
+	ledgerExportDatevDateLayout     = "20060102"
+	ledgerExportDatevDocDateLayout  = "0201"
+	ledgerExportDatevCreatedLayout  = "20060102150405.000"
+	ledgerExportDatevDocumentLength = 36
+	ledgerExportDatevTextLength     = 60
+	ledgerExportDatevAccountLength  = 4
+	ledgerExportSafTVersion         = "2.00"
+	ledgerExportSafTNamespace       = "urn:OECD:StandardAuditFile-Tax:2.00"
+	ledgerExportSoftwareName        = "paysuper-billing-server"
+
+	// Maximal difference between report and ledger amounts caused by rounding of the report totals
+	ledgerExportReconciliationTolerance = 0.01
+)
+
+var (
+	ledgerExportErrorFormatInvalid      = errors.NewBillingServerErrorMsg("le000001", "ledger export format is invalid")
+	ledgerExportErrorDatesInvalid       = errors.NewBillingServerErrorMsg("le000002", "ledger export dates are invalid")
+	ledgerExportErrorDatevPeriodInvalid = errors.NewBillingServerErrorMsg("le000003", "period of DATEV export must be within one calendar year")
+	ledgerExportErrorUnknown            = errors.NewBillingServerErrorMsg("le000004", "unknown error. try request later")
+
+	ledgerExportFileExtensions = map[string]string{
+		pkg.LedgerExportFormatCsv:   "csv",
+		pkg.LedgerExportFormatSafT:  "xml",
+		pkg.LedgerExportFormatDatev: "csv",
+	}
+
+	ledgerExportContentTypes = map[string]string{
+		pkg.LedgerExportFormatCsv:   "text/csv",
+		pkg.LedgerExportFormatSafT:  "application/xml",
+		pkg.LedgerExportFormatDatev: "text/csv",
+	}
+
+	ledgerExportCsvHeader = []string{
+		"date", "entry_id", "entry_type", "source_type", "source_id", "merchant_id",
+		"debit_account", "credit_account", "amount", "currency", "description",
+	}
+
+	ledgerExportReconciliationRules = map[string]*ledgerExportReconciliationRule{
+		pkg.AccountingEntryTypeRealGrossRevenue: {
+			pkg.LedgerExportReconciliationRoyaltyGrossRevenue, pkg.LedgerAccountAcquirerReceivable, true,
+		},
+		pkg.AccountingEntryTypeRealRefund: {
+			pkg.LedgerExportReconciliationRoyaltyGrossRevenue, pkg.LedgerAccountAcquirerReceivable, true,
+		},
+		pkg.AccountingEntryTypePsMethodFee: {
+			pkg.LedgerExportReconciliationRoyaltyFees, pkg.LedgerAccountFeeRevenue, false,
+		},
+		pkg.AccountingEntryTypeMerchantPsFixedFee: {
+			pkg.LedgerExportReconciliationRoyaltyFees, pkg.LedgerAccountFeeRevenue, false,
+		},
+		pkg.AccountingEntryTypeMerchantRefundFee: {
+			pkg.LedgerExportReconciliationRoyaltyFees, pkg.LedgerAccountFeeRevenue, false,
+		},
+		pkg.AccountingEntryTypeMerchantRefundFixedFee: {
+			pkg.LedgerExportReconciliationRoyaltyFees, pkg.LedgerAccountFeeRevenue, false,
+		},
+		pkg.AccountingEntryTypeMerchantNetRevenue: {
+			pkg.LedgerExportReconciliationRoyaltyPayout, pkg.LedgerAccountMerchantPayable, false,
+		},
+		pkg.AccountingEntryTypeMerchantReverseRevenue: {
+			pkg.LedgerExportReconciliationRoyaltyPayout, pkg.LedgerAccountMerchantPayable, false,
+		},
+		pkg.AccountingEntryTypeMerchantRoyaltyCorrection: {
+			pkg.LedgerExportReconciliationRoyaltyCorrections, pkg.LedgerAccountMerchantPayable, false,
+		},
+		pkg.AccountingEntryTypeMerchantRollingReserveCreate: {
+			pkg.LedgerExportReconciliationRoyaltyRollingReserves, pkg.LedgerAccountRollingReserve, false,
+		},
+		pkg.AccountingEntryTypeMerchantRollingReserveRelease: {
+			pkg.LedgerExportReconciliationRoyaltyRollingReserves, pkg.LedgerAccountRollingReserve, true,
+		},
+	}
+
+	ledgerExportDatevHeader = []string{
+		"Umsatz (ohne Soll/Haben-Kz)", "Soll/Haben-Kennzeichen", "WKZ Umsatz", "Kurs", "Basis-Umsatz",
+		"WKZ Basis-Umsatz", "Konto", "Gegenkonto (ohne BU-Schlüssel)", "BU-Schlüssel", "Belegdatum",
+		"Belegfeld 1", "Belegfeld 2", "Skonto", "Buchungstext",
+	}
+)
+
+// ledgerExportLine is the amount of the ledger journal posted to the debit and credit accounts
+// of the chart of accounts. The line is described by the journal line of one of its accounts.
+type ledgerExportLine struct {
+	journal *intPkg.LedgerJournal
+	entry   *intPkg.LedgerJournalLine
+	debit   *intPkg.LedgerAccount
+	credit  *intPkg.LedgerAccount
+	amount  float64
+}
+
+// ledgerExportReconciliationRule is the account which journal lines of the entry type are compared with
+// the item of royalty reports. The amount of the line is positive on the side of the rule.
+type ledgerExportReconciliationRule struct {
+	source  string
+	account string
+	debit   bool
+}
+
+type ledgerExportReconciliation map[string]*intPkg.LedgerExportReconciliation
+
+type safTAuditFile struct {
+	XMLName              xml.Name                  `xml:"AuditFile"`
+	Xmlns                string                    `xml:"xmlns,attr"`
+	Header               *safTHeader               `xml:"Header"`
+	MasterFiles          *safTMasterFiles          `xml:"MasterFiles"`
+	GeneralLedgerEntries *safTGeneralLedgerEntries `xml:"GeneralLedgerEntries"`
+}
+
+type safTHeader struct {
+	AuditFileVersion     string                 `xml:"AuditFileVersion"`
+	AuditFileCountry     string                 `xml:"AuditFileCountry"`
+	AuditFileDateCreated string                 `xml:"AuditFileDateCreated"`
+	SoftwareCompanyName  string                 `xml:"SoftwareCompanyName"`
+	SoftwareID           string                 `xml:"SoftwareID"`
+	Company              *safTCompany           `xml:"Company"`
+	SelectionCriteria    *safTSelectionCriteria `xml:"SelectionCriteria"`
+}
+
+type safTCompany struct {
+	RegistrationNumber string `xml:"RegistrationNumber"`
+	Name               string `xml:"Name"`
+}
+
+type safTSelectionCriteria struct {
+	SelectionStartDate string `xml:"SelectionStartDate"`
+	SelectionEndDate   string `xml:"SelectionEndDate"`
+}
+
+type safTMasterFiles struct {
+	Accounts []*safTAccount `xml:"GeneralLedgerAccounts>Account"`
+}
+
+type safTAccount struct {
+	AccountID          string `xml:"AccountID"`
+	AccountDescription string `xml:"AccountDescription"`
+	StandardAccountID  string `xml:"StandardAccountID"`
+	AccountType        string `xml:"AccountType"`
+}
+
+type safTGeneralLedgerEntries struct {
+	NumberOfEntries int          `xml:"NumberOfEntries"`
+	TotalDebit      string       `xml:"TotalDebit"`
+	TotalCredit     string       `xml:"TotalCredit"`
+	Journal         *safTJournal `xml:"Journal"`
+}
+
+type safTJournal struct {
+	JournalID    string             `xml:"JournalID"`
+	Description  string             `xml:"Description"`
+	Transactions []*safTTransaction `xml:"Transaction"`
+}
+
+type safTTransaction struct {
+	TransactionID   string      `xml:"TransactionID"`
+	Period          int         `xml:"Period"`
+	PeriodYear      int         `xml:"PeriodYear"`
+	TransactionDate string      `xml:"TransactionDate"`
+	SourceID        string      `xml:"SourceID"`
+	Description     string      `xml:"Description"`
+	SystemEntryDate string      `xml:"SystemEntryDate"`
+	GLPostingDate   string      `xml:"GLPostingDate"`
+	Lines           []*safTLine `xml:"Line"`
+}
+
+type safTLine struct {
+	RecordID         string      `xml:"RecordID"`
+	AccountID        string      `xml:"AccountID"`
+	SourceDocumentID string      `xml:"SourceDocumentID"`
+	Description      string      `xml:"Description"`
+	DebitAmount      *safTAmount `xml:"DebitAmount,omitempty"`
+	CreditAmount     *safTAmount `xml:"CreditAmount,omitempty"`
+}
+
+type safTAmount struct {
+	Amount       string `xml:"Amount"`
+	CurrencyCode string `xml:"CurrencyCode"`
+}
+
+// GetLedgerExport returns the file with accounting entries of the operating company posted by dates in the format
+// of ERP. The reporter service requests the file with this method to store and send it to the user.
+func (s *Service) GetLedgerExport(
+	ctx context.Context,
+	req *intPkg.LedgerExportRequest,
+	rsp *intPkg.LedgerExportResponse,
+) error {
+	if msg := validateLedgerExportRequest(req); msg != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = msg
+		return nil
+	}
+
+	oc, err := s.operatingCompanyRepository.GetById(ctx, req.OperatingCompanyId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = errorOperatingCompanyNotFound
+		return nil
+	}
+
+	export, err := s.getLedgerExport(ctx, req, oc)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = ledgerExportErrorUnknown
+
+		if err == ledgerErrorPostingRuleNotFound || err == ledgerErrorAccountNotFound ||
+			err == ledgerErrorJournalUnbalanced {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = err.(*billingpb.ResponseErrorMessage)
+		}
+
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = export
+
+	return nil
+}
+
+// CreateLedgerExportFile asks the reporter service to create the file of ledger export.
+func (s *Service) CreateLedgerExportFile(
+	ctx context.Context,
+	req *intPkg.LedgerExportRequest,
+	rsp *intPkg.CreateLedgerExportFileResponse,
+) error {
+	if msg := validateLedgerExportRequest(req); msg != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = msg
+		return nil
+	}
+
+	if !s.operatingCompanyRepository.Exists(ctx, req.OperatingCompanyId) {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = errorOperatingCompanyNotFound
+		return nil
+	}
+
+	if err := s.renderLedgerExport(ctx, req); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = ledgerExportErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// ExportLedger creates files of ledger export by the dates for the operating company or for all operating companies
+// if the operating company is empty. All formats are exported if the format is empty.
+func (s *Service) ExportLedger(ctx context.Context, operatingCompanyId string, from, to time.Time, format string) error {
+	formats := []string{pkg.LedgerExportFormatCsv, pkg.LedgerExportFormatSafT, pkg.LedgerExportFormatDatev}
+
+	if format != "" {
+		if _, ok := ledgerExportFileExtensions[format]; !ok {
+			return ledgerExportErrorFormatInvalid
+		}
+
+		formats = []string{format}
+	}
+
+	if from.IsZero() || to.Before(from) {
+		return ledgerExportErrorDatesInvalid
+	}
+
+	var operatingCompanies []*billingpb.OperatingCompany
+
+	if operatingCompanyId != "" {
+		oc, err := s.operatingCompanyRepository.GetById(ctx, operatingCompanyId)
+
+		if err != nil {
+			return errorOperatingCompanyNotFound
+		}
+
+		operatingCompanies = append(operatingCompanies, oc)
+	} else {
+		var err error
+		operatingCompanies, err = s.operatingCompanyRepository.GetAll(ctx)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, oc := range operatingCompanies {
+		for _, v := range formats {
+			req := &intPkg.LedgerExportRequest{
+				OperatingCompanyId: oc.Id,
+				Format:             v,
+				DateFrom:           from.Unix(),
+				DateTo:             to.Unix(),
+			}
+
+			if err := s.renderLedgerExport(ctx, req); err != nil {
+				return err
+			}
+		}
+
+		zap.L().Info(
+			"ledger export created",
+			zap.String("operating_company_id", oc.Id),
+			zap.Time("date_from", from),
+			zap.Time("date_to", to),
+		)
+	}
+
+	return nil
+}
+
+func (s *Service) renderLedgerExport(ctx context.Context, req *intPkg.LedgerExportRequest) error {
+	params, err := json.Marshal(req)
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to marshal the params of ledger export for the reporting service.",
+			zap.Error(err),
+		)
+		return err
+	}
+
+	fileReq := &reporterpb.ReportFile{
+		UserId:           req.UserId,
+		ReportType:       pkg.ReportTypeLedgerExport,
+		FileType:         ledgerExportFileExtensions[req.Format],
+		Params:           params,
+		SendNotification: req.UserId != "",
+	}
+
+	return s.reporterServiceCreateFile(ctx, fileReq)
+}
+
+func (s *Service) getLedgerExport(
+	ctx context.Context,
+	req *intPkg.LedgerExportRequest,
+	oc *billingpb.OperatingCompany,
+) (*intPkg.LedgerExport, error) {
+	from := time.Unix(req.DateFrom, 0).UTC()
+	to := time.Unix(req.DateTo, 0).UTC()
+
+	accounts, err := s.getLedgerAccountsMap(ctx, oc.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reconciliation, err := s.getLedgerExportReports(ctx, oc.Id, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []*ledgerExportLine
+
+	err = s.ledgerJournalRepository.FindByOperatingCompanyDates(
+		ctx,
+		oc.Id,
+		from,
+		to,
+		func(journal *intPkg.LedgerJournal) error {
+			journalLines, err := getLedgerExportJournalLines(journal, accounts)
+
+			if err != nil {
+				return err
+			}
+
+			lines = append(lines, journalLines...)
+
+			for _, line := range journal.Lines {
+				reconciliation.addLedgerAmount(line)
+			}
+
+			return nil
+		},
+	)
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to split ledger journals to lines for export",
+			zap.Error(err),
+			zap.String("operating_company_id", oc.Id),
+		)
+		return nil, err
+	}
+
+	export := &intPkg.LedgerExport{
+		FileName: fmt.Sprintf(
+			"ledger_%s_%s_%s_%s.%s",
+			req.Format,
+			oc.Id,
+			from.Format(ledgerExportDateLayout),
+			to.Format(ledgerExportDateLayout),
+			ledgerExportFileExtensions[req.Format],
+		),
+		ContentType: ledgerExportContentTypes[req.Format],
+		Totals:      getLedgerExportTotals(lines),
+	}
+
+	switch req.Format {
+	case pkg.LedgerExportFormatCsv:
+		export.Content, err = getLedgerExportCsv(lines)
+		break
+	case pkg.LedgerExportFormatSafT:
+		export.Content, err = getLedgerExportSafT(oc, accounts, lines, from, to)
+		break
+	case pkg.LedgerExportFormatDatev:
+		export.Content, err = getLedgerExportDatev(req, lines, from, to)
+		break
+	}
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to render ledger export",
+			zap.Error(err),
+			zap.String("operating_company_id", oc.Id),
+			zap.String("format", req.Format),
+		)
+		return nil, err
+	}
+
+	export.Reconciliation, err = s.getLedgerExportReconciliation(ctx, oc.Id, reconciliation, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// getLedgerExportReports sums totals of the royalty and VAT reports of the operating company which periods
+// are within the export dates. Amounts of exported journals are added to the same items while they're exported.
+func (s *Service) getLedgerExportReports(
+	ctx context.Context,
+	operatingCompanyId string,
+	from, to time.Time,
+) (ledgerExportReconciliation, error) {
+	royaltyReports, err := s.royaltyReportRepository.FindByOperatingCompanyPeriod(ctx, operatingCompanyId, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	vatReports, err := s.vatReportRepository.FindByOperatingCompanyPeriod(ctx, operatingCompanyId, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(ledgerExportReconciliation)
+
+	for _, report := range royaltyReports {
+		if report.Totals == nil {
+			continue
+		}
+
+		if report.Summary != nil && report.Summary.ProductsTotal != nil {
+			item := result.get(pkg.LedgerExportReconciliationRoyaltyGrossRevenue, "", report.Currency)
+			item.ReportAmount += report.Summary.ProductsTotal.GrossTotalAmount
+
+			item = result.get(pkg.LedgerExportReconciliationRoyaltyFees, "", report.Currency)
+			item.ReportAmount += report.Summary.ProductsTotal.TotalFees
+		}
+
+		item := result.get(pkg.LedgerExportReconciliationRoyaltyPayout, "", report.Currency)
+		item.ReportAmount += report.Totals.PayoutAmount
+
+		item = result.get(pkg.LedgerExportReconciliationRoyaltyCorrections, "", report.Currency)
+		item.ReportAmount += report.Totals.CorrectionAmount
+
+		item = result.get(pkg.LedgerExportReconciliationRoyaltyRollingReserves, "", report.Currency)
+		item.ReportAmount += report.Totals.RollingReserveAmount
+	}
+
+	for _, report := range vatReports {
+		item := result.get(pkg.LedgerExportReconciliationVat, report.Country, report.Currency)
+		item.ReportAmount += report.VatAmount
+	}
+
+	return result, nil
+}
+
+// getLedgerExportReconciliation compares totals of the royalty and VAT reports with the same totals calculated
+// by the exported journals. Gross revenue, fees and payout of royalty reports are compared with the amounts
+// of payments and refunds including their derived amounts posted to the ledger. Taxes aren't posted
+// to the ledger in local currency, so VAT reports are compared with taxes of accounting entries.
+func (s *Service) getLedgerExportReconciliation(
+	ctx context.Context,
+	operatingCompanyId string,
+	result ledgerExportReconciliation,
+	from, to time.Time,
+) ([]*intPkg.LedgerExportReconciliation, error) {
+	vat, err := s.accountingRepository.GetVatByOperatingCompanyDates(ctx, operatingCompanyId, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range vat {
+		if item := result.find(pkg.LedgerExportReconciliationVat, v.Country, v.LocalCurrency); item != nil {
+			item.LedgerAmount += v.Amount
+		}
+	}
+
+	items := make([]*intPkg.LedgerExportReconciliation, 0, len(result))
+
+	for _, item := range result {
+		item.ReportAmount = tools.FormatAmount(item.ReportAmount)
+		item.LedgerAmount = tools.FormatAmount(item.LedgerAmount)
+		item.Difference = tools.FormatAmount(item.ReportAmount - item.LedgerAmount)
+		item.Reconciled = math.Abs(item.Difference) < ledgerExportReconciliationTolerance
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Source+items[i].Country+items[i].Currency < items[j].Source+items[j].Country+items[j].Currency
+	})
+
+	return items, nil
+}
+
+func (r ledgerExportReconciliation) get(source, country, currency string) *intPkg.LedgerExportReconciliation {
+	item := r.find(source, country, currency)
+
+	if item == nil {
+		item = &intPkg.LedgerExportReconciliation{Source: source, Country: country, Currency: currency}
+		r[source+country+currency] = item
+	}
+
+	return item
+}
+
+func (r ledgerExportReconciliation) find(source, country, currency string) *intPkg.LedgerExportReconciliation {
+	return r[source+country+currency]
+}
+
+// addLedgerAmount adds the amount of the journal line to the reconciliation item which the entry type is related to.
+// Only items of the reports of the export period are calculated.
+func (r ledgerExportReconciliation) addLedgerAmount(line *intPkg.LedgerJournalLine) {
+	rule, ok := ledgerExportReconciliationRules[line.EntryType]
+
+	if !ok || rule.account != line.AccountCode {
+		return
+	}
+
+	item := r.find(rule.source, "", line.Currency)
+
+	if item == nil {
+		return
+	}
+
+	amount := tools.FormatAmount(line.Credit - line.Debit)
+
+	if rule.debit {
+		amount = -amount
+	}
+
+	item.LedgerAmount += amount
+}
+
+func validateLedgerExportRequest(req *intPkg.LedgerExportRequest) *billingpb.ResponseErrorMessage {
+	if _, ok := ledgerExportFileExtensions[req.Format]; !ok {
+		return ledgerExportErrorFormatInvalid
+	}
+
+	if req.DateFrom <= 0 || req.DateTo < req.DateFrom {
+		return ledgerExportErrorDatesInvalid
+	}
+
+	if req.Format == pkg.LedgerExportFormatDatev &&
+		time.Unix(req.DateFrom, 0).UTC().Year() != time.Unix(req.DateTo, 0).UTC().Year() {
+		return ledgerExportErrorDatevPeriodInvalid
+	}
+
+	return nil
+}
+
+// getLedgerExportJournalLines matches debit and credit lines of the journal in the same currency in order of posting.
+// The export line is described by the journal line which is exported by it completely, the credit line is preferred.
+func getLedgerExportJournalLines(
+	journal *intPkg.LedgerJournal,
+	accounts map[string]*intPkg.LedgerAccount,
+) ([]*ledgerExportLine, error) {
+	var (
+		lines      []*ledgerExportLine
+		currencies []string
+	)
+
+	debits := make(map[string][]*intPkg.LedgerJournalLine)
+	credits := make(map[string][]*intPkg.LedgerJournalLine)
+
+	for _, line := range journal.Lines {
+		if _, ok := accounts[line.AccountCode]; !ok {
+			return nil, ledgerErrorAccountNotFound
+		}
+
+		_, okDebit := debits[line.Currency]
+		_, okCredit := credits[line.Currency]
+
+		if !okDebit && !okCredit {
+			currencies = append(currencies, line.Currency)
+		}
+
+		if line.Debit > 0 {
+			debits[line.Currency] = append(debits[line.Currency], line)
+			continue
+		}
+
+		credits[line.Currency] = append(credits[line.Currency], line)
+	}
+
+	for _, currency := range currencies {
+		debit, credit := debits[currency], credits[currency]
+		debitRest := make([]float64, len(debit))
+		creditRest := make([]float64, len(credit))
+
+		for k, line := range debit {
+			debitRest[k] = line.Debit
+		}
+
+		for k, line := range credit {
+			creditRest[k] = line.Credit
+		}
+
+		i, j := 0, 0
+
+		for i < len(debit) && j < len(credit) {
+			amount := math.Min(debitRest[i], creditRest[j])
+			entry := credit[j]
+
+			if creditRest[j] > debitRest[i] {
+				entry = debit[i]
+			}
+
+			lines = append(lines, &ledgerExportLine{
+				journal: journal,
+				entry:   entry,
+				debit:   accounts[debit[i].AccountCode],
+				credit:  accounts[credit[j].AccountCode],
+				amount:  tools.FormatAmount(amount),
+			})
+
+			debitRest[i] -= amount
+			creditRest[j] -= amount
+
+			if debitRest[i] <= ledgerBalanceTolerance {
+				i++
+			}
+
+			if creditRest[j] <= ledgerBalanceTolerance {
+				j++
+			}
+		}
+
+		for ; i < len(debit); i++ {
+			if debitRest[i] > ledgerBalanceTolerance {
+				return nil, ledgerErrorJournalUnbalanced
+			}
+		}
+
+		for ; j < len(credit); j++ {
+			if creditRest[j] > ledgerBalanceTolerance {
+				return nil, ledgerErrorJournalUnbalanced
+			}
+		}
+	}
+
+	return lines, nil
+}
+
+func getLedgerExportTotals(lines []*ledgerExportLine) []*intPkg.LedgerExportTotal {
+	var totals []*intPkg.LedgerExportTotal
+	index := make(map[string]*intPkg.LedgerExportTotal)
+
+	for _, line := range lines {
+		total, ok := index[line.entry.Currency]
+
+		if !ok {
+			total = &intPkg.LedgerExportTotal{Currency: line.entry.Currency}
+			index[line.entry.Currency] = total
+			totals = append(totals, total)
+		}
+
+		total.EntriesCount++
+		total.Debit += line.amount
+		total.Credit += line.amount
+	}
+
+	for _, total := range totals {
+		total.Debit = tools.FormatAmount(total.Debit)
+		total.Credit = tools.FormatAmount(total.Credit)
+	}
+
+	return totals
+}
+
+func getLedgerExportCsv(lines []*ledgerExportLine) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+
+	if err := w.Write(ledgerExportCsvHeader); err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		record := []string{
+			line.journal.Date.Format(ledgerExportDateLayout),
+			line.entry.EntryId,
+			line.entry.EntryType,
+			line.journal.SourceType,
+			line.journal.SourceId,
+			line.journal.MerchantId,
+			getLedgerExportAccountCode(line.debit),
+			getLedgerExportAccountCode(line.credit),
+			formatLedgerExportAmount(line.amount),
+			line.entry.Currency,
+			line.description(),
+		}
+
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func getLedgerExportSafT(
+	oc *billingpb.OperatingCompany,
+	accounts map[string]*intPkg.LedgerAccount,
+	lines []*ledgerExportLine,
+	from, to time.Time,
+) ([]byte, error) {
+	file := &safTAuditFile{
+		Xmlns: ledgerExportSafTNamespace,
+		Header: &safTHeader{
+			AuditFileVersion:     ledgerExportSafTVersion,
+			AuditFileCountry:     oc.Country,
+			AuditFileDateCreated: time.Now().UTC().Format(ledgerExportDateLayout),
+			SoftwareCompanyName:  oc.Name,
+			SoftwareID:           ledgerExportSoftwareName,
+			Company: &safTCompany{
+				RegistrationNumber: oc.RegistrationNumber,
+				Name:               oc.Name,
+			},
+			SelectionCriteria: &safTSelectionCriteria{
+				SelectionStartDate: from.Format(ledgerExportDateLayout),
+				SelectionEndDate:   to.Format(ledgerExportDateLayout),
+			},
+		},
+		MasterFiles: &safTMasterFiles{},
+		GeneralLedgerEntries: &safTGeneralLedgerEntries{
+			NumberOfEntries: len(lines),
+			Journal: &safTJournal{
+				JournalID:   "GL",
+				Description: "Accounting entries",
+			},
+		},
+	}
+
+	for _, v := range defaultLedgerAccounts {
+		account, ok := accounts[v.Code]
+
+		if !ok {
+			continue
+		}
+
+		file.MasterFiles.Accounts = append(file.MasterFiles.Accounts, &safTAccount{
+			AccountID:          getLedgerExportAccountCode(account),
+			AccountDescription: account.Name,
+			StandardAccountID:  account.Code,
+			AccountType:        account.Type,
+		})
+	}
+
+	total := float64(0)
+
+	for _, line := range lines {
+		amount := formatLedgerExportAmount(line.amount)
+		total += line.amount
+
+		file.GeneralLedgerEntries.Journal.Transactions = append(
+			file.GeneralLedgerEntries.Journal.Transactions,
+			&safTTransaction{
+				TransactionID:   line.entry.EntryId,
+				Period:          int(line.journal.Date.Month()),
+				PeriodYear:      line.journal.Date.Year(),
+				TransactionDate: line.journal.Date.Format(ledgerExportDateLayout),
+				SourceID:        line.journal.SourceId,
+				Description:     line.description(),
+				SystemEntryDate: line.journal.Date.Format(ledgerExportDateLayout),
+				GLPostingDate:   line.journal.Date.Format(ledgerExportDateLayout),
+				Lines: []*safTLine{
+					{
+						RecordID:         line.entry.EntryId + "-D",
+						AccountID:        getLedgerExportAccountCode(line.debit),
+						SourceDocumentID: line.journal.SourceId,
+						Description:      line.entry.EntryType,
+						DebitAmount:      &safTAmount{Amount: amount, CurrencyCode: line.entry.Currency},
+					},
+					{
+						RecordID:         line.entry.EntryId + "-C",
+						AccountID:        getLedgerExportAccountCode(line.credit),
+						SourceDocumentID: line.journal.SourceId,
+						Description:      line.entry.EntryType,
+						CreditAmount:     &safTAmount{Amount: amount, CurrencyCode: line.entry.Currency},
+					},
+				},
+			},
+		)
+	}
+
+	file.GeneralLedgerEntries.TotalDebit = formatLedgerExportAmount(total)
+	file.GeneralLedgerEntries.TotalCredit = formatLedgerExportAmount(total)
+
+	content, err := xml.MarshalIndent(file, "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), content...), nil
+}
+
+// getLedgerExportDatev renders the lines to the posting batch (Buchungsstapel) of DATEV ASCII format.
+func getLedgerExportDatev(
+	req *intPkg.LedgerExportRequest,
+	lines []*ledgerExportLine,
+	from, to time.Time,
+) ([]byte, error) {
+	accountLength := ledgerExportDatevAccountLength
+
+	for _, line := range lines {
+		for _, account := range []*intPkg.LedgerAccount{line.debit, line.credit} {
+			if l := len(getLedgerExportAccountCode(account)); l > accountLength {
+				accountLength = l
+			}
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	w.Comma = ';'
+	w.UseCRLF = true
+
+	header := []string{
+		"EXTF", "700", "21", "Buchungsstapel", "12",
+		strings.Replace(time.Now().UTC().Format(ledgerExportDatevCreatedLayout), ".", "", 1),
+		"", "", "", "",
+		strconv.Itoa(int(req.DatevConsultantNumber)),
+		strconv.Itoa(int(req.DatevClientNumber)),
+		now.New(from).BeginningOfYear().Format(ledgerExportDatevDateLayout),
+		strconv.Itoa(accountLength),
+		from.Format(ledgerExportDatevDateLayout),
+		to.Format(ledgerExportDatevDateLayout),
+		"PaySuper accounting entries", "", "1", "0", "0", "",
+	}
+
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	if err := w.Write(ledgerExportDatevHeader); err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		record := []string{
+			strings.Replace(formatLedgerExportAmount(line.amount), ".", ",", 1),
+			"S",
+			line.entry.Currency,
+			"", "", "",
+			getLedgerExportAccountCode(line.debit),
+			getLedgerExportAccountCode(line.credit),
+			"",
+			line.journal.Date.Format(ledgerExportDatevDocDateLayout),
+			truncateLedgerExportText(line.journal.SourceId, ledgerExportDatevDocumentLength),
+			"",
+			"",
+			truncateLedgerExportText(line.description(), ledgerExportDatevTextLength),
+		}
+
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (l *ledgerExportLine) description() string {
+	if l.entry.Reason == "" {
+		return l.entry.EntryType
+	}
+
+	return l.entry.EntryType + " " + l.entry.Reason
+}
+
+func getLedgerExportAccountCode(account *intPkg.LedgerAccount) string {
+	if account.ErpCode != "" {
+		return account.ErpCode
+	}
+
+	return account.Code
+}
+
+func formatLedgerExportAmount(amount float64) string {
+	return strconv.FormatFloat(tools.FormatAmount(amount), 'f', 2, 64)
+}
+
+func truncateLedgerExportText(text string, length int) string {
+	runes := []rune(text)
+
+	if len(runes) <= length {
+		return text
+	}
+
+	return string(runes[:length])
+}
@@ -0,0 +1,412 @@
+package service
+
+import (
+	"context"
+	"encoding/xml"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/internal/repository"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	casbinMocks "github.com/paysuper/paysuper-proto/go/casbinpb/mocks"
+	"github.com/paysuper/paysuper-proto/go/reporterpb"
+	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"strings"
+	"testing"
+	"time"
+)
+
+type LedgerExportTestSuite struct {
+	suite.Suite
+	service          *Service
+	operatingCompany *billingpb.OperatingCompany
+	merchantId       string
+	from             time.Time
+	to               time.Time
+}
+
+func Test_LedgerExport(t *testing.T) {
+	suite.Run(t, new(LedgerExportTestSuite))
+}
+
+func (suite *LedgerExportTestSuite) SetupTest() {
+	cfg, err := config.NewConfig()
+	assert.NoError(suite.T(), err, "Config load failed")
+
+	db, err := mongodb.NewDatabase()
+	assert.NoError(suite.T(), err, "Database connection failed")
+
+	redisdb := mocks.NewTestRedis()
+	cache, err := database.NewCacheRedis(redisdb, "cache")
+
+	if err != nil {
+		suite.FailNow("Cache redis initialize failed", "%v", err)
+	}
+
+	suite.service = NewBillingService(
+		db,
+		cfg,
+		mocks.NewGeoIpServiceTestOk(),
+		mocks.NewRepositoryServiceOk(),
+		&mocks.TaxServiceOkMock{},
+		mocks.NewBrokerMockOk(),
+		nil,
+		cache,
+		mocks.NewCurrencyServiceMockOk(),
+		mocks.NewDocumentSignerMockOk(),
+		&reportingMocks.ReporterService{},
+		mocks.NewFormatterOK(),
+		mocks.NewBrokerMockOk(),
+		&casbinMocks.CasbinService{},
+		mocks.NewNotifierOk(),
+		mocks.NewBrokerMockOk(),
+	)
+
+	if err := suite.service.Init(); err != nil {
+		suite.FailNow("Billing service initialization failed", "%v", err)
+	}
+
+	suite.operatingCompany = HelperOperatingCompany(suite.Suite, suite.service)
+	suite.merchantId = primitive.NewObjectID().Hex()
+	suite.from = time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC)
+	suite.to = time.Date(2021, time.February, 28, 23, 59, 59, 0, time.UTC)
+}
+
+func (suite *LedgerExportTestSuite) TearDownTest() {
+	err := suite.service.db.Drop()
+
+	if err != nil {
+		suite.FailNow("Database deletion failed", "%v", err)
+	}
+
+	err = suite.service.db.Close()
+
+	if err != nil {
+		suite.FailNow("Database close failed", "%v", err)
+	}
+}
+
+func (suite *LedgerExportTestSuite) getEntry(entryType string, amount float64) *billingpb.AccountingEntry {
+	createdAt, _ := ptypes.TimestampProto(suite.from.Add(24 * time.Hour))
+
+	return &billingpb.AccountingEntry{
+		Id:                 primitive.NewObjectID().Hex(),
+		Object:             pkg.ObjectTypeBalanceTransaction,
+		Type:               entryType,
+		Source:             &billingpb.AccountingEntrySource{Id: primitive.NewObjectID().Hex(), Type: pkg.OrderTypeOrder},
+		MerchantId:         suite.merchantId,
+		Amount:             amount,
+		AmountRounded:      amount,
+		Currency:           "USD",
+		Country:            "RU",
+		LocalAmount:        amount * 70,
+		LocalAmountRounded: amount * 70,
+		LocalCurrency:      "RUB",
+		OperatingCompanyId: suite.operatingCompany.Id,
+		CreatedAt:          createdAt,
+	}
+}
+
+func (suite *LedgerExportTestSuite) insertEntries() {
+	entries := []*billingpb.AccountingEntry{
+		suite.getEntry(pkg.AccountingEntryTypeRealGrossRevenue, 100),
+		suite.getEntry(pkg.AccountingEntryTypeMerchantGrossRevenue, 100),
+		suite.getEntry(pkg.AccountingEntryTypeRealTaxFee, 20),
+		suite.getEntry(pkg.AccountingEntryTypeMerchantTaxFeeCostValue, 20),
+		suite.getEntry(pkg.AccountingEntryTypeMerchantNetRevenue, 80),
+		suite.getEntry(pkg.AccountingEntryTypeMerchantRoyaltyCorrection, -10),
+	}
+
+	for _, entry := range entries[1:5] {
+		entry.Source = entries[0].Source
+	}
+
+	entries[5].Source = &billingpb.AccountingEntrySource{Id: suite.merchantId, Type: repository.CollectionMerchant}
+	err := suite.service.accountingRepository.MultipleInsert(context.TODO(), entries)
+	assert.NoError(suite.T(), err)
+
+	suite.insertJournal(accountingEventTypePayment, entries[:5])
+	suite.insertJournal(accountingEventTypeManualCorrection, entries[5:])
+}
+
+func (suite *LedgerExportTestSuite) insertJournal(eventType string, entries []*billingpb.AccountingEntry) {
+	journal, err := suite.service.prepareLedgerJournal(context.TODO(), eventType, entries)
+	assert.NoError(suite.T(), err)
+
+	err = suite.service.ledgerJournalRepository.Insert(context.TODO(), journal)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *LedgerExportTestSuite) getRequest(format string) *intPkg.LedgerExportRequest {
+	return &intPkg.LedgerExportRequest{
+		OperatingCompanyId:    suite.operatingCompany.Id,
+		Format:                format,
+		DateFrom:              suite.from.Unix(),
+		DateTo:                suite.to.Unix(),
+		DatevConsultantNumber: 1001,
+		DatevClientNumber:     10001,
+	}
+}
+
+func (suite *LedgerExportTestSuite) getExport(format string) *intPkg.LedgerExport {
+	rsp := &intPkg.LedgerExportResponse{}
+	err := suite.service.GetLedgerExport(context.TODO(), suite.getRequest(format), rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.NotNil(suite.T(), rsp.Item)
+
+	return rsp.Item
+}
+
+func (suite *LedgerExportTestSuite) TestLedgerExport_GetLedgerExport_Csv_Ok() {
+	suite.insertEntries()
+
+	req := &intPkg.LedgerAccount{
+		OperatingCompanyId: suite.operatingCompany.Id,
+		Code:               pkg.LedgerAccountMerchantPayable,
+		Name:               "Payables to merchants",
+		Type:               pkg.LedgerAccountTypeLiability,
+		ErpCode:            "70000",
+	}
+	rsp := &intPkg.LedgerAccountResponse{}
+	err := suite.service.SetLedgerAccount(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	export := suite.getExport(pkg.LedgerExportFormatCsv)
+	assert.Equal(suite.T(), "text/csv", export.ContentType)
+	assert.True(suite.T(), strings.HasSuffix(export.FileName, ".csv"))
+
+	lines := strings.Split(strings.TrimSpace(string(export.Content)), "\n")
+	assert.Len(suite.T(), lines, 4)
+	assert.True(suite.T(), strings.HasPrefix(lines[0], "date,entry_id,entry_type"))
+	assert.Contains(suite.T(), lines[1], pkg.AccountingEntryTypeMerchantTaxFeeCostValue)
+	assert.Contains(suite.T(), lines[1], ","+pkg.LedgerAccountAcquirerReceivable+","+pkg.LedgerAccountTaxPayable+",20.00,USD,")
+	assert.Contains(suite.T(), lines[2], pkg.AccountingEntryTypeMerchantNetRevenue)
+	assert.Contains(suite.T(), lines[2], ","+pkg.LedgerAccountAcquirerReceivable+",70000,80.00,USD,")
+	assert.Contains(suite.T(), lines[3], ",70000,"+pkg.LedgerAccountCorrections+",10.00,USD,")
+
+	assert.Len(suite.T(), export.Totals, 1)
+	assert.EqualValues(suite.T(), 3, export.Totals[0].EntriesCount)
+	assert.EqualValues(suite.T(), 110, export.Totals[0].Debit)
+	assert.EqualValues(suite.T(), 110, export.Totals[0].Credit)
+}
+
+func (suite *LedgerExportTestSuite) TestLedgerExport_GetLedgerExport_SafT_Ok() {
+	suite.insertEntries()
+	export := suite.getExport(pkg.LedgerExportFormatSafT)
+	assert.Equal(suite.T(), "application/xml", export.ContentType)
+
+	file := &safTAuditFile{}
+	err := xml.Unmarshal(export.Content, file)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.operatingCompany.Name, file.Header.Company.Name)
+	assert.Equal(suite.T(), "2021-02-01", file.Header.SelectionCriteria.SelectionStartDate)
+	assert.Len(suite.T(), file.MasterFiles.Accounts, len(defaultLedgerAccounts))
+	assert.Equal(suite.T(), 3, file.GeneralLedgerEntries.NumberOfEntries)
+	assert.Equal(suite.T(), "110.00", file.GeneralLedgerEntries.TotalDebit)
+	assert.Len(suite.T(), file.GeneralLedgerEntries.Journal.Transactions, 3)
+
+	transaction := file.GeneralLedgerEntries.Journal.Transactions[0]
+	assert.Len(suite.T(), transaction.Lines, 2)
+	assert.Equal(suite.T(), "20.00", transaction.Lines[0].DebitAmount.Amount)
+	assert.Nil(suite.T(), transaction.Lines[0].CreditAmount)
+	assert.Equal(suite.T(), "20.00", transaction.Lines[1].CreditAmount.Amount)
+}
+
+func (suite *LedgerExportTestSuite) TestLedgerExport_GetLedgerExport_Datev_Ok() {
+	suite.insertEntries()
+	export := suite.getExport(pkg.LedgerExportFormatDatev)
+
+	lines := strings.Split(strings.TrimSpace(string(export.Content)), "\r\n")
+	assert.Len(suite.T(), lines, 5)
+	assert.True(suite.T(), strings.HasPrefix(lines[0], "EXTF;700;21;Buchungsstapel;"))
+	assert.Contains(suite.T(), lines[0], ";1001;10001;20210101;4;20210201;20210228;")
+	assert.True(suite.T(), strings.HasPrefix(lines[1], "Umsatz (ohne Soll/Haben-Kz);"))
+	assert.True(suite.T(), strings.HasPrefix(lines[2], "20,00;S;USD;;;;1100;2200;;0202;"))
+	assert.True(suite.T(), strings.HasPrefix(lines[3], "80,00;S;USD;;;;1100;2100;;0202;"))
+}
+
+func (suite *LedgerExportTestSuite) TestLedgerExport_GetLedgerExport_Unbalanced_Error() {
+	journal := &intPkg.LedgerJournal{
+		OperatingCompanyId: suite.operatingCompany.Id,
+		MerchantId:         suite.merchantId,
+		EventType:          accountingEventTypePayment,
+		SourceId:           primitive.NewObjectID().Hex(),
+		SourceType:         repository.CollectionOrder,
+		Lines: []*intPkg.LedgerJournalLine{
+			{
+				AccountCode: pkg.LedgerAccountAcquirerReceivable,
+				EntryType:   pkg.AccountingEntryTypeRealGrossRevenue,
+				Currency:    "USD",
+				Debit:       100,
+			},
+			{
+				AccountCode: pkg.LedgerAccountTaxPayable,
+				EntryType:   pkg.AccountingEntryTypeMerchantTaxFeeCostValue,
+				Currency:    "USD",
+				Credit:      20,
+			},
+			{
+				AccountCode: pkg.LedgerAccountFeeRevenue,
+				EntryType:   pkg.AccountingEntryTypePsMethodFee,
+				Currency:    "EUR",
+				Credit:      5,
+			},
+		},
+		Date: suite.from.Add(24 * time.Hour),
+	}
+	err := suite.service.ledgerJournalRepository.Insert(context.TODO(), journal)
+	assert.NoError(suite.T(), err)
+
+	rsp := &intPkg.LedgerExportResponse{}
+	err = suite.service.GetLedgerExport(context.TODO(), suite.getRequest(pkg.LedgerExportFormatCsv), rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), ledgerErrorJournalUnbalanced, rsp.Message)
+}
+
+func (suite *LedgerExportTestSuite) TestLedgerExport_GetLedgerExport_Reconciliation_Ok() {
+	suite.insertEntries()
+
+	report := &billingpb.RoyaltyReport{
+		Id:                 primitive.NewObjectID().Hex(),
+		MerchantId:         suite.merchantId,
+		OperatingCompanyId: suite.operatingCompany.Id,
+		Totals: &billingpb.RoyaltyReportTotals{
+			PayoutAmount:     80,
+			CorrectionAmount: -10,
+		},
+		Summary: &billingpb.RoyaltyReportSummary{
+			ProductsTotal: &billingpb.RoyaltyReportProductSummaryItem{
+				GrossTotalAmount: 100,
+				TotalFees:        5,
+			},
+		},
+		Status:         billingpb.RoyaltyReportStatusAccepted,
+		CreatedAt:      ptypes.TimestampNow(),
+		AcceptExpireAt: ptypes.TimestampNow(),
+		Currency:       "USD",
+	}
+	report.PeriodFrom, _ = ptypes.TimestampProto(suite.from)
+	report.PeriodTo, _ = ptypes.TimestampProto(suite.to)
+	err := suite.service.royaltyReportRepository.Insert(context.TODO(), report, "", pkg.RoyaltyReportChangeSourceAuto)
+	assert.NoError(suite.T(), err)
+
+	vatReport := &billingpb.VatReport{
+		Id:                 primitive.NewObjectID().Hex(),
+		Country:            "RU",
+		Currency:           "RUB",
+		Status:             pkg.VatReportStatusNeedToPay,
+		VatAmount:          1500,
+		OperatingCompanyId: suite.operatingCompany.Id,
+		CreatedAt:          ptypes.TimestampNow(),
+		UpdatedAt:          ptypes.TimestampNow(),
+		PayUntilDate:       ptypes.TimestampNow(),
+	}
+	vatReport.DateFrom, _ = ptypes.TimestampProto(suite.from)
+	vatReport.DateTo, _ = ptypes.TimestampProto(suite.to)
+	err = suite.service.vatReportRepository.Insert(context.TODO(), vatReport)
+	assert.NoError(suite.T(), err)
+
+	export := suite.getExport(pkg.LedgerExportFormatCsv)
+	assert.Len(suite.T(), export.Reconciliation, 6)
+
+	item := export.Reconciliation[0]
+	assert.Equal(suite.T(), pkg.LedgerExportReconciliationRoyaltyCorrections, item.Source)
+	assert.EqualValues(suite.T(), -10, item.ReportAmount)
+	assert.EqualValues(suite.T(), -10, item.LedgerAmount)
+	assert.True(suite.T(), item.Reconciled)
+
+	item = export.Reconciliation[1]
+	assert.Equal(suite.T(), pkg.LedgerExportReconciliationRoyaltyFees, item.Source)
+	assert.EqualValues(suite.T(), 5, item.ReportAmount)
+	assert.EqualValues(suite.T(), 0, item.LedgerAmount)
+	assert.False(suite.T(), item.Reconciled)
+
+	item = export.Reconciliation[2]
+	assert.Equal(suite.T(), pkg.LedgerExportReconciliationRoyaltyGrossRevenue, item.Source)
+	assert.EqualValues(suite.T(), 100, item.ReportAmount)
+	assert.EqualValues(suite.T(), 100, item.LedgerAmount)
+	assert.True(suite.T(), item.Reconciled)
+
+	item = export.Reconciliation[3]
+	assert.Equal(suite.T(), pkg.LedgerExportReconciliationRoyaltyPayout, item.Source)
+	assert.EqualValues(suite.T(), 80, item.ReportAmount)
+	assert.EqualValues(suite.T(), 80, item.LedgerAmount)
+	assert.True(suite.T(), item.Reconciled)
+
+	item = export.Reconciliation[4]
+	assert.Equal(suite.T(), pkg.LedgerExportReconciliationRoyaltyRollingReserves, item.Source)
+	assert.True(suite.T(), item.Reconciled)
+
+	item = export.Reconciliation[5]
+	assert.Equal(suite.T(), pkg.LedgerExportReconciliationVat, item.Source)
+	assert.Equal(suite.T(), "RU", item.Country)
+	assert.Equal(suite.T(), "RUB", item.Currency)
+	assert.EqualValues(suite.T(), 1500, item.ReportAmount)
+	assert.EqualValues(suite.T(), 1400, item.LedgerAmount)
+	assert.EqualValues(suite.T(), 100, item.Difference)
+	assert.False(suite.T(), item.Reconciled)
+}
+
+func (suite *LedgerExportTestSuite) TestLedgerExport_GetLedgerExport_ValidationErrors() {
+	requests := map[*billingpb.ResponseErrorMessage]func(req *intPkg.LedgerExportRequest){
+		ledgerExportErrorFormatInvalid: func(req *intPkg.LedgerExportRequest) {
+			req.Format = "pdf"
+		},
+		ledgerExportErrorDatesInvalid: func(req *intPkg.LedgerExportRequest) {
+			req.DateTo = req.DateFrom - 1
+		},
+		ledgerExportErrorDatevPeriodInvalid: func(req *intPkg.LedgerExportRequest) {
+			req.DateFrom = suite.from.AddDate(-1, 0, 0).Unix()
+		},
+	}
+
+	for message, fn := range requests {
+		req := suite.getRequest(pkg.LedgerExportFormatDatev)
+		fn(req)
+
+		rsp := &intPkg.LedgerExportResponse{}
+		err := suite.service.GetLedgerExport(context.TODO(), req, rsp)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+		assert.Equal(suite.T(), message, rsp.Message)
+	}
+}
+
+func (suite *LedgerExportTestSuite) TestLedgerExport_GetLedgerExport_OperatingCompanyNotFound_Error() {
+	req := suite.getRequest(pkg.LedgerExportFormatCsv)
+	req.OperatingCompanyId = primitive.NewObjectID().Hex()
+
+	rsp := &intPkg.LedgerExportResponse{}
+	err := suite.service.GetLedgerExport(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), errorOperatingCompanyNotFound, rsp.Message)
+}
+
+func (suite *LedgerExportTestSuite) TestLedgerExport_CreateLedgerExportFile_Ok() {
+	reporterMock := &reportingMocks.ReporterService{}
+	reporterMock.On("CreateFile", mock2.Anything, mock2.Anything, mock2.Anything).
+		Return(&reporterpb.CreateFileResponse{Status: billingpb.ResponseStatusOk}, nil)
+	suite.service.reporterService = reporterMock
+
+	rsp := &intPkg.CreateLedgerExportFileResponse{}
+	err := suite.service.CreateLedgerExportFile(context.TODO(), suite.getRequest(pkg.LedgerExportFormatSafT), rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	req := reporterMock.Calls[0].Arguments.Get(1).(*reporterpb.ReportFile)
+	assert.Equal(suite.T(), pkg.ReportTypeLedgerExport, req.ReportType)
+	assert.Equal(suite.T(), "xml", req.FileType)
+	assert.Contains(suite.T(), string(req.Params), suite.operatingCompany.Id)
+}
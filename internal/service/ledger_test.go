@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	casbinMocks "github.com/paysuper/paysuper-proto/go/casbinpb/mocks"
+	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"testing"
+	"time"
+)
+
+type LedgerTestSuite struct {
+	suite.Suite
+	service            *Service
+	operatingCompanyId string
+	merchantId         string
+}
+
+func Test_Ledger(t *testing.T) {
+	suite.Run(t, new(LedgerTestSuite))
+}
+
+func (suite *LedgerTestSuite) SetupTest() {
+	cfg, err := config.NewConfig()
+	assert.NoError(suite.T(), err, "Config load failed")
+
+	db, err := mongodb.NewDatabase()
+	assert.NoError(suite.T(), err, "Database connection failed")
+
+	redisdb := mocks.NewTestRedis()
+	cache, err := database.NewCacheRedis(redisdb, "cache")
+
+	if err != nil {
+		suite.FailNow("Cache redis initialize failed", "%v", err)
+	}
+
+	suite.service = NewBillingService(
+		db,
+		cfg,
+		mocks.NewGeoIpServiceTestOk(),
+		mocks.NewRepositoryServiceOk(),
+		&mocks.TaxServiceOkMock{},
+		mocks.NewBrokerMockOk(),
+		nil,
+		cache,
+		mocks.NewCurrencyServiceMockOk(),
+		mocks.NewDocumentSignerMockOk(),
+		&reportingMocks.ReporterService{},
+		mocks.NewFormatterOK(),
+		mocks.NewBrokerMockOk(),
+		&casbinMocks.CasbinService{},
+		mocks.NewNotifierOk(),
+		mocks.NewBrokerMockOk(),
+	)
+
+	if err := suite.service.Init(); err != nil {
+		suite.FailNow("Billing service initialization failed", "%v", err)
+	}
+
+	suite.operatingCompanyId = primitive.NewObjectID().Hex()
+	suite.merchantId = primitive.NewObjectID().Hex()
+}
+
+func (suite *LedgerTestSuite) TearDownTest() {
+	err := suite.service.db.Drop()
+
+	if err != nil {
+		suite.FailNow("Database deletion failed", "%v", err)
+	}
+
+	err = suite.service.db.Close()
+
+	if err != nil {
+		suite.FailNow("Database close failed", "%v", err)
+	}
+}
+
+func (suite *LedgerTestSuite) getEntry(entryType string, amount float64, date time.Time) *billingpb.AccountingEntry {
+	createdAt, _ := ptypes.TimestampProto(date)
+
+	return &billingpb.AccountingEntry{
+		Id:                 primitive.NewObjectID().Hex(),
+		Type:               entryType,
+		Source:             &billingpb.AccountingEntrySource{Id: primitive.NewObjectID().Hex(), Type: pkg.OrderTypeOrder},
+		MerchantId:         suite.merchantId,
+		Amount:             amount,
+		Currency:           "USD",
+		OperatingCompanyId: suite.operatingCompanyId,
+		CreatedAt:          createdAt,
+	}
+}
+
+func (suite *LedgerTestSuite) postPayment(date time.Time, grossRevenue, taxFee, methodFee float64) {
+	entries := []*billingpb.AccountingEntry{
+		suite.getEntry(pkg.AccountingEntryTypeRealGrossRevenue, grossRevenue, date),
+		suite.getEntry(pkg.AccountingEntryTypeMerchantGrossRevenue, grossRevenue, date),
+		suite.getEntry(pkg.AccountingEntryTypeMerchantTaxFeeCostValue, taxFee, date),
+		suite.getEntry(pkg.AccountingEntryTypePsMethodFee, methodFee, date),
+		suite.getEntry(pkg.AccountingEntryTypeMerchantNetRevenue, grossRevenue-taxFee-methodFee, date),
+	}
+
+	journal, err := suite.service.prepareLedgerJournal(context.TODO(), accountingEventTypePayment, entries)
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), journal)
+
+	err = suite.service.ledgerJournalRepository.Insert(context.TODO(), journal)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *LedgerTestSuite) TestLedger_PrepareLedgerJournal_Ok() {
+	date := time.Now().Add(-time.Hour)
+	entries := []*billingpb.AccountingEntry{
+		suite.getEntry(pkg.AccountingEntryTypeRealGrossRevenue, 100, date),
+		suite.getEntry(pkg.AccountingEntryTypeMerchantGrossRevenue, 100, date),
+		suite.getEntry(pkg.AccountingEntryTypeMerchantTaxFeeCostValue, 20, date),
+		suite.getEntry(pkg.AccountingEntryTypePsGrossRevenueFx, 0, date),
+		suite.getEntry(pkg.AccountingEntryTypeMerchantNetRevenue, 80, date),
+	}
+
+	journal, err := suite.service.prepareLedgerJournal(context.TODO(), accountingEventTypePayment, entries)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.operatingCompanyId, journal.OperatingCompanyId)
+	assert.Equal(suite.T(), suite.merchantId, journal.MerchantId)
+	assert.Equal(suite.T(), entries[0].Source.Id, journal.SourceId)
+	assert.Equal(suite.T(), date.Unix(), journal.Date.Unix())
+	assert.Len(suite.T(), journal.Lines, 3)
+
+	assert.Equal(suite.T(), pkg.LedgerAccountAcquirerReceivable, journal.Lines[0].AccountCode)
+	assert.EqualValues(suite.T(), 100, journal.Lines[0].Debit)
+	assert.Equal(suite.T(), pkg.LedgerAccountTaxPayable, journal.Lines[1].AccountCode)
+	assert.EqualValues(suite.T(), 20, journal.Lines[1].Credit)
+	assert.Equal(suite.T(), pkg.LedgerAccountMerchantPayable, journal.Lines[2].AccountCode)
+	assert.Equal(suite.T(), pkg.AccountingEntryTypeMerchantNetRevenue, journal.Lines[2].EntryType)
+	assert.EqualValues(suite.T(), 80, journal.Lines[2].Credit)
+}
+
+func (suite *LedgerTestSuite) TestLedger_PrepareLedgerJournal_Refund_Ok() {
+	date := time.Now().Add(-time.Hour)
+	entries := []*billingpb.AccountingEntry{
+		suite.getEntry(pkg.AccountingEntryTypeRealRefund, 100, date),
+		suite.getEntry(pkg.AccountingEntryTypeMerchantRefund, 102, date),
+		suite.getEntry(pkg.AccountingEntryTypeMerchantRefundFee, 3, date),
+		suite.getEntry(pkg.AccountingEntryTypeReverseTaxFee, 20, date),
+	}
+
+	journal, err := suite.service.prepareLedgerJournal(context.TODO(), accountingEventTypeRefund, entries)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), journal.Lines, 5)
+
+	assert.Equal(suite.T(), pkg.LedgerAccountAcquirerReceivable, journal.Lines[0].AccountCode)
+	assert.EqualValues(suite.T(), 100, journal.Lines[0].Credit)
+	assert.Equal(suite.T(), pkg.LedgerAccountFeeRevenue, journal.Lines[1].AccountCode)
+	assert.EqualValues(suite.T(), 3, journal.Lines[1].Credit)
+	assert.Equal(suite.T(), pkg.LedgerAccountTaxPayable, journal.Lines[2].AccountCode)
+	assert.EqualValues(suite.T(), 20, journal.Lines[2].Debit)
+	assert.Equal(suite.T(), pkg.LedgerAccountFxRevenue, journal.Lines[3].AccountCode)
+	assert.EqualValues(suite.T(), 2, journal.Lines[3].Credit)
+	assert.Equal(suite.T(), pkg.LedgerAccountMerchantPayable, journal.Lines[4].AccountCode)
+	assert.EqualValues(suite.T(), 85, journal.Lines[4].Debit)
+}
+
+func (suite *LedgerTestSuite) TestLedger_PrepareLedgerJournal_Inconsistent_Error() {
+	date := time.Now().Add(-time.Hour)
+	entries := []*billingpb.AccountingEntry{
+		suite.getEntry(pkg.AccountingEntryTypeRealGrossRevenue, 100, date),
+		suite.getEntry(pkg.AccountingEntryTypeMerchantTaxFeeCostValue, 20, date),
+		suite.getEntry(pkg.AccountingEntryTypePsMethodFee, 5, date),
+		suite.getEntry(pkg.AccountingEntryTypeMerchantNetRevenue, 75, date),
+	}
+	entries[2].Currency = "EUR"
+
+	journal, err := suite.service.prepareLedgerJournal(context.TODO(), accountingEventTypePayment, entries)
+	assert.Equal(suite.T(), ledgerErrorJournalUnbalanced, err)
+	assert.Nil(suite.T(), journal)
+
+	entries[2].Currency = "USD"
+	entries[3].Amount = 70
+
+	journal, err = suite.service.prepareLedgerJournal(context.TODO(), accountingEventTypePayment, entries)
+	assert.Equal(suite.T(), ledgerErrorJournalUnbalanced, err)
+	assert.Nil(suite.T(), journal)
+
+	entries[3].Amount = 75
+
+	journal, err = suite.service.prepareLedgerJournal(context.TODO(), accountingEventTypePayment, entries)
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), journal)
+}
+
+func (suite *LedgerTestSuite) TestLedger_PrepareLedgerJournal_NegativeAmount_Ok() {
+	entries := []*billingpb.AccountingEntry{
+		suite.getEntry(pkg.AccountingEntryTypeMerchantRoyaltyCorrection, -50, time.Now()),
+	}
+
+	journal, err := suite.service.prepareLedgerJournal(context.TODO(), accountingEventTypeManualCorrection, entries)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), journal.Lines, 2)
+	assert.Equal(suite.T(), pkg.LedgerAccountMerchantPayable, journal.Lines[0].AccountCode)
+	assert.EqualValues(suite.T(), 50, journal.Lines[0].Debit)
+	assert.Equal(suite.T(), pkg.LedgerAccountCorrections, journal.Lines[1].AccountCode)
+	assert.EqualValues(suite.T(), 50, journal.Lines[1].Credit)
+}
+
+func (suite *LedgerTestSuite) TestLedger_PrepareLedgerJournal_UnknownEntryType_Error() {
+	entries := []*billingpb.AccountingEntry{
+		suite.getEntry("unknown_entry_type", 10, time.Now()),
+	}
+
+	journal, err := suite.service.prepareLedgerJournal(context.TODO(), accountingEventTypePayment, entries)
+	assert.Equal(suite.T(), ledgerErrorPostingRuleNotFound, err)
+	assert.Nil(suite.T(), journal)
+}
+
+func (suite *LedgerTestSuite) TestLedger_PrepareLedgerJournal_AccountNotInChart_Error() {
+	err := suite.service.ledgerAccountRepository.Upsert(context.TODO(), &intPkg.LedgerAccount{
+		OperatingCompanyId: suite.operatingCompanyId,
+		Code:               pkg.LedgerAccountAcquirerReceivable,
+		Name:               "Receivables from acquirers",
+		Type:               pkg.LedgerAccountTypeAsset,
+	})
+	assert.NoError(suite.T(), err)
+
+	entries := []*billingpb.AccountingEntry{
+		suite.getEntry(pkg.AccountingEntryTypeRealGrossRevenue, 100, time.Now()),
+	}
+
+	journal, err := suite.service.prepareLedgerJournal(context.TODO(), accountingEventTypePayment, entries)
+	assert.Equal(suite.T(), ledgerErrorAccountNotFound, err)
+	assert.Nil(suite.T(), journal)
+}
+
+func (suite *LedgerTestSuite) TestLedger_ValidateLedgerJournal_Unbalanced_Error() {
+	journal := &intPkg.LedgerJournal{
+		Lines: []*intPkg.LedgerJournalLine{
+			{AccountCode: pkg.LedgerAccountAcquirerReceivable, Currency: "USD", Debit: 100},
+			{AccountCode: pkg.LedgerAccountMerchantPayable, Currency: "USD", Credit: 99.99},
+		},
+	}
+	accounts := map[string]*intPkg.LedgerAccount{}
+
+	for _, v := range getDefaultLedgerAccounts(suite.operatingCompanyId) {
+		accounts[v.Code] = v
+	}
+
+	err := validateLedgerJournal(journal, accounts)
+	assert.Equal(suite.T(), ledgerErrorJournalUnbalanced, err)
+}
+
+func (suite *LedgerTestSuite) TestLedger_ListLedgerAccounts_Default_Ok() {
+	req := &intPkg.ListLedgerAccountsRequest{OperatingCompanyId: suite.operatingCompanyId}
+	rsp := &intPkg.ListLedgerAccountsResponse{}
+	err := suite.service.ListLedgerAccounts(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, len(defaultLedgerAccounts))
+	assert.Equal(suite.T(), suite.operatingCompanyId, rsp.Items[0].OperatingCompanyId)
+}
+
+func (suite *LedgerTestSuite) TestLedger_SetLedgerAccount_Ok() {
+	req := &intPkg.LedgerAccount{
+		OperatingCompanyId: suite.operatingCompanyId,
+		Code:               pkg.LedgerAccountFeeRevenue,
+		Name:               "Commission revenue",
+		Type:               pkg.LedgerAccountTypeRevenue,
+	}
+	rsp := &intPkg.LedgerAccountResponse{}
+	err := suite.service.SetLedgerAccount(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), "Commission revenue", rsp.Item.Name)
+
+	req.Code = "4300"
+	req.Name = "Other revenue"
+	err = suite.service.SetLedgerAccount(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	rsp1 := &intPkg.ListLedgerAccountsResponse{}
+	err = suite.service.ListLedgerAccounts(
+		context.TODO(),
+		&intPkg.ListLedgerAccountsRequest{OperatingCompanyId: suite.operatingCompanyId},
+		rsp1,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), rsp1.Items, len(defaultLedgerAccounts)+1)
+
+	for _, v := range rsp1.Items {
+		if v.Code == pkg.LedgerAccountFeeRevenue {
+			assert.Equal(suite.T(), "Commission revenue", v.Name)
+		}
+	}
+}
+
+func (suite *LedgerTestSuite) TestLedger_SetLedgerAccount_TypeInvalid_Error() {
+	req := &intPkg.LedgerAccount{
+		OperatingCompanyId: suite.operatingCompanyId,
+		Code:               "9999",
+		Name:               "Unknown",
+		Type:               "unknown",
+	}
+	rsp := &intPkg.LedgerAccountResponse{}
+	err := suite.service.SetLedgerAccount(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), ledgerErrorAccountTypeInvalid, rsp.Message)
+}
+
+func (suite *LedgerTestSuite) TestLedger_GetTrialBalance_Ok() {
+	suite.postPayment(time.Now().Add(-2*time.Hour), 100, 20, 5)
+	suite.postPayment(time.Now().Add(-time.Hour), 50, 10, 2)
+
+	req := &intPkg.TrialBalanceRequest{
+		OperatingCompanyId: suite.operatingCompanyId,
+		DateFrom:           time.Now().Add(-24 * time.Hour).Unix(),
+		DateTo:             time.Now().Unix(),
+	}
+	rsp := &intPkg.TrialBalanceResponse{}
+	err := suite.service.GetTrialBalance(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 4)
+
+	var debit, credit float64
+
+	for _, item := range rsp.Items {
+		debit += item.Debit
+		credit += item.Credit
+
+		switch item.AccountCode {
+		case pkg.LedgerAccountAcquirerReceivable:
+			assert.Equal(suite.T(), pkg.LedgerAccountTypeAsset, item.AccountType)
+			assert.EqualValues(suite.T(), 150, item.Balance)
+		case pkg.LedgerAccountMerchantPayable:
+			assert.EqualValues(suite.T(), 113, item.Balance)
+		case pkg.LedgerAccountTaxPayable:
+			assert.EqualValues(suite.T(), 30, item.Balance)
+		case pkg.LedgerAccountFeeRevenue:
+			assert.EqualValues(suite.T(), 7, item.Balance)
+		}
+	}
+
+	assert.EqualValues(suite.T(), debit, credit)
+}
+
+func (suite *LedgerTestSuite) TestLedger_GetTrialBalance_DatesInvalid_Error() {
+	req := &intPkg.TrialBalanceRequest{
+		OperatingCompanyId: suite.operatingCompanyId,
+		DateFrom:           time.Now().Unix(),
+		DateTo:             time.Now().Add(-time.Hour).Unix(),
+	}
+	rsp := &intPkg.TrialBalanceResponse{}
+	err := suite.service.GetTrialBalance(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), ledgerErrorDatesInvalid, rsp.Message)
+}
+
+func (suite *LedgerTestSuite) TestLedger_GetLedgerAccountStatement_Ok() {
+	suite.postPayment(time.Now().Add(-48*time.Hour), 100, 20, 5)
+	suite.postPayment(time.Now().Add(-time.Hour), 50, 10, 2)
+
+	req := &intPkg.LedgerAccountStatementRequest{
+		OperatingCompanyId: suite.operatingCompanyId,
+		AccountCode:        pkg.LedgerAccountMerchantPayable,
+		Currency:           "USD",
+		MerchantId:         suite.merchantId,
+		DateFrom:           time.Now().Add(-24 * time.Hour).Unix(),
+		DateTo:             time.Now().Unix(),
+	}
+	rsp := &intPkg.LedgerAccountStatementResponse{}
+	err := suite.service.GetLedgerAccountStatement(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.EqualValues(suite.T(), 75, rsp.Item.OpeningBalance)
+	assert.Len(suite.T(), rsp.Item.Items, 1)
+	assert.EqualValues(suite.T(), 113, rsp.Item.ClosingBalance)
+}
+
+func (suite *LedgerTestSuite) TestLedger_GetLedgerAccountStatement_AccountNotFound_Error() {
+	req := &intPkg.LedgerAccountStatementRequest{
+		OperatingCompanyId: suite.operatingCompanyId,
+		AccountCode:        "9999",
+		Currency:           "USD",
+		DateFrom:           time.Now().Add(-24 * time.Hour).Unix(),
+		DateTo:             time.Now().Unix(),
+	}
+	rsp := &intPkg.LedgerAccountStatementResponse{}
+	err := suite.service.GetLedgerAccountStatement(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), ledgerErrorAccountNotFound, rsp.Message)
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"github.com/golang/protobuf/ptypes"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg/errors"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
@@ -56,6 +57,56 @@ func (s *Service) GetMerchantBalance(
 	return nil
 }
 
+// ListMerchantBalances returns balances of the merchant in the payout currency of the merchant and in currencies
+// of payout accounts of the merchant. Missed balances are calculated.
+func (s *Service) ListMerchantBalances(
+	ctx context.Context,
+	req *intPkg.ListMerchantBalancesRequest,
+	rsp *intPkg.ListMerchantBalancesResponse,
+) error {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	if merchant.GetPayoutCurrency() == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = errorMerchantPayoutCurrencyNotSet
+		return nil
+	}
+
+	accounts, err := s.getMerchantPayoutAccounts(ctx, merchant)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantErrorUnknown
+		return nil
+	}
+
+	for _, account := range accounts {
+		balance, err := s.merchantBalanceRepository.GetByIdAndCurrency(ctx, merchant.Id, account.Currency)
+
+		if err == mongo.ErrNoDocuments {
+			balance, err = s.updateMerchantBalanceByCurrency(ctx, merchant.Id, account.Currency)
+		}
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = merchantErrorUnknown
+			return nil
+		}
+
+		rsp.Items = append(rsp.Items, balance)
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
 func (s *Service) getMerchantBalance(ctx context.Context, merchantId string) (*billingpb.MerchantBalance, error) {
 	merchant, err := s.merchantRepository.GetById(ctx, merchantId)
 	if err != nil {
@@ -70,6 +121,8 @@ func (s *Service) getMerchantBalance(ctx context.Context, merchantId string) (*b
 	return s.merchantBalanceRepository.GetByIdAndCurrency(ctx, merchant.Id, merchant.GetPayoutCurrency())
 }
 
+// updateMerchantBalance calculates balances of the merchant per payout account of the merchant and returns
+// the balance in the payout currency of the merchant.
 func (s *Service) updateMerchantBalance(ctx context.Context, merchantId string) (*billingpb.MerchantBalance, error) {
 	merchant, err := s.merchantRepository.GetById(ctx, merchantId)
 	if err != nil {
@@ -81,17 +134,42 @@ func (s *Service) updateMerchantBalance(ctx context.Context, merchantId string)
 		return nil, errorMerchantPayoutCurrencyNotSet
 	}
 
-	debit, err := s.royaltyReportRepository.GetBalanceAmount(ctx, merchant.Id, merchant.GetPayoutCurrency())
+	accounts, err := s.getMerchantPayoutAccounts(ctx, merchant)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *billingpb.MerchantBalance
+
+	for _, account := range accounts {
+		balance, err := s.updateMerchantBalanceByCurrency(ctx, merchant.Id, account.Currency)
+		if err != nil {
+			return nil, err
+		}
+
+		if result == nil {
+			result = balance
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Service) updateMerchantBalanceByCurrency(
+	ctx context.Context,
+	merchantId, currency string,
+) (*billingpb.MerchantBalance, error) {
+	debit, err := s.royaltyReportRepository.GetBalanceAmount(ctx, merchantId, currency)
 	if err != nil {
 		return nil, err
 	}
 
-	credit, err := s.payoutRepository.GetBalanceAmount(ctx, merchant.Id, merchant.GetPayoutCurrency())
+	credit, err := s.payoutRepository.GetBalanceAmount(ctx, merchantId, currency)
 	if err != nil {
 		return nil, err
 	}
 
-	rr, err := s.getRollingReserveForBalance(ctx, merchantId, merchant.GetPayoutCurrency())
+	rr, err := s.getRollingReserveForBalance(ctx, merchantId, currency)
 	if err != nil {
 		return nil, err
 	}
@@ -99,7 +177,7 @@ func (s *Service) updateMerchantBalance(ctx context.Context, merchantId string)
 	balance := &billingpb.MerchantBalance{
 		Id:             primitive.NewObjectID().Hex(),
 		MerchantId:     merchantId,
-		Currency:       merchant.GetPayoutCurrency(),
+		Currency:       currency,
 		Debit:          debit,
 		Credit:         credit,
 		RollingReserve: rr,
@@ -585,7 +585,7 @@ func (suite *MerchantBalanceTestSuite) TestMerchantBalance_UpdateBalanceTriggeri
 		Reason:     "unit test",
 	}
 	rsp4 := &billingpb.CreateAccountingEntryResponse{}
-	err := suite.service.CreateAccountingEntry(context.TODO(), req4, rsp4)
+	err := suite.service.createAccountingEntry(context.TODO(), req4, rsp4)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp4.Status)
 	assert.Empty(suite.T(), rsp4.Message)
@@ -625,7 +625,7 @@ func (suite *MerchantBalanceTestSuite) TestMerchantBalance_UpdateBalanceTriggeri
 		Reason:     "unit test",
 	}
 	rsp5 := &billingpb.CreateAccountingEntryResponse{}
-	err := suite.service.CreateAccountingEntry(context.TODO(), req5, rsp5)
+	err := suite.service.createAccountingEntry(context.TODO(), req5, rsp5)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp5.Status)
 	assert.Empty(suite.T(), rsp5.Message)
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	merchantBalanceTransactionSourcePayout = "payout_document"
+)
+
+var (
+	merchantBalanceTransactionErrorDatesInvalid = errors.NewBillingServerErrorMsg("ba000002", "merchant balance transactions dates are invalid")
+	merchantBalanceTransactionErrorTypeInvalid  = errors.NewBillingServerErrorMsg("ba000003", "merchant balance transaction type is invalid")
+	merchantBalanceTransactionErrorUnknown      = errors.NewBillingServerErrorMsg("ba000004", "unknown error. try request later")
+
+	merchantBalanceTransactionTypes = map[string]bool{
+		pkg.MerchantBalanceTransactionTypePaymentNet:     true,
+		pkg.MerchantBalanceTransactionTypeRefund:         true,
+		pkg.MerchantBalanceTransactionTypeChargeback:     true,
+		pkg.MerchantBalanceTransactionTypeCorrection:     true,
+		pkg.MerchantBalanceTransactionTypeReserveHold:    true,
+		pkg.MerchantBalanceTransactionTypeReserveRelease: true,
+		pkg.MerchantBalanceTransactionTypePayout:         true,
+		pkg.MerchantBalanceTransactionTypePayoutReversal: true,
+	}
+)
+
+func (s *Service) ListMerchantBalanceTransactions(
+	ctx context.Context,
+	req *intPkg.ListMerchantBalanceTransactionsRequest,
+	rsp *intPkg.ListMerchantBalanceTransactionsResponse,
+) error {
+	for _, v := range req.Types {
+		if !merchantBalanceTransactionTypes[v] {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = merchantBalanceTransactionErrorTypeInvalid
+			return nil
+		}
+	}
+
+	var from, to time.Time
+
+	if req.DateFrom > 0 {
+		from = time.Unix(req.DateFrom, 0)
+	}
+
+	if req.DateTo > 0 {
+		to = time.Unix(req.DateTo, 0)
+	}
+
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = merchantBalanceTransactionErrorDatesInvalid
+		return nil
+	}
+
+	currency, err := s.getMerchantBalanceTransactionCurrency(ctx, req.MerchantId, req.Currency)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = err.(*billingpb.ResponseErrorMessage)
+		return nil
+	}
+
+	rsp.Count, err = s.merchantBalanceTransactionRepository.FindCount(ctx, req.MerchantId, currency, req.Types, from, to)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantBalanceTransactionErrorUnknown
+		return nil
+	}
+
+	if rsp.Count > 0 {
+		rsp.Items, err = s.merchantBalanceTransactionRepository.Find(
+			ctx,
+			req.MerchantId,
+			currency,
+			req.Types,
+			from,
+			to,
+			req.Offset,
+			req.Limit,
+		)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = merchantBalanceTransactionErrorUnknown
+			return nil
+		}
+
+		if err = s.setMerchantBalanceTransactionsBalance(ctx, rsp.Items); err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = merchantBalanceTransactionErrorUnknown
+			return nil
+		}
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// GetMerchantBalanceAsOf returns the merchant's balance on the date with pending and available funds.
+func (s *Service) GetMerchantBalanceAsOf(
+	ctx context.Context,
+	req *intPkg.MerchantBalanceAsOfRequest,
+	rsp *intPkg.MerchantBalanceAsOfResponse,
+) error {
+	currency, err := s.getMerchantBalanceTransactionCurrency(ctx, req.MerchantId, req.Currency)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = err.(*billingpb.ResponseErrorMessage)
+		return nil
+	}
+
+	date := time.Now()
+
+	if req.Date > 0 {
+		date = time.Unix(req.Date, 0)
+	}
+
+	balance := &intPkg.MerchantBalanceAsOf{
+		MerchantId: req.MerchantId,
+		Currency:   currency,
+		Date:       date,
+	}
+
+	amount, err := s.merchantBalanceTransactionRepository.GetBalance(ctx, req.MerchantId, currency, date)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantBalanceTransactionErrorUnknown
+		return nil
+	}
+
+	balance.Balance = tools.FormatAmount(amount)
+	pending, err := s.merchantBalanceTransactionRepository.GetPendingAmount(ctx, req.MerchantId, currency, date)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantBalanceTransactionErrorUnknown
+		return nil
+	}
+
+	balance.Pending = tools.FormatAmount(pending)
+	balance.Available = tools.FormatAmount(balance.Balance - balance.Pending)
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = balance
+
+	return nil
+}
+
+func (s *Service) getMerchantBalanceTransactionCurrency(ctx context.Context, merchantId, currency string) (string, error) {
+	merchant, err := s.merchantRepository.GetById(ctx, merchantId)
+
+	if err != nil {
+		return "", merchantErrorNotFound
+	}
+
+	if currency != "" {
+		return currency, nil
+	}
+
+	if merchant.GetPayoutCurrency() == "" {
+		return "", errorMerchantPayoutCurrencyNotSet
+	}
+
+	return merchant.GetPayoutCurrency(), nil
+}
+
+// addMerchantBalanceTransaction appends the transaction to the merchant's balance log. Balances aren't stored
+// with transactions, they're derived from the sum of transactions, so transactions may be added concurrently
+// and with the date in the past.
+func (s *Service) addMerchantBalanceTransaction(ctx context.Context, tx *intPkg.MerchantBalanceTransaction) error {
+	if tx.CreatedAt.IsZero() {
+		tx.CreatedAt = time.Now()
+	}
+
+	if tx.AvailableAt.IsZero() {
+		tx.AvailableAt = tx.CreatedAt
+	}
+
+	tx.Amount = tools.FormatAmount(tx.Amount)
+
+	return s.merchantBalanceTransactionRepository.Insert(ctx, tx)
+}
+
+// setMerchantBalanceTransactionsBalance calculates running balances of transactions of the merchant in the currency
+// ordered by date. The balance after the transaction includes transactions of all types ordered before it.
+func (s *Service) setMerchantBalanceTransactionsBalance(
+	ctx context.Context,
+	txs []*intPkg.MerchantBalanceTransaction,
+) error {
+	if len(txs) == 0 {
+		return nil
+	}
+
+	first, last := txs[0], txs[len(txs)-1]
+	balance, err := s.merchantBalanceTransactionRepository.GetBalanceBefore(ctx, first)
+
+	if err != nil {
+		return err
+	}
+
+	all, err := s.merchantBalanceTransactionRepository.Find(
+		ctx,
+		first.MerchantId,
+		first.Currency,
+		nil,
+		first.CreatedAt,
+		last.CreatedAt,
+		0,
+		0,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	index := make(map[primitive.ObjectID]*intPkg.MerchantBalanceTransaction, len(txs))
+
+	for _, tx := range txs {
+		index[tx.Id] = tx
+	}
+
+	for _, tx := range all {
+		if tx.CreatedAt.Equal(first.CreatedAt) && bytes.Compare(tx.Id[:], first.Id[:]) < 0 {
+			continue
+		}
+
+		balance += tx.Amount
+
+		if v, ok := index[tx.Id]; ok {
+			v.Balance = tools.FormatAmount(balance)
+		}
+	}
+
+	return nil
+}
+
+// addMerchantBalanceTransactionsByJournal appends transactions for the change of merchant's payable
+// made by the journal of the accounting event.
+func (s *Service) addMerchantBalanceTransactionsByJournal(
+	ctx context.Context,
+	handler *accountingEntry,
+	eventType string,
+	journal *intPkg.LedgerJournal,
+) error {
+	if journal.MerchantId == "" {
+		return nil
+	}
+
+	txType := pkg.MerchantBalanceTransactionTypeCorrection
+	createdAt := time.Now()
+	availableAt := createdAt
+
+	switch eventType {
+	case accountingEventTypePayment:
+		txType = pkg.MerchantBalanceTransactionTypePaymentNet
+		availableAt = createdAt.Add(time.Duration(s.cfg.MerchantBalanceSettlementDelay) * time.Second)
+		break
+
+	case accountingEventTypeRefund:
+		txType = pkg.MerchantBalanceTransactionTypeRefund
+
+		if handler.refund != nil && handler.refund.IsChargeback {
+			txType = pkg.MerchantBalanceTransactionTypeChargeback
+		}
+		break
+	}
+
+	var txs []*intPkg.MerchantBalanceTransaction
+	index := make(map[string]*intPkg.MerchantBalanceTransaction)
+
+	for _, line := range journal.Lines {
+		if line.AccountCode != pkg.LedgerAccountMerchantPayable {
+			continue
+		}
+
+		// Rolling reserve entries of the event are separate transactions which are available immediately
+		lineTxType, lineAvailableAt := txType, availableAt
+
+		switch line.EntryType {
+		case pkg.AccountingEntryTypeMerchantRollingReserveCreate:
+			lineTxType, lineAvailableAt = pkg.MerchantBalanceTransactionTypeReserveHold, createdAt
+			break
+
+		case pkg.AccountingEntryTypeMerchantRollingReserveRelease:
+			lineTxType, lineAvailableAt = pkg.MerchantBalanceTransactionTypeReserveRelease, createdAt
+			break
+		}
+
+		key := lineTxType + line.Currency
+		tx, ok := index[key]
+
+		if !ok {
+			tx = &intPkg.MerchantBalanceTransaction{
+				MerchantId:  journal.MerchantId,
+				Currency:    line.Currency,
+				Type:        lineTxType,
+				SourceId:    journal.SourceId,
+				SourceType:  journal.SourceType,
+				AvailableAt: lineAvailableAt,
+				CreatedAt:   createdAt,
+			}
+			index[key] = tx
+			txs = append(txs, tx)
+		}
+
+		tx.Amount += line.Credit - line.Debit
+	}
+
+	for _, tx := range txs {
+		if tools.FormatAmount(tx.Amount) == 0 {
+			continue
+		}
+
+		if err := s.addMerchantBalanceTransaction(ctx, tx); err != nil {
+			zap.L().Error(
+				"Merchant balance transaction adding failed",
+				zap.Error(err),
+				zap.Any("transaction", tx),
+			)
+			return err
+		}
+	}
+
+	return nil
+}
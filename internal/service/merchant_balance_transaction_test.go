@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	casbinMocks "github.com/paysuper/paysuper-proto/go/casbinpb/mocks"
+	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"testing"
+	"time"
+)
+
+type MerchantBalanceTransactionTestSuite struct {
+	suite.Suite
+	service  *Service
+	merchant *billingpb.Merchant
+}
+
+func Test_MerchantBalanceTransaction(t *testing.T) {
+	suite.Run(t, new(MerchantBalanceTransactionTestSuite))
+}
+
+func (suite *MerchantBalanceTransactionTestSuite) SetupTest() {
+	cfg, err := config.NewConfig()
+	assert.NoError(suite.T(), err, "Config load failed")
+
+	db, err := mongodb.NewDatabase()
+	assert.NoError(suite.T(), err, "Database connection failed")
+
+	redisdb := mocks.NewTestRedis()
+	cache, err := database.NewCacheRedis(redisdb, "cache")
+
+	if err != nil {
+		suite.FailNow("Cache redis initialize failed", "%v", err)
+	}
+
+	suite.service = NewBillingService(
+		db,
+		cfg,
+		mocks.NewGeoIpServiceTestOk(),
+		mocks.NewRepositoryServiceOk(),
+		&mocks.TaxServiceOkMock{},
+		mocks.NewBrokerMockOk(),
+		nil,
+		cache,
+		mocks.NewCurrencyServiceMockOk(),
+		mocks.NewDocumentSignerMockOk(),
+		&reportingMocks.ReporterService{},
+		mocks.NewFormatterOK(),
+		mocks.NewBrokerMockOk(),
+		&casbinMocks.CasbinService{},
+		mocks.NewNotifierOk(),
+		mocks.NewBrokerMockOk(),
+	)
+
+	if err := suite.service.Init(); err != nil {
+		suite.FailNow("Billing service initialization failed", "%v", err)
+	}
+
+	operatingCompany := HelperOperatingCompany(suite.Suite, suite.service)
+	suite.merchant = HelperCreateMerchant(suite.Suite, suite.service, "USD", "RU", nil, 0, operatingCompany.Id)
+}
+
+func (suite *MerchantBalanceTransactionTestSuite) TearDownTest() {
+	err := suite.service.db.Drop()
+
+	if err != nil {
+		suite.FailNow("Database deletion failed", "%v", err)
+	}
+
+	err = suite.service.db.Close()
+
+	if err != nil {
+		suite.FailNow("Database close failed", "%v", err)
+	}
+}
+
+func (suite *MerchantBalanceTransactionTestSuite) addTransaction(
+	txType string,
+	amount float64,
+	createdAt, availableAt time.Time,
+) *intPkg.MerchantBalanceTransaction {
+	tx := &intPkg.MerchantBalanceTransaction{
+		MerchantId:  suite.merchant.Id,
+		Currency:    "USD",
+		Type:        txType,
+		Amount:      amount,
+		SourceId:    primitive.NewObjectID().Hex(),
+		SourceType:  "order",
+		CreatedAt:   createdAt,
+		AvailableAt: availableAt,
+	}
+
+	err := suite.service.addMerchantBalanceTransaction(context.TODO(), tx)
+	assert.NoError(suite.T(), err)
+
+	return tx
+}
+
+func (suite *MerchantBalanceTransactionTestSuite) addTransactions() {
+	now := time.Now()
+	suite.addTransaction(pkg.MerchantBalanceTransactionTypePaymentNet, 100, now.AddDate(0, 0, -10), now.AddDate(0, 0, -3))
+	suite.addTransaction(pkg.MerchantBalanceTransactionTypePaymentNet, 60, now.AddDate(0, 0, -1), now.AddDate(0, 0, 6))
+	suite.addTransaction(pkg.MerchantBalanceTransactionTypeRefund, -20, now.Add(-time.Minute), time.Time{})
+}
+
+func (suite *MerchantBalanceTransactionTestSuite) TestMerchantBalanceTransaction_AddMerchantBalanceTransaction_RunningBalance_Ok() {
+	now := time.Now()
+	suite.addTransaction(pkg.MerchantBalanceTransactionTypePaymentNet, 100.5, now.Add(-time.Hour), time.Time{})
+	suite.addTransaction(pkg.MerchantBalanceTransactionTypePayout, -50, now.Add(-time.Minute), time.Time{})
+	tx := suite.addTransaction(pkg.MerchantBalanceTransactionTypeReserveHold, -10, now.Add(-30*time.Minute), time.Time{})
+	assert.Equal(suite.T(), tx.CreatedAt, tx.AvailableAt)
+
+	req := &intPkg.ListMerchantBalanceTransactionsRequest{MerchantId: suite.merchant.Id}
+	rsp := &intPkg.ListMerchantBalanceTransactionsResponse{}
+	err := suite.service.ListMerchantBalanceTransactions(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 3)
+	assert.EqualValues(suite.T(), 100.5, rsp.Items[0].Balance)
+	assert.Equal(suite.T(), tx.Id, rsp.Items[1].Id)
+	assert.EqualValues(suite.T(), 90.5, rsp.Items[1].Balance)
+	assert.EqualValues(suite.T(), 40.5, rsp.Items[2].Balance)
+
+	req.Offset = 1
+	req.Types = []string{pkg.MerchantBalanceTransactionTypeReserveHold, pkg.MerchantBalanceTransactionTypePayout}
+	err = suite.service.ListMerchantBalanceTransactions(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 1)
+	assert.EqualValues(suite.T(), 40.5, rsp.Items[0].Balance)
+}
+
+func (suite *MerchantBalanceTransactionTestSuite) TestMerchantBalanceTransaction_GetMerchantBalanceAsOf_Ok() {
+	suite.addTransactions()
+
+	req := &intPkg.MerchantBalanceAsOfRequest{MerchantId: suite.merchant.Id}
+	rsp := &intPkg.MerchantBalanceAsOfResponse{}
+	err := suite.service.GetMerchantBalanceAsOf(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), "USD", rsp.Item.Currency)
+	assert.EqualValues(suite.T(), 140, rsp.Item.Balance)
+	assert.EqualValues(suite.T(), 60, rsp.Item.Pending)
+	assert.EqualValues(suite.T(), 80, rsp.Item.Available)
+
+	req.Date = time.Now().AddDate(0, 0, -5).Unix()
+	err = suite.service.GetMerchantBalanceAsOf(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.EqualValues(suite.T(), 100, rsp.Item.Balance)
+	assert.EqualValues(suite.T(), 0, rsp.Item.Pending)
+	assert.EqualValues(suite.T(), 100, rsp.Item.Available)
+
+	req.Date = time.Now().AddDate(0, 0, -20).Unix()
+	err = suite.service.GetMerchantBalanceAsOf(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Zero(suite.T(), rsp.Item.Balance)
+}
+
+func (suite *MerchantBalanceTransactionTestSuite) TestMerchantBalanceTransaction_GetMerchantBalanceAsOf_MerchantNotFound_Error() {
+	req := &intPkg.MerchantBalanceAsOfRequest{MerchantId: primitive.NewObjectID().Hex()}
+	rsp := &intPkg.MerchantBalanceAsOfResponse{}
+	err := suite.service.GetMerchantBalanceAsOf(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantErrorNotFound, rsp.Message)
+}
+
+func (suite *MerchantBalanceTransactionTestSuite) TestMerchantBalanceTransaction_ListMerchantBalanceTransactions_Ok() {
+	suite.addTransactions()
+
+	req := &intPkg.ListMerchantBalanceTransactionsRequest{MerchantId: suite.merchant.Id}
+	rsp := &intPkg.ListMerchantBalanceTransactionsResponse{}
+	err := suite.service.ListMerchantBalanceTransactions(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.EqualValues(suite.T(), 3, rsp.Count)
+	assert.Len(suite.T(), rsp.Items, 3)
+	assert.EqualValues(suite.T(), 100, rsp.Items[0].Balance)
+	assert.EqualValues(suite.T(), 140, rsp.Items[2].Balance)
+
+	req.Types = []string{pkg.MerchantBalanceTransactionTypePaymentNet}
+	req.Limit = 1
+	err = suite.service.ListMerchantBalanceTransactions(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.EqualValues(suite.T(), 2, rsp.Count)
+	assert.Len(suite.T(), rsp.Items, 1)
+}
+
+func (suite *MerchantBalanceTransactionTestSuite) TestMerchantBalanceTransaction_ListMerchantBalanceTransactions_TypeInvalid_Error() {
+	req := &intPkg.ListMerchantBalanceTransactionsRequest{
+		MerchantId: suite.merchant.Id,
+		Types:      []string{"unknown"},
+	}
+	rsp := &intPkg.ListMerchantBalanceTransactionsResponse{}
+	err := suite.service.ListMerchantBalanceTransactions(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantBalanceTransactionErrorTypeInvalid, rsp.Message)
+}
+
+func (suite *MerchantBalanceTransactionTestSuite) TestMerchantBalanceTransaction_AddMerchantBalanceTransactionsByJournal_Chargeback_Ok() {
+	handler := &accountingEntry{
+		Service: suite.service,
+		ctx:     context.TODO(),
+		refund:  &billingpb.Refund{IsChargeback: true},
+	}
+	journal := &intPkg.LedgerJournal{
+		MerchantId: suite.merchant.Id,
+		SourceId:   primitive.NewObjectID().Hex(),
+		SourceType: pkg.OrderTypeRefund,
+		Lines: []*intPkg.LedgerJournalLine{
+			{AccountCode: pkg.LedgerAccountMerchantPayable, Currency: "USD", Debit: 30},
+			{AccountCode: pkg.LedgerAccountAcquirerReceivable, Currency: "USD", Credit: 30},
+			{AccountCode: pkg.LedgerAccountTaxPayable, Currency: "USD", Debit: 5},
+			{AccountCode: pkg.LedgerAccountMerchantPayable, Currency: "USD", Credit: 5},
+		},
+	}
+
+	err := suite.service.addMerchantBalanceTransactionsByJournal(context.TODO(), handler, accountingEventTypeRefund, journal)
+	assert.NoError(suite.T(), err)
+
+	req := &intPkg.ListMerchantBalanceTransactionsRequest{MerchantId: suite.merchant.Id}
+	rsp := &intPkg.ListMerchantBalanceTransactionsResponse{}
+	err = suite.service.ListMerchantBalanceTransactions(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), rsp.Items, 1)
+	assert.Equal(suite.T(), pkg.MerchantBalanceTransactionTypeChargeback, rsp.Items[0].Type)
+	assert.EqualValues(suite.T(), -25, rsp.Items[0].Amount)
+	assert.Equal(suite.T(), journal.SourceId, rsp.Items[0].SourceId)
+}
+
+func (suite *MerchantBalanceTransactionTestSuite) TestMerchantBalanceTransaction_AddMerchantBalanceTransactionsByJournal_ReserveHold_Ok() {
+	handler := &accountingEntry{Service: suite.service, ctx: context.TODO()}
+	journal := &intPkg.LedgerJournal{
+		MerchantId: suite.merchant.Id,
+		SourceId:   primitive.NewObjectID().Hex(),
+		SourceType: pkg.OrderTypeOrder,
+		Lines: []*intPkg.LedgerJournalLine{
+			{
+				AccountCode: pkg.LedgerAccountMerchantPayable,
+				EntryType:   pkg.AccountingEntryTypeMerchantNetRevenue,
+				Currency:    "USD",
+				Credit:      90,
+			},
+			{
+				AccountCode: pkg.LedgerAccountMerchantPayable,
+				EntryType:   pkg.AccountingEntryTypeMerchantRollingReserveCreate,
+				Currency:    "USD",
+				Debit:       9,
+			},
+			{
+				AccountCode: pkg.LedgerAccountRollingReserve,
+				EntryType:   pkg.AccountingEntryTypeMerchantRollingReserveCreate,
+				Currency:    "USD",
+				Credit:      9,
+			},
+		},
+	}
+
+	err := suite.service.addMerchantBalanceTransactionsByJournal(context.TODO(), handler, accountingEventTypePayment, journal)
+	assert.NoError(suite.T(), err)
+
+	req := &intPkg.ListMerchantBalanceTransactionsRequest{MerchantId: suite.merchant.Id}
+	rsp := &intPkg.ListMerchantBalanceTransactionsResponse{}
+	err = suite.service.ListMerchantBalanceTransactions(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), rsp.Items, 2)
+	assert.Equal(suite.T(), pkg.MerchantBalanceTransactionTypePaymentNet, rsp.Items[0].Type)
+	assert.EqualValues(suite.T(), 90, rsp.Items[0].Amount)
+	assert.Equal(suite.T(), pkg.MerchantBalanceTransactionTypeReserveHold, rsp.Items[1].Type)
+	assert.EqualValues(suite.T(), -9, rsp.Items[1].Amount)
+	assert.Equal(suite.T(), rsp.Items[1].CreatedAt, rsp.Items[1].AvailableAt)
+}
@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"strings"
+)
+
+var (
+	merchantPayoutAccountErrorCurrencyInvalid      = errors.NewBillingServerErrorMsg("pa000001", "currency of payout account is not supported")
+	merchantPayoutAccountErrorCurrencyDefault      = errors.NewBillingServerErrorMsg("pa000002", "payout account in payout currency of merchant is set by banking details of merchant")
+	merchantPayoutAccountErrorBankingRequired      = errors.NewBillingServerErrorMsg("pa000003", "bank name and account number of payout account are required")
+	merchantPayoutAccountErrorOrderCurrencyInvalid = errors.NewBillingServerErrorMsg("pa000004", "order currency of payout account is not supported")
+	merchantPayoutAccountErrorOrderCurrencyRouted  = errors.NewBillingServerErrorMsg("pa000005", "order currency is already routed to other payout account of merchant")
+	merchantPayoutAccountErrorMinAmountInvalid     = errors.NewBillingServerErrorMsg("pa000006", "minimum payout amount can't be negative")
+	merchantPayoutAccountErrorNotFound             = errors.NewBillingServerErrorMsg("pa000007", "payout account of merchant not found")
+	merchantPayoutAccountErrorBalanceNotEmpty      = errors.NewBillingServerErrorMsg("pa000008", "payout account with not paid out balance can't be deleted")
+	merchantPayoutAccountErrorUnknown              = errors.NewBillingServerErrorMsg("pa000009", "unknown error. try request later")
+	merchantPayoutAccountErrorOrdersNotReported    = errors.NewBillingServerErrorMsg("pa000010", "payout account with orders not included to royalty report can't be deleted")
+	merchantPayoutAccountErrorReportsNotPaid       = errors.NewBillingServerErrorMsg("pa000011", "payout account with not paid out royalty reports can't be deleted")
+)
+
+// ListMerchantPayoutAccounts returns payout accounts of the merchant. The payout currency and banking details
+// of the merchant are returned as the first account of the list.
+func (s *Service) ListMerchantPayoutAccounts(
+	ctx context.Context,
+	req *intPkg.ListMerchantPayoutAccountsRequest,
+	rsp *intPkg.ListMerchantPayoutAccountsResponse,
+) error {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	rsp.Items, err = s.getMerchantPayoutAccounts(ctx, merchant)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantPayoutAccountErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// SetMerchantPayoutAccount adds the payout account of the merchant in the currency or changes the existing one.
+// An order currency may be routed to one account of the merchant only.
+func (s *Service) SetMerchantPayoutAccount(
+	ctx context.Context,
+	req *intPkg.MerchantPayoutAccount,
+	rsp *intPkg.MerchantPayoutAccountResponse,
+) error {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	if msg := s.validateMerchantPayoutAccount(merchant, req); msg != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = msg
+		return nil
+	}
+
+	accounts, err := s.merchantPayoutAccountRepository.GetByMerchantId(ctx, merchant.Id)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantPayoutAccountErrorUnknown
+		return nil
+	}
+
+	account := &intPkg.MerchantPayoutAccount{MerchantId: merchant.Id, Currency: req.Currency}
+
+	for _, v := range accounts {
+		if v.Currency == req.Currency {
+			account = v
+			continue
+		}
+
+		for _, currency := range req.OrderCurrencies {
+			if v.Currency == currency || helper.Contains(v.OrderCurrencies, currency) {
+				rsp.Status = billingpb.ResponseStatusBadData
+				rsp.Message = merchantPayoutAccountErrorOrderCurrencyRouted
+				return nil
+			}
+		}
+	}
+
+	account.Banking = req.Banking
+	account.Banking.Currency = req.Currency
+	account.Banking.AccountNumber = strings.Join(strings.Fields(req.Banking.AccountNumber), "")
+	account.OrderCurrencies = req.OrderCurrencies
+	account.MinPayoutAmount = req.MinPayoutAmount
+
+	if err = s.merchantPayoutAccountRepository.Upsert(ctx, account); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantPayoutAccountErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = account
+
+	return nil
+}
+
+// DeleteMerchantPayoutAccount removes the payout account of the merchant in the currency. The account can be
+// removed only after all orders routed to it are included to royalty reports and the reports and the balance
+// are paid out, orders in its currencies are routed to the payout currency of the merchant after removal.
+func (s *Service) DeleteMerchantPayoutAccount(
+	ctx context.Context,
+	req *intPkg.DeleteMerchantPayoutAccountRequest,
+	rsp *intPkg.MerchantPayoutAccountResponse,
+) error {
+	account, err := s.merchantPayoutAccountRepository.GetByMerchantIdAndCurrency(ctx, req.MerchantId, req.Currency)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantPayoutAccountErrorNotFound
+		return nil
+	}
+
+	balance, err := s.merchantBalanceRepository.GetByIdAndCurrency(ctx, account.MerchantId, account.Currency)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantPayoutAccountErrorUnknown
+		return nil
+	}
+
+	if balance != nil && balance.Total != 0 {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = merchantPayoutAccountErrorBalanceNotEmpty
+		return nil
+	}
+
+	merchantOid, _ := primitive.ObjectIDFromHex(account.MerchantId)
+	filter := bson.M{
+		"merchant_id":              merchantOid,
+		"merchant_payout_currency": account.Currency,
+		"status":                   bson.M{"$in": orderStatusForRoyaltyReports},
+		"is_production":            true,
+		"royalty_report_id":        bson.M{"$in": []interface{}{"", nil}},
+	}
+	count, err := s.orderViewRepository.GetCountBy(ctx, filter, options.Count().SetLimit(1))
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantPayoutAccountErrorUnknown
+		return nil
+	}
+
+	if count > 0 {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = merchantPayoutAccountErrorOrdersNotReported
+		return nil
+	}
+
+	reports, err := s.royaltyReportRepository.GetNonPayoutReports(ctx, account.MerchantId, account.Currency)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantPayoutAccountErrorUnknown
+		return nil
+	}
+
+	if len(reports) > 0 {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = merchantPayoutAccountErrorReportsNotPaid
+		return nil
+	}
+
+	if err = s.merchantPayoutAccountRepository.Delete(ctx, account); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantPayoutAccountErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = account
+
+	return nil
+}
+
+func (s *Service) validateMerchantPayoutAccount(
+	merchant *billingpb.Merchant,
+	req *intPkg.MerchantPayoutAccount,
+) *billingpb.ResponseErrorMessage {
+	if !helper.Contains(s.supportedCurrencies, req.Currency) {
+		return merchantPayoutAccountErrorCurrencyInvalid
+	}
+
+	if req.Currency == merchant.GetPayoutCurrency() {
+		return merchantPayoutAccountErrorCurrencyDefault
+	}
+
+	if req.Banking == nil || req.Banking.Name == "" || strings.TrimSpace(req.Banking.AccountNumber) == "" {
+		return merchantPayoutAccountErrorBankingRequired
+	}
+
+	for _, currency := range req.OrderCurrencies {
+		if !helper.Contains(s.supportedCurrencies, currency) {
+			return merchantPayoutAccountErrorOrderCurrencyInvalid
+		}
+
+		if currency == merchant.GetPayoutCurrency() {
+			return merchantPayoutAccountErrorOrderCurrencyRouted
+		}
+	}
+
+	if req.MinPayoutAmount < 0 {
+		return merchantPayoutAccountErrorMinAmountInvalid
+	}
+
+	return nil
+}
+
+// getMerchantPayoutAccounts returns the payout currency and banking details of the merchant as the first account
+// and additional payout accounts of the merchant after it.
+func (s *Service) getMerchantPayoutAccounts(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+) ([]*intPkg.MerchantPayoutAccount, error) {
+	accounts, err := s.merchantPayoutAccountRepository.GetByMerchantId(ctx, merchant.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*intPkg.MerchantPayoutAccount{
+		{
+			MerchantId: merchant.Id,
+			Currency:   merchant.GetPayoutCurrency(),
+			Banking:    merchant.Banking,
+		},
+	}
+
+	for _, v := range accounts {
+		if v.Currency != merchant.GetPayoutCurrency() {
+			result = append(result, v)
+		}
+	}
+
+	return result, nil
+}
+
+// getMerchantPayoutCurrencyByOrderCurrency returns the currency of the payout account of the merchant the order
+// in the currency is routed to. Orders are routed to the payout currency of the merchant if the merchant has no
+// matching account.
+func (s *Service) getMerchantPayoutCurrencyByOrderCurrency(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+	currency string,
+) (string, error) {
+	if currency == "" || currency == merchant.GetPayoutCurrency() {
+		return merchant.GetPayoutCurrency(), nil
+	}
+
+	accounts, err := s.merchantPayoutAccountRepository.GetByMerchantId(ctx, merchant.Id)
+
+	if err != nil {
+		zap.L().Error(
+			"getting payout accounts of merchant failed",
+			zap.Error(err),
+			zap.String("merchant_id", merchant.Id),
+			zap.String("currency", currency),
+		)
+		return "", err
+	}
+
+	for _, v := range accounts {
+		if v.Currency == currency || helper.Contains(v.OrderCurrencies, currency) {
+			return v.Currency, nil
+		}
+	}
+
+	return merchant.GetPayoutCurrency(), nil
+}
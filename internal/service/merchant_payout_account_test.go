@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/internal/repository"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/recurringpb"
+	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (suite *PayoutsTestSuite) helperSetMerchantPayoutAccount(
+	account *intPkg.MerchantPayoutAccount,
+) *intPkg.MerchantPayoutAccountResponse {
+	account.MerchantId = suite.merchant.Id
+	rsp := &intPkg.MerchantPayoutAccountResponse{}
+	err := suite.service.SetMerchantPayoutAccount(context.TODO(), account, rsp)
+	assert.NoError(suite.T(), err)
+
+	return rsp
+}
+
+func (suite *PayoutsTestSuite) helperInsertUsdRoyaltyReport(amount float64) *billingpb.RoyaltyReport {
+	report := &billingpb.RoyaltyReport{
+		Id:         primitive.NewObjectID().Hex(),
+		MerchantId: suite.merchant.Id,
+		Totals: &billingpb.RoyaltyReportTotals{
+			TransactionsCount: 10,
+			PayoutAmount:      amount,
+			FinalPayoutAmount: amount,
+		},
+		Summary: &billingpb.RoyaltyReportSummary{
+			ProductsTotal: &billingpb.RoyaltyReportProductSummaryItem{
+				SalesCount:        10,
+				TotalTransactions: 10,
+				GrossTotalAmount:  amount,
+				PayoutAmount:      amount,
+			},
+		},
+		Status:             billingpb.RoyaltyReportStatusAccepted,
+		CreatedAt:          ptypes.TimestampNow(),
+		PeriodFrom:         suite.dateFrom1,
+		PeriodTo:           suite.dateTo1,
+		AcceptExpireAt:     ptypes.TimestampNow(),
+		Currency:           "USD",
+		OperatingCompanyId: suite.operatingCompany.Id,
+	}
+	suite.helperInsertRoyaltyReports([]*billingpb.RoyaltyReport{report})
+
+	return report
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutAccount_SetMerchantPayoutAccount_Ok() {
+	rsp := suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{
+		Currency:        "USD",
+		Banking:         &billingpb.MerchantBanking{Name: "USD bank", AccountNumber: "US 0001 0002"},
+		OrderCurrencies: []string{"GBP"},
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), "USD", rsp.Item.Banking.Currency)
+	assert.Equal(suite.T(), "US00010002", rsp.Item.Banking.AccountNumber)
+
+	id := rsp.Item.Id
+
+	rsp = suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{
+		Currency:        "USD",
+		Banking:         &billingpb.MerchantBanking{Name: "Other USD bank", AccountNumber: "US0003"},
+		OrderCurrencies: []string{"GBP"},
+		MinPayoutAmount: 500,
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), id, rsp.Item.Id)
+
+	listRsp := &intPkg.ListMerchantPayoutAccountsResponse{}
+	err := suite.service.ListMerchantPayoutAccounts(
+		context.TODO(),
+		&intPkg.ListMerchantPayoutAccountsRequest{MerchantId: suite.merchant.Id},
+		listRsp,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, listRsp.Status)
+	assert.Len(suite.T(), listRsp.Items, 2)
+	assert.Equal(suite.T(), "RUB", listRsp.Items[0].Currency)
+	assert.Equal(suite.T(), suite.merchant.Banking.Name, listRsp.Items[0].Banking.Name)
+	assert.Equal(suite.T(), "USD", listRsp.Items[1].Currency)
+	assert.Equal(suite.T(), "Other USD bank", listRsp.Items[1].Banking.Name)
+	assert.EqualValues(suite.T(), 500, listRsp.Items[1].MinPayoutAmount)
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutAccount_SetMerchantPayoutAccount_ValidationErrors() {
+	banking := &billingpb.MerchantBanking{Name: "Bank", AccountNumber: "0001"}
+
+	rsp := suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{Currency: "XXX", Banking: banking})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutAccountErrorCurrencyInvalid, rsp.Message)
+
+	rsp = suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{Currency: "RUB", Banking: banking})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutAccountErrorCurrencyDefault, rsp.Message)
+
+	rsp = suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{Currency: "USD"})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutAccountErrorBankingRequired, rsp.Message)
+
+	rsp = suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{
+		Currency:        "USD",
+		Banking:         banking,
+		OrderCurrencies: []string{"XXX"},
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutAccountErrorOrderCurrencyInvalid, rsp.Message)
+
+	rsp = suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{
+		Currency:        "USD",
+		Banking:         banking,
+		OrderCurrencies: []string{"RUB"},
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutAccountErrorOrderCurrencyRouted, rsp.Message)
+
+	rsp = suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{
+		Currency:        "USD",
+		Banking:         banking,
+		MinPayoutAmount: -1,
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutAccountErrorMinAmountInvalid, rsp.Message)
+
+	rsp = suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{
+		Currency:        "USD",
+		Banking:         banking,
+		OrderCurrencies: []string{"GBP"},
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	rsp = suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{
+		Currency:        "EUR",
+		Banking:         &billingpb.MerchantBanking{Name: "Bank", AccountNumber: "0002"},
+		OrderCurrencies: []string{"GBP"},
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutAccountErrorOrderCurrencyRouted, rsp.Message)
+
+	rsp = suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{
+		Currency:        "EUR",
+		Banking:         &billingpb.MerchantBanking{Name: "Bank", AccountNumber: "0002"},
+		OrderCurrencies: []string{"USD"},
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutAccountErrorOrderCurrencyRouted, rsp.Message)
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutAccount_getMerchantPayoutCurrencyByOrderCurrency() {
+	rsp := suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{
+		Currency:        "USD",
+		Banking:         &billingpb.MerchantBanking{Name: "USD bank", AccountNumber: "0001"},
+		OrderCurrencies: []string{"GBP"},
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	routes := map[string]string{
+		"USD": "USD",
+		"GBP": "USD",
+		"EUR": "RUB",
+		"RUB": "RUB",
+		"":    "RUB",
+	}
+
+	for orderCurrency, payoutCurrency := range routes {
+		currency, err := suite.service.getMerchantPayoutCurrencyByOrderCurrency(context.TODO(), suite.merchant, orderCurrency)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), payoutCurrency, currency, orderCurrency)
+	}
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutAccount_getMerchantPayoutCurrencyByOrderCurrency_Error() {
+	accountRep := &mocks.MerchantPayoutAccountRepositoryInterface{}
+	accountRep.On("GetByMerchantId", mock2.Anything, mock2.Anything).Return(nil, errors.New("some error"))
+	suite.service.merchantPayoutAccountRepository = accountRep
+
+	currency, err := suite.service.getMerchantPayoutCurrencyByOrderCurrency(context.TODO(), suite.merchant, "USD")
+	assert.Error(suite.T(), err)
+	assert.Empty(suite.T(), currency)
+
+	currency, err = suite.service.getMerchantPayoutCurrencyByOrderCurrency(context.TODO(), suite.merchant, "RUB")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "RUB", currency)
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutAccount_CreatePayoutDocument_PerAccount() {
+	reporting := &reportingMocks.ReporterService{}
+	reporting.On("CreateFile", mock2.Anything, mock2.Anything).Return(nil, nil)
+	suite.service.reporterService = reporting
+
+	rsp := suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{
+		Currency: "USD",
+		Banking:  &billingpb.MerchantBanking{Name: "USD bank", AccountNumber: "0001"},
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	suite.helperSetMerchantPayoutSchedule(&intPkg.MerchantPayoutSchedule{MinPayoutAmount: 1000})
+	suite.helperInsertRoyaltyReports([]*billingpb.RoyaltyReport{suite.report1})
+	usdReport := suite.helperInsertUsdRoyaltyReport(100)
+
+	_, err := suite.service.updateMerchantBalance(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+
+	balancesRsp := &intPkg.ListMerchantBalancesResponse{}
+	err = suite.service.ListMerchantBalances(
+		context.TODO(),
+		&intPkg.ListMerchantBalancesRequest{MerchantId: suite.merchant.Id},
+		balancesRsp,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, balancesRsp.Status)
+	assert.Len(suite.T(), balancesRsp.Items, 2)
+	assert.Equal(suite.T(), "RUB", balancesRsp.Items[0].Currency)
+	assert.Equal(suite.T(), "USD", balancesRsp.Items[1].Currency)
+	assert.EqualValues(suite.T(), 100, balancesRsp.Items[1].Debit)
+
+	req := &billingpb.CreatePayoutDocumentRequest{
+		MerchantId:  suite.merchant.Id,
+		Description: "test payout",
+		Ip:          "127.0.0.1",
+	}
+	res := &billingpb.CreatePayoutDocumentResponse{}
+	err = suite.service.CreatePayoutDocument(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Len(suite.T(), res.Items, 2)
+
+	assert.Equal(suite.T(), "RUB", res.Items[0].Currency)
+	assert.Equal(suite.T(), suite.merchant.Banking.Name, res.Items[0].Destination.Name)
+	assert.Equal(suite.T(), []string{suite.report1.Id}, res.Items[0].SourceId)
+
+	assert.Equal(suite.T(), "USD", res.Items[1].Currency)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPending, res.Items[1].Status)
+	assert.Equal(suite.T(), "USD bank", res.Items[1].Destination.Name)
+	assert.Equal(suite.T(), []string{usdReport.Id}, res.Items[1].SourceId)
+	assert.EqualValues(suite.T(), 100, res.Items[1].Balance)
+
+	rr, err := suite.service.royaltyReportRepository.GetById(context.TODO(), usdReport.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), res.Items[1].Id, rr.PayoutDocumentId)
+
+	res = &billingpb.CreatePayoutDocumentResponse{}
+	err = suite.service.CreatePayoutDocument(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorPayoutSourcesNotFound, res.Message)
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutAccount_DeleteMerchantPayoutAccount() {
+	banking := &billingpb.MerchantBanking{Name: "Bank", AccountNumber: "0001"}
+	suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{Currency: "USD", Banking: banking})
+	suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{Currency: "EUR", Banking: banking})
+	suite.helperInsertUsdRoyaltyReport(100)
+
+	_, err := suite.service.updateMerchantBalance(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+
+	req := &intPkg.DeleteMerchantPayoutAccountRequest{MerchantId: suite.merchant.Id, Currency: "USD"}
+	rsp := &intPkg.MerchantPayoutAccountResponse{}
+	err = suite.service.DeleteMerchantPayoutAccount(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutAccountErrorBalanceNotEmpty, rsp.Message)
+
+	req.Currency = "GBP"
+	rsp = &intPkg.MerchantPayoutAccountResponse{}
+	err = suite.service.DeleteMerchantPayoutAccount(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutAccountErrorNotFound, rsp.Message)
+
+	req.Currency = "EUR"
+	rsp = &intPkg.MerchantPayoutAccountResponse{}
+	err = suite.service.DeleteMerchantPayoutAccount(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	accounts, err := suite.service.merchantPayoutAccountRepository.GetByMerchantId(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), accounts, 1)
+	assert.Equal(suite.T(), "USD", accounts[0].Currency)
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutAccount_DeleteMerchantPayoutAccount_NotReported() {
+	banking := &billingpb.MerchantBanking{Name: "Bank", AccountNumber: "0001"}
+	suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{Currency: "USD", Banking: banking})
+	suite.helperSetMerchantPayoutAccount(&intPkg.MerchantPayoutAccount{Currency: "EUR", Banking: banking})
+
+	merchantOid, _ := primitive.ObjectIDFromHex(suite.merchant.Id)
+	_, err := suite.service.db.Collection(repository.CollectionOrderView).InsertOne(context.TODO(), bson.M{
+		"_id":                      primitive.NewObjectID(),
+		"merchant_id":              merchantOid,
+		"merchant_payout_currency": "USD",
+		"status":                   recurringpb.OrderPublicStatusProcessed,
+		"is_production":            true,
+	})
+	assert.NoError(suite.T(), err)
+
+	req := &intPkg.DeleteMerchantPayoutAccountRequest{MerchantId: suite.merchant.Id, Currency: "USD"}
+	rsp := &intPkg.MerchantPayoutAccountResponse{}
+	err = suite.service.DeleteMerchantPayoutAccount(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutAccountErrorOrdersNotReported, rsp.Message)
+
+	report := suite.helperInsertUsdRoyaltyReport(0)
+	report.Id = primitive.NewObjectID().Hex()
+	report.Currency = "EUR"
+	report.Status = billingpb.RoyaltyReportStatusPending
+	suite.helperInsertRoyaltyReports([]*billingpb.RoyaltyReport{report})
+
+	req.Currency = "EUR"
+	rsp = &intPkg.MerchantPayoutAccountResponse{}
+	err = suite.service.DeleteMerchantPayoutAccount(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutAccountErrorReportsNotPaid, rsp.Message)
+
+	accounts, err := suite.service.merchantPayoutAccountRepository.GetByMerchantId(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), accounts, 2)
+}
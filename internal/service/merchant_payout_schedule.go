@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"github.com/jinzhu/now"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+const (
+	merchantPayoutScheduleDaysInWeek = 7
+	merchantPayoutScheduleMaxDay     = 31
+)
+
+var (
+	merchantPayoutScheduleErrorFrequencyInvalid = errors.NewBillingServerErrorMsg("ps000001", "payout schedule frequency is invalid")
+	merchantPayoutScheduleErrorWeekDayInvalid   = errors.NewBillingServerErrorMsg("ps000002", "payout schedule week day must be between 0 (sunday) and 6 (saturday)")
+	merchantPayoutScheduleErrorMonthDayInvalid  = errors.NewBillingServerErrorMsg("ps000003", "payout schedule day of month must be between 1 and 31")
+	merchantPayoutScheduleErrorMinAmountInvalid = errors.NewBillingServerErrorMsg("ps000004", "minimum payout amount can't be negative")
+	merchantPayoutScheduleErrorUnknown          = errors.NewBillingServerErrorMsg("ps000005", "unknown error. try request later")
+)
+
+// GetMerchantPayoutSchedule returns the payout schedule of the merchant.
+// Merchants without schedule receive payouts on every run of the auto-payout task with the minimum payout amount
+// of the merchant, that is the returned schedule has empty frequency.
+func (s *Service) GetMerchantPayoutSchedule(
+	ctx context.Context,
+	req *intPkg.GetMerchantPayoutScheduleRequest,
+	rsp *intPkg.MerchantPayoutScheduleResponse,
+) error {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	schedule, err := s.getMerchantPayoutSchedule(ctx, merchant.Id)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantPayoutScheduleErrorUnknown
+		return nil
+	}
+
+	if schedule == nil {
+		schedule = &intPkg.MerchantPayoutSchedule{
+			MerchantId:      merchant.Id,
+			MinPayoutAmount: merchant.MinPayoutAmount,
+		}
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = schedule
+
+	return nil
+}
+
+// SetMerchantPayoutSchedule changes the payout frequency, the minimum payout amount and the hold of payouts
+// of the merchant. The date of the last run of the auto-payout task for the merchant is kept.
+func (s *Service) SetMerchantPayoutSchedule(
+	ctx context.Context,
+	req *intPkg.MerchantPayoutSchedule,
+	rsp *intPkg.MerchantPayoutScheduleResponse,
+) error {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	if msg := validateMerchantPayoutSchedule(req); msg != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = msg
+		return nil
+	}
+
+	schedule, err := s.getMerchantPayoutSchedule(ctx, merchant.Id)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantPayoutScheduleErrorUnknown
+		return nil
+	}
+
+	if schedule == nil {
+		schedule = &intPkg.MerchantPayoutSchedule{MerchantId: merchant.Id}
+	}
+
+	schedule.Frequency = req.Frequency
+	schedule.WeekDay = req.WeekDay
+	schedule.MonthDay = req.MonthDay
+	schedule.MinPayoutAmount = req.MinPayoutAmount
+	schedule.Hold = req.Hold
+	schedule.HoldReason = req.HoldReason
+
+	if !schedule.Hold {
+		schedule.HoldReason = ""
+	}
+
+	if err = s.merchantPayoutScheduleRepository.Upsert(ctx, schedule); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantPayoutScheduleErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = schedule
+
+	return nil
+}
+
+// getMerchantPayoutSchedule returns the payout schedule of the merchant or nil if the merchant has no schedule.
+func (s *Service) getMerchantPayoutSchedule(
+	ctx context.Context,
+	merchantId string,
+) (*intPkg.MerchantPayoutSchedule, error) {
+	schedule, err := s.merchantPayoutScheduleRepository.GetByMerchantId(ctx, merchantId)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// getMerchantMinPayoutAmount returns the minimum payout amount of the merchant.
+// The amount of the schedule takes precedence over the amount of the merchant.
+func getMerchantMinPayoutAmount(merchant *billingpb.Merchant, schedule *intPkg.MerchantPayoutSchedule) float64 {
+	if schedule != nil && schedule.MinPayoutAmount > 0 {
+		return schedule.MinPayoutAmount
+	}
+
+	return merchant.MinPayoutAmount
+}
+
+func validateMerchantPayoutSchedule(schedule *intPkg.MerchantPayoutSchedule) *billingpb.ResponseErrorMessage {
+	switch schedule.Frequency {
+	case "":
+		schedule.WeekDay = 0
+		schedule.MonthDay = 0
+		break
+
+	case pkg.MerchantPayoutScheduleFrequencyWeekly:
+		if schedule.WeekDay < int32(time.Sunday) || schedule.WeekDay > int32(time.Saturday) {
+			return merchantPayoutScheduleErrorWeekDayInvalid
+		}
+		schedule.MonthDay = 0
+		break
+
+	case pkg.MerchantPayoutScheduleFrequencyMonthly:
+		schedule.WeekDay = 0
+		schedule.MonthDay = 0
+		break
+
+	case pkg.MerchantPayoutScheduleFrequencyDayOfMonth:
+		if schedule.MonthDay < 1 || schedule.MonthDay > merchantPayoutScheduleMaxDay {
+			return merchantPayoutScheduleErrorMonthDayInvalid
+		}
+		schedule.WeekDay = 0
+		break
+
+	default:
+		return merchantPayoutScheduleErrorFrequencyInvalid
+	}
+
+	if schedule.MinPayoutAmount < 0 {
+		return merchantPayoutScheduleErrorMinAmountInvalid
+	}
+
+	return nil
+}
+
+// isMerchantPayoutDue checks that the payout of the merchant must be created at the date.
+// The payout is due if the auto-payout task didn't run for the merchant since the last payout day of the schedule,
+// so payout days missed by the task are caught up on the next run.
+func isMerchantPayoutDue(schedule *intPkg.MerchantPayoutSchedule, date time.Time) bool {
+	if schedule == nil {
+		return true
+	}
+
+	if schedule.Hold {
+		return false
+	}
+
+	if schedule.Frequency == "" {
+		return true
+	}
+
+	return schedule.LastRunAt.Before(getMerchantPayoutScheduleDate(schedule, date))
+}
+
+// getMerchantPayoutScheduleDate returns the beginning of the last payout day of the schedule before or at the date.
+func getMerchantPayoutScheduleDate(schedule *intPkg.MerchantPayoutSchedule, date time.Time) time.Time {
+	day := now.New(date.Local()).BeginningOfDay()
+
+	switch schedule.Frequency {
+	case pkg.MerchantPayoutScheduleFrequencyMonthly:
+		return now.New(day).BeginningOfMonth()
+
+	case pkg.MerchantPayoutScheduleFrequencyDayOfMonth:
+		payoutDay := getMerchantPayoutScheduleMonthDay(schedule, day)
+
+		if day.Before(payoutDay) {
+			payoutDay = getMerchantPayoutScheduleMonthDay(schedule, now.New(day).BeginningOfMonth().AddDate(0, -1, 0))
+		}
+
+		return payoutDay
+	}
+
+	days := (int(day.Weekday()) - int(schedule.WeekDay) + merchantPayoutScheduleDaysInWeek) % merchantPayoutScheduleDaysInWeek
+
+	return day.AddDate(0, 0, -days)
+}
+
+// getMerchantPayoutScheduleMonthDay returns the payout day of the schedule in the month of the date.
+// The last day of month is used for months shorter than the day of the schedule.
+func getMerchantPayoutScheduleMonthDay(schedule *intPkg.MerchantPayoutSchedule, date time.Time) time.Time {
+	month := now.New(date).BeginningOfMonth()
+	day := int(schedule.MonthDay)
+
+	if last := now.New(month).EndOfMonth().Day(); day > last {
+		day = last
+	}
+
+	return month.AddDate(0, 0, day-1)
+}
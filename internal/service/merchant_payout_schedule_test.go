@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"time"
+)
+
+func (suite *PayoutsTestSuite) helperSetMerchantPayoutSchedule(
+	schedule *intPkg.MerchantPayoutSchedule,
+) *intPkg.MerchantPayoutScheduleResponse {
+	schedule.MerchantId = suite.merchant.Id
+	rsp := &intPkg.MerchantPayoutScheduleResponse{}
+	err := suite.service.SetMerchantPayoutSchedule(context.TODO(), schedule, rsp)
+	assert.NoError(suite.T(), err)
+
+	return rsp
+}
+
+func (suite *PayoutsTestSuite) helperEnableAutoPayouts() {
+	reporting := &reportingMocks.ReporterService{}
+	reporting.On("CreateFile", mock2.Anything, mock2.Anything).Return(nil, nil)
+	suite.service.reporterService = reporting
+
+	suite.merchant.ManualPayoutsEnabled = false
+	err := suite.service.merchantRepository.Update(context.TODO(), suite.merchant)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *PayoutsTestSuite) helperCountPayoutDocuments() int64 {
+	count, err := suite.service.payoutRepository.FindCount(
+		context.TODO(),
+		&billingpb.GetPayoutDocumentsRequest{MerchantId: suite.merchant.Id},
+	)
+	assert.NoError(suite.T(), err)
+
+	return count
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutSchedule_GetMerchantPayoutSchedule_Default() {
+	rsp := &intPkg.MerchantPayoutScheduleResponse{}
+	err := suite.service.GetMerchantPayoutSchedule(
+		context.TODO(),
+		&intPkg.GetMerchantPayoutScheduleRequest{MerchantId: suite.merchant.Id},
+		rsp,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Empty(suite.T(), rsp.Item.Frequency)
+	assert.Equal(suite.T(), suite.merchant.MinPayoutAmount, rsp.Item.MinPayoutAmount)
+	assert.False(suite.T(), rsp.Item.Hold)
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutSchedule_SetMerchantPayoutSchedule_Ok() {
+	rsp := suite.helperSetMerchantPayoutSchedule(&intPkg.MerchantPayoutSchedule{
+		Frequency:       pkg.MerchantPayoutScheduleFrequencyDayOfMonth,
+		WeekDay:         3,
+		MonthDay:        15,
+		MinPayoutAmount: 500,
+		HoldReason:      "not applied without hold",
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.EqualValues(suite.T(), 0, rsp.Item.WeekDay)
+	assert.EqualValues(suite.T(), 15, rsp.Item.MonthDay)
+	assert.Empty(suite.T(), rsp.Item.HoldReason)
+
+	rsp = suite.helperSetMerchantPayoutSchedule(&intPkg.MerchantPayoutSchedule{
+		Frequency:  pkg.MerchantPayoutScheduleFrequencyWeekly,
+		WeekDay:    int32(time.Friday),
+		Hold:       true,
+		HoldReason: "KYC review",
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	getRsp := &intPkg.MerchantPayoutScheduleResponse{}
+	err := suite.service.GetMerchantPayoutSchedule(
+		context.TODO(),
+		&intPkg.GetMerchantPayoutScheduleRequest{MerchantId: suite.merchant.Id},
+		getRsp,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), rsp.Item.Id, getRsp.Item.Id)
+	assert.Equal(suite.T(), pkg.MerchantPayoutScheduleFrequencyWeekly, getRsp.Item.Frequency)
+	assert.EqualValues(suite.T(), time.Friday, getRsp.Item.WeekDay)
+	assert.EqualValues(suite.T(), 0, getRsp.Item.MonthDay)
+	assert.True(suite.T(), getRsp.Item.Hold)
+	assert.Equal(suite.T(), "KYC review", getRsp.Item.HoldReason)
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutSchedule_SetMerchantPayoutSchedule_ValidationErrors() {
+	rsp := suite.helperSetMerchantPayoutSchedule(&intPkg.MerchantPayoutSchedule{Frequency: "daily"})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutScheduleErrorFrequencyInvalid, rsp.Message)
+
+	rsp = suite.helperSetMerchantPayoutSchedule(&intPkg.MerchantPayoutSchedule{
+		Frequency: pkg.MerchantPayoutScheduleFrequencyWeekly,
+		WeekDay:   7,
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutScheduleErrorWeekDayInvalid, rsp.Message)
+
+	rsp = suite.helperSetMerchantPayoutSchedule(&intPkg.MerchantPayoutSchedule{
+		Frequency: pkg.MerchantPayoutScheduleFrequencyDayOfMonth,
+		MonthDay:  0,
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutScheduleErrorMonthDayInvalid, rsp.Message)
+
+	rsp = suite.helperSetMerchantPayoutSchedule(&intPkg.MerchantPayoutSchedule{
+		Frequency:       pkg.MerchantPayoutScheduleFrequencyMonthly,
+		MinPayoutAmount: -1,
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantPayoutScheduleErrorMinAmountInvalid, rsp.Message)
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutSchedule_getMerchantPayoutScheduleDate() {
+	date := time.Date(2021, time.March, 10, 15, 0, 0, 0, time.Local)
+
+	schedule := &intPkg.MerchantPayoutSchedule{
+		Frequency: pkg.MerchantPayoutScheduleFrequencyWeekly,
+		WeekDay:   int32(time.Monday),
+	}
+	assert.Equal(suite.T(), time.Date(2021, time.March, 8, 0, 0, 0, 0, time.Local), getMerchantPayoutScheduleDate(schedule, date))
+
+	schedule.WeekDay = int32(time.Wednesday)
+	assert.Equal(suite.T(), time.Date(2021, time.March, 10, 0, 0, 0, 0, time.Local), getMerchantPayoutScheduleDate(schedule, date))
+
+	schedule = &intPkg.MerchantPayoutSchedule{Frequency: pkg.MerchantPayoutScheduleFrequencyMonthly}
+	assert.Equal(suite.T(), time.Date(2021, time.March, 1, 0, 0, 0, 0, time.Local), getMerchantPayoutScheduleDate(schedule, date))
+
+	schedule = &intPkg.MerchantPayoutSchedule{Frequency: pkg.MerchantPayoutScheduleFrequencyDayOfMonth, MonthDay: 5}
+	assert.Equal(suite.T(), time.Date(2021, time.March, 5, 0, 0, 0, 0, time.Local), getMerchantPayoutScheduleDate(schedule, date))
+
+	// the payout day of shorter months is the last day of month
+	schedule.MonthDay = 31
+	assert.Equal(suite.T(), time.Date(2021, time.February, 28, 0, 0, 0, 0, time.Local), getMerchantPayoutScheduleDate(schedule, date))
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutSchedule_isMerchantPayoutDue() {
+	date := time.Date(2021, time.March, 10, 15, 0, 0, 0, time.Local)
+
+	assert.True(suite.T(), isMerchantPayoutDue(nil, date))
+	assert.True(suite.T(), isMerchantPayoutDue(&intPkg.MerchantPayoutSchedule{LastRunAt: date}, date))
+	assert.False(suite.T(), isMerchantPayoutDue(&intPkg.MerchantPayoutSchedule{Hold: true}, date))
+
+	schedule := &intPkg.MerchantPayoutSchedule{
+		Frequency: pkg.MerchantPayoutScheduleFrequencyWeekly,
+		WeekDay:   int32(time.Monday),
+	}
+	assert.True(suite.T(), isMerchantPayoutDue(schedule, date))
+
+	schedule.LastRunAt = time.Date(2021, time.March, 8, 3, 0, 0, 0, time.Local)
+	assert.False(suite.T(), isMerchantPayoutDue(schedule, date))
+
+	// missed payout day is caught up on the next run
+	schedule.LastRunAt = time.Date(2021, time.March, 7, 3, 0, 0, 0, time.Local)
+	assert.True(suite.T(), isMerchantPayoutDue(schedule, date))
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutSchedule_CreatePayoutDocument_Hold() {
+	suite.helperInsertRoyaltyReports([]*billingpb.RoyaltyReport{suite.report1, suite.report2})
+	suite.helperSetMerchantPayoutSchedule(&intPkg.MerchantPayoutSchedule{Hold: true, HoldReason: "KYC review"})
+
+	req := &billingpb.CreatePayoutDocumentRequest{
+		MerchantId:  suite.merchant.Id,
+		Description: "test payout",
+		Ip:          "127.0.0.1",
+	}
+	res := &billingpb.CreatePayoutDocumentResponse{}
+	err := suite.service.CreatePayoutDocument(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorPayoutMerchantOnHold, res.Message)
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutSchedule_CreatePayoutDocument_CarryOver() {
+	reporting := &reportingMocks.ReporterService{}
+	reporting.On("CreateFile", mock2.Anything, mock2.Anything).Return(nil, nil)
+	suite.service.reporterService = reporting
+
+	suite.helperInsertRoyaltyReports([]*billingpb.RoyaltyReport{suite.report2})
+
+	_, err := suite.service.updateMerchantBalance(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+
+	req := &billingpb.CreatePayoutDocumentRequest{
+		MerchantId:  suite.merchant.Id,
+		Description: "test payout",
+		Ip:          "127.0.0.1",
+	}
+	res := &billingpb.CreatePayoutDocumentResponse{}
+	err = suite.service.CreatePayoutDocument(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusSkip, res.Items[0].Status)
+	assert.Equal(suite.T(), pkg.PayoutSkipReasonBelowMinimum, res.Items[0].FailureCode)
+	assert.Contains(suite.T(), res.Items[0].FailureMessage, "1234.50 RUB")
+	assert.Contains(suite.T(), res.Items[0].FailureMessage, "13000.00 RUB")
+
+	rr, err := suite.service.royaltyReportRepository.GetById(context.TODO(), suite.report2.Id)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), rr.PayoutDocumentId)
+
+	balance, err := suite.service.getMerchantBalance(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1234.5, balance.Total)
+
+	suite.helperSetMerchantPayoutSchedule(&intPkg.MerchantPayoutSchedule{MinPayoutAmount: 1000})
+
+	res = &billingpb.CreatePayoutDocumentResponse{}
+	err = suite.service.CreatePayoutDocument(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPending, res.Items[0].Status)
+	assert.Equal(suite.T(), []string{suite.report2.Id}, res.Items[0].SourceId)
+	assert.EqualValues(suite.T(), 1234.5, res.Items[0].Balance)
+	assert.Empty(suite.T(), res.Items[0].FailureCode)
+
+	rr, err = suite.service.royaltyReportRepository.GetById(context.TODO(), suite.report2.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), res.Items[0].Id, rr.PayoutDocumentId)
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutSchedule_AutoCreatePayoutDocuments_Schedule() {
+	suite.helperEnableAutoPayouts()
+	suite.helperInsertRoyaltyReports([]*billingpb.RoyaltyReport{suite.report1, suite.report2})
+
+	_, err := suite.service.updateMerchantBalance(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+
+	suite.helperSetMerchantPayoutSchedule(&intPkg.MerchantPayoutSchedule{
+		Frequency: pkg.MerchantPayoutScheduleFrequencyWeekly,
+		WeekDay:   int32(time.Now().Weekday()),
+	})
+
+	err = suite.service.AutoCreatePayoutDocuments(context.TODO(), &billingpb.EmptyRequest{}, &billingpb.EmptyResponse{})
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, suite.helperCountPayoutDocuments())
+
+	schedule, err := suite.service.merchantPayoutScheduleRepository.GetByMerchantId(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), schedule.LastRunAt.IsZero())
+
+	// payout for the current payout day is already created
+	err = suite.service.AutoCreatePayoutDocuments(context.TODO(), &billingpb.EmptyRequest{}, &billingpb.EmptyResponse{})
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, suite.helperCountPayoutDocuments())
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutSchedule_AutoCreatePayoutDocuments_Hold() {
+	suite.helperEnableAutoPayouts()
+	suite.helperInsertRoyaltyReports([]*billingpb.RoyaltyReport{suite.report1, suite.report2})
+
+	_, err := suite.service.updateMerchantBalance(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+
+	suite.helperSetMerchantPayoutSchedule(&intPkg.MerchantPayoutSchedule{Hold: true})
+
+	err = suite.service.AutoCreatePayoutDocuments(context.TODO(), &billingpb.EmptyRequest{}, &billingpb.EmptyResponse{})
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 0, suite.helperCountPayoutDocuments())
+}
+
+func (suite *PayoutsTestSuite) TestMerchantPayoutSchedule_AutoCreatePayoutDocuments_CarryOverBelowTariff() {
+	suite.helperEnableAutoPayouts()
+	suite.report2.Totals.PayoutAmount = 10
+	suite.report2.Totals.FinalPayoutAmount = 10
+	suite.helperInsertRoyaltyReports([]*billingpb.RoyaltyReport{suite.report2})
+
+	_, err := suite.service.updateMerchantBalance(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+
+	suite.helperSetMerchantPayoutSchedule(&intPkg.MerchantPayoutSchedule{MinPayoutAmount: 1})
+
+	err = suite.service.AutoCreatePayoutDocuments(context.TODO(), &billingpb.EmptyRequest{}, &billingpb.EmptyResponse{})
+	assert.NoError(suite.T(), err)
+
+	payouts, err := suite.service.payoutRepository.Find(
+		context.TODO(),
+		&billingpb.GetPayoutDocumentsRequest{MerchantId: suite.merchant.Id, Limit: 10},
+	)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), payouts, 1)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusSkip, payouts[0].Status)
+	assert.Equal(suite.T(), pkg.PayoutSkipReasonBelowMinimum, payouts[0].FailureCode)
+	assert.Contains(suite.T(), payouts[0].FailureMessage, "50.00 RUB")
+
+	// skipped payout of the same reports is stored once
+	err = suite.service.AutoCreatePayoutDocuments(context.TODO(), &billingpb.EmptyRequest{}, &billingpb.EmptyResponse{})
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, suite.helperCountPayoutDocuments())
+}
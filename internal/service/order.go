@@ -24,7 +24,6 @@ import (
 	"github.com/paysuper/paysuper-proto/go/currenciespb"
 	"github.com/paysuper/paysuper-proto/go/postmarkpb"
 	"github.com/paysuper/paysuper-proto/go/recurringpb"
-	"github.com/paysuper/paysuper-proto/go/taxpb"
 	tools "github.com/paysuper/paysuper-tools/number"
 	stringTools "github.com/paysuper/paysuper-tools/string"
 	"github.com/streadway/amqp"
@@ -191,7 +190,10 @@ type PaymentCreateProcessor struct {
 	ip             string
 	acceptLanguage string
 	userAgent      string
-	checked        struct {
+	// initiator and initialTransactionId are set only for the saved card charges requested by merchant
+	initiator            string
+	initialTransactionId string
+	checked              struct {
 		order         *billingpb.Order
 		project       *billingpb.Project
 		paymentMethod *billingpb.PaymentMethod
@@ -948,7 +950,19 @@ func (s *Service) PaymentCreateProcess(
 		return nil
 	}
 
-	err = processor.processPaymentFormData(ctx)
+	return s.processPaymentCreate(ctx, processor, decryptedBrowserCustomer, req, rsp)
+}
+
+// processPaymentCreate creates payment in payment system by payment form data.
+// Browser customer is empty for server-to-server payment requests, so cookie isn't generated for them.
+func (s *Service) processPaymentCreate(
+	ctx context.Context,
+	processor *PaymentCreateProcessor,
+	decryptedBrowserCustomer *BrowserCookieCustomer,
+	req *billingpb.PaymentCreateRequest,
+	rsp *billingpb.PaymentCreateResponse,
+) error {
+	err := processor.processPaymentFormData(ctx)
 	if err != nil {
 		zap.S().Errorw(pkg.MethodFinishedWithError, "err", err.Error())
 		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
@@ -961,19 +975,21 @@ func (s *Service) PaymentCreateProcess(
 
 	order := processor.checked.order
 
-	decryptedBrowserCustomer.CustomerId = order.User.Id
-	cookie, err := s.generateBrowserCookie(decryptedBrowserCustomer)
+	if decryptedBrowserCustomer != nil {
+		decryptedBrowserCustomer.CustomerId = order.User.Id
+		cookie, err := s.generateBrowserCookie(decryptedBrowserCustomer)
 
-	if err != nil {
-		zap.S().Errorw(pkg.MethodFinishedWithError, "err", err.Error())
-		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
-			rsp.Status = billingpb.ResponseStatusBadData
-			rsp.Message = e
-			return nil
+		if err != nil {
+			zap.S().Errorw(pkg.MethodFinishedWithError, "err", err.Error())
+			if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+				rsp.Status = billingpb.ResponseStatusBadData
+				rsp.Message = e
+				return nil
+			}
 		}
-	}
 
-	rsp.Cookie = cookie
+		rsp.Cookie = cookie
+	}
 
 	if !order.CountryRestriction.PaymentsAllowed {
 		rsp.Message = orderCountryPaymentRestrictedError
@@ -1089,6 +1105,11 @@ func (s *Service) PaymentCreateProcess(
 		delete(order.PaymentRequisites, billingpb.PaymentCreateFieldRecurringId)
 	}
 
+	if initiator, ok := order.PaymentRequisites[pkg.PaymentCreateFieldInitiator]; ok {
+		req.Data[pkg.PaymentCreateFieldInitiator] = initiator
+		req.Data[pkg.PaymentCreateFieldInitialTransactionId] = order.PaymentRequisites[pkg.PaymentCreateFieldInitialTransactionId]
+	}
+
 	merchant, err := s.merchantRepository.GetById(ctx, order.GetMerchantId())
 	if err != nil {
 		return merchantErrorNotFound
@@ -1106,6 +1127,16 @@ func (s *Service) PaymentCreateProcess(
 		return err
 	}
 
+	// currency of the order is final on payment, the order is routed to the payout account of the merchant by it
+	order.Project.MerchantRoyaltyCurrency, err = s.getMerchantPayoutCurrencyByOrderCurrency(ctx, merchant, order.Currency)
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantPayoutAccountErrorUnknown
+		return nil
+	}
+
+	s.collectOrderTaxEvidence(ctx, order)
+
 	err = s.updateOrder(ctx, order)
 
 	if err != nil {
@@ -1407,6 +1438,8 @@ func (s *Service) PaymentCallbackProcess(
 		return err
 	}
 
+	s.updateSavedCardChargeStatus(ctx, order)
+
 	if pErr == nil {
 		if h.IsSubscriptionCallback(data) && subscription != nil {
 			if order.PrivateStatus != recurringpb.OrderStatusPaymentSystemComplete {
@@ -1445,6 +1478,10 @@ func (s *Service) PaymentCallbackProcess(
 
 					return errors.New(subscriptionUpdateFailed)
 				}
+
+				if order.PaymentMethod.IsBankCard() {
+					s.trackCardExpiry(ctx, order, subscription.CardpaySubscriptionId, subscription.Id)
+				}
 			}
 		}
 
@@ -1936,6 +1973,9 @@ func (s *Service) saveRecurringCard(ctx context.Context, order *billingpb.Order,
 				zap.Error(err),
 			)
 		}
+
+		s.trackCardExpiry(ctx, order, recurringId, "")
+		s.storeInitialCredential(ctx, order, recurringId)
 	}
 }
 
@@ -2195,6 +2235,23 @@ func (s *Service) getPayloadForReceipt(ctx context.Context, order *billingpb.Ord
 		fields["vat"] = vat
 	}
 
+	if vatId := getOrderReverseChargeVatId(order); vatId != "" {
+		fields["reverseCharge"] = &structpb.Value{
+			Kind: &structpb.Value_StructValue{
+				StructValue: &structpb.Struct{
+					Fields: map[string]*structpb.Value{
+						"vatId": {
+							Kind: &structpb.Value_StringValue{StringValue: vatId},
+						},
+						"note": {
+							Kind: &structpb.Value_StringValue{StringValue: vatReverseChargeNote},
+						},
+					},
+				},
+			},
+		}
+	}
+
 	payload.TemplateObjectModel = &structpb.Struct{
 		Fields: fields,
 	}
@@ -2349,6 +2406,12 @@ func (v *OrderCreateRequestProcessor) prepareOrder() (*billingpb.Order, error) {
 		return nil, orderErrorDynamicRedirectUrlsNotAllowed
 	}
 
+	merchantRoyaltyCurrency, err := v.getMerchantPayoutCurrencyByOrderCurrency(v.ctx, v.checked.merchant, v.checked.currency)
+
+	if err != nil {
+		return nil, err
+	}
+
 	order := &billingpb.Order{
 		Id:   id,
 		Type: pkg.OrderTypeOrder,
@@ -2369,7 +2432,7 @@ func (v *OrderCreateRequestProcessor) prepareOrder() (*billingpb.Order, error) {
 			CallbackProtocol:        v.checked.project.CallbackProtocol,
 			MerchantId:              v.checked.merchant.Id,
 			Status:                  v.checked.project.Status,
-			MerchantRoyaltyCurrency: v.checked.merchant.GetPayoutCurrency(),
+			MerchantRoyaltyCurrency: merchantRoyaltyCurrency,
 			RedirectSettings:        v.checked.project.RedirectSettings,
 			FirstPaymentAt:          v.checked.merchant.FirstPaymentAt,
 			FormDefaultText:         v.checked.project.FormDefaultText,
@@ -2912,6 +2975,7 @@ func (v *OrderCreateRequestProcessor) processOrderVat(order *billingpb.Order) er
 	order.TotalPaymentAmount = order.OrderAmount
 	order.ChargeAmount = order.TotalPaymentAmount
 	order.ChargeCurrency = order.Currency
+	setOrderTaxProvider(order, nil)
 
 	countryCode := order.GetCountry()
 
@@ -2937,22 +3001,30 @@ func (v *OrderCreateRequestProcessor) processOrderVat(order *billingpb.Order) er
 		}
 	}
 
-	req := &taxpb.GeoIdentity{
+	// Sales to business payers with valid VAT ID are zero-rated, VAT is accounted for by the payer
+	if getOrderReverseChargeVatId(order) != "" {
+		return nil
+	}
+
+	req := &intPkg.TaxRateRequest{
 		Country: countryCode,
+		Date:    time.Now(),
 	}
 
 	if countryCode == CountryCodeUSA {
+		req.State = order.GetState()
 		req.Zip = order.GetPostalCode()
 	}
 
-	rsp, err := v.tax.GetRate(v.ctx, req)
+	rsp, err := v.taxProvider.GetRate(v.ctx, req)
 
 	if err != nil {
-		v.logError("Tax service return error", []interface{}{"error", err.Error(), "request", req})
+		v.logError("Tax provider return error", []interface{}{"error", err.Error(), "request", req})
 		return err
 	}
 
 	order.Tax.Rate = rsp.Rate
+	setOrderTaxProvider(order, rsp)
 
 	switch order.VatPayer {
 
@@ -3257,6 +3329,10 @@ func (v *PaymentCreateProcessor) reserveKeysForOrder(ctx context.Context, order
 
 // Validate data received from payment form and write validated data to order
 func (v *PaymentCreateProcessor) processPaymentFormData(ctx context.Context) error {
+	// Initiator of the payment can't be passed from payment form
+	delete(v.data, pkg.PaymentCreateFieldInitiator)
+	delete(v.data, pkg.PaymentCreateFieldInitialTransactionId)
+
 	if _, ok := v.data[billingpb.PaymentCreateFieldOrderId]; !ok ||
 		v.data[billingpb.PaymentCreateFieldOrderId] == "" {
 		return orderErrorCreatePaymentRequiredFieldIdNotFound
@@ -3479,6 +3555,11 @@ func (v *PaymentCreateProcessor) processPaymentFormData(ctx context.Context) err
 			order.PaymentRequisites[billingpb.PaymentCreateFieldYear] = storedCard.Expire.Year
 			order.PaymentRequisites[billingpb.PaymentCreateFieldHolder] = storedCard.CardHolder
 			order.PaymentRequisites[billingpb.PaymentCreateFieldRecurringId] = storedCard.RecurringId
+
+			if v.initiator != "" {
+				order.PaymentRequisites[pkg.PaymentCreateFieldInitiator] = v.initiator
+				order.PaymentRequisites[pkg.PaymentCreateFieldInitialTransactionId] = v.initialTransactionId
+			}
 		} else {
 			validator := &bankCardValidator{
 				Pan:    v.data[billingpb.PaymentCreateFieldPan],
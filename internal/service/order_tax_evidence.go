@@ -0,0 +1,456 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/jinzhu/now"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/reporterpb"
+	"github.com/ttacon/libphonenumber"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+)
+
+const (
+	taxEvidenceBinLength          = 6
+	taxEvidenceFileNameTemplate   = "tax_evidence_%s_%s_%s.csv"
+	taxEvidenceFileDateLayout     = "2006-01-02"
+	taxEvidenceFileExtension      = "csv"
+	taxEvidenceMinConfirmingItems = 2
+)
+
+var (
+	orderTaxEvidenceErrorNotFound = errors.NewBillingServerErrorMsg("te000001", "tax evidence of order not found")
+	orderTaxEvidenceErrorUnknown  = errors.NewBillingServerErrorMsg("te000002", "unknown error. try request later")
+
+	taxEvidenceCsvHeader = []string{
+		"order_id",
+		"order_uuid",
+		"order_country",
+		"ip_country",
+		"ip",
+		"billing_address_country",
+		"billing_address_postal_code",
+		"bin_country",
+		"bin",
+		"phone_country",
+		"phone_country_code",
+		"rule",
+		"tax_country",
+		"sufficient",
+		"conflicting",
+		"created_at",
+	}
+)
+
+// GetOrderTaxEvidence returns evidence of the customer location collected on payment of the order.
+func (s *Service) GetOrderTaxEvidence(
+	ctx context.Context,
+	req *intPkg.GetOrderTaxEvidenceRequest,
+	rsp *intPkg.OrderTaxEvidenceResponse,
+) error {
+	evidence, err := s.orderTaxEvidenceRepository.GetByOrderId(ctx, req.OrderId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = orderTaxEvidenceErrorUnknown
+
+		if err == mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusNotFound
+			rsp.Message = orderTaxEvidenceErrorNotFound
+		}
+
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = evidence
+
+	return nil
+}
+
+// GetVatReportTaxEvidence returns evidence of the customer location of orders of the VAT report with the CSV file
+// for audits. The reporter service requests the file with this method to store and send it to the user.
+func (s *Service) GetVatReportTaxEvidence(
+	ctx context.Context,
+	req *intPkg.VatReportTaxEvidenceRequest,
+	rsp *intPkg.VatReportTaxEvidenceResponse,
+) error {
+	vr, err := s.vatReportRepository.GetById(ctx, req.VatReportId)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusNotFound
+			rsp.Message = errorVatReportNotFound
+			return nil
+		}
+
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = errorVatReportQueryError
+		return nil
+	}
+
+	item, err := s.getVatReportTaxEvidence(ctx, vr)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = errorVatReportInternal
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = item
+
+	return nil
+}
+
+// CreateVatReportTaxEvidenceFile asks the reporter service to create the file with evidence of orders of the VAT report.
+func (s *Service) CreateVatReportTaxEvidenceFile(
+	ctx context.Context,
+	req *intPkg.VatReportTaxEvidenceRequest,
+	rsp *intPkg.CreateVatReportTaxEvidenceFileResponse,
+) error {
+	if _, err := s.vatReportRepository.GetById(ctx, req.VatReportId); err != nil {
+		if err == mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusNotFound
+			rsp.Message = errorVatReportNotFound
+			return nil
+		}
+
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = errorVatReportQueryError
+		return nil
+	}
+
+	params, err := json.Marshal(req)
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to marshal the params of vat report tax evidence for the reporting service.",
+			zap.Error(err),
+		)
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = errorVatReportInternal
+		return nil
+	}
+
+	fileReq := &reporterpb.ReportFile{
+		UserId:           req.UserId,
+		ReportType:       pkg.ReportTypeVatReportTaxEvidence,
+		FileType:         taxEvidenceFileExtension,
+		Params:           params,
+		SendNotification: req.UserId != "",
+	}
+
+	if err = s.reporterServiceCreateFile(ctx, fileReq); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = errorVatReportInternal
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// collectOrderTaxEvidence stores evidence of the customer location of the order. The payment isn't stopped
+// if evidence can't be stored, the failure is only logged.
+func (s *Service) collectOrderTaxEvidence(ctx context.Context, order *billingpb.Order) {
+	evidence, err := s.orderTaxEvidenceRepository.GetByOrderId(ctx, order.Id)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		zap.L().Error(
+			"order tax evidence query failed",
+			zap.Error(err),
+			zap.String("order_id", order.Id),
+		)
+		return
+	}
+
+	if evidence == nil {
+		evidence = &intPkg.OrderTaxEvidence{}
+	}
+
+	evidence.OrderId = order.Id
+	evidence.OrderUuid = order.Uuid
+	evidence.OperatingCompanyId = order.OperatingCompanyId
+	evidence.OrderCountry = order.GetCountry()
+	evidence.Items = getOrderTaxEvidenceItems(order)
+	evidence.Rule, evidence.TaxCountry, evidence.Sufficient, evidence.Conflicting = getTaxEvidenceDecision(
+		evidence.Items,
+		evidence.OrderCountry,
+	)
+
+	if err = s.orderTaxEvidenceRepository.Upsert(ctx, evidence); err != nil {
+		zap.L().Error(
+			"order tax evidence saving failed",
+			zap.Error(err),
+			zap.String("order_id", order.Id),
+		)
+		return
+	}
+
+	if evidence.TaxCountry != evidence.OrderCountry {
+		zap.L().Warn(
+			"place of supply by evidence differs from country of order",
+			zap.String("order_id", order.Id),
+			zap.String("order_country", evidence.OrderCountry),
+			zap.String("tax_country", evidence.TaxCountry),
+			zap.String("rule", evidence.Rule),
+		)
+	}
+}
+
+func (s *Service) getVatReportTaxEvidence(
+	ctx context.Context,
+	vr *billingpb.VatReport,
+) (*intPkg.VatReportTaxEvidence, error) {
+	from, err := ptypes.Timestamp(vr.DateFrom)
+
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := ptypes.Timestamp(vr.DateTo)
+
+	if err != nil {
+		return nil, err
+	}
+
+	match := bson.M{
+		"pm_order_close_date": bson.M{
+			"$gte": now.New(from).BeginningOfDay(),
+			"$lte": now.New(to).EndOfDay(),
+		},
+		"country_code":         vr.Country,
+		"operating_company_id": vr.OperatingCompanyId,
+		"is_production":        true,
+		"type":                 pkg.OrderTypeOrder,
+	}
+
+	orders, err := s.orderViewRepository.GetTransactionsPrivate(ctx, match, 0, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &intPkg.VatReportTaxEvidence{
+		VatReportId: vr.Id,
+		Country:     vr.Country,
+		Items:       []*intPkg.OrderTaxEvidence{},
+		FileName: fmt.Sprintf(
+			taxEvidenceFileNameTemplate,
+			strings.ToLower(vr.Country),
+			from.Format(taxEvidenceFileDateLayout),
+			to.Format(taxEvidenceFileDateLayout),
+		),
+	}
+
+	if len(orders) > 0 {
+		ids := make([]string, 0, len(orders))
+
+		for _, order := range orders {
+			ids = append(ids, order.Id)
+		}
+
+		result.Items, err = s.orderTaxEvidenceRepository.FindByOrderIds(ctx, ids)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.MissingCount = int32(len(orders) - len(result.Items))
+	result.Content, err = getTaxEvidenceCsv(result.Items)
+
+	if err != nil {
+		zap.L().Error("Unable to render vat report tax evidence", zap.Error(err), zap.String("vat_report_id", vr.Id))
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// getOrderTaxEvidenceItems returns pieces of evidence of the customer location available in the order:
+// the country of IP address, the billing address entered by the customer, the country of issuer of bank card
+// and the country of the phone number.
+func getOrderTaxEvidenceItems(order *billingpb.Order) []*intPkg.TaxEvidenceItem {
+	var items []*intPkg.TaxEvidenceItem
+
+	if order.User != nil {
+		country := order.PaymentIpCountry
+
+		if country == "" && order.User.Address != nil {
+			country = order.User.Address.Country
+		}
+
+		if country != "" {
+			items = append(items, &intPkg.TaxEvidenceItem{
+				Type:    pkg.TaxEvidenceTypeIp,
+				Country: country,
+				Value:   order.User.Ip,
+			})
+		}
+	}
+
+	if order.BillingAddress != nil && order.BillingAddress.Country != "" {
+		items = append(items, &intPkg.TaxEvidenceItem{
+			Type:    pkg.TaxEvidenceTypeBillingAddress,
+			Country: order.BillingAddress.Country,
+			Value:   order.BillingAddress.PostalCode,
+		})
+	}
+
+	if country := order.PaymentRequisites[billingpb.PaymentCreateBankCardFieldIssuerCountryIsoCode]; country != "" {
+		bin := order.PaymentRequisites[billingpb.PaymentCreateFieldPan]
+
+		if len(bin) > taxEvidenceBinLength {
+			bin = bin[:taxEvidenceBinLength]
+		}
+
+		items = append(items, &intPkg.TaxEvidenceItem{
+			Type:    pkg.TaxEvidenceTypeBin,
+			Country: country,
+			Value:   bin,
+		})
+	}
+
+	if order.User != nil && order.User.Phone != "" {
+		if code, country := getPhoneCountry(order.User.Phone); country != "" {
+			items = append(items, &intPkg.TaxEvidenceItem{
+				Type:    pkg.TaxEvidenceTypePhone,
+				Country: country,
+				Value:   fmt.Sprintf("+%d", code),
+			})
+		}
+	}
+
+	return items
+}
+
+// getTaxEvidenceDecision returns the decision rule, the country of place of supply, flag of two non-conflicting
+// pieces of evidence are found and flag of evidence points to different countries. The billing address confirmed
+// by other evidence wins, otherwise the country confirmed by two pieces of other evidence is used.
+// The country of the order is used if evidence is insufficient.
+func getTaxEvidenceDecision(
+	items []*intPkg.TaxEvidenceItem,
+	orderCountry string,
+) (rule, country string, sufficient, conflicting bool) {
+	counts := make(map[string]int)
+	billingCountry := ""
+
+	for _, item := range items {
+		counts[item.Country]++
+
+		if item.Type == pkg.TaxEvidenceTypeBillingAddress {
+			billingCountry = item.Country
+		}
+	}
+
+	conflicting = len(counts) > 1
+
+	if billingCountry != "" && counts[billingCountry] >= taxEvidenceMinConfirmingItems {
+		return pkg.TaxEvidenceRuleBillingAddressConfirmed, billingCountry, true, conflicting
+	}
+
+	countries := make([]string, 0, len(counts))
+
+	for k := range counts {
+		countries = append(countries, k)
+	}
+
+	sort.Slice(countries, func(i, j int) bool {
+		if counts[countries[i]] != counts[countries[j]] {
+			return counts[countries[i]] > counts[countries[j]]
+		}
+		return countries[i] < countries[j]
+	})
+
+	if len(countries) > 0 && counts[countries[0]] >= taxEvidenceMinConfirmingItems {
+		return pkg.TaxEvidenceRuleNonConflicting, countries[0], true, conflicting
+	}
+
+	return pkg.TaxEvidenceRuleInsufficient, orderCountry, false, conflicting
+}
+
+// getPhoneCountry returns the country calling code and the country of the phone number.
+func getPhoneCountry(phone string) (int32, string) {
+	if !strings.HasPrefix(phone, "+") {
+		phone = "+" + phone
+	}
+
+	num, err := libphonenumber.Parse(phone, CountryCodeUSA)
+
+	if err != nil || num.CountryCode == nil {
+		return 0, ""
+	}
+
+	if country, ok := pkg.CountryPhoneCodes[*num.CountryCode]; ok {
+		return *num.CountryCode, country
+	}
+
+	return *num.CountryCode, libphonenumber.GetRegionCodeForNumber(num)
+}
+
+func getTaxEvidenceCsv(items []*intPkg.OrderTaxEvidence) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+
+	if err := w.Write(taxEvidenceCsvHeader); err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		pieces := make(map[string]*intPkg.TaxEvidenceItem, len(item.Items))
+
+		for _, piece := range item.Items {
+			pieces[piece.Type] = piece
+		}
+
+		record := []string{item.OrderId, item.OrderUuid, item.OrderCountry}
+
+		for _, t := range []string{
+			pkg.TaxEvidenceTypeIp,
+			pkg.TaxEvidenceTypeBillingAddress,
+			pkg.TaxEvidenceTypeBin,
+			pkg.TaxEvidenceTypePhone,
+		} {
+			if piece, ok := pieces[t]; ok {
+				record = append(record, piece.Country, piece.Value)
+			} else {
+				record = append(record, "", "")
+			}
+		}
+
+		record = append(
+			record,
+			item.Rule,
+			item.TaxCountry,
+			fmt.Sprintf("%t", item.Sufficient),
+			fmt.Sprintf("%t", item.Conflicting),
+			item.CreatedAt.Format(taxEvidenceFileDateLayout),
+		)
+
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
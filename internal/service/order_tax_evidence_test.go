@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+)
+
+func (suite *OrderTestSuite) TestOrderTaxEvidence_getOrderTaxEvidenceItems_Ok() {
+	order := &billingpb.Order{
+		PaymentIpCountry: "DE",
+		User: &billingpb.OrderUser{
+			Ip:    "127.0.0.1",
+			Phone: "380441234567",
+		},
+		BillingAddress: &billingpb.OrderBillingAddress{Country: "DE", PostalCode: "10115"},
+		PaymentRequisites: map[string]string{
+			billingpb.PaymentCreateFieldPan:                          "400000******0002",
+			billingpb.PaymentCreateBankCardFieldIssuerCountryIsoCode: "AT",
+		},
+	}
+
+	items := getOrderTaxEvidenceItems(order)
+	assert.Len(suite.T(), items, 4)
+	assert.Equal(suite.T(), &intPkg.TaxEvidenceItem{Type: pkg.TaxEvidenceTypeIp, Country: "DE", Value: "127.0.0.1"}, items[0])
+	assert.Equal(suite.T(), &intPkg.TaxEvidenceItem{Type: pkg.TaxEvidenceTypeBillingAddress, Country: "DE", Value: "10115"}, items[1])
+	assert.Equal(suite.T(), &intPkg.TaxEvidenceItem{Type: pkg.TaxEvidenceTypeBin, Country: "AT", Value: "400000"}, items[2])
+	assert.Equal(suite.T(), &intPkg.TaxEvidenceItem{Type: pkg.TaxEvidenceTypePhone, Country: "UA", Value: "+380"}, items[3])
+
+	assert.Empty(suite.T(), getOrderTaxEvidenceItems(&billingpb.Order{}))
+}
+
+func (suite *OrderTestSuite) TestOrderTaxEvidence_getTaxEvidenceDecision_Ok() {
+	rule, country, sufficient, conflicting := getTaxEvidenceDecision([]*intPkg.TaxEvidenceItem{
+		{Type: pkg.TaxEvidenceTypeIp, Country: "DE"},
+		{Type: pkg.TaxEvidenceTypeBillingAddress, Country: "DE"},
+		{Type: pkg.TaxEvidenceTypeBin, Country: "AT"},
+	}, "AT")
+	assert.Equal(suite.T(), pkg.TaxEvidenceRuleBillingAddressConfirmed, rule)
+	assert.Equal(suite.T(), "DE", country)
+	assert.True(suite.T(), sufficient)
+	assert.True(suite.T(), conflicting)
+
+	rule, country, sufficient, conflicting = getTaxEvidenceDecision([]*intPkg.TaxEvidenceItem{
+		{Type: pkg.TaxEvidenceTypeIp, Country: "FR"},
+		{Type: pkg.TaxEvidenceTypeBillingAddress, Country: "DE"},
+		{Type: pkg.TaxEvidenceTypeBin, Country: "FR"},
+	}, "DE")
+	assert.Equal(suite.T(), pkg.TaxEvidenceRuleNonConflicting, rule)
+	assert.Equal(suite.T(), "FR", country)
+	assert.True(suite.T(), sufficient)
+	assert.True(suite.T(), conflicting)
+
+	rule, country, sufficient, conflicting = getTaxEvidenceDecision([]*intPkg.TaxEvidenceItem{
+		{Type: pkg.TaxEvidenceTypeIp, Country: "FR"},
+		{Type: pkg.TaxEvidenceTypeBin, Country: "AT"},
+	}, "DE")
+	assert.Equal(suite.T(), pkg.TaxEvidenceRuleInsufficient, rule)
+	assert.Equal(suite.T(), "DE", country)
+	assert.False(suite.T(), sufficient)
+	assert.True(suite.T(), conflicting)
+
+	rule, country, sufficient, conflicting = getTaxEvidenceDecision(nil, "DE")
+	assert.Equal(suite.T(), pkg.TaxEvidenceRuleInsufficient, rule)
+	assert.Equal(suite.T(), "DE", country)
+	assert.False(suite.T(), sufficient)
+	assert.False(suite.T(), conflicting)
+}
+
+func (suite *OrderTestSuite) TestOrderTaxEvidence_GetOrderTaxEvidence_NotFound() {
+	req := &intPkg.GetOrderTaxEvidenceRequest{OrderId: primitive.NewObjectID().Hex()}
+	rsp := &intPkg.OrderTaxEvidenceResponse{}
+	err := suite.service.GetOrderTaxEvidence(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), orderTaxEvidenceErrorNotFound, rsp.Message)
+	assert.Nil(suite.T(), rsp.Item)
+}
+
+func (suite *VatReportsTestSuite) TestOrderTaxEvidence_GetVatReportTaxEvidence_Ok() {
+	suite.projectFixedAmount.Status = billingpb.ProjectStatusInProduction
+	if err := suite.service.project.Update(context.TODO(), suite.projectFixedAmount); err != nil {
+		suite.FailNow("Update project test data failed", "%v", err)
+	}
+
+	var orders []*billingpb.Order
+
+	for i := 0; i < 3; i++ {
+		order := HelperCreateAndPayOrder(
+			suite.Suite,
+			suite.service,
+			100,
+			"RUB",
+			"RU",
+			suite.projectFixedAmount,
+			suite.paymentMethod,
+			suite.cookie,
+		)
+		assert.NotNil(suite.T(), order)
+		orders = append(orders, order)
+	}
+
+	rsp := &intPkg.OrderTaxEvidenceResponse{}
+	err := suite.service.GetOrderTaxEvidence(context.TODO(), &intPkg.GetOrderTaxEvidenceRequest{OrderId: orders[0].Id}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), orders[0].Uuid, rsp.Item.OrderUuid)
+	assert.Equal(suite.T(), "RU", rsp.Item.OrderCountry)
+	assert.Equal(suite.T(), pkg.TaxEvidenceRuleBillingAddressConfirmed, rsp.Item.Rule)
+	assert.Equal(suite.T(), "RU", rsp.Item.TaxCountry)
+	assert.True(suite.T(), rsp.Item.Sufficient)
+	assert.True(suite.T(), rsp.Item.Conflicting)
+
+	req := &billingpb.ProcessVatReportsRequest{Date: ptypes.TimestampNow()}
+	err = suite.service.ProcessVatReports(context.TODO(), req, &billingpb.EmptyResponse{})
+	assert.NoError(suite.T(), err)
+
+	reports := billingpb.VatReportsResponse{}
+	err = suite.service.GetVatReportsForCountry(context.TODO(), &billingpb.VatReportsRequest{Country: "RU"}, &reports)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, reports.Status)
+	assert.EqualValues(suite.T(), 1, reports.Data.Count)
+
+	evidenceRsp := &intPkg.VatReportTaxEvidenceResponse{}
+	err = suite.service.GetVatReportTaxEvidence(
+		context.TODO(),
+		&intPkg.VatReportTaxEvidenceRequest{VatReportId: reports.Data.Items[0].Id},
+		evidenceRsp,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, evidenceRsp.Status)
+	assert.Equal(suite.T(), "RU", evidenceRsp.Item.Country)
+	assert.Len(suite.T(), evidenceRsp.Item.Items, 3)
+	assert.Zero(suite.T(), evidenceRsp.Item.MissingCount)
+	assert.True(suite.T(), strings.HasPrefix(evidenceRsp.Item.FileName, "tax_evidence_ru_"))
+
+	lines := strings.Split(strings.TrimSpace(string(evidenceRsp.Item.Content)), "\n")
+	assert.Len(suite.T(), lines, 4)
+	assert.Equal(suite.T(), strings.Join(taxEvidenceCsvHeader, ","), lines[0])
+	assert.True(suite.T(), strings.HasPrefix(lines[1], orders[0].Id+","+orders[0].Uuid+",RU,RU,"))
+}
+
+func (suite *VatReportsTestSuite) TestOrderTaxEvidence_GetVatReportTaxEvidence_NotFound() {
+	req := &intPkg.VatReportTaxEvidenceRequest{VatReportId: primitive.NewObjectID().Hex()}
+	rsp := &intPkg.VatReportTaxEvidenceResponse{}
+	err := suite.service.GetVatReportTaxEvidence(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), errorVatReportNotFound, rsp.Message)
+
+	createRsp := &intPkg.CreateVatReportTaxEvidenceFileResponse{}
+	err = suite.service.CreateVatReportTaxEvidenceFile(context.TODO(), req, createRsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, createRsp.Status)
+	assert.Equal(suite.T(), errorVatReportNotFound, createRsp.Message)
+}
@@ -99,6 +99,7 @@ func (suite *OrderTestSuite) SetupTest() {
 		suite.FailNow("Config load failed", "%v", err)
 	}
 	cfg.CardPayApiUrl = "https://sandbox.cardpay.com"
+	cfg.VatIdValidator = pkg.VatIdValidatorStub
 
 	db, err := mongodb.NewDatabase()
 	if err != nil {
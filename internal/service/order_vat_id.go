@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	vatReverseChargeNote = "Reverse charge: VAT to be accounted for by the recipient " +
+		"according to Article 196 of Council Directive 2006/112/EC"
+)
+
+var (
+	orderVatIdErrorUnknown                  = errors.NewBillingServerErrorMsg("vi000001", "unknown error. try request later")
+	orderVatIdErrorCountryNotSupported      = errors.NewBillingServerErrorMsg("vi000002", "vat id is supported only for payers from the european union")
+	orderVatIdErrorCountryMismatch          = errors.NewBillingServerErrorMsg("vi000003", "vat id is issued in country other than the payer country")
+	orderVatIdErrorDomesticSale             = errors.NewBillingServerErrorMsg("vi000004", "reverse charge is not applicable to payers from the country of operating company")
+	orderVatIdErrorInvalid                  = errors.NewBillingServerErrorMsg("vi000005", "vat id is invalid")
+	orderVatIdErrorValidatorUnavailable     = errors.NewBillingServerErrorMsg("vi000006", "vat id can't be validated now. try request later")
+	orderVatIdErrorOperatingCompanyNotFound = errors.NewBillingServerErrorMsg("vi000007", "operating company of order not found")
+)
+
+// SetOrderVatId validates VAT ID of the business payer entered on the payment form and stores it on the order
+// with the consultation number of the registry. The order with valid VAT ID is zero-rated under the reverse charge.
+func (s *Service) SetOrderVatId(
+	ctx context.Context,
+	req *intPkg.SetOrderVatIdRequest,
+	rsp *intPkg.SetOrderVatIdResponse,
+) error {
+	order, err := s.getOrderByUuidToForm(ctx, req.OrderId)
+
+	if err != nil {
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = e
+			return nil
+		}
+		return err
+	}
+
+	vatId := normalizeVatId(req.VatId)
+	country := order.GetCountry()
+
+	obj, err := s.orderVatIdRepository.GetByOrderId(ctx, order.Id)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = orderVatIdErrorUnknown
+		return nil
+	}
+
+	if obj == nil {
+		obj = &intPkg.OrderVatId{
+			OrderId:            order.Id,
+			OrderUuid:          order.Uuid,
+			OperatingCompanyId: order.OperatingCompanyId,
+		}
+	}
+
+	obj.Country = country
+	obj.VatId = vatId
+	obj.Valid = false
+	obj.ReverseCharge = false
+	obj.CompanyName = ""
+	obj.CompanyAddress = ""
+	obj.ConsultationNumber = ""
+
+	if vatId != "" {
+		if !helper.Contains(pkg.EuMemberStates, country) {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = orderVatIdErrorCountryNotSupported
+			return nil
+		}
+
+		if getVatIdCountry(vatId) != country {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = orderVatIdErrorCountryMismatch
+			return nil
+		}
+
+		oc, err := s.operatingCompanyRepository.GetById(ctx, order.OperatingCompanyId)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = orderVatIdErrorOperatingCompanyNotFound
+			return nil
+		}
+
+		if oc.Country == country {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = orderVatIdErrorDomesticSale
+			return nil
+		}
+
+		result, err := s.vatIdValidator.Validate(ctx, vatId, oc.VatNumber)
+
+		if err != nil {
+			zap.L().Error(
+				"vat id validation failed",
+				zap.Error(err),
+				zap.String("order_id", order.Id),
+				zap.String("vat_id", vatId),
+			)
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = orderVatIdErrorValidatorUnavailable
+			return nil
+		}
+
+		obj.Valid = result.Valid
+		obj.ReverseCharge = result.Valid
+		obj.CompanyName = result.CompanyName
+		obj.CompanyAddress = result.CompanyAddress
+		obj.ConsultationNumber = result.ConsultationNumber
+		obj.CheckedAt = result.CheckedAt
+	}
+
+	if order.PrivateMetadata == nil {
+		order.PrivateMetadata = make(map[string]string)
+	}
+
+	if obj.ReverseCharge {
+		order.PrivateMetadata[pkg.OrderPrivateMetadataVatId] = obj.VatId
+		order.PrivateMetadata[pkg.OrderPrivateMetadataVatIdConsultationNumber] = obj.ConsultationNumber
+	} else {
+		delete(order.PrivateMetadata, pkg.OrderPrivateMetadataVatId)
+		delete(order.PrivateMetadata, pkg.OrderPrivateMetadataVatIdConsultationNumber)
+	}
+
+	processor := &OrderCreateRequestProcessor{Service: s, ctx: ctx}
+	err = processor.processOrderVat(order)
+
+	if err != nil {
+		zap.S().Errorw(pkg.MethodFinishedWithError, "err", err.Error(), "method", "processOrderVat")
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = e
+			return nil
+		}
+		return err
+	}
+
+	err = s.setOrderChargeAmountAndCurrency(ctx, order)
+
+	if err != nil {
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = e
+			return nil
+		}
+		return err
+	}
+
+	// Rejected VAT ID is stored as well, it's the evidence that the payer was charged with VAT after the check
+	if vatId != "" || !obj.Id.IsZero() {
+		if err = s.orderVatIdRepository.Upsert(ctx, obj); err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = orderVatIdErrorUnknown
+			return nil
+		}
+	}
+
+	if err = s.updateOrder(ctx, order); err != nil {
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = e
+			return nil
+		}
+		return err
+	}
+
+	if vatId != "" && !obj.Valid {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = orderVatIdErrorInvalid
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = obj
+	rsp.Order = order
+
+	return nil
+}
+
+// getOrderReverseChargeVatId returns VAT ID of the business payer if the order is zero-rated under the reverse charge.
+// VAT ID is ignored if the payer changed the country after VAT ID was validated.
+func getOrderReverseChargeVatId(order *billingpb.Order) string {
+	vatId, ok := order.PrivateMetadata[pkg.OrderPrivateMetadataVatId]
+
+	if !ok || vatId == "" || getVatIdCountry(vatId) != order.GetCountry() {
+		return ""
+	}
+
+	return vatId
+}
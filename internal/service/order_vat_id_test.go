@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (suite *OrderTestSuite) TestOrderVatId_SetOrderVatId_Ok() {
+	order := suite.helperCreateOrderWithBillingCountry("IT")
+	assert.EqualValues(suite.T(), 0.2, order.Tax.Rate)
+	assert.True(suite.T(), order.Tax.Amount > 0)
+
+	req := &intPkg.SetOrderVatIdRequest{OrderId: order.Uuid, VatId: "it 123.456.789-01"}
+	rsp := &intPkg.SetOrderVatIdResponse{}
+	err := suite.service.SetOrderVatId(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), "IT12345678901", rsp.Item.VatId)
+	assert.True(suite.T(), rsp.Item.Valid)
+	assert.True(suite.T(), rsp.Item.ReverseCharge)
+	assert.NotEmpty(suite.T(), rsp.Item.ConsultationNumber)
+
+	order, err = suite.service.getOrderByUuid(context.TODO(), order.Uuid)
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), order.Tax.Rate)
+	assert.Zero(suite.T(), order.Tax.Amount)
+	assert.Equal(suite.T(), order.OrderAmount, order.TotalPaymentAmount)
+	assert.Equal(suite.T(), "IT12345678901", order.PrivateMetadata[pkg.OrderPrivateMetadataVatId])
+	assert.Equal(
+		suite.T(),
+		rsp.Item.ConsultationNumber,
+		order.PrivateMetadata[pkg.OrderPrivateMetadataVatIdConsultationNumber],
+	)
+	assert.Equal(suite.T(), "IT12345678901", getOrderReverseChargeVatId(order))
+
+	req.VatId = ""
+	rsp = &intPkg.SetOrderVatIdResponse{}
+	err = suite.service.SetOrderVatId(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.False(suite.T(), rsp.Item.ReverseCharge)
+
+	order, err = suite.service.getOrderByUuid(context.TODO(), order.Uuid)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 0.2, order.Tax.Rate)
+	assert.True(suite.T(), order.Tax.Amount > 0)
+	assert.NotContains(suite.T(), order.PrivateMetadata, pkg.OrderPrivateMetadataVatId)
+
+	obj, err := suite.service.orderVatIdRepository.GetByOrderId(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), obj.ReverseCharge)
+}
+
+func (suite *OrderTestSuite) TestOrderVatId_SetOrderVatId_CountryChanged_VatCharged() {
+	order := suite.helperCreateOrderWithBillingCountry("IT")
+
+	req := &intPkg.SetOrderVatIdRequest{OrderId: order.Uuid, VatId: "IT12345678901"}
+	rsp := &intPkg.SetOrderVatIdResponse{}
+	err := suite.service.SetOrderVatId(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	req1 := &billingpb.ProcessBillingAddressRequest{OrderId: order.Uuid, Country: "RU"}
+	rsp1 := &billingpb.ProcessBillingAddressResponse{}
+	err = suite.service.ProcessBillingAddress(context.TODO(), req1, rsp1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp1.Status)
+
+	order, err = suite.service.getOrderByUuid(context.TODO(), order.Uuid)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), getOrderReverseChargeVatId(order))
+	assert.True(suite.T(), order.Tax.Amount > 0)
+}
+
+func (suite *OrderTestSuite) TestOrderVatId_SetOrderVatId_CountryNotSupported_Error() {
+	order := suite.helperCreateOrderWithBillingCountry("RU")
+
+	req := &intPkg.SetOrderVatIdRequest{OrderId: order.Uuid, VatId: "RU1234567890"}
+	rsp := &intPkg.SetOrderVatIdResponse{}
+	err := suite.service.SetOrderVatId(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), orderVatIdErrorCountryNotSupported, rsp.Message)
+}
+
+func (suite *OrderTestSuite) TestOrderVatId_SetOrderVatId_CountryMismatch_Error() {
+	order := suite.helperCreateOrderWithBillingCountry("IT")
+
+	req := &intPkg.SetOrderVatIdRequest{OrderId: order.Uuid, VatId: "DE123456789"}
+	rsp := &intPkg.SetOrderVatIdResponse{}
+	err := suite.service.SetOrderVatId(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), orderVatIdErrorCountryMismatch, rsp.Message)
+}
+
+func (suite *OrderTestSuite) TestOrderVatId_SetOrderVatId_Invalid_Error() {
+	order := suite.helperCreateOrderWithBillingCountry("IT")
+
+	req := &intPkg.SetOrderVatIdRequest{OrderId: order.Uuid, VatId: "IT1"}
+	rsp := &intPkg.SetOrderVatIdResponse{}
+	err := suite.service.SetOrderVatId(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), orderVatIdErrorInvalid, rsp.Message)
+
+	obj, err := suite.service.orderVatIdRepository.GetByOrderId(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "IT1", obj.VatId)
+	assert.False(suite.T(), obj.Valid)
+	assert.False(suite.T(), obj.ReverseCharge)
+
+	order, err = suite.service.getOrderByUuid(context.TODO(), order.Uuid)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), order.Tax.Amount > 0)
+}
+
+func (suite *OrderTestSuite) TestOrderVatId_SetOrderVatId_ValidatorUnavailable_Error() {
+	order := suite.helperCreateOrderWithBillingCountry("IT")
+
+	validator := &mocks.VatIdValidatorInterface{}
+	validator.On("Validate", mock.Anything, "IT12345678901", suite.operatingCompany.VatNumber).
+		Return(nil, errors.New("MS_UNAVAILABLE"))
+	suite.service.vatIdValidator = validator
+
+	req := &intPkg.SetOrderVatIdRequest{OrderId: order.Uuid, VatId: "IT12345678901"}
+	rsp := &intPkg.SetOrderVatIdResponse{}
+	err := suite.service.SetOrderVatId(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusSystemError, rsp.Status)
+	assert.Equal(suite.T(), orderVatIdErrorValidatorUnavailable, rsp.Message)
+
+	_, err = suite.service.orderVatIdRepository.GetByOrderId(context.TODO(), order.Id)
+	assert.Equal(suite.T(), mongo.ErrNoDocuments, err)
+}
+
+func (suite *OrderTestSuite) TestOrderVatId_getPayloadForReceipt_ReverseChargeNote() {
+	order := suite.helperCreateOrderWithBillingCountry("IT")
+
+	req := &intPkg.SetOrderVatIdRequest{OrderId: order.Uuid, VatId: "IT12345678901"}
+	rsp := &intPkg.SetOrderVatIdResponse{}
+	err := suite.service.SetOrderVatId(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	payload, err := suite.service.getPayloadForReceipt(context.TODO(), rsp.Order)
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), payload.TemplateObjectModel.Fields, "reverseCharge")
+
+	fields := payload.TemplateObjectModel.Fields["reverseCharge"].GetStructValue().Fields
+	assert.Equal(suite.T(), "IT12345678901", fields["vatId"].GetStringValue())
+	assert.Equal(suite.T(), vatReverseChargeNote, fields["note"].GetStringValue())
+}
+
+func (suite *OrderTestSuite) TestOrderVatId_normalizeVatId() {
+	assert.Equal(suite.T(), "DE123456789", normalizeVatId(" de 123.456-789 "))
+	assert.Equal(suite.T(), "GR", getVatIdCountry("EL123456789"))
+	assert.Equal(suite.T(), "DE", getVatIdCountry("DE123456789"))
+	assert.Empty(suite.T(), getVatIdCountry("D"))
+}
+
+func (suite *OrderTestSuite) TestOrderVatId_newVatIdValidator() {
+	cfg := &config.Config{}
+	assert.IsType(suite.T(), &vatIdValidatorVies{}, newVatIdValidator(cfg, nil))
+
+	cfg.VatIdValidator = pkg.VatIdValidatorVies
+	assert.IsType(suite.T(), &vatIdValidatorVies{}, newVatIdValidator(cfg, nil))
+
+	cfg.VatIdValidator = pkg.VatIdValidatorStub
+	assert.IsType(suite.T(), &vatIdValidatorStub{}, newVatIdValidator(cfg, nil))
+}
+
+func (suite *OrderTestSuite) helperCreateOrderWithBillingCountry(country string) *billingpb.Order {
+	req := &billingpb.OrderCreateRequest{
+		Type:        pkg.OrderType_simple,
+		ProjectId:   suite.project.Id,
+		Currency:    "RUB",
+		Amount:      100,
+		Account:     "unit test",
+		Description: "unit test",
+		User: &billingpb.OrderUser{
+			Email: "test@unit.unit",
+			Ip:    "127.0.0.1",
+		},
+	}
+
+	rsp := &billingpb.OrderCreateProcessResponse{}
+	err := suite.service.OrderCreateProcess(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	req1 := &billingpb.ProcessBillingAddressRequest{OrderId: rsp.Item.Uuid, Country: country}
+	rsp1 := &billingpb.ProcessBillingAddressResponse{}
+	err = suite.service.ProcessBillingAddress(context.TODO(), req1, rsp1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp1.Status)
+
+	order, err := suite.service.getOrderByUuid(context.TODO(), rsp.Item.Uuid)
+	assert.NoError(suite.T(), err)
+
+	return order
+}
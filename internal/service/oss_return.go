@@ -0,0 +1,972 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/jinzhu/now"
+	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/currenciespb"
+	"github.com/paysuper/paysuper-proto/go/reporterpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	ossReturnCurrency             = "EUR"
+	ossReturnCurrencyRatesSource  = "cbeu"
+	ossReturnMonthsInQuarter      = 3
+	ossReturnQuartersInYear       = 4
+	ossReturnCorrectionPeriods    = 12
+	ossReturnDateTimeLayout       = "2006-01-02T15:04:05"
+	ossReturnSupplyTypeServices   = "SERVICES"
+	ossReturnFileNameTemplate     = "oss_return_%s_%d_q%d.xml"
+	ossReturnXmlSchemeUnion       = "UNION"
+	ossReturnXmlSchemeNonUnion    = "NON-UNION"
+	ossReturnXmlRateTypeStandard  = "STANDARD"
+	ossReturnXmlRateTypeReduced   = "REDUCED"
+	ossReturnPercentMultiplier    = 100
+	ossReturnAmountFormatDecimals = 2
+)
+
+var (
+	ossReturnErrorPeriodInvalid     = errors.NewBillingServerErrorMsg("os000001", "oss return period is invalid or not ended yet")
+	ossReturnErrorPeriodNotClosed   = errors.NewBillingServerErrorMsg("os000002", "vat reports of the quarter are not closed yet")
+	ossReturnErrorAlreadyFiled      = errors.NewBillingServerErrorMsg("os000003", "oss return for the quarter is already filed")
+	ossReturnErrorNotFound          = errors.NewBillingServerErrorMsg("os000004", "oss return not found")
+	ossReturnErrorStatusChange      = errors.NewBillingServerErrorMsg("os000005", "oss return status change not allowed")
+	ossReturnErrorVatReportsChanged = errors.NewBillingServerErrorMsg("os000006", "vat reports of the oss return were changed, generate the return again")
+	ossReturnErrorUnknown           = errors.NewBillingServerErrorMsg("os000007", "unknown error. try request later")
+
+	// Statuses of VAT reports of ended periods which are declared by the return.
+	ossReturnVatReportStatuses = []string{
+		pkg.VatReportStatusNeedToPay,
+		pkg.VatReportStatusOverdue,
+		pkg.VatReportStatusPaid,
+	}
+
+	// Statuses of returns filed with the tax authority.
+	ossReturnFiledStatuses = []string{
+		pkg.OssReturnStatusSubmitted,
+		pkg.OssReturnStatusAccepted,
+		pkg.OssReturnStatusPaid,
+	}
+
+	ossReturnStatusTransitions = map[string][]string{
+		pkg.OssReturnStatusDraft:     {pkg.OssReturnStatusSubmitted, pkg.OssReturnStatusCanceled},
+		pkg.OssReturnStatusSubmitted: {pkg.OssReturnStatusAccepted, pkg.OssReturnStatusRejected},
+		pkg.OssReturnStatusAccepted:  {pkg.OssReturnStatusPaid},
+	}
+
+	ossReturnXmlSchemes = map[string]string{
+		pkg.OssReturnSchemeUnion:    ossReturnXmlSchemeUnion,
+		pkg.OssReturnSchemeNonUnion: ossReturnXmlSchemeNonUnion,
+	}
+
+	ossReturnXmlRateTypes = map[string]string{
+		pkg.OssReturnRateTypeStandard: ossReturnXmlRateTypeStandard,
+		pkg.OssReturnRateTypeReduced:  ossReturnXmlRateTypeReduced,
+	}
+)
+
+// ossReturnDocument is the submission file of the return with the data set of the One-Stop-Shop VAT return
+// defined by Implementing Regulation (EU) 2020/194.
+type ossReturnDocument struct {
+	XMLName           xml.Name                   `xml:"OSSReturn"`
+	Header            *ossReturnHeader           `xml:"Header"`
+	Supplies          []*ossReturnSupply         `xml:"Supplies>Supply"`
+	Corrections       []*ossReturnCorrection     `xml:"Corrections>Correction"`
+	MemberStateTotals []*ossReturnMemberStateVat `xml:"VATDueByMemberState>MemberState"`
+	TotalVatAmount    string                     `xml:"TotalVATAmountDue"`
+}
+
+type ossReturnHeader struct {
+	Scheme                      string           `xml:"Scheme"`
+	VatIdentificationNumber     string           `xml:"VATIdentificationNumber"`
+	TraderName                  string           `xml:"TraderName"`
+	MemberStateOfIdentification string           `xml:"MemberStateOfIdentification,omitempty"`
+	Period                      *ossReturnPeriod `xml:"Period"`
+	Currency                    string           `xml:"Currency"`
+	CreationDateTime            string           `xml:"CreationDateTime"`
+}
+
+type ossReturnPeriod struct {
+	Year    int32 `xml:"Year"`
+	Quarter int32 `xml:"Quarter"`
+}
+
+type ossReturnSupply struct {
+	MemberStateOfConsumption string `xml:"MemberStateOfConsumption"`
+	SupplyType               string `xml:"SupplyType"`
+	VatRateType              string `xml:"VATRateType"`
+	VatRate                  string `xml:"VATRate"`
+	TaxableAmount            string `xml:"TaxableAmount"`
+	VatAmount                string `xml:"VATAmount"`
+}
+
+type ossReturnCorrection struct {
+	MemberStateOfConsumption string           `xml:"MemberStateOfConsumption"`
+	Period                   *ossReturnPeriod `xml:"Period"`
+	VatAmount                string           `xml:"VATAmount"`
+}
+
+type ossReturnMemberStateVat struct {
+	MemberStateOfConsumption string `xml:"MemberStateOfConsumption"`
+	VatAmount                string `xml:"VATAmount"`
+}
+
+// GenerateOssReturn creates the draft of the One-Stop-Shop return of the operating company for the ended quarter
+// and asks the reporter service to create the human-readable PDF of the return.
+// The draft of the quarter is generated again on repeated requests until the return is filed.
+func (s *Service) GenerateOssReturn(
+	ctx context.Context,
+	req *intPkg.GenerateOssReturnRequest,
+	rsp *intPkg.OssReturnResponse,
+) error {
+	if req.Year <= 0 || req.Quarter < 1 || req.Quarter > ossReturnQuartersInYear {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = ossReturnErrorPeriodInvalid
+		return nil
+	}
+
+	_, to := getOssReturnPeriodDates(req.Year, req.Quarter)
+
+	if !to.Before(time.Now()) {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = ossReturnErrorPeriodInvalid
+		return nil
+	}
+
+	oc, err := s.operatingCompanyRepository.GetById(ctx, req.OperatingCompanyId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = errorOperatingCompanyNotFound
+		return nil
+	}
+
+	ossReturn, err := s.generateOssReturn(ctx, oc, req.Year, req.Quarter, req.UserId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = ossReturnErrorUnknown
+
+		if err == ossReturnErrorPeriodNotClosed || err == ossReturnErrorAlreadyFiled {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = err.(*billingpb.ResponseErrorMessage)
+		}
+
+		return nil
+	}
+
+	if err = s.renderOssReturn(ctx, ossReturn); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = ossReturnErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = ossReturn
+
+	return nil
+}
+
+// GenerateOssReturns creates drafts of the One-Stop-Shop returns of all operating companies for the quarter
+// previous to the date. Operating companies without supplies to EU consumers and without corrections
+// in the quarter and returns which are already filed are skipped.
+func (s *Service) GenerateOssReturns(ctx context.Context, date time.Time) error {
+	quarter := now.New(date.Local()).BeginningOfQuarter().AddDate(0, -ossReturnMonthsInQuarter, 0)
+	year := int32(quarter.Year())
+	number := int32(quarter.Month()-1)/ossReturnMonthsInQuarter + 1
+
+	operatingCompanies, err := s.operatingCompanyRepository.GetAll(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, oc := range operatingCompanies {
+		ossReturn, err := s.generateOssReturn(ctx, oc, year, number, "")
+
+		if err == ossReturnErrorAlreadyFiled {
+			zap.L().Info(
+				ossReturnErrorAlreadyFiled.Message,
+				zap.String("operating_company_id", oc.Id),
+				zap.Int32("year", year),
+				zap.Int32("quarter", number),
+			)
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if ossReturn == nil {
+			continue
+		}
+
+		if err = s.renderOssReturn(ctx, ossReturn); err != nil {
+			return err
+		}
+
+		zap.L().Info(
+			"oss return generated",
+			zap.String("operating_company_id", oc.Id),
+			zap.Int32("year", year),
+			zap.Int32("quarter", number),
+		)
+	}
+
+	return nil
+}
+
+func (s *Service) GetOssReturn(
+	ctx context.Context,
+	req *intPkg.GetOssReturnRequest,
+	rsp *intPkg.OssReturnResponse,
+) error {
+	ossReturn, err := s.ossReturnRepository.GetById(ctx, req.ReturnId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = ossReturnErrorNotFound
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = ossReturn
+
+	return nil
+}
+
+func (s *Service) ListOssReturns(
+	ctx context.Context,
+	req *intPkg.ListOssReturnsRequest,
+	rsp *intPkg.ListOssReturnsResponse,
+) error {
+	var err error
+
+	rsp.Count, err = s.ossReturnRepository.FindCount(ctx, req.OperatingCompanyId, req.Year, req.Status)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = ossReturnErrorUnknown
+		return nil
+	}
+
+	if rsp.Count > 0 {
+		rsp.Items, err = s.ossReturnRepository.Find(
+			ctx,
+			req.OperatingCompanyId,
+			req.Year,
+			req.Status,
+			req.Offset,
+			req.Limit,
+		)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = ossReturnErrorUnknown
+			return nil
+		}
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// UpdateOssReturnStatus changes the filing status of the return. The draft may be submitted only if the VAT reports
+// of the return weren't changed after the generation of the draft. Payment of the return marks the underlying
+// VAT reports as paid.
+func (s *Service) UpdateOssReturnStatus(
+	ctx context.Context,
+	req *intPkg.UpdateOssReturnStatusRequest,
+	rsp *intPkg.OssReturnResponse,
+) error {
+	ossReturn, err := s.ossReturnRepository.GetById(ctx, req.ReturnId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = ossReturnErrorNotFound
+		return nil
+	}
+
+	if !helper.Contains(ossReturnStatusTransitions[ossReturn.Status], req.Status) {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = ossReturnErrorStatusChange
+		return nil
+	}
+
+	switch req.Status {
+	case pkg.OssReturnStatusSubmitted:
+		changed, err := s.isOssReturnVatReportsChanged(ctx, ossReturn)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = ossReturnErrorUnknown
+			return nil
+		}
+
+		if changed {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = ossReturnErrorVatReportsChanged
+			return nil
+		}
+
+		ossReturn.SubmittedAt = time.Now()
+		break
+
+	case pkg.OssReturnStatusPaid:
+		ossReturn.PaidAt = time.Now()
+
+		if err = s.payOssReturnVatReports(ctx, ossReturn); err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = ossReturnErrorUnknown
+			return nil
+		}
+		break
+	}
+
+	if req.Reference != "" {
+		ossReturn.Reference = req.Reference
+	}
+
+	ossReturn.Status = req.Status
+
+	if err = s.ossReturnRepository.Update(ctx, ossReturn); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = ossReturnErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = ossReturn
+
+	return nil
+}
+
+// generateOssReturn creates or updates the draft of the return of the operating company for the quarter.
+// Returns nil without error for the task if the return would be empty and it doesn't exist yet.
+func (s *Service) generateOssReturn(
+	ctx context.Context,
+	oc *billingpb.OperatingCompany,
+	year, quarter int32,
+	userId string,
+) (*intPkg.OssReturn, error) {
+	returns, err := s.ossReturnRepository.Find(ctx, oc.Id, 0, nil, 0, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ossReturn *intPkg.OssReturn
+
+	for _, v := range returns {
+		if v.Year != year || v.Quarter != quarter ||
+			v.Status == pkg.OssReturnStatusRejected || v.Status == pkg.OssReturnStatusCanceled {
+			continue
+		}
+
+		if v.Status != pkg.OssReturnStatusDraft {
+			return nil, ossReturnErrorAlreadyFiled
+		}
+
+		ossReturn = v
+	}
+
+	from, to := getOssReturnPeriodDates(year, quarter)
+
+	if ossReturn == nil {
+		ossReturn = &intPkg.OssReturn{
+			Id:                 primitive.NewObjectID(),
+			OperatingCompanyId: oc.Id,
+			Year:               year,
+			Quarter:            quarter,
+			Status:             pkg.OssReturnStatusDraft,
+		}
+	}
+
+	ossReturn.Scheme = pkg.OssReturnSchemeNonUnion
+	ossReturn.DateFrom = from
+	ossReturn.DateTo = to
+	ossReturn.Currency = ossReturnCurrency
+	ossReturn.CreatedBy = userId
+
+	if helper.Contains(pkg.EuMemberStates, oc.Country) {
+		ossReturn.Scheme = pkg.OssReturnSchemeUnion
+	}
+
+	reports, err := s.getOssReturnVatReports(ctx, oc, ossReturn.Scheme, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, report := range reports {
+		if report.Status == pkg.VatReportStatusThreshold {
+			return nil, ossReturnErrorPeriodNotClosed
+		}
+	}
+
+	reports = filterOssReturnVatReports(reports)
+	ossReturn.VatReportIds = make([]string, len(reports))
+
+	for i, report := range reports {
+		ossReturn.VatReportIds[i] = report.Id
+	}
+
+	ossReturn.Lines, err = s.getOssReturnLines(ctx, reports, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ossReturn.Corrections, err = s.getOssReturnCorrections(ctx, oc, ossReturn, returns)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ossReturn.CreatedAt.IsZero() && userId == "" && len(ossReturn.Lines) == 0 && len(ossReturn.Corrections) == 0 {
+		return nil, nil
+	}
+
+	ossReturn.TotalVatAmount = 0
+
+	for _, line := range ossReturn.Lines {
+		ossReturn.TotalVatAmount += line.VatAmount
+	}
+
+	for _, correction := range ossReturn.Corrections {
+		ossReturn.TotalVatAmount += correction.VatAmount
+	}
+
+	ossReturn.TotalVatAmount = tools.FormatAmount(ossReturn.TotalVatAmount)
+	ossReturn.FileName = fmt.Sprintf(ossReturnFileNameTemplate, oc.Id, year, quarter)
+	ossReturn.File, err = getOssReturnXml(ossReturn, oc)
+
+	if err != nil {
+		zap.L().Error(
+			"OSS return file generation failed",
+			zap.Error(err),
+			zap.String("operating_company_id", oc.Id),
+			zap.Int32("year", year),
+			zap.Int32("quarter", quarter),
+		)
+		return nil, err
+	}
+
+	if ossReturn.CreatedAt.IsZero() {
+		err = s.ossReturnRepository.Insert(ctx, ossReturn)
+	} else {
+		err = s.ossReturnRepository.Update(ctx, ossReturn)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ossReturn, nil
+}
+
+// getOssReturnVatReports returns VAT reports of the operating company for member states of consumption
+// which periods are within the dates. Supplies to consumers of the member state of identification are declared
+// by the domestic VAT return under the union scheme, so its reports are excluded.
+func (s *Service) getOssReturnVatReports(
+	ctx context.Context,
+	oc *billingpb.OperatingCompany,
+	scheme string,
+	from, to time.Time,
+) ([]*billingpb.VatReport, error) {
+	reports, err := s.vatReportRepository.FindByOperatingCompanyPeriod(ctx, oc.Id, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*billingpb.VatReport
+
+	for _, report := range reports {
+		if !helper.Contains(pkg.EuMemberStates, report.Country) {
+			continue
+		}
+
+		if scheme == pkg.OssReturnSchemeUnion && report.Country == oc.Country {
+			continue
+		}
+
+		result = append(result, report)
+	}
+
+	return result, nil
+}
+
+// getOssReturnLines groups amounts of VAT reports by member state of consumption and VAT rate. The rate equal
+// to the standard rate of the member state on the last day of the period is standard, other rates are reduced.
+// Amounts are converted to EUR at the rates of the European Central Bank on the last day of the period.
+func (s *Service) getOssReturnLines(
+	ctx context.Context,
+	reports []*billingpb.VatReport,
+	to time.Time,
+) ([]*intPkg.OssReturnLine, error) {
+	var lines []*intPkg.OssReturnLine
+
+	index := make(map[string]*intPkg.OssReturnLine)
+
+	for _, report := range reports {
+		key := report.Country + strconv.FormatFloat(report.VatRate, 'f', -1, 64)
+		line, ok := index[key]
+
+		if !ok {
+			line = &intPkg.OssReturnLine{
+				Country: report.Country,
+				VatRate: report.VatRate,
+			}
+			index[key] = line
+			lines = append(lines, line)
+		}
+
+		taxableAmount, err := s.exchangeOssReturnAmount(ctx, report.Currency, report.GrossRevenue-report.VatAmount, to)
+
+		if err != nil {
+			return nil, err
+		}
+
+		vatAmount, err := s.exchangeOssReturnAmount(ctx, report.Currency, report.VatAmount, to)
+
+		if err != nil {
+			return nil, err
+		}
+
+		line.TransactionsCount += report.TransactionsCount
+		line.TaxableAmount += taxableAmount
+		line.VatAmount += vatAmount
+	}
+
+	standardRates := make(map[string]float64)
+
+	for _, line := range lines {
+		standardRate, ok := standardRates[line.Country]
+
+		if !ok {
+			req := &intPkg.TaxRateRequest{Country: line.Country, Date: to}
+			rsp, err := s.taxProvider.GetRate(ctx, req)
+
+			if err != nil {
+				zap.L().Error(errorMsgVatReportTaxServiceGetRateFailed, zap.Error(err), zap.Any(errorFieldRequest, req))
+				return nil, err
+			}
+
+			standardRate = rsp.Rate
+			standardRates[line.Country] = standardRate
+		}
+
+		line.TaxableAmount = tools.FormatAmount(line.TaxableAmount)
+		line.VatAmount = tools.FormatAmount(line.VatAmount)
+		line.RateType = pkg.OssReturnRateTypeReduced
+
+		if tools.ToPrecise(line.VatRate) == tools.ToPrecise(standardRate) {
+			line.RateType = pkg.OssReturnRateTypeStandard
+		}
+	}
+
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Country != lines[j].Country {
+			return lines[i].Country < lines[j].Country
+		}
+
+		return lines[i].VatRate > lines[j].VatRate
+	})
+
+	return lines, nil
+}
+
+// getOssReturnCorrections compares VAT of member states declared for prior quarters within the correction period
+// by filed returns and their corrections with VAT of the latest versions of VAT reports of these quarters.
+// The differences are declared as corrections by the return.
+func (s *Service) getOssReturnCorrections(
+	ctx context.Context,
+	oc *billingpb.OperatingCompany,
+	ossReturn *intPkg.OssReturn,
+	returns []*intPkg.OssReturn,
+) ([]*intPkg.OssReturnCorrection, error) {
+	var corrections []*intPkg.OssReturnCorrection
+
+	period := getOssReturnPeriodIndex(ossReturn.Year, ossReturn.Quarter)
+
+	for _, filed := range returns {
+		filedPeriod := getOssReturnPeriodIndex(filed.Year, filed.Quarter)
+
+		if !helper.Contains(ossReturnFiledStatuses, filed.Status) || filedPeriod >= period ||
+			filedPeriod < period-ossReturnCorrectionPeriods {
+			continue
+		}
+
+		declared := make(map[string]float64)
+
+		for _, line := range filed.Lines {
+			declared[line.Country] += line.VatAmount
+		}
+
+		for _, v := range returns {
+			if v.Id == ossReturn.Id || !helper.Contains(ossReturnFiledStatuses, v.Status) {
+				continue
+			}
+
+			for _, correction := range v.Corrections {
+				if correction.Year == filed.Year && correction.Quarter == filed.Quarter {
+					declared[correction.Country] += correction.VatAmount
+				}
+			}
+		}
+
+		reports, err := s.getOssReturnVatReports(ctx, oc, filed.Scheme, filed.DateFrom, filed.DateTo)
+
+		if err != nil {
+			return nil, err
+		}
+
+		reports, err = s.getOssReturnAmendedVatReports(ctx, filterOssReturnVatReports(reports))
+
+		if err != nil {
+			return nil, err
+		}
+
+		lines, err := s.getOssReturnLines(ctx, reports, filed.DateTo)
+
+		if err != nil {
+			return nil, err
+		}
+
+		actual := make(map[string]float64)
+
+		for _, line := range lines {
+			actual[line.Country] += line.VatAmount
+		}
+
+		var countries []string
+
+		for country := range declared {
+			countries = append(countries, country)
+		}
+
+		for country := range actual {
+			if _, ok := declared[country]; !ok {
+				countries = append(countries, country)
+			}
+		}
+
+		sort.Strings(countries)
+
+		for _, country := range countries {
+			amount := tools.FormatAmount(actual[country] - declared[country])
+
+			if amount == 0 {
+				continue
+			}
+
+			corrections = append(corrections, &intPkg.OssReturnCorrection{
+				Country:   country,
+				Year:      filed.Year,
+				Quarter:   filed.Quarter,
+				VatAmount: amount,
+			})
+		}
+	}
+
+	sort.SliceStable(corrections, func(i, j int) bool {
+		return getOssReturnPeriodIndex(corrections[i].Year, corrections[i].Quarter) <
+			getOssReturnPeriodIndex(corrections[j].Year, corrections[j].Quarter)
+	})
+
+	return corrections, nil
+}
+
+// isOssReturnVatReportsChanged checks that VAT reports of the return were changed or canceled after the generation
+// of the return or new VAT reports of the quarter were created.
+func (s *Service) isOssReturnVatReportsChanged(ctx context.Context, ossReturn *intPkg.OssReturn) (bool, error) {
+	oc, err := s.operatingCompanyRepository.GetById(ctx, ossReturn.OperatingCompanyId)
+
+	if err != nil {
+		return false, err
+	}
+
+	reports, err := s.getOssReturnVatReports(ctx, oc, ossReturn.Scheme, ossReturn.DateFrom, ossReturn.DateTo)
+
+	if err != nil {
+		return false, err
+	}
+
+	reports = filterOssReturnVatReports(reports)
+
+	if len(reports) != len(ossReturn.VatReportIds) {
+		return true, nil
+	}
+
+	for _, report := range reports {
+		if !helper.Contains(ossReturn.VatReportIds, report.Id) {
+			return true, nil
+		}
+
+		updatedAt, err := ptypes.Timestamp(report.UpdatedAt)
+
+		if err != nil {
+			return false, err
+		}
+
+		if updatedAt.After(ossReturn.UpdatedAt) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// payOssReturnVatReports marks unpaid VAT reports of the return as paid.
+func (s *Service) payOssReturnVatReports(ctx context.Context, ossReturn *intPkg.OssReturn) error {
+	for _, id := range ossReturn.VatReportIds {
+		report, err := s.vatReportRepository.GetById(ctx, id)
+
+		if err != nil {
+			return err
+		}
+
+		if report.Status != pkg.VatReportStatusNeedToPay && report.Status != pkg.VatReportStatusOverdue {
+			continue
+		}
+
+		report.Status = pkg.VatReportStatusPaid
+		report.PaidAt = ptypes.TimestampNow()
+
+		if err = s.updateVatReport(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) exchangeOssReturnAmount(
+	ctx context.Context,
+	currency string,
+	amount float64,
+	date time.Time,
+) (float64, error) {
+	if currency == ossReturnCurrency || amount == 0 {
+		return amount, nil
+	}
+
+	datetime, err := ptypes.TimestampProto(date)
+
+	if err != nil {
+		return 0, err
+	}
+
+	req := &currenciespb.ExchangeCurrencyByDateCommonRequest{
+		From:              currency,
+		To:                ossReturnCurrency,
+		RateType:          currenciespb.RateTypeCentralbanks,
+		ExchangeDirection: currenciespb.ExchangeDirectionBuy,
+		Source:            ossReturnCurrencyRatesSource,
+		Amount:            amount,
+		Datetime:          datetime,
+	}
+
+	rsp, err := s.curService.ExchangeCurrencyByDateCommon(ctx, req)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorGrpcServiceCallFailed,
+			zap.Error(err),
+			zap.String(errorFieldService, "CurrencyRatesService"),
+			zap.String(errorFieldMethod, "ExchangeCurrencyByDateCommon"),
+			zap.Any(errorFieldRequest, req),
+		)
+
+		return 0, errorVatReportCurrencyExchangeFailed
+	}
+
+	return rsp.ExchangedAmount, nil
+}
+
+func (s *Service) renderOssReturn(ctx context.Context, ossReturn *intPkg.OssReturn) error {
+	params, err := json.Marshal(map[string]interface{}{reporterpb.ParamsFieldId: ossReturn.Id.Hex()})
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to marshal the params of oss return for the reporting service.",
+			zap.Error(err),
+		)
+		return err
+	}
+
+	req := &reporterpb.ReportFile{
+		UserId:           ossReturn.CreatedBy,
+		ReportType:       pkg.ReportTypeOssReturn,
+		FileType:         reporterpb.OutputExtensionPdf,
+		Params:           params,
+		SendNotification: ossReturn.CreatedBy != "",
+	}
+
+	return s.reporterServiceCreateFile(ctx, req)
+}
+
+// getOssReturnAmendedVatReports returns VAT reports with amounts of their latest versions. VAT reports stay as they
+// were due to pay, late refunds and corrections of orders of the period are stored by amendments only.
+func (s *Service) getOssReturnAmendedVatReports(
+	ctx context.Context,
+	reports []*billingpb.VatReport,
+) ([]*billingpb.VatReport, error) {
+	result := make([]*billingpb.VatReport, 0, len(reports))
+
+	for _, report := range reports {
+		versions, err := s.vatReportVersionRepository.FindByVatReportId(ctx, report.Id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(versions) == 0 {
+			result = append(result, report)
+			continue
+		}
+
+		amended := &billingpb.VatReport{
+			Id:       report.Id,
+			Country:  report.Country,
+			VatRate:  report.VatRate,
+			Currency: report.Currency,
+		}
+
+		for _, line := range versions[len(versions)-1].Lines {
+			switch line.Name {
+			case pkg.VatReportLineTransactionsCount:
+				amended.TransactionsCount = int32(line.Amount)
+			case pkg.VatReportLineGrossRevenue:
+				amended.GrossRevenue = line.Amount
+			case pkg.VatReportLineVatAmount:
+				amended.VatAmount = line.Amount
+			}
+		}
+
+		result = append(result, amended)
+	}
+
+	return result, nil
+}
+
+// filterOssReturnVatReports returns VAT reports of ended periods which are due to pay.
+func filterOssReturnVatReports(reports []*billingpb.VatReport) []*billingpb.VatReport {
+	var result []*billingpb.VatReport
+
+	for _, report := range reports {
+		if helper.Contains(ossReturnVatReportStatuses, report.Status) {
+			result = append(result, report)
+		}
+	}
+
+	return result
+}
+
+func getOssReturnXml(ossReturn *intPkg.OssReturn, oc *billingpb.OperatingCompany) ([]byte, error) {
+	doc := &ossReturnDocument{
+		Header: &ossReturnHeader{
+			Scheme:                  ossReturnXmlSchemes[ossReturn.Scheme],
+			VatIdentificationNumber: oc.VatNumber,
+			TraderName:              oc.Name,
+			Period: &ossReturnPeriod{
+				Year:    ossReturn.Year,
+				Quarter: ossReturn.Quarter,
+			},
+			Currency:         ossReturn.Currency,
+			CreationDateTime: time.Now().UTC().Format(ossReturnDateTimeLayout),
+		},
+		TotalVatAmount: formatOssReturnAmount(ossReturn.TotalVatAmount),
+	}
+
+	if ossReturn.Scheme == pkg.OssReturnSchemeUnion {
+		doc.Header.MemberStateOfIdentification = oc.Country
+	}
+
+	var countries []string
+	totals := make(map[string]float64)
+
+	for _, line := range ossReturn.Lines {
+		doc.Supplies = append(doc.Supplies, &ossReturnSupply{
+			MemberStateOfConsumption: line.Country,
+			SupplyType:               ossReturnSupplyTypeServices,
+			VatRateType:              ossReturnXmlRateTypes[line.RateType],
+			VatRate:                  formatOssReturnAmount(line.VatRate * ossReturnPercentMultiplier),
+			TaxableAmount:            formatOssReturnAmount(line.TaxableAmount),
+			VatAmount:                formatOssReturnAmount(line.VatAmount),
+		})
+
+		if _, ok := totals[line.Country]; !ok {
+			countries = append(countries, line.Country)
+		}
+
+		totals[line.Country] += line.VatAmount
+	}
+
+	for _, correction := range ossReturn.Corrections {
+		doc.Corrections = append(doc.Corrections, &ossReturnCorrection{
+			MemberStateOfConsumption: correction.Country,
+			Period: &ossReturnPeriod{
+				Year:    correction.Year,
+				Quarter: correction.Quarter,
+			},
+			VatAmount: formatOssReturnAmount(correction.VatAmount),
+		})
+
+		if _, ok := totals[correction.Country]; !ok {
+			countries = append(countries, correction.Country)
+		}
+
+		totals[correction.Country] += correction.VatAmount
+	}
+
+	sort.Strings(countries)
+
+	for _, country := range countries {
+		doc.MemberStateTotals = append(doc.MemberStateTotals, &ossReturnMemberStateVat{
+			MemberStateOfConsumption: country,
+			VatAmount:                formatOssReturnAmount(totals[country]),
+		})
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
+
+// getOssReturnPeriodDates returns dates of the quarter in the same location as dates of VAT reports.
+func getOssReturnPeriodDates(year, quarter int32) (time.Time, time.Time) {
+	month := time.Month((quarter-1)*ossReturnMonthsInQuarter + 1)
+	date := now.New(time.Date(int(year), month, 1, 0, 0, 0, 0, time.Local))
+
+	return date.BeginningOfQuarter(), date.EndOfQuarter()
+}
+
+func getOssReturnPeriodIndex(year, quarter int32) int32 {
+	return year*ossReturnQuartersInYear + quarter - 1
+}
+
+func formatOssReturnAmount(amount float64) string {
+	return strconv.FormatFloat(tools.FormatAmount(amount), 'f', ossReturnAmountFormatDecimals, 64)
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/jinzhu/now"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/reporterpb"
+	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+func (suite *VatReportsTestSuite) TestOssReturn_GenerateOssReturn_Ok() {
+	oc := HelperOperatingCompany(suite.Suite, suite.service)
+	reporterMock := suite.helperOssReturnReporterMock()
+	suite.helperOssReturnStandardRates(map[string]float64{"DE": 0.19, "FR": 0.2})
+
+	suite.helperInsertOssVatReport(oc.Id, "DE", 0.19, 1190, 190, time.January, pkg.VatReportStatusNeedToPay)
+	suite.helperInsertOssVatReport(oc.Id, "DE", 0.19, 595, 95, time.February, pkg.VatReportStatusOverdue)
+	suite.helperInsertOssVatReport(oc.Id, "DE", 0.07, 107, 7, time.March, pkg.VatReportStatusPaid)
+	suite.helperInsertOssVatReport(oc.Id, "FR", 0.2, 600, 100, time.January, pkg.VatReportStatusNeedToPay)
+	suite.helperInsertOssVatReport(oc.Id, "FR", 0.2, 60, 10, time.February, pkg.VatReportStatusExpired)
+	suite.helperInsertOssVatReport(oc.Id, "US", 0.1, 110, 10, time.January, pkg.VatReportStatusNeedToPay)
+
+	req := &intPkg.GenerateOssReturnRequest{
+		OperatingCompanyId: oc.Id,
+		Year:               2020,
+		Quarter:            1,
+		UserId:             primitive.NewObjectID().Hex(),
+	}
+	rsp := &intPkg.OssReturnResponse{}
+	err := suite.service.GenerateOssReturn(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.OssReturnStatusDraft, rsp.Item.Status)
+	assert.Equal(suite.T(), pkg.OssReturnSchemeNonUnion, rsp.Item.Scheme)
+	assert.Equal(suite.T(), "EUR", rsp.Item.Currency)
+	assert.Len(suite.T(), rsp.Item.VatReportIds, 4)
+	assert.Empty(suite.T(), rsp.Item.Corrections)
+	assert.Equal(suite.T(), 392.0, rsp.Item.TotalVatAmount)
+
+	assert.Len(suite.T(), rsp.Item.Lines, 3)
+	assert.Equal(suite.T(), "DE", rsp.Item.Lines[0].Country)
+	assert.Equal(suite.T(), pkg.OssReturnRateTypeStandard, rsp.Item.Lines[0].RateType)
+	assert.Equal(suite.T(), 285.0, rsp.Item.Lines[0].VatAmount)
+	assert.Equal(suite.T(), 1500.0, rsp.Item.Lines[0].TaxableAmount)
+	assert.Equal(suite.T(), "DE", rsp.Item.Lines[1].Country)
+	assert.Equal(suite.T(), pkg.OssReturnRateTypeReduced, rsp.Item.Lines[1].RateType)
+	assert.Equal(suite.T(), 7.0, rsp.Item.Lines[1].VatAmount)
+	assert.Equal(suite.T(), "FR", rsp.Item.Lines[2].Country)
+	assert.Equal(suite.T(), pkg.OssReturnRateTypeStandard, rsp.Item.Lines[2].RateType)
+	assert.Equal(suite.T(), 100.0, rsp.Item.Lines[2].VatAmount)
+
+	file := string(rsp.Item.File)
+	assert.Contains(suite.T(), file, "<Scheme>NON-UNION</Scheme>")
+	assert.Contains(suite.T(), file, "<VATRateType>REDUCED</VATRateType>")
+	assert.Contains(suite.T(), file, "<VATRate>19.00</VATRate>")
+	assert.Contains(suite.T(), file, "<TotalVATAmountDue>392.00</TotalVATAmountDue>")
+	assert.NotContains(suite.T(), file, "<MemberStateOfConsumption>US</MemberStateOfConsumption>")
+
+	fileReq := reporterMock.Calls[0].Arguments.Get(1).(*reporterpb.ReportFile)
+	assert.Equal(suite.T(), pkg.ReportTypeOssReturn, fileReq.ReportType)
+	assert.Equal(suite.T(), reporterpb.OutputExtensionPdf, fileReq.FileType)
+	assert.Equal(suite.T(), req.UserId, fileReq.UserId)
+	assert.Contains(suite.T(), string(fileReq.Params), rsp.Item.Id.Hex())
+
+	id := rsp.Item.Id
+	rsp = &intPkg.OssReturnResponse{}
+	err = suite.service.GenerateOssReturn(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), id, rsp.Item.Id)
+
+	listRsp := &intPkg.ListOssReturnsResponse{}
+	err = suite.service.ListOssReturns(context.TODO(), &intPkg.ListOssReturnsRequest{OperatingCompanyId: oc.Id}, listRsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, listRsp.Status)
+	assert.EqualValues(suite.T(), 1, listRsp.Count)
+}
+
+func (suite *VatReportsTestSuite) TestOssReturn_GenerateOssReturn_OnlyReducedRate() {
+	oc := HelperOperatingCompany(suite.Suite, suite.service)
+	suite.helperOssReturnReporterMock()
+	suite.helperOssReturnStandardRates(map[string]float64{"DE": 0.19})
+
+	suite.helperInsertOssVatReport(oc.Id, "DE", 0.07, 107, 7, time.March, pkg.VatReportStatusPaid)
+
+	req := &intPkg.GenerateOssReturnRequest{OperatingCompanyId: oc.Id, Year: 2020, Quarter: 1}
+	rsp := &intPkg.OssReturnResponse{}
+	err := suite.service.GenerateOssReturn(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Item.Lines, 1)
+	assert.Equal(suite.T(), "DE", rsp.Item.Lines[0].Country)
+	assert.Equal(suite.T(), pkg.OssReturnRateTypeReduced, rsp.Item.Lines[0].RateType)
+}
+
+func (suite *VatReportsTestSuite) TestOssReturn_GenerateOssReturn_UnionScheme_ExcludesMemberStateOfIdentification() {
+	oc := HelperOperatingCompany(suite.Suite, suite.service)
+	oc.Country = "DE"
+	err := suite.service.operatingCompanyRepository.Upsert(context.TODO(), oc)
+	assert.NoError(suite.T(), err)
+	suite.helperOssReturnReporterMock()
+
+	suite.helperInsertOssVatReport(oc.Id, "DE", 0.19, 1190, 190, time.January, pkg.VatReportStatusNeedToPay)
+	suite.helperInsertOssVatReport(oc.Id, "FR", 0.2, 600, 100, time.January, pkg.VatReportStatusNeedToPay)
+
+	req := &intPkg.GenerateOssReturnRequest{OperatingCompanyId: oc.Id, Year: 2020, Quarter: 1}
+	rsp := &intPkg.OssReturnResponse{}
+	err = suite.service.GenerateOssReturn(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.OssReturnSchemeUnion, rsp.Item.Scheme)
+	assert.Len(suite.T(), rsp.Item.Lines, 1)
+	assert.Equal(suite.T(), "FR", rsp.Item.Lines[0].Country)
+	assert.Contains(suite.T(), string(rsp.Item.File), "<MemberStateOfIdentification>DE</MemberStateOfIdentification>")
+}
+
+func (suite *VatReportsTestSuite) TestOssReturn_GenerateOssReturn_PeriodInvalid_Error() {
+	oc := HelperOperatingCompany(suite.Suite, suite.service)
+
+	req := &intPkg.GenerateOssReturnRequest{OperatingCompanyId: oc.Id, Year: 2020, Quarter: 5}
+	rsp := &intPkg.OssReturnResponse{}
+	err := suite.service.GenerateOssReturn(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), ossReturnErrorPeriodInvalid, rsp.Message)
+
+	req.Year = int32(time.Now().Year())
+	req.Quarter = int32(time.Now().Month()-1)/ossReturnMonthsInQuarter + 1
+	rsp = &intPkg.OssReturnResponse{}
+	err = suite.service.GenerateOssReturn(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), ossReturnErrorPeriodInvalid, rsp.Message)
+}
+
+func (suite *VatReportsTestSuite) TestOssReturn_GenerateOssReturn_PeriodNotClosed_Error() {
+	oc := HelperOperatingCompany(suite.Suite, suite.service)
+	suite.helperInsertOssVatReport(oc.Id, "DE", 0.19, 1190, 190, time.January, pkg.VatReportStatusThreshold)
+
+	req := &intPkg.GenerateOssReturnRequest{OperatingCompanyId: oc.Id, Year: 2020, Quarter: 1}
+	rsp := &intPkg.OssReturnResponse{}
+	err := suite.service.GenerateOssReturn(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), ossReturnErrorPeriodNotClosed, rsp.Message)
+}
+
+func (suite *VatReportsTestSuite) TestOssReturn_UpdateOssReturnStatus_Lifecycle() {
+	oc := HelperOperatingCompany(suite.Suite, suite.service)
+	suite.helperOssReturnReporterMock()
+	report := suite.helperInsertOssVatReport(oc.Id, "DE", 0.19, 1190, 190, time.January, pkg.VatReportStatusNeedToPay)
+
+	req := &intPkg.GenerateOssReturnRequest{OperatingCompanyId: oc.Id, Year: 2020, Quarter: 1}
+	rsp := &intPkg.OssReturnResponse{}
+	err := suite.service.GenerateOssReturn(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	id := rsp.Item.Id.Hex()
+
+	statusReq := &intPkg.UpdateOssReturnStatusRequest{ReturnId: id, Status: pkg.OssReturnStatusPaid}
+	rsp = &intPkg.OssReturnResponse{}
+	err = suite.service.UpdateOssReturnStatus(context.TODO(), statusReq, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), ossReturnErrorStatusChange, rsp.Message)
+
+	statusReq = &intPkg.UpdateOssReturnStatusRequest{ReturnId: id, Status: pkg.OssReturnStatusSubmitted, Reference: "OSS-123"}
+	rsp = &intPkg.OssReturnResponse{}
+	err = suite.service.UpdateOssReturnStatus(context.TODO(), statusReq, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.OssReturnStatusSubmitted, rsp.Item.Status)
+	assert.Equal(suite.T(), "OSS-123", rsp.Item.Reference)
+	assert.False(suite.T(), rsp.Item.SubmittedAt.IsZero())
+
+	rsp = &intPkg.OssReturnResponse{}
+	err = suite.service.GenerateOssReturn(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), ossReturnErrorAlreadyFiled, rsp.Message)
+
+	for _, status := range []string{pkg.OssReturnStatusAccepted, pkg.OssReturnStatusPaid} {
+		statusReq = &intPkg.UpdateOssReturnStatusRequest{ReturnId: id, Status: status}
+		rsp = &intPkg.OssReturnResponse{}
+		err = suite.service.UpdateOssReturnStatus(context.TODO(), statusReq, rsp)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+		assert.Equal(suite.T(), status, rsp.Item.Status)
+	}
+
+	assert.False(suite.T(), rsp.Item.PaidAt.IsZero())
+
+	vr, err := suite.service.vatReportRepository.GetById(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.VatReportStatusPaid, vr.Status)
+	assert.NotNil(suite.T(), vr.PaidAt)
+}
+
+func (suite *VatReportsTestSuite) TestOssReturn_UpdateOssReturnStatus_VatReportsChanged_Error() {
+	oc := HelperOperatingCompany(suite.Suite, suite.service)
+	suite.helperOssReturnReporterMock()
+	report := suite.helperInsertOssVatReport(oc.Id, "DE", 0.19, 1190, 190, time.January, pkg.VatReportStatusNeedToPay)
+
+	req := &intPkg.GenerateOssReturnRequest{OperatingCompanyId: oc.Id, Year: 2020, Quarter: 1}
+	rsp := &intPkg.OssReturnResponse{}
+	err := suite.service.GenerateOssReturn(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	time.Sleep(10 * time.Millisecond)
+	report.VatAmount = 200
+	err = suite.service.vatReportRepository.Update(context.TODO(), report)
+	assert.NoError(suite.T(), err)
+
+	statusReq := &intPkg.UpdateOssReturnStatusRequest{ReturnId: rsp.Item.Id.Hex(), Status: pkg.OssReturnStatusSubmitted}
+	rsp = &intPkg.OssReturnResponse{}
+	err = suite.service.UpdateOssReturnStatus(context.TODO(), statusReq, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), ossReturnErrorVatReportsChanged, rsp.Message)
+}
+
+func (suite *VatReportsTestSuite) TestOssReturn_GenerateOssReturn_PriorPeriodCorrections() {
+	oc := HelperOperatingCompany(suite.Suite, suite.service)
+	suite.helperOssReturnReporterMock()
+	report := suite.helperInsertOssVatReport(oc.Id, "DE", 0.19, 1190, 190, time.January, pkg.VatReportStatusNeedToPay)
+	suite.helperInsertOssVatReport(oc.Id, "FR", 0.2, 600, 100, time.April, pkg.VatReportStatusNeedToPay)
+
+	req := &intPkg.GenerateOssReturnRequest{OperatingCompanyId: oc.Id, Year: 2020, Quarter: 1}
+	rsp := &intPkg.OssReturnResponse{}
+	err := suite.service.GenerateOssReturn(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	statusReq := &intPkg.UpdateOssReturnStatusRequest{ReturnId: rsp.Item.Id.Hex(), Status: pkg.OssReturnStatusSubmitted}
+	rsp = &intPkg.OssReturnResponse{}
+	err = suite.service.UpdateOssReturnStatus(context.TODO(), statusReq, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	report.VatAmount = 180
+	err = suite.service.vatReportRepository.Update(context.TODO(), report)
+	assert.NoError(suite.T(), err)
+
+	req.Quarter = 2
+	rsp = &intPkg.OssReturnResponse{}
+	err = suite.service.GenerateOssReturn(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Item.Lines, 1)
+	assert.Equal(suite.T(), "FR", rsp.Item.Lines[0].Country)
+	assert.Len(suite.T(), rsp.Item.Corrections, 1)
+	assert.Equal(suite.T(), "DE", rsp.Item.Corrections[0].Country)
+	assert.EqualValues(suite.T(), 2020, rsp.Item.Corrections[0].Year)
+	assert.EqualValues(suite.T(), 1, rsp.Item.Corrections[0].Quarter)
+	assert.Equal(suite.T(), -10.0, rsp.Item.Corrections[0].VatAmount)
+	assert.Equal(suite.T(), 90.0, rsp.Item.TotalVatAmount)
+	assert.Contains(suite.T(), string(rsp.Item.File), "<VATAmount>-10.00</VATAmount>")
+}
+
+func (suite *VatReportsTestSuite) TestOssReturn_GenerateOssReturn_PriorPeriodAmendments() {
+	oc := HelperOperatingCompany(suite.Suite, suite.service)
+	suite.helperOssReturnReporterMock()
+	report := suite.helperInsertOssVatReport(oc.Id, "DE", 0.19, 1190, 190, time.January, pkg.VatReportStatusPaid)
+	suite.helperInsertOssVatReport(oc.Id, "FR", 0.2, 600, 100, time.April, pkg.VatReportStatusNeedToPay)
+
+	req := &intPkg.GenerateOssReturnRequest{OperatingCompanyId: oc.Id, Year: 2020, Quarter: 1}
+	rsp := &intPkg.OssReturnResponse{}
+	err := suite.service.GenerateOssReturn(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	statusReq := &intPkg.UpdateOssReturnStatusRequest{ReturnId: rsp.Item.Id.Hex(), Status: pkg.OssReturnStatusSubmitted}
+	rsp = &intPkg.OssReturnResponse{}
+	err = suite.service.UpdateOssReturnStatus(context.TODO(), statusReq, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	original, err := suite.service.createVatReportOriginalVersion(context.TODO(), report)
+	assert.NoError(suite.T(), err)
+
+	amendment := &intPkg.VatReportVersion{
+		VatReportId:        report.Id,
+		Version:            original.Version + 1,
+		Type:               pkg.VatReportVersionTypeAmendment,
+		OperatingCompanyId: oc.Id,
+		Country:            report.Country,
+		Currency:           report.Currency,
+		Lines: []*intPkg.VatReportVersionLine{
+			{Name: pkg.VatReportLineTransactionsCount, Amount: 9, Delta: -1},
+			{Name: pkg.VatReportLineGrossRevenue, Amount: 1130, Delta: -60},
+			{Name: pkg.VatReportLineVatAmount, Amount: 180, Delta: -10},
+		},
+		CorrectionAmount: -10,
+	}
+	err = suite.service.vatReportVersionRepository.Insert(context.TODO(), amendment)
+	assert.NoError(suite.T(), err)
+
+	req.Quarter = 2
+	rsp = &intPkg.OssReturnResponse{}
+	err = suite.service.GenerateOssReturn(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Item.Lines, 1)
+	assert.Equal(suite.T(), 100.0, rsp.Item.Lines[0].VatAmount)
+	assert.Len(suite.T(), rsp.Item.Corrections, 1)
+	assert.Equal(suite.T(), "DE", rsp.Item.Corrections[0].Country)
+	assert.EqualValues(suite.T(), 1, rsp.Item.Corrections[0].Quarter)
+	assert.Equal(suite.T(), -10.0, rsp.Item.Corrections[0].VatAmount)
+	assert.Equal(suite.T(), 90.0, rsp.Item.TotalVatAmount)
+}
+
+func (suite *VatReportsTestSuite) TestOssReturn_GenerateOssReturns_SkipsEmptyReturns() {
+	oc := HelperOperatingCompany(suite.Suite, suite.service)
+	reporterMock := suite.helperOssReturnReporterMock()
+
+	err := suite.service.GenerateOssReturns(context.TODO(), time.Date(2020, time.April, 15, 0, 0, 0, 0, time.Local))
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), reporterMock.Calls)
+
+	suite.helperInsertOssVatReport(oc.Id, "DE", 0.19, 1190, 190, time.January, pkg.VatReportStatusNeedToPay)
+
+	err = suite.service.GenerateOssReturns(context.TODO(), time.Date(2020, time.April, 15, 0, 0, 0, 0, time.Local))
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), reporterMock.Calls, 1)
+
+	returns, err := suite.service.ossReturnRepository.Find(context.TODO(), oc.Id, 2020, nil, 0, 0)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), returns, 1)
+	assert.EqualValues(suite.T(), 1, returns[0].Quarter)
+	assert.Empty(suite.T(), returns[0].CreatedBy)
+}
+
+func (suite *VatReportsTestSuite) helperOssReturnReporterMock() *reportingMocks.ReporterService {
+	reporterMock := &reportingMocks.ReporterService{}
+	reporterMock.On("CreateFile", mock2.Anything, mock2.Anything, mock2.Anything).
+		Return(&reporterpb.CreateFileResponse{Status: billingpb.ResponseStatusOk}, nil)
+	suite.service.reporterService = reporterMock
+
+	return reporterMock
+}
+
+// helperOssReturnStandardRates configures standard VAT rates of member states by local tax rate table,
+// because the tax service mock returns the same rate for all countries.
+func (suite *VatReportsTestSuite) helperOssReturnStandardRates(rates map[string]float64) {
+	req := &intPkg.AddTaxRateTableRequest{EffectiveFrom: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)}
+
+	for country, rate := range rates {
+		req.Rates = append(req.Rates, &intPkg.TaxRateTableItem{Country: country, Rate: rate})
+	}
+
+	rsp := &intPkg.TaxRateTableResponse{}
+	err := suite.service.AddTaxRateTable(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	suite.service.taxProvider = &taxProviderRateTable{repository: suite.service.taxRateTableRepository}
+}
+
+func (suite *VatReportsTestSuite) helperInsertOssVatReport(
+	operatingCompanyId, country string,
+	rate, grossRevenue, vatAmount float64,
+	month time.Month,
+	status string,
+) *billingpb.VatReport {
+	from := time.Date(2020, month, 1, 0, 0, 0, 0, time.Local)
+	to := now.New(from).EndOfMonth()
+
+	report := &billingpb.VatReport{
+		Id:                 primitive.NewObjectID().Hex(),
+		Country:            country,
+		VatRate:            rate,
+		Currency:           "EUR",
+		TransactionsCount:  10,
+		GrossRevenue:       grossRevenue,
+		VatAmount:          vatAmount,
+		Status:             status,
+		OperatingCompanyId: operatingCompanyId,
+		CreatedAt:          ptypes.TimestampNow(),
+		UpdatedAt:          ptypes.TimestampNow(),
+	}
+
+	var err error
+	report.DateFrom, err = ptypes.TimestampProto(from)
+	assert.NoError(suite.T(), err)
+	report.DateTo, err = ptypes.TimestampProto(to)
+	assert.NoError(suite.T(), err)
+	report.PayUntilDate, err = ptypes.TimestampProto(to.AddDate(0, 0, 20))
+	assert.NoError(suite.T(), err)
+
+	err = suite.service.vatReportRepository.Insert(context.TODO(), report)
+	assert.NoError(suite.T(), err)
+
+	return report
+}
@@ -108,6 +108,12 @@ func NewCardPayMock() payment_system.PaymentSystemInterface {
 	cpMock.On("IsSubscriptionCallback", mock.Anything).Return(false, nil)
 	cpMock.On("DeleteRecurringSubscription", mock.Anything, mock.Anything).
 		Return(nil, nil)
+	cpMock.On("PauseRecurringSubscription", mock.Anything, mock.Anything).
+		Return(nil, nil)
+	cpMock.On("ResumeRecurringSubscription", mock.Anything, mock.Anything).
+		Return(nil, nil)
+	cpMock.On("UpdateRecurringCard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return("0987654321", nil)
 	cpMock.On("CanSaveCard", mock.Anything).Return(false)
 	return cpMock
 }
@@ -154,6 +160,18 @@ func (m *PaymentSystemMockOk) DeleteRecurringSubscription(_ *billingpb.Order, _
 	return nil
 }
 
+func (m *PaymentSystemMockOk) PauseRecurringSubscription(_ *billingpb.Order, _ *recurringpb.Subscription) error {
+	return nil
+}
+
+func (m *PaymentSystemMockOk) ResumeRecurringSubscription(_ *billingpb.Order, _ *recurringpb.Subscription) error {
+	return nil
+}
+
+func (m *PaymentSystemMockOk) UpdateRecurringCard(_ *billingpb.Order, _ *recurringpb.Subscription, _ map[string]string, _ time.Time) (string, error) {
+	return "", nil
+}
+
 func (m *PaymentSystemMockOk) CanSaveCard(_ proto.Message) bool {
 	return false
 }
@@ -196,6 +214,18 @@ func (m *PaymentSystemMockError) DeleteRecurringSubscription(_ *billingpb.Order,
 	return nil
 }
 
+func (m *PaymentSystemMockError) PauseRecurringSubscription(_ *billingpb.Order, _ *recurringpb.Subscription) error {
+	return nil
+}
+
+func (m *PaymentSystemMockError) ResumeRecurringSubscription(_ *billingpb.Order, _ *recurringpb.Subscription) error {
+	return nil
+}
+
+func (m *PaymentSystemMockError) UpdateRecurringCard(_ *billingpb.Order, _ *recurringpb.Subscription, _ map[string]string, _ time.Time) (string, error) {
+	return "", nil
+}
+
 func (m *PaymentSystemMockError) CanSaveCard(_ proto.Message) bool {
 	return false
 }
@@ -0,0 +1,805 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/internal/repository"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	payoutBatchSepaCurrency       = "EUR"
+	payoutBatchPain001Namespace   = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+	payoutBatchDateLayout         = "2006-01-02"
+	payoutBatchDateTimeLayout     = "2006-01-02T15:04:05"
+	payoutBatchPaymentMethod      = "TRF"
+	payoutBatchSepaServiceLevel   = "SEPA"
+	payoutBatchSepaChargeBearer   = "SLEV"
+	payoutBatchSwiftChargeBearer  = "SHA"
+	payoutBatchNameLength         = 70
+	payoutBatchRemittanceLength   = 140
+	payoutBatchFileNameTemplate   = "payout_batch_%s.%s"
+	payoutBatchRemittanceTemplate = "Payout %s"
+
+	payoutBatchTransactionStatusRejected = "RJCT"
+
+	payoutBatchRejectedMessage = "rejected by bank"
+)
+
+var (
+	payoutBatchErrorCurrencyRequired      = errors.NewBillingServerErrorMsg("pb000001", "payout batch currency is required")
+	payoutBatchErrorDebtorAccountRequired = errors.NewBillingServerErrorMsg("pb000002", "debtor account of operating company is required")
+	payoutBatchErrorNoPayouts             = errors.NewBillingServerErrorMsg("pb000003", "no pending payouts found for batch")
+	payoutBatchErrorPayoutNotAllowed      = errors.NewBillingServerErrorMsg("pb000004", "payout document is not pending or already included into another batch")
+	payoutBatchErrorNotFound              = errors.NewBillingServerErrorMsg("pb000005", "payout batch not found")
+	payoutBatchErrorStatusReportInvalid   = errors.NewBillingServerErrorMsg("pb000006", "payment status report is invalid")
+	payoutBatchErrorPayoutUpdate          = errors.NewBillingServerErrorMsg("pb000007", "failed to update payout document by payment status report")
+	payoutBatchErrorUnknown               = errors.NewBillingServerErrorMsg("pb000008", "unknown error. try request later")
+
+	payoutBatchSwiftCsvHeader = []string{
+		"reference",
+		"value_date",
+		"currency",
+		"amount",
+		"ordering_customer",
+		"ordering_account",
+		"ordering_bank_swift",
+		"beneficiary_name",
+		"beneficiary_address",
+		"beneficiary_country",
+		"beneficiary_account",
+		"beneficiary_bank_name",
+		"beneficiary_bank_address",
+		"beneficiary_bank_swift",
+		"correspondent_account",
+		"remittance_information",
+		"charges",
+	}
+
+	// Payout statuses by transaction statuses of ISO 20022 payment status report. Transactions in other statuses,
+	// including accepted for settlement in process (ACSP), are not final and payouts stay pending.
+	payoutBatchTransactionStatuses = map[string]string{
+		"ACSC":                               pkg.PayoutDocumentStatusPaid,
+		"ACCC":                               pkg.PayoutDocumentStatusPaid,
+		payoutBatchTransactionStatusRejected: pkg.PayoutDocumentStatusFailed,
+	}
+
+	payoutBatchUnprocessedStatuses = []string{
+		pkg.PayoutBatchStatusCreated,
+		pkg.PayoutBatchStatusPartiallyProcessed,
+	}
+)
+
+type pain001Document struct {
+	XMLName    xml.Name           `xml:"Document"`
+	Xmlns      string             `xml:"xmlns,attr"`
+	Initiation *pain001Initiation `xml:"CstmrCdtTrfInitn"`
+}
+
+type pain001Initiation struct {
+	GroupHeader *pain001GroupHeader `xml:"GrpHdr"`
+	Payment     *pain001Payment     `xml:"PmtInf"`
+}
+
+type pain001GroupHeader struct {
+	MessageId           string        `xml:"MsgId"`
+	CreationDateTime    string        `xml:"CreDtTm"`
+	NumberOfTransaction int           `xml:"NbOfTxs"`
+	ControlSum          string        `xml:"CtrlSum"`
+	InitiatingParty     *pain001Party `xml:"InitgPty"`
+}
+
+type pain001Payment struct {
+	PaymentId            string                `xml:"PmtInfId"`
+	PaymentMethod        string                `xml:"PmtMtd"`
+	NumberOfTransaction  int                   `xml:"NbOfTxs"`
+	ControlSum           string                `xml:"CtrlSum"`
+	ServiceLevel         string                `xml:"PmtTpInf>SvcLvl>Cd"`
+	RequestedExecutionAt string                `xml:"ReqdExctnDt"`
+	Debtor               *pain001Party         `xml:"Dbtr"`
+	DebtorAccount        string                `xml:"DbtrAcct>Id>IBAN"`
+	DebtorAgent          *pain001Agent         `xml:"DbtrAgt,omitempty"`
+	ChargeBearer         string                `xml:"ChrgBr"`
+	Transactions         []*pain001Transaction `xml:"CdtTrfTxInf"`
+}
+
+type pain001Party struct {
+	Name    string          `xml:"Nm"`
+	Address *pain001Address `xml:"PstlAdr,omitempty"`
+}
+
+type pain001Address struct {
+	Country string   `xml:"Ctry,omitempty"`
+	Lines   []string `xml:"AdrLine,omitempty"`
+}
+
+type pain001Agent struct {
+	Bic string `xml:"FinInstnId>BIC"`
+}
+
+type pain001Transaction struct {
+	EndToEndId      string         `xml:"PmtId>EndToEndId"`
+	Amount          *pain001Amount `xml:"Amt>InstdAmt"`
+	CreditorAgent   *pain001Agent  `xml:"CdtrAgt,omitempty"`
+	Creditor        *pain001Party  `xml:"Cdtr"`
+	CreditorAccount string         `xml:"CdtrAcct>Id>IBAN"`
+	Remittance      string         `xml:"RmtInf>Ustrd"`
+}
+
+type pain001Amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type pain002Document struct {
+	Report *pain002Report `xml:"CstmrPmtStsRpt"`
+}
+
+type pain002Report struct {
+	OriginalGroup    *pain002OriginalGroup     `xml:"OrgnlGrpInfAndSts"`
+	OriginalPayments []*pain002OriginalPayment `xml:"OrgnlPmtInfAndSts"`
+}
+
+type pain002OriginalGroup struct {
+	MessageId string                 `xml:"OrgnlMsgId"`
+	Status    string                 `xml:"GrpSts"`
+	Reasons   []*pain002StatusReason `xml:"StsRsnInf"`
+}
+
+type pain002OriginalPayment struct {
+	PaymentId    string                 `xml:"OrgnlPmtInfId"`
+	Status       string                 `xml:"PmtInfSts"`
+	Reasons      []*pain002StatusReason `xml:"StsRsnInf"`
+	Transactions []*pain002Transaction  `xml:"TxInfAndSts"`
+}
+
+type pain002Transaction struct {
+	EndToEndId string                 `xml:"OrgnlEndToEndId"`
+	Status     string                 `xml:"TxSts"`
+	Reasons    []*pain002StatusReason `xml:"StsRsnInf"`
+	Reference  string                 `xml:"AcctSvcrRef"`
+}
+
+type pain002StatusReason struct {
+	Code        string   `xml:"Rsn>Cd"`
+	Proprietary string   `xml:"Rsn>Prtry"`
+	Information []string `xml:"AddtlInf"`
+}
+
+// payoutBatchTransactionStatus is the status of the transfer of the batch item in the payment status report.
+type payoutBatchTransactionStatus struct {
+	status    string
+	code      string
+	message   string
+	reference string
+}
+
+// CreatePayoutBatch generates the payment file with pending payouts of the operating company in the currency.
+// Payouts with incomplete banking details of the merchant are not included into the file and returned as skipped.
+func (s *Service) CreatePayoutBatch(
+	ctx context.Context,
+	req *intPkg.CreatePayoutBatchRequest,
+	rsp *intPkg.PayoutBatchResponse,
+) error {
+	if req.Currency == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = payoutBatchErrorCurrencyRequired
+		return nil
+	}
+
+	if req.DebtorAccount == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = payoutBatchErrorDebtorAccountRequired
+		return nil
+	}
+
+	oc, err := s.operatingCompanyRepository.GetById(ctx, req.OperatingCompanyId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = errorOperatingCompanyNotFound
+		return nil
+	}
+
+	payouts, err := s.getPayoutBatchPayouts(ctx, oc.Id, req.Currency, req.PayoutDocumentIds)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = payoutBatchErrorUnknown
+
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = e
+		}
+
+		return nil
+	}
+
+	batch := &intPkg.PayoutBatch{
+		Id:                 primitive.NewObjectID(),
+		OperatingCompanyId: oc.Id,
+		Currency:           req.Currency,
+		Format:             pkg.PayoutBatchFormatSwiftCsv,
+		Status:             pkg.PayoutBatchStatusCreated,
+		DebtorAccount:      strings.Replace(req.DebtorAccount, " ", "", -1),
+		DebtorSwift:        req.DebtorSwift,
+		ExecutionDate:      req.ExecutionDate,
+		Items:              []*intPkg.PayoutBatchItem{},
+		CreatedBy:          req.UserId,
+	}
+	batch.MessageId = batch.Id.Hex()
+
+	if batch.Currency == payoutBatchSepaCurrency {
+		batch.Format = pkg.PayoutBatchFormatSepaPain001
+	}
+
+	if batch.ExecutionDate.IsZero() {
+		batch.ExecutionDate = time.Now()
+	}
+
+	for _, pd := range payouts {
+		item := getPayoutBatchItem(pd)
+
+		if item.CreditorAccount == "" || (batch.Format == pkg.PayoutBatchFormatSwiftCsv && item.CreditorSwift == "") {
+			batch.SkippedPayoutDocumentIds = append(batch.SkippedPayoutDocumentIds, pd.Id)
+			continue
+		}
+
+		batch.Items = append(batch.Items, item)
+		batch.TotalAmount += item.Amount
+	}
+
+	if len(batch.Items) <= 0 {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = payoutBatchErrorNoPayouts
+		return nil
+	}
+
+	batch.TotalAmount = tools.FormatAmount(batch.TotalAmount)
+
+	if batch.Format == pkg.PayoutBatchFormatSepaPain001 {
+		batch.File, err = getPayoutBatchPain001(batch, oc)
+		batch.FileName = fmt.Sprintf(payoutBatchFileNameTemplate, batch.MessageId, "xml")
+	} else {
+		batch.File, err = getPayoutBatchSwiftCsv(batch, oc, payouts)
+		batch.FileName = fmt.Sprintf(payoutBatchFileNameTemplate, batch.MessageId, "csv")
+	}
+
+	if err != nil {
+		zap.L().Error(
+			"Payout batch file generation failed",
+			zap.Error(err),
+			zap.String("operating_company_id", oc.Id),
+			zap.String("currency", batch.Currency),
+		)
+
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = payoutBatchErrorUnknown
+		return nil
+	}
+
+	// payouts are reserved by the unique index of batches items, the concurrent batch with the same payouts fails
+	if err = s.payoutBatchRepository.Insert(ctx, batch); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = payoutBatchErrorUnknown
+
+		if err == repository.ErrPayoutBatchPayoutExists {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = payoutBatchErrorPayoutNotAllowed
+		}
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = batch
+
+	return nil
+}
+
+func (s *Service) GetPayoutBatch(
+	ctx context.Context,
+	req *intPkg.GetPayoutBatchRequest,
+	rsp *intPkg.PayoutBatchResponse,
+) error {
+	batch, err := s.payoutBatchRepository.GetById(ctx, req.BatchId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = payoutBatchErrorNotFound
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = batch
+
+	return nil
+}
+
+func (s *Service) ListPayoutBatches(
+	ctx context.Context,
+	req *intPkg.ListPayoutBatchesRequest,
+	rsp *intPkg.ListPayoutBatchesResponse,
+) error {
+	var err error
+
+	rsp.Count, err = s.payoutBatchRepository.FindCount(ctx, req.OperatingCompanyId, req.Currency, req.Status)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = payoutBatchErrorUnknown
+		return nil
+	}
+
+	if rsp.Count > 0 {
+		rsp.Items, err = s.payoutBatchRepository.Find(
+			ctx,
+			req.OperatingCompanyId,
+			req.Currency,
+			req.Status,
+			req.Offset,
+			req.Limit,
+		)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = payoutBatchErrorUnknown
+			return nil
+		}
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// ImportPayoutBatchStatusReport applies the payment status report of the bank to the batch.
+// Payouts of settled transfers are marked as paid, payouts of rejected transfers are marked as failed
+// with the rejection reason. Status of the transfer is inherited from the payment information or the group
+// if the report doesn't contain the status of the transaction.
+func (s *Service) ImportPayoutBatchStatusReport(
+	ctx context.Context,
+	req *intPkg.ImportPayoutBatchStatusReportRequest,
+	rsp *intPkg.PayoutBatchResponse,
+) error {
+	report := &pain002Document{}
+	err := xml.Unmarshal(req.File, report)
+
+	if err != nil || report.Report == nil || report.Report.OriginalGroup == nil ||
+		report.Report.OriginalGroup.MessageId == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = payoutBatchErrorStatusReportInvalid
+		return nil
+	}
+
+	batch, err := s.payoutBatchRepository.GetByMessageId(ctx, report.Report.OriginalGroup.MessageId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = payoutBatchErrorNotFound
+		return nil
+	}
+
+	statuses := getPayoutBatchTransactionStatuses(report.Report, batch)
+
+	for _, item := range batch.Items {
+		status, ok := statuses[item.EndToEndId]
+
+		if item.Status != pkg.PayoutDocumentStatusPending || !ok || status.status == "" {
+			continue
+		}
+
+		err = s.applyPayoutBatchItemStatus(ctx, item, status, req.Ip)
+
+		if err != nil {
+			zap.L().Error(
+				payoutBatchErrorPayoutUpdate.Message,
+				zap.Error(err),
+				zap.String("payout_batch_id", batch.Id.Hex()),
+				zap.String("payout_document_id", item.PayoutDocumentId),
+			)
+			break
+		}
+	}
+
+	batch.Status = pkg.PayoutBatchStatusProcessed
+
+	for _, item := range batch.Items {
+		if item.Status == pkg.PayoutDocumentStatusPending {
+			batch.Status = pkg.PayoutBatchStatusPartiallyProcessed
+			break
+		}
+	}
+
+	if batch.Status == pkg.PayoutBatchStatusProcessed && batch.ProcessedAt.IsZero() {
+		batch.ProcessedAt = time.Now()
+	}
+
+	if e := s.payoutBatchRepository.Update(ctx, batch); e != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = payoutBatchErrorUnknown
+		return nil
+	}
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = payoutBatchErrorPayoutUpdate
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = batch
+
+	return nil
+}
+
+// getPayoutBatchPayouts returns pending payouts of the operating company in the currency
+// which are not included into unprocessed batches.
+func (s *Service) getPayoutBatchPayouts(
+	ctx context.Context,
+	operatingCompanyId, currency string,
+	payoutDocumentIds []string,
+) ([]*billingpb.PayoutDocument, error) {
+	payouts, err := s.payoutRepository.FindPending(ctx, operatingCompanyId, currency)
+
+	if err != nil {
+		return nil, err
+	}
+
+	batches, err := s.payoutBatchRepository.Find(ctx, operatingCompanyId, currency, payoutBatchUnprocessedStatuses, 0, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	inBatch := make(map[string]bool)
+
+	for _, batch := range batches {
+		for _, item := range batch.Items {
+			if item.Status == pkg.PayoutDocumentStatusPending {
+				inBatch[item.PayoutDocumentId] = true
+			}
+		}
+	}
+
+	requested := make(map[string]bool, len(payoutDocumentIds))
+
+	for _, id := range payoutDocumentIds {
+		requested[id] = true
+	}
+
+	var result []*billingpb.PayoutDocument
+
+	for _, pd := range payouts {
+		if inBatch[pd.Id] || (len(requested) > 0 && !requested[pd.Id]) {
+			continue
+		}
+
+		result = append(result, pd)
+	}
+
+	if len(requested) > 0 && len(result) != len(requested) {
+		return nil, payoutBatchErrorPayoutNotAllowed
+	}
+
+	if len(result) <= 0 {
+		return nil, payoutBatchErrorNoPayouts
+	}
+
+	return result, nil
+}
+
+// applyPayoutBatchItemStatus changes the status of the payout of the batch item.
+// Payouts which were already changed manually keep their status.
+func (s *Service) applyPayoutBatchItemStatus(
+	ctx context.Context,
+	item *intPkg.PayoutBatchItem,
+	status *payoutBatchTransactionStatus,
+	ip string,
+) error {
+	pd, err := s.payoutRepository.GetById(ctx, item.PayoutDocumentId)
+
+	if err != nil {
+		return err
+	}
+
+	if pd.Status != pkg.PayoutDocumentStatusPending {
+		item.Status = pd.Status
+		return nil
+	}
+
+	updateReq := &billingpb.UpdatePayoutDocumentRequest{
+		PayoutDocumentId: pd.Id,
+		Status:           status.status,
+		Ip:               ip,
+	}
+
+	if status.status == pkg.PayoutDocumentStatusPaid {
+		updateReq.Transaction = status.reference
+	} else {
+		updateReq.FailureCode = status.code
+		updateReq.FailureMessage = status.message
+		updateReq.FailureTransaction = status.reference
+	}
+
+	updateRsp := &billingpb.PayoutDocumentResponse{}
+	err = s.UpdatePayoutDocument(ctx, updateReq, updateRsp)
+
+	if err != nil {
+		return err
+	}
+
+	if updateRsp.Status != billingpb.ResponseStatusOk {
+		if updateRsp.Message != nil {
+			return updateRsp.Message
+		}
+		return payoutBatchErrorPayoutUpdate
+	}
+
+	item.Status = status.status
+	item.StatusCode = status.code
+	item.StatusMessage = status.message
+
+	return nil
+}
+
+// getPayoutBatchTransactionStatuses returns statuses of transfers of the batch from the report by end to end id.
+func getPayoutBatchTransactionStatuses(
+	report *pain002Report,
+	batch *intPkg.PayoutBatch,
+) map[string]*payoutBatchTransactionStatus {
+	group := getPayoutBatchTransactionStatus(report.OriginalGroup.Status, report.OriginalGroup.Reasons, "")
+	statuses := make(map[string]*payoutBatchTransactionStatus)
+
+	for _, item := range batch.Items {
+		statuses[item.EndToEndId] = &payoutBatchTransactionStatus{
+			status:    group.status,
+			code:      group.code,
+			message:   group.message,
+			reference: item.EndToEndId,
+		}
+	}
+
+	for _, payment := range report.OriginalPayments {
+		if payment.Status != "" {
+			status := getPayoutBatchTransactionStatus(payment.Status, payment.Reasons, "")
+
+			for _, item := range batch.Items {
+				statuses[item.EndToEndId] = &payoutBatchTransactionStatus{
+					status:    status.status,
+					code:      status.code,
+					message:   status.message,
+					reference: item.EndToEndId,
+				}
+			}
+		}
+
+		for _, tx := range payment.Transactions {
+			if _, ok := statuses[tx.EndToEndId]; !ok {
+				continue
+			}
+
+			reference := tx.Reference
+
+			if reference == "" {
+				reference = tx.EndToEndId
+			}
+
+			statuses[tx.EndToEndId] = getPayoutBatchTransactionStatus(tx.Status, tx.Reasons, reference)
+		}
+	}
+
+	return statuses
+}
+
+func getPayoutBatchTransactionStatus(
+	code string,
+	reasons []*pain002StatusReason,
+	reference string,
+) *payoutBatchTransactionStatus {
+	status := &payoutBatchTransactionStatus{
+		status:    payoutBatchTransactionStatuses[code],
+		reference: reference,
+	}
+
+	if status.status != pkg.PayoutDocumentStatusFailed {
+		return status
+	}
+
+	status.code = payoutBatchTransactionStatusRejected
+	status.message = payoutBatchRejectedMessage
+
+	for _, reason := range reasons {
+		if reason.Code != "" {
+			status.code = reason.Code
+		} else if reason.Proprietary != "" {
+			status.code = reason.Proprietary
+		}
+
+		if len(reason.Information) > 0 {
+			status.message = strings.Join(reason.Information, " ")
+		}
+
+		break
+	}
+
+	return status
+}
+
+func getPayoutBatchItem(pd *billingpb.PayoutDocument) *intPkg.PayoutBatchItem {
+	return &intPkg.PayoutBatchItem{
+		PayoutDocumentId: pd.Id,
+		MerchantId:       pd.MerchantId,
+		EndToEndId:       pd.Id,
+		Amount:           tools.FormatAmount(pd.TotalFees),
+		CreditorName:     pd.GetCompany().GetName(),
+		CreditorAccount:  strings.Replace(pd.GetDestination().GetAccountNumber(), " ", "", -1),
+		CreditorSwift:    pd.GetDestination().GetSwift(),
+		Status:           pkg.PayoutDocumentStatusPending,
+	}
+}
+
+// getPayoutBatchPain001 renders the batch to SEPA credit transfer initiation message (ISO 20022 pain.001.001.03).
+func getPayoutBatchPain001(
+	batch *intPkg.PayoutBatch,
+	oc *billingpb.OperatingCompany,
+) ([]byte, error) {
+	total := formatPayoutBatchAmount(batch.TotalAmount)
+	debtor := &pain001Party{
+		Name:    truncatePayoutBatchText(oc.Name, payoutBatchNameLength),
+		Address: &pain001Address{Country: oc.Country},
+	}
+
+	if oc.Address != "" {
+		debtor.Address.Lines = []string{truncatePayoutBatchText(oc.Address, payoutBatchNameLength)}
+	}
+
+	payment := &pain001Payment{
+		PaymentId:            batch.MessageId,
+		PaymentMethod:        payoutBatchPaymentMethod,
+		NumberOfTransaction:  len(batch.Items),
+		ControlSum:           total,
+		ServiceLevel:         payoutBatchSepaServiceLevel,
+		RequestedExecutionAt: batch.ExecutionDate.Format(payoutBatchDateLayout),
+		Debtor:               debtor,
+		DebtorAccount:        batch.DebtorAccount,
+		DebtorAgent:          getPayoutBatchPain001Agent(batch.DebtorSwift),
+		ChargeBearer:         payoutBatchSepaChargeBearer,
+	}
+
+	for _, item := range batch.Items {
+		payment.Transactions = append(payment.Transactions, &pain001Transaction{
+			EndToEndId:      item.EndToEndId,
+			Amount:          &pain001Amount{Currency: batch.Currency, Value: formatPayoutBatchAmount(item.Amount)},
+			CreditorAgent:   getPayoutBatchPain001Agent(item.CreditorSwift),
+			Creditor:        &pain001Party{Name: truncatePayoutBatchText(item.CreditorName, payoutBatchNameLength)},
+			CreditorAccount: item.CreditorAccount,
+			Remittance:      getPayoutBatchRemittance(item),
+		})
+	}
+
+	document := &pain001Document{
+		Xmlns: payoutBatchPain001Namespace,
+		Initiation: &pain001Initiation{
+			GroupHeader: &pain001GroupHeader{
+				MessageId:           batch.MessageId,
+				CreationDateTime:    time.Now().UTC().Format(payoutBatchDateTimeLayout),
+				NumberOfTransaction: len(batch.Items),
+				ControlSum:          total,
+				InitiatingParty:     &pain001Party{Name: debtor.Name},
+			},
+			Payment: payment,
+		},
+	}
+
+	content, err := xml.MarshalIndent(document, "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), content...), nil
+}
+
+// getPayoutBatchSwiftCsv renders the batch to CSV file with fields of SWIFT customer credit transfers.
+func getPayoutBatchSwiftCsv(
+	batch *intPkg.PayoutBatch,
+	oc *billingpb.OperatingCompany,
+	payouts []*billingpb.PayoutDocument,
+) ([]byte, error) {
+	payoutsById := make(map[string]*billingpb.PayoutDocument, len(payouts))
+
+	for _, pd := range payouts {
+		payoutsById[pd.Id] = pd
+	}
+
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+
+	if err := w.Write(payoutBatchSwiftCsvHeader); err != nil {
+		return nil, err
+	}
+
+	for _, item := range batch.Items {
+		pd := payoutsById[item.PayoutDocumentId]
+		company := pd.GetCompany()
+		var address []string
+
+		for _, v := range []string{company.GetAddress(), company.GetCity(), company.GetZip()} {
+			if v != "" {
+				address = append(address, v)
+			}
+		}
+
+		record := []string{
+			item.EndToEndId,
+			batch.ExecutionDate.Format(payoutBatchDateLayout),
+			batch.Currency,
+			formatPayoutBatchAmount(item.Amount),
+			oc.Name,
+			batch.DebtorAccount,
+			batch.DebtorSwift,
+			item.CreditorName,
+			strings.Join(address, ", "),
+			company.GetCountry(),
+			item.CreditorAccount,
+			pd.GetDestination().GetName(),
+			pd.GetDestination().GetAddress(),
+			item.CreditorSwift,
+			pd.GetDestination().GetCorrespondentAccount(),
+			getPayoutBatchRemittance(item),
+			payoutBatchSwiftChargeBearer,
+		}
+
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// getPayoutBatchPain001Agent returns the financial institution of the account by BIC.
+// The institution is omitted if BIC is unknown, the bank identifies it by IBAN then.
+func getPayoutBatchPain001Agent(bic string) *pain001Agent {
+	if bic == "" {
+		return nil
+	}
+
+	return &pain001Agent{Bic: bic}
+}
+
+func getPayoutBatchRemittance(item *intPkg.PayoutBatchItem) string {
+	return truncatePayoutBatchText(fmt.Sprintf(payoutBatchRemittanceTemplate, item.PayoutDocumentId), payoutBatchRemittanceLength)
+}
+
+func formatPayoutBatchAmount(amount float64) string {
+	return strconv.FormatFloat(tools.FormatAmount(amount), 'f', 2, 64)
+}
+
+func truncatePayoutBatchText(text string, length int) string {
+	runes := []rune(text)
+
+	if len(runes) <= length {
+		return text
+	}
+
+	return string(runes[:length])
+}
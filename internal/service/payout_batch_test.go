@@ -0,0 +1,385 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/internal/repository"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+)
+
+const (
+	payoutBatchTestDebtorAccount = "CY17 0020 0128 0000 0012 0052 7600"
+	payoutBatchTestDebtorSwift   = "BCYPCY2N"
+	payoutBatchTestPain002       = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.002.001.03">
+  <CstmrPmtStsRpt>
+    <GrpHdr><MsgId>STS-1</MsgId><CreDtTm>2021-03-12T10:00:00</CreDtTm></GrpHdr>
+    <OrgnlGrpInfAndSts>
+      <OrgnlMsgId>%s</OrgnlMsgId>
+      <OrgnlMsgNmId>pain.001.001.03</OrgnlMsgNmId>
+      <GrpSts>%s</GrpSts>
+    </OrgnlGrpInfAndSts>
+    <OrgnlPmtInfAndSts>
+      <OrgnlPmtInfId>%s</OrgnlPmtInfId>
+      %s
+    </OrgnlPmtInfAndSts>
+  </CstmrPmtStsRpt>
+</Document>`
+	payoutBatchTestPain002Transaction = `<TxInfAndSts>
+        <OrgnlEndToEndId>%s</OrgnlEndToEndId>
+        <TxSts>%s</TxSts>
+        <StsRsnInf><Rsn><Cd>%s</Cd></Rsn><AddtlInf>%s</AddtlInf></StsRsnInf>
+        <AcctSvcrRef>%s</AcctSvcrRef>
+      </TxInfAndSts>`
+)
+
+func (suite *PayoutsTestSuite) helperInsertBatchPayout(
+	currency string,
+	sources []string,
+	banking *billingpb.MerchantBanking,
+) *billingpb.PayoutDocument {
+	pd := &billingpb.PayoutDocument{
+		Id:                 primitive.NewObjectID().Hex(),
+		MerchantId:         suite.merchant.Id,
+		SourceId:           sources,
+		TotalFees:          1234.56,
+		Balance:            1234.56,
+		Currency:           currency,
+		Status:             pkg.PayoutDocumentStatusPending,
+		Description:        "test payout document",
+		Destination:        banking,
+		Company:            suite.merchant.Company,
+		CreatedAt:          ptypes.TimestampNow(),
+		UpdatedAt:          ptypes.TimestampNow(),
+		ArrivalDate:        ptypes.TimestampNow(),
+		OperatingCompanyId: suite.operatingCompany.Id,
+	}
+	suite.helperInsertPayoutDocuments([]*billingpb.PayoutDocument{pd})
+
+	return pd
+}
+
+func (suite *PayoutsTestSuite) helperPayoutBatchBanking(currency string) *billingpb.MerchantBanking {
+	return &billingpb.MerchantBanking{
+		Currency:             currency,
+		Name:                 "Bank name",
+		Address:              "Bank address",
+		AccountNumber:        "DE89 3704 0044 0532 0130 00",
+		Swift:                "COBADEFFXXX",
+		CorrespondentAccount: "0000002",
+	}
+}
+
+func (suite *PayoutsTestSuite) helperCreatePayoutBatch(currency string, ids ...string) *intPkg.PayoutBatchResponse {
+	req := &intPkg.CreatePayoutBatchRequest{
+		OperatingCompanyId: suite.operatingCompany.Id,
+		Currency:           currency,
+		PayoutDocumentIds:  ids,
+		DebtorAccount:      payoutBatchTestDebtorAccount,
+		DebtorSwift:        payoutBatchTestDebtorSwift,
+		UserId:             "finance-user",
+	}
+	rsp := &intPkg.PayoutBatchResponse{}
+	err := suite.service.CreatePayoutBatch(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+
+	return rsp
+}
+
+func (suite *PayoutsTestSuite) helperImportPayoutBatchStatusReport(
+	batch *intPkg.PayoutBatch,
+	groupStatus string,
+	transactions ...string,
+) *intPkg.PayoutBatchResponse {
+	file := fmt.Sprintf(payoutBatchTestPain002, batch.MessageId, groupStatus, batch.MessageId, strings.Join(transactions, ""))
+	req := &intPkg.ImportPayoutBatchStatusReportRequest{File: []byte(file), Ip: "127.0.0.1"}
+	rsp := &intPkg.PayoutBatchResponse{}
+	err := suite.service.ImportPayoutBatchStatusReport(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+
+	return rsp
+}
+
+func (suite *PayoutsTestSuite) TestPayoutBatch_CreatePayoutBatch_SwiftCsv_Ok() {
+	pd1 := suite.helperInsertBatchPayout("RUB", []string{}, suite.helperPayoutBatchBanking("RUB"))
+	pd2 := suite.helperInsertBatchPayout("RUB", []string{}, suite.helperPayoutBatchBanking("RUB"))
+
+	rsp := suite.helperCreatePayoutBatch("RUB")
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.PayoutBatchFormatSwiftCsv, rsp.Item.Format)
+	assert.Equal(suite.T(), pkg.PayoutBatchStatusCreated, rsp.Item.Status)
+	assert.Equal(suite.T(), "CY17002001280000001200527600", rsp.Item.DebtorAccount)
+	assert.Equal(suite.T(), 2469.12, rsp.Item.TotalAmount)
+	assert.Len(suite.T(), rsp.Item.Items, 2)
+	assert.Equal(suite.T(), pd1.Id, rsp.Item.Items[0].PayoutDocumentId)
+	assert.Equal(suite.T(), pd2.Id, rsp.Item.Items[1].EndToEndId)
+	assert.Equal(suite.T(), "DE89370400440532013000", rsp.Item.Items[0].CreditorAccount)
+	assert.True(suite.T(), strings.HasSuffix(rsp.Item.FileName, ".csv"))
+
+	records, err := csv.NewReader(bytes.NewReader(rsp.Item.File)).ReadAll()
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), records, 3)
+	assert.Equal(suite.T(), payoutBatchSwiftCsvHeader, records[0])
+	assert.Equal(suite.T(), pd1.Id, records[1][0])
+	assert.Equal(suite.T(), "RUB", records[1][2])
+	assert.Equal(suite.T(), "1234.56", records[1][3])
+	assert.Equal(suite.T(), suite.operatingCompany.Name, records[1][4])
+	assert.Equal(suite.T(), suite.merchant.Company.Name, records[1][7])
+	assert.Equal(suite.T(), "COBADEFFXXX", records[1][13])
+	assert.Equal(suite.T(), payoutBatchSwiftChargeBearer, records[1][16])
+
+	batch, err := suite.service.payoutBatchRepository.GetById(context.TODO(), rsp.Item.Id.Hex())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), rsp.Item.File, batch.File)
+}
+
+func (suite *PayoutsTestSuite) TestPayoutBatch_CreatePayoutBatch_SepaPain001_Ok() {
+	pd := suite.helperInsertBatchPayout("EUR", []string{}, suite.helperPayoutBatchBanking("EUR"))
+	suite.helperInsertBatchPayout("RUB", []string{}, suite.helperPayoutBatchBanking("RUB"))
+
+	rsp := suite.helperCreatePayoutBatch("EUR")
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.PayoutBatchFormatSepaPain001, rsp.Item.Format)
+	assert.Len(suite.T(), rsp.Item.Items, 1)
+	assert.True(suite.T(), strings.HasSuffix(rsp.Item.FileName, ".xml"))
+
+	file := string(rsp.Item.File)
+	assert.Contains(suite.T(), file, payoutBatchPain001Namespace)
+	assert.Contains(suite.T(), file, "<MsgId>"+rsp.Item.MessageId+"</MsgId>")
+	assert.Contains(suite.T(), file, "<EndToEndId>"+pd.Id+"</EndToEndId>")
+	assert.Contains(suite.T(), file, `<InstdAmt Ccy="EUR">1234.56</InstdAmt>`)
+	assert.Contains(suite.T(), file, "<IBAN>DE89370400440532013000</IBAN>")
+	assert.Contains(suite.T(), file, "<BIC>"+payoutBatchTestDebtorSwift+"</BIC>")
+	assert.Contains(suite.T(), file, "<Cd>SEPA</Cd>")
+}
+
+func (suite *PayoutsTestSuite) TestPayoutBatch_CreatePayoutBatch_SkipIncompleteBanking_Ok() {
+	pd1 := suite.helperInsertBatchPayout("RUB", []string{}, suite.helperPayoutBatchBanking("RUB"))
+	pd2 := suite.helperInsertBatchPayout("RUB", []string{}, &billingpb.MerchantBanking{Currency: "RUB", Name: "Bank name"})
+
+	rsp := suite.helperCreatePayoutBatch("RUB")
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Item.Items, 1)
+	assert.Equal(suite.T(), pd1.Id, rsp.Item.Items[0].PayoutDocumentId)
+	assert.Equal(suite.T(), []string{pd2.Id}, rsp.Item.SkippedPayoutDocumentIds)
+}
+
+func (suite *PayoutsTestSuite) TestPayoutBatch_CreatePayoutBatch_ExcludesPayoutsInBatch() {
+	suite.helperInsertBatchPayout("RUB", []string{}, suite.helperPayoutBatchBanking("RUB"))
+
+	rsp := suite.helperCreatePayoutBatch("RUB")
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	rsp = suite.helperCreatePayoutBatch("RUB")
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), payoutBatchErrorNoPayouts, rsp.Message)
+
+	pd := suite.helperInsertBatchPayout("RUB", []string{}, suite.helperPayoutBatchBanking("RUB"))
+
+	rsp = suite.helperCreatePayoutBatch("RUB")
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Item.Items, 1)
+	assert.Equal(suite.T(), pd.Id, rsp.Item.Items[0].PayoutDocumentId)
+}
+
+func (suite *PayoutsTestSuite) TestPayoutBatch_CreatePayoutBatch_SelectedPayouts_Ok() {
+	pd := suite.helperInsertBatchPayout("RUB", []string{}, suite.helperPayoutBatchBanking("RUB"))
+	suite.helperInsertBatchPayout("RUB", []string{}, suite.helperPayoutBatchBanking("RUB"))
+
+	rsp := suite.helperCreatePayoutBatch("RUB", pd.Id)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Item.Items, 1)
+	assert.Equal(suite.T(), pd.Id, rsp.Item.Items[0].PayoutDocumentId)
+}
+
+func (suite *PayoutsTestSuite) TestPayoutBatch_CreatePayoutBatch_PayoutNotAllowed_Error() {
+	pd := suite.helperInsertBatchPayout("RUB", []string{}, suite.helperPayoutBatchBanking("RUB"))
+	suite.helperInsertPayoutDocuments([]*billingpb.PayoutDocument{suite.payout7})
+
+	rsp := suite.helperCreatePayoutBatch("RUB", pd.Id, suite.payout7.Id)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), payoutBatchErrorPayoutNotAllowed, rsp.Message)
+}
+
+func (suite *PayoutsTestSuite) TestPayoutBatch_CreatePayoutBatch_PayoutReserved_Error() {
+	// The unique index of batch items is created by migrations which aren't applied to the test database.
+	_, err := suite.service.db.Collection("payout_batch").Indexes().CreateOne(
+		context.TODO(),
+		mongo.IndexModel{
+			Keys:    bson.M{"items.payout_document_id": 1},
+			Options: options.Index().SetUnique(true),
+		},
+	)
+	assert.NoError(suite.T(), err)
+
+	pd := suite.helperInsertBatchPayout("RUB", []string{}, suite.helperPayoutBatchBanking("RUB"))
+
+	rsp := suite.helperCreatePayoutBatch("RUB", pd.Id)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	batch := &intPkg.PayoutBatch{
+		OperatingCompanyId: suite.operatingCompany.Id,
+		Currency:           "RUB",
+		Items:              []*intPkg.PayoutBatchItem{{PayoutDocumentId: pd.Id}},
+	}
+	err = suite.service.payoutBatchRepository.Insert(context.TODO(), batch)
+	assert.Equal(suite.T(), repository.ErrPayoutBatchPayoutExists, err)
+}
+
+func (suite *PayoutsTestSuite) TestPayoutBatch_CreatePayoutBatch_ValidationErrors() {
+	req := &intPkg.CreatePayoutBatchRequest{
+		OperatingCompanyId: suite.operatingCompany.Id,
+		DebtorAccount:      payoutBatchTestDebtorAccount,
+	}
+	rsp := &intPkg.PayoutBatchResponse{}
+	err := suite.service.CreatePayoutBatch(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), payoutBatchErrorCurrencyRequired, rsp.Message)
+
+	req.Currency = "RUB"
+	req.DebtorAccount = ""
+	rsp = &intPkg.PayoutBatchResponse{}
+	err = suite.service.CreatePayoutBatch(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), payoutBatchErrorDebtorAccountRequired, rsp.Message)
+
+	req.DebtorAccount = payoutBatchTestDebtorAccount
+	req.OperatingCompanyId = primitive.NewObjectID().Hex()
+	rsp = &intPkg.PayoutBatchResponse{}
+	err = suite.service.CreatePayoutBatch(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), errorOperatingCompanyNotFound, rsp.Message)
+}
+
+func (suite *PayoutsTestSuite) TestPayoutBatch_ListPayoutBatches_Ok() {
+	suite.helperInsertBatchPayout("RUB", []string{}, suite.helperPayoutBatchBanking("RUB"))
+	suite.helperInsertBatchPayout("EUR", []string{}, suite.helperPayoutBatchBanking("EUR"))
+	suite.helperCreatePayoutBatch("RUB")
+	suite.helperCreatePayoutBatch("EUR")
+
+	req := &intPkg.ListPayoutBatchesRequest{OperatingCompanyId: suite.operatingCompany.Id, Currency: "EUR"}
+	rsp := &intPkg.ListPayoutBatchesResponse{}
+	err := suite.service.ListPayoutBatches(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.EqualValues(suite.T(), 1, rsp.Count)
+	assert.Len(suite.T(), rsp.Items, 1)
+	assert.Equal(suite.T(), "EUR", rsp.Items[0].Currency)
+
+	getRsp := &intPkg.PayoutBatchResponse{}
+	err = suite.service.GetPayoutBatch(context.TODO(), &intPkg.GetPayoutBatchRequest{BatchId: rsp.Items[0].Id.Hex()}, getRsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, getRsp.Status)
+	assert.Equal(suite.T(), rsp.Items[0].MessageId, getRsp.Item.MessageId)
+}
+
+func (suite *PayoutsTestSuite) TestPayoutBatch_ImportPayoutBatchStatusReport_Ok() {
+	suite.helperInsertRoyaltyReports([]*billingpb.RoyaltyReport{suite.report1, suite.report6})
+	pd1 := suite.helperInsertBatchPayout("EUR", []string{suite.report1.Id}, suite.helperPayoutBatchBanking("EUR"))
+	pd2 := suite.helperInsertBatchPayout("EUR", []string{suite.report6.Id}, suite.helperPayoutBatchBanking("EUR"))
+
+	batch := suite.helperCreatePayoutBatch("EUR").Item
+	rsp := suite.helperImportPayoutBatchStatusReport(
+		batch,
+		"PART",
+		fmt.Sprintf(payoutBatchTestPain002Transaction, pd1.Id, "ACSC", "", "", "BANKREF1"),
+		fmt.Sprintf(payoutBatchTestPain002Transaction, pd2.Id, "RJCT", "AC04", "Closed account number", ""),
+	)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.PayoutBatchStatusProcessed, rsp.Item.Status)
+	assert.False(suite.T(), rsp.Item.ProcessedAt.IsZero())
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPaid, rsp.Item.Items[0].Status)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusFailed, rsp.Item.Items[1].Status)
+	assert.Equal(suite.T(), "AC04", rsp.Item.Items[1].StatusCode)
+
+	pd, err := suite.service.payoutRepository.GetById(context.TODO(), pd1.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPaid, pd.Status)
+	assert.Equal(suite.T(), "BANKREF1", pd.Transaction)
+
+	rr, err := suite.service.royaltyReportRepository.GetById(context.TODO(), suite.report1.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.RoyaltyReportStatusPaid, rr.Status)
+
+	pd, err = suite.service.payoutRepository.GetById(context.TODO(), pd2.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusFailed, pd.Status)
+	assert.Equal(suite.T(), "AC04", pd.FailureCode)
+	assert.Equal(suite.T(), "Closed account number", pd.FailureMessage)
+	assert.Equal(suite.T(), pd2.Id, pd.FailureTransaction)
+
+	// payouts of the processed batch can be included into new batches after manual changes only
+	rsp = suite.helperCreatePayoutBatch("EUR")
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), payoutBatchErrorNoPayouts, rsp.Message)
+}
+
+func (suite *PayoutsTestSuite) TestPayoutBatch_ImportPayoutBatchStatusReport_PartiallyProcessed() {
+	suite.helperInsertRoyaltyReports([]*billingpb.RoyaltyReport{suite.report1})
+	pd1 := suite.helperInsertBatchPayout("EUR", []string{suite.report1.Id}, suite.helperPayoutBatchBanking("EUR"))
+	pd2 := suite.helperInsertBatchPayout("EUR", []string{}, suite.helperPayoutBatchBanking("EUR"))
+
+	batch := suite.helperCreatePayoutBatch("EUR").Item
+	rsp := suite.helperImportPayoutBatchStatusReport(
+		batch,
+		"PART",
+		fmt.Sprintf(payoutBatchTestPain002Transaction, pd1.Id, "ACSC", "", "", ""),
+		fmt.Sprintf(payoutBatchTestPain002Transaction, pd2.Id, "ACSP", "", "", ""),
+	)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.PayoutBatchStatusPartiallyProcessed, rsp.Item.Status)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPaid, rsp.Item.Items[0].Status)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPending, rsp.Item.Items[1].Status)
+
+	pd, err := suite.service.payoutRepository.GetById(context.TODO(), pd1.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pd1.Id, pd.Transaction)
+
+	pd, err = suite.service.payoutRepository.GetById(context.TODO(), pd2.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPending, pd.Status)
+}
+
+func (suite *PayoutsTestSuite) TestPayoutBatch_ImportPayoutBatchStatusReport_GroupRejected() {
+	suite.helperInsertRoyaltyReports([]*billingpb.RoyaltyReport{suite.report6})
+	pd := suite.helperInsertBatchPayout("EUR", []string{suite.report6.Id}, suite.helperPayoutBatchBanking("EUR"))
+
+	batch := suite.helperCreatePayoutBatch("EUR").Item
+	rsp := suite.helperImportPayoutBatchStatusReport(batch, payoutBatchTransactionStatusRejected)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.PayoutBatchStatusProcessed, rsp.Item.Status)
+
+	pd, err := suite.service.payoutRepository.GetById(context.TODO(), pd.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusFailed, pd.Status)
+	assert.Equal(suite.T(), payoutBatchTransactionStatusRejected, pd.FailureCode)
+	assert.Equal(suite.T(), payoutBatchRejectedMessage, pd.FailureMessage)
+}
+
+func (suite *PayoutsTestSuite) TestPayoutBatch_ImportPayoutBatchStatusReport_Errors() {
+	req := &intPkg.ImportPayoutBatchStatusReportRequest{File: []byte("not a report")}
+	rsp := &intPkg.PayoutBatchResponse{}
+	err := suite.service.ImportPayoutBatchStatusReport(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), payoutBatchErrorStatusReportInvalid, rsp.Message)
+
+	batch := &intPkg.PayoutBatch{MessageId: primitive.NewObjectID().Hex()}
+	rsp = suite.helperImportPayoutBatchStatusReport(batch, "ACSC")
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), payoutBatchErrorNotFound, rsp.Message)
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/jinzhu/now"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg/errors"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
@@ -29,6 +32,8 @@ const (
 	payoutChangeSourceAdmin    = "admin"
 
 	payoutArrivalInDays = 5
+
+	payoutSkipBelowMinimumMessage = "payout amount %.2f %s is less than minimum payout amount %.2f %s, the amount is carried over to the next payout"
 )
 
 var (
@@ -49,6 +54,10 @@ var (
 	errorPayoutTarrifNotFound          = errors.NewBillingServerErrorMsg("po000019", "not found minimal tarrif for payout currency")
 	errorPayoutTarrifMinimal           = errors.NewBillingServerErrorMsg("po000020", "minimal payout should be greater")
 	errorGettingB2BVatRate             = errors.NewBillingServerErrorMsg("po000021", "failed to get B2B vat rate")
+	errorPayoutFxRealization           = errors.NewBillingServerErrorMsg("po000022", "failed to post realized currency exchange gain of payout")
+	errorPayoutMerchantOnHold          = errors.NewBillingServerErrorMsg("po000023", "payouts of merchant are on hold")
+	errorPayoutScheduleUnknown         = errors.NewBillingServerErrorMsg("po000024", "getting payout schedule of merchant failed")
+	errorPayoutAccountsUnknown         = errors.NewBillingServerErrorMsg("po000026", "getting payout accounts of merchant failed")
 
 	statusForUpdateBalance = map[string]bool{
 		pkg.PayoutDocumentStatusPending: true,
@@ -88,15 +97,87 @@ func (s *Service) CreatePayoutDocument(
 		return nil
 	}
 
+	schedule, err := s.getMerchantPayoutSchedule(ctx, merchant.Id)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorPayoutScheduleUnknown
+		return nil
+	}
+
+	if schedule != nil && schedule.Hold {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorPayoutMerchantOnHold
+		return nil
+	}
+
 	return s.createPayoutDocument(ctx, merchant, req, res)
 }
 
+// createPayoutDocument creates payouts of the merchant per payout account of the merchant. Accounts without
+// royalty reports to pay are skipped if payouts to other accounts are created.
 func (s *Service) createPayoutDocument(
 	ctx context.Context,
 	merchant *billingpb.Merchant,
 	req *billingpb.CreatePayoutDocumentRequest,
 	res *billingpb.CreatePayoutDocumentResponse,
 ) error {
+	accounts, err := s.getMerchantPayoutAccounts(ctx, merchant)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorPayoutAccountsUnknown
+		return nil
+	}
+
+	var failed *billingpb.CreatePayoutDocumentResponse
+
+	for _, account := range accounts {
+		rsp := &billingpb.CreatePayoutDocumentResponse{}
+
+		if err = s.createPayoutDocumentByAccount(ctx, merchant, account, req, rsp); err != nil {
+			return err
+		}
+
+		if rsp.Status == billingpb.ResponseStatusOk {
+			res.Items = append(res.Items, rsp.Items...)
+			continue
+		}
+
+		if failed == nil || failed.Message == errorPayoutSourcesNotFound {
+			failed = rsp
+		}
+	}
+
+	if failed != nil && (len(res.Items) == 0 || failed.Message != errorPayoutSourcesNotFound) {
+		res.Status = failed.Status
+		res.Message = failed.Message
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+func (s *Service) createPayoutDocumentByAccount(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+	account *intPkg.MerchantPayoutAccount,
+	req *billingpb.CreatePayoutDocumentRequest,
+	res *billingpb.CreatePayoutDocumentResponse,
+) error {
+	reports, err := s.getPayoutDocumentSourcesByCurrency(ctx, merchant.Id, account.Currency)
+
+	if err != nil {
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			res.Status = billingpb.ResponseStatusBadData
+			res.Message = e
+			return nil
+		}
+		return err
+	}
+
 	arrivalDate, err := ptypes.TimestampProto(now.EndOfDay().Add(time.Hour * 24 * payoutArrivalInDays))
 	if err != nil {
 		return err
@@ -116,25 +197,14 @@ func (s *Service) createPayoutDocument(
 		UpdatedAt:               ptypes.TimestampNow(),
 		ArrivalDate:             arrivalDate,
 		MerchantId:              merchant.Id,
-		Destination:             merchant.Banking,
+		Destination:             account.Banking,
 		Company:                 merchant.Company,
 		MerchantAgreementNumber: merchant.AgreementNumber,
 		OperatingCompanyId:      merchant.OperatingCompanyId,
 		AutoincrementId:         autoincrementId,
 	}
 
-	reports, err := s.getPayoutDocumentSources(ctx, merchant)
-
-	if err != nil {
-		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
-			res.Status = billingpb.ResponseStatusBadData
-			res.Message = e
-			return nil
-		}
-		return err
-	}
-
-	pd.Currency = reports[0].Currency
+	pd.Currency = account.Currency
 
 	times := make([]time.Time, 0)
 	stringTimes := make([]string, 0)
@@ -181,7 +251,7 @@ func (s *Service) createPayoutDocument(
 	pd.B2BVatAmount = math.Round(pd.B2BVatAmount*100) / 100
 	pd.FeesExcludingVat = math.Round(pd.FeesExcludingVat*100) / 100
 
-	currency := merchant.GetPayoutCurrency()
+	currency := account.Currency
 	var minimal float32
 	var ok bool
 
@@ -198,16 +268,7 @@ func (s *Service) createPayoutDocument(
 		return nil
 	}
 
-	if pd.Balance < float64(minimal) {
-		if req.IsAutoGeneration {
-			zap.L().Info("balance is less than minimal in tariff for merchant currency. skipping auto-generation payout",
-				zap.String("merchant_id", merchant.Id),
-				zap.Float32("minimal", minimal),
-				zap.Float64("amount", pd.Balance),
-				zap.String("currency", currency))
-			res.Status = billingpb.ResponseStatusOk
-			return nil
-		}
+	if pd.Balance < float64(minimal) && !req.IsAutoGeneration {
 		res.Status = billingpb.ResponseStatusBadData
 		res.Message = errorPayoutTarrifMinimal
 		return nil
@@ -219,7 +280,7 @@ func (s *Service) createPayoutDocument(
 		return nil
 	}
 
-	balance, err := s.getMerchantBalance(ctx, merchant.Id)
+	balance, err := s.merchantBalanceRepository.GetByIdAndCurrency(ctx, merchant.Id, account.Currency)
 	if err != nil {
 		res.Status = billingpb.ResponseStatusSystemError
 		res.Message = errorPayoutBalanceError
@@ -234,8 +295,34 @@ func (s *Service) createPayoutDocument(
 		return nil
 	}
 
-	if pd.Balance < merchant.MinPayoutAmount {
+	schedule, err := s.getMerchantPayoutSchedule(ctx, merchant.Id)
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorPayoutScheduleUnknown
+		return nil
+	}
+
+	minPayoutAmount := getMerchantMinPayoutAmount(merchant, schedule)
+
+	if account.Currency != merchant.GetPayoutCurrency() {
+		minPayoutAmount = account.MinPayoutAmount
+	}
+
+	// auto-generated payouts less than minimal in tariff are skipped and carried over as well
+	if req.IsAutoGeneration && float64(minimal) > minPayoutAmount {
+		minPayoutAmount = float64(minimal)
+	}
+
+	if pd.Balance < minPayoutAmount {
+		zap.L().Info("payout amount is less than minimum payout amount of merchant. payout is skipped",
+			zap.String("merchant_id", merchant.Id),
+			zap.Float64("minimal", minPayoutAmount),
+			zap.Float64("amount", pd.Balance),
+			zap.String("currency", pd.Currency))
+
 		pd.Status = pkg.PayoutDocumentStatusSkip
+		pd.FailureCode = pkg.PayoutSkipReasonBelowMinimum
+		pd.FailureMessage = fmt.Sprintf(payoutSkipBelowMinimumMessage, pd.Balance, pd.Currency, minPayoutAmount, pd.Currency)
 	}
 
 	sort.Slice(times, func(i, j int) bool {
@@ -267,19 +354,35 @@ func (s *Service) createPayoutDocument(
 	pd.StringPeriodFrom = stringTimes[0]
 	pd.StringPeriodTo = stringTimes[len(stringTimes)-1]
 
-	err = s.payoutRepository.Insert(ctx, pd, req.Ip, payoutChangeSourceMerchant)
+	if pd.Status == pkg.PayoutDocumentStatusSkip {
+		err = s.savePayoutDocumentSkip(ctx, pd, req.Ip)
+	} else {
+		err = database.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+			if err := s.payoutRepository.Insert(ctx, pd, req.Ip, payoutChangeSourceMerchant); err != nil {
+				return err
+			}
+
+			if err := s.royaltyReportSetPayoutDocumentId(ctx, pd.SourceId, pd.Id, req.Ip, req.Initiator); err != nil {
+				return err
+			}
+
+			err := s.addMerchantBalanceTransaction(ctx, &intPkg.MerchantBalanceTransaction{
+				MerchantId: pd.MerchantId,
+				Currency:   pd.Currency,
+				Type:       pkg.MerchantBalanceTransactionTypePayout,
+				Amount:     -pd.TotalFees,
+				SourceId:   pd.Id,
+				SourceType: merchantBalanceTransactionSourcePayout,
+			})
+
+			if err != nil {
+				return errorPayoutUpdateBalance
+			}
 
-	if err != nil {
-		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
-			res.Status = billingpb.ResponseStatusSystemError
-			res.Message = e
 			return nil
-		}
-		return err
+		})
 	}
 
-	err = s.royaltyReportSetPayoutDocumentId(ctx, pd.SourceId, pd.Id, req.Ip, req.Initiator)
-
 	if err != nil {
 		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
 			res.Status = billingpb.ResponseStatusSystemError
@@ -314,6 +417,33 @@ func (s *Service) createPayoutDocument(
 	return nil
 }
 
+// savePayoutDocumentSkip stores the skipped payout once per payout account and period. Royalty reports of skipped
+// payout stay without payout and are carried over, so the next skipped payout of the same reports updates
+// the stored one instead of adding the new payout on each run.
+func (s *Service) savePayoutDocumentSkip(ctx context.Context, pd *billingpb.PayoutDocument, ip string) error {
+	from, err := ptypes.Timestamp(pd.PeriodFrom)
+
+	if err != nil {
+		return err
+	}
+
+	skipped, err := s.payoutRepository.GetSkipped(ctx, pd.MerchantId, pd.Currency, from)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	if skipped == nil {
+		return s.payoutRepository.Insert(ctx, pd, ip, payoutChangeSourceMerchant)
+	}
+
+	pd.Id = skipped.Id
+	pd.AutoincrementId = skipped.AutoincrementId
+	pd.CreatedAt = skipped.CreatedAt
+
+	return s.payoutRepository.Update(ctx, pd, ip, payoutChangeSourceMerchant)
+}
+
 func (s *Service) GetPayoutDocument(
 	ctx context.Context,
 	req *billingpb.GetPayoutDocumentRequest,
@@ -392,23 +522,48 @@ func (s *Service) AutoCreatePayoutDocuments(
 		return err
 	}
 
+	schedules, err := s.merchantPayoutScheduleRepository.GetAll(ctx)
+	if err != nil {
+		zap.L().Error("getting payout schedules of merchants failed", zap.Error(err))
+		return err
+	}
+
+	merchantSchedules := make(map[string]*intPkg.MerchantPayoutSchedule, len(schedules))
+
+	for _, v := range schedules {
+		merchantSchedules[v.MerchantId] = v
+	}
+
 	req1 := &billingpb.CreatePayoutDocumentRequest{
 		Ip:               "0.0.0.0",
 		Initiator:        pkg.RoyaltyReportChangeSourceAuto,
 		IsAutoGeneration: true,
 	}
-	res := &billingpb.CreatePayoutDocumentResponse{}
 
 	wasErrors := false
+	date := time.Now()
 
 	for _, m := range merchants {
+		schedule := merchantSchedules[m.Id]
+
+		if schedule != nil && schedule.Hold {
+			zap.L().Info(
+				"payouts of merchant are on hold. skipping auto-generation payout",
+				zap.String("merchantId", m.Id),
+				zap.String("reason", schedule.HoldReason),
+			)
+			continue
+		}
+
+		if !isMerchantPayoutDue(schedule, date) {
+			continue
+		}
+
 		req1.MerchantId = m.Id
+		res := &billingpb.CreatePayoutDocumentResponse{}
 		err = s.createPayoutDocument(ctx, m, req1, res)
 
-		if err != nil {
-			if err == errorPayoutSourcesNotFound {
-				continue
-			}
+		if err != nil && err != errorPayoutSourcesNotFound {
 			zap.L().Error(
 				"auto createPayoutDocument failed with error",
 				zap.Error(err),
@@ -417,10 +572,9 @@ func (s *Service) AutoCreatePayoutDocuments(
 			wasErrors = true
 			continue
 		}
-		if res.Status != billingpb.ResponseStatusOk {
-			if res.Message == errorPayoutAmountInvalid || res.Message == errorPayoutSourcesNotFound {
-				continue
-			}
+
+		if err == nil && res.Status != billingpb.ResponseStatusOk &&
+			res.Message != errorPayoutAmountInvalid && res.Message != errorPayoutSourcesNotFound {
 			zap.L().Error(
 				"auto createPayoutDocument failed in response",
 				zap.Int32("code", res.Status),
@@ -430,6 +584,21 @@ func (s *Service) AutoCreatePayoutDocuments(
 			wasErrors = true
 			continue
 		}
+
+		if schedule == nil || schedule.Frequency == "" {
+			continue
+		}
+
+		schedule.LastRunAt = date
+
+		if err = s.merchantPayoutScheduleRepository.Upsert(ctx, schedule); err != nil {
+			zap.L().Error(
+				"updating payout schedule of merchant failed",
+				zap.Error(err),
+				zap.String("merchantId", m.Id),
+			)
+			wasErrors = true
+		}
 	}
 
 	if wasErrors {
@@ -553,7 +722,21 @@ func (s *Service) UpdatePayoutDocument(
 	}
 
 	if isChanged {
-		err = s.payoutRepository.Update(ctx, pd, req.Ip, payoutChangeSourceAdmin)
+		err = database.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+			// realized gain is posted with the paid status, so the paid payout can't remain without it
+			if becomePaid {
+				if err := s.realizeFxRevaluation(ctx, pd); err != nil {
+					zap.L().Error(
+						"Unable to realize currency exchange gain of payout",
+						zap.Error(err),
+						zap.String("payout_document_id", pd.Id),
+					)
+					return errorPayoutFxRealization
+				}
+			}
+
+			return s.payoutRepository.Update(ctx, pd, req.Ip, payoutChangeSourceAdmin)
+		})
 		if err != nil {
 			if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
 				res.Status = billingpb.ResponseStatusSystemError
@@ -563,7 +746,7 @@ func (s *Service) UpdatePayoutDocument(
 			return err
 		}
 
-		if becomePaid == true {
+		if becomePaid {
 			err = s.royaltyReportSetPaid(ctx, pd.SourceId, pd.Id, req.Ip, pkg.RoyaltyReportChangeSourceAdmin)
 			if err != nil {
 				res.Status = billingpb.ResponseStatusSystemError
@@ -581,6 +764,22 @@ func (s *Service) UpdatePayoutDocument(
 
 					return nil
 				}
+
+				err = s.addMerchantBalanceTransaction(ctx, &intPkg.MerchantBalanceTransaction{
+					MerchantId: pd.MerchantId,
+					Currency:   pd.Currency,
+					Type:       pkg.MerchantBalanceTransactionTypePayoutReversal,
+					Amount:     pd.TotalFees,
+					SourceId:   pd.Id,
+					SourceType: merchantBalanceTransactionSourcePayout,
+				})
+
+				if err != nil {
+					res.Status = billingpb.ResponseStatusSystemError
+					res.Message = errorPayoutUpdateBalance
+
+					return nil
+				}
 			}
 		}
 
@@ -738,7 +937,14 @@ func (s *Service) getPayoutDocumentSources(
 	ctx context.Context,
 	merchant *billingpb.Merchant,
 ) ([]*billingpb.RoyaltyReport, error) {
-	result, err := s.royaltyReportRepository.GetNonPayoutReports(ctx, merchant.Id, merchant.GetPayoutCurrency())
+	return s.getPayoutDocumentSourcesByCurrency(ctx, merchant.Id, merchant.GetPayoutCurrency())
+}
+
+func (s *Service) getPayoutDocumentSourcesByCurrency(
+	ctx context.Context,
+	merchantId, currency string,
+) ([]*billingpb.RoyaltyReport, error) {
+	result, err := s.royaltyReportRepository.GetNonPayoutReports(ctx, merchantId, currency)
 
 	if err != nil && err != mongo.ErrNoDocuments {
 		return nil, err
@@ -1045,6 +1045,34 @@ func (suite *PayoutsTestSuite) TestPayouts_UpdatePayoutDocument_Ok_PaidOk() {
 	assert.Greater(suite.T(), rr.PayoutDate.Seconds, int64(-62135596800))
 }
 
+func (suite *PayoutsTestSuite) TestPayouts_UpdatePayoutDocument_PaidFxRealizationFailed() {
+	suite.helperInsertRoyaltyReports([]*billingpb.RoyaltyReport{suite.report6})
+	suite.helperInsertPayoutDocuments([]*billingpb.PayoutDocument{suite.payout2})
+	suite.service.curService = mocks.NewCurrencyServiceMockError()
+
+	req := &billingpb.UpdatePayoutDocumentRequest{
+		PayoutDocumentId: suite.payout2.Id,
+		Status:           pkg.PayoutDocumentStatusPaid,
+		Transaction:      "transaction123",
+		Ip:               "192.168.1.1",
+	}
+
+	res := &billingpb.PayoutDocumentResponse{}
+
+	err := suite.service.UpdatePayoutDocument(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusSystemError, res.Status)
+	assert.Equal(suite.T(), errorPayoutFxRealization, res.Message)
+
+	pd, err := suite.service.payoutRepository.GetById(context.TODO(), suite.payout2.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPending, pd.Status)
+
+	rr, err := suite.service.royaltyReportRepository.GetById(context.TODO(), suite.report6.Id)
+	assert.NoError(suite.T(), err)
+	assert.NotEqual(suite.T(), billingpb.RoyaltyReportStatusPaid, rr.Status)
+}
+
 func (suite *PayoutsTestSuite) TestPayouts_UpdatePayoutDocument_Failed_StatusForbidden() {
 
 	suite.helperInsertRoyaltyReports([]*billingpb.RoyaltyReport{suite.report6})
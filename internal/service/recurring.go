@@ -3,13 +3,19 @@ package service
 import (
 	"context"
 	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/payment_system"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg/errors"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"github.com/paysuper/paysuper-proto/go/recurringpb"
+	"github.com/streadway/amqp"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
+	"time"
 )
 
 var (
@@ -21,6 +27,10 @@ var (
 	recurringErrorIdentifierNotFound   = errors.NewBillingServerErrorMsg("re000007", "identifier for subscription is not found")
 	recurringErrorSubscriptionNotFound = errors.NewBillingServerErrorMsg("re000008", "subscription not found")
 	recurringErrorAccessDeny           = errors.NewBillingServerErrorMsg("re000009", "subscription access denied")
+	recurringErrorPauseCyclesInvalid   = errors.NewBillingServerErrorMsg("re000010", "number of cycles to pause subscription is invalid")
+	recurringErrorStatusNotAllowed     = errors.NewBillingServerErrorMsg("re000011", "action is not allowed for current subscription status")
+	recurringErrorPauseOutOfRange      = errors.NewBillingServerErrorMsg("re000012", "subscription pause must end before subscription expiration date")
+	recurringErrorUpdateSubscription   = errors.NewBillingServerErrorMsg("re000013", "unable to update subscription")
 )
 
 func (s *Service) DeleteSavedCard(
@@ -271,9 +281,18 @@ func (s *Service) GetSubscription(
 		return nil
 	}
 
+	state, err := s.getSubscriptionState(ctx, rsp1.Subscription)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = recurringErrorUnknown
+		return nil
+	}
+
 	rsp.Message = nil
 	rsp.Status = billingpb.ResponseStatusOk
 	rsp.Subscription = s.mapRecurringToBilling(rsp1.Subscription)
+	applySubscriptionState(rsp.Subscription, state)
 
 	return nil
 }
@@ -325,34 +344,11 @@ func (s *Service) DeleteRecurringSubscription(
 		return nil
 	}
 
-	order, err := s.orderRepository.GetById(ctx, subscription.OrderId)
-
-	if err != nil {
-		res.Status = billingpb.ResponseStatusNotFound
-		res.Message = orderErrorNotFound
-		return nil
-	}
-
-	ps, err := s.paymentSystemRepository.GetById(ctx, order.PaymentMethod.PaymentSystemId)
-
-	if err != nil {
-		res.Status = billingpb.ResponseStatusNotFound
-		res.Message = orderErrorPaymentSystemInactive
-		return nil
-	}
-
-	h, err := s.paymentSystemGateway.GetGateway(ps.Handler)
-
-	if err != nil {
-		zap.L().Error(
-			"Unable to get payment system gateway",
-			zap.Error(err),
-			zap.Any("subscription", subscription),
-			zap.Any("payment_system", ps),
-		)
+	order, ps, h, status, msg := s.getSubscriptionGateway(ctx, subscription)
 
-		res.Status = billingpb.ResponseStatusSystemError
-		res.Message = orderErrorPaymentSystemInactive
+	if msg != nil {
+		res.Status = status
+		res.Message = msg
 		return nil
 	}
 
@@ -389,6 +385,20 @@ func (s *Service) DeleteRecurringSubscription(
 		return nil
 	}
 
+	state, err := s.getSubscriptionState(ctx, subscription)
+
+	if err == nil {
+		err = s.changeSubscriptionState(ctx, subscription, state, pkg.SubscriptionStatusCanceled, getSubscriptionChangeSource(customerId))
+	}
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to save state of deleted subscription",
+			zap.Error(err),
+			zap.Any("subscription", subscription),
+		)
+	}
+
 	res.Status = billingpb.ResponseStatusOk
 
 	return nil
@@ -446,20 +456,626 @@ func (s *Service) FindSubscriptions(ctx context.Context, req *billingpb.FindSubs
 	rsp.Count = rsp1.Count
 	rsp.List = make([]*billingpb.RecurringSubscription, len(rsp1.List))
 
+	states := make(map[string]*intPkg.SubscriptionState)
+
+	if len(rsp1.List) > 0 {
+		ids := make([]string, len(rsp1.List))
+
+		for i, subscription := range rsp1.List {
+			ids[i] = subscription.Id
+		}
+
+		list, err := s.subscriptionStateRepository.GetBySubscriptionIds(ctx, ids)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = recurringErrorUnknown
+			return nil
+		}
+
+		for _, state := range list {
+			states[state.SubscriptionId] = state
+		}
+	}
+
 	for i, subscription := range rsp1.List {
 		rsp.List[i] = s.mapRecurringToBilling(subscription)
+		applySubscriptionState(rsp.List[i], states[subscription.Id])
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+func (s *Service) GetRecurringSubscriptionState(
+	ctx context.Context,
+	req *intPkg.RecurringSubscriptionRequest,
+	rsp *intPkg.RecurringSubscriptionStateResponse,
+) error {
+	subscription, customerId, status, msg := s.getSubscriptionForPerformer(ctx, req.Id, req.Cookie, req.MerchantId)
+
+	if msg != nil {
+		rsp.Status = status
+		rsp.Message = msg
+		return nil
+	}
+
+	state, err := s.getSubscriptionState(ctx, subscription)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = recurringErrorUnknown
+		return nil
+	}
+
+	if customerId != "" {
+		state.History = nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = state
+
+	return nil
+}
+
+func (s *Service) PauseRecurringSubscription(
+	ctx context.Context,
+	req *intPkg.PauseRecurringSubscriptionRequest,
+	rsp *intPkg.RecurringSubscriptionStateResponse,
+) error {
+	if req.Cycles <= 0 || req.Cycles > pkg.SubscriptionMaxPauseCycles {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = recurringErrorPauseCyclesInvalid
+		return nil
+	}
+
+	subscription, customerId, status, msg := s.getSubscriptionForPerformer(ctx, req.Id, req.Cookie, req.MerchantId)
+
+	if msg != nil {
+		rsp.Status = status
+		rsp.Message = msg
+		return nil
+	}
+
+	state, err := s.getSubscriptionState(ctx, subscription)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = recurringErrorUnknown
+		return nil
+	}
+
+	if state.Status != pkg.SubscriptionStatusActive {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = recurringErrorStatusNotAllowed
+		return nil
+	}
+
+	periodEnd, err := getSubscriptionPeriodEnd(subscription)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = recurringErrorUnknown
+		return nil
+	}
+
+	resumeAt := addRecurringPeriods(periodEnd, subscription.Period, int(req.Cycles))
+
+	if subscription.ExpireAt != nil {
+		expireAt, err := ptypes.Timestamp(subscription.ExpireAt)
+
+		if err != nil || !resumeAt.Before(expireAt) {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = recurringErrorPauseOutOfRange
+			return nil
+		}
+	}
+
+	order, _, h, status, msg := s.getSubscriptionGateway(ctx, subscription)
+
+	if msg != nil {
+		rsp.Status = status
+		rsp.Message = msg
+		return nil
+	}
+
+	if err = h.PauseRecurringSubscription(order, subscription); err != nil {
+		zap.L().Error(
+			"Unable to pause subscription on payment system",
+			zap.Error(err),
+			zap.Any("subscription", subscription),
+		)
+
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = recurringErrorUpdateSubscription
+		return nil
+	}
+
+	state.PauseCycles = req.Cycles
+	state.PausedAt = time.Now()
+	state.ResumeAt = resumeAt
+
+	err = s.changeSubscriptionState(ctx, subscription, state, pkg.SubscriptionStatusPaused, getSubscriptionChangeSource(customerId))
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = recurringErrorUpdateSubscription
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = state
+
+	return nil
+}
+
+func (s *Service) ResumeRecurringSubscription(
+	ctx context.Context,
+	req *intPkg.RecurringSubscriptionRequest,
+	rsp *intPkg.RecurringSubscriptionStateResponse,
+) error {
+	subscription, customerId, status, msg := s.getSubscriptionForPerformer(ctx, req.Id, req.Cookie, req.MerchantId)
+
+	if msg != nil {
+		rsp.Status = status
+		rsp.Message = msg
+		return nil
+	}
+
+	state, err := s.getSubscriptionState(ctx, subscription)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = recurringErrorUnknown
+		return nil
+	}
+
+	if state.Status != pkg.SubscriptionStatusPaused && state.Status != pkg.SubscriptionStatusCancelAtPeriodEnd {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = recurringErrorStatusNotAllowed
+		return nil
+	}
+
+	err = s.resumeSubscription(ctx, subscription, state, getSubscriptionChangeSource(customerId))
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = recurringErrorUpdateSubscription
+
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Message = e
+		}
+
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = state
+
+	return nil
+}
+
+func (s *Service) CancelRecurringSubscriptionAtPeriodEnd(
+	ctx context.Context,
+	req *intPkg.RecurringSubscriptionRequest,
+	rsp *intPkg.RecurringSubscriptionStateResponse,
+) error {
+	subscription, customerId, status, msg := s.getSubscriptionForPerformer(ctx, req.Id, req.Cookie, req.MerchantId)
+
+	if msg != nil {
+		rsp.Status = status
+		rsp.Message = msg
+		return nil
+	}
+
+	state, err := s.getSubscriptionState(ctx, subscription)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = recurringErrorUnknown
+		return nil
+	}
+
+	if state.Status != pkg.SubscriptionStatusActive {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = recurringErrorStatusNotAllowed
+		return nil
+	}
+
+	periodEnd, err := getSubscriptionPeriodEnd(subscription)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = recurringErrorUnknown
+		return nil
+	}
+
+	order, _, h, status, msg := s.getSubscriptionGateway(ctx, subscription)
+
+	if msg != nil {
+		rsp.Status = status
+		rsp.Message = msg
+		return nil
+	}
+
+	// Charges are stopped at the gateway right away, but the subscription itself
+	// is kept until the end of paid period, so customer doesn't lose access to the product.
+	if err = h.PauseRecurringSubscription(order, subscription); err != nil {
+		zap.L().Error(
+			"Unable to stop subscription charges on payment system",
+			zap.Error(err),
+			zap.Any("subscription", subscription),
+		)
+
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = recurringErrorUpdateSubscription
+		return nil
+	}
+
+	state.CancelAt = periodEnd
+
+	err = s.changeSubscriptionState(ctx, subscription, state, pkg.SubscriptionStatusCancelAtPeriodEnd, getSubscriptionChangeSource(customerId))
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = recurringErrorUpdateSubscription
+		return nil
 	}
 
 	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = state
+
+	return nil
+}
+
+// ProcessSubscriptionsLifecycle resumes paused subscriptions which pause is over
+// and deletes subscriptions which were canceled at the end of paid period.
+func (s *Service) ProcessSubscriptionsLifecycle(ctx context.Context) error {
+	states, err := s.subscriptionStateRepository.FindDue(ctx, time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	for _, state := range states {
+		rsp, err := s.rep.GetSubscription(ctx, &recurringpb.GetSubscriptionRequest{Id: state.SubscriptionId})
+
+		if err != nil || rsp.Status != billingpb.ResponseStatusOk {
+			if err == nil {
+				err = fmt.Errorf(rsp.Message)
+			}
+
+			zap.L().Error(
+				"Unable to get subscription",
+				zap.Error(err),
+				zap.String("subscription_id", state.SubscriptionId),
+			)
+			continue
+		}
+
+		subscription := rsp.Subscription
+
+		if state.Status == pkg.SubscriptionStatusPaused {
+			err = s.resumeSubscription(ctx, subscription, state, pkg.SubscriptionChangeSourceSystem)
+		} else {
+			err = s.cancelSubscription(ctx, subscription, state)
+		}
+
+		if err != nil {
+			zap.L().Error(
+				"Unable to process subscription lifecycle",
+				zap.Error(err),
+				zap.Any("state", state),
+			)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) resumeSubscription(
+	ctx context.Context,
+	subscription *recurringpb.Subscription,
+	state *intPkg.SubscriptionState,
+	source string,
+) error {
+	order, _, h, _, msg := s.getSubscriptionGateway(ctx, subscription)
+
+	if msg != nil {
+		return msg
+	}
+
+	if err := h.ResumeRecurringSubscription(order, subscription); err != nil {
+		zap.L().Error(
+			"Unable to resume subscription on payment system",
+			zap.Error(err),
+			zap.Any("subscription", subscription),
+		)
+
+		return recurringErrorUpdateSubscription
+	}
+
+	state.PauseCycles = 0
+	state.PausedAt = time.Time{}
+	state.ResumeAt = time.Time{}
+	state.CancelAt = time.Time{}
+
+	return s.changeSubscriptionState(ctx, subscription, state, pkg.SubscriptionStatusActive, source)
+}
+
+func (s *Service) cancelSubscription(
+	ctx context.Context,
+	subscription *recurringpb.Subscription,
+	state *intPkg.SubscriptionState,
+) error {
+	order, _, h, _, msg := s.getSubscriptionGateway(ctx, subscription)
+
+	if msg != nil {
+		return msg
+	}
+
+	if err := h.DeleteRecurringSubscription(order, subscription); err != nil {
+		zap.L().Error(
+			"Unable to delete subscription on payment system",
+			zap.Error(err),
+			zap.Any("subscription", subscription),
+		)
+
+		return recurringErrorDeleteSubscription
+	}
+
+	rsp, err := s.rep.DeleteSubscription(ctx, subscription)
+
+	if err != nil || rsp.Status != billingpb.ResponseStatusOk {
+		if err == nil {
+			err = fmt.Errorf(rsp.Message)
+		}
+
+		zap.L().Error(
+			"Unable to delete subscription on recurring service",
+			zap.Error(err),
+			zap.Any("subscription", subscription),
+		)
+
+		return recurringErrorDeleteSubscription
+	}
+
+	return s.changeSubscriptionState(ctx, subscription, state, pkg.SubscriptionStatusCanceled, pkg.SubscriptionChangeSourceSystem)
+}
+
+func (s *Service) changeSubscriptionState(
+	ctx context.Context,
+	subscription *recurringpb.Subscription,
+	state *intPkg.SubscriptionState,
+	status, source string,
+) error {
+	state.Status = status
+	state.History = append(state.History, &intPkg.SubscriptionStateChange{
+		Status:    status,
+		Source:    source,
+		CreatedAt: time.Now(),
+	})
+
+	if err := s.subscriptionStateRepository.Upsert(ctx, state); err != nil {
+		return err
+	}
+
+	s.notifySubscriptionStateChanged(ctx, subscription, state)
 
 	return nil
 }
 
+// notifySubscriptionStateChanged sends the webhook about the changed state of the subscription to the merchant
+// through the payment notification topic used for order webhooks. The last order of the subscription is sent
+// as the payload and the state of the subscription is passed in the message headers.
+func (s *Service) notifySubscriptionStateChanged(
+	ctx context.Context,
+	subscription *recurringpb.Subscription,
+	state *intPkg.SubscriptionState,
+) {
+	filter := bson.M{"recurring_id": subscription.Id}
+	opts := options.FindOne().SetSort(bson.D{{"created_at", -1}, {"_id", -1}})
+	order, err := s.orderRepository.GetOneBy(ctx, filter, opts)
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to get last order of subscription to notify merchant",
+			zap.Error(err),
+			zap.String("subscription_id", subscription.Id),
+			zap.String("status", state.Status),
+		)
+		return
+	}
+
+	headers := amqp.Table{
+		"x-retry-count": int32(0),
+		pkg.SubscriptionNotifyHeaderSubscriptionId: subscription.Id,
+		pkg.SubscriptionNotifyHeaderStatus:         state.Status,
+	}
+
+	if state.Status == pkg.SubscriptionStatusCancelAtPeriodEnd {
+		headers[pkg.SubscriptionNotifyHeaderCancelAt] = state.CancelAt.Format(time.RFC3339)
+	}
+
+	if state.Status == pkg.SubscriptionStatusPaused {
+		headers[pkg.SubscriptionNotifyHeaderResumeAt] = state.ResumeAt.Format(time.RFC3339)
+	}
+
+	err = s.broker.Publish(recurringpb.PayOneTopicNotifyPaymentName, order, headers)
+
+	if err != nil {
+		zap.L().Error(
+			brokerPublicationFailed,
+			zap.Error(err),
+			zap.String("topic", recurringpb.PayOneTopicNotifyPaymentName),
+			zap.String("order_id", order.Id),
+			zap.String("subscription_id", subscription.Id),
+			zap.String("status", state.Status),
+		)
+	}
+}
+
+func (s *Service) getSubscriptionForPerformer(
+	ctx context.Context,
+	id, cookie, merchantId string,
+) (*recurringpb.Subscription, string, int32, *billingpb.ResponseErrorMessage) {
+	var customerId string
+
+	browserCookie, err := s.findAndParseBrowserCookie(cookie)
+
+	if err != nil {
+		return nil, "", billingpb.ResponseStatusForbidden, recurringCustomerNotFound
+	}
+
+	if browserCookie != nil {
+		customerId = browserCookie.CustomerId
+	}
+
+	rsp, err := s.rep.GetSubscription(ctx, &recurringpb.GetSubscriptionRequest{Id: id})
+
+	if err != nil || rsp.Status != billingpb.ResponseStatusOk {
+		if err == nil {
+			err = fmt.Errorf(rsp.Message)
+		}
+
+		zap.L().Error(
+			"Unable to get subscription",
+			zap.Error(err),
+			zap.Any("subscription_id", id),
+		)
+
+		return nil, "", billingpb.ResponseStatusNotFound, recurringErrorSubscriptionNotFound
+	}
+
+	if err = s.checkSubscriptionPermission(customerId, merchantId, rsp.Subscription); err != nil {
+		return nil, "", billingpb.ResponseStatusForbidden, recurringErrorAccessDeny
+	}
+
+	return rsp.Subscription, customerId, billingpb.ResponseStatusOk, nil
+}
+
+func (s *Service) getSubscriptionGateway(
+	ctx context.Context,
+	subscription *recurringpb.Subscription,
+) (*billingpb.Order, *billingpb.PaymentSystem, payment_system.PaymentSystemInterface, int32, *billingpb.ResponseErrorMessage) {
+	return s.getOrderGateway(ctx, subscription.OrderId)
+}
+
+func (s *Service) getOrderGateway(
+	ctx context.Context,
+	orderId string,
+) (*billingpb.Order, *billingpb.PaymentSystem, payment_system.PaymentSystemInterface, int32, *billingpb.ResponseErrorMessage) {
+	order, err := s.orderRepository.GetById(ctx, orderId)
+
+	if err != nil {
+		return nil, nil, nil, billingpb.ResponseStatusNotFound, orderErrorNotFound
+	}
+
+	ps, err := s.paymentSystemRepository.GetById(ctx, order.PaymentMethod.PaymentSystemId)
+
+	if err != nil {
+		return nil, nil, nil, billingpb.ResponseStatusNotFound, orderErrorPaymentSystemInactive
+	}
+
+	h, err := s.paymentSystemGateway.GetGateway(ps.Handler)
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to get payment system gateway",
+			zap.Error(err),
+			zap.String("order_id", orderId),
+			zap.Any("payment_system", ps),
+		)
+
+		return nil, nil, nil, billingpb.ResponseStatusSystemError, orderErrorPaymentSystemInactive
+	}
+
+	return order, ps, h, billingpb.ResponseStatusOk, nil
+}
+
+func (s *Service) getSubscriptionState(
+	ctx context.Context,
+	subscription *recurringpb.Subscription,
+) (*intPkg.SubscriptionState, error) {
+	state, err := s.subscriptionStateRepository.GetBySubscriptionId(ctx, subscription.Id)
+
+	if err == nil {
+		return state, nil
+	}
+
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	state = &intPkg.SubscriptionState{
+		SubscriptionId: subscription.Id,
+		MerchantId:     subscription.MerchantId,
+		ProjectId:      subscription.ProjectId,
+		CustomerId:     subscription.CustomerId,
+		Status:         pkg.SubscriptionStatusActive,
+	}
+
+	return state, nil
+}
+
+func applySubscriptionState(sub *billingpb.RecurringSubscription, state *intPkg.SubscriptionState) {
+	if state == nil {
+		return
+	}
+
+	switch state.Status {
+	case pkg.SubscriptionStatusPaused, pkg.SubscriptionStatusCanceled:
+		sub.IsActive = false
+	case pkg.SubscriptionStatusCancelAtPeriodEnd:
+		sub.ExpireAt, _ = ptypes.TimestampProto(state.CancelAt)
+	}
+}
+
+func getSubscriptionChangeSource(customerId string) string {
+	if customerId != "" {
+		return pkg.SubscriptionChangeSourceCustomer
+	}
+
+	return pkg.SubscriptionChangeSourceMerchant
+}
+
+// getSubscriptionPeriodEnd returns the date till which the subscription is paid.
+func getSubscriptionPeriodEnd(subscription *recurringpb.Subscription) (time.Time, error) {
+	paidAt := subscription.LastPaymentAt
+
+	if paidAt == nil {
+		paidAt = subscription.CreatedAt
+	}
+
+	t, err := ptypes.Timestamp(paidAt)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return addRecurringPeriods(t, subscription.Period, 1), nil
+}
+
+func addRecurringPeriods(t time.Time, period string, count int) time.Time {
+	switch period {
+	case recurringpb.RecurringPeriodDay:
+		return t.AddDate(0, 0, count)
+	case recurringpb.RecurringPeriodWeek:
+		return t.AddDate(0, 0, 7*count)
+	case recurringpb.RecurringPeriodMonth:
+		return t.AddDate(0, count, 0)
+	case recurringpb.RecurringPeriodYear:
+		return t.AddDate(count, 0, 0)
+	}
+
+	return t
+}
+
 func (s *Service) mapRecurringToBilling(sub *recurringpb.Subscription) *billingpb.RecurringSubscription {
 	rSub := &billingpb.RecurringSubscription{
 		Id:            sub.Id,
 		CustomerId:    sub.CustomerId,
-		CustomerEmail: sub.CustomerInfo.Email,
 		Period:        sub.Period,
 		MerchantId:    sub.MerchantId,
 		ProjectId:     sub.ProjectId,
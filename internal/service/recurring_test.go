@@ -7,18 +7,22 @@ import (
 	"github.com/paysuper/paysuper-billing-server/internal/config"
 	"github.com/paysuper/paysuper-billing-server/internal/database"
 	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	casbinMocks "github.com/paysuper/paysuper-proto/go/casbinpb/mocks"
 	"github.com/paysuper/paysuper-proto/go/recurringpb"
 	recurringMocks "github.com/paysuper/paysuper-proto/go/recurringpb/mocks"
 	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
+	"github.com/streadway/amqp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
 	"testing"
+	"time"
 )
 
 type RecurringTestSuite struct {
@@ -1202,3 +1206,248 @@ func (suite *RecurringTestSuite) TestOrder_mapRecurringToBilling_Ok() {
 	assert.Equal(suite.T(), subscription.LastPaymentAt, billingSubscription.LastPaymentAt)
 	assert.Equal(suite.T(), subscription.ProjectName, billingSubscription.ProjectName)
 }
+
+func (suite *RecurringTestSuite) mockSubscriptionGateway(subscription *recurringpb.Subscription) *mocks.PaymentSystemInterface {
+	order := &billingpb.Order{
+		PaymentMethod: &billingpb.PaymentMethodOrder{
+			PaymentSystemId: "payment_system_id",
+		},
+	}
+
+	recurring := &recurringMocks.RepositoryService{}
+	recurring.On("GetSubscription", mock.Anything, mock.Anything).Return(&recurringpb.GetSubscriptionResponse{
+		Status:       billingpb.ResponseStatusOk,
+		Subscription: subscription,
+	}, nil)
+	recurring.On("DeleteSubscription", mock.Anything, subscription).Return(&recurringpb.DeleteSubscriptionResponse{
+		Status: billingpb.ResponseStatusOk,
+	}, nil)
+	recurring.On("FindSubscriptions", mock.Anything, mock.Anything).Return(&recurringpb.FindSubscriptionsResponse{
+		List:  []*recurringpb.Subscription{subscription},
+		Count: 1,
+	}, nil)
+	suite.service.rep = recurring
+
+	orderRepository := &mocks.OrderRepositoryInterface{}
+	orderRepository.On("GetById", mock.Anything, subscription.OrderId).Return(order, nil)
+	orderRepository.On("GetOneBy", mock.Anything, bson.M{"recurring_id": subscription.Id}, mock.Anything).
+		Return(order, nil)
+	suite.service.orderRepository = orderRepository
+
+	psRepository := &mocks.PaymentSystemRepositoryInterface{}
+	psRepository.On("GetById", mock.Anything, "payment_system_id").Return(&billingpb.PaymentSystem{
+		Handler: "payment_system_handler",
+	}, nil)
+	suite.service.paymentSystemRepository = psRepository
+
+	paymentSystem := &mocks.PaymentSystemInterface{}
+	paymentSystem.On("PauseRecurringSubscription", order, subscription).Return(nil)
+	paymentSystem.On("ResumeRecurringSubscription", order, subscription).Return(nil)
+	paymentSystem.On("DeleteRecurringSubscription", order, subscription).Return(nil)
+
+	gatewayManagerMock := &mocks.PaymentSystemManagerInterface{}
+	gatewayManagerMock.On("GetGateway", "payment_system_handler").Return(paymentSystem, nil)
+	suite.service.paymentSystemGateway = gatewayManagerMock
+
+	return paymentSystem
+}
+
+func (suite *RecurringTestSuite) getTestSubscription() *recurringpb.Subscription {
+	lastPaymentAt, _ := ptypes.TimestampProto(time.Now().AddDate(0, 0, -10))
+	expireAt, _ := ptypes.TimestampProto(time.Now().AddDate(1, 0, 0))
+
+	return &recurringpb.Subscription{
+		Id:            primitive.NewObjectID().Hex(),
+		OrderId:       "order_id",
+		MerchantId:    "merchant_id",
+		CustomerId:    "customer_id",
+		Period:        recurringpb.RecurringPeriodMonth,
+		IsActive:      true,
+		LastPaymentAt: lastPaymentAt,
+		ExpireAt:      expireAt,
+	}
+}
+
+func (suite *RecurringTestSuite) TestRecurring_PauseRecurringSubscription_Ok() {
+	subscription := suite.getTestSubscription()
+	paymentSystem := suite.mockSubscriptionGateway(subscription)
+
+	broker := &mocks.BrokerInterface{}
+	broker.On("Publish", recurringpb.PayOneTopicNotifyPaymentName, mock.Anything, mock.Anything).Return(nil)
+	suite.service.broker = broker
+
+	rsp := &intPkg.RecurringSubscriptionStateResponse{}
+	err := suite.service.PauseRecurringSubscription(context.Background(), &intPkg.PauseRecurringSubscriptionRequest{
+		Id:         subscription.Id,
+		MerchantId: subscription.MerchantId,
+		Cycles:     2,
+	}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.SubscriptionStatusPaused, rsp.Item.Status)
+	assert.EqualValues(suite.T(), 2, rsp.Item.PauseCycles)
+	paymentSystem.AssertCalled(suite.T(), "PauseRecurringSubscription", mock.Anything, subscription)
+
+	broker.AssertNumberOfCalls(suite.T(), "Publish", 1)
+	headers := broker.Calls[0].Arguments.Get(2).(amqp.Table)
+	assert.Equal(suite.T(), subscription.Id, headers[pkg.SubscriptionNotifyHeaderSubscriptionId])
+	assert.Equal(suite.T(), pkg.SubscriptionStatusPaused, headers[pkg.SubscriptionNotifyHeaderStatus])
+
+	lastPaymentAt, _ := ptypes.Timestamp(subscription.LastPaymentAt)
+	assert.Equal(suite.T(), lastPaymentAt.AddDate(0, 3, 0).Unix(), rsp.Item.ResumeAt.Unix())
+
+	state, err := suite.service.subscriptionStateRepository.GetBySubscriptionId(context.Background(), subscription.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.SubscriptionStatusPaused, state.Status)
+	assert.Len(suite.T(), state.History, 1)
+	assert.Equal(suite.T(), pkg.SubscriptionChangeSourceMerchant, state.History[0].Source)
+
+	rsp1 := &billingpb.FindSubscriptionsResponse{}
+	err = suite.service.FindSubscriptions(context.Background(), &billingpb.FindSubscriptionsRequest{
+		MerchantId: subscription.MerchantId,
+	}, rsp1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp1.Status)
+	assert.Len(suite.T(), rsp1.List, 1)
+	assert.False(suite.T(), rsp1.List[0].IsActive)
+}
+
+func (suite *RecurringTestSuite) TestRecurring_PauseRecurringSubscription_InvalidCycles_Error() {
+	rsp := &intPkg.RecurringSubscriptionStateResponse{}
+	err := suite.service.PauseRecurringSubscription(context.Background(), &intPkg.PauseRecurringSubscriptionRequest{
+		Id:         primitive.NewObjectID().Hex(),
+		MerchantId: "merchant_id",
+		Cycles:     pkg.SubscriptionMaxPauseCycles + 1,
+	}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), recurringErrorPauseCyclesInvalid, rsp.Message)
+}
+
+func (suite *RecurringTestSuite) TestRecurring_PauseRecurringSubscription_OutOfExpireDate_Error() {
+	subscription := suite.getTestSubscription()
+	subscription.ExpireAt, _ = ptypes.TimestampProto(time.Now().AddDate(0, 2, 0))
+	suite.mockSubscriptionGateway(subscription)
+
+	rsp := &intPkg.RecurringSubscriptionStateResponse{}
+	err := suite.service.PauseRecurringSubscription(context.Background(), &intPkg.PauseRecurringSubscriptionRequest{
+		Id:         subscription.Id,
+		MerchantId: subscription.MerchantId,
+		Cycles:     3,
+	}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), recurringErrorPauseOutOfRange, rsp.Message)
+}
+
+func (suite *RecurringTestSuite) TestRecurring_PauseRecurringSubscription_AlreadyPaused_Error() {
+	subscription := suite.getTestSubscription()
+	suite.mockSubscriptionGateway(subscription)
+
+	req := &intPkg.PauseRecurringSubscriptionRequest{
+		Id:         subscription.Id,
+		MerchantId: subscription.MerchantId,
+		Cycles:     1,
+	}
+	rsp := &intPkg.RecurringSubscriptionStateResponse{}
+	err := suite.service.PauseRecurringSubscription(context.Background(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	rsp = &intPkg.RecurringSubscriptionStateResponse{}
+	err = suite.service.PauseRecurringSubscription(context.Background(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), recurringErrorStatusNotAllowed, rsp.Message)
+}
+
+func (suite *RecurringTestSuite) TestRecurring_ResumeRecurringSubscription_Ok() {
+	subscription := suite.getTestSubscription()
+	paymentSystem := suite.mockSubscriptionGateway(subscription)
+
+	rsp := &intPkg.RecurringSubscriptionStateResponse{}
+	err := suite.service.PauseRecurringSubscription(context.Background(), &intPkg.PauseRecurringSubscriptionRequest{
+		Id:         subscription.Id,
+		MerchantId: subscription.MerchantId,
+		Cycles:     1,
+	}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	rsp = &intPkg.RecurringSubscriptionStateResponse{}
+	err = suite.service.ResumeRecurringSubscription(context.Background(), &intPkg.RecurringSubscriptionRequest{
+		Id:         subscription.Id,
+		MerchantId: subscription.MerchantId,
+	}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.SubscriptionStatusActive, rsp.Item.Status)
+	assert.True(suite.T(), rsp.Item.ResumeAt.IsZero())
+	assert.Len(suite.T(), rsp.Item.History, 2)
+	paymentSystem.AssertCalled(suite.T(), "ResumeRecurringSubscription", mock.Anything, subscription)
+}
+
+func (suite *RecurringTestSuite) TestRecurring_ResumeRecurringSubscription_NotPaused_Error() {
+	subscription := suite.getTestSubscription()
+	suite.mockSubscriptionGateway(subscription)
+
+	rsp := &intPkg.RecurringSubscriptionStateResponse{}
+	err := suite.service.ResumeRecurringSubscription(context.Background(), &intPkg.RecurringSubscriptionRequest{
+		Id:         subscription.Id,
+		MerchantId: subscription.MerchantId,
+	}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), recurringErrorStatusNotAllowed, rsp.Message)
+}
+
+func (suite *RecurringTestSuite) TestRecurring_CancelRecurringSubscriptionAtPeriodEnd_Ok() {
+	subscription := suite.getTestSubscription()
+	paymentSystem := suite.mockSubscriptionGateway(subscription)
+
+	rsp := &intPkg.RecurringSubscriptionStateResponse{}
+	err := suite.service.CancelRecurringSubscriptionAtPeriodEnd(context.Background(), &intPkg.RecurringSubscriptionRequest{
+		Id:         subscription.Id,
+		MerchantId: subscription.MerchantId,
+	}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.SubscriptionStatusCancelAtPeriodEnd, rsp.Item.Status)
+	paymentSystem.AssertCalled(suite.T(), "PauseRecurringSubscription", mock.Anything, subscription)
+	paymentSystem.AssertNotCalled(suite.T(), "DeleteRecurringSubscription", mock.Anything, mock.Anything)
+
+	rsp1 := &billingpb.GetSubscriptionResponse{}
+	err = suite.service.GetSubscription(context.Background(), &billingpb.GetSubscriptionRequest{
+		Id:         subscription.Id,
+		MerchantId: subscription.MerchantId,
+	}, rsp1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp1.Status)
+	assert.True(suite.T(), rsp1.Subscription.IsActive)
+
+	expireAt, _ := ptypes.Timestamp(rsp1.Subscription.ExpireAt)
+	assert.Equal(suite.T(), rsp.Item.CancelAt.Unix(), expireAt.Unix())
+}
+
+func (suite *RecurringTestSuite) TestRecurring_ProcessSubscriptionsLifecycle_Ok() {
+	subscription := suite.getTestSubscription()
+	subscription.LastPaymentAt, _ = ptypes.TimestampProto(time.Now().AddDate(0, -2, 0))
+	paymentSystem := suite.mockSubscriptionGateway(subscription)
+
+	rsp := &intPkg.RecurringSubscriptionStateResponse{}
+	err := suite.service.CancelRecurringSubscriptionAtPeriodEnd(context.Background(), &intPkg.RecurringSubscriptionRequest{
+		Id:         subscription.Id,
+		MerchantId: subscription.MerchantId,
+	}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	err = suite.service.ProcessSubscriptionsLifecycle(context.Background())
+	assert.NoError(suite.T(), err)
+	paymentSystem.AssertCalled(suite.T(), "DeleteRecurringSubscription", mock.Anything, subscription)
+
+	state, err := suite.service.subscriptionStateRepository.GetBySubscriptionId(context.Background(), subscription.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.SubscriptionStatusCanceled, state.Status)
+	assert.Equal(suite.T(), pkg.SubscriptionChangeSourceSystem, state.History[len(state.History)-1].Source)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/golang/protobuf/ptypes"
 	"github.com/google/uuid"
 	"github.com/jinzhu/copier"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg/errors"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
@@ -15,6 +16,8 @@ import (
 	tools "github.com/paysuper/paysuper-tools/number"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -30,6 +33,26 @@ var (
 	refundErrorNotFound           = errors.NewBillingServerErrorMsg("rf000005", "refund with specified data not found")
 	refundErrorOrderNotFound      = errors.NewBillingServerErrorMsg("rf000006", "information about payment for refund with specified data not found")
 	refundErrorCostsRatesNotFound = errors.NewBillingServerErrorMsg("rf000007", "settings to calculate commissions for refund not found")
+	refundErrorReasonRequired     = errors.NewBillingServerErrorMsg("rf000008", "refund reason code is required")
+	refundErrorReasonUnknown      = errors.NewBillingServerErrorMsg("rf000009", "refund reason code is unknown")
+	refundErrorAnalyticsGroupBy   = errors.NewBillingServerErrorMsg("rf000010", "refund analytics grouping is invalid")
+	refundErrorAnalyticsInterval  = errors.NewBillingServerErrorMsg("rf000011", "refund analytics interval is invalid")
+	refundErrorAnalyticsDates     = errors.NewBillingServerErrorMsg("rf000012", "refund analytics dates are invalid")
+)
+
+var (
+	refundAnalyticsGroups = map[string]bool{
+		pkg.RefundAnalyticsGroupByProject:       true,
+		pkg.RefundAnalyticsGroupByProduct:       true,
+		pkg.RefundAnalyticsGroupByPaymentMethod: true,
+		pkg.RefundAnalyticsGroupByCountry:       true,
+		pkg.RefundAnalyticsGroupByReason:        true,
+	}
+	refundAnalyticsIntervals = map[string]bool{
+		pkg.RefundAnalyticsIntervalDay:   true,
+		pkg.RefundAnalyticsIntervalWeek:  true,
+		pkg.RefundAnalyticsIntervalMonth: true,
+	}
 )
 
 type createRefundChecked struct {
@@ -37,22 +60,65 @@ type createRefundChecked struct {
 }
 
 type createRefundProcessor struct {
-	service *Service
-	request *billingpb.CreateRefundRequest
-	checked *createRefundChecked
-	ctx     context.Context
+	service    *Service
+	request    *billingpb.CreateRefundRequest
+	reasonCode string
+	checked    *createRefundChecked
+	ctx        context.Context
 }
 
 func (s *Service) CreateRefund(
 	ctx context.Context,
 	req *billingpb.CreateRefundRequest,
 	rsp *billingpb.CreateRefundResponse,
+) error {
+	return s.createRefund(ctx, req, "", rsp)
+}
+
+// CreateRefundWithReason creates refund with the reason code of the catalog and the merchant's comment
+// which is saved as the reason of the refund.
+func (s *Service) CreateRefundWithReason(
+	ctx context.Context,
+	req *intPkg.CreateRefundWithReasonRequest,
+	rsp *billingpb.CreateRefundResponse,
+) error {
+	reasonCode := strings.ToLower(strings.TrimSpace(req.ReasonCode))
+
+	if reasonCode == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = refundErrorReasonRequired
+		return nil
+	}
+
+	if _, ok := pkg.RefundReasons[reasonCode]; !ok {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = refundErrorReasonUnknown
+		return nil
+	}
+
+	refundReq := &billingpb.CreateRefundRequest{
+		OrderId:    req.OrderId,
+		Amount:     req.Amount,
+		CreatorId:  req.CreatorId,
+		MerchantId: req.MerchantId,
+		Reason:     strings.TrimSpace(req.Comment),
+	}
+
+	return s.createRefund(ctx, refundReq, reasonCode, rsp)
+}
+
+func (s *Service) createRefund(
+	ctx context.Context,
+	req *billingpb.CreateRefundRequest,
+	reasonCode string,
+	rsp *billingpb.CreateRefundResponse,
 ) error {
 	processor := &createRefundProcessor{
-		service: s,
-		request: req,
-		checked: &createRefundChecked{},
-		ctx:     ctx,
+		service:    s,
+		request:    req,
+		reasonCode: reasonCode,
+		checked:    &createRefundChecked{},
+		ctx:        ctx,
 	}
 
 	refund, err := processor.processCreateRefund()
@@ -98,6 +164,92 @@ func (s *Service) CreateRefund(
 	return nil
 }
 
+// GetRefundReasons returns the catalog of refund reason codes which can be used to create refund.
+func (s *Service) GetRefundReasons(
+	_ context.Context,
+	_ *billingpb.EmptyRequest,
+	rsp *intPkg.RefundReasonsResponse,
+) error {
+	rsp.Items = make([]*intPkg.RefundReason, 0, len(pkg.RefundReasons))
+
+	for code, label := range pkg.RefundReasons {
+		rsp.Items = append(rsp.Items, &intPkg.RefundReason{Code: code, Label: label})
+	}
+
+	sort.Slice(rsp.Items, func(i, j int) bool {
+		return rsp.Items[i].Code < rsp.Items[j].Code
+	})
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// GetRefundAnalytics returns refund rate and amounts of the merchant's payments grouped by project, product,
+// payment method, country or refund reason for every interval of the requested dates.
+func (s *Service) GetRefundAnalytics(
+	ctx context.Context,
+	req *intPkg.RefundAnalyticsRequest,
+	rsp *intPkg.RefundAnalyticsResponse,
+) error {
+	if !refundAnalyticsGroups[req.GroupBy] {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = refundErrorAnalyticsGroupBy
+		return nil
+	}
+
+	if !refundAnalyticsIntervals[req.Interval] {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = refundErrorAnalyticsInterval
+		return nil
+	}
+
+	if req.DateFrom <= 0 || req.DateTo < req.DateFrom {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = refundErrorAnalyticsDates
+		return nil
+	}
+
+	_, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	if req.ProjectId != "" {
+		project, err := s.project.GetById(ctx, req.ProjectId)
+
+		if err != nil || project.MerchantId != req.MerchantId {
+			rsp.Status = billingpb.ResponseStatusNotFound
+			rsp.Message = projectErrorNotFound
+			return nil
+		}
+	}
+
+	items, err := s.orderViewRepository.GetRefundAnalytics(
+		ctx,
+		req.MerchantId,
+		req.ProjectId,
+		req.GroupBy,
+		req.Interval,
+		time.Unix(req.DateFrom, 0),
+		time.Unix(req.DateTo, 0),
+	)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = refundErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Items = items
+
+	return nil
+}
+
 func (s *Service) ListRefunds(
 	ctx context.Context,
 	req *billingpb.ListRefundsRequest,
@@ -324,6 +476,7 @@ func (s *Service) ProcessRefundCallback(
 			Amount:        refundedAmount,
 			Currency:      order.ChargeCurrency,
 			Reason:        refund.Reason,
+			Code:          s.getRefundReasonCode(ctx, refund),
 			ReceiptNumber: refund.Id,
 		}
 
@@ -454,6 +607,7 @@ func (s *Service) createOrderByRefund(ctx context.Context, order *billingpb.Orde
 		Amount:        refund.Amount,
 		Currency:      refund.Currency,
 		Reason:        refund.Reason,
+		Code:          s.getRefundReasonCode(ctx, refund),
 		ReceiptNumber: refund.Id,
 	}
 	refundOrder.ParentOrder = &billingpb.ParentOrder{
@@ -495,6 +649,8 @@ func (s *Service) createOrderByRefund(ctx context.Context, order *billingpb.Orde
 }
 
 func (p *createRefundProcessor) processCreateRefund() (*billingpb.Refund, error) {
+	p.processReason()
+
 	err := p.processOrder()
 
 	if err != nil {
@@ -547,13 +703,21 @@ func (p *createRefundProcessor) processCreateRefund() (*billingpb.Refund, error)
 		refund.Reason = p.request.Reason
 	}
 
-	if err = p.service.refundRepository.Insert(p.ctx, refund); err != nil {
+	if err = p.service.refundRepository.InsertWithReasonCode(p.ctx, refund, p.reasonCode); err != nil {
 		return nil, errors.NewBillingServerResponseError(billingpb.ResponseStatusBadData, orderErrorUnknown)
 	}
 
 	return refund, nil
 }
 
+// processReason sets the chargeback reason code to chargebacks created without the code. Refunds created
+// without the code stay unclassified, their reason is a free text only.
+func (p *createRefundProcessor) processReason() {
+	if p.reasonCode == "" && p.request.IsChargeback {
+		p.reasonCode = pkg.RefundReasonChargeback
+	}
+}
+
 func (p *createRefundProcessor) processOrder() error {
 	order, err := p.service.orderRepository.GetByUuidAndMerchantId(p.ctx, p.request.OrderId, p.request.MerchantId)
 
@@ -640,3 +804,19 @@ func (p *createRefundProcessor) hasMoneyBackCosts(ctx context.Context, order *bi
 	_, err = p.service.getMoneyBackCostMerchant(ctx, data1)
 	return err == nil
 }
+
+// getRefundReasonCode returns the reason code of the refund.
+// Refunds created before the reason codes catalog have no code, except of chargebacks.
+func (s *Service) getRefundReasonCode(ctx context.Context, refund *billingpb.Refund) string {
+	code, err := s.refundRepository.GetReasonCode(ctx, refund.Id)
+
+	if err == nil && code != "" {
+		return code
+	}
+
+	if refund.IsChargeback {
+		return pkg.RefundReasonChargeback
+	}
+
+	return ""
+}
@@ -942,6 +942,8 @@ func (suite *RefundTestSuite) TestRefund_CreateRefund_Ok() {
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), refund)
 	assert.Equal(suite.T(), pkg.RefundStatusInProgress, refund.Status)
+	assert.Equal(suite.T(), req2.Reason, refund.Reason)
+	assert.Empty(suite.T(), suite.service.getRefundReasonCode(context.TODO(), refund))
 }
 
 func (suite *RefundTestSuite) TestRefund_CreateRefund_PaymentSystemNotExists_Error() {
@@ -2358,15 +2360,16 @@ func (suite *RefundTestSuite) TestRefund_ProcessRefundCallback_OrderFullyRefunde
 	err = suite.service.accountingRepository.MultipleInsert(context.TODO(), accountingEntries)
 	assert.NoError(suite.T(), err)
 
-	req2 := &billingpb.CreateRefundRequest{
+	req2 := &intPkg.CreateRefundWithReasonRequest{
 		OrderId:    rsp.Uuid,
 		Amount:     order.TotalPaymentAmount,
 		CreatorId:  primitive.NewObjectID().Hex(),
-		Reason:     "unit test",
 		MerchantId: suite.project.MerchantId,
+		ReasonCode: pkg.RefundReasonCustomerRequest,
+		Comment:    "unit test",
 	}
 	rsp2 := &billingpb.CreateRefundResponse{}
-	err = suite.service.CreateRefund(context.TODO(), req2, rsp2)
+	err = suite.service.CreateRefundWithReason(context.TODO(), req2, rsp2)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp2.Status)
 	assert.Empty(suite.T(), rsp2.Message)
@@ -2422,7 +2425,8 @@ func (suite *RefundTestSuite) TestRefund_ProcessRefundCallback_OrderFullyRefunde
 	assert.EqualValues(suite.T(), recurringpb.OrderStatusRefund, order.PrivateStatus)
 	assert.NotNil(suite.T(), order.Refund)
 	assert.Equal(suite.T(), req2.Amount, order.Refund.Amount)
-	assert.Equal(suite.T(), req2.Reason, order.Refund.Reason)
+	assert.Equal(suite.T(), req2.Comment, order.Refund.Reason)
+	assert.Equal(suite.T(), pkg.RefundReasonCustomerRequest, order.Refund.Code)
 	assert.Equal(suite.T(), rsp2.Item.Id, order.Refund.ReceiptNumber)
 }
 
@@ -2902,3 +2906,120 @@ func (suite *RefundTestSuite) TestRefund_ProcessRefundCallback_Declined() {
 	assert.Equal(suite.T(), originalOrderViewPublic.OrderCharge.Currency, originalOrderViewPublicFromJson.OrderCharge.Currency)
 	assert.Equal(suite.T(), originalOrderViewPublic.OrderCharge.AmountRounded, originalOrderViewPublicFromJson.OrderCharge.AmountRounded)
 }
+
+func (suite *RefundTestSuite) TestRefund_CreateRefundWithReason_ReasonRequired_Error() {
+	req := &intPkg.CreateRefundWithReasonRequest{
+		OrderId:    uuid.New().String(),
+		Amount:     10,
+		CreatorId:  primitive.NewObjectID().Hex(),
+		MerchantId: suite.project.MerchantId,
+		Comment:    "unit test",
+	}
+	rsp := &billingpb.CreateRefundResponse{}
+	err := suite.service.CreateRefundWithReason(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), refundErrorReasonRequired, rsp.Message)
+}
+
+func (suite *RefundTestSuite) TestRefund_CreateRefundWithReason_ReasonUnknown_Error() {
+	req := &intPkg.CreateRefundWithReasonRequest{
+		OrderId:    uuid.New().String(),
+		Amount:     10,
+		CreatorId:  primitive.NewObjectID().Hex(),
+		MerchantId: suite.project.MerchantId,
+		ReasonCode: "unit test",
+	}
+	rsp := &billingpb.CreateRefundResponse{}
+	err := suite.service.CreateRefundWithReason(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), refundErrorReasonUnknown, rsp.Message)
+}
+
+func (suite *RefundTestSuite) TestRefund_GetRefundReasonCode() {
+	refund := &billingpb.Refund{
+		Id:            primitive.NewObjectID().Hex(),
+		OriginalOrder: &billingpb.RefundOrder{Id: primitive.NewObjectID().Hex(), Uuid: uuid.New().String()},
+		CreatorId:     primitive.NewObjectID().Hex(),
+		Reason:        pkg.RefundReasonKeyInvalid,
+		Status:        pkg.RefundStatusCreated,
+	}
+	err := suite.service.refundRepository.InsertWithReasonCode(context.TODO(), refund, pkg.RefundReasonDuplicate)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.RefundReasonDuplicate, suite.service.getRefundReasonCode(context.TODO(), refund))
+
+	refund.Status = pkg.RefundStatusCompleted
+	err = suite.service.refundRepository.Update(context.TODO(), refund)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.RefundReasonDuplicate, suite.service.getRefundReasonCode(context.TODO(), refund))
+
+	refund.Id = primitive.NewObjectID().Hex()
+	refund.IsChargeback = true
+	err = suite.service.refundRepository.Insert(context.TODO(), refund)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.RefundReasonChargeback, suite.service.getRefundReasonCode(context.TODO(), refund))
+
+	refund.Id = primitive.NewObjectID().Hex()
+	refund.IsChargeback = false
+	err = suite.service.refundRepository.Insert(context.TODO(), refund)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), suite.service.getRefundReasonCode(context.TODO(), refund))
+}
+
+func (suite *RefundTestSuite) TestRefund_GetRefundReasons_Ok() {
+	rsp := &intPkg.RefundReasonsResponse{}
+	err := suite.service.GetRefundReasons(context.TODO(), &billingpb.EmptyRequest{}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, len(pkg.RefundReasons))
+
+	for _, item := range rsp.Items {
+		assert.Equal(suite.T(), pkg.RefundReasons[item.Code], item.Label)
+	}
+}
+
+func (suite *RefundTestSuite) TestRefund_GetRefundAnalytics_InvalidRequest_Error() {
+	req := &intPkg.RefundAnalyticsRequest{
+		MerchantId: suite.project.MerchantId,
+		GroupBy:    "unknown",
+		Interval:   pkg.RefundAnalyticsIntervalDay,
+		DateFrom:   time.Now().AddDate(0, -1, 0).Unix(),
+		DateTo:     time.Now().Unix(),
+	}
+	rsp := &intPkg.RefundAnalyticsResponse{}
+	err := suite.service.GetRefundAnalytics(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), refundErrorAnalyticsGroupBy, rsp.Message)
+
+	req.GroupBy = pkg.RefundAnalyticsGroupByCountry
+	req.Interval = "year"
+	rsp = &intPkg.RefundAnalyticsResponse{}
+	err = suite.service.GetRefundAnalytics(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), refundErrorAnalyticsInterval, rsp.Message)
+
+	req.Interval = pkg.RefundAnalyticsIntervalMonth
+	req.DateFrom, req.DateTo = req.DateTo, req.DateFrom
+	rsp = &intPkg.RefundAnalyticsResponse{}
+	err = suite.service.GetRefundAnalytics(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), refundErrorAnalyticsDates, rsp.Message)
+}
+
+func (suite *RefundTestSuite) TestRefund_GetRefundAnalytics_Ok() {
+	req := &intPkg.RefundAnalyticsRequest{
+		MerchantId: suite.project.MerchantId,
+		ProjectId:  suite.project.Id,
+		GroupBy:    pkg.RefundAnalyticsGroupByReason,
+		Interval:   pkg.RefundAnalyticsIntervalMonth,
+		DateFrom:   time.Now().AddDate(0, -1, 0).Unix(),
+		DateTo:     time.Now().Unix(),
+	}
+	rsp := &intPkg.RefundAnalyticsResponse{}
+	err := suite.service.GetRefundAnalytics(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Empty(suite.T(), rsp.Items)
+}
@@ -0,0 +1,434 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"math"
+	"time"
+)
+
+const (
+	rollingReserveHoldReason    = "rolling reserve hold of order %s"
+	rollingReserveReleaseReason = "rolling reserve release of order %s"
+)
+
+var (
+	rollingReservePolicyErrorTypeInvalid     = errors.NewBillingServerErrorMsg("rv000001", "rolling reserve policy type is invalid")
+	rollingReservePolicyErrorPercentInvalid  = errors.NewBillingServerErrorMsg("rv000002", "rolling reserve percent must be greater than 0 and less or equal 100")
+	rollingReservePolicyErrorHoldDaysInvalid = errors.NewBillingServerErrorMsg("rv000003", "rolling reserve hold days are invalid")
+	rollingReservePolicyErrorAmountInvalid   = errors.NewBillingServerErrorMsg("rv000004", "rolling reserve amount is invalid")
+	rollingReservePolicyErrorUnknown         = errors.NewBillingServerErrorMsg("rv000005", "unknown error. try request later")
+)
+
+func (s *Service) GetRollingReservePolicy(
+	ctx context.Context,
+	req *intPkg.GetRollingReservePolicyRequest,
+	rsp *intPkg.RollingReservePolicyResponse,
+) error {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	policy, err := s.rollingReservePolicyRepository.GetByMerchantId(ctx, merchant.Id)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = rollingReservePolicyErrorUnknown
+			return nil
+		}
+
+		policy = &intPkg.RollingReservePolicy{
+			MerchantId: merchant.Id,
+			Currency:   merchant.GetPayoutCurrency(),
+		}
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = policy
+
+	return nil
+}
+
+func (s *Service) SetRollingReservePolicy(
+	ctx context.Context,
+	req *intPkg.RollingReservePolicy,
+	rsp *intPkg.RollingReservePolicyResponse,
+) error {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	if merchant.GetPayoutCurrency() == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = errorMerchantPayoutCurrencyNotSet
+		return nil
+	}
+
+	if req.HoldDays < 0 {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = rollingReservePolicyErrorHoldDaysInvalid
+		return nil
+	}
+
+	switch req.Type {
+	case pkg.RollingReservePolicyTypePercentage:
+		if req.Percent <= 0 || req.Percent > 100 {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = rollingReservePolicyErrorPercentInvalid
+			return nil
+		}
+
+		if req.HoldDays == 0 {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = rollingReservePolicyErrorHoldDaysInvalid
+			return nil
+		}
+
+		if req.Cap < 0 {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = rollingReservePolicyErrorAmountInvalid
+			return nil
+		}
+		break
+
+	case pkg.RollingReservePolicyTypeFixedDeposit:
+		if req.Amount <= 0 {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = rollingReservePolicyErrorAmountInvalid
+			return nil
+		}
+		break
+
+	default:
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = rollingReservePolicyErrorTypeInvalid
+		return nil
+	}
+
+	policy, err := s.rollingReservePolicyRepository.GetByMerchantId(ctx, merchant.Id)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = rollingReservePolicyErrorUnknown
+			return nil
+		}
+
+		policy = &intPkg.RollingReservePolicy{MerchantId: merchant.Id}
+	}
+
+	policy.Type = req.Type
+	policy.Percent = req.Percent
+	policy.HoldDays = req.HoldDays
+	policy.Cap = req.Cap
+	policy.Amount = req.Amount
+	policy.Currency = merchant.GetPayoutCurrency()
+	policy.Enabled = req.Enabled
+
+	if err = s.rollingReservePolicyRepository.Upsert(ctx, policy); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = rollingReservePolicyErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = policy
+
+	return nil
+}
+
+// GetRollingReserveSchedule returns upcoming automatic releases of the merchant's rolling reserve.
+func (s *Service) GetRollingReserveSchedule(
+	ctx context.Context,
+	req *intPkg.RollingReserveScheduleRequest,
+	rsp *intPkg.RollingReserveScheduleResponse,
+) error {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	schedule := &intPkg.RollingReserveSchedule{
+		MerchantId: merchant.Id,
+		Currency:   merchant.GetPayoutCurrency(),
+	}
+
+	schedule.TotalHeld, err = s.rollingReserveHoldRepository.GetHeldAmount(ctx, merchant.Id, schedule.Currency)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = rollingReservePolicyErrorUnknown
+		return nil
+	}
+
+	schedule.Items, err = s.rollingReserveHoldRepository.GetSchedule(ctx, merchant.Id, schedule.Currency)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = rollingReservePolicyErrorUnknown
+		return nil
+	}
+
+	scheduled := float64(0)
+
+	for _, item := range schedule.Items {
+		item.Amount = tools.FormatAmount(item.Amount)
+		scheduled += item.Amount
+	}
+
+	schedule.TotalHeld = tools.FormatAmount(schedule.TotalHeld)
+	schedule.UndatedAmount = tools.FormatAmount(schedule.TotalHeld - scheduled)
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = schedule
+
+	return nil
+}
+
+// ReleaseRollingReserves releases rolling reserve holds which release date has come.
+func (s *Service) ReleaseRollingReserves(ctx context.Context) error {
+	holds, err := s.rollingReserveHoldRepository.FindDue(ctx, time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	merchants := make(map[string]*billingpb.Merchant)
+
+	for _, hold := range holds {
+		merchant, ok := merchants[hold.MerchantId]
+
+		if !ok {
+			merchant, err = s.merchantRepository.GetById(ctx, hold.MerchantId)
+
+			if err != nil {
+				zap.L().Error(
+					"Merchant of rolling reserve hold not found",
+					zap.Error(err),
+					zap.String("hold_id", hold.Id.Hex()),
+				)
+				continue
+			}
+
+			merchants[hold.MerchantId] = merchant
+		}
+
+		// The release entry and the released hold are saved together, the hold which release failed
+		// is released by the next run
+		err = database.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+			entry, err := s.createRollingReserveEntry(
+				ctx,
+				merchant,
+				pkg.AccountingEntryTypeMerchantRollingReserveRelease,
+				hold.Amount,
+				hold.Currency,
+				fmt.Sprintf(rollingReserveReleaseReason, hold.OrderId),
+			)
+
+			if err != nil {
+				return err
+			}
+
+			hold.Status = pkg.RollingReserveHoldStatusReleased
+			hold.ReleaseEntryId = entry.Id
+			hold.ReleasedAt = time.Now()
+
+			return s.rollingReserveHoldRepository.Update(ctx, hold)
+		})
+
+		if err != nil {
+			zap.L().Error(
+				"Rolling reserve hold release failed",
+				zap.Error(err),
+				zap.String("hold_id", hold.Id.Hex()),
+				zap.String("merchant_id", hold.MerchantId),
+			)
+		}
+	}
+
+	for _, merchant := range merchants {
+		if _, err = s.updateMerchantBalance(ctx, merchant.Id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// processRollingReserveHold adds the entry which holds the part of the payment in the rolling reserve
+// by the merchant's policy to the entries of the payment, so the hold is saved and posted with them.
+// The payment must be processed in the transaction which locks holds of the merchant till the hold is saved.
+// The merchant's balance isn't updated by the hold, the hold is included in the next balance update.
+func (h *accountingEntry) processRollingReserveHold() error {
+	hold, err := h.rollingReserveHoldRepository.GetByOrderId(h.ctx, h.order.Id)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	// The hold of the payment processed again is posted again with its amount
+	if hold != nil {
+		return h.addRollingReserveHoldEntry(hold)
+	}
+
+	policy, err := h.rollingReservePolicyRepository.GetByMerchantId(h.ctx, h.merchant.Id)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+
+	if !policy.Enabled {
+		return nil
+	}
+
+	// Holds of the merchant are locked until the payment is posted, so concurrent payments can't exceed the cap
+	if err = h.rollingReserveHoldRepository.Lock(h.ctx, h.merchant.Id, policy.Currency); err != nil {
+		return err
+	}
+
+	// Merchant's gross revenue isn't stored, it's calculated by the same formula as in the order view
+	revenue, currency := float64(0), ""
+
+	for _, entry := range h.accountingEntries {
+		switch entry.Type {
+		case pkg.AccountingEntryTypeRealGrossRevenue:
+			revenue += entry.Amount
+			currency = entry.Currency
+			break
+
+		case pkg.AccountingEntryTypePsGrossRevenueFx:
+			revenue -= entry.Amount
+			break
+		}
+	}
+
+	if currency != policy.Currency {
+		return nil
+	}
+
+	held, err := h.rollingReserveHoldRepository.GetHeldAmount(h.ctx, h.merchant.Id, policy.Currency)
+
+	if err != nil {
+		return err
+	}
+
+	amount := float64(0)
+
+	switch policy.Type {
+	case pkg.RollingReservePolicyTypePercentage:
+		amount = revenue * policy.Percent / 100
+
+		if policy.Cap > 0 {
+			amount = math.Min(amount, policy.Cap-held)
+		}
+		break
+
+	case pkg.RollingReservePolicyTypeFixedDeposit:
+		amount = math.Min(revenue, policy.Amount-held)
+		break
+	}
+
+	amount = tools.FormatAmount(amount)
+
+	if amount <= 0 {
+		return nil
+	}
+
+	hold = &intPkg.RollingReserveHold{
+		MerchantId: h.merchant.Id,
+		OrderId:    h.order.Id,
+		Currency:   policy.Currency,
+		Amount:     amount,
+		Status:     pkg.RollingReserveHoldStatusHeld,
+	}
+
+	if policy.HoldDays > 0 {
+		hold.ReleaseAt = time.Now().AddDate(0, 0, int(policy.HoldDays))
+	}
+
+	return h.addRollingReserveHoldEntry(hold)
+}
+
+// addRollingReserveHoldEntry adds the entry of the hold to the entries of the payment, the hold is saved
+// with the entries.
+func (h *accountingEntry) addRollingReserveHoldEntry(hold *intPkg.RollingReserveHold) error {
+	entry := h.newEntry(pkg.AccountingEntryTypeMerchantRollingReserveCreate)
+	entry.Amount = hold.Amount
+	entry.Currency = hold.Currency
+	entry.Reason = fmt.Sprintf(rollingReserveHoldReason, h.order.Id)
+
+	if err := h.addEntry(entry); err != nil {
+		return err
+	}
+
+	hold.HoldEntryId = entry.Id
+	h.rollingReserveHold = hold
+
+	return nil
+}
+
+func (s *Service) createRollingReserveEntry(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+	entryType string,
+	amount float64,
+	currency string,
+	reason string,
+) (*billingpb.AccountingEntry, error) {
+	handler := &accountingEntry{
+		Service:  s,
+		ctx:      ctx,
+		merchant: merchant,
+		req: &billingpb.CreateAccountingEntryRequest{
+			Type:       entryType,
+			MerchantId: merchant.Id,
+			Amount:     amount,
+			Currency:   currency,
+			Status:     pkg.BalanceTransactionStatusAvailable,
+			Date:       time.Now().Unix(),
+			Reason:     reason,
+		},
+	}
+
+	// country of the entry is informational only for rolling reserve entries
+	country, err := s.country.GetByIsoCodeA2(ctx, merchant.GetCompany().GetCountry())
+
+	if err == nil {
+		handler.country = country
+	}
+
+	if err = s.processEvent(handler, accountingEventTypeManualCorrection); err != nil {
+		zap.L().Error(
+			"Rolling reserve accounting entry creation failed",
+			zap.Error(err),
+			zap.String("merchant_id", merchant.Id),
+			zap.String("type", entryType),
+		)
+		return nil, err
+	}
+
+	return handler.accountingEntries[0], nil
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	casbinMocks "github.com/paysuper/paysuper-proto/go/casbinpb/mocks"
+	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"testing"
+	"time"
+)
+
+type RollingReserveTestSuite struct {
+	suite.Suite
+	service  *Service
+	merchant *billingpb.Merchant
+}
+
+func Test_RollingReserve(t *testing.T) {
+	suite.Run(t, new(RollingReserveTestSuite))
+}
+
+func (suite *RollingReserveTestSuite) SetupTest() {
+	cfg, err := config.NewConfig()
+	assert.NoError(suite.T(), err, "Config load failed")
+
+	db, err := mongodb.NewDatabase()
+	assert.NoError(suite.T(), err, "Database connection failed")
+
+	redisdb := mocks.NewTestRedis()
+	cache, err := database.NewCacheRedis(redisdb, "cache")
+
+	if err != nil {
+		suite.FailNow("Cache redis initialize failed", "%v", err)
+	}
+
+	suite.service = NewBillingService(
+		db,
+		cfg,
+		mocks.NewGeoIpServiceTestOk(),
+		mocks.NewRepositoryServiceOk(),
+		&mocks.TaxServiceOkMock{},
+		mocks.NewBrokerMockOk(),
+		nil,
+		cache,
+		mocks.NewCurrencyServiceMockOk(),
+		mocks.NewDocumentSignerMockOk(),
+		&reportingMocks.ReporterService{},
+		mocks.NewFormatterOK(),
+		mocks.NewBrokerMockOk(),
+		&casbinMocks.CasbinService{},
+		mocks.NewNotifierOk(),
+		mocks.NewBrokerMockOk(),
+	)
+
+	if err := suite.service.Init(); err != nil {
+		suite.FailNow("Billing service initialization failed", "%v", err)
+	}
+
+	operatingCompany := HelperOperatingCompany(suite.Suite, suite.service)
+	suite.merchant = HelperCreateMerchant(suite.Suite, suite.service, "USD", "RU", nil, 0, operatingCompany.Id)
+}
+
+func (suite *RollingReserveTestSuite) TearDownTest() {
+	err := suite.service.db.Drop()
+
+	if err != nil {
+		suite.FailNow("Database deletion failed", "%v", err)
+	}
+
+	err = suite.service.db.Close()
+
+	if err != nil {
+		suite.FailNow("Database close failed", "%v", err)
+	}
+}
+
+func (suite *RollingReserveTestSuite) setPolicy(policy *intPkg.RollingReservePolicy) {
+	policy.MerchantId = suite.merchant.Id
+	policy.Enabled = true
+	rsp := &intPkg.RollingReservePolicyResponse{}
+	err := suite.service.SetRollingReservePolicy(context.TODO(), policy, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+}
+
+func (suite *RollingReserveTestSuite) holdPayment(amount float64) *billingpb.Order {
+	order := &billingpb.Order{Id: primitive.NewObjectID().Hex()}
+	handler := suite.processHold(order, amount)
+
+	if handler.rollingReserveHold != nil {
+		err := suite.service.rollingReserveHoldRepository.Insert(context.TODO(), handler.rollingReserveHold)
+		assert.NoError(suite.T(), err)
+	}
+
+	return order
+}
+
+func (suite *RollingReserveTestSuite) processHold(order *billingpb.Order, amount float64) *accountingEntry {
+	entries := []*billingpb.AccountingEntry{
+		{Type: pkg.AccountingEntryTypeRealGrossRevenue, Amount: amount, Currency: "USD"},
+	}
+
+	handler := &accountingEntry{
+		Service:           suite.service,
+		ctx:               context.TODO(),
+		order:             order,
+		merchant:          suite.merchant,
+		accountingEntries: entries,
+	}
+	err := handler.processRollingReserveHold()
+	assert.NoError(suite.T(), err)
+
+	if handler.rollingReserveHold != nil {
+		entry := handler.accountingEntries[len(handler.accountingEntries)-1]
+		assert.Equal(suite.T(), pkg.AccountingEntryTypeMerchantRollingReserveCreate, entry.Type)
+		assert.Equal(suite.T(), entry.Id, handler.rollingReserveHold.HoldEntryId)
+		assert.Equal(suite.T(), handler.rollingReserveHold.Amount, entry.Amount)
+	}
+
+	return handler
+}
+
+func (suite *RollingReserveTestSuite) getSchedule() *intPkg.RollingReserveSchedule {
+	req := &intPkg.RollingReserveScheduleRequest{MerchantId: suite.merchant.Id}
+	rsp := &intPkg.RollingReserveScheduleResponse{}
+	err := suite.service.GetRollingReserveSchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	return rsp.Item
+}
+
+func (suite *RollingReserveTestSuite) TestRollingReserve_GetRollingReservePolicy_Default_Ok() {
+	req := &intPkg.GetRollingReservePolicyRequest{MerchantId: suite.merchant.Id}
+	rsp := &intPkg.RollingReservePolicyResponse{}
+	err := suite.service.GetRollingReservePolicy(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.False(suite.T(), rsp.Item.Enabled)
+	assert.Equal(suite.T(), "USD", rsp.Item.Currency)
+}
+
+func (suite *RollingReserveTestSuite) TestRollingReserve_SetRollingReservePolicy_Ok() {
+	suite.setPolicy(&intPkg.RollingReservePolicy{
+		Type:     pkg.RollingReservePolicyTypePercentage,
+		Percent:  10,
+		HoldDays: 90,
+	})
+
+	req := &intPkg.GetRollingReservePolicyRequest{MerchantId: suite.merchant.Id}
+	rsp := &intPkg.RollingReservePolicyResponse{}
+	err := suite.service.GetRollingReservePolicy(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.True(suite.T(), rsp.Item.Enabled)
+	assert.Equal(suite.T(), pkg.RollingReservePolicyTypePercentage, rsp.Item.Type)
+	assert.EqualValues(suite.T(), 90, rsp.Item.HoldDays)
+}
+
+func (suite *RollingReserveTestSuite) TestRollingReserve_SetRollingReservePolicy_ValidationErrors() {
+	policies := map[*billingpb.ResponseErrorMessage]*intPkg.RollingReservePolicy{
+		rollingReservePolicyErrorTypeInvalid:     {Type: "unknown"},
+		rollingReservePolicyErrorPercentInvalid:  {Type: pkg.RollingReservePolicyTypePercentage, Percent: 101, HoldDays: 30},
+		rollingReservePolicyErrorHoldDaysInvalid: {Type: pkg.RollingReservePolicyTypePercentage, Percent: 10},
+		rollingReservePolicyErrorAmountInvalid:   {Type: pkg.RollingReservePolicyTypeFixedDeposit},
+	}
+
+	for message, policy := range policies {
+		policy.MerchantId = suite.merchant.Id
+		rsp := &intPkg.RollingReservePolicyResponse{}
+		err := suite.service.SetRollingReservePolicy(context.TODO(), policy, rsp)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+		assert.Equal(suite.T(), message, rsp.Message)
+	}
+}
+
+func (suite *RollingReserveTestSuite) TestRollingReserve_HoldRollingReserve_Percentage_Ok() {
+	suite.setPolicy(&intPkg.RollingReservePolicy{
+		Type:     pkg.RollingReservePolicyTypePercentage,
+		Percent:  10,
+		HoldDays: 30,
+		Cap:      25,
+	})
+
+	order := suite.holdPayment(200)
+	suite.holdPayment(200)
+	suite.holdPayment(200)
+
+	hold, err := suite.service.rollingReserveHoldRepository.GetByOrderId(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 20, hold.Amount)
+	assert.Equal(suite.T(), pkg.RollingReserveHoldStatusHeld, hold.Status)
+	assert.NotEmpty(suite.T(), hold.HoldEntryId)
+
+	schedule := suite.getSchedule()
+	assert.EqualValues(suite.T(), 25, schedule.TotalHeld)
+	assert.Zero(suite.T(), schedule.UndatedAmount)
+	assert.Len(suite.T(), schedule.Items, 1)
+	assert.EqualValues(suite.T(), 2, schedule.Items[0].Count)
+	assert.Equal(suite.T(), time.Now().AddDate(0, 0, 30).UTC().Format("2006-01-02"), schedule.Items[0].Date)
+}
+
+func (suite *RollingReserveTestSuite) TestRollingReserve_HoldRollingReserve_ProcessedAgain_Ok() {
+	suite.setPolicy(&intPkg.RollingReservePolicy{
+		Type:     pkg.RollingReservePolicyTypePercentage,
+		Percent:  10,
+		HoldDays: 30,
+	})
+
+	order := suite.holdPayment(200)
+	hold, err := suite.service.rollingReserveHoldRepository.GetByOrderId(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+
+	suite.setPolicy(&intPkg.RollingReservePolicy{
+		Type:     pkg.RollingReservePolicyTypePercentage,
+		Percent:  50,
+		HoldDays: 30,
+	})
+
+	handler := suite.processHold(order, 200)
+	assert.NotNil(suite.T(), handler.rollingReserveHold)
+	assert.Equal(suite.T(), hold.Id, handler.rollingReserveHold.Id)
+	assert.EqualValues(suite.T(), 20, handler.rollingReserveHold.Amount)
+	assert.NotEqual(suite.T(), hold.HoldEntryId, handler.rollingReserveHold.HoldEntryId)
+}
+
+func (suite *RollingReserveTestSuite) TestRollingReserve_HoldRollingReserve_PolicyDisabled_Ok() {
+	policy := &intPkg.RollingReservePolicy{
+		MerchantId: suite.merchant.Id,
+		Type:       pkg.RollingReservePolicyTypePercentage,
+		Percent:    10,
+		HoldDays:   30,
+	}
+	rsp := &intPkg.RollingReservePolicyResponse{}
+	err := suite.service.SetRollingReservePolicy(context.TODO(), policy, rsp)
+	assert.NoError(suite.T(), err)
+
+	suite.holdPayment(200)
+	assert.Zero(suite.T(), suite.getSchedule().TotalHeld)
+}
+
+func (suite *RollingReserveTestSuite) TestRollingReserve_HoldRollingReserve_FixedDeposit_Ok() {
+	suite.setPolicy(&intPkg.RollingReservePolicy{
+		Type:   pkg.RollingReservePolicyTypeFixedDeposit,
+		Amount: 150,
+	})
+
+	order := suite.holdPayment(100)
+	suite.holdPayment(100)
+	suite.holdPayment(100)
+
+	hold, err := suite.service.rollingReserveHoldRepository.GetByOrderId(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), hold.ReleaseAt.IsZero())
+
+	schedule := suite.getSchedule()
+	assert.EqualValues(suite.T(), 150, schedule.TotalHeld)
+	assert.EqualValues(suite.T(), 150, schedule.UndatedAmount)
+	assert.Empty(suite.T(), schedule.Items)
+}
+
+func (suite *RollingReserveTestSuite) TestRollingReserve_ReleaseRollingReserves_Ok() {
+	suite.setPolicy(&intPkg.RollingReservePolicy{
+		Type:     pkg.RollingReservePolicyTypePercentage,
+		Percent:  10,
+		HoldDays: 30,
+	})
+
+	order := suite.holdPayment(200)
+	suite.holdPayment(100)
+
+	hold, err := suite.service.rollingReserveHoldRepository.GetByOrderId(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+
+	hold.ReleaseAt = time.Now().Add(-time.Minute)
+	err = suite.service.rollingReserveHoldRepository.Update(context.TODO(), hold)
+	assert.NoError(suite.T(), err)
+
+	err = suite.service.ReleaseRollingReserves(context.TODO())
+	assert.NoError(suite.T(), err)
+
+	hold, err = suite.service.rollingReserveHoldRepository.GetByOrderId(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.RollingReserveHoldStatusReleased, hold.Status)
+	assert.NotEmpty(suite.T(), hold.ReleaseEntryId)
+	assert.EqualValues(suite.T(), 10, suite.getSchedule().TotalHeld)
+
+	req := &intPkg.ListMerchantBalanceTransactionsRequest{
+		MerchantId: suite.merchant.Id,
+		Types:      []string{pkg.MerchantBalanceTransactionTypeReserveRelease},
+	}
+	rsp := &intPkg.ListMerchantBalanceTransactionsResponse{}
+	err = suite.service.ListMerchantBalanceTransactions(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), rsp.Items, 1)
+	assert.EqualValues(suite.T(), 20, rsp.Items[0].Amount)
+}
@@ -74,6 +74,18 @@ func (s *Service) CreateRoyaltyReport(
 	from := to.Add(-time.Duration(s.cfg.RoyaltyReportPeriod) * time.Second).Add(1 * time.Nanosecond)
 	from = now.New(from).BeginningOfDay()
 
+	schedules, err := s.royaltyReportScheduleRepository.GetAll(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	merchantsSchedules := make(map[string]*pkg2.RoyaltyReportSchedule, len(schedules))
+
+	for _, v := range schedules {
+		merchantsSchedules[v.MerchantId] = v
+	}
+
 	var merchants []*pkg2.RoyaltyReportMerchant
 
 	if len(req.Merchants) > 0 {
@@ -88,6 +100,16 @@ func (s *Service) CreateRoyaltyReport(
 		}
 	} else {
 		merchants, _ = s.orderViewRepository.GetRoyaltyForMerchants(ctx, orderStatusForRoyaltyReports, from, to)
+
+		for _, v := range schedules {
+			oid, err := primitive.ObjectIDFromHex(v.MerchantId)
+
+			if err != nil {
+				continue
+			}
+
+			merchants = append(merchants, &pkg2.RoyaltyReportMerchant{Id: oid})
+		}
 	}
 
 	if len(merchants) <= 0 {
@@ -100,17 +122,47 @@ func (s *Service) CreateRoyaltyReport(
 		from:    from,
 		to:      to,
 	}
+	processed := make(map[string]bool, len(merchants))
 
 	for _, v := range merchants {
+		merchantId := v.Id.Hex()
+
+		if processed[merchantId] {
+			continue
+		}
+
+		processed[merchantId] = true
+
+		// merchants with schedule receive reports only for ended periods of their schedules
+		if schedule, ok := merchantsSchedules[merchantId]; ok {
+			created, err := s.createScheduledRoyaltyReports(ctx, schedule, time.Now())
+
+			if err != nil {
+				zap.L().Error(
+					pkg.ErrorRoyaltyReportGenerationFailed,
+					zap.Error(err),
+					zap.String(pkg.ErrorRoyaltyReportFieldMerchantId, merchantId),
+				)
+			}
+
+			if created {
+				rsp.Merchants = append(rsp.Merchants, merchantId)
+			}
+
+			continue
+		}
+
 		err := handler.createMerchantRoyaltyReport(ctx, v.Id)
 
 		if err == nil {
-			rsp.Merchants = append(rsp.Merchants, v.Id.Hex())
+			rsp.Merchants = append(rsp.Merchants, merchantId)
+		} else if err == royaltyReportErrorAlreadyExistsAndCannotBeUpdated {
+			zap.L().Info("royalty report for the period already exists", zap.String("merchant_id", merchantId))
 		} else {
 			zap.L().Error(
 				pkg.ErrorRoyaltyReportGenerationFailed,
 				zap.Error(err),
-				zap.String(pkg.ErrorRoyaltyReportFieldMerchantId, v.Id.Hex()),
+				zap.String(pkg.ErrorRoyaltyReportFieldMerchantId, merchantId),
 				zap.Any(pkg.ErrorRoyaltyReportFieldFrom, from),
 				zap.Any(pkg.ErrorRoyaltyReportFieldTo, to),
 			)
@@ -396,7 +448,7 @@ func (s *Service) ChangeRoyaltyReport(
 			Type:       pkg.AccountingEntryTypeMerchantRoyaltyCorrection,
 		}
 		resAe := &billingpb.CreateAccountingEntryResponse{}
-		err = s.CreateAccountingEntry(ctx, reqAe, resAe)
+		err = s.createAccountingEntry(ctx, reqAe, resAe)
 		if err != nil {
 			zap.L().Error("create correction accounting entry failed", zap.Error(err))
 			rsp.Status = billingpb.ResponseStatusSystemError
@@ -410,19 +462,7 @@ func (s *Service) ChangeRoyaltyReport(
 			return nil
 		}
 
-		if report.Totals == nil {
-			report.Totals = &billingpb.RoyaltyReportTotals{}
-		}
-		if report.Summary == nil {
-			report.Summary = &billingpb.RoyaltyReportSummary{}
-		}
-
-		handler := &royaltyHandler{
-			Service: s,
-			from:    from,
-			to:      to,
-		}
-		report.Summary.Corrections, report.Totals.CorrectionAmount, err = handler.getRoyaltyReportCorrections(ctx, report.MerchantId, report.Currency)
+		err = s.updateRoyaltyReportCorrections(ctx, report, from, to)
 		if err != nil {
 			zap.L().Error("get royalty report corrections error", zap.Error(err))
 			rsp.Status = billingpb.ResponseStatusSystemError
@@ -430,14 +470,6 @@ func (s *Service) ChangeRoyaltyReport(
 			return nil
 		}
 
-		report.Totals.B2BVatBase = report.Totals.FeeAmount
-		report.Totals.B2BVatAmount = report.Totals.B2BVatBase * report.Totals.B2BVatRate
-		// FinalPayoutAmount is a real amount to be paid to merchant, excluding all VAT and including all manual corrections, if any.
-		report.Totals.FinalPayoutAmount = report.Totals.PayoutAmount + report.Totals.CorrectionAmount - report.Totals.B2BVatAmount
-
-		report.Totals.B2BVatAmount = math.Round(report.Totals.B2BVatAmount*100) / 100
-		report.Totals.FinalPayoutAmount = math.Round(report.Totals.FinalPayoutAmount*100) / 100
-
 		hasChanges = true
 	}
 
@@ -538,6 +570,38 @@ func (s *Service) ListRoyaltyReportOrders(
 	return nil
 }
 
+// updateRoyaltyReportCorrections recalculates corrections and final payout amount of the report by period dates.
+func (s *Service) updateRoyaltyReportCorrections(ctx context.Context, report *billingpb.RoyaltyReport, from, to time.Time) error {
+	var err error
+
+	if report.Totals == nil {
+		report.Totals = &billingpb.RoyaltyReportTotals{}
+	}
+	if report.Summary == nil {
+		report.Summary = &billingpb.RoyaltyReportSummary{}
+	}
+
+	handler := &royaltyHandler{
+		Service: s,
+		from:    from,
+		to:      to,
+	}
+	report.Summary.Corrections, report.Totals.CorrectionAmount, err = handler.getRoyaltyReportCorrections(ctx, report.MerchantId, report.Currency)
+	if err != nil {
+		return err
+	}
+
+	report.Totals.B2BVatBase = report.Totals.FeeAmount
+	report.Totals.B2BVatAmount = report.Totals.B2BVatBase * report.Totals.B2BVatRate
+	// FinalPayoutAmount is a real amount to be paid to merchant, excluding all VAT and including all manual corrections, if any.
+	report.Totals.FinalPayoutAmount = report.Totals.PayoutAmount + report.Totals.CorrectionAmount - report.Totals.B2BVatAmount
+
+	report.Totals.B2BVatAmount = math.Round(report.Totals.B2BVatAmount*100) / 100
+	report.Totals.FinalPayoutAmount = math.Round(report.Totals.FinalPayoutAmount*100) / 100
+
+	return nil
+}
+
 func (h *royaltyHandler) getRoyaltyReportCorrections(ctx context.Context, merchantId, currency string) (
 	entries []*billingpb.RoyaltyReportCorrectionItem,
 	total float64,
@@ -590,6 +654,8 @@ func (h *royaltyHandler) getRoyaltyReportRollingReserves(
 	return
 }
 
+// createMerchantRoyaltyReport creates royalty reports of the merchant per payout account of the merchant.
+// Reports of additional payout accounts are created only if orders or corrections are routed to the account.
 func (h *royaltyHandler) createMerchantRoyaltyReport(ctx context.Context, merchantId primitive.ObjectID) error {
 	zap.L().Info("start generating royalty reports for merchant", zap.String("merchant_id", merchantId.Hex()))
 
@@ -598,16 +664,54 @@ func (h *royaltyHandler) createMerchantRoyaltyReport(ctx context.Context, mercha
 		return merchantErrorNotFound
 	}
 
-	existingReport := h.royaltyReportRepository.GetReportExists(ctx, merchant.Id, merchant.GetPayoutCurrency(), h.from, h.to)
+	accounts, err := h.Service.getMerchantPayoutAccounts(ctx, merchant)
+	if err != nil {
+		return err
+	}
+
+	var result error
+
+	for i, account := range accounts {
+		err = h.createMerchantRoyaltyReportByCurrency(ctx, merchant, account.Currency, i > 0)
+
+		if err == royaltyReportErrorAlreadyExistsAndCannotBeUpdated {
+			if i == 0 {
+				result = err
+			}
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	zap.L().Info("generating royalty reports for merchant finished", zap.String("merchant_id", merchantId.Hex()))
+
+	return result
+}
+
+func (h *royaltyHandler) createMerchantRoyaltyReportByCurrency(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+	currency string,
+	skipEmpty bool,
+) error {
+	existingReport := h.royaltyReportRepository.GetReportExists(ctx, merchant.Id, currency, h.from, h.to)
 	if existingReport != nil && existingReport.Status != billingpb.RoyaltyReportStatusPending {
 		return royaltyReportErrorAlreadyExistsAndCannotBeUpdated
 	}
 
-	newReport, ordersIds, err := h.buildMerchantRoyaltyReportRoundedAmounts(ctx, merchant)
+	newReport, ordersIds, err := h.buildMerchantRoyaltyReportRoundedAmounts(ctx, merchant, currency)
 	if err != nil {
 		return err
 	}
 
+	if skipEmpty && existingReport == nil && newReport.Totals.TransactionsCount == 0 &&
+		len(newReport.Summary.Corrections) == 0 && len(newReport.Summary.RollingReserves) == 0 {
+		return nil
+	}
+
 	oc, err := h.Service.operatingCompanyRepository.GetById(ctx, newReport.OperatingCompanyId)
 	if err != nil {
 		return merchantOperatingCompanyNotFound
@@ -678,14 +782,7 @@ func (h *royaltyHandler) createMerchantRoyaltyReport(ctx context.Context, mercha
 		return err
 	}
 
-	err = h.Service.renderRoyaltyReport(ctx, newReport, merchant)
-	if err != nil {
-		return err
-	}
-
-	zap.L().Info("generating royalty reports for merchant finished", zap.String("merchant_id", merchantId.Hex()))
-
-	return nil
+	return h.Service.renderRoyaltyReport(ctx, newReport, merchant)
 }
 
 func (s *Service) renderRoyaltyReport(
@@ -1096,9 +1193,8 @@ func (s *Service) markOrdersIncludedToRoyaltyReport(
 }
 
 func (h *royaltyHandler) buildMerchantRoyaltyReportRoundedAmounts(
-	ctx context.Context, merchant *billingpb.Merchant,
+	ctx context.Context, merchant *billingpb.Merchant, merchantPayoutCurrency string,
 ) (*billingpb.RoyaltyReport, []primitive.ObjectID, error) {
-	merchantPayoutCurrency := merchant.GetPayoutCurrency()
 	summaryItems, summaryTotal, ordersIds, err := h.orderViewRepository.GetRoyaltySummaryRoundedAmounts(
 		ctx,
 		merchant.Id,
@@ -1125,7 +1221,7 @@ func (h *royaltyHandler) buildMerchantRoyaltyReportRoundedAmounts(
 		Id:                 primitive.NewObjectID().Hex(),
 		MerchantId:         merchant.Id,
 		OperatingCompanyId: merchant.OperatingCompanyId,
-		Currency:           merchant.GetPayoutCurrency(),
+		Currency:           merchantPayoutCurrency,
 		Status:             billingpb.RoyaltyReportStatusPending,
 		CreatedAt:          ptypes.TimestampNow(),
 		UpdatedAt:          ptypes.TimestampNow(),
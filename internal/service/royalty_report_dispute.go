@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	royaltyReportDisputeOrderReason   = "dispute %s: order %s"
+	royaltyReportDisputeProductReason = "dispute %s: product %s"
+)
+
+var (
+	royaltyReportDisputeErrorNotFound           = errors.NewBillingServerErrorMsg("rd000001", "royalty report dispute not found")
+	royaltyReportDisputeErrorItemsRequired      = errors.NewBillingServerErrorMsg("rd000002", "dispute must reference at least one order or product line of royalty report")
+	royaltyReportDisputeErrorItemInvalid        = errors.NewBillingServerErrorMsg("rd000003", "dispute item must reference either order or product line")
+	royaltyReportDisputeErrorOrderNotInReport   = errors.NewBillingServerErrorMsg("rd000004", "order isn't included to royalty report")
+	royaltyReportDisputeErrorProductNotInReport = errors.NewBillingServerErrorMsg("rd000005", "product line isn't included to royalty report")
+	royaltyReportDisputeErrorStatusInvalid      = errors.NewBillingServerErrorMsg("rd000006", "action isn't allowed in current status of royalty report dispute")
+	royaltyReportDisputeErrorMessageRequired    = errors.NewBillingServerErrorMsg("rd000007", "comment message is required")
+	royaltyReportDisputeErrorUserRequired       = errors.NewBillingServerErrorMsg("rd000008", "user identifier is required")
+	royaltyReportDisputeErrorItemNotFound       = errors.NewBillingServerErrorMsg("rd000009", "adjusted item not found in royalty report dispute")
+	royaltyReportDisputeErrorUnknown            = errors.NewBillingServerErrorMsg("rd000010", "unknown error. try request later")
+)
+
+// OpenRoyaltyReportDispute opens the merchant's dispute of the pending royalty report for the orders
+// or product lines of the report and moves the report to the dispute status.
+func (s *Service) OpenRoyaltyReportDispute(
+	ctx context.Context,
+	req *intPkg.OpenRoyaltyReportDisputeRequest,
+	rsp *intPkg.RoyaltyReportDisputeResponse,
+) error {
+	report, err := s.royaltyReportRepository.GetById(ctx, req.ReportId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = royaltyReportErrorReportNotFound
+		return nil
+	}
+
+	if report.MerchantId != req.MerchantId {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyReportErrorNotOwnedByMerchant
+		return nil
+	}
+
+	if report.Status != billingpb.RoyaltyReportStatusPending {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyReportErrorReportStatusChangeDenied
+		return nil
+	}
+
+	if len(req.Items) <= 0 {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyReportDisputeErrorItemsRequired
+		return nil
+	}
+
+	for _, item := range req.Items {
+		if msg := s.validateRoyaltyReportDisputeItem(ctx, report, item); msg != nil {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = msg
+			return nil
+		}
+
+		item.Id = primitive.NewObjectID().Hex()
+		item.AdjustmentAmount = 0
+		item.AdjustmentEntryId = ""
+	}
+
+	dispute := &intPkg.RoyaltyReportDispute{
+		RoyaltyReportId: report.Id,
+		MerchantId:      report.MerchantId,
+		Status:          pkg.RoyaltyReportDisputeStatusOpen,
+		Reason:          req.Reason,
+		Items:           req.Items,
+		Comments:        []*intPkg.RoyaltyReportDisputeComment{},
+		CreatedBy:       req.UserId,
+	}
+
+	if err = s.royaltyReportDisputeRepository.Insert(ctx, dispute); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportDisputeErrorUnknown
+		return nil
+	}
+
+	report.Status = billingpb.RoyaltyReportStatusDispute
+	report.DisputeReason = req.Reason
+	report.DisputeStartedAt = ptypes.TimestampNow()
+	report.UpdatedAt = ptypes.TimestampNow()
+
+	err = s.royaltyReportRepository.Update(ctx, report, req.Ip, pkg.RoyaltyReportChangeSourceMerchant)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportDisputeErrorUnknown
+		return nil
+	}
+
+	if err = s.onRoyaltyReportStatusChanged(ctx, report); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportDisputeErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = dispute
+
+	return nil
+}
+
+// AddRoyaltyReportDisputeComment adds the comment of the merchant or the admin to the thread of the open dispute.
+func (s *Service) AddRoyaltyReportDisputeComment(
+	ctx context.Context,
+	req *intPkg.RoyaltyReportDisputeCommentRequest,
+	rsp *intPkg.RoyaltyReportDisputeResponse,
+) error {
+	if req.UserId == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyReportDisputeErrorUserRequired
+		return nil
+	}
+
+	if req.Message == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyReportDisputeErrorMessageRequired
+		return nil
+	}
+
+	dispute, err := s.royaltyReportDisputeRepository.GetById(ctx, req.DisputeId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = royaltyReportDisputeErrorNotFound
+		return nil
+	}
+
+	author := pkg.RoyaltyReportChangeSourceAdmin
+
+	if req.MerchantId != "" {
+		if dispute.MerchantId != req.MerchantId {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = royaltyReportErrorNotOwnedByMerchant
+			return nil
+		}
+
+		author = pkg.RoyaltyReportChangeSourceMerchant
+	}
+
+	if dispute.Status != pkg.RoyaltyReportDisputeStatusOpen {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyReportDisputeErrorStatusInvalid
+		return nil
+	}
+
+	comment := &intPkg.RoyaltyReportDisputeComment{
+		Id:        primitive.NewObjectID().Hex(),
+		Author:    author,
+		UserId:    req.UserId,
+		Message:   req.Message,
+		CreatedAt: time.Now(),
+	}
+
+	if err = s.royaltyReportDisputeRepository.AddComment(ctx, req.DisputeId, comment); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportDisputeErrorUnknown
+		return nil
+	}
+
+	dispute.Comments = append(dispute.Comments, comment)
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = dispute
+
+	return nil
+}
+
+// ResolveRoyaltyReportDispute closes the open dispute by the admin. Adjustments of the disputed items are posted
+// as royalty corrections into the period of the report, the report totals are recalculated and the report
+// is returned to the merchant for review with re-rendered documents.
+func (s *Service) ResolveRoyaltyReportDispute(
+	ctx context.Context,
+	req *intPkg.ResolveRoyaltyReportDisputeRequest,
+	rsp *intPkg.RoyaltyReportDisputeResponse,
+) error {
+	if req.UserId == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyReportDisputeErrorUserRequired
+		return nil
+	}
+
+	dispute, err := s.royaltyReportDisputeRepository.GetById(ctx, req.DisputeId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = royaltyReportDisputeErrorNotFound
+		return nil
+	}
+
+	if dispute.Status != pkg.RoyaltyReportDisputeStatusOpen {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyReportDisputeErrorStatusInvalid
+		return nil
+	}
+
+	items := make(map[string]*intPkg.RoyaltyReportDisputeItem, len(dispute.Items))
+
+	for _, item := range dispute.Items {
+		items[item.Id] = item
+	}
+
+	for _, adjustment := range req.Adjustments {
+		if _, ok := items[adjustment.ItemId]; !ok {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = royaltyReportDisputeErrorItemNotFound
+			return nil
+		}
+
+		if adjustment.Amount == 0 {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = royaltyReportErrorCorrectionAmountRequired
+			return nil
+		}
+	}
+
+	report, err := s.royaltyReportRepository.GetById(ctx, dispute.RoyaltyReportId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = royaltyReportErrorReportNotFound
+		return nil
+	}
+
+	if report.Status != billingpb.RoyaltyReportStatusDispute {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyReportErrorReportStatusChangeDenied
+		return nil
+	}
+
+	err = s.applyRoyaltyReportDisputeResolution(ctx, dispute, report, items, req)
+
+	if err != nil {
+		zap.L().Error(
+			"royalty report dispute resolution failed",
+			zap.Error(err),
+			zap.String("dispute_id", req.DisputeId),
+		)
+
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportDisputeErrorUnknown
+
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Message = e
+		}
+
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = dispute
+
+	return nil
+}
+
+func (s *Service) ListRoyaltyReportDisputes(
+	ctx context.Context,
+	req *intPkg.ListRoyaltyReportDisputesRequest,
+	rsp *intPkg.ListRoyaltyReportDisputesResponse,
+) error {
+	report, err := s.royaltyReportRepository.GetById(ctx, req.ReportId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = royaltyReportErrorReportNotFound
+		return nil
+	}
+
+	if req.MerchantId != "" && report.MerchantId != req.MerchantId {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyReportErrorNotOwnedByMerchant
+		return nil
+	}
+
+	disputes, err := s.royaltyReportDisputeRepository.FindByReportId(ctx, report.Id)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportDisputeErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Items = disputes
+
+	return nil
+}
+
+func (s *Service) validateRoyaltyReportDisputeItem(
+	ctx context.Context,
+	report *billingpb.RoyaltyReport,
+	item *intPkg.RoyaltyReportDisputeItem,
+) *billingpb.ResponseErrorMessage {
+	if (item.OrderId == "") == (item.Product == "") {
+		return royaltyReportDisputeErrorItemInvalid
+	}
+
+	if item.OrderId != "" {
+		order, err := s.orderRepository.GetById(ctx, item.OrderId)
+
+		if err != nil || order.RoyaltyReportId != report.Id {
+			return royaltyReportDisputeErrorOrderNotInReport
+		}
+
+		return nil
+	}
+
+	if report.Summary != nil {
+		for _, v := range report.Summary.ProductsItems {
+			if v.Product == item.Product && (item.Region == "" || v.Region == item.Region) {
+				return nil
+			}
+		}
+	}
+
+	return royaltyReportDisputeErrorProductNotInReport
+}
+
+func (s *Service) applyRoyaltyReportDisputeResolution(
+	ctx context.Context,
+	dispute *intPkg.RoyaltyReportDispute,
+	report *billingpb.RoyaltyReport,
+	items map[string]*intPkg.RoyaltyReportDisputeItem,
+	req *intPkg.ResolveRoyaltyReportDisputeRequest,
+) error {
+	from, err := ptypes.Timestamp(report.PeriodFrom)
+
+	if err != nil {
+		return err
+	}
+
+	to, err := ptypes.Timestamp(report.PeriodTo)
+
+	if err != nil {
+		return err
+	}
+
+	// entries of adjustments, the dispute and the report are changed together, so the failed resolution
+	// can be repeated without duplicated adjustments
+	err = database.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		for _, adjustment := range req.Adjustments {
+			item := items[adjustment.ItemId]
+			reason := fmt.Sprintf(royaltyReportDisputeOrderReason, dispute.Id.Hex(), item.OrderId)
+
+			if item.OrderId == "" {
+				reason = fmt.Sprintf(royaltyReportDisputeProductReason, dispute.Id.Hex(), item.Product)
+			}
+
+			reqAe := &billingpb.CreateAccountingEntryRequest{
+				MerchantId: report.MerchantId,
+				Amount:     adjustment.Amount,
+				Currency:   report.Currency,
+				Reason:     reason,
+				Date:       to.Add(-1 * time.Second).Unix(),
+				Type:       pkg.AccountingEntryTypeMerchantRoyaltyCorrection,
+			}
+			resAe := &billingpb.CreateAccountingEntryResponse{}
+
+			if err = s.createAccountingEntry(ctx, reqAe, resAe); err != nil {
+				return err
+			}
+
+			if resAe.Status != billingpb.ResponseStatusOk {
+				return resAe.Message
+			}
+
+			item.AdjustmentAmount += adjustment.Amount
+			item.AdjustmentEntryId = resAe.Item.Id
+		}
+
+		if len(req.Adjustments) > 0 {
+			if err = s.updateRoyaltyReportCorrections(ctx, report, from, to); err != nil {
+				return err
+			}
+
+			dispute.Status = pkg.RoyaltyReportDisputeStatusResolved
+		} else {
+			dispute.Status = pkg.RoyaltyReportDisputeStatusRejected
+		}
+
+		if req.Comment != "" {
+			dispute.Comments = append(dispute.Comments, &intPkg.RoyaltyReportDisputeComment{
+				Id:        primitive.NewObjectID().Hex(),
+				Author:    pkg.RoyaltyReportChangeSourceAdmin,
+				UserId:    req.UserId,
+				Message:   req.Comment,
+				CreatedAt: time.Now(),
+			})
+		}
+
+		dispute.ResolvedBy = req.UserId
+		dispute.ClosedAt = time.Now()
+
+		if err = s.royaltyReportDisputeRepository.Update(ctx, dispute); err != nil {
+			return err
+		}
+
+		// the merchant reviews the report with resolved dispute again
+		report.Status = billingpb.RoyaltyReportStatusPending
+		report.DisputeClosedAt = ptypes.TimestampNow()
+		report.UpdatedAt = ptypes.TimestampNow()
+		report.AcceptExpireAt, err = ptypes.TimestampProto(
+			time.Now().Add(time.Duration(s.cfg.RoyaltyReportAcceptTimeout) * time.Second),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		err = s.royaltyReportRepository.Update(ctx, report, req.Ip, pkg.RoyaltyReportChangeSourceAdmin)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = s.updateMerchantBalance(ctx, report.MerchantId)
+
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	merchant, err := s.merchantRepository.GetById(ctx, report.MerchantId)
+
+	if err != nil {
+		return royaltyReportErrorMerchantNotFound
+	}
+
+	if err = s.renderRoyaltyReport(ctx, report, merchant); err != nil {
+		return err
+	}
+
+	s.sendRoyaltyReportNotification(ctx, report)
+
+	return nil
+}
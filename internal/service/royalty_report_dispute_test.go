@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (suite *RoyaltyReportTestSuite) createReportForDispute() (*billingpb.RoyaltyReport, *billingpb.Order) {
+	var order *billingpb.Order
+
+	for i := 0; i < 3; i++ {
+		order = suite.createOrder(suite.project)
+	}
+
+	err := suite.service.updateOrderView(context.TODO(), []string{})
+	assert.NoError(suite.T(), err)
+
+	req := &billingpb.CreateRoyaltyReportRequest{Merchants: []string{suite.merchant.Id}}
+	rsp := &billingpb.CreateRoyaltyReportRequest{}
+	err = suite.service.CreateRoyaltyReport(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+
+	reports, err := suite.service.royaltyReportRepository.GetAll(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), reports, 1)
+
+	return reports[0], order
+}
+
+func (suite *RoyaltyReportTestSuite) openDispute(
+	report *billingpb.RoyaltyReport,
+	items []*intPkg.RoyaltyReportDisputeItem,
+) *intPkg.RoyaltyReportDisputeResponse {
+	req := &intPkg.OpenRoyaltyReportDisputeRequest{
+		ReportId:   report.Id,
+		MerchantId: report.MerchantId,
+		UserId:     "merchant-user",
+		Reason:     "wrong fees",
+		Items:      items,
+	}
+	rsp := &intPkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.OpenRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+
+	return rsp
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_OpenRoyaltyReportDispute_Ok() {
+	report, order := suite.createReportForDispute()
+	product := report.Summary.ProductsItems[0]
+
+	rsp := suite.openDispute(report, []*intPkg.RoyaltyReportDisputeItem{
+		{OrderId: order.Id, ClaimedAmount: 5, Description: "fee is too high"},
+		{Product: product.Product, Region: product.Region, ClaimedAmount: 3},
+	})
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.RoyaltyReportDisputeStatusOpen, rsp.Item.Status)
+	assert.Len(suite.T(), rsp.Item.Items, 2)
+	assert.NotEmpty(suite.T(), rsp.Item.Items[0].Id)
+
+	report, err := suite.service.royaltyReportRepository.GetById(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.RoyaltyReportStatusDispute, report.Status)
+	assert.Equal(suite.T(), "wrong fees", report.DisputeReason)
+	assert.NotNil(suite.T(), report.DisputeStartedAt)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_OpenRoyaltyReportDispute_ItemsRequired_Error() {
+	report, _ := suite.createReportForDispute()
+
+	rsp := suite.openDispute(report, nil)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportDisputeErrorItemsRequired, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_OpenRoyaltyReportDispute_ItemInvalid_Error() {
+	report, order := suite.createReportForDispute()
+
+	rsp := suite.openDispute(report, []*intPkg.RoyaltyReportDisputeItem{{OrderId: order.Id, Product: "product"}})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportDisputeErrorItemInvalid, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_OpenRoyaltyReportDispute_OrderNotInReport_Error() {
+	report, _ := suite.createReportForDispute()
+
+	rsp := suite.openDispute(report, []*intPkg.RoyaltyReportDisputeItem{{OrderId: primitive.NewObjectID().Hex()}})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportDisputeErrorOrderNotInReport, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_OpenRoyaltyReportDispute_ProductNotInReport_Error() {
+	report, _ := suite.createReportForDispute()
+
+	rsp := suite.openDispute(report, []*intPkg.RoyaltyReportDisputeItem{{Product: "unknown product"}})
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportDisputeErrorProductNotInReport, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_OpenRoyaltyReportDispute_NotOwnedByMerchant_Error() {
+	report, order := suite.createReportForDispute()
+
+	req := &intPkg.OpenRoyaltyReportDisputeRequest{
+		ReportId:   report.Id,
+		MerchantId: suite.merchant1.Id,
+		Items:      []*intPkg.RoyaltyReportDisputeItem{{OrderId: order.Id}},
+	}
+	rsp := &intPkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.OpenRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportErrorNotOwnedByMerchant, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_AddRoyaltyReportDisputeComment_Ok() {
+	report, order := suite.createReportForDispute()
+	dispute := suite.openDispute(report, []*intPkg.RoyaltyReportDisputeItem{{OrderId: order.Id}}).Item
+
+	req := &intPkg.RoyaltyReportDisputeCommentRequest{
+		DisputeId:  dispute.Id.Hex(),
+		MerchantId: report.MerchantId,
+		UserId:     "merchant-user",
+		Message:    "please check the order",
+	}
+	rsp := &intPkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.AddRoyaltyReportDisputeComment(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	req = &intPkg.RoyaltyReportDisputeCommentRequest{
+		DisputeId: dispute.Id.Hex(),
+		UserId:    "admin-user",
+		Message:   "checking",
+	}
+	rsp = &intPkg.RoyaltyReportDisputeResponse{}
+	err = suite.service.AddRoyaltyReportDisputeComment(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	dispute, err = suite.service.royaltyReportDisputeRepository.GetById(context.TODO(), dispute.Id.Hex())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), dispute.Comments, 2)
+	assert.Equal(suite.T(), pkg.RoyaltyReportChangeSourceMerchant, dispute.Comments[0].Author)
+	assert.Equal(suite.T(), pkg.RoyaltyReportChangeSourceAdmin, dispute.Comments[1].Author)
+	assert.Equal(suite.T(), "checking", dispute.Comments[1].Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_AddRoyaltyReportDisputeComment_MessageRequired_Error() {
+	req := &intPkg.RoyaltyReportDisputeCommentRequest{DisputeId: primitive.NewObjectID().Hex(), UserId: "admin-user"}
+	rsp := &intPkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.AddRoyaltyReportDisputeComment(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportDisputeErrorMessageRequired, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_ResolveRoyaltyReportDispute_Ok() {
+	report, order := suite.createReportForDispute()
+	dispute := suite.openDispute(report, []*intPkg.RoyaltyReportDisputeItem{{OrderId: order.Id, ClaimedAmount: 10}}).Item
+	correctionAmount := report.Totals.CorrectionAmount
+	finalPayoutAmount := report.Totals.FinalPayoutAmount
+
+	req := &intPkg.ResolveRoyaltyReportDisputeRequest{
+		DisputeId: dispute.Id.Hex(),
+		UserId:    "admin-user",
+		Comment:   "fee refunded",
+		Adjustments: []*intPkg.RoyaltyReportDisputeAdjustment{
+			{ItemId: dispute.Items[0].Id, Amount: 10},
+		},
+	}
+	rsp := &intPkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.ResolveRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.RoyaltyReportDisputeStatusResolved, rsp.Item.Status)
+	assert.EqualValues(suite.T(), 10, rsp.Item.Items[0].AdjustmentAmount)
+	assert.NotEmpty(suite.T(), rsp.Item.Items[0].AdjustmentEntryId)
+	assert.Len(suite.T(), rsp.Item.Comments, 1)
+	assert.Equal(suite.T(), "admin-user", rsp.Item.ResolvedBy)
+
+	entry, err := suite.service.accountingRepository.GetById(context.TODO(), rsp.Item.Items[0].AdjustmentEntryId)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.AccountingEntryTypeMerchantRoyaltyCorrection, entry.Type)
+	assert.EqualValues(suite.T(), 10, entry.Amount)
+
+	report, err = suite.service.royaltyReportRepository.GetById(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.RoyaltyReportStatusPending, report.Status)
+	assert.NotNil(suite.T(), report.DisputeClosedAt)
+	assert.EqualValues(suite.T(), correctionAmount+10, report.Totals.CorrectionAmount)
+	assert.InDelta(suite.T(), finalPayoutAmount+10, report.Totals.FinalPayoutAmount, 0.01)
+	assert.Len(suite.T(), report.Summary.Corrections, 1)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_ResolveRoyaltyReportDispute_Rejected_Ok() {
+	report, order := suite.createReportForDispute()
+	dispute := suite.openDispute(report, []*intPkg.RoyaltyReportDisputeItem{{OrderId: order.Id}}).Item
+
+	req := &intPkg.ResolveRoyaltyReportDisputeRequest{DisputeId: dispute.Id.Hex(), UserId: "admin-user"}
+	rsp := &intPkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.ResolveRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.RoyaltyReportDisputeStatusRejected, rsp.Item.Status)
+
+	report, err = suite.service.royaltyReportRepository.GetById(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.RoyaltyReportStatusPending, report.Status)
+
+	// closed dispute can't be resolved or commented again
+	rsp = &intPkg.RoyaltyReportDisputeResponse{}
+	err = suite.service.ResolveRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportDisputeErrorStatusInvalid, rsp.Message)
+
+	reqComment := &intPkg.RoyaltyReportDisputeCommentRequest{DisputeId: dispute.Id.Hex(), UserId: "admin-user", Message: "late"}
+	rsp = &intPkg.RoyaltyReportDisputeResponse{}
+	err = suite.service.AddRoyaltyReportDisputeComment(context.TODO(), reqComment, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportDisputeErrorStatusInvalid, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_ResolveRoyaltyReportDispute_ItemNotFound_Error() {
+	report, order := suite.createReportForDispute()
+	dispute := suite.openDispute(report, []*intPkg.RoyaltyReportDisputeItem{{OrderId: order.Id}}).Item
+
+	req := &intPkg.ResolveRoyaltyReportDisputeRequest{
+		DisputeId:   dispute.Id.Hex(),
+		UserId:      "admin-user",
+		Adjustments: []*intPkg.RoyaltyReportDisputeAdjustment{{ItemId: primitive.NewObjectID().Hex(), Amount: 1}},
+	}
+	rsp := &intPkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.ResolveRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportDisputeErrorItemNotFound, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_ListRoyaltyReportDisputes_Ok() {
+	report, order := suite.createReportForDispute()
+	suite.openDispute(report, []*intPkg.RoyaltyReportDisputeItem{{OrderId: order.Id}})
+
+	req := &intPkg.ListRoyaltyReportDisputesRequest{ReportId: report.Id, MerchantId: report.MerchantId}
+	rsp := &intPkg.ListRoyaltyReportDisputesResponse{}
+	err := suite.service.ListRoyaltyReportDisputes(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 1)
+	assert.Equal(suite.T(), report.Id, rsp.Items[0].RoyaltyReportId)
+}
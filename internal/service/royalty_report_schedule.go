@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/jinzhu/now"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	royaltyReportScheduleDaysInWeek   = 7
+	royaltyReportScheduleDaysInBiWeek = 14
+)
+
+var (
+	royaltyReportScheduleErrorTypeInvalid    = errors.NewBillingServerErrorMsg("rs000001", "royalty report schedule type is invalid")
+	royaltyReportScheduleErrorWeekDayInvalid = errors.NewBillingServerErrorMsg("rs000002", "royalty report schedule week day must be between 0 (sunday) and 6 (saturday)")
+	royaltyReportScheduleErrorUnknown        = errors.NewBillingServerErrorMsg("rs000003", "unknown error. try request later")
+)
+
+func (s *Service) GetRoyaltyReportSchedule(
+	ctx context.Context,
+	req *intPkg.GetRoyaltyReportScheduleRequest,
+	rsp *intPkg.RoyaltyReportScheduleResponse,
+) error {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	schedule, err := s.royaltyReportScheduleRepository.GetByMerchantId(ctx, merchant.Id)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = royaltyReportScheduleErrorUnknown
+			return nil
+		}
+
+		// Merchants without schedule receive weekly reports for weeks started on Monday
+		schedule = &intPkg.RoyaltyReportSchedule{
+			MerchantId: merchant.Id,
+			Type:       pkg.RoyaltyReportScheduleTypeWeekly,
+			WeekDay:    int32(time.Monday),
+		}
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = schedule
+
+	return nil
+}
+
+// SetRoyaltyReportSchedule changes the schedule of royalty reports of the merchant.
+// The new schedule is applied from the end of the last generated period, so the first period after the change
+// may be shorter than a regular one.
+func (s *Service) SetRoyaltyReportSchedule(
+	ctx context.Context,
+	req *intPkg.RoyaltyReportSchedule,
+	rsp *intPkg.RoyaltyReportScheduleResponse,
+) error {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	switch req.Type {
+	case pkg.RoyaltyReportScheduleTypeWeekly, pkg.RoyaltyReportScheduleTypeBiWeekly:
+		if req.WeekDay < int32(time.Sunday) || req.WeekDay > int32(time.Saturday) {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = royaltyReportScheduleErrorWeekDayInvalid
+			return nil
+		}
+		break
+
+	case pkg.RoyaltyReportScheduleTypeMonthly:
+		req.WeekDay = 0
+		break
+
+	default:
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyReportScheduleErrorTypeInvalid
+		return nil
+	}
+
+	schedule, err := s.royaltyReportScheduleRepository.GetByMerchantId(ctx, merchant.Id)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = royaltyReportScheduleErrorUnknown
+			return nil
+		}
+
+		schedule = &intPkg.RoyaltyReportSchedule{MerchantId: merchant.Id}
+	}
+
+	if schedule.Type != req.Type || schedule.WeekDay != req.WeekDay || schedule.AnchorDate.IsZero() {
+		schedule.Type = req.Type
+		schedule.WeekDay = req.WeekDay
+		schedule.AnchorDate = getRoyaltyReportScheduleWeekStart(schedule, time.Now())
+	}
+
+	if err = s.royaltyReportScheduleRepository.Upsert(ctx, schedule); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportScheduleErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = schedule
+
+	return nil
+}
+
+// createScheduledRoyaltyReports generates royalty reports of the merchant for all periods of the schedule
+// which are ended before the date. Returns true if at least one report was generated.
+func (s *Service) createScheduledRoyaltyReports(
+	ctx context.Context,
+	schedule *intPkg.RoyaltyReportSchedule,
+	date time.Time,
+) (bool, error) {
+	merchant, err := s.merchantRepository.GetById(ctx, schedule.MerchantId)
+
+	if err != nil {
+		return false, merchantErrorNotFound
+	}
+
+	from, err := s.getRoyaltyReportScheduleFrom(ctx, schedule, merchant, date)
+
+	if err != nil {
+		return false, err
+	}
+
+	created := false
+	merchantOid, err := primitive.ObjectIDFromHex(merchant.Id)
+
+	if err != nil {
+		return false, merchantErrorNotFound
+	}
+
+	for end := getRoyaltyReportSchedulePeriodEnd(schedule, from); !end.After(date); end = getRoyaltyReportSchedulePeriodEnd(schedule, from) {
+		to := end.Add(-1 * time.Nanosecond)
+		filter := bson.M{
+			"merchant_id":         merchantOid,
+			"pm_order_close_date": bson.M{"$gte": from, "$lte": to},
+			"status":              bson.M{"$in": orderStatusForRoyaltyReports},
+			"is_production":       true,
+		}
+		count, err := s.orderViewRepository.GetCountBy(ctx, filter, options.Count().SetLimit(1))
+
+		if err != nil {
+			return created, err
+		}
+
+		if count > 0 {
+			handler := &royaltyHandler{
+				Service: s,
+				from:    from,
+				to:      to,
+			}
+			err = handler.createMerchantRoyaltyReport(ctx, merchantOid)
+
+			if err != nil && err != royaltyReportErrorAlreadyExistsAndCannotBeUpdated {
+				zap.L().Error(
+					pkg.ErrorRoyaltyReportGenerationFailed,
+					zap.Error(err),
+					zap.String(pkg.ErrorRoyaltyReportFieldMerchantId, merchant.Id),
+					zap.Any(pkg.ErrorRoyaltyReportFieldFrom, from),
+					zap.Any(pkg.ErrorRoyaltyReportFieldTo, to),
+				)
+				return created, err
+			}
+
+			created = created || err == nil
+		}
+
+		schedule.NextPeriodFrom = end
+
+		if err = s.royaltyReportScheduleRepository.Upsert(ctx, schedule); err != nil {
+			return created, err
+		}
+
+		from = end
+	}
+
+	return created, nil
+}
+
+// getRoyaltyReportScheduleFrom returns the beginning of the next period of the merchant's royalty reports.
+// The period starts right after the end of the last generated period or the last royalty report of the merchant.
+// For merchants without reports it is the beginning of the last ended period of the schedule.
+func (s *Service) getRoyaltyReportScheduleFrom(
+	ctx context.Context,
+	schedule *intPkg.RoyaltyReportSchedule,
+	merchant *billingpb.Merchant,
+	date time.Time,
+) (time.Time, error) {
+	if !schedule.NextPeriodFrom.IsZero() {
+		return schedule.NextPeriodFrom, nil
+	}
+
+	report, err := s.royaltyReportRepository.GetLastByMerchant(ctx, merchant.Id, merchant.GetPayoutCurrency())
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		return time.Time{}, err
+	}
+
+	if report != nil && report.PeriodTo != nil {
+		to, err := ptypes.Timestamp(report.PeriodTo)
+
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		// period end is stored with milliseconds precision
+		return to.Add(time.Millisecond).Truncate(time.Millisecond), nil
+	}
+
+	start := getRoyaltyReportSchedulePeriodStart(schedule, date)
+
+	return getRoyaltyReportSchedulePeriodStart(schedule, start.Add(-1*time.Nanosecond)), nil
+}
+
+// getRoyaltyReportSchedulePeriodStart returns the beginning of the schedule's period containing the date.
+func getRoyaltyReportSchedulePeriodStart(schedule *intPkg.RoyaltyReportSchedule, date time.Time) time.Time {
+	date = date.Local()
+
+	if schedule.Type == pkg.RoyaltyReportScheduleTypeMonthly {
+		return now.New(date).BeginningOfMonth()
+	}
+
+	start := getRoyaltyReportScheduleWeekStart(schedule, date)
+
+	if schedule.Type == pkg.RoyaltyReportScheduleTypeBiWeekly && !schedule.AnchorDate.IsZero() {
+		days := getRoyaltyReportScheduleDaysBetween(schedule.AnchorDate, start) % royaltyReportScheduleDaysInBiWeek
+
+		if days < 0 {
+			days += royaltyReportScheduleDaysInBiWeek
+		}
+
+		start = start.AddDate(0, 0, -days)
+	}
+
+	return start
+}
+
+// getRoyaltyReportSchedulePeriodEnd returns the beginning of the first schedule's period started after the date.
+func getRoyaltyReportSchedulePeriodEnd(schedule *intPkg.RoyaltyReportSchedule, date time.Time) time.Time {
+	start := getRoyaltyReportSchedulePeriodStart(schedule, date)
+
+	switch schedule.Type {
+	case pkg.RoyaltyReportScheduleTypeMonthly:
+		return start.AddDate(0, 1, 0)
+	case pkg.RoyaltyReportScheduleTypeBiWeekly:
+		return start.AddDate(0, 0, royaltyReportScheduleDaysInBiWeek)
+	}
+
+	return start.AddDate(0, 0, royaltyReportScheduleDaysInWeek)
+}
+
+// getRoyaltyReportScheduleWeekStart returns the beginning of the last schedule's week day before or at the date.
+func getRoyaltyReportScheduleWeekStart(schedule *intPkg.RoyaltyReportSchedule, date time.Time) time.Time {
+	day := now.New(date.Local()).BeginningOfDay()
+	days := (int(day.Weekday()) - int(schedule.WeekDay) + royaltyReportScheduleDaysInWeek) % royaltyReportScheduleDaysInWeek
+
+	return day.AddDate(0, 0, -days)
+}
+
+// getRoyaltyReportScheduleDaysBetween returns the number of calendar days between the dates
+// regardless of daylight saving time changes.
+func getRoyaltyReportScheduleDaysBetween(from, to time.Time) int {
+	from, to = from.Local(), to.Local()
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(to.Sub(from).Hours() / 24)
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/jinzhu/now"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_GetRoyaltyReportSchedule_Default_Ok() {
+	req := &intPkg.GetRoyaltyReportScheduleRequest{MerchantId: suite.merchant.Id}
+	rsp := &intPkg.RoyaltyReportScheduleResponse{}
+	err := suite.service.GetRoyaltyReportSchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.RoyaltyReportScheduleTypeWeekly, rsp.Item.Type)
+	assert.EqualValues(suite.T(), time.Monday, rsp.Item.WeekDay)
+	assert.True(suite.T(), rsp.Item.Id.IsZero())
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_SetRoyaltyReportSchedule_Ok() {
+	req := &intPkg.RoyaltyReportSchedule{
+		MerchantId: suite.merchant.Id,
+		Type:       pkg.RoyaltyReportScheduleTypeBiWeekly,
+		WeekDay:    int32(time.Wednesday),
+	}
+	rsp := &intPkg.RoyaltyReportScheduleResponse{}
+	err := suite.service.SetRoyaltyReportSchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), time.Wednesday, rsp.Item.AnchorDate.Weekday())
+
+	rsp1 := &intPkg.RoyaltyReportScheduleResponse{}
+	err = suite.service.GetRoyaltyReportSchedule(
+		context.TODO(),
+		&intPkg.GetRoyaltyReportScheduleRequest{MerchantId: suite.merchant.Id},
+		rsp1,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp1.Status)
+	assert.Equal(suite.T(), rsp.Item.Id, rsp1.Item.Id)
+	assert.Equal(suite.T(), pkg.RoyaltyReportScheduleTypeBiWeekly, rsp1.Item.Type)
+	assert.EqualValues(suite.T(), time.Wednesday, rsp1.Item.WeekDay)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_SetRoyaltyReportSchedule_KeepsNextPeriod_Ok() {
+	nextPeriodFrom := now.Monday()
+	schedule := &intPkg.RoyaltyReportSchedule{
+		MerchantId:     suite.merchant.Id,
+		Type:           pkg.RoyaltyReportScheduleTypeWeekly,
+		WeekDay:        int32(time.Monday),
+		AnchorDate:     nextPeriodFrom,
+		NextPeriodFrom: nextPeriodFrom,
+	}
+	err := suite.service.royaltyReportScheduleRepository.Upsert(context.TODO(), schedule)
+	assert.NoError(suite.T(), err)
+
+	req := &intPkg.RoyaltyReportSchedule{
+		MerchantId: suite.merchant.Id,
+		Type:       pkg.RoyaltyReportScheduleTypeMonthly,
+	}
+	rsp := &intPkg.RoyaltyReportScheduleResponse{}
+	err = suite.service.SetRoyaltyReportSchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.RoyaltyReportScheduleTypeMonthly, rsp.Item.Type)
+	assert.True(suite.T(), nextPeriodFrom.Equal(rsp.Item.NextPeriodFrom))
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_SetRoyaltyReportSchedule_TypeInvalid_Error() {
+	req := &intPkg.RoyaltyReportSchedule{MerchantId: suite.merchant.Id, Type: "daily"}
+	rsp := &intPkg.RoyaltyReportScheduleResponse{}
+	err := suite.service.SetRoyaltyReportSchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportScheduleErrorTypeInvalid, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_SetRoyaltyReportSchedule_WeekDayInvalid_Error() {
+	req := &intPkg.RoyaltyReportSchedule{
+		MerchantId: suite.merchant.Id,
+		Type:       pkg.RoyaltyReportScheduleTypeWeekly,
+		WeekDay:    7,
+	}
+	rsp := &intPkg.RoyaltyReportScheduleResponse{}
+	err := suite.service.SetRoyaltyReportSchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportScheduleErrorWeekDayInvalid, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_SetRoyaltyReportSchedule_MerchantNotFound_Error() {
+	req := &intPkg.RoyaltyReportSchedule{
+		MerchantId: primitive.NewObjectID().Hex(),
+		Type:       pkg.RoyaltyReportScheduleTypeMonthly,
+	}
+	rsp := &intPkg.RoyaltyReportScheduleResponse{}
+	err := suite.service.SetRoyaltyReportSchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), merchantErrorNotFound, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_RoyaltyReportSchedulePeriods_Ok() {
+	date := time.Date(2021, time.March, 10, 15, 0, 0, 0, time.Local)
+
+	weekly := &intPkg.RoyaltyReportSchedule{Type: pkg.RoyaltyReportScheduleTypeWeekly, WeekDay: int32(time.Thursday)}
+	assert.Equal(suite.T(), time.Date(2021, time.March, 4, 0, 0, 0, 0, time.Local), getRoyaltyReportSchedulePeriodStart(weekly, date))
+	assert.Equal(suite.T(), time.Date(2021, time.March, 11, 0, 0, 0, 0, time.Local), getRoyaltyReportSchedulePeriodEnd(weekly, date))
+
+	biWeekly := &intPkg.RoyaltyReportSchedule{
+		Type:       pkg.RoyaltyReportScheduleTypeBiWeekly,
+		WeekDay:    int32(time.Monday),
+		AnchorDate: time.Date(2021, time.February, 1, 0, 0, 0, 0, time.Local),
+	}
+	assert.Equal(suite.T(), time.Date(2021, time.March, 1, 0, 0, 0, 0, time.Local), getRoyaltyReportSchedulePeriodStart(biWeekly, date))
+	assert.Equal(suite.T(), time.Date(2021, time.March, 15, 0, 0, 0, 0, time.Local), getRoyaltyReportSchedulePeriodEnd(biWeekly, date))
+
+	monthly := &intPkg.RoyaltyReportSchedule{Type: pkg.RoyaltyReportScheduleTypeMonthly}
+	assert.Equal(suite.T(), time.Date(2021, time.March, 1, 0, 0, 0, 0, time.Local), getRoyaltyReportSchedulePeriodStart(monthly, date))
+	assert.Equal(suite.T(), time.Date(2021, time.April, 1, 0, 0, 0, 0, time.Local), getRoyaltyReportSchedulePeriodEnd(monthly, date))
+
+	// first period after change of the schedule ends on the nearest boundary of the new schedule
+	from := time.Date(2021, time.March, 8, 0, 0, 0, 0, time.Local)
+	assert.Equal(suite.T(), time.Date(2021, time.April, 1, 0, 0, 0, 0, time.Local), getRoyaltyReportSchedulePeriodEnd(monthly, from))
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_CreateRoyaltyReport_Scheduled_Ok() {
+	for i := 0; i < 5; i++ {
+		suite.createOrder(suite.project)
+	}
+	err := suite.service.updateOrderView(context.TODO(), []string{})
+	assert.NoError(suite.T(), err)
+
+	lastWeek := now.Monday().AddDate(0, 0, -7)
+	schedule := &intPkg.RoyaltyReportSchedule{
+		MerchantId:     suite.merchant.Id,
+		Type:           pkg.RoyaltyReportScheduleTypeWeekly,
+		WeekDay:        int32(time.Monday),
+		AnchorDate:     lastWeek,
+		NextPeriodFrom: lastWeek.AddDate(0, 0, -7),
+	}
+	err = suite.service.royaltyReportScheduleRepository.Upsert(context.TODO(), schedule)
+	assert.NoError(suite.T(), err)
+
+	req := &billingpb.CreateRoyaltyReportRequest{Merchants: []string{suite.merchant.Id}}
+	rsp := &billingpb.CreateRoyaltyReportRequest{}
+	err = suite.service.CreateRoyaltyReport(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{suite.merchant.Id}, rsp.Merchants)
+
+	// the week without transactions is skipped, the report is generated for the last week only
+	reports, err := suite.service.royaltyReportRepository.GetAll(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), reports, 1)
+
+	periodFrom, err := ptypes.Timestamp(reports[0].PeriodFrom)
+	assert.NoError(suite.T(), err)
+	periodTo, err := ptypes.Timestamp(reports[0].PeriodTo)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), lastWeek.Equal(periodFrom))
+	assert.True(suite.T(), now.Monday().Add(-1*time.Millisecond).Equal(periodTo))
+
+	schedule, err = suite.service.royaltyReportScheduleRepository.GetByMerchantId(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), now.Monday().Equal(schedule.NextPeriodFrom))
+
+	// the next period isn't ended yet, so nothing is due
+	rsp = &billingpb.CreateRoyaltyReportRequest{}
+	err = suite.service.CreateRoyaltyReport(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), rsp.Merchants)
+
+	reports, err = suite.service.royaltyReportRepository.GetAll(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), reports, 1)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_CreateRoyaltyReport_ScheduleContinuesLastReport_Ok() {
+	for i := 0; i < 5; i++ {
+		suite.createOrder(suite.project)
+	}
+	err := suite.service.updateOrderView(context.TODO(), []string{})
+	assert.NoError(suite.T(), err)
+
+	req := &billingpb.CreateRoyaltyReportRequest{Merchants: []string{suite.merchant.Id}}
+	rsp := &billingpb.CreateRoyaltyReportRequest{}
+	err = suite.service.CreateRoyaltyReport(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), rsp.Merchants)
+
+	schedule := &intPkg.RoyaltyReportSchedule{
+		MerchantId: suite.merchant.Id,
+		Type:       pkg.RoyaltyReportScheduleTypeMonthly,
+	}
+	from, err := suite.service.getRoyaltyReportScheduleFrom(context.TODO(), schedule, suite.merchant, time.Now())
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), now.Monday().Equal(from))
+}
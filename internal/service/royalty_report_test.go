@@ -1166,7 +1166,7 @@ func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_CreateRoyaltyReport_Ok_Me
 		Reason:     "unit test",
 	}
 	rsp := &billingpb.CreateAccountingEntryResponse{}
-	err = suite.service.CreateAccountingEntry(context.TODO(), req, rsp)
+	err = suite.service.createAccountingEntry(context.TODO(), req, rsp)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
 	assert.Empty(suite.T(), rsp.Message)
@@ -1181,7 +1181,7 @@ func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_CreateRoyaltyReport_Ok_Me
 		Date:       entryDate.Unix(),
 		Reason:     "unit test",
 	}
-	err = suite.service.CreateAccountingEntry(context.TODO(), req, rsp)
+	err = suite.service.createAccountingEntry(context.TODO(), req, rsp)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
 	assert.Empty(suite.T(), rsp.Message)
@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/jinzhu/now"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	errors2 "github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.uber.org/zap"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	salesTaxNexusCurrency = "USD"
+
+	// Part of the threshold after which finance is alerted that the threshold is approached
+	salesTaxNexusApproachingRatio = 0.8
+)
+
+var (
+	salesTaxNexusErrorUnknown = errors2.NewBillingServerErrorMsg("sn000001", "unknown error. try request later")
+
+	errorSalesTaxNexusTrackingFailed = errors.New("errors occurred while tracking sales tax nexus")
+
+	salesTaxNexusStatusRanks = map[string]int{
+		"":                                 0,
+		pkg.SalesTaxNexusStatusBelow:       0,
+		pkg.SalesTaxNexusStatusApproaching: 1,
+		pkg.SalesTaxNexusStatusCrossed:     2,
+	}
+
+	// Economic nexus thresholds of US states with sales tax for remote sellers
+	salesTaxNexusThresholds = map[string]*intPkg.SalesTaxNexusThreshold{
+		"AL": {SalesAmount: 250000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"AR": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"AZ": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"CA": {SalesAmount: 500000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"CO": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"CT": {SalesAmount: 100000, TransactionsCount: 200, RequireBoth: true, Window: pkg.SalesTaxNexusWindowRolling12Months},
+		"DC": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"FL": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"GA": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"HI": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"IA": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"ID": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"IL": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowRolling12Months},
+		"IN": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"KS": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"KY": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"LA": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"MA": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"MD": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"ME": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"MI": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"MN": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowRolling12Months},
+		"MO": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowRolling12Months},
+		"MS": {SalesAmount: 250000, Window: pkg.SalesTaxNexusWindowRolling12Months},
+		"NC": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"ND": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"NE": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"NJ": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"NM": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"NV": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"NY": {SalesAmount: 500000, TransactionsCount: 100, RequireBoth: true, Window: pkg.SalesTaxNexusWindowRolling12Months},
+		"OH": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"OK": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"PA": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"RI": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"SC": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"SD": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"TN": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowRolling12Months},
+		"TX": {SalesAmount: 500000, Window: pkg.SalesTaxNexusWindowRolling12Months},
+		"UT": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"VA": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"VT": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowRolling12Months},
+		"WA": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"WI": {SalesAmount: 100000, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"WV": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+		"WY": {SalesAmount: 100000, TransactionsCount: 200, Window: pkg.SalesTaxNexusWindowCalendarYear},
+	}
+)
+
+// salesTaxNexusWindow is the period of sales checked against thresholds with sales of states in the period.
+type salesTaxNexusWindow struct {
+	name  string
+	from  time.Time
+	to    time.Time
+	sales map[string]*intPkg.SalesTaxNexusQueryResItem
+}
+
+// GetSalesTaxNexusReport returns US states where sales of the operating company reached the economic nexus
+// threshold on the last run of the tracking task.
+func (s *Service) GetSalesTaxNexusReport(
+	ctx context.Context,
+	req *intPkg.GetSalesTaxNexusReportRequest,
+	rsp *intPkg.SalesTaxNexusReportResponse,
+) error {
+	if !s.operatingCompanyRepository.Exists(ctx, req.OperatingCompanyId) {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = errorOperatingCompanyNotFound
+		return nil
+	}
+
+	status := req.Status
+
+	if len(status) == 0 {
+		status = []string{pkg.SalesTaxNexusStatusCrossed}
+	}
+
+	items, err := s.salesTaxNexusRepository.Find(ctx, req.OperatingCompanyId, status)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = salesTaxNexusErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Items = items
+
+	return nil
+}
+
+// TrackSalesTaxNexus updates trackers of US sales tax nexus of all operating companies by sales at the end
+// of the date and alerts finance through the financier channel of Centrifugo when a state threshold is approached
+// or crossed. Failure of an operating company or a state doesn't stop tracking of others, the error is returned
+// after all of them are processed.
+func (s *Service) TrackSalesTaxNexus(ctx context.Context, date time.Time) error {
+	operatingCompanies, err := s.operatingCompanyRepository.GetAll(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	hasErrors := false
+
+	for _, oc := range operatingCompanies {
+		if err = s.trackSalesTaxNexus(ctx, oc.Id, date); err != nil {
+			zap.L().Error(
+				"sales tax nexus tracking failed",
+				zap.Error(err),
+				zap.String("operating_company_id", oc.Id),
+			)
+			hasErrors = true
+		}
+	}
+
+	if hasErrors {
+		return errorSalesTaxNexusTrackingFailed
+	}
+
+	return nil
+}
+
+func (s *Service) trackSalesTaxNexus(ctx context.Context, operatingCompanyId string, date time.Time) error {
+	date = now.New(date).EndOfDay()
+	year := now.New(date).BeginningOfYear()
+	windows := map[string]*salesTaxNexusWindow{
+		pkg.SalesTaxNexusWindowCalendarYear: {
+			from: year,
+			to:   date,
+		},
+		pkg.SalesTaxNexusWindowPreviousYear: {
+			from: year.AddDate(-1, 0, 0),
+			to:   now.New(year.AddDate(-1, 0, 0)).EndOfYear(),
+		},
+		pkg.SalesTaxNexusWindowRolling12Months: {
+			from: date.AddDate(-1, 0, 0),
+			to:   date,
+		},
+	}
+
+	for name, window := range windows {
+		items, err := s.orderViewRepository.GetSalesTaxNexusSummary(
+			ctx,
+			operatingCompanyId,
+			CountryCodeUSA,
+			salesTaxNexusCurrency,
+			window.from,
+			window.to,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		window.name = name
+		window.sales = make(map[string]*intPkg.SalesTaxNexusQueryResItem)
+
+		for _, item := range items {
+			window.sales[item.Id] = item
+		}
+	}
+
+	trackers, err := s.salesTaxNexusRepository.Find(ctx, operatingCompanyId, nil)
+
+	if err != nil {
+		return err
+	}
+
+	index := make(map[string]*intPkg.SalesTaxNexus)
+
+	for _, tracker := range trackers {
+		index[tracker.State] = tracker
+	}
+
+	var states []string
+
+	for state := range salesTaxNexusThresholds {
+		states = append(states, state)
+	}
+
+	sort.Strings(states)
+	hasErrors := false
+
+	for _, state := range states {
+		threshold := salesTaxNexusThresholds[state]
+		candidates := []*salesTaxNexusWindow{windows[threshold.Window]}
+
+		if threshold.Window == pkg.SalesTaxNexusWindowCalendarYear {
+			candidates = append(candidates, windows[pkg.SalesTaxNexusWindowPreviousYear])
+		}
+
+		var window *salesTaxNexusWindow
+		var sales *intPkg.SalesTaxNexusQueryResItem
+		ratio := float64(-1)
+
+		for _, candidate := range candidates {
+			item, ok := candidate.sales[state]
+
+			if !ok {
+				item = &intPkg.SalesTaxNexusQueryResItem{Id: state}
+			}
+
+			if v := getSalesTaxNexusRatio(threshold, item); v > ratio {
+				window, sales, ratio = candidate, item, v
+			}
+		}
+
+		tracker, ok := index[state]
+
+		if !ok && sales.Count <= 0 {
+			continue
+		}
+
+		if !ok {
+			tracker = &intPkg.SalesTaxNexus{
+				OperatingCompanyId: operatingCompanyId,
+				Country:            CountryCodeUSA,
+				State:              state,
+			}
+		}
+
+		previousStatus := tracker.Status
+
+		tracker.Window = window.name
+		tracker.WindowFrom = window.from
+		tracker.WindowTo = window.to
+		tracker.Currency = salesTaxNexusCurrency
+		tracker.SalesAmount = tools.FormatAmount(sales.Amount)
+		tracker.TransactionsCount = sales.Count
+		tracker.ThresholdSalesAmount = threshold.SalesAmount
+		tracker.ThresholdTransactionsCount = threshold.TransactionsCount
+		tracker.ThresholdRequireBoth = threshold.RequireBoth
+		tracker.Status = getSalesTaxNexusStatus(ratio)
+
+		if tracker.Status != pkg.SalesTaxNexusStatusCrossed {
+			tracker.NexusEstablishedAt = time.Time{}
+		} else if tracker.NexusEstablishedAt.IsZero() {
+			tracker.NexusEstablishedAt = date
+		}
+
+		// Finance is alerted before the status is stored, so the alert is sent again by the next run
+		// if publishing fails.
+		if salesTaxNexusStatusRanks[tracker.Status] > salesTaxNexusStatusRanks[previousStatus] {
+			zap.L().Info(
+				"sales tax nexus threshold reached",
+				zap.String("operating_company_id", operatingCompanyId),
+				zap.String("state", state),
+				zap.String("status", tracker.Status),
+			)
+
+			err = s.centrifugoDashboard.Publish(ctx, s.cfg.CentrifugoFinancierChannel, tracker)
+
+			if err != nil {
+				zap.L().Error(
+					"sales tax nexus alert publishing failed",
+					zap.Error(err),
+					zap.String("operating_company_id", operatingCompanyId),
+					zap.String("state", state),
+				)
+				hasErrors = true
+				continue
+			}
+		}
+
+		if err = s.salesTaxNexusRepository.Upsert(ctx, tracker); err != nil {
+			hasErrors = true
+			continue
+		}
+	}
+
+	if hasErrors {
+		return errorSalesTaxNexusTrackingFailed
+	}
+
+	return nil
+}
+
+// getSalesTaxNexusRatio returns the part of the threshold reached by sales. Sales amount and count of transactions
+// are compared separately, the lower part is returned if both must reach the threshold and the higher one otherwise.
+func getSalesTaxNexusRatio(threshold *intPkg.SalesTaxNexusThreshold, sales *intPkg.SalesTaxNexusQueryResItem) float64 {
+	ratio := sales.Amount / threshold.SalesAmount
+
+	if threshold.TransactionsCount <= 0 {
+		return ratio
+	}
+
+	countRatio := float64(sales.Count) / float64(threshold.TransactionsCount)
+
+	if threshold.RequireBoth {
+		return math.Min(ratio, countRatio)
+	}
+
+	return math.Max(ratio, countRatio)
+}
+
+func getSalesTaxNexusStatus(ratio float64) string {
+	if ratio >= 1 {
+		return pkg.SalesTaxNexusStatusCrossed
+	}
+
+	if ratio >= salesTaxNexusApproachingRatio {
+		return pkg.SalesTaxNexusStatusApproaching
+	}
+
+	return pkg.SalesTaxNexusStatusBelow
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+func (suite *TurnoversTestSuite) TestSalesTaxNexus_TrackSalesTaxNexus_Ok() {
+	items := []*intPkg.SalesTaxNexusQueryResItem{
+		{Id: "CA", Amount: 450000, Count: 10},
+		{Id: "TX", Amount: 600000, Count: 20},
+		{Id: "NY", Amount: 600000, Count: 50},
+		{Id: "WA", Amount: 1000, Count: 5},
+		{Id: "XX", Amount: 1000000, Count: 5000},
+	}
+	suite.helperSalesTaxNexusOrderViewMock(func(from, to time.Time) []*intPkg.SalesTaxNexusQueryResItem {
+		return items
+	})
+	centrifugoMock := suite.helperSalesTaxNexusCentrifugoMock()
+	date := time.Now()
+
+	err := suite.service.TrackSalesTaxNexus(context.TODO(), date)
+	assert.NoError(suite.T(), err)
+
+	trackers, err := suite.service.salesTaxNexusRepository.Find(context.TODO(), suite.operatingCompany.Id, nil)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), trackers, 4)
+
+	assert.Equal(suite.T(), "CA", trackers[0].State)
+	assert.Equal(suite.T(), pkg.SalesTaxNexusStatusApproaching, trackers[0].Status)
+	assert.True(suite.T(), trackers[0].NexusEstablishedAt.IsZero())
+	assert.Equal(suite.T(), "NY", trackers[1].State)
+	assert.Equal(suite.T(), pkg.SalesTaxNexusStatusBelow, trackers[1].Status)
+	assert.Equal(suite.T(), "TX", trackers[2].State)
+	assert.Equal(suite.T(), pkg.SalesTaxNexusStatusCrossed, trackers[2].Status)
+	assert.Equal(suite.T(), pkg.SalesTaxNexusWindowRolling12Months, trackers[2].Window)
+	assert.Equal(suite.T(), 600000.0, trackers[2].SalesAmount)
+	assert.Equal(suite.T(), 500000.0, trackers[2].ThresholdSalesAmount)
+	assert.Equal(suite.T(), CountryCodeUSA, trackers[2].Country)
+	assert.False(suite.T(), trackers[2].NexusEstablishedAt.IsZero())
+	assert.Equal(suite.T(), "WA", trackers[3].State)
+	assert.Equal(suite.T(), pkg.SalesTaxNexusStatusBelow, trackers[3].Status)
+
+	centrifugoMock.AssertNumberOfCalls(suite.T(), "Publish", 2)
+	assert.Equal(suite.T(), suite.service.cfg.CentrifugoFinancierChannel, centrifugoMock.Calls[0].Arguments.Get(1))
+
+	establishedAt := trackers[2].NexusEstablishedAt
+
+	err = suite.service.TrackSalesTaxNexus(context.TODO(), date.AddDate(0, 0, 1))
+	assert.NoError(suite.T(), err)
+	centrifugoMock.AssertNumberOfCalls(suite.T(), "Publish", 2)
+
+	trackers, err = suite.service.salesTaxNexusRepository.Find(
+		context.TODO(),
+		suite.operatingCompany.Id,
+		[]string{pkg.SalesTaxNexusStatusCrossed},
+	)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), trackers, 1)
+	assert.Equal(suite.T(), establishedAt.Unix(), trackers[0].NexusEstablishedAt.Unix())
+}
+
+func (suite *TurnoversTestSuite) TestSalesTaxNexus_TrackSalesTaxNexus_PreviousCalendarYear() {
+	date := time.Now()
+
+	suite.helperSalesTaxNexusOrderViewMock(func(from, to time.Time) []*intPkg.SalesTaxNexusQueryResItem {
+		if to.Year() < date.Year() {
+			return []*intPkg.SalesTaxNexusQueryResItem{
+				{Id: "CO", Amount: 150000, Count: 100},
+				{Id: "TX", Amount: 600000, Count: 100},
+			}
+		}
+
+		return []*intPkg.SalesTaxNexusQueryResItem{
+			{Id: "CO", Amount: 10000, Count: 10},
+		}
+	})
+	suite.helperSalesTaxNexusCentrifugoMock()
+
+	err := suite.service.TrackSalesTaxNexus(context.TODO(), date)
+	assert.NoError(suite.T(), err)
+
+	trackers, err := suite.service.salesTaxNexusRepository.Find(context.TODO(), suite.operatingCompany.Id, nil)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), trackers, 1)
+	assert.Equal(suite.T(), "CO", trackers[0].State)
+	assert.Equal(suite.T(), pkg.SalesTaxNexusStatusCrossed, trackers[0].Status)
+	assert.Equal(suite.T(), pkg.SalesTaxNexusWindowPreviousYear, trackers[0].Window)
+	assert.Equal(suite.T(), 150000.0, trackers[0].SalesAmount)
+	assert.Equal(suite.T(), date.Year()-1, trackers[0].WindowFrom.Year())
+}
+
+func (suite *TurnoversTestSuite) TestSalesTaxNexus_TrackSalesTaxNexus_NexusLost() {
+	amount := float64(150000)
+
+	suite.helperSalesTaxNexusOrderViewMock(func(from, to time.Time) []*intPkg.SalesTaxNexusQueryResItem {
+		return []*intPkg.SalesTaxNexusQueryResItem{{Id: "WA", Amount: amount, Count: 10}}
+	})
+	centrifugoMock := suite.helperSalesTaxNexusCentrifugoMock()
+
+	err := suite.service.TrackSalesTaxNexus(context.TODO(), time.Now())
+	assert.NoError(suite.T(), err)
+	centrifugoMock.AssertNumberOfCalls(suite.T(), "Publish", 1)
+
+	amount = 1000
+	err = suite.service.TrackSalesTaxNexus(context.TODO(), time.Now())
+	assert.NoError(suite.T(), err)
+	centrifugoMock.AssertNumberOfCalls(suite.T(), "Publish", 1)
+
+	trackers, err := suite.service.salesTaxNexusRepository.Find(context.TODO(), suite.operatingCompany.Id, nil)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), trackers, 1)
+	assert.Equal(suite.T(), pkg.SalesTaxNexusStatusBelow, trackers[0].Status)
+	assert.True(suite.T(), trackers[0].NexusEstablishedAt.IsZero())
+}
+
+func (suite *TurnoversTestSuite) TestSalesTaxNexus_TrackSalesTaxNexus_PublishFailed() {
+	suite.helperSalesTaxNexusOrderViewMock(func(from, to time.Time) []*intPkg.SalesTaxNexusQueryResItem {
+		return []*intPkg.SalesTaxNexusQueryResItem{
+			{Id: "TX", Amount: 600000, Count: 20},
+			{Id: "WA", Amount: 1000, Count: 5},
+		}
+	})
+	centrifugoMock := &mocks.CentrifugoInterface{}
+	centrifugoMock.On("Publish", mock2.Anything, mock2.Anything, mock2.Anything).Return(errors.New("some error"))
+	suite.service.centrifugoDashboard = centrifugoMock
+
+	err := suite.service.TrackSalesTaxNexus(context.TODO(), time.Now())
+	assert.Equal(suite.T(), errorSalesTaxNexusTrackingFailed, err)
+
+	trackers, err := suite.service.salesTaxNexusRepository.Find(context.TODO(), suite.operatingCompany.Id, nil)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), trackers, 1)
+	assert.Equal(suite.T(), "WA", trackers[0].State)
+
+	centrifugoMock = suite.helperSalesTaxNexusCentrifugoMock()
+
+	err = suite.service.TrackSalesTaxNexus(context.TODO(), time.Now())
+	assert.NoError(suite.T(), err)
+	centrifugoMock.AssertNumberOfCalls(suite.T(), "Publish", 1)
+
+	trackers, err = suite.service.salesTaxNexusRepository.Find(
+		context.TODO(),
+		suite.operatingCompany.Id,
+		[]string{pkg.SalesTaxNexusStatusCrossed},
+	)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), trackers, 1)
+	assert.Equal(suite.T(), "TX", trackers[0].State)
+}
+
+func (suite *TurnoversTestSuite) TestSalesTaxNexus_GetSalesTaxNexusReport_Ok() {
+	suite.helperSalesTaxNexusOrderViewMock(func(from, to time.Time) []*intPkg.SalesTaxNexusQueryResItem {
+		return []*intPkg.SalesTaxNexusQueryResItem{
+			{Id: "CA", Amount: 450000, Count: 10},
+			{Id: "TX", Amount: 600000, Count: 20},
+			{Id: "WA", Amount: 1000, Count: 5},
+		}
+	})
+	suite.helperSalesTaxNexusCentrifugoMock()
+
+	err := suite.service.TrackSalesTaxNexus(context.TODO(), time.Now())
+	assert.NoError(suite.T(), err)
+
+	req := &intPkg.GetSalesTaxNexusReportRequest{OperatingCompanyId: suite.operatingCompany.Id}
+	rsp := &intPkg.SalesTaxNexusReportResponse{}
+	err = suite.service.GetSalesTaxNexusReport(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 1)
+	assert.Equal(suite.T(), "TX", rsp.Items[0].State)
+
+	req.Status = []string{pkg.SalesTaxNexusStatusCrossed, pkg.SalesTaxNexusStatusApproaching}
+	rsp = &intPkg.SalesTaxNexusReportResponse{}
+	err = suite.service.GetSalesTaxNexusReport(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 2)
+	assert.Equal(suite.T(), "CA", rsp.Items[0].State)
+}
+
+func (suite *TurnoversTestSuite) TestSalesTaxNexus_GetSalesTaxNexusReport_OperatingCompanyNotFound_Error() {
+	req := &intPkg.GetSalesTaxNexusReportRequest{OperatingCompanyId: primitive.NewObjectID().Hex()}
+	rsp := &intPkg.SalesTaxNexusReportResponse{}
+	err := suite.service.GetSalesTaxNexusReport(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), errorOperatingCompanyNotFound, rsp.Message)
+}
+
+func (suite *TurnoversTestSuite) TestSalesTaxNexus_getSalesTaxNexusRatio() {
+	threshold := &intPkg.SalesTaxNexusThreshold{SalesAmount: 100000, TransactionsCount: 200}
+	sales := &intPkg.SalesTaxNexusQueryResItem{Amount: 50000, Count: 200}
+	assert.Equal(suite.T(), 1.0, getSalesTaxNexusRatio(threshold, sales))
+
+	threshold.RequireBoth = true
+	assert.Equal(suite.T(), 0.5, getSalesTaxNexusRatio(threshold, sales))
+
+	threshold.TransactionsCount = 0
+	assert.Equal(suite.T(), 0.5, getSalesTaxNexusRatio(threshold, sales))
+
+	assert.Equal(suite.T(), pkg.SalesTaxNexusStatusBelow, getSalesTaxNexusStatus(0.5))
+	assert.Equal(suite.T(), pkg.SalesTaxNexusStatusApproaching, getSalesTaxNexusStatus(0.8))
+	assert.Equal(suite.T(), pkg.SalesTaxNexusStatusCrossed, getSalesTaxNexusStatus(1))
+}
+
+func (suite *TurnoversTestSuite) helperSalesTaxNexusOrderViewMock(
+	fn func(from, to time.Time) []*intPkg.SalesTaxNexusQueryResItem,
+) {
+	orderViewMock := &mocks.OrderViewRepositoryInterface{}
+	orderViewMock.
+		On("GetSalesTaxNexusSummary", mock2.Anything, suite.operatingCompany.Id, CountryCodeUSA, "USD", mock2.Anything, mock2.Anything).
+		Return(
+			func(_ context.Context, _, _, _ string, from, to time.Time) []*intPkg.SalesTaxNexusQueryResItem {
+				return fn(from, to)
+			},
+			nil,
+		)
+	orderViewMock.
+		On("GetSalesTaxNexusSummary", mock2.Anything, mock2.Anything, CountryCodeUSA, "USD", mock2.Anything, mock2.Anything).
+		Return(nil, nil)
+	suite.service.orderViewRepository = orderViewMock
+}
+
+func (suite *TurnoversTestSuite) helperSalesTaxNexusCentrifugoMock() *mocks.CentrifugoInterface {
+	centrifugoMock := &mocks.CentrifugoInterface{}
+	centrifugoMock.On("Publish", mock2.Anything, mock2.Anything, mock2.Anything).Return(nil)
+	suite.service.centrifugoDashboard = centrifugoMock
+
+	return centrifugoMock
+}
@@ -0,0 +1,470 @@
+package service
+
+import (
+	"context"
+	"github.com/jinzhu/now"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/internal/repository"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/recurringpb"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"time"
+)
+
+var (
+	savedCardChargeErrorNotAllowed           = errors.NewBillingServerErrorMsg("sc000001", "charges of saved cards are not allowed for the project")
+	savedCardChargeErrorMitNotAllowed        = errors.NewBillingServerErrorMsg("sc000002", "merchant-initiated charges are not allowed for the project")
+	savedCardChargeErrorInitiatorInvalid     = errors.NewBillingServerErrorMsg("sc000003", "charge initiator is invalid")
+	savedCardChargeErrorCardNotFound         = errors.NewBillingServerErrorMsg("sc000004", "saved card not found")
+	savedCardChargeErrorCredentialNotFound   = errors.NewBillingServerErrorMsg("sc000005", "initial transaction of the saved card not found")
+	savedCardChargeErrorCurrencyNotAllowed   = errors.NewBillingServerErrorMsg("sc000006", "charge currency doesn't match currency of the customer limits")
+	savedCardChargeErrorDailyLimitExceeded   = errors.NewBillingServerErrorMsg("sc000007", "daily limit of charges of the customer exceeded")
+	savedCardChargeErrorMonthlyLimitExceeded = errors.NewBillingServerErrorMsg("sc000008", "monthly limit of charges of the customer exceeded")
+	savedCardChargeErrorPaymentMethod        = errors.NewBillingServerErrorMsg("sc000009", "bank card payment method isn't available for the order")
+	savedCardChargeErrorLimitsInvalid        = errors.NewBillingServerErrorMsg("sc000010", "currency is required when customer limits are set")
+	savedCardChargeErrorUnknown              = errors.NewBillingServerErrorMsg("sc000011", "unknown error")
+)
+
+// ChargeSavedCard charges the customer's saved card by server-to-server request of the merchant.
+// The order must be created by the merchant before the charge, so the payment is processed
+// the same way as payment from the payment form.
+func (s *Service) ChargeSavedCard(
+	ctx context.Context,
+	req *intPkg.ChargeSavedCardRequest,
+	rsp *intPkg.ChargeSavedCardResponse,
+) error {
+	if req.Initiator != pkg.PaymentInitiatorCustomer && req.Initiator != pkg.PaymentInitiatorMerchant {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = savedCardChargeErrorInitiatorInvalid
+		return nil
+	}
+
+	settings, err := s.savedCardChargeSettingsRepository.GetByProjectId(ctx, req.ProjectId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusForbidden
+		rsp.Message = savedCardChargeErrorNotAllowed
+
+		if err != mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = savedCardChargeErrorUnknown
+		}
+
+		return nil
+	}
+
+	if !settings.Enabled {
+		rsp.Status = billingpb.ResponseStatusForbidden
+		rsp.Message = savedCardChargeErrorNotAllowed
+		return nil
+	}
+
+	if req.Initiator == pkg.PaymentInitiatorMerchant && !settings.MitEnabled {
+		rsp.Status = billingpb.ResponseStatusForbidden
+		rsp.Message = savedCardChargeErrorMitNotAllowed
+		return nil
+	}
+
+	order, err := s.getOrderByUuidToForm(ctx, req.OrderId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = err.(*billingpb.ResponseErrorMessage)
+		return nil
+	}
+
+	if order.Project.Id != req.ProjectId {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = orderErrorNotFound
+		return nil
+	}
+
+	card, err := s.rep.FindSavedCardById(ctx, &recurringpb.FindByStringValue{Value: req.SavedCardId})
+
+	if err != nil || card == nil || card.ProjectId != req.ProjectId {
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorGrpcServiceCallFailed,
+				zap.Error(err),
+				zap.String(errorFieldService, recurringpb.PayOneRepositoryServiceName),
+				zap.String(errorFieldMethod, "FindSavedCardById"),
+				zap.String("saved_card_id", req.SavedCardId),
+			)
+		}
+
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = savedCardChargeErrorCardNotFound
+		return nil
+	}
+
+	customer, err := s.getCustomerById(ctx, card.Token)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = savedCardChargeErrorCardNotFound
+		return nil
+	}
+
+	charge := &intPkg.SavedCardCharge{
+		OrderId:     order.Id,
+		ProjectId:   req.ProjectId,
+		CustomerId:  customer.Id,
+		SavedCardId: req.SavedCardId,
+		Initiator:   req.Initiator,
+		Amount:      order.ChargeAmount,
+		Currency:    order.ChargeCurrency,
+		Status:      pkg.SavedCardChargeStatusPending,
+	}
+
+	if req.Initiator == pkg.PaymentInitiatorMerchant {
+		credential, err := s.storedCredentialRepository.GetByRecurringId(ctx, card.RecurringId)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = savedCardChargeErrorCredentialNotFound
+			return nil
+		}
+
+		charge.InitialTransactionId = credential.InitialTransactionId
+	}
+
+	if settings.HasCustomerLimits() && settings.Currency != charge.Currency {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = savedCardChargeErrorCurrencyNotAllowed
+		return nil
+	}
+
+	order.User.Id = customer.Id
+	order.User.Uuid = customer.Uuid
+	order.User.Email = customer.Email
+
+	if err = s.updateOrder(ctx, order); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = savedCardChargeErrorUnknown
+		return nil
+	}
+
+	paymentMethods, err := s.paymentMethodRepository.ListByOrder(ctx, order)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = savedCardChargeErrorUnknown
+		return nil
+	}
+
+	var paymentMethod *billingpb.PaymentMethod
+
+	for _, pm := range paymentMethods {
+		if pm.IsBankCard() {
+			paymentMethod = pm
+			break
+		}
+	}
+
+	if paymentMethod == nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = savedCardChargeErrorPaymentMethod
+		return nil
+	}
+
+	if msg := s.reserveSavedCardChargeLimits(ctx, settings, charge); msg != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = msg
+
+		if msg == savedCardChargeErrorUnknown {
+			rsp.Status = billingpb.ResponseStatusSystemError
+		}
+
+		return nil
+	}
+
+	// The charge is saved before the payment is sent to the payment system, so every charge sent to the payment
+	// system is counted in limits of the customer and its status is updated by the payment notification.
+	if err = s.savedCardChargeRepository.Insert(ctx, charge); err != nil {
+		s.releaseSavedCardChargeLimits(ctx, charge)
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = savedCardChargeErrorUnknown
+		return nil
+	}
+
+	paymentReq := &billingpb.PaymentCreateRequest{
+		Data: map[string]string{
+			billingpb.PaymentCreateFieldOrderId:         order.Uuid,
+			billingpb.PaymentCreateFieldPaymentMethodId: paymentMethod.Id,
+			billingpb.PaymentCreateFieldEmail:           customer.Email,
+			billingpb.PaymentCreateFieldStoredCardId:    req.SavedCardId,
+		},
+		Ip: req.Ip,
+	}
+	processor := &PaymentCreateProcessor{
+		service:              s,
+		data:                 paymentReq.Data,
+		ip:                   req.Ip,
+		initiator:            req.Initiator,
+		initialTransactionId: charge.InitialTransactionId,
+	}
+	paymentRsp := &billingpb.PaymentCreateResponse{}
+
+	// The charge stays pending on the system error as the payment may be sent to the payment system already.
+	if err = s.processPaymentCreate(ctx, processor, nil, paymentReq, paymentRsp); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = savedCardChargeErrorUnknown
+		return nil
+	}
+
+	if paymentRsp.Status != billingpb.ResponseStatusOk {
+		if err = s.setSavedCardChargeStatus(ctx, order.Id, pkg.SavedCardChargeStatusFailed); err != nil {
+			zap.L().Error(
+				"Unable to update status of the saved card charge",
+				zap.Error(err),
+				zap.String("order_id", order.Id),
+			)
+		}
+
+		rsp.Status = paymentRsp.Status
+		rsp.Message = paymentRsp.Message
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = charge
+
+	if paymentRsp.NeedRedirect {
+		rsp.RedirectUrl = paymentRsp.RedirectUrl
+	}
+
+	return nil
+}
+
+func (s *Service) GetSavedCardChargeSettings(
+	ctx context.Context,
+	req *intPkg.GetSavedCardChargeSettingsRequest,
+	rsp *intPkg.SavedCardChargeSettingsResponse,
+) error {
+	project, err := s.project.GetById(ctx, req.ProjectId)
+
+	if err != nil || project.MerchantId != req.MerchantId {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = projectErrorNotFound
+		return nil
+	}
+
+	settings, err := s.savedCardChargeSettingsRepository.GetByProjectId(ctx, req.ProjectId)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = savedCardChargeErrorUnknown
+			return nil
+		}
+
+		settings = &intPkg.SavedCardChargeSettings{
+			ProjectId:  project.Id,
+			MerchantId: project.MerchantId,
+		}
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = settings
+
+	return nil
+}
+
+func (s *Service) SetSavedCardChargeSettings(
+	ctx context.Context,
+	req *intPkg.SavedCardChargeSettings,
+	rsp *intPkg.SavedCardChargeSettingsResponse,
+) error {
+	project, err := s.project.GetById(ctx, req.ProjectId)
+
+	if err != nil || project.MerchantId != req.MerchantId {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = projectErrorNotFound
+		return nil
+	}
+
+	if req.HasCustomerLimits() && req.Currency == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = savedCardChargeErrorLimitsInvalid
+		return nil
+	}
+
+	settings, err := s.savedCardChargeSettingsRepository.GetByProjectId(ctx, req.ProjectId)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = savedCardChargeErrorUnknown
+			return nil
+		}
+
+		settings = &intPkg.SavedCardChargeSettings{ProjectId: project.Id, MerchantId: project.MerchantId}
+	}
+
+	settings.Enabled = req.Enabled
+	settings.MitEnabled = req.MitEnabled
+	settings.Currency = req.Currency
+	settings.CustomerDailyLimit = req.CustomerDailyLimit
+	settings.CustomerMonthlyLimit = req.CustomerMonthlyLimit
+
+	if err = s.savedCardChargeSettingsRepository.Upsert(ctx, settings); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = savedCardChargeErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = settings
+
+	return nil
+}
+
+// reserveSavedCardChargeLimits adds the amount of the charge to counters of charges of the customer
+// for the day and the month if the charge doesn't exceed limits of the project.
+func (s *Service) reserveSavedCardChargeLimits(
+	ctx context.Context,
+	settings *intPkg.SavedCardChargeSettings,
+	charge *intPkg.SavedCardCharge,
+) *billingpb.ResponseErrorMessage {
+	if !settings.HasCustomerLimits() {
+		return nil
+	}
+
+	if settings.Currency != charge.Currency {
+		return savedCardChargeErrorCurrencyNotAllowed
+	}
+
+	current := now.New(time.Now().UTC())
+	limits := []struct {
+		limit  float64
+		period string
+		from   time.Time
+		msg    *billingpb.ResponseErrorMessage
+	}{
+		{
+			settings.CustomerDailyLimit,
+			pkg.SavedCardChargeLimitPeriodDay,
+			current.BeginningOfDay(),
+			savedCardChargeErrorDailyLimitExceeded,
+		},
+		{
+			settings.CustomerMonthlyLimit,
+			pkg.SavedCardChargeLimitPeriodMonth,
+			current.BeginningOfMonth(),
+			savedCardChargeErrorMonthlyLimitExceeded,
+		},
+	}
+
+	for _, v := range limits {
+		if v.limit <= 0 {
+			continue
+		}
+
+		counter := &intPkg.SavedCardChargeCounter{
+			ProjectId:  charge.ProjectId,
+			CustomerId: charge.CustomerId,
+			Currency:   charge.Currency,
+			Period:     v.period,
+			PeriodFrom: v.from,
+		}
+		err := s.savedCardChargeRepository.ReserveCustomerAmount(ctx, counter, charge.Amount, v.limit)
+
+		if err != nil {
+			s.releaseSavedCardChargeLimits(ctx, charge)
+			charge.CounterIds = nil
+
+			if err == repository.ErrSavedCardChargeLimitExceeded {
+				return v.msg
+			}
+
+			return savedCardChargeErrorUnknown
+		}
+
+		charge.CounterIds = append(charge.CounterIds, counter.Id)
+	}
+
+	return nil
+}
+
+func (s *Service) releaseSavedCardChargeLimits(ctx context.Context, charge *intPkg.SavedCardCharge) {
+	if len(charge.CounterIds) <= 0 {
+		return
+	}
+
+	err := s.savedCardChargeRepository.ReleaseCustomerAmount(ctx, charge.CounterIds, charge.Amount)
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to release amount of the saved card charge from limits of the customer",
+			zap.Error(err),
+			zap.String("order_id", charge.OrderId),
+		)
+	}
+}
+
+// setSavedCardChargeStatus finishes the pending charge of the order, the amount of the failed charge
+// is returned to limits of the customer.
+func (s *Service) setSavedCardChargeStatus(ctx context.Context, orderId, status string) error {
+	charge, err := s.savedCardChargeRepository.UpdateStatusByOrderId(ctx, orderId, status)
+
+	if err != nil {
+		return err
+	}
+
+	if status == pkg.SavedCardChargeStatusFailed {
+		s.releaseSavedCardChargeLimits(ctx, charge)
+	}
+
+	return nil
+}
+
+// storeInitialCredential saves reference to the initial transaction of the saved card
+// which is required by card schemes for the following merchant-initiated charges.
+func (s *Service) storeInitialCredential(ctx context.Context, order *billingpb.Order, recurringId string) {
+	credential := &intPkg.StoredCredential{
+		RecurringId:          recurringId,
+		CustomerId:           order.User.Id,
+		ProjectId:            order.Project.Id,
+		InitialOrderId:       order.Id,
+		InitialTransactionId: order.Transaction,
+	}
+
+	if err := s.storedCredentialRepository.Insert(ctx, credential); err != nil {
+		zap.L().Error(
+			"Unable to save initial transaction of the saved card",
+			zap.Error(err),
+			zap.String("order_id", order.Id),
+		)
+	}
+}
+
+func (s *Service) updateSavedCardChargeStatus(ctx context.Context, order *billingpb.Order) {
+	if _, ok := order.PaymentRequisites[pkg.PaymentCreateFieldInitiator]; !ok {
+		return
+	}
+
+	var status string
+
+	switch order.PrivateStatus {
+	case recurringpb.OrderStatusPaymentSystemComplete:
+		status = pkg.SavedCardChargeStatusProcessed
+	case recurringpb.OrderStatusPaymentSystemDeclined,
+		recurringpb.OrderStatusPaymentSystemReject,
+		recurringpb.OrderStatusPaymentSystemCanceled:
+		status = pkg.SavedCardChargeStatusFailed
+	default:
+		return
+	}
+
+	// The charge isn't found when it's already finished by the previous notification about the payment.
+	if err := s.setSavedCardChargeStatus(ctx, order.Id, status); err != nil && err != mongo.ErrNoDocuments {
+		zap.L().Error(
+			"Unable to update status of the saved card charge",
+			zap.Error(err),
+			zap.String("order_id", order.Id),
+		)
+	}
+}
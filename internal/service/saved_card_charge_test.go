@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	"github.com/paysuper/paysuper-billing-server/internal/database"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	casbinMocks "github.com/paysuper/paysuper-proto/go/casbinpb/mocks"
+	"github.com/paysuper/paysuper-proto/go/recurringpb"
+	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"testing"
+)
+
+type SavedCardChargeTestSuite struct {
+	suite.Suite
+	service *Service
+	project *billingpb.Project
+}
+
+func Test_SavedCardCharge(t *testing.T) {
+	suite.Run(t, new(SavedCardChargeTestSuite))
+}
+
+func (suite *SavedCardChargeTestSuite) SetupTest() {
+	cfg, err := config.NewConfig()
+	assert.NoError(suite.T(), err, "Config load failed")
+
+	db, err := mongodb.NewDatabase()
+	assert.NoError(suite.T(), err, "Database connection failed")
+
+	redisdb := mocks.NewTestRedis()
+	cache, err := database.NewCacheRedis(redisdb, "cache")
+
+	if err != nil {
+		suite.FailNow("Cache redis initialize failed", "%v", err)
+	}
+
+	suite.service = NewBillingService(
+		db,
+		cfg,
+		mocks.NewGeoIpServiceTestOk(),
+		mocks.NewRepositoryServiceOk(),
+		&mocks.TaxServiceOkMock{},
+		mocks.NewBrokerMockOk(),
+		nil,
+		cache,
+		mocks.NewCurrencyServiceMockOk(),
+		mocks.NewDocumentSignerMockOk(),
+		&reportingMocks.ReporterService{},
+		mocks.NewFormatterOK(),
+		mocks.NewBrokerMockOk(),
+		&casbinMocks.CasbinService{},
+		mocks.NewNotifierOk(),
+		mocks.NewBrokerMockOk(),
+	)
+
+	if err := suite.service.Init(); err != nil {
+		suite.FailNow("Billing service initialization failed", "%v", err)
+	}
+
+	suite.project = &billingpb.Project{
+		Id:         primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+	}
+
+	if err := suite.service.project.Insert(context.TODO(), suite.project); err != nil {
+		suite.FailNow("Insert project test data failed", "%v", err)
+	}
+}
+
+func (suite *SavedCardChargeTestSuite) TearDownTest() {
+	err := suite.service.db.Drop()
+
+	if err != nil {
+		suite.FailNow("Database deletion failed", "%v", err)
+	}
+
+	err = suite.service.db.Close()
+
+	if err != nil {
+		suite.FailNow("Database close failed", "%v", err)
+	}
+}
+
+func (suite *SavedCardChargeTestSuite) setSettings(enabled, mitEnabled bool, dailyLimit, monthlyLimit float64) {
+	req := &intPkg.SavedCardChargeSettings{
+		ProjectId:            suite.project.Id,
+		MerchantId:           suite.project.MerchantId,
+		Enabled:              enabled,
+		MitEnabled:           mitEnabled,
+		Currency:             "USD",
+		CustomerDailyLimit:   dailyLimit,
+		CustomerMonthlyLimit: monthlyLimit,
+	}
+	rsp := &intPkg.SavedCardChargeSettingsResponse{}
+	err := suite.service.SetSavedCardChargeSettings(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_GetSavedCardChargeSettings_Default_Ok() {
+	req := &intPkg.GetSavedCardChargeSettingsRequest{ProjectId: suite.project.Id, MerchantId: suite.project.MerchantId}
+	rsp := &intPkg.SavedCardChargeSettingsResponse{}
+	err := suite.service.GetSavedCardChargeSettings(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.False(suite.T(), rsp.Item.Enabled)
+	assert.False(suite.T(), rsp.Item.MitEnabled)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_SetSavedCardChargeSettings_Ok() {
+	suite.setSettings(true, false, 100, 0)
+	suite.setSettings(true, true, 100, 1000)
+
+	req := &intPkg.GetSavedCardChargeSettingsRequest{ProjectId: suite.project.Id, MerchantId: suite.project.MerchantId}
+	rsp := &intPkg.SavedCardChargeSettingsResponse{}
+	err := suite.service.GetSavedCardChargeSettings(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.True(suite.T(), rsp.Item.Enabled)
+	assert.True(suite.T(), rsp.Item.MitEnabled)
+	assert.EqualValues(suite.T(), 1000, rsp.Item.CustomerMonthlyLimit)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_SetSavedCardChargeSettings_ProjectNotOwn_Error() {
+	req := &intPkg.SavedCardChargeSettings{
+		ProjectId:  suite.project.Id,
+		MerchantId: primitive.NewObjectID().Hex(),
+		Enabled:    true,
+	}
+	rsp := &intPkg.SavedCardChargeSettingsResponse{}
+	err := suite.service.SetSavedCardChargeSettings(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), projectErrorNotFound, rsp.Message)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_SetSavedCardChargeSettings_LimitsWithoutCurrency_Error() {
+	req := &intPkg.SavedCardChargeSettings{
+		ProjectId:          suite.project.Id,
+		MerchantId:         suite.project.MerchantId,
+		Enabled:            true,
+		CustomerDailyLimit: 100,
+	}
+	rsp := &intPkg.SavedCardChargeSettingsResponse{}
+	err := suite.service.SetSavedCardChargeSettings(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), savedCardChargeErrorLimitsInvalid, rsp.Message)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_ChargeSavedCard_InitiatorInvalid_Error() {
+	req := &intPkg.ChargeSavedCardRequest{ProjectId: suite.project.Id, Initiator: "unknown"}
+	rsp := &intPkg.ChargeSavedCardResponse{}
+	err := suite.service.ChargeSavedCard(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), savedCardChargeErrorInitiatorInvalid, rsp.Message)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_ChargeSavedCard_NotAllowed_Error() {
+	req := &intPkg.ChargeSavedCardRequest{ProjectId: suite.project.Id, Initiator: pkg.PaymentInitiatorCustomer}
+	rsp := &intPkg.ChargeSavedCardResponse{}
+	err := suite.service.ChargeSavedCard(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusForbidden, rsp.Status)
+	assert.Equal(suite.T(), savedCardChargeErrorNotAllowed, rsp.Message)
+
+	suite.setSettings(false, true, 0, 0)
+
+	rsp = &intPkg.ChargeSavedCardResponse{}
+	err = suite.service.ChargeSavedCard(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusForbidden, rsp.Status)
+	assert.Equal(suite.T(), savedCardChargeErrorNotAllowed, rsp.Message)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_ChargeSavedCard_MitNotAllowed_Error() {
+	suite.setSettings(true, false, 0, 0)
+
+	req := &intPkg.ChargeSavedCardRequest{ProjectId: suite.project.Id, Initiator: pkg.PaymentInitiatorMerchant}
+	rsp := &intPkg.ChargeSavedCardResponse{}
+	err := suite.service.ChargeSavedCard(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusForbidden, rsp.Status)
+	assert.Equal(suite.T(), savedCardChargeErrorMitNotAllowed, rsp.Message)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_ChargeSavedCard_OrderNotFound_Error() {
+	suite.setSettings(true, true, 0, 0)
+
+	req := &intPkg.ChargeSavedCardRequest{
+		ProjectId: suite.project.Id,
+		OrderId:   primitive.NewObjectID().Hex(),
+		Initiator: pkg.PaymentInitiatorMerchant,
+	}
+	rsp := &intPkg.ChargeSavedCardResponse{}
+	err := suite.service.ChargeSavedCard(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), orderErrorNotFound, rsp.Message)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_ReserveSavedCardChargeLimits() {
+	suite.setSettings(true, true, 50, 70)
+
+	settings, err := suite.service.savedCardChargeSettingsRepository.GetByProjectId(context.TODO(), suite.project.Id)
+	assert.NoError(suite.T(), err)
+
+	charge := &intPkg.SavedCardCharge{
+		OrderId:    primitive.NewObjectID().Hex(),
+		ProjectId:  suite.project.Id,
+		CustomerId: primitive.NewObjectID().Hex(),
+		Amount:     40,
+		Currency:   "USD",
+		Status:     pkg.SavedCardChargeStatusPending,
+	}
+	assert.Nil(suite.T(), suite.service.reserveSavedCardChargeLimits(context.TODO(), settings, charge))
+	assert.Len(suite.T(), charge.CounterIds, 2)
+	assert.NoError(suite.T(), suite.service.savedCardChargeRepository.Insert(context.TODO(), charge))
+
+	charge2 := &intPkg.SavedCardCharge{
+		ProjectId:  charge.ProjectId,
+		CustomerId: charge.CustomerId,
+		Amount:     20,
+		Currency:   "USD",
+	}
+	msg := suite.service.reserveSavedCardChargeLimits(context.TODO(), settings, charge2)
+	assert.Equal(suite.T(), savedCardChargeErrorDailyLimitExceeded, msg)
+	assert.Empty(suite.T(), charge2.CounterIds)
+
+	charge2.Currency = "EUR"
+	msg = suite.service.reserveSavedCardChargeLimits(context.TODO(), settings, charge2)
+	assert.Equal(suite.T(), savedCardChargeErrorCurrencyNotAllowed, msg)
+
+	settings.CustomerDailyLimit = 0
+	charge2.Currency = "USD"
+	charge2.Amount = 31
+	msg = suite.service.reserveSavedCardChargeLimits(context.TODO(), settings, charge2)
+	assert.Equal(suite.T(), savedCardChargeErrorMonthlyLimitExceeded, msg)
+
+	err = suite.service.setSavedCardChargeStatus(context.TODO(), charge.OrderId, pkg.SavedCardChargeStatusFailed)
+	assert.NoError(suite.T(), err)
+
+	msg = suite.service.reserveSavedCardChargeLimits(context.TODO(), settings, charge2)
+	assert.Nil(suite.T(), msg)
+	assert.Len(suite.T(), charge2.CounterIds, 1)
+
+	err = suite.service.setSavedCardChargeStatus(context.TODO(), charge.OrderId, pkg.SavedCardChargeStatusFailed)
+	assert.Equal(suite.T(), mongo.ErrNoDocuments, err)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_ReserveSavedCardChargeLimits_Concurrent() {
+	suite.setSettings(true, true, 100, 0)
+
+	settings, err := suite.service.savedCardChargeSettingsRepository.GetByProjectId(context.TODO(), suite.project.Id)
+	assert.NoError(suite.T(), err)
+
+	// The unique index of counters is created by migrations which aren't applied to the test database.
+	_, err = suite.service.db.Collection("saved_card_charge_counter").Indexes().CreateOne(
+		context.TODO(),
+		mongo.IndexModel{
+			Keys:    bson.D{{"project_id", 1}, {"customer_id", 1}, {"currency", 1}, {"period", 1}, {"period_from", 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	)
+	assert.NoError(suite.T(), err)
+
+	customerId := primitive.NewObjectID().Hex()
+	results := make(chan *billingpb.ResponseErrorMessage, 10)
+
+	for i := 0; i < 10; i++ {
+		go func() {
+			charge := &intPkg.SavedCardCharge{
+				ProjectId:  suite.project.Id,
+				CustomerId: customerId,
+				Amount:     30,
+				Currency:   "USD",
+			}
+			results <- suite.service.reserveSavedCardChargeLimits(context.TODO(), settings, charge)
+		}()
+	}
+
+	reserved := 0
+
+	for i := 0; i < 10; i++ {
+		if msg := <-results; msg == nil {
+			reserved++
+		} else {
+			assert.Equal(suite.T(), savedCardChargeErrorDailyLimitExceeded, msg)
+		}
+	}
+
+	assert.Equal(suite.T(), 3, reserved)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_UpdateSavedCardChargeStatus_Ok() {
+	order := &billingpb.Order{
+		Id:                primitive.NewObjectID().Hex(),
+		PrivateStatus:     recurringpb.OrderStatusPaymentSystemComplete,
+		PaymentRequisites: map[string]string{pkg.PaymentCreateFieldInitiator: pkg.PaymentInitiatorMerchant},
+	}
+	charge := &intPkg.SavedCardCharge{
+		OrderId:  order.Id,
+		Amount:   10,
+		Currency: "USD",
+		Status:   pkg.SavedCardChargeStatusPending,
+	}
+	assert.NoError(suite.T(), suite.service.savedCardChargeRepository.Insert(context.TODO(), charge))
+
+	suite.service.updateSavedCardChargeStatus(context.TODO(), order)
+
+	charge, err := suite.service.savedCardChargeRepository.GetByOrderId(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.SavedCardChargeStatusProcessed, charge.Status)
+}
+
+func (suite *SavedCardChargeTestSuite) TestSavedCardCharge_StoreInitialCredential_Ok() {
+	order := &billingpb.Order{
+		Id:          primitive.NewObjectID().Hex(),
+		Transaction: "initial_transaction",
+		Project:     &billingpb.ProjectOrder{Id: suite.project.Id},
+		User:        &billingpb.OrderUser{Id: primitive.NewObjectID().Hex()},
+	}
+	suite.service.storeInitialCredential(context.TODO(), order, "recurring_id")
+
+	credential, err := suite.service.storedCredentialRepository.GetByRecurringId(context.TODO(), "recurring_id")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), order.Transaction, credential.InitialTransactionId)
+	assert.Equal(suite.T(), order.Id, credential.InitialOrderId)
+	assert.Equal(suite.T(), order.User.Id, credential.CustomerId)
+}
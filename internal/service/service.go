@@ -114,6 +114,35 @@ type Service struct {
 	merchantDocumentRepository             repository.MerchantDocumentRepositoryInterface
 	validateUserBroker                     rabbitmq.BrokerInterface
 	autoincrementRepository                repository.AutoincrementRepositoryInterface
+	subscriptionStateRepository            repository.SubscriptionStateRepositoryInterface
+	cardExpiryRepository                   repository.CardExpiryRepositoryInterface
+	accountUpdater                         payment_system.AccountUpdaterInterface
+	savedCardChargeSettingsRepository      repository.SavedCardChargeSettingsRepositoryInterface
+	storedCredentialRepository             repository.StoredCredentialRepositoryInterface
+	savedCardChargeRepository              repository.SavedCardChargeRepositoryInterface
+	ledgerAccountRepository                repository.LedgerAccountRepositoryInterface
+	ledgerJournalRepository                repository.LedgerJournalRepositoryInterface
+	merchantBalanceTransactionRepository   repository.MerchantBalanceTransactionRepositoryInterface
+	rollingReservePolicyRepository         repository.RollingReservePolicyRepositoryInterface
+	rollingReserveHoldRepository           repository.RollingReserveHoldRepositoryInterface
+	accountingCorrectionRepository         repository.AccountingCorrectionRepositoryInterface
+	accountingCorrectionLogRepository      repository.AccountingCorrectionLogRepositoryInterface
+	fxRevaluationRepository                repository.FxRevaluationRepositoryInterface
+	royaltyReportScheduleRepository        repository.RoyaltyReportScheduleRepositoryInterface
+	royaltyReportDisputeRepository         repository.RoyaltyReportDisputeRepositoryInterface
+	payoutBatchRepository                  repository.PayoutBatchRepositoryInterface
+	merchantPayoutScheduleRepository       repository.MerchantPayoutScheduleRepositoryInterface
+	ossReturnRepository                    repository.OssReturnRepositoryInterface
+	salesTaxNexusRepository                repository.SalesTaxNexusRepositoryInterface
+	orderVatIdRepository                   repository.OrderVatIdRepositoryInterface
+	vatReportReverseChargeRepository       repository.VatReportReverseChargeRepositoryInterface
+	vatIdValidator                         VatIdValidatorInterface
+	vatReportVersionRepository             repository.VatReportVersionRepositoryInterface
+	taxRateTableRepository                 repository.TaxRateTableRepositoryInterface
+	taxProvider                            TaxProviderInterface
+	orderTaxEvidenceRepository             repository.OrderTaxEvidenceRepositoryInterface
+	billingDocumentRepository              repository.BillingDocumentRepositoryInterface
+	merchantPayoutAccountRepository        repository.MerchantPayoutAccountRepositoryInterface
 	moneyRegistry                          map[string]*helper.Money
 	moneyRegistryMx                        sync.Mutex
 }
@@ -204,6 +233,38 @@ func (s *Service) Init() (err error) {
 	s.dashboardRepository = repository.NewDashboardRepository(s.db, s.cacher)
 	s.autoincrementRepository = repository.NewAutoincrementRepository(s.db)
 	s.merchantDocumentRepository = repository.NewMerchantDocumentRepository(s.db)
+	s.subscriptionStateRepository = repository.NewSubscriptionStateRepository(s.db)
+	s.cardExpiryRepository = repository.NewCardExpiryRepository(s.db)
+	s.savedCardChargeSettingsRepository = repository.NewSavedCardChargeSettingsRepository(s.db)
+	s.storedCredentialRepository = repository.NewStoredCredentialRepository(s.db)
+	s.savedCardChargeRepository = repository.NewSavedCardChargeRepository(s.db)
+	s.ledgerAccountRepository = repository.NewLedgerAccountRepository(s.db)
+	s.ledgerJournalRepository = repository.NewLedgerJournalRepository(s.db)
+	s.merchantBalanceTransactionRepository = repository.NewMerchantBalanceTransactionRepository(s.db)
+	s.rollingReservePolicyRepository = repository.NewRollingReservePolicyRepository(s.db)
+	s.rollingReserveHoldRepository = repository.NewRollingReserveHoldRepository(s.db)
+	s.accountingCorrectionRepository = repository.NewAccountingCorrectionRepository(s.db)
+	s.accountingCorrectionLogRepository = repository.NewAccountingCorrectionLogRepository(s.db)
+	s.fxRevaluationRepository = repository.NewFxRevaluationRepository(s.db)
+	s.royaltyReportScheduleRepository = repository.NewRoyaltyReportScheduleRepository(s.db)
+	s.royaltyReportDisputeRepository = repository.NewRoyaltyReportDisputeRepository(s.db)
+	s.payoutBatchRepository = repository.NewPayoutBatchRepository(s.db)
+	s.merchantPayoutScheduleRepository = repository.NewMerchantPayoutScheduleRepository(s.db)
+	s.ossReturnRepository = repository.NewOssReturnRepository(s.db)
+	s.salesTaxNexusRepository = repository.NewSalesTaxNexusRepository(s.db)
+	s.orderVatIdRepository = repository.NewOrderVatIdRepository(s.db)
+	s.vatReportReverseChargeRepository = repository.NewVatReportReverseChargeRepository(s.db)
+	s.vatIdValidator = newVatIdValidator(s.cfg, httpTools.NewLoggedHttpClient(zap.S()))
+	s.vatReportVersionRepository = repository.NewVatReportVersionRepository(s.db)
+	s.taxRateTableRepository = repository.NewTaxRateTableRepository(s.db)
+	s.taxProvider = newTaxProvider(s.cfg, s.tax, s.taxRateTableRepository)
+	s.orderTaxEvidenceRepository = repository.NewOrderTaxEvidenceRepository(s.db)
+	s.billingDocumentRepository = repository.NewBillingDocumentRepository(s.db)
+	s.merchantPayoutAccountRepository = repository.NewMerchantPayoutAccountRepository(s.db)
+
+	if s.cfg.CardAccountUpdaterFile != "" {
+		s.accountUpdater = payment_system.NewAccountUpdaterFile(s.cfg.CardAccountUpdaterFile)
+	}
 
 	sCurr, err := s.curService.GetSupportedCurrencies(context.TODO(), &currenciespb.EmptyRequest{})
 	if err != nil {
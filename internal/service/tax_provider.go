@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/internal/repository"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	errors2 "github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/taxpb"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	taxRateTableErrorRatesRequired = errors2.NewBillingServerErrorMsg("tx000001", "tax rate table must contain at least one rate")
+	taxRateTableErrorRateInvalid   = errors2.NewBillingServerErrorMsg("tx000002", "tax rate must have country and be between 0 and 1")
+	taxRateTableErrorRateDuplicate = errors2.NewBillingServerErrorMsg("tx000003", "tax rate table contains duplicate rates for the same location")
+	taxRateTableErrorNotFound      = errors2.NewBillingServerErrorMsg("tx000004", "tax rate table not found")
+	taxRateTableErrorUnknown       = errors2.NewBillingServerErrorMsg("tx000005", "unknown error. try request later")
+
+	errorTaxRateNotFound = errors.New("tax rate for location not found in tax rate table")
+)
+
+// TaxProviderInterface is an abstraction of the source of tax rates for orders and VAT reports.
+type TaxProviderInterface interface {
+	// GetRate returns the tax rate for the location of the customer at the date.
+	GetRate(ctx context.Context, req *intPkg.TaxRateRequest) (*intPkg.TaxRateResult, error)
+}
+
+type taxProviderTaxService struct {
+	tax taxpb.TaxService
+}
+
+type taxProviderRateTable struct {
+	repository repository.TaxRateTableRepositoryInterface
+}
+
+// taxProviderFallback requests rates from the fallback provider when the main provider fails,
+// to keep checkout running while the tax service is unavailable.
+type taxProviderFallback struct {
+	main     TaxProviderInterface
+	fallback TaxProviderInterface
+}
+
+func newTaxProvider(
+	cfg *config.Config,
+	tax taxpb.TaxService,
+	rateTableRepository repository.TaxRateTableRepositoryInterface,
+) TaxProviderInterface {
+	providers := map[string]TaxProviderInterface{
+		pkg.TaxProviderTaxService: &taxProviderTaxService{tax: tax},
+		pkg.TaxProviderRateTable:  &taxProviderRateTable{repository: rateTableRepository},
+	}
+
+	main, ok := providers[cfg.TaxProvider]
+
+	if !ok {
+		main = providers[pkg.TaxProviderTaxService]
+	}
+
+	fallback, ok := providers[cfg.TaxProviderFallback]
+
+	if !ok || fallback == main {
+		return main
+	}
+
+	return &taxProviderFallback{main: main, fallback: fallback}
+}
+
+func (p *taxProviderTaxService) GetRate(
+	ctx context.Context,
+	req *intPkg.TaxRateRequest,
+) (*intPkg.TaxRateResult, error) {
+	rsp, err := p.tax.GetRate(ctx, &taxpb.GeoIdentity{Country: req.Country, Zip: req.Zip})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &intPkg.TaxRateResult{Rate: rsp.Rate, Provider: pkg.TaxProviderTaxService}, nil
+}
+
+func (p *taxProviderRateTable) GetRate(
+	ctx context.Context,
+	req *intPkg.TaxRateRequest,
+) (*intPkg.TaxRateResult, error) {
+	date := req.Date
+
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	table, err := p.repository.GetEffective(ctx, date)
+
+	if err != nil {
+		return nil, err
+	}
+
+	item := getTaxRateTableItem(table, req.Country, req.State, req.Zip)
+
+	if item == nil {
+		return nil, errorTaxRateNotFound
+	}
+
+	result := &intPkg.TaxRateResult{
+		Rate:         item.Rate,
+		Provider:     pkg.TaxProviderRateTable,
+		TableVersion: table.Version,
+	}
+
+	return result, nil
+}
+
+func (p *taxProviderFallback) GetRate(
+	ctx context.Context,
+	req *intPkg.TaxRateRequest,
+) (*intPkg.TaxRateResult, error) {
+	result, err := p.main.GetRate(ctx, req)
+
+	if err == nil {
+		return result, nil
+	}
+
+	zap.L().Warn(
+		"tax provider failed, fallback tax provider is used",
+		zap.Error(err),
+		zap.String("country", req.Country),
+		zap.String("zip", req.Zip),
+	)
+
+	return p.fallback.GetRate(ctx, req)
+}
+
+// AddTaxRateTable adds new version of local tax rate table effective from the date.
+func (s *Service) AddTaxRateTable(
+	ctx context.Context,
+	req *intPkg.AddTaxRateTableRequest,
+	rsp *intPkg.TaxRateTableResponse,
+) error {
+	if len(req.Rates) == 0 {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = taxRateTableErrorRatesRequired
+		return nil
+	}
+
+	locations := make(map[string]bool, len(req.Rates))
+
+	for _, item := range req.Rates {
+		item.Country = strings.ToUpper(strings.TrimSpace(item.Country))
+		item.State = strings.ToUpper(strings.TrimSpace(item.State))
+		item.Zip = strings.TrimSpace(item.Zip)
+
+		if item.Country == "" || item.Rate < 0 || item.Rate > 1 {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = taxRateTableErrorRateInvalid
+			return nil
+		}
+
+		location := item.Country + "|" + item.State + "|" + item.Zip
+
+		if locations[location] {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = taxRateTableErrorRateDuplicate
+			return nil
+		}
+
+		locations[location] = true
+	}
+
+	version, err := s.taxRateTableRepository.GetLatestVersion(ctx)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = taxRateTableErrorUnknown
+		return nil
+	}
+
+	table := &intPkg.TaxRateTable{
+		Version:       version + 1,
+		EffectiveFrom: req.EffectiveFrom,
+		Rates:         req.Rates,
+		CreatedBy:     req.UserId,
+	}
+
+	if table.EffectiveFrom.IsZero() {
+		table.EffectiveFrom = time.Now()
+	}
+
+	if err = s.taxRateTableRepository.Insert(ctx, table); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = taxRateTableErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = table
+
+	return nil
+}
+
+func (s *Service) GetTaxRateTable(
+	ctx context.Context,
+	req *intPkg.GetTaxRateTableRequest,
+	rsp *intPkg.TaxRateTableResponse,
+) error {
+	date := req.Date
+
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	table, err := s.taxRateTableRepository.GetEffective(ctx, date)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusNotFound
+			rsp.Message = taxRateTableErrorNotFound
+			return nil
+		}
+
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = taxRateTableErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = table
+
+	return nil
+}
+
+// getTaxRateTableItem returns the most specific rate of the table for the location,
+// the rate of the zip code wins over the rate of the state and the rate of the state wins over the rate of the country.
+func getTaxRateTableItem(table *intPkg.TaxRateTable, country, state, zip string) *intPkg.TaxRateTableItem {
+	var result *intPkg.TaxRateTableItem
+	resultScore := -1
+
+	country = strings.ToUpper(country)
+	state = strings.ToUpper(state)
+
+	for _, item := range table.Rates {
+		if item.Country != country || (item.State != "" && item.State != state) || (item.Zip != "" && item.Zip != zip) {
+			continue
+		}
+
+		score := 0
+
+		if item.Zip != "" {
+			score += 2
+		}
+
+		if item.State != "" {
+			score++
+		}
+
+		if score > resultScore {
+			result = item
+			resultScore = score
+		}
+	}
+
+	return result
+}
+
+// setOrderTaxProvider records the provider and the version of tax rate table which calculated the tax rate of the order.
+func setOrderTaxProvider(order *billingpb.Order, result *intPkg.TaxRateResult) {
+	delete(order.PrivateMetadata, pkg.OrderPrivateMetadataTaxProvider)
+	delete(order.PrivateMetadata, pkg.OrderPrivateMetadataTaxRateTableVersion)
+
+	if result == nil {
+		return
+	}
+
+	if order.PrivateMetadata == nil {
+		order.PrivateMetadata = make(map[string]string)
+	}
+
+	order.PrivateMetadata[pkg.OrderPrivateMetadataTaxProvider] = result.Provider
+
+	if result.TableVersion > 0 {
+		order.PrivateMetadata[pkg.OrderPrivateMetadataTaxRateTableVersion] = strconv.Itoa(int(result.TableVersion))
+	}
+}
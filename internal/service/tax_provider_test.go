@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"time"
+)
+
+func (suite *OrderTestSuite) TestTaxProvider_RateTable_GetRate_Ok() {
+	suite.helperAddTaxRateTable(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), []*intPkg.TaxRateTableItem{
+		{Country: "US", Rate: 0.05},
+		{Country: "us", State: "ca", Rate: 0.0725},
+		{Country: "US", State: "CA", Zip: "90001", Rate: 0.095},
+		{Country: "DE", Rate: 0.19},
+	})
+	suite.helperAddTaxRateTable(time.Date(2020, time.July, 1, 0, 0, 0, 0, time.UTC), []*intPkg.TaxRateTableItem{
+		{Country: "DE", Rate: 0.16},
+	})
+
+	provider := &taxProviderRateTable{repository: suite.service.taxRateTableRepository}
+	date := time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	res, err := provider.GetRate(context.TODO(), &intPkg.TaxRateRequest{Country: "US", State: "NY", Zip: "10001", Date: date})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0.05, res.Rate)
+	assert.Equal(suite.T(), pkg.TaxProviderRateTable, res.Provider)
+	assert.EqualValues(suite.T(), 1, res.TableVersion)
+
+	res, err = provider.GetRate(context.TODO(), &intPkg.TaxRateRequest{Country: "US", State: "CA", Zip: "90210", Date: date})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0.0725, res.Rate)
+
+	res, err = provider.GetRate(context.TODO(), &intPkg.TaxRateRequest{Country: "US", State: "CA", Zip: "90001", Date: date})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0.095, res.Rate)
+
+	res, err = provider.GetRate(context.TODO(), &intPkg.TaxRateRequest{Country: "DE", Date: date})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0.19, res.Rate)
+
+	res, err = provider.GetRate(context.TODO(), &intPkg.TaxRateRequest{Country: "DE", Date: date.AddDate(0, 6, 0)})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0.16, res.Rate)
+	assert.EqualValues(suite.T(), 2, res.TableVersion)
+
+	_, err = provider.GetRate(context.TODO(), &intPkg.TaxRateRequest{Country: "US", Date: date.AddDate(0, 6, 0)})
+	assert.Equal(suite.T(), errorTaxRateNotFound, err)
+
+	_, err = provider.GetRate(context.TODO(), &intPkg.TaxRateRequest{Country: "DE", Date: date.AddDate(-1, 0, 0)})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *OrderTestSuite) TestTaxProvider_AddTaxRateTable_RateInvalid_Error() {
+	req := &intPkg.AddTaxRateTableRequest{Rates: []*intPkg.TaxRateTableItem{{Country: "DE", Rate: 19}}}
+	rsp := &intPkg.TaxRateTableResponse{}
+	err := suite.service.AddTaxRateTable(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), taxRateTableErrorRateInvalid, rsp.Message)
+
+	req.Rates = []*intPkg.TaxRateTableItem{{Country: "DE", Rate: 0.19}, {Country: "de", Rate: 0.16}}
+	rsp = &intPkg.TaxRateTableResponse{}
+	err = suite.service.AddTaxRateTable(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), taxRateTableErrorRateDuplicate, rsp.Message)
+
+	req.Rates = nil
+	rsp = &intPkg.TaxRateTableResponse{}
+	err = suite.service.AddTaxRateTable(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), taxRateTableErrorRatesRequired, rsp.Message)
+}
+
+func (suite *OrderTestSuite) TestTaxProvider_GetTaxRateTable_NotFound_Error() {
+	rsp := &intPkg.TaxRateTableResponse{}
+	err := suite.service.GetTaxRateTable(context.TODO(), &intPkg.GetTaxRateTableRequest{}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), taxRateTableErrorNotFound, rsp.Message)
+}
+
+func (suite *OrderTestSuite) TestTaxProvider_processOrderVat_TaxService() {
+	order := suite.helperCreateOrderWithBillingCountry("IT")
+	assert.EqualValues(suite.T(), 0.2, order.Tax.Rate)
+	assert.Equal(suite.T(), pkg.TaxProviderTaxService, order.PrivateMetadata[pkg.OrderPrivateMetadataTaxProvider])
+	assert.NotContains(suite.T(), order.PrivateMetadata, pkg.OrderPrivateMetadataTaxRateTableVersion)
+}
+
+func (suite *OrderTestSuite) TestTaxProvider_processOrderVat_Fallback() {
+	suite.helperAddTaxRateTable(time.Now().AddDate(0, -1, 0), []*intPkg.TaxRateTableItem{{Country: "IT", Rate: 0.22}})
+
+	cfg := &config.Config{TaxProvider: pkg.TaxProviderTaxService, TaxProviderFallback: pkg.TaxProviderRateTable}
+	suite.service.taxProvider = newTaxProvider(cfg, mocks.NewTaxServiceErrorMock(), suite.service.taxRateTableRepository)
+
+	order := suite.helperCreateOrderWithBillingCountry("IT")
+	assert.EqualValues(suite.T(), 0.22, order.Tax.Rate)
+	assert.True(suite.T(), order.Tax.Amount > 0)
+	assert.Equal(suite.T(), pkg.TaxProviderRateTable, order.PrivateMetadata[pkg.OrderPrivateMetadataTaxProvider])
+	assert.Equal(suite.T(), "1", order.PrivateMetadata[pkg.OrderPrivateMetadataTaxRateTableVersion])
+}
+
+func (suite *OrderTestSuite) TestTaxProvider_newTaxProvider_FallbackDisabled() {
+	cfg := &config.Config{TaxProvider: pkg.TaxProviderTaxService, TaxProviderFallback: pkg.TaxProviderFallbackNone}
+	provider := newTaxProvider(cfg, mocks.NewTaxServiceErrorMock(), suite.service.taxRateTableRepository)
+	assert.IsType(suite.T(), &taxProviderTaxService{}, provider)
+
+	_, err := provider.GetRate(context.TODO(), &intPkg.TaxRateRequest{Country: "IT"})
+	assert.Error(suite.T(), err)
+
+	cfg.TaxProviderFallback = pkg.TaxProviderRateTable
+	assert.IsType(suite.T(), &taxProviderFallback{}, newTaxProvider(cfg, mocks.NewTaxServiceErrorMock(), nil))
+
+	cfg.TaxProvider = pkg.TaxProviderRateTable
+	assert.IsType(suite.T(), &taxProviderRateTable{}, newTaxProvider(cfg, mocks.NewTaxServiceErrorMock(), nil))
+}
+
+func (suite *OrderTestSuite) helperAddTaxRateTable(effectiveFrom time.Time, rates []*intPkg.TaxRateTableItem) {
+	req := &intPkg.AddTaxRateTableRequest{EffectiveFrom: effectiveFrom, Rates: rates}
+	rsp := &intPkg.TaxRateTableResponse{}
+	err := suite.service.AddTaxRateTable(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/paysuper/paysuper-billing-server/internal/config"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	viesUserErrorValid   = "VALID"
+	viesUserErrorInvalid = "INVALID"
+)
+
+var (
+	vatIdFormat    = regexp.MustCompile(`^[A-Z]{2}[0-9A-Z+*]{2,12}$`)
+	vatIdSeparator = strings.NewReplacer(" ", "", ".", "", "-", "")
+
+	// VAT ID prefixes which differ from ISO 3166 code of the member state
+	vatIdCountryPrefixes = map[string]string{
+		"EL": "GR",
+	}
+
+	errorVatIdValidatorUnavailable = errors.New("vat id registry is unavailable")
+)
+
+// VatIdValidatorInterface is an abstraction of the registry of VAT IDs of the European Union member states.
+type VatIdValidatorInterface interface {
+	// Validate checks VAT ID of the business payer in the registry. The consultation number is issued only
+	// if VAT ID of the requester (operating company) is passed.
+	Validate(ctx context.Context, vatId, requesterVatId string) (*intPkg.VatIdValidationResult, error)
+}
+
+type vatIdValidatorStub struct{}
+
+type vatIdValidatorVies struct {
+	url        string
+	httpClient *http.Client
+}
+
+type viesCheckVatRequest struct {
+	CountryCode              string `json:"countryCode"`
+	VatNumber                string `json:"vatNumber"`
+	RequesterMemberStateCode string `json:"requesterMemberStateCode,omitempty"`
+	RequesterNumber          string `json:"requesterNumber,omitempty"`
+}
+
+type viesCheckVatResponse struct {
+	Valid             bool   `json:"valid"`
+	Name              string `json:"name"`
+	Address           string `json:"address"`
+	RequestIdentifier string `json:"requestIdentifier"`
+	UserError         string `json:"userError"`
+}
+
+// newVatIdValidator returns the stub only when it's configured explicitly, because it grants reverse charge
+// to any VAT ID with valid format.
+func newVatIdValidator(cfg *config.Config, httpClient *http.Client) VatIdValidatorInterface {
+	if cfg.VatIdValidator == pkg.VatIdValidatorStub {
+		return &vatIdValidatorStub{}
+	}
+
+	return &vatIdValidatorVies{url: cfg.VatIdViesUrl, httpClient: httpClient}
+}
+
+// normalizeVatId removes separators which customers often use to enter VAT ID.
+func normalizeVatId(vatId string) string {
+	return strings.ToUpper(vatIdSeparator.Replace(strings.TrimSpace(vatId)))
+}
+
+// getVatIdCountry returns ISO 3166 code of the member state which issued VAT ID.
+func getVatIdCountry(vatId string) string {
+	if len(vatId) < 2 {
+		return ""
+	}
+
+	prefix := vatId[:2]
+
+	if country, ok := vatIdCountryPrefixes[prefix]; ok {
+		return country
+	}
+
+	return prefix
+}
+
+func splitVatId(vatId string) (string, string) {
+	if len(vatId) < 2 {
+		return "", vatId
+	}
+
+	return vatId[:2], vatId[2:]
+}
+
+// Validate of the stub accepts any VAT ID with valid format and issues a local consultation number.
+func (v *vatIdValidatorStub) Validate(_ context.Context, vatId, _ string) (*intPkg.VatIdValidationResult, error) {
+	result := &intPkg.VatIdValidationResult{
+		Valid:     vatIdFormat.MatchString(vatId),
+		CheckedAt: time.Now(),
+	}
+
+	if result.Valid {
+		result.ConsultationNumber = strings.ToUpper(fmt.Sprintf("STUB%s", primitive.NewObjectID().Hex()))
+	}
+
+	return result, nil
+}
+
+func (v *vatIdValidatorVies) Validate(
+	ctx context.Context,
+	vatId, requesterVatId string,
+) (*intPkg.VatIdValidationResult, error) {
+	req := &viesCheckVatRequest{}
+	req.CountryCode, req.VatNumber = splitVatId(vatId)
+
+	if requesterVatId != "" {
+		req.RequesterMemberStateCode, req.RequesterNumber = splitVatId(normalizeVatId(requesterVatId))
+	}
+
+	b, err := json.Marshal(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, v.url, bytes.NewReader(b))
+
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+
+	rsp, err := v.httpClient.Do(httpReq.WithContext(ctx))
+
+	if err != nil {
+		zap.L().Error("vies request failed", zap.Error(err), zap.String("vat_id", vatId))
+		return nil, err
+	}
+
+	defer rsp.Body.Close()
+
+	b, err = ioutil.ReadAll(rsp.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rsp.StatusCode != http.StatusOK {
+		zap.L().Error(
+			"vies returned unexpected status",
+			zap.Int("status", rsp.StatusCode),
+			zap.String("vat_id", vatId),
+			zap.ByteString("response", b),
+		)
+		return nil, errorVatIdValidatorUnavailable
+	}
+
+	data := &viesCheckVatResponse{}
+	err = json.Unmarshal(b, data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Member state registry may be unavailable, VIES returns the reason of error in user error field
+	if data.UserError != "" && data.UserError != viesUserErrorValid && data.UserError != viesUserErrorInvalid {
+		zap.L().Error("vies returned error", zap.String("error", data.UserError), zap.String("vat_id", vatId))
+		return nil, errorVatIdValidatorUnavailable
+	}
+
+	result := &intPkg.VatIdValidationResult{
+		Valid:              data.Valid,
+		CompanyName:        data.Name,
+		CompanyAddress:     data.Address,
+		ConsultationNumber: data.RequestIdentifier,
+		CheckedAt:          time.Now(),
+	}
+
+	return result, nil
+}
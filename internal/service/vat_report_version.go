@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/golang/protobuf/ptypes"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/reporterpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"time"
+)
+
+var (
+	// Statuses of VAT reports of ended periods which are amended instead of recalculation.
+	vatReportAmendableStatuses = []string{
+		pkg.VatReportStatusNeedToPay,
+		pkg.VatReportStatusOverdue,
+		pkg.VatReportStatusPaid,
+	}
+)
+
+// GetVatReportHistory returns versions of the VAT report and amendments of previous VAT reports
+// which are filed in the VAT report.
+func (s *Service) GetVatReportHistory(
+	ctx context.Context,
+	req *intPkg.GetVatReportHistoryRequest,
+	rsp *intPkg.VatReportHistoryResponse,
+) error {
+	vr, err := s.vatReportRepository.GetById(ctx, req.VatReportId)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusNotFound
+			rsp.Message = errorVatReportNotFound
+			return nil
+		}
+
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = errorVatReportQueryError
+		return nil
+	}
+
+	rsp.Items, err = s.vatReportVersionRepository.FindByVatReportId(ctx, vr.Id)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = errorVatReportQueryError
+		return nil
+	}
+
+	rsp.FiledAmendments, err = s.vatReportVersionRepository.FindAmendments(ctx, vr.OperatingCompanyId, vr.Country, vr.Id)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = errorVatReportQueryError
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// ProcessVatReportAmendments recalculates VAT reports which are due to pay or already paid. If late refunds
+// or corrections of orders changed amounts of the report, the amendment with the delta to the previous
+// version is created. The report itself stays as it was filed.
+func (h *vatReportProcessor) ProcessVatReportAmendments(ctx context.Context) error {
+	reports, err := h.vatReportRepository.GetByStatus(ctx, vatReportAmendableStatuses)
+
+	if err != nil {
+		return err
+	}
+
+	for _, report := range reports {
+		if h.getCountry(report.Country) == nil {
+			continue
+		}
+
+		if err = h.processVatReportAmendment(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *vatReportProcessor) processVatReportAmendment(ctx context.Context, report *billingpb.VatReport) error {
+	versions, err := h.vatReportVersionRepository.FindByVatReportId(ctx, report.Id)
+
+	if err != nil {
+		return err
+	}
+
+	// Reports which were due to pay before versioning have no original version yet
+	if len(versions) == 0 {
+		original, err := h.createVatReportOriginalVersion(ctx, report)
+
+		if err != nil {
+			return err
+		}
+
+		versions = append(versions, original)
+	}
+
+	from, err := ptypes.Timestamp(report.DateFrom)
+
+	if err != nil {
+		return err
+	}
+
+	to, err := ptypes.Timestamp(report.DateTo)
+
+	if err != nil {
+		return err
+	}
+
+	current := &billingpb.VatReport{
+		Id:                 report.Id,
+		OperatingCompanyId: report.OperatingCompanyId,
+		Country:            report.Country,
+		Currency:           report.Currency,
+	}
+
+	if _, err = h.calcVatReportAmounts(ctx, current, from, to); err != nil {
+		return err
+	}
+
+	previous := versions[len(versions)-1]
+	lines, changed := getVatReportVersionLines(current, previous.Lines)
+
+	if !changed {
+		return nil
+	}
+
+	amendment := newVatReportVersion(report, from, to, previous.Version+1, pkg.VatReportVersionTypeAmendment, lines)
+	amendment.CorrectionAmount = getVatReportVersionCorrectionAmount(lines)
+
+	if err = h.vatReportVersionRepository.Insert(ctx, amendment); err != nil {
+		return err
+	}
+
+	zap.L().Info(
+		"vat report amended",
+		zap.String("vat_report_id", report.Id),
+		zap.Int32("version", amendment.Version),
+		zap.Float64("correction_amount", amendment.CorrectionAmount),
+	)
+
+	h.renderVatReportVersion(ctx, amendment)
+
+	return nil
+}
+
+// createVatReportOriginalVersion stores amounts of the VAT report at the moment it became due to pay.
+func (s *Service) createVatReportOriginalVersion(
+	ctx context.Context,
+	report *billingpb.VatReport,
+) (*intPkg.VatReportVersion, error) {
+	from, err := ptypes.Timestamp(report.DateFrom)
+
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := ptypes.Timestamp(report.DateTo)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lines, _ := getVatReportVersionLines(report, nil)
+	original := newVatReportVersion(report, from, to, 1, pkg.VatReportVersionTypeOriginal, lines)
+
+	if err = s.vatReportVersionRepository.Insert(ctx, original); err != nil {
+		return nil, err
+	}
+
+	s.renderVatReportVersion(ctx, original)
+
+	return original, nil
+}
+
+// fileVatReportAmendments includes amendments of VAT reports of previous periods which aren't filed yet
+// to the VAT report and returns the total correction amount of amendments filed in the report.
+func (h *vatReportProcessor) fileVatReportAmendments(
+	ctx context.Context,
+	report *billingpb.VatReport,
+	from time.Time,
+) (float64, error) {
+	pending, err := h.vatReportVersionRepository.FindAmendments(ctx, report.OperatingCompanyId, report.Country, "")
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, amendment := range pending {
+		if amendment.VatReportId == report.Id || !amendment.DateTo.Before(from) {
+			continue
+		}
+
+		amendment.FiledInVatReportId = report.Id
+
+		if err = h.vatReportVersionRepository.Update(ctx, amendment); err != nil {
+			return 0, err
+		}
+	}
+
+	filed, err := h.vatReportVersionRepository.FindAmendments(ctx, report.OperatingCompanyId, report.Country, report.Id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	amount := float64(0)
+
+	for _, amendment := range filed {
+		amount += amendment.CorrectionAmount
+	}
+
+	return tools.FormatAmount(amount), nil
+}
+
+// renderVatReportVersion requests PDF file of the version of VAT report. Files are kept by the reporting service
+// for each version and may be requested again by id of the version, so the failure doesn't stop processing.
+func (s *Service) renderVatReportVersion(ctx context.Context, version *intPkg.VatReportVersion) {
+	params, err := json.Marshal(map[string]interface{}{reporterpb.ParamsFieldId: version.Id.Hex()})
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to marshal the params of vat report version for the reporting service.",
+			zap.Error(err),
+		)
+		return
+	}
+
+	req := &reporterpb.ReportFile{
+		ReportType: pkg.ReportTypeVatReportVersion,
+		FileType:   reporterpb.OutputExtensionPdf,
+		Params:     params,
+	}
+
+	_ = s.reporterServiceCreateFile(ctx, req)
+}
+
+func newVatReportVersion(
+	report *billingpb.VatReport,
+	from, to time.Time,
+	version int32,
+	versionType string,
+	lines []*intPkg.VatReportVersionLine,
+) *intPkg.VatReportVersion {
+	return &intPkg.VatReportVersion{
+		VatReportId:        report.Id,
+		Version:            version,
+		Type:               versionType,
+		OperatingCompanyId: report.OperatingCompanyId,
+		Country:            report.Country,
+		Currency:           report.Currency,
+		VatRate:            report.VatRate,
+		DateFrom:           from,
+		DateTo:             to,
+		Lines:              lines,
+	}
+}
+
+// getVatReportVersionLines returns amounts of the VAT report with deltas to lines of the previous version
+// and flag of any line was changed.
+func getVatReportVersionLines(
+	report *billingpb.VatReport,
+	previous []*intPkg.VatReportVersionLine,
+) ([]*intPkg.VatReportVersionLine, bool) {
+	amounts := map[string]float64{
+		pkg.VatReportLineTransactionsCount: float64(report.TransactionsCount),
+		pkg.VatReportLineGrossRevenue:      report.GrossRevenue,
+		pkg.VatReportLineVatAmount:         report.VatAmount,
+		pkg.VatReportLineFeesAmount:        report.FeesAmount,
+		pkg.VatReportLineDeductionAmount:   report.DeductionAmount,
+	}
+	names := []string{
+		pkg.VatReportLineTransactionsCount,
+		pkg.VatReportLineGrossRevenue,
+		pkg.VatReportLineVatAmount,
+		pkg.VatReportLineFeesAmount,
+		pkg.VatReportLineDeductionAmount,
+	}
+	previousAmounts := make(map[string]float64, len(previous))
+
+	for _, line := range previous {
+		previousAmounts[line.Name] = line.Amount
+	}
+
+	lines := make([]*intPkg.VatReportVersionLine, 0, len(names))
+	changed := false
+
+	for _, name := range names {
+		line := &intPkg.VatReportVersionLine{Name: name, Amount: tools.FormatAmount(amounts[name])}
+
+		if previous != nil {
+			line.Delta = tools.FormatAmount(line.Amount - previousAmounts[name])
+			changed = changed || line.Delta != 0
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, changed
+}
+
+// getVatReportVersionCorrectionAmount returns the delta of VAT due to pay of the amendment.
+func getVatReportVersionCorrectionAmount(lines []*intPkg.VatReportVersionLine) float64 {
+	amount := float64(0)
+
+	for _, line := range lines {
+		switch line.Name {
+		case pkg.VatReportLineVatAmount:
+			amount += line.Delta
+		case pkg.VatReportLineDeductionAmount:
+			amount -= line.Delta
+		}
+	}
+
+	return tools.FormatAmount(amount)
+}
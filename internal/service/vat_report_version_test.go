@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/jinzhu/now"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+func (suite *VatReportsTestSuite) TestVatReportVersion_ProcessVatReportAmendments_Ok() {
+	oc := HelperOperatingCompany(suite.Suite, suite.service)
+	reporterMock := suite.helperOssReturnReporterMock()
+	report := suite.helperInsertOssVatReport(oc.Id, "FI", 0.24, 1240, 240, time.January, pkg.VatReportStatusNeedToPay)
+
+	suite.helperVatReportVersionOrderViewMock(oc.Id, &intPkg.VatReportQueryResItem{
+		Count:                          11,
+		PaymentGrossRevenueLocal:       1240,
+		PaymentTaxFeeLocal:             240,
+		PaymentRefundGrossRevenueLocal: 124,
+		PaymentRefundTaxFeeLocal:       24,
+	})
+
+	processor, err := NewVatReportProcessor(suite.service, context.TODO(), ptypes.TimestampNow())
+	assert.NoError(suite.T(), err)
+
+	err = processor.ProcessVatReportAmendments(context.TODO())
+	assert.NoError(suite.T(), err)
+
+	versions, err := suite.service.vatReportVersionRepository.FindByVatReportId(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), versions, 2)
+
+	assert.EqualValues(suite.T(), 1, versions[0].Version)
+	assert.Equal(suite.T(), pkg.VatReportVersionTypeOriginal, versions[0].Type)
+	assert.Equal(suite.T(), pkg.VatReportLineGrossRevenue, versions[0].Lines[1].Name)
+	assert.Equal(suite.T(), 1240.0, versions[0].Lines[1].Amount)
+	assert.Zero(suite.T(), versions[0].Lines[1].Delta)
+	assert.Zero(suite.T(), versions[0].CorrectionAmount)
+
+	assert.EqualValues(suite.T(), 2, versions[1].Version)
+	assert.Equal(suite.T(), pkg.VatReportVersionTypeAmendment, versions[1].Type)
+	assert.Equal(suite.T(), 1.0, versions[1].Lines[0].Delta)
+	assert.Equal(suite.T(), 1116.0, versions[1].Lines[1].Amount)
+	assert.Equal(suite.T(), -124.0, versions[1].Lines[1].Delta)
+	assert.Equal(suite.T(), 216.0, versions[1].Lines[2].Amount)
+	assert.Equal(suite.T(), -24.0, versions[1].Lines[2].Delta)
+	assert.Equal(suite.T(), -24.0, versions[1].CorrectionAmount)
+	assert.Empty(suite.T(), versions[1].FiledInVatReportId)
+
+	err = processor.ProcessVatReportAmendments(context.TODO())
+	assert.NoError(suite.T(), err)
+
+	versions, err = suite.service.vatReportVersionRepository.FindByVatReportId(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), versions, 2)
+
+	reporterMock.AssertNumberOfCalls(suite.T(), "CreateFile", 2)
+
+	vr, err := suite.service.vatReportRepository.GetById(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 240.0, vr.VatAmount)
+}
+
+func (suite *VatReportsTestSuite) TestVatReportVersion_fileVatReportAmendments_Ok() {
+	oc := HelperOperatingCompany(suite.Suite, suite.service)
+	january := suite.helperInsertOssVatReport(oc.Id, "FI", 0.24, 1240, 240, time.January, pkg.VatReportStatusPaid)
+	february := suite.helperInsertOssVatReport(oc.Id, "FI", 0.24, 620, 120, time.February, pkg.VatReportStatusThreshold)
+	amendment := suite.helperInsertVatReportAmendment(january, -24)
+
+	processor, err := NewVatReportProcessor(suite.service, context.TODO(), ptypes.TimestampNow())
+	assert.NoError(suite.T(), err)
+
+	from, err := ptypes.Timestamp(january.DateFrom)
+	assert.NoError(suite.T(), err)
+
+	// Amendment of the report isn't filed in the report of the same or earlier period
+	amount, err := processor.fileVatReportAmendments(context.TODO(), january, from)
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), amount)
+
+	from, err = ptypes.Timestamp(february.DateFrom)
+	assert.NoError(suite.T(), err)
+
+	amount, err = processor.fileVatReportAmendments(context.TODO(), february, from)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), -24.0, amount)
+
+	amount, err = processor.fileVatReportAmendments(context.TODO(), february, from)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), -24.0, amount)
+
+	req := &intPkg.GetVatReportHistoryRequest{VatReportId: february.Id}
+	rsp := &intPkg.VatReportHistoryResponse{}
+	err = suite.service.GetVatReportHistory(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Empty(suite.T(), rsp.Items)
+	assert.Len(suite.T(), rsp.FiledAmendments, 1)
+	assert.Equal(suite.T(), amendment.Id, rsp.FiledAmendments[0].Id)
+	assert.Equal(suite.T(), february.Id, rsp.FiledAmendments[0].FiledInVatReportId)
+
+	req.VatReportId = january.Id
+	rsp = &intPkg.VatReportHistoryResponse{}
+	err = suite.service.GetVatReportHistory(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 1)
+	assert.Empty(suite.T(), rsp.FiledAmendments)
+}
+
+func (suite *VatReportsTestSuite) TestVatReportVersion_GetVatReportHistory_NotFound_Error() {
+	req := &intPkg.GetVatReportHistoryRequest{VatReportId: primitive.NewObjectID().Hex()}
+	rsp := &intPkg.VatReportHistoryResponse{}
+	err := suite.service.GetVatReportHistory(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), errorVatReportNotFound, rsp.Message)
+}
+
+func (suite *VatReportsTestSuite) TestVatReportVersion_getVatReportVersionLines() {
+	report := &billingpb.VatReport{TransactionsCount: 10, GrossRevenue: 100, VatAmount: 20, DeductionAmount: 5}
+
+	lines, changed := getVatReportVersionLines(report, nil)
+	assert.False(suite.T(), changed)
+	assert.Len(suite.T(), lines, 5)
+
+	report.DeductionAmount = 7
+	lines, changed = getVatReportVersionLines(report, lines)
+	assert.True(suite.T(), changed)
+	assert.Equal(suite.T(), 2.0, lines[4].Delta)
+	assert.Equal(suite.T(), -2.0, getVatReportVersionCorrectionAmount(lines))
+
+	lines, changed = getVatReportVersionLines(report, lines)
+	assert.False(suite.T(), changed)
+}
+
+func (suite *VatReportsTestSuite) helperVatReportVersionOrderViewMock(
+	operatingCompanyId string,
+	item *intPkg.VatReportQueryResItem,
+) {
+	orderViewMock := &mocks.OrderViewRepositoryInterface{}
+	orderViewMock.
+		On("GetVatSummary", mock2.Anything, operatingCompanyId, mock2.Anything, false, mock2.Anything, mock2.Anything).
+		Return([]*intPkg.VatReportQueryResItem{item}, nil)
+	orderViewMock.
+		On("GetVatSummary", mock2.Anything, mock2.Anything, mock2.Anything, mock2.Anything, mock2.Anything, mock2.Anything).
+		Return(nil, nil)
+	orderViewMock.
+		On("GetReverseChargeVatSummary", mock2.Anything, mock2.Anything, mock2.Anything, mock2.Anything, mock2.Anything, mock2.Anything).
+		Return(nil, nil)
+	suite.service.orderViewRepository = orderViewMock
+}
+
+func (suite *VatReportsTestSuite) helperInsertVatReportAmendment(
+	report *billingpb.VatReport,
+	correctionAmount float64,
+) *intPkg.VatReportVersion {
+	from, err := ptypes.Timestamp(report.DateFrom)
+	assert.NoError(suite.T(), err)
+
+	lines, _ := getVatReportVersionLines(report, nil)
+	amendment := newVatReportVersion(report, from, now.New(from).EndOfMonth(), 2, pkg.VatReportVersionTypeAmendment, lines)
+	amendment.CorrectionAmount = correctionAmount
+
+	err = suite.service.vatReportVersionRepository.Insert(context.TODO(), amendment)
+	assert.NoError(suite.T(), err)
+
+	return amendment
+}
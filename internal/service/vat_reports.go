@@ -6,12 +6,12 @@ import (
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/jinzhu/now"
 	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg/errors"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"github.com/paysuper/paysuper-proto/go/currenciespb"
 	"github.com/paysuper/paysuper-proto/go/postmarkpb"
-	"github.com/paysuper/paysuper-proto/go/taxpb"
 	tools "github.com/paysuper/paysuper-tools/number"
 	"github.com/streadway/amqp"
 	"go.mongodb.org/mongo-driver/bson"
@@ -199,6 +199,50 @@ func (s *Service) GetVatReport(
 	return nil
 }
 
+// GetVatReportReverseCharge returns the summary of zero-rated sales to business payers with VAT ID
+// which are excluded from the VAT report.
+func (s *Service) GetVatReportReverseCharge(
+	ctx context.Context,
+	req *intPkg.GetVatReportReverseChargeRequest,
+	rsp *intPkg.VatReportReverseChargeResponse,
+) error {
+	vr, err := s.vatReportRepository.GetById(ctx, req.VatReportId)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusNotFound
+			rsp.Message = errorVatReportNotFound
+			return nil
+		}
+
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = errorVatReportQueryError
+		return nil
+	}
+
+	item, err := s.vatReportReverseChargeRepository.GetByVatReportId(ctx, vr.Id)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = errorVatReportQueryError
+			return nil
+		}
+
+		item = &intPkg.VatReportReverseCharge{
+			VatReportId:        vr.Id,
+			OperatingCompanyId: vr.OperatingCompanyId,
+			Country:            vr.Country,
+			Currency:           vr.Currency,
+		}
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = item
+
+	return nil
+}
+
 func (s *Service) GetVatReportTransactions(
 	ctx context.Context,
 	req *billingpb.VatTransactionsRequest,
@@ -292,6 +336,12 @@ func (s *Service) ProcessVatReports(
 		return err
 	}
 
+	zap.S().Info("processing vat reports amendments")
+	err = handler.ProcessVatReportAmendments(ctx)
+	if err != nil {
+		return err
+	}
+
 	zap.S().Info("processing vat reports")
 	err = handler.ProcessVatReports(ctx)
 	if err != nil {
@@ -496,6 +546,12 @@ func (h *vatReportProcessor) ProcessVatReportsStatus(ctx context.Context) error
 		if err != nil {
 			return err
 		}
+
+		if report.Status == pkg.VatReportStatusNeedToPay {
+			if _, err = h.Service.createVatReportOriginalVersion(ctx, report); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -569,11 +625,12 @@ func (h *vatReportProcessor) processVatReportForPeriod(ctx context.Context, coun
 		"to", to.Format(time.RFC3339),
 	)
 
-	req := &taxpb.GeoIdentity{
+	req := &intPkg.TaxRateRequest{
 		Country: country.IsoCodeA2,
+		Date:    h.date,
 	}
 
-	rsp, err := h.Service.tax.GetRate(ctx, req)
+	rsp, err := h.Service.taxProvider.GetRate(ctx, req)
 	if err != nil {
 		zap.L().Error(errorMsgVatReportTaxServiceGetRateFailed, zap.Error(err))
 		return err
@@ -653,12 +710,64 @@ func (h *vatReportProcessor) processVatReportForPeriod(ctx context.Context, coun
 	isCurrencyRatesPolicyOnDay := country.VatCurrencyRatesPolicy == pkg.VatCurrencyRatesPolicyOnDay
 	report.AmountsApproximate = !(isCurrencyRatesPolicyOnDay || (!isCurrencyRatesPolicyOnDay && isLastDayOfPeriod))
 
-	res, err := h.orderViewRepository.GetVatSummary(h.ctx, operatingCompanyId, country.IsoCodeA2, false, from, to)
+	reverseCharge, err := h.calcVatReportAmounts(ctx, report, from, to)
+
+	if err != nil {
+		return err
+	}
+
+	vr, err := h.vatReportRepository.GetByCountryPeriod(ctx, report.Country, from, to)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	isNew := err == mongo.ErrNoDocuments
+
+	if !isNew {
+		report.Id = vr.Id
+		report.CreatedAt = vr.CreatedAt
+	}
+
+	report.CorrectionAmount, err = h.fileVatReportAmendments(ctx, report, from)
+
+	if err != nil {
+		return err
+	}
+
+	if isNew {
+		err = h.Service.vatReportRepository.Insert(ctx, report)
+	} else {
+		err = h.Service.updateVatReport(ctx, report)
+	}
 
 	if err != nil {
 		return err
 	}
 
+	reverseCharge.VatReportId = report.Id
+	return h.Service.vatReportReverseChargeRepository.Upsert(ctx, reverseCharge)
+}
+
+// calcVatReportAmounts sets amounts of the VAT report for the period from orders and returns the summary
+// of zero-rated sales under the reverse charge, which are excluded from the report.
+func (h *vatReportProcessor) calcVatReportAmounts(
+	ctx context.Context,
+	report *billingpb.VatReport,
+	from, to time.Time,
+) (*intPkg.VatReportReverseCharge, error) {
+	report.TransactionsCount = 0
+	report.GrossRevenue = 0
+	report.VatAmount = 0
+	report.FeesAmount = 0
+	report.DeductionAmount = 0
+
+	res, err := h.orderViewRepository.GetVatSummary(ctx, report.OperatingCompanyId, report.Country, false, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
 	if len(res) == 1 {
 		report.TransactionsCount = res[0].Count
 		report.GrossRevenue = tools.FormatAmount(res[0].PaymentGrossRevenueLocal - res[0].PaymentRefundGrossRevenueLocal)
@@ -666,10 +775,10 @@ func (h *vatReportProcessor) processVatReportForPeriod(ctx context.Context, coun
 		report.FeesAmount = res[0].PaymentFeesTotal + res[0].PaymentRefundFeesTotal
 	}
 
-	res, err = h.orderViewRepository.GetVatSummary(h.ctx, operatingCompanyId, country.IsoCodeA2, true, from, to)
+	res, err = h.orderViewRepository.GetVatSummary(ctx, report.OperatingCompanyId, report.Country, true, from, to)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(res) == 1 {
@@ -678,22 +787,63 @@ func (h *vatReportProcessor) processVatReportForPeriod(ctx context.Context, coun
 		report.FeesAmount += res[0].PaymentFeesTotal + res[0].PaymentRefundFeesTotal
 	}
 
-	report.FeesAmount = tools.FormatAmount(report.FeesAmount)
+	reverseCharge, err := h.getVatReportReverseCharge(ctx, report, from, to)
 
-	vr, err := h.vatReportRepository.GetByCountryPeriod(ctx, report.Country, from, to)
-
-	if err == mongo.ErrNoDocuments {
-		return h.Service.vatReportRepository.Insert(ctx, report)
+	if err != nil {
+		return nil, err
 	}
 
-	if err != nil {
-		return err
+	// Zero-rated B2B sales are declared by payers, so they are reported separately
+	report.TransactionsCount -= reverseCharge.TransactionsCount
+	report.GrossRevenue = tools.FormatAmount(report.GrossRevenue - reverseCharge.GrossRevenue)
+	report.FeesAmount = tools.FormatAmount(report.FeesAmount - reverseCharge.FeesAmount)
+
+	return reverseCharge, nil
+}
+
+func (h *vatReportProcessor) getVatReportReverseCharge(
+	ctx context.Context,
+	report *billingpb.VatReport,
+	from, to time.Time,
+) (*intPkg.VatReportReverseCharge, error) {
+	reverseCharge := &intPkg.VatReportReverseCharge{
+		OperatingCompanyId: report.OperatingCompanyId,
+		Country:            report.Country,
+		DateFrom:           from,
+		DateTo:             to,
+		Currency:           report.Currency,
+	}
+
+	for _, isVatDeduction := range []bool{false, true} {
+		res, err := h.orderViewRepository.GetReverseChargeVatSummary(
+			ctx,
+			report.OperatingCompanyId,
+			report.Country,
+			isVatDeduction,
+			from,
+			to,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(res) != 1 {
+			continue
+		}
+
+		reverseCharge.TransactionsCount += res[0].Count
+		reverseCharge.FeesAmount += res[0].PaymentFeesTotal + res[0].PaymentRefundFeesTotal
+
+		if !isVatDeduction {
+			reverseCharge.GrossRevenue = res[0].PaymentGrossRevenueLocal - res[0].PaymentRefundGrossRevenueLocal
+		}
 	}
 
-	report.Id = vr.Id
-	report.CreatedAt = vr.CreatedAt
-	return h.Service.updateVatReport(ctx, report)
+	reverseCharge.GrossRevenue = tools.FormatAmount(reverseCharge.GrossRevenue)
+	reverseCharge.FeesAmount = tools.FormatAmount(reverseCharge.FeesAmount)
 
+	return reverseCharge, nil
 }
 
 func (h *vatReportProcessor) processAccountingEntriesForPeriod(ctx context.Context, country *billingpb.Country) error {
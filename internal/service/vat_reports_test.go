@@ -12,6 +12,7 @@ import (
 	"github.com/paysuper/paysuper-billing-server/internal/database"
 	"github.com/paysuper/paysuper-billing-server/internal/mocks"
 	"github.com/paysuper/paysuper-billing-server/internal/payment_system"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	casbinMocks "github.com/paysuper/paysuper-proto/go/casbinpb/mocks"
@@ -506,3 +507,82 @@ func (suite *VatReportsTestSuite) TestVatReports_ProcessVatReports_OnlyTestOrder
 
 	assert.NoError(suite.T(), err)
 }
+
+func (suite *VatReportsTestSuite) TestVatReports_ProcessVatReports_ReverseChargeReportedSeparately() {
+	numberOfOrders := 10
+
+	suite.projectFixedAmount.Status = billingpb.ProjectStatusInProduction
+	if err := suite.service.project.Update(context.TODO(), suite.projectFixedAmount); err != nil {
+		suite.FailNow("Update project test data failed", "%v", err)
+	}
+
+	for i := 0; i < numberOfOrders; i++ {
+		order := HelperCreateAndPayOrder(
+			suite.Suite,
+			suite.service,
+			10,
+			"USD",
+			"FI",
+			suite.projectFixedAmount,
+			suite.paymentMethod,
+			suite.cookie,
+		)
+		assert.NotNil(suite.T(), order)
+
+		if i%3 != 0 {
+			continue
+		}
+
+		err := suite.service.orderVatIdRepository.Upsert(context.TODO(), &intPkg.OrderVatId{
+			OrderId:            order.Id,
+			OrderUuid:          order.Uuid,
+			OperatingCompanyId: order.OperatingCompanyId,
+			Country:            "FI",
+			VatId:              "FI12345678",
+			Valid:              true,
+			ReverseCharge:      true,
+		})
+		assert.NoError(suite.T(), err)
+	}
+
+	req := &billingpb.ProcessVatReportsRequest{
+		Date: ptypes.TimestampNow(),
+	}
+	err := suite.service.ProcessVatReports(context.TODO(), req, &billingpb.EmptyResponse{})
+	assert.NoError(suite.T(), err)
+
+	repRes := billingpb.VatReportsResponse{}
+	err = suite.service.GetVatReportsForCountry(context.TODO(), &billingpb.VatReportsRequest{Country: "FI"}, &repRes)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, repRes.Status)
+	assert.Equal(suite.T(), int32(1), repRes.Data.Count)
+
+	report := repRes.Data.Items[0]
+	assert.EqualValues(suite.T(), 6, report.TransactionsCount)
+
+	rsp := &intPkg.VatReportReverseChargeResponse{}
+	err = suite.service.GetVatReportReverseCharge(
+		context.TODO(),
+		&intPkg.GetVatReportReverseChargeRequest{VatReportId: report.Id},
+		rsp,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), report.Id, rsp.Item.VatReportId)
+	assert.Equal(suite.T(), "FI", rsp.Item.Country)
+	assert.EqualValues(suite.T(), 4, rsp.Item.TransactionsCount)
+	assert.True(suite.T(), rsp.Item.GrossRevenue > 0)
+	assert.InDelta(suite.T(), report.GrossRevenue/6, rsp.Item.GrossRevenue/4, 0.01)
+}
+
+func (suite *VatReportsTestSuite) TestVatReports_GetVatReportReverseCharge_NotFound_Error() {
+	rsp := &intPkg.VatReportReverseChargeResponse{}
+	err := suite.service.GetVatReportReverseCharge(
+		context.TODO(),
+		&intPkg.GetVatReportReverseChargeRequest{VatReportId: primitive.NewObjectID().Hex()},
+		rsp,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), errorVatReportNotFound, rsp.Message)
+}
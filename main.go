@@ -14,7 +14,14 @@ func main() {
 	task := app.CliArgs.Get("task").String("")
 	date := app.CliArgs.Get("date").String("")
 	orderId := app.CliArgs.Get("orderid").String("")
+	format := app.CliArgs.Get("format").String("")
+	merchantId := app.CliArgs.Get("merchantid").String("")
+	operatingCompanyId := app.CliArgs.Get("operatingcompanyid").String("")
+	dateFrom := app.CliArgs.Get("datefrom").String("")
+	dateTo := app.CliArgs.Get("dateto").String("")
 	force := strings.ToLower(app.CliArgs.Get("force").String("")) == "true"
+	dryRun := strings.ToLower(app.CliArgs.Get("dryrun").String("")) == "true"
+	apply := strings.ToLower(app.CliArgs.Get("apply").String("")) == "true"
 
 	if task != "" {
 
@@ -53,7 +60,11 @@ func main() {
 			break
 
 		case "rebuild_accounting_entries":
-			err = app.TaskRebuildAccountingEntries(orderId, force)
+			if dryRun || apply {
+				err = app.TaskRebuildAccountingEntriesDiff(orderId, merchantId, dateFrom, dateTo, apply)
+			} else {
+				err = app.TaskRebuildAccountingEntries(orderId, force)
+			}
 			break
 
 		case "fix_reports_dates":
@@ -67,6 +78,38 @@ func main() {
 		case "extend_royalties_with_vat":
 			err = app.TaskExtendRoyaltiesWithVat()
 			break
+
+		case "subscriptions_lifecycle":
+			err = app.TaskProcessSubscriptionsLifecycle()
+			break
+
+		case "expiring_cards":
+			err = app.TaskProcessExpiringCards()
+			break
+
+		case "release_rolling_reserves":
+			err = app.TaskReleaseRollingReserves()
+			break
+
+		case "export_ledger":
+			err = app.TaskExportLedger(date, operatingCompanyId, dateFrom, dateTo, format)
+			break
+
+		case "fx_revaluation":
+			err = app.TaskFxRevaluation(date)
+			break
+
+		case "oss_returns":
+			err = app.TaskGenerateOssReturns(date)
+			break
+
+		case "sales_tax_nexus":
+			err = app.TaskTrackSalesTaxNexus(date)
+			break
+
+		case "billing_documents":
+			err = app.TaskGenerateBillingDocuments(date)
+			break
 		}
 
 		if err != nil {
@@ -155,6 +155,14 @@ const (
 	RoyaltyReportChangeSourceMerchant = "merchant"
 	RoyaltyReportChangeSourceAdmin    = "admin"
 
+	RoyaltyReportScheduleTypeWeekly   = "weekly"
+	RoyaltyReportScheduleTypeBiWeekly = "biweekly"
+	RoyaltyReportScheduleTypeMonthly  = "monthly"
+
+	RoyaltyReportDisputeStatusOpen     = "open"
+	RoyaltyReportDisputeStatusResolved = "resolved"
+	RoyaltyReportDisputeStatusRejected = "rejected"
+
 	VatCurrencyRatesPolicyOnDay    = "on-day"
 	VatCurrencyRatesPolicyLastDay  = "last-day"
 	VatCurrencyRatesPolicyAvgMonth = "avg-month"
@@ -167,6 +175,15 @@ const (
 	VatReportStatusOverdue   = "overdue"
 	VatReportStatusCanceled  = "canceled"
 
+	VatReportVersionTypeOriginal  = "original"
+	VatReportVersionTypeAmendment = "amendment"
+
+	VatReportLineTransactionsCount = "transactions_count"
+	VatReportLineGrossRevenue      = "gross_revenue"
+	VatReportLineVatAmount         = "vat_amount"
+	VatReportLineFeesAmount        = "fees_amount"
+	VatReportLineDeductionAmount   = "deduction_amount"
+
 	UndoReasonReversal   = "reversal"
 	UndoReasonChargeback = "chargeback"
 
@@ -192,6 +209,203 @@ const (
 	PayoutDocumentStatusCanceled = "canceled"
 	PayoutDocumentStatusFailed   = "failed"
 
+	PayoutSkipReasonBelowMinimum = "below_minimum_amount"
+
+	MerchantPayoutScheduleFrequencyWeekly     = "weekly"
+	MerchantPayoutScheduleFrequencyMonthly    = "monthly"
+	MerchantPayoutScheduleFrequencyDayOfMonth = "day_of_month"
+
+	PayoutBatchFormatSepaPain001 = "sepa_pain001"
+	PayoutBatchFormatSwiftCsv    = "swift_csv"
+
+	PayoutBatchStatusCreated            = "created"
+	PayoutBatchStatusPartiallyProcessed = "partially_processed"
+	PayoutBatchStatusProcessed          = "processed"
+
+	OssReturnStatusDraft     = "draft"
+	OssReturnStatusSubmitted = "submitted"
+	OssReturnStatusAccepted  = "accepted"
+	OssReturnStatusRejected  = "rejected"
+	OssReturnStatusPaid      = "paid"
+	OssReturnStatusCanceled  = "canceled"
+
+	OssReturnSchemeUnion    = "union"
+	OssReturnSchemeNonUnion = "non_union"
+
+	OssReturnRateTypeStandard = "standard"
+	OssReturnRateTypeReduced  = "reduced"
+
+	SalesTaxNexusStatusBelow       = "below"
+	SalesTaxNexusStatusApproaching = "approaching"
+	SalesTaxNexusStatusCrossed     = "crossed"
+
+	SalesTaxNexusWindowCalendarYear    = "calendar_year"
+	SalesTaxNexusWindowPreviousYear    = "previous_calendar_year"
+	SalesTaxNexusWindowRolling12Months = "rolling_12_months"
+
+	VatIdValidatorStub = "stub"
+	VatIdValidatorVies = "vies"
+
+	OrderPrivateMetadataVatId                   = "VatId"
+	OrderPrivateMetadataVatIdConsultationNumber = "VatIdConsultationNumber"
+	OrderPrivateMetadataTaxProvider             = "TaxProvider"
+	OrderPrivateMetadataTaxRateTableVersion     = "TaxRateTableVersion"
+
+	TaxProviderTaxService   = "tax_service"
+	TaxProviderRateTable    = "rate_table"
+	TaxProviderFallbackNone = "none"
+
+	TaxEvidenceTypeIp             = "ip"
+	TaxEvidenceTypeBillingAddress = "billing_address"
+	TaxEvidenceTypeBin            = "bin"
+	TaxEvidenceTypePhone          = "phone"
+
+	TaxEvidenceRuleBillingAddressConfirmed = "billing_address_confirmed"
+	TaxEvidenceRuleNonConflicting          = "non_conflicting_evidence"
+	TaxEvidenceRuleInsufficient            = "insufficient_evidence"
+
+	BillingDocumentTypeActOfCompletion = "act_of_completion"
+	BillingDocumentTypeInvoice         = "invoice"
+	BillingDocumentTypeCreditNote      = "credit_note"
+	BillingDocumentTypeDebitNote       = "debit_note"
+
+	BillingDocumentVatTreatmentStandard      = "standard"
+	BillingDocumentVatTreatmentReverseCharge = "reverse_charge"
+	BillingDocumentVatTreatmentExempt        = "exempt"
+
+	SubscriptionStatusActive            = "active"
+	SubscriptionStatusPaused            = "paused"
+	SubscriptionStatusCancelAtPeriodEnd = "cancel_at_period_end"
+	SubscriptionStatusCanceled          = "canceled"
+
+	SubscriptionChangeSourceCustomer = "customer"
+	SubscriptionChangeSourceMerchant = "merchant"
+	SubscriptionChangeSourceSystem   = "system"
+
+	SubscriptionMaxPauseCycles = int32(12)
+
+	// Headers of the message sent to the payment notification topic when the state of the subscription changes.
+	SubscriptionNotifyHeaderSubscriptionId = "x-subscription-id"
+	SubscriptionNotifyHeaderStatus         = "x-subscription-status"
+	SubscriptionNotifyHeaderCancelAt       = "x-subscription-cancel-at"
+	SubscriptionNotifyHeaderResumeAt       = "x-subscription-resume-at"
+
+	CardExpiryNotifyDays = 30
+
+	// PaymentCreateFieldInitiator is a payment requisite which marks payments created by the merchant's
+	// server-to-server request against the saved card. Value is one of PaymentInitiator* constants.
+	PaymentCreateFieldInitiator            = "initiator"
+	PaymentCreateFieldInitialTransactionId = "initial_transaction_id"
+
+	PaymentInitiatorCustomer = "cit"
+	PaymentInitiatorMerchant = "mit"
+
+	SavedCardChargeStatusPending   = "pending"
+	SavedCardChargeStatusProcessed = "processed"
+	SavedCardChargeStatusFailed    = "failed"
+
+	SavedCardChargeLimitPeriodDay   = "day"
+	SavedCardChargeLimitPeriodMonth = "month"
+
+	RefundReasonFraud           = "fraud"
+	RefundReasonDuplicate       = "duplicate"
+	RefundReasonNotDelivered    = "not_delivered"
+	RefundReasonKeyInvalid      = "key_invalid"
+	RefundReasonCustomerRequest = "customer_request"
+	RefundReasonChargeback      = "chargeback"
+
+	RefundAnalyticsGroupByProject       = "project"
+	RefundAnalyticsGroupByProduct       = "product"
+	RefundAnalyticsGroupByPaymentMethod = "payment_method"
+	RefundAnalyticsGroupByCountry       = "country"
+	RefundAnalyticsGroupByReason        = "reason"
+
+	RefundAnalyticsIntervalDay   = "day"
+	RefundAnalyticsIntervalWeek  = "week"
+	RefundAnalyticsIntervalMonth = "month"
+
+	LedgerAccountTypeAsset     = "asset"
+	LedgerAccountTypeLiability = "liability"
+	LedgerAccountTypeEquity    = "equity"
+	LedgerAccountTypeRevenue   = "revenue"
+	LedgerAccountTypeExpense   = "expense"
+
+	LedgerAccountAcquirerReceivable = "1100"
+	LedgerAccountMerchantPayable    = "2100"
+	LedgerAccountTaxPayable         = "2200"
+	LedgerAccountRollingReserve     = "2300"
+	LedgerAccountFxRevaluation      = "2400"
+	LedgerAccountFeeRevenue         = "4100"
+	LedgerAccountFxRevenue          = "4200"
+	LedgerAccountFxUnrealized       = "4400"
+	LedgerAccountFxRealized         = "4500"
+	LedgerAccountPaymentMethodCosts = "5100"
+	LedgerAccountRefundCosts        = "5200"
+	LedgerAccountCorrections        = "5300"
+
+	LedgerExportFormatCsv   = "csv"
+	LedgerExportFormatSafT  = "saf-t"
+	LedgerExportFormatDatev = "datev"
+
+	LedgerExportReconciliationRoyaltyGrossRevenue    = "royalty_gross_revenue"
+	LedgerExportReconciliationRoyaltyFees            = "royalty_fees"
+	LedgerExportReconciliationRoyaltyPayout          = "royalty_payout"
+	LedgerExportReconciliationRoyaltyCorrections     = "royalty_corrections"
+	LedgerExportReconciliationRoyaltyRollingReserves = "royalty_rolling_reserves"
+	LedgerExportReconciliationVat                    = "vat"
+
+	ReportTypeLedgerExport         = "ledger_export"
+	ReportTypeOssReturn            = "oss_return"
+	ReportTypeVatReportVersion     = "vat_report_version"
+	ReportTypeVatReportTaxEvidence = "vat_report_tax_evidence"
+	ReportTypeBillingDocument      = "billing_document"
+
+	FxRevaluationTypeUnrealized = "unrealized"
+	FxRevaluationTypeRealized   = "realized"
+
+	FxPositionTypeMerchantBalance = "merchant_balance"
+	FxPositionTypeRollingReserve  = "rolling_reserve"
+
+	AccountingRebuildActionAdded   = "added"
+	AccountingRebuildActionChanged = "changed"
+	AccountingRebuildActionRemoved = "removed"
+
+	MerchantBalanceTransactionTypePaymentNet     = "payment_net"
+	MerchantBalanceTransactionTypeRefund         = "refund"
+	MerchantBalanceTransactionTypeChargeback     = "chargeback"
+	MerchantBalanceTransactionTypeCorrection     = "correction"
+	MerchantBalanceTransactionTypeReserveHold    = "reserve_hold"
+	MerchantBalanceTransactionTypeReserveRelease = "reserve_release"
+	MerchantBalanceTransactionTypePayout         = "payout"
+	MerchantBalanceTransactionTypePayoutReversal = "payout_reversal"
+
+	RollingReservePolicyTypePercentage   = "percentage"
+	RollingReservePolicyTypeFixedDeposit = "fixed_deposit"
+
+	RollingReserveHoldStatusHeld     = "held"
+	RollingReserveHoldStatusReleased = "released"
+
+	AccountingCorrectionStatusDraft    = "draft"
+	AccountingCorrectionStatusPosted   = "posted"
+	AccountingCorrectionStatusRejected = "rejected"
+	AccountingCorrectionStatusReversed = "reversed"
+
+	AccountingCorrectionActionCreate  = "create"
+	AccountingCorrectionActionApprove = "approve"
+	AccountingCorrectionActionReject  = "reject"
+	AccountingCorrectionActionReverse = "reverse"
+
+	AccountingCorrectionReasonFeeAdjustment  = "fee_adjustment"
+	AccountingCorrectionReasonTaxAdjustment  = "tax_adjustment"
+	AccountingCorrectionReasonChargeback     = "chargeback"
+	AccountingCorrectionReasonRollingReserve = "rolling_reserve"
+	AccountingCorrectionReasonGoodwill       = "goodwill"
+	AccountingCorrectionReasonOther          = "other"
+	AccountingCorrectionReasonBillingNote    = "billing_note"
+
+	// AccountingCorrectionReasonCommentSeparator separates reason code and comment in the reason of correction entry.
+	AccountingCorrectionReasonCommentSeparator = ":"
+
 	OrderIssuerReferenceTypePaylink = "paylink"
 
 	PaylinkUrlDefaultMask = "/paylink/%s"
@@ -245,6 +459,7 @@ const (
 	MerchantKycListUrl         = "%s/settings/company?expandedItem=documents"
 	AdminKycListUrl            = "%s/merchants/%s/company-documents"
 	UploadProductKeysUrl       = "%s/projects/%s/game-keys/%s"
+	CardUpdateUrl              = "%s/pay/card/update/%s"
 
 	OrderType_simple         = "simple"
 	OrderType_key            = "key"
@@ -282,6 +497,23 @@ var (
 
 	SupportedMccCodes = []string{billingpb.MccCodeLowRisk, billingpb.MccCodeHighRisk}
 
+	// RefundReasons is the catalog of refund reason codes with keys of their localized labels in i18n messages.
+	RefundReasons = map[string]string{
+		RefundReasonFraud:           "REFUND_REASON_FRAUD",
+		RefundReasonDuplicate:       "REFUND_REASON_DUPLICATE",
+		RefundReasonNotDelivered:    "REFUND_REASON_NOT_DELIVERED",
+		RefundReasonKeyInvalid:      "REFUND_REASON_KEY_INVALID",
+		RefundReasonCustomerRequest: "REFUND_REASON_CUSTOMER_REQUEST",
+		RefundReasonChargeback:      "REFUND_REASON_CHARGEBACK",
+	}
+
+	// EuMemberStates is the list of member states of the European Union where VAT of supplies to consumers
+	// is declared by the One-Stop-Shop return.
+	EuMemberStates = []string{
+		"AT", "BE", "BG", "CY", "CZ", "DE", "DK", "EE", "ES", "FI", "FR", "GR", "HR", "HU",
+		"IE", "IT", "LT", "LU", "LV", "MT", "NL", "PL", "PT", "RO", "SE", "SI", "SK",
+	}
+
 	SupportedTariffRegions = []string{
 		billingpb.TariffRegionRussiaAndCis,
 		billingpb.TariffRegionEurope,